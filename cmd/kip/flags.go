@@ -16,12 +16,19 @@ limitations under the License.
 
 package main
 
-import "github.com/spf13/pflag"
+import (
+	"github.com/elotl/kip/pkg/server"
+	"github.com/spf13/pflag"
+)
 
 type ServerConfig struct {
-	DebugServer        bool
-	NetworkAgentSecret string
-	ClusterDNS         string
+	DebugServer         bool
+	NetworkAgentSecret  string
+	ClusterDNS          string
+	DefaultInstanceType string
+	DefaultVolumeSize   string
+	ItzoVersion         string
+	ItzoURL             string
 }
 
 func (c *ServerConfig) FlagSet() *pflag.FlagSet {
@@ -29,5 +36,37 @@ func (c *ServerConfig) FlagSet() *pflag.FlagSet {
 	flags.BoolVar(&c.DebugServer, "debug-server", c.DebugServer, "Enable a listener in the server for inspecting internal kip structures.")
 	flags.StringVar(&c.NetworkAgentSecret, "network-agent-secret", c.NetworkAgentSecret, "Service account secret for the cell network agent, in the form of <namespace>/<name>")
 	flags.StringVar(&c.ClusterDNS, "cluster-dns", c.ClusterDNS, "Default cluster DNS server to use; if not specified, the kube-system/kube-dns service IP will be used")
+	flags.StringVar(&c.DefaultInstanceType, "default-instance-type", c.DefaultInstanceType, "Default cloud instance type for cells; overrides the provider config file and environment")
+	flags.StringVar(&c.DefaultVolumeSize, "default-volume-size", c.DefaultVolumeSize, "Default boot volume size for cells; overrides the provider config file and environment")
+	flags.StringVar(&c.ItzoVersion, "itzo-version", c.ItzoVersion, "Version of the itzo cell agent to use; overrides the provider config file and environment")
+	flags.StringVar(&c.ItzoURL, "itzo-url", c.ItzoURL, "Base URL for downloading the itzo cell agent; overrides the provider config file and environment")
 	return flags
 }
+
+// ConfigOverrides converts any set flags into server config overrides.
+// Flags are the highest precedence configuration source, applied on
+// top of the provider config file and KIP_* environment variables.
+func (c *ServerConfig) ConfigOverrides() []server.ConfigOverride {
+	overrides := make([]server.ConfigOverride, 0, 4)
+	if c.DefaultInstanceType != "" {
+		overrides = append(overrides, func(cf *server.ServerConfigFile) {
+			cf.Cells.DefaultInstanceType = c.DefaultInstanceType
+		})
+	}
+	if c.DefaultVolumeSize != "" {
+		overrides = append(overrides, func(cf *server.ServerConfigFile) {
+			cf.Cells.DefaultVolumeSize = c.DefaultVolumeSize
+		})
+	}
+	if c.ItzoVersion != "" {
+		overrides = append(overrides, func(cf *server.ServerConfigFile) {
+			cf.Cells.Itzo.Version = c.ItzoVersion
+		})
+	}
+	if c.ItzoURL != "" {
+		overrides = append(overrides, func(cf *server.ServerConfigFile) {
+			cf.Cells.Itzo.URL = c.ItzoURL
+		})
+	}
+	return overrides
+}
@@ -112,6 +112,7 @@ func main() {
 					serverConfig.DebugServer,
 					cfg.ResourceManager,
 					ctx.Done(),
+					serverConfig.ConfigOverrides()...,
 				)
 			}),
 		cli.WithPersistentFlags(traceConfig.FlagSet()),
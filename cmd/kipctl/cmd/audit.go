@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/elotl/kip/pkg/server/audit"
+	"github.com/spf13/cobra"
+)
+
+var auditOutputJSON bool
+
+func auditTrail(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		fatal("Too many arguments\nUsage: kipctl audit [OBJECT-NAME]")
+	}
+	object := ""
+	if len(args) == 1 {
+		object = args[0]
+	}
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	dumpRequest := &clientapi.DumpRequest{
+		Kind: []byte("audit"),
+	}
+	reply, err := client.Dump(context.Background(), dumpRequest)
+	dieIfError(err, "Could not get audit trail")
+	dieIfReplyError("Audit", reply)
+	var entries []audit.Entry
+	err = json.Unmarshal(reply.Body, &entries)
+	dieIfError(err, "Could not parse audit trail")
+	if object != "" {
+		filtered := make([]audit.Entry, 0, len(entries))
+		for _, e := range entries {
+			if e.Object == object {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+	if auditOutputJSON {
+		b, err := json.MarshalIndent(entries, "", "    ")
+		dieIfError(err, "Could not serialize audit trail")
+		fmt.Println(string(b))
+		return
+	}
+	w := kipctl.GetNewTabWriter(os.Stdout)
+	defer w.Flush()
+	fmt.Fprintf(w, "TIME\tCONTROLLER\tOBJECT\tDECISION\tMESSAGE\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format("2006-01-02T15:04:05Z"), e.Controller,
+			e.Object, e.Decision, e.Message)
+	}
+}
+
+func AuditCommand() *cobra.Command {
+	var auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Show recent controller decisions",
+		Long: `Show the trail of recent controller decisions: why pods were
+placed on nodes, why nodes were started or stopped and why launches
+will be retried.  An optional object name restricts the output to
+decisions about that pod or node.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			auditTrail(cmd, args)
+		},
+	}
+	auditCmd.Flags().BoolVar(&auditOutputJSON, "json", false, "Print the audit trail as JSON")
+	return auditCmd
+}
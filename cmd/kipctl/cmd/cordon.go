@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/spf13/cobra"
+)
+
+func cordon(cmd *cobra.Command, cordoned bool) {
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	cordonRequest := &clientapi.CordonRequest{
+		Cordoned: cordoned,
+	}
+	reply, err := client.Cordon(context.Background(), cordonRequest)
+	dieIfError(err, "Could not change cordon state")
+	dieIfReplyError("Cordon", reply)
+	fmt.Println(string(reply.Body))
+}
+
+func CordonCommand() *cobra.Command {
+
+	var cordonCmd = &cobra.Command{
+		Use:   "cordon",
+		Short: "Mark the virtual node as unschedulable",
+		Long: `Mark the virtual node as unschedulable.  New pods will be rejected
+but running pods are left alone.  The state persists across controller
+restarts; use "kipctl uncordon" to accept pods again`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cordon(cmd, true)
+		},
+	}
+
+	return cordonCmd
+}
+
+func UncordonCommand() *cobra.Command {
+
+	var uncordonCmd = &cobra.Command{
+		Use:   "uncordon",
+		Short: "Mark the virtual node as schedulable",
+		Long:  `Mark the virtual node as schedulable again after "kipctl cordon"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cordon(cmd, false)
+		},
+	}
+
+	return uncordonCmd
+}
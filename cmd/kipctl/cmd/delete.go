@@ -17,18 +17,50 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/elotl/kip/pkg/clientapi"
 	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/elotl/kip/pkg/server"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
+func deletePods(cmd *cobra.Command, client clientapi.KipClient) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	all, _ := cmd.Flags().GetBool("all")
+	if namespace == "" && !all {
+		fatal("Deleting pods across all namespaces requires --all")
+	}
+	deletePodsRequest := &clientapi.DeletePodsRequest{
+		Namespace: []byte(namespace),
+		All:       all,
+	}
+	reply, err := client.DeletePods(context.Background(), deletePodsRequest)
+	dieIfError(err, "Could not delete pods")
+	dieIfReplyError("Delete", reply)
+	var result server.PodsDeleteResult
+	err = json.Unmarshal(reply.Body, &result)
+	dieIfError(err, "Could not parse delete results")
+	for _, name := range result.Deleted {
+		fmt.Printf("%s\n", name)
+	}
+	for _, failure := range result.Failed {
+		fmt.Fprintf(os.Stderr, "Failed to delete %s: %s\n",
+			failure.Name, failure.Error)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
 func del(cmd *cobra.Command, args []string) {
 	// see if app manifest file has been supplied
-	if len(args) > 0 && len(args) != 2 {
+	if len(args) > 0 && len(args) != 2 &&
+		!(len(args) == 1 && kipctl.CleanupResourceName(args[0]) == "Pod") {
 		fatal("Usage: kipctl delete <resource> <name>")
 	}
 	cascade, _ := cmd.Flags().GetBool("cascade")
@@ -37,7 +69,9 @@ func del(cmd *cobra.Command, args []string) {
 	dieIfError(err, "Failed to create kip client")
 	defer conn.Close()
 
-	if len(args) == 2 {
+	if len(args) == 1 {
+		deletePods(cmd, client)
+	} else if len(args) == 2 {
 		kind := kipctl.CleanupResourceName(args[0])
 		name := args[1]
 		if !util.StringInSlice(kind, deleteTypes) {
@@ -79,12 +113,20 @@ kipctl delete -f ./pod.yml
 kipctl delete pod mypod
 
 # Delete a deployment named mydeployment and delete all objects managed by that deployment
-kipctl delete --cascade deployment mypod`,
+kipctl delete --cascade deployment mypod
+
+# Delete all pods in the dev namespace
+kipctl delete pods --namespace=dev
+
+# Delete every pod
+kipctl delete pods --all`,
 		Run: func(cmd *cobra.Command, args []string) {
 			del(cmd, args)
 		},
 	}
 	deleteCmd.Flags().BoolP("cascade", "", true, "If true, cascade the deletion of the resources managed by this resource")
 	deleteCmd.Flags().StringP("file", "f", "", "Fully qualified path to manifest file")
+	deleteCmd.Flags().StringP("namespace", "n", "", "Delete all pods in this namespace")
+	deleteCmd.Flags().BoolP("all", "", false, "Delete pods in all namespaces")
 	return deleteCmd
 }
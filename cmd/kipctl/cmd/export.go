@@ -0,0 +1,51 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/spf13/cobra"
+)
+
+func export(cmd *cobra.Command) {
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	reply, err := client.Export(context.Background(), &clientapi.ExportRequest{})
+	dieIfError(err, "Could not export the inventory")
+	dieIfReplyError("Export", reply)
+	fmt.Println(string(reply.Body))
+}
+
+func ExportCommand() *cobra.Command {
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the pod and cell inventory as JSON",
+		Long: `Export a point in time snapshot of every pod and cell this
+controller knows about (instance IDs, types, states and prices) as
+structured JSON for external tooling such as CMDB or cost reporting.
+The schema only grows, fields are never renamed or repurposed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			export(cmd)
+		},
+	}
+	return exportCmd
+}
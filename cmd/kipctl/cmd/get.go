@@ -22,13 +22,20 @@ import (
 	"os"
 
 	"github.com/elotl/kip/pkg/api"
-	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/client"
 	"github.com/elotl/kip/pkg/kipctl"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
+var (
+	getLimit         int
+	getContinue      string
+	getLabelSelector string
+	getFieldSelector string
+)
+
 func get(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		msg := "Not enough arguments\nUsage: kipctl get (pod|node|service|resource) [Name]"
@@ -43,21 +50,31 @@ func get(cmd *cobra.Command, args []string) {
 		fatal("Illegal resource type for GET: %s", kind)
 	}
 
-	client, conn, err := getKipClient(cmd.InheritedFlags(), false)
+	kipClient, conn, err := getKipClient(cmd.InheritedFlags(), false)
 	dieIfError(err, "Failed to create kip client")
 	defer conn.Close()
+	c := client.New(kipClient)
 
-	getRequest := &clientapi.GetRequest{
-		Kind: []byte(kind),
-		Name: []byte(name),
+	var kipObj api.MilpaObject
+	if name == "" {
+		var continueToken string
+		kipObj, continueToken, err = c.List(context.Background(), kind, client.ListOptions{
+			Limit:         getLimit,
+			Continue:      getContinue,
+			LabelSelector: getLabelSelector,
+			FieldSelector: getFieldSelector,
+		})
+		dieIfError(err, "Could not get resource")
+		if continueToken != "" {
+			fmt.Fprintf(os.Stderr,
+				"More items remain, rerun with --continue=%s\n", continueToken)
+		}
+	} else {
+		kipObj, err = c.Get(context.Background(), kind, name)
+		dieIfError(err, "Could not get resource")
 	}
-	reply, err := client.Get(context.Background(), getRequest)
-	dieIfError(err, "Could not get resource")
-	dieIfReplyError("Get", reply)
 	printer, err := kipctl.GetPrinter(cmd)
 	dieIfError(err, "Error getting printer for result")
-	kipObj, err := api.Decode(reply.Body)
-	dieIfError(err, "")
 	err = printer.PrintObj(kipObj, os.Stdout)
 	if err != nil {
 		// Just print the body of the response
@@ -82,5 +99,9 @@ func GetCommand() *cobra.Command {
 	// get it working when we have individual pod status fields in the
 	// PodStatus object
 	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|table.")
+	cmd.Flags().IntVar(&getLimit, "limit", 0, "Maximum number of items to return from a list, 0 means no limit")
+	cmd.Flags().StringVar(&getContinue, "continue", "", "Continue token from a previous paginated list")
+	cmd.Flags().StringVarP(&getLabelSelector, "selector", "l", "", "Label selector to filter on, e.g. app=web")
+	cmd.Flags().StringVar(&getFieldSelector, "field-selector", "", "Field selector to filter on, e.g. status.phase=Running")
 	return cmd
 }
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/elotl/kip/pkg/server/nodemanager"
+	"github.com/spf13/cobra"
+)
+
+var launchesOutputJSON bool
+
+func listLaunches(cmd *cobra.Command) {
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	reply, err := client.GetLaunches(
+		context.Background(), &clientapi.GetLaunchesRequest{})
+	dieIfError(err, "Could not get in-flight launches")
+	dieIfReplyError("Launches", reply)
+	var launches []nodemanager.LaunchStatus
+	err = json.Unmarshal(reply.Body, &launches)
+	dieIfError(err, "Could not parse launches")
+	if launchesOutputJSON {
+		b, err := json.MarshalIndent(launches, "", "    ")
+		dieIfError(err, "Could not serialize launches")
+		fmt.Println(string(b))
+		return
+	}
+	w := kipctl.GetNewTabWriter(os.Stdout)
+	defer w.Flush()
+	fmt.Fprintf(w, "POD\tNODE\tINSTANCE-TYPE\tAZ\tELAPSED\n")
+	for _, l := range launches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			l.Pod, l.Node, l.InstanceType, l.AvailabilityZone, l.Elapsed)
+	}
+}
+
+func cancelLaunch(cmd *cobra.Command, podName string) {
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	cancelRequest := &clientapi.CancelLaunchRequest{
+		PodName: []byte(podName),
+	}
+	reply, err := client.CancelLaunch(context.Background(), cancelRequest)
+	dieIfError(err, "Could not cancel launch")
+	dieIfReplyError("CancelLaunch", reply)
+	fmt.Println(string(reply.Body))
+}
+
+func LaunchesCommand() *cobra.Command {
+	var launchesCmd = &cobra.Command{
+		Use:   "launches",
+		Short: "Show node launches that are currently in flight",
+		Long: `Show node launches that are currently in flight: the pod each
+node is booted for, the instance type, the availability zone and how
+long the launch has been running.  Use "kipctl launches cancel POD-NAME"
+to cancel one during an incident.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			listLaunches(cmd)
+		},
+	}
+	launchesCmd.Flags().BoolVar(&launchesOutputJSON, "json", false, "Print the launches as JSON")
+
+	var cancelCmd = &cobra.Command{
+		Use:   "cancel POD-NAME",
+		Short: "Cancel the in-flight node launch for a pod",
+		Long: `Cancel the in-flight node launch for a pod.  Any partially
+created instance is terminated and the pod goes back to waiting for a
+node.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				fatal("A pod name is required\nUsage: kipctl launches cancel POD-NAME")
+			}
+			cancelLaunch(cmd, args[0])
+		},
+	}
+	launchesCmd.AddCommand(cancelCmd)
+	return launchesCmd
+}
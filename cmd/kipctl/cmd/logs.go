@@ -21,7 +21,7 @@ import (
 	"io"
 	"os"
 
-	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/client"
 	"github.com/elotl/kip/pkg/clientapi"
 	"github.com/elotl/wsstream"
 	"github.com/spf13/cobra"
@@ -31,41 +31,36 @@ import (
 var (
 	logsLines      int
 	logsLimitBytes int
+	logsStartLine  int
+	logsStartByte  int
 	logsFollow     bool
 	logsUnitName   string
 )
 
 func getLogs(cmd *cobra.Command, args []string) {
 	resourceName := args[0]
-	if logsLimitBytes == 0 && logsLines == 0 {
+	if logsLimitBytes == 0 && logsLines == 0 &&
+		logsStartLine == 0 && logsStartByte == 0 {
 		logsLines = 20
 	}
 
-	client, conn, err := getKipClient(cmd.InheritedFlags(), false)
+	kipClient, conn, err := getKipClient(cmd.InheritedFlags(), false)
 	dieIfError(err, "Failed to create kip client")
 	defer conn.Close()
 
 	if logsFollow {
-		tailLogs(client, resourceName, logsUnitName)
+		tailLogs(kipClient, resourceName, logsUnitName)
 	} else {
-		logsRequest := &clientapi.LogsRequest{
-			ResourceName: resourceName,
-			ItemName:     logsUnitName,
-			Lines:        int32(logsLines),
-			Limitbytes:   int32(logsLimitBytes)}
-		reply, err := client.GetLogs(context.Background(), logsRequest)
+		logfile, err := client.New(kipClient).GetLogsRange(
+			context.Background(),
+			resourceName,
+			logsUnitName,
+			logsStartLine,
+			logsLines,
+			logsStartByte,
+			logsLimitBytes,
+		)
 		dieIfError(err, "Could not get %s logs", resourceName)
-		dieIfReplyError("Logs", reply)
-		obj, err := api.Decode(reply.Body)
-		dieIfError(err, "")
-
-		logfile, ok := obj.(*api.LogFile)
-		if !ok {
-			fatal(
-				"Got back unknown object type.\nObject:\n%#v",
-				string(reply.Body),
-			)
-		}
 		fmt.Printf("Resource %s (item %s) logs:\n%s",
 			resourceName, logsUnitName, string(logfile.Content))
 	}
@@ -120,5 +115,7 @@ Kip will save the tail logs of deleted resources and allow them to be queried fo
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow logs")
 	logsCmd.Flags().IntVar(&logsLines, "lines", 0, "Number of lines to retrieve")
 	logsCmd.Flags().IntVar(&logsLimitBytes, "limit-bytes", 0, "Limit length of logs")
+	logsCmd.Flags().IntVar(&logsStartLine, "start-line", 0, "Zero-based line to start from; with --lines this pages through a log instead of tailing it")
+	logsCmd.Flags().IntVar(&logsStartByte, "start-byte", 0, "Zero-based byte offset to start from; with --limit-bytes this pages through a log instead of tailing it")
 	return logsCmd
 }
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
+	"github.com/elotl/kip/pkg/client"
+	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var migrateInstanceType string
+
+func migrate(cmd *cobra.Command, args []string) {
+	if len(args) != 2 || kipctl.CleanupResourceName(args[0]) != "pod" {
+		fatal("Usage: kipctl migrate pod [NAME] --instance-type=[TYPE]")
+	}
+	name := args[1]
+	if migrateInstanceType == "" {
+		fatal("--instance-type is required")
+	}
+
+	kipClient, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+	c := client.New(kipClient)
+
+	pod, err := c.GetPod(context.Background(), name)
+	dieIfError(err, "Could not get pod %s", name)
+	if api.IsTerminalPodPhase(pod.Status.Phase) {
+		fatal("Pod %s is %s, only live pods can be migrated",
+			name, pod.Status.Phase)
+	}
+	if pod.Spec.InstanceType == migrateInstanceType {
+		fmt.Printf("Pod %s is already on instance type %s\n",
+			name, migrateInstanceType)
+		return
+	}
+	pod.Spec.InstanceType = migrateInstanceType
+	// If the pod came in through kubernetes with an instance-type
+	// annotation, update the annotation as well so a later sync of the
+	// kubernetes pod doesn't revert the migration.
+	if _, ok := pod.Annotations[annotations.PodInstanceType]; ok {
+		pod.Annotations[annotations.PodInstanceType] = migrateInstanceType
+	}
+	_, err = c.UpdatePod(context.Background(), pod)
+	dieIfError(err, "Could not update pod %s", name)
+	fmt.Printf("Pod %s is being migrated to a %s cell\n",
+		name, migrateInstanceType)
+}
+
+func MigrateCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Move a pod to a different instance type",
+		Long: `Move a pod to a different instance type without recreating it.  A
+new cell of the requested type is launched alongside the pod's current
+cell, the pod's units are started there and, once they are ready, the
+old cell is drained and terminated.  Pods that own data volumes pause
+briefly during the cutover since a volume can only be attached to one
+instance at a time`,
+		Run: func(cmd *cobra.Command, args []string) {
+			migrate(cmd, args)
+		},
+	}
+	cmd.Flags().StringVar(&migrateInstanceType, "instance-type", "", "Instance type to move the pod onto")
+	return cmd
+}
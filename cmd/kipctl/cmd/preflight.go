@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/elotl/kip/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+func preflight(cmd *cobra.Command) {
+	launchTestInstance, err := cmd.Flags().GetBool("launch-test-instance")
+	dieIfError(err, "Failed to get launch-test-instance flag")
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	preflightRequest := &clientapi.PreflightRequest{
+		LaunchTestInstance: launchTestInstance,
+	}
+	reply, err := client.Preflight(context.Background(), preflightRequest)
+	dieIfError(err, "Could not run preflight checks")
+	dieIfReplyError("Preflight", reply)
+	var result server.PreflightResult
+	err = json.Unmarshal(reply.Body, &result)
+	dieIfError(err, "Could not parse preflight results")
+	w := kipctl.GetNewTabWriter(os.Stdout)
+	fmt.Fprintf(w, "CHECK\tSTATUS\tERROR\n")
+	for _, check := range result.Checks {
+		status := "ok"
+		errMsg := ""
+		if !check.Passed {
+			status = "failed"
+			errMsg = check.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, status, errMsg)
+	}
+	w.Flush()
+	if !result.Passed {
+		os.Exit(1)
+	}
+}
+
+func PreflightCommand() *cobra.Command {
+
+	var preflightCmd = &cobra.Command{
+		Use:   "preflight",
+		Short: "Run preflight checks against the kip server",
+		Long: `Run preflight checks against the kip server: etcd health, cloud
+credentials, boot image and instance type availability.  With
+--launch-test-instance a throwaway instance is launched and terminated
+to prove the credentials end to end.  Exits nonzero if any check fails`,
+		Run: func(cmd *cobra.Command, args []string) {
+			preflight(cmd)
+		},
+	}
+	preflightCmd.Flags().Bool("launch-test-instance", false, "also launch and terminate a throwaway instance (costs a small instance boot)")
+
+	return preflightCmd
+}
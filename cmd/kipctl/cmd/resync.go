@@ -0,0 +1,63 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/spf13/cobra"
+)
+
+func resyncPod(cmd *cobra.Command, podName string) {
+	client, conn, err := getKipClient(cmd.InheritedFlags(), true)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+
+	resyncRequest := &clientapi.ResyncPodRequest{
+		PodName: []byte(podName),
+	}
+	reply, err := client.ResyncPod(context.Background(), resyncRequest)
+	dieIfError(err, "Could not resync pod")
+	dieIfReplyError("ResyncPod", reply)
+	fmt.Println(string(reply.Body))
+}
+
+func ResyncCommand() *cobra.Command {
+	var resyncCmd = &cobra.Command{
+		Use:   "resync",
+		Short: "Force a resource to refresh its status immediately",
+	}
+
+	var podCmd = &cobra.Command{
+		Use:   "pod POD-NAME",
+		Short: "Re-poll a running pod's status from its cell right away",
+		Long: `Query the pod's cell for fresh unit statuses and apply them to the
+registry immediately, bypassing the normal status poll interval, then
+print the refreshed pod.  Useful when a pod's status looks stale or
+right after poking at a cell by hand.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				fatal("A pod name is required\nUsage: kipctl resync pod POD-NAME")
+			}
+			resyncPod(cmd, args[0])
+		},
+	}
+	resyncCmd.AddCommand(podCmd)
+	return resyncCmd
+}
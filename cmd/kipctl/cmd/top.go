@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/client"
+	"github.com/elotl/kip/pkg/kipctl"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var (
+	topSortBy        string
+	topWatch         bool
+	topWatchInterval int
+)
+
+var topSortColumns = []string{"cpu", "memory", "cost"}
+
+type topRow struct {
+	name  string
+	usage api.ResourceMetrics
+}
+
+// sortTopRows orders rows descending by the requested metric, falling
+// back to the name so output is stable when values are missing or
+// tied.
+func sortTopRows(rows []topRow, sortBy string) {
+	sort.Slice(rows, func(i, j int) bool {
+		ui, uj := rows[i].usage[sortBy], rows[j].usage[sortBy]
+		if ui != uj {
+			return ui > uj
+		}
+		return rows[i].name < rows[j].name
+	})
+}
+
+// topPodRows returns one row per pod with current metrics.
+func topPodRows(metrics *api.MetricsList) []topRow {
+	rows := make([]topRow, 0, len(metrics.Items))
+	for _, m := range metrics.Items {
+		rows = append(rows, topRow{name: m.Name, usage: m.ResourceUsage})
+	}
+	return rows
+}
+
+// topNodeRows maps pod metrics onto the nodes running those pods.
+// Cells run a single pod, so a node's usage is its bound pod's usage.
+// Nodes without a bound pod (or without metrics yet) are listed with
+// no usage.
+func topNodeRows(metrics *api.MetricsList, nodes *api.NodeList) []topRow {
+	podUsage := make(map[string]api.ResourceMetrics, len(metrics.Items))
+	for _, m := range metrics.Items {
+		podUsage[m.Name] = m.ResourceUsage
+	}
+	rows := make([]topRow, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		rows = append(rows, topRow{
+			name:  node.Name,
+			usage: podUsage[node.Status.BoundPodName],
+		})
+	}
+	return rows
+}
+
+func printTopRows(rows []topRow) {
+	w := kipctl.GetNewTabWriter(os.Stdout)
+	defer w.Flush()
+	fmt.Fprintf(w, "NAME\tCPU\tMEMORY\tCOST\n")
+	for _, row := range rows {
+		cost := "<none>"
+		if c, exists := row.usage["cost"]; exists {
+			cost = fmt.Sprintf("%.4f", c)
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%s\n",
+			row.name, row.usage["cpu"], row.usage["memory"], cost)
+	}
+}
+
+func top(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		fatal("Not enough arguments\nUsage: kipctl top (nodes|pods)")
+	}
+	kind := kipctl.CleanupResourceName(args[0])
+	if kind != "Node" && kind != "Pod" {
+		fatal("Illegal resource type for TOP: %s", kind)
+	}
+	if !util.StringInSlice(topSortBy, topSortColumns) {
+		fatal("Illegal --sort-by column %s, must be one of cpu|memory|cost", topSortBy)
+	}
+
+	kipClient, conn, err := getKipClient(cmd.InheritedFlags(), false)
+	dieIfError(err, "Failed to create kip client")
+	defer conn.Close()
+	c := client.New(kipClient)
+
+	for {
+		obj, _, err := c.List(context.Background(), "Metric", client.ListOptions{})
+		dieIfError(err, "Could not get metrics")
+		metrics, ok := obj.(*api.MetricsList)
+		if !ok {
+			fatal("Unexpected reply listing metrics: %T", obj)
+		}
+		var rows []topRow
+		if kind == "Node" {
+			nodes, err := c.GetNodes(context.Background())
+			dieIfError(err, "Could not get nodes")
+			rows = topNodeRows(metrics, nodes)
+		} else {
+			rows = topPodRows(metrics)
+		}
+		sortTopRows(rows, topSortBy)
+		printTopRows(rows)
+		if !topWatch {
+			break
+		}
+		time.Sleep(time.Duration(topWatchInterval) * time.Second)
+		fmt.Println()
+	}
+}
+
+func TopCommand() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "top",
+		Short: "Show resource usage of nodes or pods",
+		Long:  `Show current CPU/memory usage (and cost, if available) of nodes or pods, sorted descending`,
+		Run: func(cmd *cobra.Command, args []string) {
+			top(cmd, args)
+		},
+	}
+	cmd.Flags().StringVar(&topSortBy, "sort-by", "cpu", "Column to sort on. One of: cpu|memory|cost")
+	cmd.Flags().BoolVarP(&topWatch, "watch", "w", false, "Keep printing usage every interval")
+	cmd.Flags().IntVar(&topWatchInterval, "interval", 2, "Number of seconds between refreshes with --watch")
+	return cmd
+}
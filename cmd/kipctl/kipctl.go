@@ -60,13 +60,22 @@ func main() {
 	rootCmd.PersistentFlags().StringSliceVar(&endpoints, "endpoints", []string{defaultEndpoint}, "comma separated list of server IP and Port ('ip:port') endpoints to connect to")
 
 	rootCmd.AddCommand(cmd.AttachCommand())
+	rootCmd.AddCommand(cmd.AuditCommand())
+	rootCmd.AddCommand(cmd.CordonCommand())
 	rootCmd.AddCommand(cmd.CreateCommand())
 	rootCmd.AddCommand(cmd.DeleteCommand())
 	rootCmd.AddCommand(cmd.DeployCommand())
 	rootCmd.AddCommand(cmd.DumpCommand())
 	rootCmd.AddCommand(cmd.ExecCommand())
+	rootCmd.AddCommand(cmd.ExportCommand())
 	rootCmd.AddCommand(cmd.GetCommand())
+	rootCmd.AddCommand(cmd.LaunchesCommand())
 	rootCmd.AddCommand(cmd.LogsCommand())
+	rootCmd.AddCommand(cmd.MigrateCommand())
+	rootCmd.AddCommand(cmd.PreflightCommand())
+	rootCmd.AddCommand(cmd.ResyncCommand())
+	rootCmd.AddCommand(cmd.TopCommand())
+	rootCmd.AddCommand(cmd.UncordonCommand())
 	rootCmd.AddCommand(cmd.UpdateCommand())
 	rootCmd.AddCommand(cmd.VersionCommand())
 
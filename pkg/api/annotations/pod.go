@@ -37,6 +37,32 @@ const PodInstanceType = "pod.elotl.co/instance-type"
 // increments, the value will be rounded up to the nearest GiB
 const PodVolumeSize = "pod.elotl.co/volume-size"
 
+// PodVolumeEncrypted is an annotation users can put on their
+// kubernetes pods to control encryption at rest of the root volume of
+// the pod's cell: "true" requires an encrypted volume, "false"
+// explicitly disables encryption. Without the annotation, the
+// cells.volumeEncrypted server setting and then the cloud account's
+// default encryption setting apply.
+const PodVolumeEncrypted = "pod.elotl.co/volume-encrypted"
+
+// PodVolumeKMSKey is an annotation users can put on their kubernetes
+// pods to pick the customer managed key that encrypts the root volume
+// of the pod's cell. The value is a key ID, alias or ARN
+// (e.g. "alias/my-key") and implies volume encryption. Without the
+// annotation, the cells.volumeKMSKey server setting and then the
+// account's default key apply.
+const PodVolumeKMSKey = "pod.elotl.co/volume-kms-key"
+
+// PodDataVolumes is an annotation users can put on their kubernetes
+// pods to tell kip to create and attach additional block volumes to
+// the pod's cell at launch. The value is a JSON list of data volume
+// specs, e.g.
+// [{"name": "scratch", "size": "100Gi", "mountPath": "/scratch"}].
+// Each entry can also specify a provider specific volume "type" and
+// "persistent": true to keep the volume around after the pod is
+// deleted.
+const PodDataVolumes = "pod.elotl.co/data-volumes"
+
 // PodResourcesPrivateIPOnly is an annotation users can put on their
 // kubernetes pods to tell kip to run this pod on a node that only
 // has a private IP address and no public IP address. Setting this
@@ -48,6 +74,70 @@ const PodResourcesPrivateIPOnly = "pod.elotl.co/private-ip-only"
 // to the instance backing their pod.
 const PodSecurityGroups = "pod.elotl.co/security-groups"
 
+// PodRuntimeClass is an annotation users can put on their kubernetes
+// pods to run the pod's units under a sandboxed container runtime
+// (e.g. "gvisor" or "kata") on the cell, assuming the node image
+// supports it. The runtime must be listed in the allowedRuntimeClasses
+// server setting. A runtimeClassName in the kubernetes pod spec is
+// mapped to this as well; the annotation wins if both are set.
+const PodRuntimeClass = "pod.elotl.co/runtime-class"
+
+// PodStopSignal is an annotation users can put on their kubernetes
+// pods to change the signal sent to their containers' processes at
+// shutdown, for applications that expect something other than the
+// default SIGTERM.  The value is a signal name or number
+// (e.g. "SIGINT" or "2") and applies to all of the pod's containers.
+const PodStopSignal = "pod.elotl.co/stop-signal"
+
+// PodSubnetID is an annotation users can put on their kubernetes
+// pods to pin the instance backing their pod to a specific subnet,
+// e.g. for workloads that must use a particular route table. The
+// subnet must belong to the VPC kip is configured to use.
+const PodSubnetID = "pod.elotl.co/subnet-id"
+
+// PodHostnameOnInstance is an annotation users can put on their
+// kubernetes pods to also name the cloud instance backing their pod
+// after the pod's hostname (from spec.hostname/spec.subdomain,
+// defaulting to the pod name), making cells easy to find in the cloud
+// console. Without it, instances get a generic kip name tag.
+const PodHostnameOnInstance = "pod.elotl.co/hostname-on-instance"
+
+// PodTenancy is an annotation users can put on their kubernetes pods
+// to control the tenancy of the instance backing their pod: "default"
+// (shared hardware), "dedicated" (single-tenant hardware) or "host"
+// (a dedicated host). If unset, the tenancy configured in
+// provider.yaml is used. Only supported on AWS; spot pods must use
+// default tenancy.
+const PodTenancy = "pod.elotl.co/tenancy"
+
+// PodDedicatedHostID is an annotation users can put on their
+// kubernetes pods to pin the instance backing their pod to a specific
+// dedicated host (e.g. "h-0123456789abcdef0"). Requires "host"
+// tenancy.
+const PodDedicatedHostID = "pod.elotl.co/dedicated-host-id"
+
+// PodElasticIP is an annotation users can put on their kubernetes
+// pods to associate a pre-allocated elastic IP (AWS) or static IP
+// with the instance backing their pod, for workloads that must
+// present a fixed address to external systems. The value is the
+// public IP address or the provider's allocation ID
+// (e.g. "eipalloc-0123456789abcdef0"). The address must already be
+// allocated and not in use by anything kip doesn't manage; it follows
+// the pod when the pod is rescheduled onto a new instance and is
+// disassociated (but not released) when the pod is terminated.
+const PodElasticIP = "pod.elotl.co/elastic-ip"
+
+// PodEgressRules is an annotation users can put on their kubernetes
+// pods to restrict egress traffic from the instance backing their
+// pod. The value is a JSON list of egress rules, e.g.
+// [{"protocol": "TCP", "port": 443, "cidr": "10.20.0.0/16"}].
+// When set, kip creates an ephemeral security group for the pod
+// allowing only the listed destinations, attaches it at launch and
+// deletes it when the pod is terminated. Only CIDR and port based
+// restrictions are supported, NetworkPolicy pod and namespace
+// selectors are not.
+const PodEgressRules = "pod.elotl.co/egress-rules"
+
 // PodSecurityGroups is an annotation users can put on their
 // kubernetes pods to tell kip to attach an instance profile
 // to the instance backing their pod.
@@ -76,3 +166,117 @@ const PodHealthcheckHealthyTimeout = "pod.elotl.co/healthcheck-healthy-timeout"
 // e.g. "10.20.30.40/24 192.168.1.0/28". Route to these CIDRs, using the
 // instance as the next hop, will be added to the route table of the subnet.
 const PodCloudRoute = "pod.elotl.co/cloud-route"
+
+// PodTraceContext carries the serialized trace span context of the
+// CreatePod call that created the pod.  It is set internally by kip
+// (not by users) so the asynchronous controllers can join their spans
+// to the same trace.
+const PodTraceContext = "pod.elotl.co/trace-context"
+
+// PodOOMScoreAdj is an annotation users can put on their kubernetes
+// pods to adjust the OOM killer score of the pod's containers on the
+// cell. The value is an integer between -999 (very unlikely to be
+// OOM-killed) and 1000 (very likely) and applies to all of the pod's
+// containers. -1000 is reserved for system processes and is rejected.
+const PodOOMScoreAdj = "pod.elotl.co/oom-score-adj"
+
+// PodMemorySwap is an annotation users can put on their kubernetes
+// pods to control how much swap space the pod's containers may use
+// on the cell. The value is a quantity ("1Gi"); "0" disables swap.
+// The pod must have a memory limit so swap usage stays bounded.
+const PodMemorySwap = "pod.elotl.co/memory-swap"
+
+// PodCapacityPreference is an annotation users can put on their
+// kubernetes pods to balance cost and reliability automatically. The
+// only supported value is "spot-then-ondemand": the pod prefers spot
+// (preemptible) instances but falls back to on-demand instances when
+// spot capacity is unavailable or the pod has been interrupted too
+// many times (see cells.maxSpotInterruptions in provider.yaml).
+const PodCapacityPreference = "pod.elotl.co/capacity-preference"
+
+// PodOvercommitRatio is an annotation users can put on their
+// kubernetes pods to override the cells.overcommitRatio setting for
+// one pod. The instance selector divides the pod's CPU and memory
+// requirements by the ratio before matching instance types, so a
+// ratio above 1.0 lets the pod land on a smaller (cheaper) instance
+// than its requests imply. Must be at least 1.0.
+const PodOvercommitRatio = "pod.elotl.co/overcommit-ratio"
+
+// PodShieldedVM is an annotation users can put on their kubernetes
+// pods to launch the pod's cell as a GCE Shielded VM (secure boot,
+// vTPM and integrity monitoring enabled), or with "false" to opt a
+// pod out of a cluster-wide shielded VM default. GCE only.
+const PodShieldedVM = "pod.elotl.co/shielded-vm"
+
+// PodConfidentialVM is an annotation users can put on their
+// kubernetes pods to launch the pod's cell as a GCE Confidential VM
+// with memory encrypted in use. Confidential VMs require an AMD EPYC
+// based instance family (e.g. n2d) and imply a shielded VM. GCE only.
+const PodConfidentialVM = "pod.elotl.co/confidential-vm"
+
+// PodAWSLaunchOverrides is an annotation users can put on their
+// kubernetes pods to pass additional AWS launch settings through to
+// the RunInstances call for the instance backing their pod, without
+// needing a dedicated annotation for every cloud feature. The value
+// is a JSON object; only the following keys are allowed, anything
+// else is rejected when the pod is created:
+// "ebsOptimized" (bool), "monitoring" (bool, detailed monitoring),
+// "creditSpecification" ("standard" or "unlimited"), "keyName"
+// (string) and "capacityReservationId" (string). AWS only.
+const PodAWSLaunchOverrides = "pod.elotl.co/aws-launch-overrides"
+
+// PodDedicatedCPUs is an annotation users can put on their
+// kubernetes pods to request exclusive CPU pinning for
+// latency-sensitive workloads. The value is the number of cores to
+// dedicate to the pod (e.g. "4"); itzo binds the pod's units to that
+// many cores via cpusets and keeps other work on the instance off
+// those cores, like the kubernetes static CPU manager policy for
+// guaranteed pods. The count must fit within the core count of the
+// pod's instance type and the pinned cpuset is reflected in the pod
+// status.
+const PodDedicatedCPUs = "pod.elotl.co/dedicated-cpus"
+
+// PodNetworkInterfaces is an annotation users can put on their
+// kubernetes pods to attach additional network interfaces to the
+// instance backing their pod, e.g. to separate management and data
+// plane traffic. The value is a JSON list of interface specs, e.g.
+// [{"subnetID": "subnet-0123", "securityGroupIDs": ["sg-0123"]}].
+// The interfaces are created and attached at launch, configured by
+// itzo inside the cell and deleted when the cell is terminated. The
+// number of interfaces is limited by the pod's instance type.
+const PodNetworkInterfaces = "pod.elotl.co/network-interfaces"
+
+// PodRetryLimit is an annotation users can put on their kubernetes
+// pods to cap how many times a failed pod is restarted on a fresh
+// cell before it is failed permanently. The value is the number of
+// allowed restarts (e.g. "3"); "0" fails the pod on its first
+// failure. Useful for Job-style workloads where relaunching a broken
+// pod forever only churns instances. Pods with restart policy Never
+// are not restarted regardless of this annotation. Without the
+// annotation the controller's configured retry limit applies.
+const PodRetryLimit = "pod.elotl.co/retry-limit"
+
+// PodUlimits is an annotation users can put on their kubernetes pods
+// to adjust resource limits for the pod's containers on the cell,
+// e.g. for high-connection servers and databases that need more open
+// files or processes than the node image's defaults. The value is a
+// JSON list of limits, e.g.
+// [{"name": "nofile", "soft": 65536, "hard": 65536}]. Values are
+// validated against the instance-level maximums; an invalid
+// annotation is ignored with a warning and the node image's defaults
+// apply. Limits not listed keep the node image's defaults.
+const PodUlimits = "pod.elotl.co/ulimits"
+
+// PodTimezone is an annotation users can put on their kubernetes
+// pods to set the timezone inside the pod's cell. The value must be
+// an IANA tz database name (e.g. "Europe/Berlin"); invalid names are
+// ignored and the pod runs in UTC. Kip sets the TZ environment
+// variable on every unit and deploys the matching /etc/localtime to
+// the cell.
+const PodTimezone = "pod.elotl.co/timezone"
+
+// PodLocale is an annotation users can put on their kubernetes pods
+// to set the locale inside the pod's cell (e.g. "de_DE.UTF-8"). Kip
+// sets the LANG and LC_ALL environment variables on every unit. The
+// locale has to be available in the unit's image to take effect.
+const PodLocale = "pod.elotl.co/locale"
@@ -185,6 +185,35 @@ func ToObjectReference(resource interface{}) ObjectReference {
 	}
 }
 
+// GetPodCondition returns the condition with the given type from the
+// pod status, or nil if it isn't present.
+func GetPodCondition(status *PodStatus, conditionType string) *PodCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetPodCondition sets or replaces a condition in the pod status.
+// LastTransitionTime is only updated when the condition's status
+// changes.
+func SetPodCondition(status *PodStatus, condition PodCondition) {
+	existing := GetPodCondition(status, condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = Now()
+		status.Conditions = append(status.Conditions, condition)
+		return
+	}
+	if existing.Status != condition.Status {
+		condition.LastTransitionTime = Now()
+	} else {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = condition
+}
+
 func IsHostNetwork(securityContext *PodSecurityContext) bool {
 	if securityContext == nil {
 		return false
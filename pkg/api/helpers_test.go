@@ -153,3 +153,48 @@ func TestLabelSelectorAsMap(t *testing.T) {
 		}
 	}
 }
+
+func TestSetPodCondition(t *testing.T) {
+	status := &PodStatus{}
+	SetPodCondition(status, PodCondition{
+		Type:   "elotl.co/traffic-ready",
+		Status: ConditionFalse,
+	})
+	if len(status.Conditions) != 1 {
+		t.Fatalf("expected 1 condition, got %d", len(status.Conditions))
+	}
+	firstTransition := status.Conditions[0].LastTransitionTime
+	if firstTransition.IsZero() {
+		t.Errorf("expected LastTransitionTime to be set")
+	}
+	// same status keeps the transition time
+	SetPodCondition(status, PodCondition{
+		Type:    "elotl.co/traffic-ready",
+		Status:  ConditionFalse,
+		Message: "still waiting",
+	})
+	if len(status.Conditions) != 1 {
+		t.Fatalf("expected condition to be replaced, got %d conditions", len(status.Conditions))
+	}
+	if status.Conditions[0].LastTransitionTime != firstTransition {
+		t.Errorf("expected LastTransitionTime to be unchanged")
+	}
+	if status.Conditions[0].Message != "still waiting" {
+		t.Errorf("expected message to be updated")
+	}
+	// a status change updates the transition time
+	SetPodCondition(status, PodCondition{
+		Type:   "elotl.co/traffic-ready",
+		Status: ConditionTrue,
+	})
+	if !status.Conditions[0].LastTransitionTime.After(firstTransition) &&
+		status.Conditions[0].LastTransitionTime == firstTransition {
+		t.Errorf("expected LastTransitionTime to be updated")
+	}
+	if c := GetPodCondition(status, "elotl.co/traffic-ready"); c == nil || c.Status != ConditionTrue {
+		t.Errorf("expected condition to be true")
+	}
+	if c := GetPodCondition(status, "other"); c != nil {
+		t.Errorf("expected no condition for unknown type")
+	}
+}
@@ -39,6 +39,15 @@ type PodStatusReply struct {
 	PodIP            string          `json:"podIP"`
 }
 
+// BootstrapStatus is itzo's report on the cell's cloud-init
+// bootstrap.  Healthy is true once cloud-init has finished without
+// errors; Error carries the cloud-init error text when it failed.
+// Neither set means the bootstrap is still in progress.
+type BootstrapStatus struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
 type PortForwardParams struct {
 	PodName string
 	Port    string
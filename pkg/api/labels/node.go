@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+// NodeInstanceType holds the cloud instance type backing a cell. We
+// use the well-known kubernetes key so tooling that understands
+// standard node labels works unchanged.
+const NodeInstanceType = "node.kubernetes.io/instance-type"
+
+// NodeZone holds the availability zone the cell was launched in,
+// under the standard kubernetes topology key so
+// topologySpreadConstraints and nodeAffinity against it behave the
+// same as on regular nodes.
+const NodeZone = "topology.kubernetes.io/zone"
+
+// NodeCapacityType records whether the cell runs on spot or on-demand
+// capacity, see CapacityTypeSpot and CapacityTypeOnDemand.
+const NodeCapacityType = "node.elotl.co/capacity-type"
+
+// NodeBootImage holds the cloud image (e.g. AMI ID) the cell was
+// booted from, handy when debugging image rollouts.
+const NodeBootImage = "node.elotl.co/boot-image"
+
+const (
+	// CapacityTypeSpot is the NodeCapacityType value for cells on
+	// spot (preemptible) instances.
+	CapacityTypeSpot = "spot"
+	// CapacityTypeOnDemand is the NodeCapacityType value for cells on
+	// on-demand instances.
+	CapacityTypeOnDemand = "on-demand"
+)
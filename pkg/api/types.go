@@ -116,11 +116,33 @@ type PodSpec struct {
 	// with an exponential back-off delay (10s, 20s, 40s …) capped at
 	// five minutes, the delay is reset after 10 minutes.
 	RestartPolicy RestartPolicy `json:"restartPolicy"`
+	// Optional duration in seconds the Pod may be active relative to
+	// its start time before the system tries to mark it failed and
+	// kill its Units. Matches the kubernetes activeDeadlineSeconds
+	// semantics, a Pod that exceeds its deadline is failed with
+	// reason "DeadlineExceeded" and is not restarted.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// Maximum number of times a failed Pod is restarted on a fresh
+	// cell before it is failed permanently. Only consulted when the
+	// restartPolicy allows restarts. Nil uses the controller's
+	// configured retry limit.
+	RetryLimit *int32 `json:"retryLimit,omitempty"`
+	// Ulimits adjusts resource limits, e.g. open file ("nofile") or
+	// process ("nproc") counts, applied by itzo to all Units on the
+	// Pod's cell. Limits not listed keep the node image's defaults.
+	Ulimits []Ulimit `json:"ulimits,omitempty"`
 	// List of Units that together compose this Pod.
 	Units []Unit `json:"units"`
 	// Init Units. They are run in order, one at a time before regular Units
 	// are started.
 	InitUnits []Unit `json:"initUnits"`
+	// Debug Units added to a running Pod (kubernetes ephemeral
+	// containers, e.g. via kubectl debug). They are started on the
+	// Pod's cell next to the regular Units without restarting the Pod
+	// and don't count towards the Pod's resource requirements or
+	// readiness.
+	// +optional
+	EphemeralUnits []Unit `json:"ephemeralUnits,omitempty"`
 	// List of Secrets that will be used for authenticating when pulling
 	// images.
 	ImagePullSecrets []string `json:"imagePullSecrets,omitemtpy"`
@@ -159,6 +181,139 @@ type PodSpec struct {
 	// +patchMergeKey=ip
 	// +patchStrategy=merge
 	HostAliases []HostAlias `json:"hostAliases,omitempty"`
+	// ServiceAccountName is the name of the Kubernetes service account
+	// whose tokens get projected into serviceAccountToken volumes.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Additional cloud block volumes created and attached to the
+	// Pod's cell when the Pod is dispatched.
+	// +optional
+	DataVolumes []DataVolumeSpec `json:"dataVolumes,omitempty"`
+	// NetworkInterfaces lists extra network interfaces to create and
+	// attach to the Pod's cell at launch, beyond the cell's primary
+	// interface. The interfaces are deleted when the cell is
+	// terminated.
+	// +optional
+	NetworkInterfaces []NetworkInterfaceSpec `json:"networkInterfaces,omitempty"`
+	// LaunchOverrides is a JSON object of provider specific settings
+	// merged into the launch request for the Pod's cell.  Only keys
+	// from the provider's allowlist are accepted; the cloud client
+	// validates the object before the Pod is created.
+	// +optional
+	LaunchOverrides string `json:"launchOverrides,omitempty"`
+	// Optional duration in seconds the Pod's Units have to terminate
+	// gracefully (including running any preStop handlers). Defaults
+	// to 30 seconds if not specified.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Name of the container runtime the Pod's Units run under on the
+	// cell (e.g. "gvisor" or "kata"), assuming the node image
+	// supports it. Empty uses the cell's default runtime.
+	// +optional
+	RuntimeClass string `json:"runtimeClass,omitempty"`
+	// IANA tz database name of the timezone the Pod's Units run in
+	// (e.g. "Europe/Berlin"). The TZ environment variable is set on
+	// every Unit and the matching /etc/localtime is deployed to the
+	// cell. Empty runs the Pod in UTC, the cell's default.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+	// Locale the Pod's Units run with (e.g. "de_DE.UTF-8"), set via
+	// the LANG and LC_ALL environment variables. The locale has to be
+	// available in the Unit's image to take effect.
+	// +optional
+	Locale string `json:"locale,omitempty"`
+	// ReadinessGates lists additional conditions that must be "True"
+	// in Status.Conditions before the Pod is considered Ready. The
+	// conditions themselves are set by external controllers.
+	// +optional
+	ReadinessGates []PodReadinessGate `json:"readinessGates,omitempty"`
+	// TopologySpreadConstraints describes how Pods matching a label
+	// selector should be spread across topology domains, e.g. across
+	// availability zones ("topology.kubernetes.io/zone") or cloud
+	// instance types ("node.kubernetes.io/instance-type").
+	// +optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// PodReadinessGate references a condition in the Pod's status.
+type PodReadinessGate struct {
+	// ConditionType refers to a condition in the Pod's condition list
+	// with matching type.
+	ConditionType string `json:"conditionType"`
+}
+
+// TopologySpreadConstraint specifies how to spread matching Pods
+// across a topology domain, mirroring the kubernetes field of the
+// same name. Spreading replicas across instance types diversifies a
+// spot fleet so one reclaimed capacity pool doesn't take out every
+// replica.
+type TopologySpreadConstraint struct {
+	// MaxSkew is the largest permitted difference between the number
+	// of matching Pods in any two topology domains.
+	MaxSkew int32 `json:"maxSkew"`
+	// TopologyKey is the node label whose values divide cells into
+	// topology domains. "topology.kubernetes.io/zone" and
+	// "node.kubernetes.io/instance-type" are supported.
+	TopologyKey string `json:"topologyKey"`
+	// WhenUnsatisfiable is "DoNotSchedule" (the default) to keep the
+	// Pod waiting when the constraint cannot be satisfied or
+	// "ScheduleAnyway" to place it in the least loaded domain
+	// regardless.
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+	// LabelSelector selects the Pods counted when computing the
+	// spread, typically the labels shared by a deployment's
+	// replicas. Only exact label matches are supported.
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+}
+
+// UnsatisfiableConstraintAction says what to do with a Pod whose
+// topology spread constraint cannot be satisfied.
+type UnsatisfiableConstraintAction string
+
+const (
+	// DoNotSchedule keeps the Pod waiting until the constraint can be
+	// satisfied.
+	DoNotSchedule UnsatisfiableConstraintAction = "DoNotSchedule"
+	// ScheduleAnyway places the Pod in the least loaded domain even
+	// when maxSkew would be exceeded.
+	ScheduleAnyway UnsatisfiableConstraintAction = "ScheduleAnyway"
+)
+
+// DataVolumeSpec requests an extra cloud block volume beyond the root
+// volume. The volume is created and attached to the Pod's cell at
+// dispatch time and itzo mounts the device at MountPath inside the
+// cell. Volumes are deleted when the Pod is deleted unless marked
+// persistent.
+type DataVolumeSpec struct {
+	// Name of the volume, used for tagging the cloud resource.
+	Name string `json:"name"`
+	// Size of the volume, e.g. "100Gi". Cloud providers sell disks in
+	// GiB increments, the value will be rounded up to the nearest GiB.
+	Size string `json:"size"`
+	// Provider specific volume type (e.g. "gp2" on AWS). An empty
+	// value uses the provider's default type.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Path the volume is mounted at inside the cell.
+	MountPath string `json:"mountPath"`
+	// Persistent volumes are left in place when the Pod is deleted.
+	// +optional
+	Persistent bool `json:"persistent,omitempty"`
+}
+
+// NetworkInterfaceSpec requests an extra network interface beyond the
+// cell's primary interface, e.g. to separate management and data
+// plane traffic for network-function workloads. The interface is
+// created and attached to the Pod's cell at launch and itzo
+// configures it inside the cell.
+type NetworkInterfaceSpec struct {
+	// ID of the subnet the interface is created in. Must belong to
+	// the VPC kip is configured to use.
+	SubnetID string `json:"subnetID"`
+	// Security groups attached to the interface. Empty uses the
+	// controller's boot security groups.
+	// +optional
+	SecurityGroupIDs []string `json:"securityGroupIDs,omitempty"`
 }
 
 // HostAlias holds the mapping between IP and hostnames that will be injected as an entry in the
@@ -233,6 +388,9 @@ type PodSecurityContext struct {
 	// List of groups applied to the first process run in the sandbox, in
 	// addition to the pod's primary GID.
 	SupplementalGroups []int64 `json:"supplementalGroups,omitempty"`
+	// A special supplemental group that applies to all containers in
+	// the pod and to any volumes that support ownership management.
+	FSGroup *int64 `json:"fsGroup,omitempty"`
 	// Set these sysctls in the pod.
 	Sysctls []Sysctl `json:"sysctls,omitempty"`
 }
@@ -308,6 +466,10 @@ type VolumeSource struct {
 	HostPath *HostPathVolumeSource `json:"hostPath,omitempty"`
 	// Items for all in one resources secrets, configmaps, and downward API
 	Projected *ProjectedVolumeSource `json:"projected,omitempty"`
+	// DownwardAPI represents downward API about the pod that should
+	// populate this volume
+	// +optional
+	DownwardAPI *DownwardAPIVolumeSource `json:"downwardAPI,omitempty"`
 }
 
 // Represents a host path mapped into a pod.
@@ -476,7 +638,28 @@ type VolumeProjection struct {
 	ConfigMap *ConfigMapProjection `json:"configMap,omitempty"`
 	// information about the serviceAccountToken data to project
 	// +optional
-	//ServiceAccountToken *ServiceAccountTokenProjection `json:"serviceAccountToken,omitempty"`
+	ServiceAccountToken *ServiceAccountTokenProjection `json:"serviceAccountToken,omitempty"`
+}
+
+// ServiceAccountTokenProjection represents a projected service account
+// token volume. This projection can be used to insert a service
+// account token into the pods runtime filesystem for use against APIs
+// (Kubernetes API Server or otherwise).
+type ServiceAccountTokenProjection struct {
+	// Audience is the intended audience of the token. A recipient of a
+	// token must identify itself with an identifier specified in the
+	// audience of the token, and otherwise should reject the token. The
+	// audience defaults to the identifier of the apiserver.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+	// ExpirationSeconds is the requested duration of validity of the
+	// service account token. The token issuer may return a token with a
+	// different validity duration.
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+	// Path is the path relative to the mount point of the file to
+	// project the token into.
+	Path string `json:"path"`
 }
 
 const (
@@ -539,45 +722,65 @@ type ConfigMapProjection struct {
 // 	Items []DownwardAPIVolumeFile `json:"items,omitempty" protobuf:"bytes,1,rep,name=items"`
 // }
 
-// // DownwardAPIVolumeFile represents information to create the file containing the pod field
-// type DownwardAPIVolumeFile struct {
-// 	// Required: Path is  the relative path name of the file to be created. Must not be absolute or contain the '..' path. Must be utf-8 encoded. The first item of the relative path must not start with '..'
-// 	Path string `json:"path" protobuf:"bytes,1,opt,name=path"`
-// 	// Required: Selects a field of the pod: only annotations, labels, name and namespace are supported.
-// 	// +optional
-// 	FieldRef *ObjectFieldSelector `json:"fieldRef,omitempty" protobuf:"bytes,2,opt,name=fieldRef"`
-// 	// Selects a resource of the container: only resources limits and requests
-// 	// (limits.cpu, limits.memory, requests.cpu and requests.memory) are currently supported.
-// 	// +optional
-// 	ResourceFieldRef *ResourceFieldSelector `json:"resourceFieldRef,omitempty" protobuf:"bytes,3,opt,name=resourceFieldRef"`
-// 	// Optional: mode bits to use on this file, must be a value between 0
-// 	// and 0777. If not specified, the volume defaultMode will be used.
-// 	// This might be in conflict with other options that affect the file
-// 	// mode, like fsGroup, and the result can be other mode bits set.
-// 	// +optional
-// 	Mode *int32 `json:"mode,omitempty" protobuf:"varint,4,opt,name=mode"`
-// }
+// DownwardAPIVolumeSource represents a volume containing downward API
+// info. The controller resolves the field references against pod
+// metadata at creation time and deploys the resulting files to the
+// cell as a package.
+type DownwardAPIVolumeSource struct {
+	// Items is a list of downward API volume file
+	// +optional
+	Items []DownwardAPIVolumeFile `json:"items,omitempty"`
+	// Optional: mode bits to use on created files by default. Must be a
+	// value between 0 and 0777. Defaults to 0644.
+	// +optional
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+}
 
-// // ObjectFieldSelector selects an APIVersioned field of an object.
-// type ObjectFieldSelector struct {
-// 	// Version of the schema the FieldPath is written in terms of, defaults to "v1".
-// 	// +optional
-// 	APIVersion string `json:"apiVersion,omitempty" protobuf:"bytes,1,opt,name=apiVersion"`
-// 	// Path of the field to select in the specified API version.
-// 	FieldPath string `json:"fieldPath" protobuf:"bytes,2,opt,name=fieldPath"`
-// }
+// DownwardAPIVolumeFile represents information to create the file containing the pod field
+type DownwardAPIVolumeFile struct {
+	// Required: Path is  the relative path name of the file to be created. Must not be absolute or contain the '..' path. Must be utf-8 encoded. The first item of the relative path must not start with '..'
+	Path string `json:"path" protobuf:"bytes,1,opt,name=path"`
+	// Required: Selects a field of the pod: only annotations, labels, name, namespace and uid are supported.
+	// +optional
+	FieldRef *ObjectFieldSelector `json:"fieldRef,omitempty" protobuf:"bytes,2,opt,name=fieldRef"`
+	// Selects a resource of the container: only resources limits and requests
+	// (limits.cpu, limits.memory, requests.cpu and requests.memory) are currently supported.
+	// +optional
+	ResourceFieldRef *ResourceFieldSelector `json:"resourceFieldRef,omitempty" protobuf:"bytes,3,opt,name=resourceFieldRef"`
+	// Resolved contents for a resourceFieldRef item. The controller
+	// computes this at pod creation; units carry no resource limits of
+	// their own to resolve against later.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Optional: mode bits to use on this file, must be a value between 0
+	// and 0777. If not specified, the volume defaultMode will be used.
+	// This might be in conflict with other options that affect the file
+	// mode, like fsGroup, and the result can be other mode bits set.
+	// +optional
+	Mode *int32 `json:"mode,omitempty" protobuf:"varint,4,opt,name=mode"`
+}
 
-// // ResourceFieldSelector represents container resources (cpu, memory) and their output format
-// type ResourceFieldSelector struct {
-// 	// Container name: required for volumes, optional for env vars
-// 	// +optional
-// 	ContainerName string `json:"containerName,omitempty" protobuf:"bytes,1,opt,name=containerName"`
-// 	// Required: resource to select
-// 	Resource string `json:"resource" protobuf:"bytes,2,opt,name=resource"`
-// 	// Specifies the output format of the exposed resources, defaults to "1"
-// 	// +optional
-// 	Divisor resource.Quantity `json:"divisor,omitempty" protobuf:"bytes,3,opt,name=divisor"`
-// }
+// ObjectFieldSelector selects an APIVersioned field of an object.
+type ObjectFieldSelector struct {
+	// Version of the schema the FieldPath is written in terms of, defaults to "v1".
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty" protobuf:"bytes,1,opt,name=apiVersion"`
+	// Path of the field to select in the specified API version.
+	FieldPath string `json:"fieldPath" protobuf:"bytes,2,opt,name=fieldPath"`
+}
+
+// ResourceFieldSelector represents container resources (cpu, memory) and their output format
+type ResourceFieldSelector struct {
+	// Container name: required for volumes, optional for env vars
+	// +optional
+	ContainerName string `json:"containerName,omitempty" protobuf:"bytes,1,opt,name=containerName"`
+	// Required: resource to select
+	Resource string `json:"resource" protobuf:"bytes,2,opt,name=resource"`
+	// Specifies the output format of the exposed resources as a
+	// quantity, defaults to "1"
+	// +optional
+	Divisor string `json:"divisor,omitempty" protobuf:"bytes,3,opt,name=divisor"`
+}
 
 const (
 	ContainerInstanceType = "ContainerInstance"
@@ -599,6 +802,16 @@ type ResourceSpec struct {
 	// However according to their docs, AWS will bill you in
 	// GB.
 	VolumeSize string `json:"volumeSize,omitempty"`
+	// If VolumeEncrypted is true, the root volume of the Pod's cell
+	// will be encrypted at rest; if false it explicitly will not be.
+	// When unset, the cloud account's default encryption setting
+	// applies.
+	VolumeEncrypted *bool `json:"volumeEncrypted,omitempty"`
+	// VolumeKMSKey is the customer managed key used to encrypt the
+	// root volume of the Pod's cell, given as a key ID, alias or
+	// ARN.  Setting a key implies volumeEncrypted.  Empty uses the
+	// account's default key.
+	VolumeKMSKey string `json:"volumeKMSKey,omitempty"`
 	// Request an instance with dedicated or non-shared CPU. For AWS
 	// T2 instances have a shared CPU, all other instance families
 	// have a dedicated CPU.  Set dedicatedCPU to true if you do
@@ -614,6 +827,29 @@ type ResourceSpec struct {
 	// If ContainerInstance is true, the pod will be run as a cloud
 	// container, in AWS, the pod will be run on Fargate{
 	ContainerInstance *bool `json:"containerInstance,omitempty"`
+	// OvercommitRatio divides the CPU and memory requirements by the
+	// given ratio (e.g. "1.5") before instance selection, allowing
+	// the pod to be placed on a smaller instance than its requests
+	// imply.  Empty or "1.0" sizes the instance to the full request.
+	OvercommitRatio string `json:"overcommitRatio,omitempty"`
+	// If ShieldedVM is true, the Pod's cell is launched as a GCE
+	// Shielded VM with secure boot, vTPM and integrity monitoring
+	// enabled; if false it explicitly is not.  When unset, the
+	// cluster-wide cloud config setting applies.  GCE only.
+	ShieldedVM *bool `json:"shieldedVM,omitempty"`
+	// If ConfidentialVM is true, the Pod's cell is launched as a GCE
+	// Confidential VM with memory encrypted in use.  Confidential VMs
+	// require an AMD EPYC based instance family (e.g. n2d) and imply
+	// a Shielded VM.  When unset, the cluster-wide cloud config
+	// setting applies.  GCE only.
+	ConfidentialVM *bool `json:"confidentialVM,omitempty"`
+	// DedicatedCPUs requests exclusive CPU pinning for the Pod: itzo
+	// binds the Pod's Units to this many dedicated cores via cpusets
+	// and keeps other work on the instance off those cores, like the
+	// kubernetes static CPU manager policy for guaranteed pods.  The
+	// count must fit within the core count of the Pod's instance
+	// type.  Zero disables pinning.
+	DedicatedCPUs int64 `json:"dedicatedCPUs,omitempty"`
 }
 
 // Units run applications. A Pod consists of one or more Units.
@@ -662,6 +898,23 @@ type Unit struct {
 	//initialized. If specified, no other probes are executed until
 	//this completes successfully.
 	StartupProbe *Probe `json:"startupProbe,omitempty"`
+	// Actions that the itzo agent takes in response to Unit lifecycle
+	// events. Cannot be updated.
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+	// StopSignal is the signal the itzo agent sends to the unit's
+	// process to stop it, as a name ("SIGINT") or number ("2").
+	// Empty means SIGTERM.
+	StopSignal string `json:"stopSignal,omitempty"`
+	// OOMScoreAdj adjusts the OOM killer score of the Unit's
+	// processes on the cell, from -999 (very unlikely to be killed)
+	// to 1000 (very likely). -1000 is reserved for system processes
+	// and is rejected. Empty leaves the kernel default.
+	OOMScoreAdj *int32 `json:"oomScoreAdj,omitempty"`
+	// MemorySwap is the amount of swap space the Unit may use,
+	// expressed as a quantity ("1Gi"). "0" disables swap for the
+	// Unit. Requires a memory limit on the Pod so swap usage stays
+	// bounded. Empty leaves swap at the cell image's default.
+	MemorySwap string `json:"memorySwap,omitempty"`
 }
 
 // Optional security context that overrides whatever is set for the pod.
@@ -682,6 +935,8 @@ type SecurityContext struct {
 	RunAsUser *int64 `json:"runAsUser,omitempty"`
 	// Username to run unit processes as.
 	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// Run unit in privileged mode.
+	Privileged *bool `json:"privileged,omitempty"`
 }
 
 // Capability contains the capabilities to add or drop.
@@ -790,6 +1045,20 @@ type Probe struct {
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
 }
 
+// Lifecycle describes actions that the itzo agent takes in response
+// to Unit lifecycle events.
+type Lifecycle struct {
+	// PostStart is called by itzo immediately after a Unit is
+	// started. If the handler fails, the Unit is considered failed and
+	// is restarted according to its restart policy.
+	PostStart *Handler `json:"postStart,omitempty"`
+	// PreStop is called by itzo immediately before a Unit is
+	// terminated. The Unit's termination grace period countdown begins
+	// before the handler is executed, so regardless of the outcome of
+	// the handler the Unit will be terminated within the grace period.
+	PreStop *Handler `json:"preStop,omitempty"`
+}
+
 // VolumeMount specifies what Volumes to attach to the Unit and the path where
 // they will be located inside the Unit.
 type VolumeMount struct {
@@ -839,8 +1108,9 @@ type SecretKeySelector struct {
 type SpotPolicy string
 
 const (
-	SpotAlways SpotPolicy = "Always"
-	SpotNever  SpotPolicy = "Never"
+	SpotAlways    SpotPolicy = "Always"
+	SpotNever     SpotPolicy = "Never"
+	SpotPreferred SpotPolicy = "Preferred"
 )
 
 // PodSpot is the policy that determines if a spot instance may be used for a
@@ -853,6 +1123,18 @@ type PodSpot struct {
 	// Notify string     `json:"notify"`
 }
 
+// Ulimit sets one resource limit on a Pod's Units, e.g. the number
+// of open files ("nofile") or processes ("nproc").
+type Ulimit struct {
+	// Name of the limit, e.g. "nofile", "nproc" or "memlock".
+	Name string `json:"name"`
+	// Soft limit, can be raised up to the hard limit by the workload
+	// itself.
+	Soft int64 `json:"soft"`
+	// Hard limit, the ceiling enforced by the kernel.
+	Hard int64 `json:"hard"`
+}
+
 type NetworkAddressType string
 
 const (
@@ -883,12 +1165,68 @@ type PodStatus struct {
 	Addresses []NetworkAddress `json:"addresses"`
 	// Number of failures encountered while Milpa tried to start a Pod.
 	StartFailures int `json:"startFailures"`
+	// Number of times a spot cell running this Pod was reclaimed by
+	// the cloud.  Pods with a "Preferred" spot policy stop asking for
+	// spot cells after too many interruptions.
+	SpotFailures int `json:"spotFailures,omitempty"`
 	// Shows the status of the Units on the Pod with one entry for
 	// each Unit in the Pod's Spec.
 	UnitStatuses []UnitStatus `json:"unitStatuses"`
 	// Shows the status of the init Units on the Pod with one entry for each
 	// init Unit in the Pod's Spec.
 	InitUnitStatuses []UnitStatus `json:"initUnitStatuses"`
+	// Shows the status of the ephemeral Units on the Pod with one
+	// entry for each ephemeral Unit in the Pod's Spec.
+	EphemeralUnitStatuses []UnitStatus `json:"ephemeralUnitStatuses"`
+	// IDs of the cloud data volumes attached to the Pod's cell, one
+	// entry for each volume in Spec.DataVolumes.
+	DataVolumeIDs []string `json:"dataVolumeIDs,omitempty"`
+	// PinnedCPUs is the cpuset the Pod's Units are bound to
+	// (e.g. "0-3") when Spec.Resources.DedicatedCPUs is set, filled
+	// in when the Pod is dispatched to its cell.
+	PinnedCPUs string `json:"pinnedCPUs,omitempty"`
+	// Labels of the Node running this Pod (instance type, zone,
+	// capacity type, boot image), mirrored here at dispatch for
+	// debugging and topology-aware tooling.
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// FailureReason is a machine readable reason set when the Pod is
+	// failed permanently (e.g. "DeadlineExceeded"). A Pod with a
+	// failure reason is never restarted, regardless of its restart
+	// policy, and the reason is surfaced in the kubernetes pod
+	// status.
+	FailureReason string `json:"failureReason,omitempty"`
+	// UnschedulableReason is set when the Pod has been waiting for a
+	// cell for longer than the configured scheduling timeout.
+	// Surfaced to Kubernetes as a PodScheduled=False condition and
+	// cleared when the Pod is dispatched.
+	UnschedulableReason string `json:"unschedulableReason,omitempty"`
+	// Conditions observed on the Pod, set by external controllers to
+	// satisfy the readiness gates declared in Spec.ReadinessGates.
+	Conditions []PodCondition `json:"conditions,omitempty"`
+}
+
+// ConditionStatus is the status of a condition: "True", "False" or
+// "Unknown".
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// PodCondition is a single condition observed on a Pod.
+type PodCondition struct {
+	// Type of the condition (e.g. "elotl.co/traffic-ready").
+	Type string `json:"type"`
+	// Status of the condition: "True", "False" or "Unknown".
+	Status ConditionStatus `json:"status"`
+	// Time the condition last changed status.
+	LastTransitionTime Time `json:"lastTransitionTime,omitempty"`
+	// Machine readable reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Human readable message about the condition's last transition.
+	Message string `json:"message,omitempty"`
 }
 
 // Phase is the last observed phase of the Pod. Can be "creating",
@@ -957,6 +1295,9 @@ type NodeSpec struct {
 	InstanceType string `json:"instanceType"`
 	// Cloud image that is used for this instance.
 	BootImage string `json:"bootImage"`
+	// Version of the itzo agent this Node was launched with, so
+	// canary rollouts can tell which agent each node runs.
+	ItzoVersion string `json:"itzoVersion,omitempty"`
 	// Indicates that this Node has been requested to be terminated.
 	Terminate bool `json:"terminate,omitempty"`
 	// This is a spot cloud instance.
@@ -969,10 +1310,35 @@ type NodeSpec struct {
 	Resources ResourceSpec `json:"resources,omitempty"`
 	// Placement of the Node in the infrastructure.
 	Placement PlacementSpec `json:"placement,omitempty"`
+	// LaunchOverrides is the JSON object of provider specific launch
+	// settings from the bound Pod's spec, merged into the launch
+	// request for this Node's instance.
+	LaunchOverrides string `json:"launchOverrides,omitempty"`
 }
 
+const (
+	// TenancyDefault runs the cell on shared hardware.
+	TenancyDefault = "default"
+	// TenancyDedicated runs the cell on single-tenant hardware.
+	TenancyDedicated = "dedicated"
+	// TenancyHost runs the cell on a dedicated host, optionally
+	// pinned with DedicatedHostID.
+	TenancyHost = "host"
+)
+
 type PlacementSpec struct {
 	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	// SubnetID pins the Pod's cell to a specific subnet in the
+	// configured VPC. If empty, the cell is launched into the
+	// provider's configured or detected subnet.
+	SubnetID string `json:"subnetID,omitempty"`
+	// Tenancy selects the instance tenancy of the Pod's cell:
+	// "default", "dedicated" or "host". If empty, the provider's
+	// configured tenancy is used. Only supported on AWS.
+	Tenancy string `json:"tenancy,omitempty"`
+	// DedicatedHostID pins the Pod's cell to a specific dedicated
+	// host. Requires "host" tenancy.
+	DedicatedHostID string `json:"dedicatedHostID,omitempty"`
 
 	// Future additions: In addition to explicitly specifying a subnet
 	// we could make it so that users can use a selector to match
@@ -989,6 +1355,19 @@ type NodeStatus struct {
 	Addresses []NetworkAddress `json:"addresses"`
 	// If a Pod is bound to this Node, this is the name of that Pod.
 	BoundPodName string `json:"boundPodName"`
+	// Version of itzo running on this Node as reported by the node
+	// agent once the Node has booted. Can differ from the version in
+	// the Node's spec if a new build was promoted between boots.
+	ItzoVersion string `json:"itzoVersion,omitempty"`
+	// Time the cloud instance backing this Node was launched.
+	LaunchedAt Time `json:"launchedAt,omitempty"`
+	// Time this Node first became available to run Pods. The
+	// difference from LaunchedAt is the Node's time-to-ready.
+	ReadyAt Time `json:"readyAt,omitempty"`
+	// Time of the last successful heartbeat from the node agent.
+	// Persisted at a coarse interval to keep registry writes down, so
+	// it can lag the node's true health by a few minutes.
+	LastHeartbeatAt Time `json:"lastHeartbeatAt,omitempty"`
 }
 
 // NodePhase is the last observed phase of the Node. Can be "creating",
@@ -1180,6 +1559,14 @@ type Event struct {
 
 	// Human readable message about what happened.
 	Message string `json:"message,omitempty"`
+
+	// The number of times this event has occurred within the event
+	// registry's aggregation window.
+	Count int32 `json:"count,omitempty"`
+
+	// The time at which the most recent occurrence of this event was
+	// recorded.
+	LastSeen Time `json:"lastSeen,omitempty"`
 }
 
 // A list of Events.
@@ -1200,8 +1587,25 @@ type LogFile struct {
 	// The content of the logfile. If the logfile is long, this will
 	// likely be the tail of the file.
 	Content string `json:"Content,omitempty"`
+
+	// Zero-based line number in the original logfile where Content
+	// starts, when a line range was applied to produce Content.
+	StartLine int `json:"startLine,omitempty"`
+
+	// Zero-based byte offset in the original logfile where Content
+	// starts, when a byte range was applied to produce Content.
+	StartByte int `json:"startByte,omitempty"`
+
+	// How Content is encoded in the registry: empty for plain text,
+	// "gzip" for base64-encoded gzip data. The registry decodes
+	// Content transparently on read, readers never see this set.
+	ContentEncoding string `json:"contentEncoding,omitempty"`
 }
 
+// LogContentEncodingGzip marks a LogFile whose Content holds
+// base64-encoded gzip data.
+const LogContentEncodingGzip = "gzip"
+
 // A list of logfiles.
 type LogFileList struct {
 	TypeMeta `json:",inline"`
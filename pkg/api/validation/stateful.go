@@ -67,6 +67,19 @@ func (v *StatefulValidator) ValidatePodSpec(spec *api.PodSpec, fldPath *field.Pa
 			}
 		}
 	}
+	if spec.Placement.SubnetID != "" {
+		found := false
+		for _, subnet := range v.cloudStatus.GetAllSubnets() {
+			if subnet.ID == spec.Placement.SubnetID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			msg := fmt.Sprintf("Subnet %s was not found in the configured VPC", spec.Placement.SubnetID)
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("placement.subnetID"), spec.Placement.SubnetID, msg))
+		}
+	}
 	if v.cloudProvider == cloud.ProviderAzure {
 		if spec.Resources.SustainedCPU != nil && *spec.Resources.SustainedCPU {
 			msg := "Azure does not support burstable instances with sustained CPUs"
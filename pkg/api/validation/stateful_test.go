@@ -67,6 +67,27 @@ func TestValidateStatefulPodSpecPlacement(t *testing.T) {
 	}
 }
 
+func TestValidateStatefulPodSpecSubnetID(t *testing.T) {
+	cs, err := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	assert.NoError(t, err)
+	sv := StatefulValidator{cloudStatus: cs}
+	subnetTests := []struct {
+		subnetID string
+		numErrs  int
+	}{
+		{"", 0},
+		{"sub-1111", 0},
+		{"sub-2222", 0},
+		{"sub-other-vpc", 1},
+	}
+	for i, testCase := range subnetTests {
+		ps := api.PodSpec{}
+		ps.Placement.SubnetID = testCase.subnetID
+		errs := sv.ValidatePodSpec(&ps, field.NewPath("field"))
+		assert.Len(t, errs, testCase.numErrs, "test %d failed", i)
+	}
+}
+
 func TestValidateStatefulPodSpecSustainedCPU(t *testing.T) {
 	sv := StatefulValidator{}
 	truth := true
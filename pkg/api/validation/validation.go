@@ -327,6 +327,10 @@ func ValidateInstanceType(instanceType string, fldPath *field.Path) field.ErrorL
 		msg := "The instance type you have selected is not supported by Milpa at this time"
 		allErrs = append(allErrs, field.Invalid(fldPath, instanceType, msg))
 	}
+	if !instanceselector.IsPermittedInstance(instanceType) {
+		msg := "The instance type you have selected is excluded by the operator's instance type allow/block lists"
+		allErrs = append(allErrs, field.Invalid(fldPath, instanceType, msg))
+	}
 	return allErrs
 }
 
@@ -347,6 +351,28 @@ func ValidatePodSpec(spec *api.PodSpec, fldPath *field.Path) field.ErrorList {
 	allErrs = append(allErrs, validatePodSpot(spec.Spot, fldPath.Child("spot"))...)
 	allErrs = append(allErrs, ValidateInstanceType(spec.InstanceType, fldPath.Child("instanceType"))...)
 	allErrs = append(allErrs, validatePodSecurityContext(spec.SecurityContext, fldPath.Child("SecurityContext"))...)
+	allErrs = append(allErrs, validateMemorySwapRequiresLimit(spec, fldPath)...)
+	return allErrs
+}
+
+// Swap without a memory limit on the pod is unbounded, the unit could
+// dirty the cell's whole disk before the OOM killer steps in.  Reject
+// the combination instead of letting the cell thrash.
+func validateMemorySwapRequiresLimit(spec *api.PodSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.Resources.Memory != "" {
+		return allErrs
+	}
+	for i, unit := range spec.Units {
+		if unit.MemorySwap != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("units").Index(i).Child("memorySwap"), unit.MemorySwap, "memorySwap requires a memory limit in spec.resources"))
+		}
+	}
+	for i, unit := range spec.InitUnits {
+		if unit.MemorySwap != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("initUnits").Index(i).Child("memorySwap"), unit.MemorySwap, "memorySwap requires a memory limit in spec.resources"))
+		}
+	}
 	return allErrs
 }
 
@@ -371,6 +397,7 @@ func validateUnits(units []api.Unit, volumes sets.String, fldPath *field.Path) f
 
 	// in milpa, you can have 0 units and it's all good
 	allNames := sets.String{}
+	allPortNames := sets.String{}
 	for i, unit := range units {
 		idxPath := fldPath.Index(i)
 		namePath := idxPath.Child("name")
@@ -393,7 +420,10 @@ func validateUnits(units []api.Unit, volumes sets.String, fldPath *field.Path) f
 			allErrs = append(allErrs, field.Invalid(idxPath.Child("image"), unit.Image, msg))
 		}
 		allErrs = append(allErrs, validateEnv(unit.Env, idxPath.Child("env"))...)
+		allErrs = append(allErrs, validateUnitPorts(unit.Ports, allPortNames, idxPath.Child("ports"))...)
 		allErrs = append(allErrs, validateVolumeMounts(unit.VolumeMounts, volumes, idxPath.Child("volumeMounts"))...)
+		allErrs = append(allErrs, validateStopSignal(unit.StopSignal, idxPath.Child("stopSignal"))...)
+		allErrs = append(allErrs, validateUnitMemoryTuning(&unit, idxPath)...)
 		//
 		// todo: validate probes when we get probes
 		//
@@ -401,6 +431,91 @@ func validateUnits(units []api.Unit, volumes sets.String, fldPath *field.Path) f
 	return allErrs
 }
 
+// signalNames are the POSIX signal names a unit's stopSignal may use.
+var signalNames = sets.NewString(
+	"SIGABRT", "SIGALRM", "SIGBUS", "SIGCHLD", "SIGCONT", "SIGFPE",
+	"SIGHUP", "SIGILL", "SIGINT", "SIGIO", "SIGKILL", "SIGPIPE",
+	"SIGPROF", "SIGPWR", "SIGQUIT", "SIGSEGV", "SIGSTKFLT", "SIGSTOP",
+	"SIGSYS", "SIGTERM", "SIGTRAP", "SIGTSTP", "SIGTTIN", "SIGTTOU",
+	"SIGURG", "SIGUSR1", "SIGUSR2", "SIGVTALRM", "SIGWINCH", "SIGXCPU",
+	"SIGXFSZ",
+)
+
+// A stop signal can be a signal name ("SIGINT") or a number ("2"),
+// matching what docker accepts for STOPSIGNAL.  Empty means the
+// default of SIGTERM.
+func validateStopSignal(signal string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if signal == "" {
+		return allErrs
+	}
+	if num, err := strconv.Atoi(signal); err == nil {
+		if num < 1 || num > 64 {
+			allErrs = append(allErrs, field.Invalid(fldPath, signal, "signal number must be between 1 and 64"))
+		}
+	} else if !signalNames.Has(strings.ToUpper(signal)) {
+		allErrs = append(allErrs, field.Invalid(fldPath, signal, "must be a signal name (e.g. SIGINT) or number"))
+	}
+	return allErrs
+}
+
+// The OOM score adjustment and swap settings are passed to itzo
+// verbatim, catch out of range or unparsable values here.  -1000 is
+// rejected since that would make the unit invisible to the OOM
+// killer, a privilege reserved for system processes.
+func validateUnitMemoryTuning(unit *api.Unit, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if unit.OOMScoreAdj != nil {
+		adj := *unit.OOMScoreAdj
+		if adj < -999 || adj > 1000 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("oomScoreAdj"), adj, "must be between -999 and 1000"))
+		}
+	}
+	if unit.MemorySwap != "" {
+		q, err := resource.ParseQuantity(unit.MemorySwap)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("memorySwap"), unit.MemorySwap, "must be a quantity (e.g. 1Gi), 0 disables swap"))
+		} else if q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("memorySwap"), unit.MemorySwap, "must not be negative"))
+		}
+	}
+	return allErrs
+}
+
+// Declared ports are forwarded to itzo as-is and named ports can be
+// referenced by probes and services, so catch bad port numbers,
+// protocols and duplicate names here instead of on the cell.
+func validateUnitPorts(ports []api.ContainerPort, portNames sets.String, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, port := range ports {
+		idxPath := fldPath.Index(i)
+		for _, msg := range validation.IsValidPortNum(int(port.ContainerPort)) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("containerPort"), port.ContainerPort, msg))
+		}
+		if port.HostPort != 0 {
+			for _, msg := range validation.IsValidPortNum(int(port.HostPort)) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("hostPort"), port.HostPort, msg))
+			}
+		}
+		if len(port.Name) > 0 {
+			for _, msg := range validation.IsValidPortName(port.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), port.Name, msg))
+			}
+			if portNames.Has(port.Name) {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), port.Name))
+			} else {
+				portNames.Insert(port.Name)
+			}
+		}
+		switch port.Protocol {
+		case "", api.ProtocolTCP, api.ProtocolUDP, api.ProtocolSCTP:
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("protocol"), port.Protocol, []string{string(api.ProtocolTCP), string(api.ProtocolUDP), string(api.ProtocolSCTP)}))
+		}
+	}
+	return allErrs
+}
+
 func validateInitUnits(units, otherUnits []api.Unit, volumes sets.String, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	if len(units) > 0 {
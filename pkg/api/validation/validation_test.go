@@ -210,6 +210,8 @@ func TestValidateUnits(t *testing.T) {
 		{Name: "abc", Image: "image"},
 		{Name: "123", Image: "image"},
 		{Name: "abc-123", Image: "image"},
+		{Name: "stop-name", Image: "image", StopSignal: "SIGINT"},
+		{Name: "stop-num", Image: "image", StopSignal: "2"},
 	}
 	if errs := validateUnits(successCase, sets.NewString(), field.NewPath("field")); len(errs) != 0 {
 		t.Errorf("expected success: %v", errs)
@@ -224,6 +226,12 @@ func TestValidateUnits(t *testing.T) {
 			{Name: "abc", Image: "image"},
 		},
 		"zero-length image": {{Name: "abc", Image: ""}},
+		"unknown stop signal": {
+			{Name: "abc", Image: "image", StopSignal: "SIGBOGUS"},
+		},
+		"stop signal number out of range": {
+			{Name: "abc", Image: "image", StopSignal: "65"},
+		},
 	}
 
 	for k, v := range errorCases {
@@ -233,6 +241,77 @@ func TestValidateUnits(t *testing.T) {
 	}
 }
 
+func TestValidateUnitPorts(t *testing.T) {
+	successCase := []api.ContainerPort{
+		{ContainerPort: 80},
+		{Name: "http", ContainerPort: 8080, Protocol: api.ProtocolTCP},
+		{Name: "dns", ContainerPort: 53, Protocol: api.ProtocolUDP},
+		{ContainerPort: 9000, HostPort: 9000},
+	}
+	if errs := validateUnitPorts(successCase, sets.NewString(), field.NewPath("field")); len(errs) != 0 {
+		t.Errorf("expected success: %v", errs)
+	}
+
+	errorCases := map[string][]api.ContainerPort{
+		"zero container port":    {{ContainerPort: 0}},
+		"container port too big": {{ContainerPort: 65536}},
+		"negative host port":     {{ContainerPort: 80, HostPort: -1}},
+		"name not an IANA name":  {{Name: "NotValid", ContainerPort: 80}},
+		"unsupported protocol":   {{ContainerPort: 80, Protocol: "ICMP"}},
+		"duplicate port name": {
+			{Name: "http", ContainerPort: 80},
+			{Name: "http", ContainerPort: 8080},
+		},
+	}
+	for k, v := range errorCases {
+		if errs := validateUnitPorts(v, sets.NewString(), field.NewPath("field")); len(errs) == 0 {
+			t.Errorf("expected failure for %s", k)
+		}
+	}
+}
+
+func TestValidateUnitMemoryTuning(t *testing.T) {
+	adj := func(v int32) *int32 { return &v }
+	successCases := []api.Unit{
+		{},
+		{OOMScoreAdj: adj(-999)},
+		{OOMScoreAdj: adj(1000)},
+		{MemorySwap: "1Gi"},
+		{MemorySwap: "0"},
+		{OOMScoreAdj: adj(500), MemorySwap: "512Mi"},
+	}
+	for i, unit := range successCases {
+		if errs := validateUnitMemoryTuning(&unit, field.NewPath("field")); len(errs) != 0 {
+			t.Errorf("expected success for case %d: %v", i, errs)
+		}
+	}
+
+	errorCases := map[string]api.Unit{
+		"oomScoreAdj reserved":     {OOMScoreAdj: adj(-1000)},
+		"oomScoreAdj too big":      {OOMScoreAdj: adj(1001)},
+		"memorySwap not parseable": {MemorySwap: "lots"},
+		"memorySwap negative":      {MemorySwap: "-1Gi"},
+	}
+	for k, unit := range errorCases {
+		if errs := validateUnitMemoryTuning(&unit, field.NewPath("field")); len(errs) == 0 {
+			t.Errorf("expected failure for %s", k)
+		}
+	}
+}
+
+func TestValidateMemorySwapRequiresLimit(t *testing.T) {
+	spec := &api.PodSpec{
+		Units: []api.Unit{{Name: "main", MemorySwap: "1Gi"}},
+	}
+	if errs := validateMemorySwapRequiresLimit(spec, field.NewPath("spec")); len(errs) != 1 {
+		t.Errorf("expected swap without a memory limit to be rejected: %v", errs)
+	}
+	spec.Resources.Memory = "2Gi"
+	if errs := validateMemorySwapRequiresLimit(spec, field.NewPath("spec")); len(errs) != 0 {
+		t.Errorf("expected success: %v", errs)
+	}
+}
+
 func TestValidateInitUnitNames(t *testing.T) {
 	initUnits := []api.Unit{
 		{Name: "duplicate", Image: "image"},
@@ -0,0 +1,47 @@
+package v1beta1
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodToCell converts a Kubernetes pod into a Cell custom resource.
+// The pod spec is carried over whole so containers, resources and
+// volumes survive a round trip; labels and annotations ride along on
+// the cell's metadata and the pod's identity is recorded in the
+// cell's status.
+func PodToCell(pod *v1.Pod) *Cell {
+	cell := &Cell{}
+	cell.Name = pod.Name
+	cell.Labels = copyStringMap(pod.Labels)
+	cell.Annotations = copyStringMap(pod.Annotations)
+	pod.Spec.DeepCopyInto(&cell.Spec.Pod)
+	cell.Status.PodName = pod.Name
+	cell.Status.PodNamespace = pod.Namespace
+	return cell
+}
+
+// CellToPod converts a Cell custom resource back into the Kubernetes
+// pod it describes.
+func CellToPod(cell *Cell) *v1.Pod {
+	pod := &v1.Pod{}
+	pod.Name = cell.Status.PodName
+	if pod.Name == "" {
+		pod.Name = cell.Name
+	}
+	pod.Namespace = cell.Status.PodNamespace
+	pod.Labels = copyStringMap(cell.Labels)
+	pod.Annotations = copyStringMap(cell.Annotations)
+	cell.Spec.Pod.DeepCopyInto(&pod.Spec)
+	return pod
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func fakeK8sPod() *v1.Pod {
+	pod := &v1.Pod{}
+	pod.Name = "my-pod"
+	pod.Namespace = "my-namespace"
+	pod.Labels = map[string]string{"app": "my-app"}
+	pod.Annotations = map[string]string{
+		"pod.elotl.co/instance-type": "t3.nano",
+	}
+	pod.Spec = v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:    "main",
+				Image:   "library/nginx:stable",
+				Command: []string{"nginx"},
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						"cpu":    resource.MustParse("1"),
+						"memory": resource.MustParse("512Mi"),
+					},
+				},
+				VolumeMounts: []v1.VolumeMount{
+					{Name: "scratch", MountPath: "/scratch"},
+				},
+			},
+		},
+		Volumes: []v1.Volume{
+			{
+				Name: "scratch",
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{},
+				},
+			},
+		},
+		RestartPolicy: v1.RestartPolicyAlways,
+	}
+	return pod
+}
+
+func TestPodToCellRoundTrip(t *testing.T) {
+	pod := fakeK8sPod()
+	cell := PodToCell(pod)
+	assert.Equal(t, pod.Name, cell.Name)
+	assert.Equal(t, pod.Name, cell.Status.PodName)
+	assert.Equal(t, pod.Namespace, cell.Status.PodNamespace)
+
+	roundTripped := CellToPod(cell)
+	assert.Equal(t, pod.Name, roundTripped.Name)
+	assert.Equal(t, pod.Namespace, roundTripped.Namespace)
+	assert.Equal(t, pod.Labels, roundTripped.Labels)
+	assert.Equal(t, pod.Annotations, roundTripped.Annotations)
+	assert.Equal(t, pod.Spec, roundTripped.Spec)
+
+	// the conversion must not share the original pod's spec or maps
+	cell.Spec.Pod.Containers[0].Image = "library/nginx:mainline"
+	cell.Annotations["changed"] = "true"
+	assert.Equal(t, "library/nginx:stable", pod.Spec.Containers[0].Image)
+	assert.NotContains(t, pod.Annotations, "changed")
+}
+
+func TestCellToPodFallsBackToCellName(t *testing.T) {
+	cell := &Cell{}
+	cell.Name = "standby-cell"
+	pod := CellToPod(cell)
+	assert.Equal(t, "standby-cell", pod.Name)
+	assert.Equal(t, "", pod.Namespace)
+}
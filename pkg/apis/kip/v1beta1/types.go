@@ -1,6 +1,9 @@
 package v1beta1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // A couple of notes on how to use all of this
 // 1. This relies on k8s.io/code-generator that should be vendored into the repo
@@ -42,6 +45,11 @@ spec:
     openAPIV3Schema:
       type: object
       properties:
+        spec:
+          type: object
+          properties:
+            pod:
+              type: object
         status:
           type: object
           properties:
@@ -105,9 +113,20 @@ type Cell struct {
 	// +optional
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// +optional
+	Spec CellSpec `json:"spec,omitempty"`
+	// +optional
 	Status CellStatus `json:"status,omitempty"`
 }
 
+// CellSpec lets users drive kip through the CRD: the embedded pod
+// spec converts to and from a regular Kubernetes pod (see
+// PodToCell/CellToPod).
+type CellSpec struct {
+	// Pod is the Kubernetes pod spec the cell runs.
+	// +optional
+	Pod v1.PodSpec `json:"pod,omitempty"`
+}
+
 type CellStatus struct {
 	PodName      string `json:"podName,omitempty"`
 	PodNamespace string `json:"podNamespace,omitempty"`
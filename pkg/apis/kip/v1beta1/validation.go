@@ -0,0 +1,58 @@
+package v1beta1
+
+import (
+	"net"
+
+	"github.com/elotl/kip/pkg/util/validation"
+	"github.com/elotl/kip/pkg/util/validation/field"
+)
+
+// Valid values for CellStatus.LaunchType.  Empty means the launch
+// type isn't known yet.
+const (
+	LaunchTypeOnDemand = "On-Demand"
+	LaunchTypeSpot     = "Spot"
+)
+
+// ValidateCell checks a Cell custom resource and returns a list of
+// field-specific errors.  It's exported so a validating admission
+// webhook can reject invalid Cells before they reach the controller.
+func ValidateCell(cell *Cell) field.ErrorList {
+	allErrs := field.ErrorList{}
+	metaPath := field.NewPath("metadata")
+	if cell.Name == "" {
+		allErrs = append(allErrs, field.Required(metaPath.Child("name"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(cell.Name) {
+			allErrs = append(allErrs, field.Invalid(metaPath.Child("name"), cell.Name, msg))
+		}
+	}
+	allErrs = append(allErrs, ValidateCellStatus(&cell.Status, field.NewPath("status"))...)
+	return allErrs
+}
+
+// ValidateCellStatus checks the observed status of a Cell.  The
+// status mirrors values from the internal node and pod model so the
+// same naming rules apply here.
+func ValidateCellStatus(status *CellStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch status.LaunchType {
+	case "", LaunchTypeOnDemand, LaunchTypeSpot:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("launchType"), status.LaunchType, []string{LaunchTypeOnDemand, LaunchTypeSpot}))
+	}
+	if status.PodName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(status.PodName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("podName"), status.PodName, msg))
+		}
+	}
+	if status.PodNamespace != "" {
+		for _, msg := range validation.IsDNS1123Label(status.PodNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("podNamespace"), status.PodNamespace, msg))
+		}
+	}
+	if status.IP != "" && net.ParseIP(status.IP) == nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ip"), status.IP, "must be a valid IP address"))
+	}
+	return allErrs
+}
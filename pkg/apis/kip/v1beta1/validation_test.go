@@ -0,0 +1,57 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCell(t *testing.T) {
+	cell := &Cell{}
+	cell.Name = "7a5ef54b-0fc3-4a5a-a42f-f121e7a335cb"
+	cell.Status = CellStatus{
+		PodName:      "my-pod",
+		PodNamespace: "default",
+		LaunchType:   LaunchTypeOnDemand,
+		InstanceType: "t3.nano",
+		IP:           "10.0.0.12",
+	}
+	errs := ValidateCell(cell)
+	assert.Len(t, errs, 0)
+
+	errorCases := []struct {
+		name string
+		mod  func(c *Cell)
+	}{
+		{
+			name: "missing name",
+			mod:  func(c *Cell) { c.Name = "" },
+		},
+		{
+			name: "invalid name",
+			mod:  func(c *Cell) { c.Name = "Not_A_Subdomain" },
+		},
+		{
+			name: "unknown launch type",
+			mod:  func(c *Cell) { c.Status.LaunchType = "Preemptible" },
+		},
+		{
+			name: "invalid pod name",
+			mod:  func(c *Cell) { c.Status.PodName = "UPPER" },
+		},
+		{
+			name: "invalid pod namespace",
+			mod:  func(c *Cell) { c.Status.PodNamespace = "name.space" },
+		},
+		{
+			name: "invalid ip",
+			mod:  func(c *Cell) { c.Status.IP = "not-an-ip" },
+		},
+	}
+	for _, tc := range errorCases {
+		bad := cell.DeepCopy()
+		tc.mod(bad)
+		errs := ValidateCell(bad)
+		assert.True(t, len(errs) > 0, "expected errors for case %q", tc.name)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client provides a typed Go client for the kip server API.
+// It wraps the grpc clientapi plumbing used by kipctl so external
+// tools can list and fetch kip objects without dealing with raw
+// APIReply messages.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/clientapi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+// Client is a typed wrapper around the kip grpc API.  Create one
+// with New from an existing connection or with Dial.
+type Client struct {
+	api     clientapi.KipClient
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+type Option func(*Client)
+
+// WithTimeout sets the per-call timeout applied when the caller's
+// context has no deadline of its own.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// New wraps an existing grpc client.  The caller remains responsible
+// for closing the underlying connection.
+func New(kipClient clientapi.KipClient, opts ...Option) *Client {
+	c := &Client{
+		api:     kipClient,
+		timeout: defaultRequestTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Dial connects to a kip server and returns a typed client.  Close
+// the client to release the connection.
+func Dial(ctx context.Context, address string, opts ...Option) (*Client, error) {
+	conn, err := grpc.DialContext(
+		ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, err
+	}
+	c := New(clientapi.NewKipClient(conn), opts...)
+	c.conn = conn
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func replyToObject(cmd string, reply *clientapi.APIReply) (api.MilpaObject, error) {
+	if reply.Status < 200 || reply.Status >= 400 {
+		return nil, fmt.Errorf("%s returned %d - %s",
+			cmd, reply.Status, string(reply.Body))
+	}
+	return api.Decode(reply.Body)
+}
+
+// ListOptions narrows and paginates list requests.  A zero Limit
+// returns everything; Continue resumes from a previous page's token.
+type ListOptions struct {
+	Limit         int
+	Continue      string
+	LabelSelector string
+	FieldSelector string
+}
+
+// List fetches a page of objects of the given kind and returns the
+// continue token for the next page ("" when there are no more items).
+func (c *Client) List(ctx context.Context, kind string, opts ListOptions) (api.MilpaObject, string, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	request := &clientapi.GetRequest{
+		Kind:          []byte(kind),
+		Limit:         int32(opts.Limit),
+		Continue:      []byte(opts.Continue),
+		LabelSelector: []byte(opts.LabelSelector),
+		FieldSelector: []byte(opts.FieldSelector),
+	}
+	reply, err := c.api.Get(ctx, request)
+	if err != nil {
+		return nil, "", err
+	}
+	obj, err := replyToObject("Get", reply)
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, string(reply.Continue), nil
+}
+
+// Get fetches a single object, or a list if name is empty.  Kind is
+// a kip resource kind such as "Pod" or "Node".
+func (c *Client) Get(ctx context.Context, kind, name string) (api.MilpaObject, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	request := &clientapi.GetRequest{
+		Kind: []byte(kind),
+		Name: []byte(name),
+	}
+	reply, err := c.api.Get(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return replyToObject("Get", reply)
+}
+
+func (c *Client) GetPod(ctx context.Context, name string) (*api.Pod, error) {
+	obj, err := c.Get(ctx, "Pod", name)
+	if err != nil {
+		return nil, err
+	}
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a pod in reply, got %T", obj)
+	}
+	return pod, nil
+}
+
+// UpdatePod replaces the spec and labels of an existing pod.  Pass a
+// pod fetched with GetPod, modified in place; the server rejects
+// updates to pods in a terminal phase.
+func (c *Client) UpdatePod(ctx context.Context, pod *api.Pod) (*api.Pod, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	manifest, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	request := &clientapi.UpdateRequest{
+		Manifest: manifest,
+	}
+	reply, err := c.api.Update(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := replyToObject("Update", reply)
+	if err != nil {
+		return nil, err
+	}
+	updated, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a pod in reply, got %T", obj)
+	}
+	return updated, nil
+}
+
+func (c *Client) ListPods(ctx context.Context) (*api.PodList, error) {
+	obj, err := c.Get(ctx, "Pod", "")
+	if err != nil {
+		return nil, err
+	}
+	podList, ok := obj.(*api.PodList)
+	if !ok {
+		return nil, fmt.Errorf("expected a pod list in reply, got %T", obj)
+	}
+	return podList, nil
+}
+
+func (c *Client) GetNode(ctx context.Context, name string) (*api.Node, error) {
+	obj, err := c.Get(ctx, "Node", name)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return nil, fmt.Errorf("expected a node in reply, got %T", obj)
+	}
+	return node, nil
+}
+
+func (c *Client) GetNodes(ctx context.Context) (*api.NodeList, error) {
+	obj, err := c.Get(ctx, "Node", "")
+	if err != nil {
+		return nil, err
+	}
+	nodeList, ok := obj.(*api.NodeList)
+	if !ok {
+		return nil, fmt.Errorf("expected a node list in reply, got %T", obj)
+	}
+	return nodeList, nil
+}
+
+// GetLogs fetches logs for a pod's unit (or a node's log item).
+// Lines and limitBytes bound how much of the log tail is returned;
+// zero values leave the limit up to the server.
+func (c *Client) GetLogs(ctx context.Context, resourceName, itemName string, lines, limitBytes int) (*api.LogFile, error) {
+	return c.GetLogsRange(ctx, resourceName, itemName, 0, lines, 0, limitBytes)
+}
+
+// GetLogsRange fetches a specific line or byte range of a log so
+// callers can paginate large logs. StartLine and startByte are
+// zero-based offsets from the start of the log; a range past the end
+// of the log returns whatever is available.
+func (c *Client) GetLogsRange(ctx context.Context, resourceName, itemName string, startLine, lines, startByte, limitBytes int) (*api.LogFile, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	request := &clientapi.LogsRequest{
+		ResourceName: resourceName,
+		ItemName:     itemName,
+		Lines:        int32(lines),
+		Limitbytes:   int32(limitBytes),
+		StartLine:    int32(startLine),
+		StartByte:    int32(startByte),
+	}
+	reply, err := c.api.GetLogs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := replyToObject("Logs", reply)
+	if err != nil {
+		return nil, err
+	}
+	logFile, ok := obj.(*api.LogFile)
+	if !ok {
+		return nil, fmt.Errorf("expected a log file in reply, got %T", obj)
+	}
+	return logFile, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"golang.org/x/net/context"
+)
+
+// Watcher delivers watch events from a Watch stream.  Cancel the
+// context passed to Watch to stop watching.
+type Watcher struct {
+	stream clientapi.Kip_WatchClient
+}
+
+// Recv blocks until the next event arrives or the stream ends.
+func (w *Watcher) Recv() (*clientapi.WatchEvent, error) {
+	msg, err := w.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	event := &clientapi.WatchEvent{}
+	if err := json.Unmarshal(msg.Data, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Watch streams add/update/delete events for objects of the given
+// kind ("Pod" or "Node").  A sinceVersion of 0 starts from now; pass
+// the Version of the last event seen to resume a broken watch.  No
+// call timeout is applied, the stream runs until the context is
+// canceled or the server goes away.
+func (c *Client) Watch(ctx context.Context, kind string, sinceVersion int64) (*Watcher, error) {
+	request := &clientapi.WatchRequest{
+		Kind:         []byte(kind),
+		SinceVersion: sinceVersion,
+	}
+	stream, err := c.api.Watch(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{stream: stream}, nil
+}
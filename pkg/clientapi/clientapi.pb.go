@@ -103,8 +103,16 @@ func (m *UpdateRequest) GetManifest() []byte {
 }
 
 type GetRequest struct {
-	Kind                 []byte   `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
-	Name                 []byte   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Kind []byte `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Name []byte `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Maximum number of items to return from a list, 0 means no limit.
+	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Continue token from a previous paginated list reply.
+	Continue []byte `protobuf:"bytes,4,opt,name=continue,proto3" json:"continue,omitempty"`
+	// Optional label selector, e.g. "app=web,tier!=backend".
+	LabelSelector []byte `protobuf:"bytes,5,opt,name=labelSelector,proto3" json:"labelSelector,omitempty"`
+	// Optional field selector, e.g. "status.phase=Running".
+	FieldSelector        []byte   `protobuf:"bytes,6,opt,name=fieldSelector,proto3" json:"fieldSelector,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -149,6 +157,34 @@ func (m *GetRequest) GetName() []byte {
 	return nil
 }
 
+func (m *GetRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *GetRequest) GetContinue() []byte {
+	if m != nil {
+		return m.Continue
+	}
+	return nil
+}
+
+func (m *GetRequest) GetLabelSelector() []byte {
+	if m != nil {
+		return m.LabelSelector
+	}
+	return nil
+}
+
+func (m *GetRequest) GetFieldSelector() []byte {
+	if m != nil {
+		return m.FieldSelector
+	}
+	return nil
+}
+
 type DeleteRequest struct {
 	Kind                 []byte   `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
 	Name                 []byte   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
@@ -215,7 +251,7 @@ func (m *DumpRequest) Reset()         { *m = DumpRequest{} }
 func (m *DumpRequest) String() string { return proto.CompactTextString(m) }
 func (*DumpRequest) ProtoMessage()    {}
 func (*DumpRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{4}
+	return fileDescriptor_76c811cce3b5e496, []int{9}
 }
 
 func (m *DumpRequest) XXX_Unmarshal(b []byte) error {
@@ -247,7 +283,10 @@ type APIReply struct {
 	Status int32  `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
 	Body   []byte `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
 	// Warnings are printed by the client to stderr
-	Warning              []byte   `protobuf:"bytes,3,opt,name=warning,proto3" json:"warning,omitempty"`
+	Warning []byte `protobuf:"bytes,3,opt,name=warning,proto3" json:"warning,omitempty"`
+	// Continue token set on paginated list replies when more items
+	// remain.
+	Continue             []byte   `protobuf:"bytes,4,opt,name=continue,proto3" json:"continue,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -257,7 +296,7 @@ func (m *APIReply) Reset()         { *m = APIReply{} }
 func (m *APIReply) String() string { return proto.CompactTextString(m) }
 func (*APIReply) ProtoMessage()    {}
 func (*APIReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{5}
+	return fileDescriptor_76c811cce3b5e496, []int{10}
 }
 
 func (m *APIReply) XXX_Unmarshal(b []byte) error {
@@ -299,6 +338,13 @@ func (m *APIReply) GetWarning() []byte {
 	return nil
 }
 
+func (m *APIReply) GetContinue() []byte {
+	if m != nil {
+		return m.Continue
+	}
+	return nil
+}
+
 type VersionRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -309,7 +355,7 @@ func (m *VersionRequest) Reset()         { *m = VersionRequest{} }
 func (m *VersionRequest) String() string { return proto.CompactTextString(m) }
 func (*VersionRequest) ProtoMessage()    {}
 func (*VersionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{6}
+	return fileDescriptor_76c811cce3b5e496, []int{11}
 }
 
 func (m *VersionRequest) XXX_Unmarshal(b []byte) error {
@@ -341,7 +387,7 @@ func (m *VersionReply) Reset()         { *m = VersionReply{} }
 func (m *VersionReply) String() string { return proto.CompactTextString(m) }
 func (*VersionReply) ProtoMessage()    {}
 func (*VersionReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{7}
+	return fileDescriptor_76c811cce3b5e496, []int{12}
 }
 
 func (m *VersionReply) XXX_Unmarshal(b []byte) error {
@@ -379,7 +425,7 @@ func (m *IsLeaderRequest) Reset()         { *m = IsLeaderRequest{} }
 func (m *IsLeaderRequest) String() string { return proto.CompactTextString(m) }
 func (*IsLeaderRequest) ProtoMessage()    {}
 func (*IsLeaderRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{8}
+	return fileDescriptor_76c811cce3b5e496, []int{13}
 }
 
 func (m *IsLeaderRequest) XXX_Unmarshal(b []byte) error {
@@ -411,7 +457,7 @@ func (m *IsLeaderReply) Reset()         { *m = IsLeaderReply{} }
 func (m *IsLeaderReply) String() string { return proto.CompactTextString(m) }
 func (*IsLeaderReply) ProtoMessage()    {}
 func (*IsLeaderReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{9}
+	return fileDescriptor_76c811cce3b5e496, []int{16}
 }
 
 func (m *IsLeaderReply) XXX_Unmarshal(b []byte) error {
@@ -444,6 +490,8 @@ type LogsRequest struct {
 	ItemName             string   `protobuf:"bytes,2,opt,name=itemName,proto3" json:"itemName,omitempty"`
 	Lines                int32    `protobuf:"varint,3,opt,name=lines,proto3" json:"lines,omitempty"`
 	Limitbytes           int32    `protobuf:"varint,4,opt,name=limitbytes,proto3" json:"limitbytes,omitempty"`
+	StartLine            int32    `protobuf:"varint,5,opt,name=startLine,proto3" json:"startLine,omitempty"`
+	StartByte            int32    `protobuf:"varint,6,opt,name=startByte,proto3" json:"startByte,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -453,7 +501,7 @@ func (m *LogsRequest) Reset()         { *m = LogsRequest{} }
 func (m *LogsRequest) String() string { return proto.CompactTextString(m) }
 func (*LogsRequest) ProtoMessage()    {}
 func (*LogsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{10}
+	return fileDescriptor_76c811cce3b5e496, []int{17}
 }
 
 func (m *LogsRequest) XXX_Unmarshal(b []byte) error {
@@ -502,6 +550,20 @@ func (m *LogsRequest) GetLimitbytes() int32 {
 	return 0
 }
 
+func (m *LogsRequest) GetStartLine() int32 {
+	if m != nil {
+		return m.StartLine
+	}
+	return 0
+}
+
+func (m *LogsRequest) GetStartByte() int32 {
+	if m != nil {
+		return m.StartByte
+	}
+	return 0
+}
+
 type DeployRequest struct {
 	ResourceName         string   `protobuf:"bytes,1,opt,name=resourceName,proto3" json:"resourceName,omitempty"`
 	ItemName             string   `protobuf:"bytes,2,opt,name=itemName,proto3" json:"itemName,omitempty"`
@@ -515,7 +577,7 @@ func (m *DeployRequest) Reset()         { *m = DeployRequest{} }
 func (m *DeployRequest) String() string { return proto.CompactTextString(m) }
 func (*DeployRequest) ProtoMessage()    {}
 func (*DeployRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{11}
+	return fileDescriptor_76c811cce3b5e496, []int{18}
 }
 
 func (m *DeployRequest) XXX_Unmarshal(b []byte) error {
@@ -570,7 +632,7 @@ func (m *StreamLogsRequest) Reset()         { *m = StreamLogsRequest{} }
 func (m *StreamLogsRequest) String() string { return proto.CompactTextString(m) }
 func (*StreamLogsRequest) ProtoMessage()    {}
 func (*StreamLogsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{12}
+	return fileDescriptor_76c811cce3b5e496, []int{19}
 }
 
 func (m *StreamLogsRequest) XXX_Unmarshal(b []byte) error {
@@ -612,6 +674,318 @@ func (m *StreamLogsRequest) GetMetadata() bool {
 	return false
 }
 
+type WatchRequest struct {
+	Kind                 []byte   `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	SinceVersion         int64    `protobuf:"varint,2,opt,name=sinceVersion,proto3" json:"sinceVersion,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{20}
+}
+
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(m, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetKind() []byte {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (m *WatchRequest) GetSinceVersion() int64 {
+	if m != nil {
+		return m.SinceVersion
+	}
+	return 0
+}
+
+type CordonRequest struct {
+	Cordoned             bool     `protobuf:"varint,1,opt,name=cordoned,proto3" json:"cordoned,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CordonRequest) Reset()         { *m = CordonRequest{} }
+func (m *CordonRequest) String() string { return proto.CompactTextString(m) }
+func (*CordonRequest) ProtoMessage()    {}
+func (*CordonRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{14}
+}
+
+func (m *CordonRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CordonRequest.Unmarshal(m, b)
+}
+func (m *CordonRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CordonRequest.Marshal(b, m, deterministic)
+}
+func (m *CordonRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CordonRequest.Merge(m, src)
+}
+func (m *CordonRequest) XXX_Size() int {
+	return xxx_messageInfo_CordonRequest.Size(m)
+}
+func (m *CordonRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CordonRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CordonRequest proto.InternalMessageInfo
+
+func (m *CordonRequest) GetCordoned() bool {
+	if m != nil {
+		return m.Cordoned
+	}
+	return false
+}
+
+type PreflightRequest struct {
+	LaunchTestInstance   bool     `protobuf:"varint,1,opt,name=launchTestInstance,proto3" json:"launchTestInstance,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PreflightRequest) Reset()         { *m = PreflightRequest{} }
+func (m *PreflightRequest) String() string { return proto.CompactTextString(m) }
+func (*PreflightRequest) ProtoMessage()    {}
+func (*PreflightRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{15}
+}
+
+func (m *PreflightRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PreflightRequest.Unmarshal(m, b)
+}
+func (m *PreflightRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PreflightRequest.Marshal(b, m, deterministic)
+}
+func (m *PreflightRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PreflightRequest.Merge(m, src)
+}
+func (m *PreflightRequest) XXX_Size() int {
+	return xxx_messageInfo_PreflightRequest.Size(m)
+}
+func (m *PreflightRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PreflightRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PreflightRequest proto.InternalMessageInfo
+
+func (m *PreflightRequest) GetLaunchTestInstance() bool {
+	if m != nil {
+		return m.LaunchTestInstance
+	}
+	return false
+}
+
+type DeletePodsRequest struct {
+	Namespace            []byte   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	All                  bool     `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePodsRequest) Reset()         { *m = DeletePodsRequest{} }
+func (m *DeletePodsRequest) String() string { return proto.CompactTextString(m) }
+func (*DeletePodsRequest) ProtoMessage()    {}
+func (*DeletePodsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{4}
+}
+
+func (m *DeletePodsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePodsRequest.Unmarshal(m, b)
+}
+func (m *DeletePodsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePodsRequest.Marshal(b, m, deterministic)
+}
+func (m *DeletePodsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePodsRequest.Merge(m, src)
+}
+func (m *DeletePodsRequest) XXX_Size() int {
+	return xxx_messageInfo_DeletePodsRequest.Size(m)
+}
+func (m *DeletePodsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePodsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePodsRequest proto.InternalMessageInfo
+
+func (m *DeletePodsRequest) GetNamespace() []byte {
+	if m != nil {
+		return m.Namespace
+	}
+	return nil
+}
+
+func (m *DeletePodsRequest) GetAll() bool {
+	if m != nil {
+		return m.All
+	}
+	return false
+}
+
+type GetLaunchesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLaunchesRequest) Reset()         { *m = GetLaunchesRequest{} }
+func (m *GetLaunchesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLaunchesRequest) ProtoMessage()    {}
+func (*GetLaunchesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{5}
+}
+
+func (m *GetLaunchesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLaunchesRequest.Unmarshal(m, b)
+}
+func (m *GetLaunchesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLaunchesRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLaunchesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLaunchesRequest.Merge(m, src)
+}
+func (m *GetLaunchesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLaunchesRequest.Size(m)
+}
+func (m *GetLaunchesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLaunchesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLaunchesRequest proto.InternalMessageInfo
+
+type CancelLaunchRequest struct {
+	PodName              []byte   `protobuf:"bytes,1,opt,name=podName,proto3" json:"podName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelLaunchRequest) Reset()         { *m = CancelLaunchRequest{} }
+func (m *CancelLaunchRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelLaunchRequest) ProtoMessage()    {}
+func (*CancelLaunchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{6}
+}
+
+func (m *CancelLaunchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelLaunchRequest.Unmarshal(m, b)
+}
+func (m *CancelLaunchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelLaunchRequest.Marshal(b, m, deterministic)
+}
+func (m *CancelLaunchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelLaunchRequest.Merge(m, src)
+}
+func (m *CancelLaunchRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelLaunchRequest.Size(m)
+}
+func (m *CancelLaunchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelLaunchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelLaunchRequest proto.InternalMessageInfo
+
+func (m *CancelLaunchRequest) GetPodName() []byte {
+	if m != nil {
+		return m.PodName
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportRequest) Reset()         { *m = ExportRequest{} }
+func (m *ExportRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()    {}
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{7}
+}
+
+func (m *ExportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportRequest.Unmarshal(m, b)
+}
+func (m *ExportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportRequest.Marshal(b, m, deterministic)
+}
+func (m *ExportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportRequest.Merge(m, src)
+}
+func (m *ExportRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportRequest.Size(m)
+}
+func (m *ExportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportRequest proto.InternalMessageInfo
+
+type ResyncPodRequest struct {
+	PodName              []byte   `protobuf:"bytes,1,opt,name=podName,proto3" json:"podName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResyncPodRequest) Reset()         { *m = ResyncPodRequest{} }
+func (m *ResyncPodRequest) String() string { return proto.CompactTextString(m) }
+func (*ResyncPodRequest) ProtoMessage()    {}
+func (*ResyncPodRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_76c811cce3b5e496, []int{8}
+}
+
+func (m *ResyncPodRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResyncPodRequest.Unmarshal(m, b)
+}
+func (m *ResyncPodRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResyncPodRequest.Marshal(b, m, deterministic)
+}
+func (m *ResyncPodRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResyncPodRequest.Merge(m, src)
+}
+func (m *ResyncPodRequest) XXX_Size() int {
+	return xxx_messageInfo_ResyncPodRequest.Size(m)
+}
+func (m *ResyncPodRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResyncPodRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResyncPodRequest proto.InternalMessageInfo
+
+func (m *ResyncPodRequest) GetPodName() []byte {
+	if m != nil {
+		return m.PodName
+	}
+	return nil
+}
+
 type ConsoleStreamMsg struct {
 	Data                 []byte   `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -623,7 +997,7 @@ func (m *ConsoleStreamMsg) Reset()         { *m = ConsoleStreamMsg{} }
 func (m *ConsoleStreamMsg) String() string { return proto.CompactTextString(m) }
 func (*ConsoleStreamMsg) ProtoMessage()    {}
 func (*ConsoleStreamMsg) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{13}
+	return fileDescriptor_76c811cce3b5e496, []int{21}
 }
 
 func (m *ConsoleStreamMsg) XXX_Unmarshal(b []byte) error {
@@ -663,7 +1037,7 @@ func (m *StreamMsg) Reset()         { *m = StreamMsg{} }
 func (m *StreamMsg) String() string { return proto.CompactTextString(m) }
 func (*StreamMsg) ProtoMessage()    {}
 func (*StreamMsg) Descriptor() ([]byte, []int) {
-	return fileDescriptor_76c811cce3b5e496, []int{14}
+	return fileDescriptor_76c811cce3b5e496, []int{22}
 }
 
 func (m *StreamMsg) XXX_Unmarshal(b []byte) error {
@@ -712,6 +1086,14 @@ func init() {
 	proto.RegisterType((*LogsRequest)(nil), "LogsRequest")
 	proto.RegisterType((*DeployRequest)(nil), "DeployRequest")
 	proto.RegisterType((*StreamLogsRequest)(nil), "StreamLogsRequest")
+	proto.RegisterType((*WatchRequest)(nil), "WatchRequest")
+	proto.RegisterType((*CordonRequest)(nil), "CordonRequest")
+	proto.RegisterType((*PreflightRequest)(nil), "PreflightRequest")
+	proto.RegisterType((*DeletePodsRequest)(nil), "DeletePodsRequest")
+	proto.RegisterType((*GetLaunchesRequest)(nil), "GetLaunchesRequest")
+	proto.RegisterType((*CancelLaunchRequest)(nil), "CancelLaunchRequest")
+	proto.RegisterType((*ExportRequest)(nil), "ExportRequest")
+	proto.RegisterType((*ResyncPodRequest)(nil), "ResyncPodRequest")
 	proto.RegisterType((*ConsoleStreamMsg)(nil), "ConsoleStreamMsg")
 	proto.RegisterType((*StreamMsg)(nil), "StreamMsg")
 }
@@ -719,45 +1101,65 @@ func init() {
 func init() { proto.RegisterFile("clientapi.proto", fileDescriptor_76c811cce3b5e496) }
 
 var fileDescriptor_76c811cce3b5e496 = []byte{
-	// 602 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0x5b, 0x4f, 0xdb, 0x30,
-	0x18, 0x25, 0x6b, 0x29, 0xe9, 0xd7, 0x0b, 0xc5, 0x9a, 0xa6, 0xaa, 0x0f, 0x5b, 0xb1, 0x18, 0xab,
-	0x84, 0x64, 0x55, 0xbb, 0xfc, 0x00, 0x04, 0x08, 0xa1, 0x01, 0x62, 0x99, 0xd8, 0xbb, 0x49, 0x3e,
-	0x3a, 0x8b, 0x24, 0x0e, 0xb1, 0xbb, 0xad, 0xcf, 0xfb, 0xbd, 0xfb, 0x0f, 0x93, 0x9d, 0x4b, 0xe3,
-	0x21, 0x55, 0x3c, 0xec, 0xcd, 0xe7, 0xf4, 0xe4, 0x3b, 0xbe, 0x9c, 0x53, 0xd8, 0x0d, 0x63, 0x81,
-	0xa9, 0xe6, 0x99, 0x60, 0x59, 0x2e, 0xb5, 0xa4, 0x47, 0x30, 0x38, 0xc9, 0x91, 0x6b, 0x0c, 0xf0,
-	0x71, 0x89, 0x4a, 0x93, 0x09, 0xf8, 0x09, 0x4f, 0xc5, 0x3d, 0x2a, 0x3d, 0xf6, 0xa6, 0xde, 0xac,
-	0x1f, 0xd4, 0xd8, 0x88, 0x6f, 0xb3, 0xe8, 0x99, 0xe2, 0x8f, 0x00, 0xe7, 0xa8, 0x2b, 0x25, 0x81,
-	0xf6, 0x83, 0x48, 0xa3, 0x52, 0x65, 0xd7, 0x86, 0x4b, 0x79, 0x82, 0xe3, 0x17, 0x05, 0x67, 0xd6,
-	0xf4, 0x0b, 0x0c, 0x4e, 0x31, 0xc6, 0xb5, 0xc5, 0x33, 0x3f, 0x24, 0x63, 0xd8, 0x09, 0xb9, 0x0a,
-	0x79, 0x84, 0xe3, 0xd6, 0xd4, 0x9b, 0xf9, 0x41, 0x05, 0xe9, 0x3e, 0xf4, 0x4e, 0x97, 0x49, 0xb6,
-	0x61, 0x20, 0xbd, 0x01, 0xff, 0xf8, 0xe6, 0x22, 0xc0, 0x2c, 0x5e, 0x91, 0x57, 0xd0, 0x51, 0x9a,
-	0xeb, 0xa5, 0xb2, 0x8a, 0xed, 0xa0, 0x44, 0xe6, 0xbb, 0x3b, 0x19, 0xad, 0x2a, 0x53, 0xb3, 0x36,
-	0xa6, 0x3f, 0x79, 0x9e, 0x8a, 0x74, 0x61, 0x4d, 0xfb, 0x41, 0x05, 0xe9, 0x08, 0x86, 0xdf, 0x30,
-	0x57, 0x42, 0xa6, 0xa5, 0x2f, 0x9d, 0x43, 0xbf, 0x66, 0x8c, 0xcf, 0x14, 0x7a, 0x3f, 0x0a, 0x7c,
-	0x91, 0xde, 0xcb, 0x72, 0x3b, 0x4d, 0x8a, 0xee, 0xc1, 0xee, 0x85, 0xba, 0x44, 0x1e, 0x61, 0x5e,
-	0x0d, 0x39, 0x82, 0xc1, 0x9a, 0x32, 0x53, 0x26, 0xe0, 0x8b, 0x92, 0xb0, 0x23, 0xfc, 0xa0, 0xc6,
-	0xf4, 0xb7, 0x07, 0xbd, 0x4b, 0xb9, 0x50, 0xd5, 0xc9, 0x29, 0xf4, 0x73, 0x54, 0x72, 0x99, 0x87,
-	0x78, 0x6d, 0xae, 0xcf, 0xe8, 0xbb, 0x81, 0xc3, 0xd9, 0x79, 0x1a, 0x93, 0xeb, 0xea, 0x7a, 0xbb,
-	0x41, 0x8d, 0xc9, 0x4b, 0xd8, 0x8e, 0x45, 0x8a, 0xca, 0x9e, 0x75, 0x3b, 0x28, 0x00, 0x79, 0x0d,
-	0x10, 0x8b, 0x44, 0xe8, 0xbb, 0x95, 0x46, 0x35, 0x6e, 0xdb, 0x9f, 0x1a, 0x0c, 0x7d, 0x34, 0x2f,
-	0x9a, 0xc5, 0x72, 0xf5, 0xbf, 0xb6, 0x31, 0x85, 0x5e, 0xc6, 0xc3, 0x07, 0xbe, 0xc0, 0x53, 0xae,
-	0x79, 0x79, 0xf1, 0x4d, 0x8a, 0xde, 0xc2, 0xde, 0x57, 0x9d, 0x23, 0x4f, 0x9a, 0xa7, 0x1f, 0x41,
-	0xeb, 0x46, 0x46, 0xa5, 0x9b, 0x59, 0x9a, 0x17, 0xbd, 0x4d, 0x85, 0x2e, 0x0d, 0xec, 0xda, 0x18,
-	0x5f, 0xa1, 0xe6, 0x51, 0x35, 0xd9, 0x0f, 0x6a, 0x4c, 0x0f, 0x61, 0x74, 0x22, 0x53, 0x25, 0x63,
-	0x2c, 0xa6, 0x5f, 0xa9, 0x85, 0x99, 0x61, 0x77, 0x51, 0xa6, 0xc9, 0xda, 0x7f, 0x82, 0xee, 0x46,
-	0x81, 0xb9, 0xc8, 0xb3, 0x3c, 0x97, 0x79, 0x99, 0xa5, 0x02, 0xbc, 0xff, 0xd3, 0x82, 0xd6, 0x67,
-	0x91, 0x11, 0x66, 0x8b, 0x53, 0x66, 0x85, 0xec, 0x32, 0x37, 0x47, 0x93, 0x01, 0x6b, 0xc6, 0x88,
-	0x6e, 0x11, 0x06, 0x7e, 0x95, 0x09, 0x32, 0x62, 0xff, 0x24, 0x66, 0x32, 0x64, 0x4e, 0x60, 0xe8,
-	0x16, 0x79, 0x0b, 0x9d, 0xa2, 0xf2, 0x64, 0xc8, 0x9c, 0xee, 0x4f, 0xba, 0xac, 0x6a, 0x41, 0x21,
-	0x2b, 0xca, 0x4e, 0x86, 0xcc, 0x69, 0xbd, 0x2b, 0x7b, 0x03, 0xad, 0x73, 0xd4, 0xa4, 0xc7, 0xd6,
-	0x65, 0x7f, 0x32, 0xa7, 0x68, 0x34, 0x19, 0x32, 0xa7, 0xda, 0xae, 0xec, 0x00, 0x76, 0xce, 0x51,
-	0x9b, 0x07, 0x23, 0x7d, 0xd6, 0x78, 0x37, 0x57, 0xb5, 0x0f, 0x6d, 0xd3, 0x65, 0xd2, 0x67, 0x8d,
-	0x4a, 0xbb, 0x92, 0x77, 0xc6, 0xcf, 0xe4, 0xcd, 0xfa, 0x35, 0x82, 0xe7, 0xc8, 0x66, 0x1e, 0x99,
-	0x03, 0xac, 0x53, 0x42, 0x08, 0x7b, 0x12, 0x99, 0x09, 0xb0, 0xfa, 0x1d, 0xe9, 0xd6, 0xdc, 0x23,
-	0x07, 0xd0, 0x3e, 0xfb, 0x85, 0x21, 0x69, 0xf0, 0xae, 0x66, 0xe6, 0xcd, 0x3d, 0x72, 0x08, 0x9d,
-	0x63, 0xad, 0x79, 0xf8, 0x7d, 0xb3, 0xee, 0xae, 0x63, 0xff, 0x81, 0x3f, 0xfc, 0x0d, 0x00, 0x00,
-	0xff, 0xff, 0x87, 0xdf, 0x3c, 0x11, 0x94, 0x05, 0x00, 0x00,
+	// 924 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xef, 0x6e, 0xdb, 0x36,
+	0x10, 0x8f, 0xe7, 0xd8, 0xb1, 0xcf, 0x7f, 0x62, 0xb3, 0xc1, 0x60, 0x18, 0xc3, 0x96, 0x12, 0x59,
+	0x17, 0xa0, 0x1b, 0x9b, 0x6d, 0xd8, 0x03, 0xb4, 0x49, 0x16, 0x04, 0x4b, 0x0b, 0x4f, 0x5d, 0xb6,
+	0xcf, 0x8c, 0x74, 0x71, 0x84, 0xca, 0xa4, 0x2a, 0xd2, 0x5b, 0xfd, 0x5a, 0x7b, 0x81, 0xbd, 0xc2,
+	0x1e, 0x69, 0x20, 0x29, 0x4a, 0x62, 0x1c, 0x64, 0xfd, 0xd0, 0x6f, 0xbc, 0xd3, 0x8f, 0xbf, 0x3b,
+	0x92, 0xf7, 0xbb, 0x13, 0xec, 0xc7, 0x59, 0x8a, 0x42, 0xf3, 0x3c, 0x65, 0x79, 0x21, 0xb5, 0xa4,
+	0xcf, 0x61, 0x74, 0x5a, 0x20, 0xd7, 0x18, 0xe1, 0xfb, 0x35, 0x2a, 0x4d, 0xe6, 0xd0, 0x5b, 0x71,
+	0x91, 0xde, 0xa2, 0xd2, 0xb3, 0xd6, 0x61, 0xeb, 0x78, 0x18, 0x55, 0xb6, 0x01, 0x5f, 0xe7, 0xc9,
+	0x47, 0x82, 0xff, 0x6e, 0x01, 0x5c, 0xa0, 0xf6, 0x50, 0x02, 0xbb, 0xef, 0x52, 0x91, 0x94, 0x30,
+	0xbb, 0x36, 0x3e, 0xc1, 0x57, 0x38, 0xfb, 0xcc, 0xf9, 0xcc, 0x9a, 0x1c, 0x40, 0x27, 0x4b, 0x57,
+	0xa9, 0x9e, 0xb5, 0x0f, 0x5b, 0xc7, 0x9d, 0xc8, 0x19, 0x26, 0x50, 0x2c, 0x85, 0x4e, 0xc5, 0x1a,
+	0x67, 0xbb, 0x2e, 0x90, 0xb7, 0xc9, 0x11, 0x8c, 0x32, 0x7e, 0x83, 0xd9, 0x5b, 0xcc, 0x30, 0xd6,
+	0xb2, 0x98, 0x75, 0x2c, 0x20, 0x74, 0x1a, 0xd4, 0x6d, 0x8a, 0x59, 0x52, 0xa1, 0xba, 0x0e, 0x15,
+	0x38, 0xe9, 0xaf, 0x30, 0x3a, 0xc3, 0x0c, 0xeb, 0x13, 0x7e, 0x6c, 0xda, 0x33, 0xd8, 0x8b, 0xb9,
+	0x8a, 0x79, 0x82, 0x36, 0xf1, 0x5e, 0xe4, 0x4d, 0x7a, 0x0a, 0x53, 0x47, 0xb9, 0x90, 0x89, 0xf2,
+	0xb4, 0x5f, 0x40, 0xdf, 0x6c, 0x53, 0x39, 0x8f, 0xb1, 0xe4, 0xae, 0x1d, 0x64, 0x02, 0x6d, 0x9e,
+	0x65, 0x96, 0xbf, 0x17, 0x99, 0x25, 0x3d, 0x00, 0x72, 0x81, 0xfa, 0x8a, 0xaf, 0x45, 0x7c, 0x87,
+	0x9e, 0x85, 0xbe, 0x80, 0x27, 0xa7, 0x5c, 0xc4, 0x98, 0xb9, 0x0f, 0x9e, 0x7c, 0x06, 0x7b, 0xb9,
+	0x4c, 0xde, 0x98, 0x14, 0x1d, 0xb5, 0x37, 0xe9, 0x3e, 0x8c, 0xce, 0x3f, 0xe4, 0xb2, 0xf0, 0xaf,
+	0x42, 0xbf, 0x85, 0x49, 0x84, 0x6a, 0x23, 0xe2, 0x85, 0x4c, 0xfe, 0x7f, 0xfb, 0x53, 0x18, 0x9c,
+	0xad, 0x57, 0xf9, 0x23, 0x77, 0x43, 0x33, 0xe8, 0xbd, 0x5c, 0x5c, 0x46, 0x98, 0x67, 0x1b, 0xf2,
+	0x39, 0x74, 0x95, 0xe6, 0x7a, 0xad, 0x2c, 0xa2, 0x13, 0x95, 0x96, 0xd9, 0x77, 0x23, 0x93, 0x8d,
+	0xbf, 0x3f, 0xb3, 0x36, 0x41, 0xff, 0xe2, 0x85, 0x48, 0xc5, 0xd2, 0xde, 0xdf, 0x30, 0xf2, 0xe6,
+	0x63, 0x4f, 0x4f, 0x27, 0x30, 0xfe, 0x1d, 0x0b, 0x95, 0x4a, 0xe1, 0x0f, 0x74, 0x02, 0xc3, 0xca,
+	0x63, 0x72, 0x38, 0x84, 0xc1, 0x9f, 0xce, 0xbe, 0x14, 0xb7, 0xb2, 0x4c, 0xb5, 0xe9, 0xa2, 0x53,
+	0xd8, 0xbf, 0x54, 0x57, 0xc8, 0x13, 0x2c, 0x3c, 0x89, 0x11, 0x85, 0x2c, 0x92, 0x8a, 0xd5, 0xe5,
+	0x60, 0x1c, 0xe8, 0x4e, 0xdb, 0x8b, 0x2a, 0x9b, 0xbe, 0x82, 0xc9, 0xa2, 0xc0, 0xdb, 0x2c, 0x5d,
+	0xde, 0x55, 0xc5, 0xce, 0x80, 0x64, 0xf6, 0x49, 0x7e, 0x43, 0xa5, 0x2f, 0x85, 0xd2, 0xe6, 0x95,
+	0xca, 0x9d, 0x0f, 0x7c, 0x31, 0x01, 0xeb, 0x1c, 0x4c, 0xda, 0x73, 0xe8, 0xa5, 0xa5, 0xc3, 0x07,
+	0xf4, 0x36, 0xfd, 0xa7, 0x05, 0x83, 0x2b, 0xb9, 0xac, 0x6a, 0x89, 0xc2, 0xb0, 0x40, 0x25, 0xd7,
+	0x45, 0x8c, 0xd5, 0xa3, 0xf5, 0xa3, 0xc0, 0x67, 0xf9, 0x34, 0xae, 0xde, 0xf8, 0xb2, 0xed, 0x47,
+	0x95, 0xed, 0x14, 0x27, 0x50, 0xd5, 0x8a, 0x13, 0xa8, 0xc8, 0x97, 0x00, 0x56, 0x7a, 0x37, 0x1b,
+	0x8d, 0xca, 0x5e, 0x7c, 0x27, 0x6a, 0x78, 0x4c, 0x05, 0x2b, 0xcd, 0x0b, 0x7d, 0x95, 0x0a, 0xb4,
+	0x8a, 0xeb, 0x44, 0xb5, 0xa3, 0xfa, 0xfa, 0x6a, 0xa3, 0xd1, 0x2a, 0xcd, 0x7f, 0x35, 0x0e, 0xfa,
+	0xde, 0xa8, 0x2c, 0xcf, 0xe4, 0xe6, 0x53, 0x1d, 0xe1, 0x10, 0x06, 0x39, 0x8f, 0xdf, 0xf1, 0x25,
+	0x9e, 0x71, 0xcd, 0xcb, 0x0a, 0x6a, 0xba, 0xe8, 0x35, 0x4c, 0xdf, 0xea, 0x02, 0xf9, 0xaa, 0x79,
+	0x73, 0x13, 0x68, 0x2f, 0x64, 0x52, 0x46, 0x33, 0x4b, 0x53, 0x9a, 0xd7, 0x22, 0xd5, 0x65, 0x00,
+	0xbb, 0x36, 0x81, 0x5f, 0xa3, 0xe6, 0x89, 0x67, 0xee, 0x45, 0x95, 0x4d, 0x7f, 0x86, 0xe1, 0x1f,
+	0x5c, 0xd7, 0xd2, 0x7b, 0xa8, 0x5d, 0x50, 0x18, 0xaa, 0x54, 0xc4, 0x58, 0xd6, 0xa5, 0xe5, 0x6e,
+	0x47, 0x81, 0x8f, 0x3e, 0x83, 0xc9, 0xa9, 0x14, 0x4a, 0x66, 0xe8, 0xb2, 0x7c, 0xad, 0x96, 0x86,
+	0xcb, 0x9e, 0xa6, 0xe4, 0xb2, 0xc7, 0xf8, 0x09, 0xfa, 0x8f, 0x02, 0xcc, 0x63, 0x9e, 0x17, 0x85,
+	0x2c, 0x4a, 0x71, 0x39, 0xe3, 0x87, 0x7f, 0xbb, 0xd0, 0xfe, 0x25, 0xcd, 0x09, 0xb3, 0x2d, 0xb9,
+	0x0c, 0x4a, 0xf6, 0x59, 0x28, 0x9e, 0xf9, 0x88, 0x35, 0xb5, 0x43, 0x77, 0x08, 0x83, 0x9e, 0xaf,
+	0x4b, 0x32, 0x61, 0xf7, 0x64, 0x32, 0x1f, 0xb3, 0xa0, 0x68, 0xe9, 0x0e, 0xf9, 0x1a, 0xba, 0x4e,
+	0x38, 0x64, 0xcc, 0x02, 0x05, 0xcd, 0xfb, 0xcc, 0xb7, 0x05, 0xba, 0x43, 0x9e, 0x43, 0xbf, 0x92,
+	0x0c, 0x99, 0xb2, 0xfb, 0xf2, 0x09, 0xc1, 0x86, 0xd3, 0x4e, 0x28, 0xc3, 0xd9, 0x1c, 0x55, 0x5b,
+	0x30, 0x37, 0x9b, 0xc8, 0x98, 0x05, 0x43, 0x2a, 0x84, 0x7d, 0x05, 0xed, 0x0b, 0xd4, 0x64, 0xc0,
+	0xea, 0xd1, 0xb4, 0xc5, 0xe3, 0xda, 0x35, 0x19, 0xb3, 0x60, 0x14, 0x84, 0xb0, 0xef, 0x00, 0xea,
+	0xae, 0x4e, 0x08, 0xdb, 0x6a, 0xf1, 0x21, 0xfc, 0x05, 0x0c, 0x1a, 0xfd, 0x9b, 0x3c, 0x61, 0xdb,
+	0xdd, 0x3c, 0xdc, 0xf0, 0x3d, 0x0c, 0x9b, 0xad, 0x9d, 0x1c, 0xb0, 0x07, 0x3a, 0xfd, 0x56, 0xe6,
+	0xae, 0xb9, 0x93, 0x31, 0x0b, 0xba, 0xfc, 0xd6, 0xe5, 0x57, 0x2d, 0x9f, 0x4c, 0xd9, 0xfd, 0xf6,
+	0x1f, 0x82, 0x8f, 0x60, 0xcf, 0x64, 0x2a, 0x97, 0x8a, 0x0c, 0x59, 0x43, 0x3a, 0x21, 0xea, 0x29,
+	0xec, 0x9a, 0xb9, 0x40, 0x86, 0xac, 0x31, 0x1e, 0x42, 0xc8, 0x37, 0xe6, 0x5a, 0x8d, 0xe4, 0xed,
+	0xb5, 0x36, 0xb4, 0x1f, 0xc0, 0x8e, 0x5b, 0xe4, 0x04, 0xa0, 0x16, 0x2a, 0x21, 0x6c, 0x4b, 0xb5,
+	0x73, 0x60, 0x95, 0x04, 0xe8, 0xce, 0x49, 0x8b, 0x3c, 0x83, 0x8e, 0xd5, 0x20, 0x19, 0xb1, 0xa6,
+	0x16, 0xb7, 0x70, 0x47, 0xb0, 0x7b, 0xfe, 0x01, 0x63, 0xd2, 0xf0, 0x87, 0x98, 0xe3, 0x96, 0x65,
+	0xeb, 0xbe, 0xd4, 0x9a, 0xc7, 0x77, 0x8f, 0xe3, 0x6e, 0xba, 0xf6, 0xff, 0xe9, 0xc7, 0xff, 0x02,
+	0x00, 0x00, 0xff, 0xff, 0x8d, 0xb8, 0xdd, 0x65, 0x52, 0x09, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -776,6 +1178,11 @@ type KipClient interface {
 	GetVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionReply, error)
 	// Asks the server if it is the leader.
 	IsLeader(ctx context.Context, in *IsLeaderRequest, opts ...grpc.CallOption) (*IsLeaderReply, error)
+	// Cordon or uncordon the virtual node.
+	Cordon(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// Run preflight checks (etcd, cloud credentials, boot image,
+	// instance type availability) and return the results as JSON.
+	Preflight(ctx context.Context, in *PreflightRequest, opts ...grpc.CallOption) (*APIReply, error)
 	// Create given resource on cloud backend.
 	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*APIReply, error)
 	// Update given resource on cloud backend.
@@ -784,6 +1191,20 @@ type KipClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*APIReply, error)
 	// Get the given resource
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// Delete all pods in a namespace (or every pod) in one call,
+	// terminating their instances concurrently.
+	DeletePods(ctx context.Context, in *DeletePodsRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// List node launches that are currently in flight as JSON.
+	GetLaunches(ctx context.Context, in *GetLaunchesRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// Cancel an in-flight node launch, terminating any partially
+	// created instance.
+	CancelLaunch(ctx context.Context, in *CancelLaunchRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// Export the full pod and cell inventory as structured JSON for
+	// external tooling (CMDB, cost reporting).
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*APIReply, error)
+	// Force an immediate re-poll of a pod's status from its cell,
+	// bypassing the normal status poll interval.
+	ResyncPod(ctx context.Context, in *ResyncPodRequest, opts ...grpc.CallOption) (*APIReply, error)
 	// Get logs of a given pod.
 	GetLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*APIReply, error)
 	// Dump controller status.
@@ -792,6 +1213,8 @@ type KipClient interface {
 	Deploy(ctx context.Context, opts ...grpc.CallOption) (Kip_DeployClient, error)
 	// Stream logs from a running pod
 	StreamLogs(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (Kip_StreamLogsClient, error)
+	// Watch pods or nodes for changes
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kip_WatchClient, error)
 	// Execute a command in the context of a unit
 	Exec(ctx context.Context, opts ...grpc.CallOption) (Kip_ExecClient, error)
 	// Attach to stdin, stdout and stderr of a running pod
@@ -824,6 +1247,24 @@ func (c *kipClient) IsLeader(ctx context.Context, in *IsLeaderRequest, opts ...g
 	return out, nil
 }
 
+func (c *kipClient) Cordon(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/Cordon", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kipClient) Preflight(ctx context.Context, in *PreflightRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/Preflight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kipClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*APIReply, error) {
 	out := new(APIReply)
 	err := c.cc.Invoke(ctx, "/Kip/Create", in, out, opts...)
@@ -860,6 +1301,51 @@ func (c *kipClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *kipClient) DeletePods(ctx context.Context, in *DeletePodsRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/DeletePods", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kipClient) GetLaunches(ctx context.Context, in *GetLaunchesRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/GetLaunches", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kipClient) CancelLaunch(ctx context.Context, in *CancelLaunchRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/CancelLaunch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kipClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/Export", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kipClient) ResyncPod(ctx context.Context, in *ResyncPodRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	out := new(APIReply)
+	err := c.cc.Invoke(ctx, "/Kip/ResyncPod", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kipClient) GetLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*APIReply, error) {
 	out := new(APIReply)
 	err := c.cc.Invoke(ctx, "/Kip/GetLogs", in, out, opts...)
@@ -944,6 +1430,38 @@ func (x *kipStreamLogsClient) Recv() (*StreamMsg, error) {
 	return m, nil
 }
 
+func (c *kipClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kip_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Kip_serviceDesc.Streams[4], "/Kip/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kipWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Kip_WatchClient interface {
+	Recv() (*StreamMsg, error)
+	grpc.ClientStream
+}
+
+type kipWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *kipWatchClient) Recv() (*StreamMsg, error) {
+	m := new(StreamMsg)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *kipClient) Exec(ctx context.Context, opts ...grpc.CallOption) (Kip_ExecClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_Kip_serviceDesc.Streams[2], "/Kip/Exec", opts...)
 	if err != nil {
@@ -1012,6 +1530,11 @@ type KipServer interface {
 	GetVersion(context.Context, *VersionRequest) (*VersionReply, error)
 	// Asks the server if it is the leader.
 	IsLeader(context.Context, *IsLeaderRequest) (*IsLeaderReply, error)
+	// Cordon or uncordon the virtual node.
+	Cordon(context.Context, *CordonRequest) (*APIReply, error)
+	// Run preflight checks (etcd, cloud credentials, boot image,
+	// instance type availability) and return the results as JSON.
+	Preflight(context.Context, *PreflightRequest) (*APIReply, error)
 	// Create given resource on cloud backend.
 	Create(context.Context, *CreateRequest) (*APIReply, error)
 	// Update given resource on cloud backend.
@@ -1020,6 +1543,20 @@ type KipServer interface {
 	Get(context.Context, *GetRequest) (*APIReply, error)
 	// Get the given resource
 	Delete(context.Context, *DeleteRequest) (*APIReply, error)
+	// Delete all pods in a namespace (or every pod) in one call,
+	// terminating their instances concurrently.
+	DeletePods(context.Context, *DeletePodsRequest) (*APIReply, error)
+	// List node launches that are currently in flight as JSON.
+	GetLaunches(context.Context, *GetLaunchesRequest) (*APIReply, error)
+	// Cancel an in-flight node launch, terminating any partially
+	// created instance.
+	CancelLaunch(context.Context, *CancelLaunchRequest) (*APIReply, error)
+	// Export the full pod and cell inventory as structured JSON for
+	// external tooling (CMDB, cost reporting).
+	Export(context.Context, *ExportRequest) (*APIReply, error)
+	// Force an immediate re-poll of a pod's status from its cell,
+	// bypassing the normal status poll interval.
+	ResyncPod(context.Context, *ResyncPodRequest) (*APIReply, error)
 	// Get logs of a given pod.
 	GetLogs(context.Context, *LogsRequest) (*APIReply, error)
 	// Dump controller status.
@@ -1028,6 +1565,8 @@ type KipServer interface {
 	Deploy(Kip_DeployServer) error
 	// Stream logs from a running pod
 	StreamLogs(*StreamLogsRequest, Kip_StreamLogsServer) error
+	// Watch pods or nodes for changes
+	Watch(*WatchRequest, Kip_WatchServer) error
 	// Execute a command in the context of a unit
 	Exec(Kip_ExecServer) error
 	// Attach to stdin, stdout and stderr of a running pod
@@ -1044,6 +1583,12 @@ func (*UnimplementedKipServer) GetVersion(ctx context.Context, req *VersionReque
 func (*UnimplementedKipServer) IsLeader(ctx context.Context, req *IsLeaderRequest) (*IsLeaderReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsLeader not implemented")
 }
+func (*UnimplementedKipServer) Cordon(ctx context.Context, req *CordonRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cordon not implemented")
+}
+func (*UnimplementedKipServer) Preflight(ctx context.Context, req *PreflightRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Preflight not implemented")
+}
 func (*UnimplementedKipServer) Create(ctx context.Context, req *CreateRequest) (*APIReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
 }
@@ -1056,6 +1601,21 @@ func (*UnimplementedKipServer) Get(ctx context.Context, req *GetRequest) (*APIRe
 func (*UnimplementedKipServer) Delete(ctx context.Context, req *DeleteRequest) (*APIReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (*UnimplementedKipServer) DeletePods(ctx context.Context, req *DeletePodsRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePods not implemented")
+}
+func (*UnimplementedKipServer) GetLaunches(ctx context.Context, req *GetLaunchesRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLaunches not implemented")
+}
+func (*UnimplementedKipServer) CancelLaunch(ctx context.Context, req *CancelLaunchRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelLaunch not implemented")
+}
+func (*UnimplementedKipServer) Export(ctx context.Context, req *ExportRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (*UnimplementedKipServer) ResyncPod(ctx context.Context, req *ResyncPodRequest) (*APIReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResyncPod not implemented")
+}
 func (*UnimplementedKipServer) GetLogs(ctx context.Context, req *LogsRequest) (*APIReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
 }
@@ -1068,6 +1628,9 @@ func (*UnimplementedKipServer) Deploy(srv Kip_DeployServer) error {
 func (*UnimplementedKipServer) StreamLogs(req *StreamLogsRequest, srv Kip_StreamLogsServer) error {
 	return status.Errorf(codes.Unimplemented, "method StreamLogs not implemented")
 }
+func (*UnimplementedKipServer) Watch(req *WatchRequest, srv Kip_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (*UnimplementedKipServer) Exec(srv Kip_ExecServer) error {
 	return status.Errorf(codes.Unimplemented, "method Exec not implemented")
 }
@@ -1187,6 +1750,96 @@ func _Kip_Delete_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Kip_DeletePods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).DeletePods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/DeletePods",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).DeletePods(ctx, req.(*DeletePodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kip_GetLaunches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLaunchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).GetLaunches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/GetLaunches",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).GetLaunches(ctx, req.(*GetLaunchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kip_CancelLaunch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelLaunchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).CancelLaunch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/CancelLaunch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).CancelLaunch(ctx, req.(*CancelLaunchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kip_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).Export(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kip_ResyncPod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResyncPodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).ResyncPod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/ResyncPod",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).ResyncPod(ctx, req.(*ResyncPodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Kip_GetLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogsRequest)
 	if err := dec(in); err != nil {
@@ -1270,6 +1923,27 @@ func (x *kipStreamLogsServer) Send(m *StreamMsg) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Kip_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KipServer).Watch(m, &kipWatchServer{stream})
+}
+
+type Kip_WatchServer interface {
+	Send(*StreamMsg) error
+	grpc.ServerStream
+}
+
+type kipWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *kipWatchServer) Send(m *StreamMsg) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Kip_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(KipServer).Exec(&kipExecServer{stream})
 }
@@ -1322,6 +1996,42 @@ func (x *kipAttachServer) Recv() (*StreamMsg, error) {
 	return m, nil
 }
 
+func _Kip_Cordon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CordonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).Cordon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/Cordon",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).Cordon(ctx, req.(*CordonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kip_Preflight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreflightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KipServer).Preflight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Kip/Preflight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KipServer).Preflight(ctx, req.(*PreflightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Kip_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Kip",
 	HandlerType: (*KipServer)(nil),
@@ -1334,6 +2044,14 @@ var _Kip_serviceDesc = grpc.ServiceDesc{
 			MethodName: "IsLeader",
 			Handler:    _Kip_IsLeader_Handler,
 		},
+		{
+			MethodName: "Cordon",
+			Handler:    _Kip_Cordon_Handler,
+		},
+		{
+			MethodName: "Preflight",
+			Handler:    _Kip_Preflight_Handler,
+		},
 		{
 			MethodName: "Create",
 			Handler:    _Kip_Create_Handler,
@@ -1350,6 +2068,26 @@ var _Kip_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _Kip_Delete_Handler,
 		},
+		{
+			MethodName: "DeletePods",
+			Handler:    _Kip_DeletePods_Handler,
+		},
+		{
+			MethodName: "GetLaunches",
+			Handler:    _Kip_GetLaunches_Handler,
+		},
+		{
+			MethodName: "CancelLaunch",
+			Handler:    _Kip_CancelLaunch_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _Kip_Export_Handler,
+		},
+		{
+			MethodName: "ResyncPod",
+			Handler:    _Kip_ResyncPod_Handler,
+		},
 		{
 			MethodName: "GetLogs",
 			Handler:    _Kip_GetLogs_Handler,
@@ -1382,6 +2120,11 @@ var _Kip_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Kip_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "clientapi.proto",
 }
@@ -33,13 +33,21 @@ type MockKipClient struct {
 	Updater      func(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*APIReply, error)
 	Getter       func(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*APIReply, error)
 	Deleter      func(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*APIReply, error)
+	PodsDeleter  func(ctx context.Context, in *DeletePodsRequest, opts ...grpc.CallOption) (*APIReply, error)
+	LaunchGetter func(ctx context.Context, in *GetLaunchesRequest, opts ...grpc.CallOption) (*APIReply, error)
+	LaunchCancel func(ctx context.Context, in *CancelLaunchRequest, opts ...grpc.CallOption) (*APIReply, error)
+	Exporter     func(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*APIReply, error)
+	PodResyncer  func(ctx context.Context, in *ResyncPodRequest, opts ...grpc.CallOption) (*APIReply, error)
 	GetLogser    func(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*APIReply, error)
 	Dumper       func(ctx context.Context, in *DumpRequest, opts ...grpc.CallOption) (*APIReply, error)
 	Deployer     func(ctx context.Context, opts ...grpc.CallOption) (Kip_DeployClient, error)
 	StreamLogser func(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (Kip_StreamLogsClient, error)
+	Watcher      func(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kip_WatchClient, error)
 	Execer       func(ctx context.Context, opts ...grpc.CallOption) (Kip_ExecClient, error)
 	Attacher     func(ctx context.Context, opts ...grpc.CallOption) (Kip_AttachClient, error)
 	Leader       func(ctx context.Context, in *IsLeaderRequest, opts ...grpc.CallOption) (*IsLeaderReply, error)
+	Cordoner     func(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*APIReply, error)
+	Preflighter  func(ctx context.Context, in *PreflightRequest, opts ...grpc.CallOption) (*APIReply, error)
 }
 
 func (m MockKipClient) GetVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionReply, error) {
@@ -50,6 +58,14 @@ func (m MockKipClient) IsLeader(ctx context.Context, in *IsLeaderRequest, opts .
 	return m.Leader(ctx, in, opts...)
 }
 
+func (m MockKipClient) Cordon(ctx context.Context, in *CordonRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.Cordoner(ctx, in, opts...)
+}
+
+func (m MockKipClient) Preflight(ctx context.Context, in *PreflightRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.Preflighter(ctx, in, opts...)
+}
+
 func (m MockKipClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*APIReply, error) {
 	return m.Creator(ctx, in, opts...)
 }
@@ -66,6 +82,26 @@ func (m MockKipClient) Delete(ctx context.Context, in *DeleteRequest, opts ...gr
 	return m.Deleter(ctx, in, opts...)
 }
 
+func (m MockKipClient) DeletePods(ctx context.Context, in *DeletePodsRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.PodsDeleter(ctx, in, opts...)
+}
+
+func (m MockKipClient) GetLaunches(ctx context.Context, in *GetLaunchesRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.LaunchGetter(ctx, in, opts...)
+}
+
+func (m MockKipClient) CancelLaunch(ctx context.Context, in *CancelLaunchRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.LaunchCancel(ctx, in, opts...)
+}
+
+func (m MockKipClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.Exporter(ctx, in, opts...)
+}
+
+func (m MockKipClient) ResyncPod(ctx context.Context, in *ResyncPodRequest, opts ...grpc.CallOption) (*APIReply, error) {
+	return m.PodResyncer(ctx, in, opts...)
+}
+
 func (m MockKipClient) GetLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*APIReply, error) {
 	return m.GetLogser(ctx, in, opts...)
 }
@@ -82,6 +118,10 @@ func (m MockKipClient) StreamLogs(ctx context.Context, in *StreamLogsRequest, op
 	return m.StreamLogser(ctx, in, opts...)
 }
 
+func (m MockKipClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kip_WatchClient, error) {
+	return m.Watcher(ctx, in, opts...)
+}
+
 func (m MockKipClient) Exec(ctx context.Context, opts ...grpc.CallOption) (Kip_ExecClient, error) {
 	return m.Execer(ctx, opts...)
 }
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientapi
+
+import (
+	"encoding/json"
+
+	"github.com/elotl/kip/pkg/api"
+)
+
+// Types of WatchEvents sent over a Watch stream.
+const (
+	WatchAdded    = "ADDED"
+	WatchModified = "MODIFIED"
+	WatchDeleted  = "DELETED"
+)
+
+// WatchEvent is the payload carried in StreamMsg.Data on a Watch
+// stream.  Version is a server-assigned, monotonically increasing
+// sequence number; pass the last version seen back in
+// WatchRequest.SinceVersion to resume a broken watch.
+type WatchEvent struct {
+	Type    string          `json:"type"`
+	Version int64           `json:"version"`
+	Object  json.RawMessage `json:"object"`
+}
+
+// DecodeObject decodes the event's object into its milpa API type.
+func (e *WatchEvent) DecodeObject() (api.MilpaObject, error) {
+	return api.Decode(e.Object)
+}
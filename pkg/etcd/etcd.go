@@ -44,8 +44,12 @@ var (
 type EtcdServer struct {
 	ConfigFile string
 	DataDir    string
-	Proc       *embed.Etcd
-	Client     *SimpleEtcd
+	// MaintenanceInterval is how often the store is compacted and
+	// defragmented, zero disables scheduled maintenance.
+	MaintenanceInterval time.Duration
+	Proc                *embed.Etcd
+	Client              *SimpleEtcd
+	maintStats          MaintenanceStats
 }
 
 func ensureEtcdDataDir(dataDir string) error {
@@ -138,6 +142,10 @@ func (s *EtcdServer) Start(quit <-chan struct{}, wg *sync.WaitGroup) error {
 		client:   apiClient,
 		external: false,
 	}
+	if s.MaintenanceInterval > 0 {
+		wg.Add(1)
+		go s.maintenanceLoop(quit, wg)
+	}
 	wg.Add(1)
 	go func() {
 		<-quit
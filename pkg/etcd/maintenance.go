@@ -0,0 +1,122 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/mvcc"
+	"k8s.io/klog"
+)
+
+var (
+	maintenanceDeadline = 1 * time.Minute
+)
+
+// MaintenanceStats records the outcome of the last scheduled
+// maintenance pass of the embedded etcd, it shows up in the server
+// dump so operators can verify the store isn't growing unbounded.
+type MaintenanceStats struct {
+	LastRun         time.Time
+	Runs            int64
+	Errors          int64
+	CompactRevision int64
+	// DBSizeBefore and DBSizeAfter are the backend database size in
+	// bytes before and after the last compact+defrag pass.
+	DBSizeBefore int64
+	DBSizeAfter  int64
+	sync.Mutex
+}
+
+func (s *MaintenanceStats) Copy() *MaintenanceStats {
+	s.Lock()
+	defer s.Unlock()
+	return &MaintenanceStats{
+		LastRun:         s.LastRun,
+		Runs:            s.Runs,
+		Errors:          s.Errors,
+		CompactRevision: s.CompactRevision,
+		DBSizeBefore:    s.DBSizeBefore,
+		DBSizeAfter:     s.DBSizeAfter,
+	}
+}
+
+// MaintenanceStats returns a snapshot of the last maintenance pass.
+func (s *EtcdServer) MaintenanceStats() *MaintenanceStats {
+	return s.maintStats.Copy()
+}
+
+func (s *EtcdServer) maintenanceLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(s.MaintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			s.runMaintenance()
+		}
+	}
+}
+
+// runMaintenance compacts old revisions and defragments the embedded
+// etcd's backend database so a long-running controller's store
+// doesn't grow without bound.  Compaction happens online and doesn't
+// block in-flight operations; we only wait (physical compaction) so
+// the following defragmentation can actually return the freed pages
+// to the filesystem.  Defragmentation briefly holds back writes,
+// which is why maintenance only runs at the configured interval.
+func (s *EtcdServer) runMaintenance() {
+	backend := s.Proc.Server.Backend()
+	sizeBefore := backend.Size()
+	rev := s.Proc.Server.KV().Rev()
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceDeadline)
+	_, err := s.Client.client.Compact(ctx, rev, clientv3.WithCompactPhysical())
+	cancel()
+	if err != nil && err != mvcc.ErrCompacted && err != rpctypes.ErrCompacted {
+		klog.Errorf("Error compacting etcd to revision %d: %v", rev, err)
+		s.recordMaintenance(rev, sizeBefore, backend.Size(), true)
+		return
+	}
+	if err := backend.Defrag(); err != nil {
+		klog.Errorf("Error defragmenting etcd backend: %v", err)
+		s.recordMaintenance(rev, sizeBefore, backend.Size(), true)
+		return
+	}
+	sizeAfter := backend.Size()
+	klog.V(2).Infof("etcd maintenance: compacted through revision %d, db size %d -> %d bytes",
+		rev, sizeBefore, sizeAfter)
+	s.recordMaintenance(rev, sizeBefore, sizeAfter, false)
+}
+
+func (s *EtcdServer) recordMaintenance(rev, sizeBefore, sizeAfter int64, isError bool) {
+	s.maintStats.Lock()
+	defer s.maintStats.Unlock()
+	s.maintStats.LastRun = time.Now()
+	s.maintStats.Runs++
+	if isError {
+		s.maintStats.Errors++
+	}
+	s.maintStats.CompactRevision = rev
+	s.maintStats.DBSizeBefore = sizeBefore
+	s.maintStats.DBSizeAfter = sizeAfter
+}
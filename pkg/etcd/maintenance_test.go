@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtcdMaintenance(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	quit := make(chan struct{})
+	dataDir, err := ioutil.TempDir(os.TempDir(), "etcdtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	db := EtcdServer{
+		DataDir: dataDir,
+	}
+	err = db.Start(quit, wg)
+	if err != nil {
+		fmt.Println(err)
+		assert.FailNow(t, "Error setting up embedded etcd for testing")
+	}
+	defer func() { quit <- struct{}{} }()
+
+	// create some revisions worth compacting
+	for i := 0; i < 50; i++ {
+		err = db.Client.Put("maint/key", []byte(fmt.Sprintf("val%d", i)), nil)
+		assert.NoError(t, err)
+	}
+	db.runMaintenance()
+	stats := db.MaintenanceStats()
+	assert.Equal(t, int64(1), stats.Runs)
+	assert.Equal(t, int64(0), stats.Errors)
+	assert.True(t, stats.CompactRevision > 0)
+	assert.True(t, stats.DBSizeBefore > 0)
+	assert.True(t, stats.DBSizeAfter > 0)
+	assert.False(t, stats.LastRun.IsZero())
+
+	// the store still serves reads and writes after a defrag
+	err = db.Client.Put("maint/key", []byte("after"), nil)
+	assert.NoError(t, err)
+	pair, err := db.Client.Get("maint/key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("after"), pair.Value)
+
+	// a second pass with nothing new to compact is not an error
+	db.runMaintenance()
+	stats = db.MaintenanceStats()
+	assert.Equal(t, int64(2), stats.Runs)
+	assert.Equal(t, int64(0), stats.Errors)
+}
@@ -51,6 +51,43 @@ var (
 	ServerName               = "MilpaNode"
 )
 
+// ItzoClientConfig holds the connection settings used when talking to
+// the itzo agent running on cells.  Zero values fall back to the
+// defaults from DefaultItzoClientConfig.
+type ItzoClientConfig struct {
+	// ConnectTimeout bounds dialing the node and the TLS handshake.
+	ConnectTimeout time.Duration
+	// RequestTimeout bounds an entire request including reading the
+	// response body.  It's large by default since deploying a big
+	// package can take a while.
+	RequestTimeout time.Duration
+	// Retries is the number of additional attempts made for
+	// idempotent requests (status, logs, files) after a retryable
+	// error.  Non-idempotent requests are never retried.
+	Retries int
+	// HostnameTemplate, when set, is used to build the address we
+	// connect to instead of a raw IP.  The placeholders {publicIP},
+	// {privateIP}, {publicDNS} and {privateDNS} are replaced with the
+	// node's addresses, e.g. "{privateDNS}" or
+	// "{privateIP}.cells.example.internal".  If any placeholder in
+	// the template has no value for a node, we fall back to
+	// connecting by IP.
+	HostnameTemplate string
+	// ProxyURL routes itzo connections through an HTTP(S) proxy,
+	// e.g. a bastion host, for clusters where cells aren't directly
+	// routable from the controller.  Empty honors the standard proxy
+	// environment variables.
+	ProxyURL string
+}
+
+func DefaultItzoClientConfig() ItzoClientConfig {
+	return ItzoClientConfig{
+		ConnectTimeout: 10 * time.Second,
+		RequestTimeout: 120 * time.Second,
+		Retries:        2,
+	}
+}
+
 type ItzoClientFactoryer interface {
 	// We pass in the whole NetworkAddress here since we might want to
 	// connect to either the public or private IP of the node/pod,
@@ -64,9 +101,10 @@ type ItzoClientFactory struct {
 	tlsConfig         *tls.Config
 	clients           *timeoutmap.TimeoutMap
 	defaultToPublicIP bool
+	config            ItzoClientConfig
 }
 
-func NewItzoFactory(rootCert *x509.Certificate, cert tls.Certificate, defaultToPublicIP bool) *ItzoClientFactory {
+func NewItzoFactory(rootCert *x509.Certificate, cert tls.Certificate, defaultToPublicIP bool, config ItzoClientConfig) *ItzoClientFactory {
 	caCertPool := x509.NewCertPool()
 	caCertPool.AddCert(rootCert)
 	clientFactory := &ItzoClientFactory{
@@ -77,17 +115,49 @@ func NewItzoFactory(rootCert *x509.Certificate, cert tls.Certificate, defaultToP
 		},
 		clients:           timeoutmap.New(false, nil),
 		defaultToPublicIP: defaultToPublicIP,
+		config:            config,
 	}
 	go clientFactory.clients.Start(30 * time.Second)
 	return clientFactory
 }
 
-// Rules for getting the node address: Try to get a public address if
-// we want to connect via public addresses (defaultToPublicAddress).
-// However, if a user boots a node with a private address only (in a
-// public subnet). Fallback to attempt to connect with the private
-// address.
+// expandHostnameTemplate replaces the {publicIP}, {privateIP},
+// {publicDNS} and {privateDNS} placeholders in tmpl with the node's
+// addresses.  It returns "" if a placeholder used in the template has
+// no value for this node (e.g. the cloud hasn't reported a DNS name
+// yet) so callers can fall back to connecting by IP.
+func expandHostnameTemplate(tmpl string, addy []api.NetworkAddress) string {
+	placeholders := map[string]string{
+		"{publicIP}":   api.GetPublicIP(addy),
+		"{privateIP}":  api.GetPrivateIP(addy),
+		"{publicDNS}":  api.GetPublicDNS(addy),
+		"{privateDNS}": api.GetPrivateDNS(addy),
+	}
+	address := tmpl
+	for placeholder, value := range placeholders {
+		if !strings.Contains(address, placeholder) {
+			continue
+		}
+		if value == "" {
+			return ""
+		}
+		address = strings.Replace(address, placeholder, value, -1)
+	}
+	return address
+}
+
+// Rules for getting the node address: If a hostname template is
+// configured, use it as long as the node has the addresses it needs.
+// Otherwise try to get a public address if we want to connect via
+// public addresses (defaultToPublicAddress).  However, if a user
+// boots a node with a private address only (in a public subnet).
+// Fallback to attempt to connect with the private address.
 func (fac *ItzoClientFactory) getAddress(addy []api.NetworkAddress) string {
+	if fac.config.HostnameTemplate != "" {
+		if address := expandHostnameTemplate(fac.config.HostnameTemplate, addy); address != "" {
+			return address
+		}
+	}
 	address := ""
 	if fac.defaultToPublicIP {
 		address = api.GetPublicIP(addy)
@@ -104,7 +174,7 @@ func (fac *ItzoClientFactory) GetClient(addy []api.NetworkAddress) NodeClient {
 	ip := fac.getAddress(addy)
 	client, exists := fac.clients.Get(ip)
 	if !exists {
-		newClient = NewItzoClient(ip, fac.tlsConfig)
+		newClient = NewItzoClient(ip, fac.tlsConfig, fac.config)
 		fac.clients.Add(ip, newClient, clientTTL, timeoutmap.Noop)
 	} else {
 		newClient = client.(*ItzoClient)
@@ -124,7 +194,7 @@ func (fac *ItzoClientFactory) GetWSStream(addy []api.NetworkAddress, path string
 	dialer := &websocket.Dialer{
 		TLSClientConfig:  fac.tlsConfig.Clone(),
 		HandshakeTimeout: 10 * time.Second,
-		Proxy:            http.ProxyFromEnvironment,
+		Proxy:            proxyFunc(fac.config.ProxyURL),
 	}
 	header := http.Header{}
 	header.Add("Content-Type", "application/x-www-form-urlencoded")
@@ -162,28 +232,54 @@ type ItzoClient struct {
 	// make short timeouts work)
 	httpClient        *http.Client
 	healthcheckClient *http.Client
+	retries           int
+}
+
+// proxyFunc returns the proxy selection function used when dialing
+// itzo.  A configured proxy URL wins; otherwise the standard proxy
+// environment variables apply.
+func proxyFunc(proxyURL string) func(*http.Request) (*neturl.URL, error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment
+	}
+	u, err := neturl.Parse(proxyURL)
+	if err != nil {
+		klog.Errorf("Invalid itzo proxy URL %q, falling back to environment proxy settings: %v", proxyURL, err)
+		return http.ProxyFromEnvironment
+	}
+	return http.ProxyURL(u)
 }
 
-func NewItzoClient(instanceIp string, tlsConfig *tls.Config) *ItzoClient {
+func NewItzoClient(instanceIp string, tlsConfig *tls.Config, config ItzoClientConfig) *ItzoClient {
+	defaults := DefaultItzoClientConfig()
+	if config.ConnectTimeout <= 0 {
+		config.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = defaults.RequestTimeout
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
 	return &ItzoClient{
 		instanceIp: instanceIp,
 		baseURL:    fmt.Sprintf("https://%s:%d/", instanceIp, ItzoPort),
-		// The main timeout was arbitrarily chosen.  It was made to be
-		// very large since large containers might take a long time to
-		// download.  We might need to specify different timeouts for
-		// the stages of the connection lifetime.  For now use a
-		// relaxed timeout.  I've added a dial & handshake timeout to
+		// The request timeout defaults to a very large value since
+		// large containers might take a long time to download.  We
+		// might need to specify different timeouts for the stages of
+		// the connection lifetime.  The dial & handshake timeouts
 		// help with hangs that can happen after a node goes away.  In
 		// that case, operations might fail but at least we don't hang
 		// milpa.
 		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: config.RequestTimeout,
 			Transport: &http.Transport{
+				Proxy:           proxyFunc(config.ProxyURL),
 				TLSClientConfig: tlsConfig.Clone(),
 				Dial: (&net.Dialer{
-					Timeout: 10 * time.Second,
+					Timeout: config.ConnectTimeout,
 				}).Dial,
-				TLSHandshakeTimeout: 10 * time.Second,
+				TLSHandshakeTimeout: config.ConnectTimeout,
 			},
 		},
 		// This should be less than the node controller's heartbeat
@@ -191,9 +287,47 @@ func NewItzoClient(instanceIp string, tlsConfig *tls.Config) *ItzoClient {
 		healthcheckClient: &http.Client{
 			Timeout: 3 * time.Second,
 			Transport: &http.Transport{
+				Proxy:           proxyFunc(config.ProxyURL),
 				TLSClientConfig: tlsConfig.Clone(),
 			},
 		},
+		retries: config.Retries,
+	}
+}
+
+// IsRetryableNodeError reports whether an error talking to a node is
+// likely transient (timeouts, connection resets, a connection refused
+// while the agent restarts) and worth retrying.  Errors that made it
+// to the itzo HTTP layer (bad status codes) are considered fatal.
+func IsRetryableNodeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if urlErr, ok := err.(*neturl.Error); ok {
+		err = urlErr.Err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if netErr, ok := err.(net.Error); ok &&
+		(netErr.Timeout() || netErr.Temporary()) {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+// getWithRetry issues a GET request, retrying up to the client's
+// retry budget when the error looks transient.  Only use this for
+// idempotent requests.
+func (c *ItzoClient) getWithRetry(url string) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = c.httpClient.Get(url)
+		if err == nil || attempt >= c.retries || !IsRetryableNodeError(err) {
+			return resp, err
+		}
+		klog.V(2).Infof("Retrying GET %s after transient error: %v", url, err)
 	}
 }
 
@@ -260,7 +394,7 @@ func (c *ItzoClient) ResizeVolume() error {
 
 func (c *ItzoClient) GetStatus() (*api.PodStatusReply, error) {
 	url := createUrl(c.baseURL, "rest/v1/status")
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.getWithRetry(url)
 	if err != nil {
 		return nil, err
 	}
@@ -293,12 +427,79 @@ func (c *ItzoClient) Healthcheck() error {
 	return nil
 }
 
-func (c *ItzoClient) GetLogs(unit string, lines, bytes int) ([]byte, error) {
+// GetVersion returns the version of itzo running on the node. Old
+// itzo builds don't serve the version endpoint, callers should treat
+// an error here as non-fatal.
+func (c *ItzoClient) GetVersion() (string, error) {
+	url := c.baseURL + "rest/v1/version"
+	resp, err := c.getWithRetry(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode/200 != 1 {
+		return "", fmt.Errorf("Failed to fetch itzo version: %s (%d); %s",
+			resp.Status, resp.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// GetBootstrapStatus returns itzo's report on the cell's cloud-init
+// bootstrap.  Old itzo builds don't serve the bootstrap endpoint,
+// callers should treat an error here as non-fatal.
+func (c *ItzoClient) GetBootstrapStatus() (*api.BootstrapStatus, error) {
+	url := c.baseURL + "rest/v1/bootstrap"
+	resp, err := c.getWithRetry(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/200 != 1 {
+		return nil, fmt.Errorf("Failed to fetch bootstrap status: %s (%d); %s",
+			resp.Status, resp.StatusCode, string(body))
+	}
+	s := api.BootstrapStatus{}
+	err = json.Unmarshal(body, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// rangeValues encodes a LogRange for itzo's log and file endpoints.
+// Older itzo builds ignore startLine and startByte and serve the tail
+// of the log instead.
+func rangeValues(lr LogRange) neturl.Values {
+	v := neturl.Values{}
+	if lr.Lines > 0 {
+		v.Set("lines", strconv.Itoa(lr.Lines))
+	}
+	if lr.Bytes > 0 {
+		v.Set("bytes", strconv.Itoa(lr.Bytes))
+	}
+	if lr.StartLine > 0 {
+		v.Set("startLine", strconv.Itoa(lr.StartLine))
+	}
+	if lr.StartByte > 0 {
+		v.Set("startByte", strconv.Itoa(lr.StartByte))
+	}
+	return v
+}
+
+func (c *ItzoClient) GetLogs(unit string, lr LogRange) ([]byte, error) {
 	url := c.baseURL + "rest/v1/logs/" + unit
-	if lines > 0 || bytes > 0 {
-		url = url + fmt.Sprintf("?lines=%d&bytes=%d", lines, bytes)
+	if qs := rangeValues(lr).Encode(); qs != "" {
+		url = url + "?" + qs
 	}
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.getWithRetry(url)
 	if err != nil {
 		klog.Errorf("Error getting logs from %s: %s", c.instanceIp, err)
 		return nil, err
@@ -318,20 +519,14 @@ func (c *ItzoClient) GetLogs(unit string, lines, bytes int) ([]byte, error) {
 	return body, nil
 }
 
-func (c *ItzoClient) GetFile(path string, lines, bytes int) ([]byte, error) {
-	v := neturl.Values{}
+func (c *ItzoClient) GetFile(path string, lr LogRange) ([]byte, error) {
+	v := rangeValues(lr)
 	v.Set("path", path)
-	if lines > 0 {
-		v.Set("lines", strconv.Itoa(lines))
-	}
-	if bytes > 0 {
-		v.Set("bytes", strconv.Itoa(bytes))
-	}
 	qs := v.Encode()
 	url := c.baseURL + "rest/v1/file/?" + qs
 
 	// Todo: combine with logs getter?
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.getWithRetry(url)
 	if err != nil {
 		klog.Errorf("Error getting file from %s: %s", c.instanceIp, err)
 		return nil, err
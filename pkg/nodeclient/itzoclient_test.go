@@ -18,9 +18,13 @@ package nodeclient
 
 import (
 	"crypto/tls"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/elotl/kip/pkg/api"
 )
 
 const okResponseBody = "123"
@@ -40,16 +44,40 @@ func setupClientServer(success bool) (*ItzoClient, *httptest.Server) {
 	} else {
 		s = httptest.NewTLSServer(http.HandlerFunc(ErrorResponse))
 	}
-	c := NewItzoClient("1.2.3.4", &tls.Config{})
+	c := NewItzoClient("1.2.3.4", &tls.Config{}, ItzoClientConfig{})
 	c.baseURL = s.URL + "/"
 	c.httpClient = s.Client()
 	return c, s
 }
 
+// flakyHandler drops the connection for the first failures requests
+// and answers normally afterwards.
+type flakyHandler struct {
+	failures int
+	requests int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests++
+	if h.requests <= h.failures {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			panic("response writer does not support hijacking")
+		}
+		conn, _, err := hj.Hijack()
+		if err != nil {
+			panic(err)
+		}
+		conn.Close()
+		return
+	}
+	w.Write([]byte(okResponseBody))
+}
+
 func TestGetLogsHappyPath(t *testing.T) {
 	c, s := setupClientServer(true)
 	defer s.Close()
-	logs, err := c.GetLogs("", 100, 0)
+	logs, err := c.GetLogs("", LogRange{Lines: 100})
 	if err != nil {
 		t.Errorf("Error on logs happy path: %v", err)
 	}
@@ -61,8 +89,130 @@ func TestGetLogsHappyPath(t *testing.T) {
 func TestGetLogsError(t *testing.T) {
 	c, s := setupClientServer(false)
 	defer s.Close()
-	_, err := c.GetLogs("", 100, 0)
+	_, err := c.GetLogs("", LogRange{Lines: 100})
 	if err == nil {
 		t.Errorf("Gettings logs error path did not return any errors")
 	}
 }
+
+func TestGetLogsRetriesTransientErrors(t *testing.T) {
+	h := &flakyHandler{failures: 2}
+	s := httptest.NewTLSServer(h)
+	defer s.Close()
+	c := NewItzoClient("1.2.3.4", &tls.Config{}, ItzoClientConfig{Retries: 2})
+	c.baseURL = s.URL + "/"
+	c.httpClient = s.Client()
+	logs, err := c.GetLogs("", LogRange{Lines: 100})
+	if err != nil {
+		t.Errorf("Error getting logs with retries: %v", err)
+	}
+	if string(logs) != okResponseBody {
+		t.Errorf("Expected response body to be %s, got %s", okResponseBody, logs)
+	}
+	if h.requests != 3 {
+		t.Errorf("Expected 3 attempts (2 failures + 1 success), got %d", h.requests)
+	}
+}
+
+func TestGetLogsRetryBudgetExhausted(t *testing.T) {
+	h := &flakyHandler{failures: 100}
+	s := httptest.NewTLSServer(h)
+	defer s.Close()
+	c := NewItzoClient("1.2.3.4", &tls.Config{}, ItzoClientConfig{Retries: 1})
+	c.baseURL = s.URL + "/"
+	c.httpClient = s.Client()
+	_, err := c.GetLogs("", LogRange{Lines: 100})
+	if err == nil {
+		t.Errorf("Expected an error after exhausting the retry budget")
+	}
+	if h.requests != 2 {
+		t.Errorf("Expected 2 attempts (1 try + 1 retry), got %d", h.requests)
+	}
+}
+
+func TestFatalErrorsAreNotRetried(t *testing.T) {
+	requests := 0
+	s := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(400)
+		}))
+	defer s.Close()
+	c := NewItzoClient("1.2.3.4", &tls.Config{}, ItzoClientConfig{Retries: 3})
+	c.baseURL = s.URL + "/"
+	c.httpClient = s.Client()
+	_, err := c.GetLogs("", LogRange{Lines: 100})
+	if err == nil {
+		t.Errorf("Expected an error from a 400 response")
+	}
+	if requests != 1 {
+		t.Errorf("Expected exactly 1 attempt for an HTTP error, got %d", requests)
+	}
+}
+
+func TestIsRetryableNodeError(t *testing.T) {
+	if IsRetryableNodeError(nil) {
+		t.Errorf("nil should not be retryable")
+	}
+	if IsRetryableNodeError(fmt.Errorf("Server responded with status code 500")) {
+		t.Errorf("HTTP status errors should not be retryable")
+	}
+	if !IsRetryableNodeError(io.EOF) {
+		t.Errorf("io.EOF should be retryable")
+	}
+	if !IsRetryableNodeError(fmt.Errorf("dial tcp 1.2.3.4:6421: connect: connection refused")) {
+		t.Errorf("connection refused should be retryable")
+	}
+}
+
+func TestGetAddressHostnameTemplate(t *testing.T) {
+	addresses := []api.NetworkAddress{
+		{Type: api.PublicIP, Address: "5.6.7.8"},
+		{Type: api.PrivateIP, Address: "10.0.0.1"},
+		{Type: api.PrivateDNS, Address: "ip-10-0-0-1.ec2.internal"},
+	}
+	testCases := []struct {
+		name            string
+		template        string
+		defaultToPublic bool
+		expected        string
+	}{
+		{
+			name:            "no template keeps the public IP default",
+			template:        "",
+			defaultToPublic: true,
+			expected:        "5.6.7.8",
+		},
+		{
+			name:     "no template keeps the private IP default",
+			template: "",
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "private DNS name",
+			template: "{privateDNS}",
+			expected: "ip-10-0-0-1.ec2.internal",
+		},
+		{
+			name:     "pattern built from the private IP",
+			template: "{privateIP}.cells.example.internal",
+			expected: "10.0.0.1.cells.example.internal",
+		},
+		{
+			name:            "missing placeholder value falls back to IP",
+			template:        "{publicDNS}",
+			defaultToPublic: true,
+			expected:        "5.6.7.8",
+		},
+	}
+	for _, tc := range testCases {
+		fac := &ItzoClientFactory{
+			defaultToPublicIP: tc.defaultToPublic,
+			config:            ItzoClientConfig{HostnameTemplate: tc.template},
+		}
+		address := fac.getAddress(addresses)
+		if address != tc.expected {
+			t.Errorf("%s: expected address %q, got %q", tc.name, tc.expected, address)
+		}
+	}
+}
@@ -32,10 +32,16 @@ func NewMockItzoClientFactory() *MockItzoClientFactory {
 		Health: func() error {
 			return nil
 		},
-		Logs: func(unit string, lines, bytes int) ([]byte, error) {
+		Version: func() (string, error) {
+			return "", nil
+		},
+		Bootstrap: func() (*api.BootstrapStatus, error) {
+			return &api.BootstrapStatus{Healthy: true}, nil
+		},
+		Logs: func(unit string, lr LogRange) ([]byte, error) {
 			return []byte("logs"), nil
 		},
-		File: func(unit string, lines, bytes int) ([]byte, error) {
+		File: func(unit string, lr LogRange) ([]byte, error) {
 			return []byte("file"), nil
 		},
 		Resize: func() error {
@@ -55,8 +61,10 @@ func NewMockItzoClientFactory() *MockItzoClientFactory {
 
 type MockItzoClientFactory struct {
 	Health        func() error
-	Logs          func(unit string, lines, bytes int) ([]byte, error)
-	File          func(unit string, lines, bytes int) ([]byte, error)
+	Version       func() (string, error)
+	Bootstrap     func() (*api.BootstrapStatus, error)
+	Logs          func(unit string, lr LogRange) ([]byte, error)
+	File          func(unit string, lr LogRange) ([]byte, error)
 	Resize        func() error
 	Status        func() (*api.PodStatusReply, error)
 	Update        func(pp api.PodParameters) error
@@ -80,12 +88,20 @@ func (a *MockItzoClientFactory) Healthcheck() error {
 	return a.Health()
 }
 
-func (a *MockItzoClientFactory) GetLogs(unit string, lines, bytes int) ([]byte, error) {
-	return a.Logs(unit, lines, bytes)
+func (a *MockItzoClientFactory) GetVersion() (string, error) {
+	return a.Version()
+}
+
+func (a *MockItzoClientFactory) GetBootstrapStatus() (*api.BootstrapStatus, error) {
+	return a.Bootstrap()
+}
+
+func (a *MockItzoClientFactory) GetLogs(unit string, lr LogRange) ([]byte, error) {
+	return a.Logs(unit, lr)
 }
 
-func (a *MockItzoClientFactory) GetFile(unit string, lines, bytes int) ([]byte, error) {
-	return a.Logs(unit, lines, bytes)
+func (a *MockItzoClientFactory) GetFile(unit string, lr LogRange) ([]byte, error) {
+	return a.Logs(unit, lr)
 }
 
 func (a *MockItzoClientFactory) ResizeVolume() error {
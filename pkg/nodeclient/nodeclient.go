@@ -22,10 +22,29 @@ import (
 	"github.com/elotl/kip/pkg/api"
 )
 
+// LogRange selects a portion of a log. StartLine and StartByte are
+// zero-based offsets from the beginning of the log, Lines and Bytes
+// limit how many lines or bytes are returned (0 means no limit).
+// With both offsets at zero, Lines and Bytes select the tail of the
+// log, matching the behavior clients have always gotten.
+type LogRange struct {
+	StartLine int
+	Lines     int
+	StartByte int
+	Bytes     int
+}
+
+// TailRange selects the last lines lines and bytes bytes of a log.
+func TailRange(lines, bytes int) LogRange {
+	return LogRange{Lines: lines, Bytes: bytes}
+}
+
 type NodeClient interface {
 	Healthcheck() error
-	GetLogs(unit string, lines, bytes int) ([]byte, error)
-	GetFile(filepath string, lines, bytes int) ([]byte, error)
+	GetVersion() (string, error)
+	GetBootstrapStatus() (*api.BootstrapStatus, error)
+	GetLogs(unit string, lr LogRange) ([]byte, error)
+	GetFile(filepath string, lr LogRange) ([]byte, error)
 	ResizeVolume() error
 	GetStatus() (*api.PodStatusReply, error)
 	UpdateUnits(api.PodParameters) error
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit keeps a bounded, in-memory trail of controller
+// decisions: why a pod was placed on a node, why a node was started or
+// stopped, why a launch will be retried.  Events cover what happened
+// to an object, the audit trail covers why the controllers did what
+// they did, which is the part that's hard to reconstruct from logs
+// when debugging scheduling and scaling behavior.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+const DefaultSize = 1000
+
+// Decisions recorded by the controllers.
+const (
+	DecisionBindPod      = "bind-pod"
+	DecisionFailPod      = "fail-pod"
+	DecisionStartNode    = "start-node"
+	DecisionStopNode     = "stop-node"
+	DecisionLaunchFailed = "launch-failed"
+)
+
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Controller string    `json:"controller"`
+	Object     string    `json:"object,omitempty"`
+	Decision   string    `json:"decision"`
+	Message    string    `json:"message"`
+}
+
+// Trail is a fixed-size ring of the most recent controller decisions.
+// If a file path was given at creation, every decision is also
+// appended to the file as a JSON line so the trail survives restarts
+// (the in-memory ring is not reloaded from the file).
+type Trail struct {
+	sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	file    *os.File
+}
+
+func NewTrail(size int, path string) (*Trail, error) {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	t := &Trail{entries: make([]Entry, size)}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, util.WrapError(err, "Error opening audit file %s", path)
+		}
+		t.file = f
+	}
+	return t, nil
+}
+
+// Record appends a decision to the trail.  Recording to a nil Trail is
+// a no-op so callers don't have to guard against audit being disabled.
+func (t *Trail) Record(controller, object, decision, message string) {
+	if t == nil {
+		return
+	}
+	e := Entry{
+		Time:       time.Now().UTC(),
+		Controller: controller,
+		Object:     object,
+		Decision:   decision,
+		Message:    message,
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.entries[t.next] = e
+	t.next++
+	if t.next == len(t.entries) {
+		t.next = 0
+		t.full = true
+	}
+	if t.file != nil {
+		b, err := json.Marshal(e)
+		if err == nil {
+			_, err = t.file.Write(append(b, '\n'))
+		}
+		if err != nil {
+			klog.Warningf("Error persisting audit entry, disabling audit persistence: %v", err)
+			t.file.Close()
+			t.file = nil
+		}
+	}
+}
+
+// Entries returns the recorded decisions in chronological order.  A
+// non-empty object restricts the result to decisions about that
+// object.
+func (t *Trail) Entries(object string) []Entry {
+	if t == nil {
+		return nil
+	}
+	t.Lock()
+	defer t.Unlock()
+	ordered := make([]Entry, 0, len(t.entries))
+	if t.full {
+		ordered = append(ordered, t.entries[t.next:]...)
+	}
+	ordered = append(ordered, t.entries[:t.next]...)
+	if object == "" {
+		return ordered
+	}
+	filtered := make([]Entry, 0, len(ordered))
+	for _, e := range ordered {
+		if e.Object == object {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
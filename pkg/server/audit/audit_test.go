@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrailBounded(t *testing.T) {
+	trail, err := NewTrail(3, "")
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		trail.Record("pod-controller", fmt.Sprintf("pod-%d", i),
+			DecisionBindPod, "msg")
+	}
+	entries := trail.Entries("")
+	assert.Len(t, entries, 3)
+	// The two oldest entries got dropped, order is chronological.
+	assert.Equal(t, "pod-2", entries[0].Object)
+	assert.Equal(t, "pod-4", entries[2].Object)
+}
+
+func TestTrailFilter(t *testing.T) {
+	trail, err := NewTrail(10, "")
+	assert.NoError(t, err)
+	trail.Record("pod-controller", "mypod", DecisionBindPod, "bound")
+	trail.Record("node-controller", "mynode", DecisionStartNode, "started")
+	trail.Record("pod-controller", "mypod", DecisionFailPod, "failed")
+	entries := trail.Entries("mypod")
+	assert.Len(t, entries, 2)
+	assert.Equal(t, DecisionBindPod, entries[0].Decision)
+	assert.Equal(t, DecisionFailPod, entries[1].Decision)
+}
+
+func TestTrailNil(t *testing.T) {
+	var trail *Trail
+	trail.Record("pod-controller", "mypod", DecisionBindPod, "bound")
+	assert.Nil(t, trail.Entries(""))
+}
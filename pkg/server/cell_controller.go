@@ -194,9 +194,9 @@ func (c *CellController) makeNodeCell(n *api.Node, p *api.Pod) *v1beta1.Cell {
 		kn.Name = n.Name
 		kn.Status.ControllerID = c.controllerID
 		kn.Status.Node = c.nodeName
-		kn.Status.LaunchType = "On-Demand"
+		kn.Status.LaunchType = v1beta1.LaunchTypeOnDemand
 		if n.Spec.Spot {
-			kn.Status.LaunchType = "Spot"
+			kn.Status.LaunchType = v1beta1.LaunchTypeSpot
 		}
 		kn.Status.InstanceType = n.Spec.InstanceType
 		kn.Status.InstanceID = n.Status.InstanceID
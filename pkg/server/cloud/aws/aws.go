@@ -44,20 +44,26 @@ var (
 )
 
 type AwsEC2 struct {
-	client               *ec2.EC2
-	elb                  *elb.ELB
-	ecs                  *ecs.ECS
-	ecsClusterName       string
-	controllerID         string
-	nametag              string
-	vpcID                string
-	vpcCIDR              string
-	subnetID             string
-	availabilityZone     string
-	usePublicIPs         bool
-	region               string
-	bootSecurityGroupIDs []string
-	cloudStatus          *cloud.LinkedAZSubnetStatus
+	client                *ec2.EC2
+	elb                   *elb.ELB
+	ecs                   *ecs.ECS
+	ecsClusterName        string
+	controllerID          string
+	nametag               string
+	vpcID                 string
+	vpcCIDR               string
+	subnetID              string
+	availabilityZone      string
+	usePublicIPs          bool
+	region                string
+	bootSecurityGroupIDs  []string
+	cloudStatus           *cloud.LinkedAZSubnetStatus
+	launchTemplateID      string
+	launchTemplateVersion string
+	imdsv2Required        bool
+	imdsv2HopLimit        int
+	defaultTenancy        string
+	defaultHostID         string
 }
 
 func getEC2Client() (*ec2.EC2, error) {
@@ -132,6 +138,23 @@ type EC2ClientConfig struct {
 	SubnetID       string
 	ECSClusterName string
 	PrivateIPOnly  bool
+	// LaunchTemplateID optionally references an existing EC2 launch
+	// template supplying the AMI, networking and IMDS settings for
+	// cells.  LaunchTemplateVersion can be a version number,
+	// "$Latest" or "$Default"; empty means "$Default".
+	LaunchTemplateID      string
+	LaunchTemplateVersion string
+	// IMDSv2Required launches cells with IMDSv2 enforced (session
+	// tokens required, IMDSv1 disabled).  IMDSv2HopLimit sets the
+	// metadata PUT response hop limit; zero keeps the AWS default.
+	IMDSv2Required bool
+	IMDSv2HopLimit int
+	// Tenancy is the default instance tenancy for cells: "default",
+	// "dedicated" or "host".  Pods can override it with the tenancy
+	// annotation.  DedicatedHostID optionally pins host tenancy
+	// cells to a specific dedicated host.
+	Tenancy         string
+	DedicatedHostID string
 }
 
 // Parsing our server.json configuration should have put all confg
@@ -177,6 +200,25 @@ func NewEC2Client(config EC2ClientConfig) (*AwsEC2, error) {
 			return nil, util.WrapError(err, "Could not detect current subnet from metadata service. Please supply an AWS subnet id in provider.yaml")
 		}
 	}
+	client.imdsv2Required = config.IMDSv2Required
+	client.imdsv2HopLimit = config.IMDSv2HopLimit
+	client.launchTemplateID = config.LaunchTemplateID
+	client.launchTemplateVersion = config.LaunchTemplateVersion
+	if client.launchTemplateID != "" {
+		if client.launchTemplateVersion == "" {
+			client.launchTemplateVersion = defaultLaunchTemplateVersion
+		}
+		if err := client.validateLaunchTemplate(); err != nil {
+			return nil, util.WrapError(err, "Error validating launch template")
+		}
+	}
+	client.defaultTenancy = config.Tenancy
+	client.defaultHostID = config.DedicatedHostID
+	if client.defaultHostID != "" {
+		if err := client.validateDedicatedHost(client.defaultHostID); err != nil {
+			return nil, util.WrapError(err, "Error validating dedicated host")
+		}
+	}
 	client.region = os.Getenv("AWS_REGION")
 	client.cloudStatus, err = cloud.NewLinkedAZSubnetStatus(client)
 	if err != nil {
@@ -0,0 +1,285 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+const (
+	defaultDataVolumeType = "gp2"
+	minDataVolumeSizeGiB  = 1
+	maxDataVolumeSizeGiB  = 16384
+	dataVolumeTimeout     = 60 * time.Second
+)
+
+var awsDataVolumeTypes = sets.NewString(
+	"gp2", "gp3", "io1", "io2", "st1", "sc1", "standard")
+
+func (e *AwsEC2) ValidateDataVolumes(volumes []api.DataVolumeSpec) error {
+	for _, vol := range volumes {
+		if vol.Name == "" {
+			return fmt.Errorf("data volumes must have a name")
+		}
+		if vol.MountPath == "" {
+			return fmt.Errorf("data volume %s must have a mountPath", vol.Name)
+		}
+		sizeGiB, err := parseDataVolumeSize(vol)
+		if err != nil {
+			return err
+		}
+		if sizeGiB < minDataVolumeSizeGiB || sizeGiB > maxDataVolumeSizeGiB {
+			return fmt.Errorf("data volume %s size must be between %dGiB and %dGiB",
+				vol.Name, minDataVolumeSizeGiB, maxDataVolumeSizeGiB)
+		}
+		if vol.Type != "" && !awsDataVolumeTypes.Has(vol.Type) {
+			return fmt.Errorf("data volume %s has unknown volume type %s, supported types: %v",
+				vol.Name, vol.Type, awsDataVolumeTypes.List())
+		}
+	}
+	return nil
+}
+
+func parseDataVolumeSize(vol api.DataVolumeSpec) (int64, error) {
+	size, err := resource.ParseQuantity(vol.Size)
+	if err != nil {
+		return 0, util.WrapError(err, "Could not parse size of data volume %s", vol.Name)
+	}
+	return int64(util.ToGiBRoundUp(&size)), nil
+}
+
+// Data volumes are attached after the root device, starting at
+// /dev/sdf, the device range AWS recommends for EBS data volumes.
+func dataVolumeDeviceName(index int) string {
+	return fmt.Sprintf("/dev/sd%c", 'f'+index)
+}
+
+func (e *AwsEC2) getInstanceAZ(instanceID string) (string, error) {
+	result, err := e.client.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return "", util.WrapError(err, "Error describing instance %s", instanceID)
+	}
+	if len(result.Reservations) == 0 ||
+		len(result.Reservations[0].Instances) == 0 {
+		return "", fmt.Errorf("Could not find instance %s", instanceID)
+	}
+	placement := result.Reservations[0].Instances[0].Placement
+	if placement == nil || placement.AvailabilityZone == nil {
+		return "", fmt.Errorf("Could not get availability zone of instance %s", instanceID)
+	}
+	return *placement.AvailabilityZone, nil
+}
+
+func (e *AwsEC2) AttachDataVolumes(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error) {
+	if len(volumes) == 0 {
+		return nil, nil
+	}
+	if err := e.ValidateDataVolumes(volumes); err != nil {
+		return nil, err
+	}
+	az, err := e.getInstanceAZ(node.Status.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	volumeIDs := make([]string, 0, len(volumes))
+	for i, vol := range volumes {
+		sizeGiB, _ := parseDataVolumeSize(vol)
+		volType := vol.Type
+		if volType == "" {
+			volType = defaultDataVolumeType
+		}
+		result, err := e.client.CreateVolume(&ec2.CreateVolumeInput{
+			AvailabilityZone: aws.String(az),
+			Size:             aws.Int64(sizeGiB),
+			VolumeType:       aws.String(volType),
+			TagSpecifications: []*ec2.TagSpecification{{
+				ResourceType: aws.String(ec2.ResourceTypeVolume),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("Name"),
+						Value: aws.String(node.Name + "-" + vol.Name),
+					},
+					{
+						Key:   aws.String(cloud.ControllerTagKey),
+						Value: aws.String(e.controllerID),
+					},
+					{
+						Key:   aws.String(cloud.NametagTagKey),
+						Value: aws.String(e.nametag),
+					},
+				},
+			}},
+		})
+		if err != nil {
+			e.cleanupDataVolumes(volumeIDs)
+			return nil, util.WrapError(err, "Error creating data volume %s for node %s", vol.Name, node.Name)
+		}
+		volumeID := aws.StringValue(result.VolumeId)
+		volumeIDs = append(volumeIDs, volumeID)
+		err = e.waitForVolumeState(volumeID, ec2.VolumeStateAvailable)
+		if err == nil {
+			_, err = e.client.AttachVolume(&ec2.AttachVolumeInput{
+				Device:     aws.String(dataVolumeDeviceName(i)),
+				InstanceId: aws.String(node.Status.InstanceID),
+				VolumeId:   aws.String(volumeID),
+			})
+		}
+		if err != nil {
+			e.cleanupDataVolumes(volumeIDs)
+			return nil, util.WrapError(err, "Error attaching data volume %s to node %s", vol.Name, node.Name)
+		}
+	}
+	return volumeIDs, nil
+}
+
+func (e *AwsEC2) describeDataVolume(volumeID string) (*ec2.Volume, error) {
+	result, err := e.client.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws.String(volumeID)},
+	})
+	if err != nil {
+		return nil, util.WrapError(err, "Error describing volume %s", volumeID)
+	}
+	if len(result.Volumes) == 0 {
+		return nil, fmt.Errorf("Could not find volume %s", volumeID)
+	}
+	return result.Volumes[0], nil
+}
+
+func (e *AwsEC2) GetDataVolumeAZ(volumeID string) (string, error) {
+	vol, err := e.describeDataVolume(volumeID)
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(vol.AvailabilityZone), nil
+}
+
+func (e *AwsEC2) ReattachDataVolumes(node *api.Node, volumeIDs []string) error {
+	for i, volumeID := range volumeIDs {
+		vol, err := e.describeDataVolume(volumeID)
+		if err != nil {
+			return err
+		}
+		attachedTo := ""
+		for _, att := range vol.Attachments {
+			if aws.StringValue(att.State) != ec2.VolumeAttachmentStateDetached {
+				attachedTo = aws.StringValue(att.InstanceId)
+			}
+		}
+		if attachedTo == node.Status.InstanceID {
+			continue
+		}
+		if attachedTo != "" {
+			klog.V(2).Infof("Detaching volume %s from instance %s",
+				volumeID, attachedTo)
+			_, err = e.client.DetachVolume(&ec2.DetachVolumeInput{
+				VolumeId: aws.String(volumeID),
+			})
+			if err != nil {
+				return util.WrapError(err, "Error detaching volume %s from instance %s",
+					volumeID, attachedTo)
+			}
+		}
+		err = e.waitForVolumeState(volumeID, ec2.VolumeStateAvailable)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.AttachVolume(&ec2.AttachVolumeInput{
+			Device:     aws.String(dataVolumeDeviceName(i)),
+			InstanceId: aws.String(node.Status.InstanceID),
+			VolumeId:   aws.String(volumeID),
+		})
+		if err != nil {
+			return util.WrapError(err, "Error attaching volume %s to node %s",
+				volumeID, node.Name)
+		}
+		err = e.waitForVolumeState(volumeID, ec2.VolumeStateInUse)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *AwsEC2) waitForVolumeState(volumeID, state string) error {
+	timeout := time.Now().Add(dataVolumeTimeout)
+	for time.Now().Before(timeout) {
+		result, err := e.client.DescribeVolumes(&ec2.DescribeVolumesInput{
+			VolumeIds: []*string{aws.String(volumeID)},
+		})
+		if err == nil &&
+			len(result.Volumes) > 0 &&
+			aws.StringValue(result.Volumes[0].State) == state {
+			return nil
+		}
+		time.Sleep(1 * time.Second)
+	}
+	return fmt.Errorf("Timed out waiting for volume %s to become %s", volumeID, state)
+}
+
+// cleanupDataVolumes rolls back volumes created during a failed
+// AttachDataVolumes call, it only logs errors since we're already on
+// an error path.
+func (e *AwsEC2) cleanupDataVolumes(volumeIDs []string) {
+	if len(volumeIDs) == 0 {
+		return
+	}
+	if err := e.DeleteDataVolumes(volumeIDs); err != nil {
+		klog.Errorf("Error cleaning up data volumes %v: %v", volumeIDs, err)
+	}
+}
+
+func (e *AwsEC2) DeleteDataVolumes(volumeIDs []string) error {
+	var lastErr error
+	for _, volumeID := range volumeIDs {
+		// Detach errors are expected when the volume was never
+		// attached or the instance is already gone.
+		_, err := e.client.DetachVolume(&ec2.DetachVolumeInput{
+			VolumeId: aws.String(volumeID),
+		})
+		if err != nil {
+			klog.V(3).Infof("Could not detach volume %s (possibly not attached): %v",
+				volumeID, err)
+		}
+		err = e.waitForVolumeState(volumeID, ec2.VolumeStateAvailable)
+		if err != nil {
+			klog.Warningf("Error waiting for volume %s to detach: %v", volumeID, err)
+		}
+		_, err = e.client.DeleteVolume(&ec2.DeleteVolumeInput{
+			VolumeId: aws.String(volumeID),
+		})
+		if err != nil {
+			klog.Errorf("Error deleting data volume %s: %v", volumeID, err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return util.WrapError(lastErr, "Error deleting data volumes")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDataVolumes(t *testing.T) {
+	e := &AwsEC2{}
+	testCases := []struct {
+		name    string
+		volumes []api.DataVolumeSpec
+		isError bool
+	}{
+		{
+			name:    "no volumes",
+			volumes: nil,
+			isError: false,
+		},
+		{
+			name: "valid volume",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "100Gi", Type: "gp2", MountPath: "/scratch"},
+			},
+			isError: false,
+		},
+		{
+			name: "default type",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "10Gi", MountPath: "/scratch"},
+			},
+			isError: false,
+		},
+		{
+			name: "missing name",
+			volumes: []api.DataVolumeSpec{
+				{Size: "10Gi", MountPath: "/scratch"},
+			},
+			isError: true,
+		},
+		{
+			name: "missing mount path",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "10Gi"},
+			},
+			isError: true,
+		},
+		{
+			name: "bad size",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "lots", MountPath: "/scratch"},
+			},
+			isError: true,
+		},
+		{
+			name: "size too large",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "20000Gi", MountPath: "/scratch"},
+			},
+			isError: true,
+		},
+		{
+			name: "unknown type",
+			volumes: []api.DataVolumeSpec{
+				{Name: "scratch", Size: "10Gi", Type: "floppy", MountPath: "/scratch"},
+			},
+			isError: true,
+		},
+	}
+	for _, tc := range testCases {
+		err := e.ValidateDataVolumes(tc.volumes)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestDataVolumeDeviceName(t *testing.T) {
+	assert.Equal(t, "/dev/sdf", dataVolumeDeviceName(0))
+	assert.Equal(t, "/dev/sdh", dataVolumeDeviceName(2))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
+)
+
+// podSecurityGroupName creates the deterministic name of the pod's
+// ephemeral egress security group so we can find it again at
+// teardown without storing the group ID.
+func (e *AwsEC2) podSecurityGroupName(podName string) string {
+	name := strings.Replace(podName, "_", "-", -1)
+	return util.CreateSecurityGroupName(e.controllerID, "pod-"+name)
+}
+
+func (e *AwsEC2) EnsurePodSecurityGroup(podName string, egress []cloud.EgressRule) (string, error) {
+	if err := cloud.ValidateEgressRules(egress); err != nil {
+		return "", err
+	}
+	sgName := e.podSecurityGroupName(podName)
+	sg, err := e.FindSecurityGroup(sgName)
+	if err != nil {
+		return "", util.WrapError(err, "Error finding pod security group")
+	}
+	if sg != nil {
+		return sg.ID, nil
+	}
+	createRes, err := e.client.CreateSecurityGroup(&ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(sgName),
+		Description: aws.String(fmt.Sprintf("MilpaSG %s %s", e.nametag, sgName)),
+		VpcId:       aws.String(e.vpcID),
+	})
+	if err != nil {
+		return "", util.WrapError(err, "Could not create pod security group")
+	}
+	groupID := aws.StringValue(createRes.GroupId)
+	err = util.Retry(
+		90*time.Second,
+		func() error { return e.tagSecurityGroup(sgName, groupID) },
+		func(err error) bool {
+			return strings.HasPrefix(err.Error(), "InvalidGroup.NotFound")
+		})
+	if err != nil {
+		_ = e.DeleteSecurityGroup(groupID)
+		return "", util.WrapError(err, "Could not tag pod security group")
+	}
+	// New groups allow all egress by default, revoke that rule
+	// before opening up the allowed destinations.
+	_, err = e.client.RevokeSecurityGroupEgress(&ec2.RevokeSecurityGroupEgressInput{
+		GroupId: aws.String(groupID),
+		IpPermissions: []*ec2.IpPermission{
+			{
+				IpProtocol: aws.String("-1"),
+				IpRanges: []*ec2.IpRange{
+					{CidrIp: aws.String("0.0.0.0/0")},
+				},
+			},
+		},
+	})
+	if err != nil {
+		_ = e.DeleteSecurityGroup(groupID)
+		return "", util.WrapError(
+			err, "Could not remove the default egress rule from pod security group %s", sgName)
+	}
+	if len(egress) > 0 {
+		err = util.Retry(
+			45*time.Second,
+			func() error {
+				_, err := e.client.AuthorizeSecurityGroupEgress(
+					&ec2.AuthorizeSecurityGroupEgressInput{
+						GroupId:       aws.String(groupID),
+						IpPermissions: makeEgressIPPermissions(egress),
+					})
+				return err
+			},
+			func(err error) bool {
+				return strings.HasPrefix(err.Error(), "InvalidGroup.NotFound")
+			})
+		if err != nil {
+			_ = e.DeleteSecurityGroup(groupID)
+			return "", util.WrapError(
+				err, "Unable to set security group %s egress", sgName)
+		}
+	}
+	return groupID, nil
+}
+
+func (e *AwsEC2) DeletePodSecurityGroup(podName string) error {
+	sgName := e.podSecurityGroupName(podName)
+	sg, err := e.FindSecurityGroup(sgName)
+	if err != nil {
+		return util.WrapError(err, "Error finding pod security group")
+	}
+	if sg == nil {
+		return nil
+	}
+	// The group can't be deleted while the instance it was attached
+	// to is still shutting down, retry on dependency errors.
+	return util.Retry(
+		60*time.Second,
+		func() error { return e.DeleteSecurityGroup(sg.ID) },
+		func(err error) bool {
+			return strings.Contains(err.Error(), "DependencyViolation")
+		})
+}
+
+func makeEgressIPPermissions(rules []cloud.EgressRule) []*ec2.IpPermission {
+	ingressLike := make([]cloud.IngressRule, len(rules))
+	for i, rule := range rules {
+		ingressLike[i] = cloud.IngressRule{
+			Port:          rule.Port,
+			PortRangeSize: rule.PortRangeSize,
+			Protocol:      rule.Protocol,
+			Source:        rule.CIDR,
+		}
+	}
+	return makeIPPermissions(ingressLike)
+}
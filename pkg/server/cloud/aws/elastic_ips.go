@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+// findAddress looks up a pre-allocated elastic IP by public IP
+// address or allocation ID (eipalloc-...).
+func (e *AwsEC2) findAddress(eip string) (*ec2.Address, error) {
+	input := &ec2.DescribeAddressesInput{}
+	if strings.HasPrefix(eip, "eipalloc-") {
+		input.AllocationIds = []*string{aws.String(eip)}
+	} else {
+		input.PublicIps = []*string{aws.String(eip)}
+	}
+	out, err := e.client.DescribeAddresses(input)
+	if err != nil {
+		return nil, util.WrapError(err, "Error looking up elastic IP %s", eip)
+	}
+	if len(out.Addresses) != 1 {
+		return nil, fmt.Errorf("elastic IP %s is not allocated in this account and region", eip)
+	}
+	return out.Addresses[0], nil
+}
+
+// instanceOwnedByController reports whether the instance is a cell
+// managed by this controller.
+func (e *AwsEC2) instanceOwnedByController(instanceID string) (bool, error) {
+	filters := []*ec2.Filter{
+		{
+			Name:   aws.String("instance-id"),
+			Values: []*string{aws.String(instanceID)},
+		},
+		{
+			Name:   aws.String(fmt.Sprintf("tag:%s", cloud.ControllerTagKey)),
+			Values: []*string{aws.String(e.controllerID)},
+		},
+	}
+	instances, err := e.listInstancesHelper(filters)
+	if err != nil {
+		return false, err
+	}
+	return len(instances) == 1, nil
+}
+
+func (e *AwsEC2) AssociateElasticIP(node *api.Node, eip string) error {
+	addr, err := e.findAddress(eip)
+	if err != nil {
+		return err
+	}
+	attachedTo := aws.StringValue(addr.InstanceId)
+	if attachedTo == node.Status.InstanceID {
+		return nil
+	}
+	allowReassociation := false
+	if attachedTo != "" {
+		// The address can still be held by the pod's previous cell
+		// while that cell shuts down; moving it between our own
+		// instances is how the address follows a rescheduled pod.
+		// Refuse to steal it from an instance we don't manage.
+		owned, err := e.instanceOwnedByController(attachedTo)
+		if err != nil {
+			return util.WrapError(err, "Error checking current holder of elastic IP %s", eip)
+		}
+		if !owned {
+			return fmt.Errorf("elastic IP %s is already associated with instance %s, which is not managed by this controller", eip, attachedTo)
+		}
+		allowReassociation = true
+	}
+	_, err = e.client.AssociateAddress(&ec2.AssociateAddressInput{
+		AllocationId:       addr.AllocationId,
+		InstanceId:         aws.String(node.Status.InstanceID),
+		AllowReassociation: aws.Bool(allowReassociation),
+	})
+	if err != nil {
+		return util.WrapError(err, "Error associating elastic IP %s with node %s", eip, node.Name)
+	}
+	klog.V(2).Infof("Associated elastic IP %s with node %s", eip, node.Name)
+	return nil
+}
+
+func (e *AwsEC2) DisassociateElasticIP(eip string) error {
+	addr, err := e.findAddress(eip)
+	if err != nil {
+		return err
+	}
+	if aws.StringValue(addr.AssociationId) == "" {
+		return nil
+	}
+	_, err = e.client.DisassociateAddress(&ec2.DisassociateAddressInput{
+		AssociationId: addr.AssociationId,
+	})
+	if err != nil {
+		return util.WrapError(err, "Error disassociating elastic IP %s", eip)
+	}
+	return nil
+}
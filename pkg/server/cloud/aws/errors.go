@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
+)
+
+// mapAWSError converts an EC2 API error into one of the cloud
+// package's typed errors when its error code identifies a known
+// class (throttling, auth, quota, missing resource), so controllers
+// can branch on the class instead of parsing AWS messages.  Errors
+// with other codes are wrapped with the given message like
+// util.WrapError.
+func mapAWSError(err error, format string, args ...interface{}) error {
+	if awsErr, ok := err.(awserr.Error); ok {
+		code := awsErr.Code()
+		switch code {
+		case "RequestLimitExceeded", "Throttling", "ThrottlingException",
+			"RequestThrottled", "RequestThrottledException":
+			return &cloud.ThrottledError{OriginalError: err.Error()}
+		case "AuthFailure", "UnauthorizedOperation", "Blocked",
+			"OptInRequired", "InvalidClientTokenId", "SignatureDoesNotMatch",
+			"ExpiredToken", "ExpiredTokenException":
+			return &cloud.AuthError{OriginalError: err.Error()}
+		case "InstanceLimitExceeded", "MaxSpotInstanceCountExceeded",
+			"VcpuLimitExceeded", "VolumeLimitExceeded",
+			"AddressLimitExceeded":
+			return &cloud.QuotaError{OriginalError: err.Error()}
+		}
+		if strings.HasSuffix(code, ".NotFound") {
+			// e.g. InvalidInstanceID.NotFound, InvalidAMIID.NotFound
+			return &cloud.NotFoundError{OriginalError: err.Error()}
+		}
+	}
+	return util.WrapError(err, format, args...)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapAWSError(t *testing.T) {
+	tests := []struct {
+		code    string
+		checker func(error) bool
+	}{
+		{"RequestLimitExceeded", cloud.IsThrottledError},
+		{"Throttling", cloud.IsThrottledError},
+		{"AuthFailure", cloud.IsAuthError},
+		{"UnauthorizedOperation", cloud.IsAuthError},
+		{"ExpiredToken", cloud.IsAuthError},
+		{"InstanceLimitExceeded", cloud.IsQuotaError},
+		{"MaxSpotInstanceCountExceeded", cloud.IsQuotaError},
+		{"InvalidInstanceID.NotFound", cloud.IsNotFoundError},
+		{"InvalidAMIID.NotFound", cloud.IsNotFoundError},
+	}
+	for i, tc := range tests {
+		err := mapAWSError(awserr.New(tc.code, "boom", nil), "wrapped")
+		assert.True(t, tc.checker(err), "case %d: %s mapped to %T", i, tc.code, err)
+	}
+
+	// unknown codes and non-AWS errors get wrapped with the message
+	err := mapAWSError(awserr.New("DryRunOperation", "boom", nil), "wrapped %s", "here")
+	assert.Contains(t, err.Error(), "wrapped here")
+	err = mapAWSError(fmt.Errorf("plain error"), "wrapped")
+	assert.Contains(t, err.Error(), "wrapped")
+}
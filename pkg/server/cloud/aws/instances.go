@@ -18,6 +18,7 @@ package aws
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -50,7 +51,37 @@ func (e *AwsEC2) StopInstance(instanceID string) error {
 		// todo, check on status of instance, set status of instance
 		// based on that, prepare to come back and clean this
 		// inconsistency up
-		return err
+		return mapAWSError(err, "Error terminating instance %s", instanceID)
+	}
+	return nil
+}
+
+func (e *AwsEC2) HaltInstance(instanceID string) error {
+	_, err := e.client.StopInstances(&ec2.StopInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return mapAWSError(err, "Error stopping instance %s", instanceID)
+	}
+	return nil
+}
+
+func (e *AwsEC2) ResumeInstance(instanceID string) error {
+	_, err := e.client.StartInstances(&ec2.StartInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return mapAWSError(err, "Error starting stopped instance %s", instanceID)
+	}
+	return nil
+}
+
+func (e *AwsEC2) RebootInstance(instanceID string) error {
+	_, err := e.client.RebootInstances(&ec2.RebootInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return mapAWSError(err, "Error rebooting instance %s", instanceID)
 	}
 	return nil
 }
@@ -78,21 +109,32 @@ func (e *AwsEC2) getNodeTags(node *api.Node) []*ec2.Tag {
 	return tags
 }
 
-func (e *AwsEC2) getBlockDeviceMapping(image cloud.Image, volSizeGiB int32) []*ec2.BlockDeviceMapping {
+func (e *AwsEC2) getBlockDeviceMapping(image cloud.Image, volSizeGiB int32, resources api.ResourceSpec) []*ec2.BlockDeviceMapping {
 	awsVolSize := aws.Int64(int64(volSizeGiB))
+	ebs := &ec2.EbsBlockDevice{
+		VolumeType:          aws.String("gp2"),
+		DeleteOnTermination: aws.Bool(true),
+		VolumeSize:          awsVolSize,
+	}
+	// A customer managed key implies encryption; leaving Encrypted
+	// unset falls back to the account's encryption-by-default
+	// setting.
+	if resources.VolumeKMSKey != "" {
+		ebs.Encrypted = aws.Bool(true)
+		ebs.KmsKeyId = aws.String(resources.VolumeKMSKey)
+	} else if resources.VolumeEncrypted != nil {
+		ebs.Encrypted = aws.Bool(*resources.VolumeEncrypted)
+	}
 	devices := []*ec2.BlockDeviceMapping{
 		&ec2.BlockDeviceMapping{
 			DeviceName: aws.String(image.RootDevice),
-			Ebs: &ec2.EbsBlockDevice{
-				VolumeType:          aws.String("gp2"),
-				DeleteOnTermination: aws.Bool(true),
-				VolumeSize:          awsVolSize,
-			}},
+			Ebs:        ebs,
+		},
 	}
 	return devices
 }
 
-func (e *AwsEC2) getInstanceNetworkSpec(privateIPOnly bool) []*ec2.InstanceNetworkInterfaceSpecification {
+func (e *AwsEC2) getInstanceNetworkSpec(privateIPOnly bool, subnetID string) []*ec2.InstanceNetworkInterfaceSpecification {
 	associatePublicIPAddress := true
 	if privateIPOnly || !e.usePublicIPs {
 		associatePublicIPAddress = false
@@ -106,10 +148,130 @@ func (e *AwsEC2) getInstanceNetworkSpec(privateIPOnly bool) []*ec2.InstanceNetwo
 		},
 	}
 	// Let AWS figure out the subnet/AZ if we didn't specify a subnet
-	networkSpec[0].SubnetId = aws.String(e.subnetID)
+	networkSpec[0].SubnetId = aws.String(subnetID)
 	return networkSpec
 }
 
+// nodeSubnetID returns the subnet the node's instance will be
+// launched into: the subnet pinned in the node's placement spec if
+// present, otherwise the client's configured subnet.
+func (e *AwsEC2) nodeSubnetID(node *api.Node) string {
+	if node.Spec.Placement.SubnetID != "" {
+		return node.Spec.Placement.SubnetID
+	}
+	return e.subnetID
+}
+
+// ValidatePlacement checks a pod's tenancy settings at creation time.
+// EC2 only runs spot instances on shared hardware and a dedicated
+// host ID only makes sense with host tenancy.
+func (e *AwsEC2) ValidatePlacement(placement *api.PlacementSpec, spot bool) error {
+	tenancy := placement.Tenancy
+	switch tenancy {
+	case "", api.TenancyDefault, api.TenancyDedicated, api.TenancyHost:
+	default:
+		return fmt.Errorf("unknown tenancy %q, must be %q, %q or %q",
+			tenancy, api.TenancyDefault, api.TenancyDedicated, api.TenancyHost)
+	}
+	if tenancy == "" {
+		tenancy = e.defaultTenancy
+	}
+	if placement.DedicatedHostID != "" {
+		if tenancy != api.TenancyHost {
+			return fmt.Errorf("a dedicated host ID requires %q tenancy",
+				api.TenancyHost)
+		}
+		if !strings.HasPrefix(placement.DedicatedHostID, "h-") {
+			return fmt.Errorf("invalid dedicated host ID %q",
+				placement.DedicatedHostID)
+		}
+	}
+	if spot && tenancy != "" && tenancy != api.TenancyDefault {
+		return fmt.Errorf("spot pods can only use %q tenancy",
+			api.TenancyDefault)
+	}
+	return nil
+}
+
+// kmsKeyIDRegexp matches the bare key ID form of a KMS key.
+var kmsKeyIDRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ValidateVolumeEncryption checks a pod's root volume encryption
+// settings at creation time.  EC2 can encrypt any gp2 root volume, so
+// only the key reference is checked here: a key ID, an "alias/" name
+// or a full key ARN.  Whether the key exists and is usable is
+// enforced by EC2 at launch (the controller doesn't talk to the KMS
+// API); a bad key fails the launch and the error is surfaced on the
+// pod.
+func (e *AwsEC2) ValidateVolumeEncryption(encrypted *bool, kmsKeyID string) error {
+	if kmsKeyID == "" {
+		return nil
+	}
+	if encrypted != nil && !*encrypted {
+		return fmt.Errorf("a KMS key cannot be combined with volume encryption disabled")
+	}
+	if strings.HasPrefix(kmsKeyID, "alias/") ||
+		strings.HasPrefix(kmsKeyID, "arn:") ||
+		kmsKeyIDRegexp.MatchString(kmsKeyID) {
+		return nil
+	}
+	return fmt.Errorf("KMS key %q must be a key ID, an \"alias/\" name or a key ARN", kmsKeyID)
+}
+
+func (e *AwsEC2) ValidateVMSecurity(shieldedVM, confidentialVM *bool, instanceType string) error {
+	if shieldedVM == nil && confidentialVM == nil {
+		return nil
+	}
+	return fmt.Errorf("shielded and confidential VM settings are not implemented for aws")
+}
+
+// validateDedicatedHost checks that a dedicated host exists and can
+// accept instances before we start launching cells onto it.
+func (e *AwsEC2) validateDedicatedHost(hostID string) error {
+	result, err := e.client.DescribeHosts(&ec2.DescribeHostsInput{
+		HostIds: []*string{aws.String(hostID)},
+	})
+	if err != nil {
+		return util.WrapError(err, "Could not describe dedicated host %s", hostID)
+	}
+	if len(result.Hosts) == 0 {
+		return fmt.Errorf("dedicated host %s not found", hostID)
+	}
+	state := aws.StringValue(result.Hosts[0].State)
+	if state != ec2.AllocationStateAvailable {
+		return fmt.Errorf("dedicated host %s is %s, not %s",
+			hostID, state, ec2.AllocationStateAvailable)
+	}
+	return nil
+}
+
+// nodePlacement builds the EC2 placement for a node's instance from
+// its tenancy settings, falling back to the client-wide defaults.
+// Returns nil when everything is default so the RunInstances request
+// is unchanged.
+func (e *AwsEC2) nodePlacement(node *api.Node) *ec2.Placement {
+	tenancy := node.Spec.Placement.Tenancy
+	if tenancy == "" {
+		tenancy = e.defaultTenancy
+	}
+	if tenancy == "" || tenancy == api.TenancyDefault {
+		return nil
+	}
+	placement := &ec2.Placement{
+		Tenancy: aws.String(tenancy),
+	}
+	if tenancy == api.TenancyHost {
+		hostID := node.Spec.Placement.DedicatedHostID
+		if hostID == "" {
+			hostID = e.defaultHostID
+		}
+		if hostID != "" {
+			placement.HostId = aws.String(hostID)
+		}
+	}
+	return placement
+}
+
 func (e *AwsEC2) getFirstVolume(instanceId string) *ec2.Volume {
 	input := &ec2.DescribeVolumesInput{
 		Filters: []*ec2.Filter{
@@ -267,6 +429,27 @@ func (e *AwsEC2) GetImage(spec cloud.BootImageSpec) (cloud.Image, error) {
 	return images[len(images)-1], nil
 }
 
+// metadataOptions returns the instance metadata options for new
+// cells, or nil to keep the EC2 defaults.  With IMDSv2 required,
+// metadata requests must carry a session token and IMDSv1 is
+// disabled; itzo uses token-based metadata requests so cells keep
+// working.  The default hop limit of 1 stops containerized workloads
+// on the cell from reaching the metadata service, set the hop limit
+// to 2 if units need metadata access.
+func (e *AwsEC2) metadataOptions() *ec2.InstanceMetadataOptionsRequest {
+	if !e.imdsv2Required {
+		return nil
+	}
+	opts := &ec2.InstanceMetadataOptionsRequest{
+		HttpEndpoint: aws.String(ec2.InstanceMetadataEndpointStateEnabled),
+		HttpTokens:   aws.String(ec2.HttpTokensStateRequired),
+	}
+	if e.imdsv2HopLimit > 0 {
+		opts.HttpPutResponseHopLimit = aws.Int64(int64(e.imdsv2HopLimit))
+	}
+	return opts
+}
+
 func (e *AwsEC2) StartNode(node *api.Node, image cloud.Image, metadata string) (*cloud.StartNodeResult, error) {
 	klog.V(2).Infof("Starting instance for node: %v", node)
 	tags := e.getNodeTags(node)
@@ -275,11 +458,12 @@ func (e *AwsEC2) StartNode(node *api.Node, image cloud.Image, metadata string) (
 		Tags:         tags,
 	}
 	volSizeGiB := cloud.ToSaneVolumeSize(node.Spec.Resources.VolumeSize)
-	devices := e.getBlockDeviceMapping(image, volSizeGiB)
-	networkSpec := e.getInstanceNetworkSpec(node.Spec.Resources.PrivateIPOnly)
+	devices := e.getBlockDeviceMapping(image, volSizeGiB, node.Spec.Resources)
+	subnetID := e.nodeSubnetID(node)
+	networkSpec := e.getInstanceNetworkSpec(node.Spec.Resources.PrivateIPOnly, subnetID)
 	klog.V(2).Infof("Starting node with security groups: %v subnet: '%s'",
-		e.bootSecurityGroupIDs, e.subnetID)
-	result, err := e.client.RunInstances(&ec2.RunInstancesInput{
+		e.bootSecurityGroupIDs, subnetID)
+	runInput := &ec2.RunInstancesInput{
 		ImageId:             aws.String(node.Spec.BootImage),
 		InstanceType:        aws.String(node.Spec.InstanceType),
 		MinCount:            aws.Int64(1),
@@ -288,19 +472,26 @@ func (e *AwsEC2) StartNode(node *api.Node, image cloud.Image, metadata string) (
 		NetworkInterfaces:   networkSpec,
 		BlockDeviceMappings: devices,
 		UserData:            aws.String(metadata),
-	})
+		MetadataOptions:     e.metadataOptions(),
+		Placement:           e.nodePlacement(node),
+	}
+	e.applyLaunchTemplate(runInput)
+	if err := e.applyLaunchOverrides(runInput, node.Spec.LaunchOverrides); err != nil {
+		return nil, util.WrapError(err, "Could not apply launch overrides")
+	}
+	result, err := e.client.RunInstances(runInput)
 	if err != nil {
 		if isSubnetConstrainedError(err) {
 			return nil, &cloud.NoCapacityError{
 				OriginalError: err.Error(),
-				SubnetID:      e.subnetID,
+				SubnetID:      subnetID,
 			}
 		} else if isAZConstrainedError(err) || isInstanceConstrainedError(err) {
 			return nil, &cloud.NoCapacityError{
 				OriginalError: err.Error(),
 			}
 		}
-		return nil, util.WrapError(err, "Could not run instance")
+		return nil, mapAWSError(err, "Could not run instance")
 	}
 	if len(result.Instances) == 0 {
 		return nil, fmt.Errorf("Could not get instance info at result.Instances")
@@ -323,15 +514,21 @@ func (e *AwsEC2) StartSpotNode(node *api.Node, image cloud.Image, metadata strin
 		ResourceType: aws.String("instance"),
 		Tags:         tags,
 	}
+	// EC2 only runs spot instances on shared hardware.
+	if placement := e.nodePlacement(node); placement != nil {
+		return nil, fmt.Errorf("spot nodes can only use %q tenancy",
+			api.TenancyDefault)
+	}
 	var err error
 	//var subnet *cloud.SubnetAttributes
-	klog.V(2).Infof("Starting spot node in: %s", e.subnetID)
+	subnetID := e.nodeSubnetID(node)
+	klog.V(2).Infof("Starting spot node in: %s", subnetID)
 	volSizeGiB := cloud.ToSaneVolumeSize(node.Spec.Resources.VolumeSize)
-	devices := e.getBlockDeviceMapping(image, volSizeGiB)
-	networkSpec := e.getInstanceNetworkSpec(node.Spec.Resources.PrivateIPOnly)
+	devices := e.getBlockDeviceMapping(image, volSizeGiB, node.Spec.Resources)
+	networkSpec := e.getInstanceNetworkSpec(node.Spec.Resources.PrivateIPOnly, subnetID)
 	klog.V(2).Infof("Starting node with security groups: %v subnet: '%s'",
-		e.bootSecurityGroupIDs, e.subnetID)
-	result, err := e.client.RunInstances(&ec2.RunInstancesInput{
+		e.bootSecurityGroupIDs, subnetID)
+	runInput := &ec2.RunInstancesInput{
 		ImageId:             aws.String(node.Spec.BootImage),
 		InstanceType:        aws.String(node.Spec.InstanceType),
 		MinCount:            aws.Int64(1),
@@ -340,6 +537,7 @@ func (e *AwsEC2) StartSpotNode(node *api.Node, image cloud.Image, metadata strin
 		NetworkInterfaces:   networkSpec,
 		BlockDeviceMappings: devices,
 		UserData:            aws.String(metadata),
+		MetadataOptions:     e.metadataOptions(),
 		InstanceMarketOptions: &ec2.InstanceMarketOptionsRequest{
 			MarketType: aws.String("spot"),
 			SpotOptions: &ec2.SpotMarketOptions{
@@ -347,13 +545,18 @@ func (e *AwsEC2) StartSpotNode(node *api.Node, image cloud.Image, metadata strin
 				SpotInstanceType:             aws.String("one-time"),
 			},
 		},
-	})
+	}
+	e.applyLaunchTemplate(runInput)
+	if err := e.applyLaunchOverrides(runInput, node.Spec.LaunchOverrides); err != nil {
+		return nil, util.WrapError(err, "Could not apply launch overrides")
+	}
+	result, err := e.client.RunInstances(runInput)
 
 	if err != nil {
 		if isSubnetConstrainedError(err) {
 			return nil, &cloud.NoCapacityError{
 				OriginalError: err.Error(),
-				SubnetID:      e.subnetID,
+				SubnetID:      subnetID,
 			}
 		} else if isAZConstrainedError(err) || isInstanceConstrainedError(err) {
 			return nil, &cloud.NoCapacityError{
@@ -362,7 +565,7 @@ func (e *AwsEC2) StartSpotNode(node *api.Node, image cloud.Image, metadata strin
 		} else if isUnsupportedInstanceError(err) {
 			return nil, &cloud.UnsupportedInstanceError{err.Error()}
 		}
-		return nil, util.WrapError(err, "Could not run instance")
+		return nil, mapAWSError(err, "Could not run instance")
 	}
 	if len(result.Instances) == 0 {
 		return nil, fmt.Errorf("Could not get instance info at result.Instances")
@@ -570,7 +773,8 @@ func isSubnetConstrainedError(err error) bool {
 func isAZConstrainedError(err error) bool {
 	if awsErr, ok := err.(awserr.Error); ok {
 		switch awsErr.Code() {
-		case "InsufficientInstanceCapacity", "InsufficientCapacity":
+		case "InsufficientInstanceCapacity", "InsufficientCapacity",
+			"InsufficientHostCapacity", "InsufficientReservedInstanceCapacity":
 			// Note according to the docs, "InsufficientCapacity"
 			// pertains only to instance imports. Older forum posts
 			// show InsufficientCapacity errors when there's no
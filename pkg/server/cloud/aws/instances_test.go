@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +68,239 @@ func TestBootImageSpecToDescribeImagesInput(t *testing.T) {
 		assert.Equal(t, tc.Input, *input)
 	}
 }
+
+func TestApplyLaunchTemplate(t *testing.T) {
+	input := &ec2.RunInstancesInput{
+		ImageId:           aws.String("ami-123"),
+		InstanceType:      aws.String("t3.nano"),
+		NetworkInterfaces: []*ec2.InstanceNetworkInterfaceSpecification{{}},
+		UserData:          aws.String("metadata"),
+	}
+	// No template configured leaves the input untouched.
+	e := &AwsEC2{}
+	e.applyLaunchTemplate(input)
+	assert.Nil(t, input.LaunchTemplate)
+	assert.Equal(t, "ami-123", aws.StringValue(input.ImageId))
+
+	// With a template, the template supplies the AMI and networking
+	// while kip's per-node parameters stay in the input.
+	e = &AwsEC2{
+		launchTemplateID:      "lt-123",
+		launchTemplateVersion: "$Default",
+	}
+	e.applyLaunchTemplate(input)
+	if assert.NotNil(t, input.LaunchTemplate) {
+		assert.Equal(t, "lt-123", aws.StringValue(input.LaunchTemplate.LaunchTemplateId))
+		assert.Equal(t, "$Default", aws.StringValue(input.LaunchTemplate.Version))
+	}
+	assert.Nil(t, input.ImageId)
+	assert.Nil(t, input.NetworkInterfaces)
+	assert.Equal(t, "t3.nano", aws.StringValue(input.InstanceType))
+	assert.Equal(t, "metadata", aws.StringValue(input.UserData))
+}
+
+func TestMetadataOptions(t *testing.T) {
+	e := &AwsEC2{}
+	assert.Nil(t, e.metadataOptions())
+
+	e = &AwsEC2{imdsv2Required: true}
+	opts := e.metadataOptions()
+	if assert.NotNil(t, opts) {
+		assert.Equal(t, ec2.HttpTokensStateRequired, aws.StringValue(opts.HttpTokens))
+		assert.Nil(t, opts.HttpPutResponseHopLimit)
+	}
+
+	e = &AwsEC2{imdsv2Required: true, imdsv2HopLimit: 2}
+	opts = e.metadataOptions()
+	if assert.NotNil(t, opts) {
+		assert.Equal(t, int64(2), aws.Int64Value(opts.HttpPutResponseHopLimit))
+	}
+}
+
+func TestValidatePlacement(t *testing.T) {
+	testCases := []struct {
+		name       string
+		tenancy    string
+		hostID     string
+		defTenancy string
+		spot       bool
+		isError    bool
+	}{
+		{
+			name: "empty placement",
+		},
+		{
+			name:    "default tenancy",
+			tenancy: api.TenancyDefault,
+		},
+		{
+			name:    "dedicated tenancy",
+			tenancy: api.TenancyDedicated,
+		},
+		{
+			name:    "host tenancy with host ID",
+			tenancy: api.TenancyHost,
+			hostID:  "h-0123456789abcdef0",
+		},
+		{
+			name:    "unknown tenancy",
+			tenancy: "shared",
+			isError: true,
+		},
+		{
+			name:    "host ID without host tenancy",
+			tenancy: api.TenancyDedicated,
+			hostID:  "h-0123456789abcdef0",
+			isError: true,
+		},
+		{
+			name:       "host ID with configured host tenancy",
+			hostID:     "h-0123456789abcdef0",
+			defTenancy: api.TenancyHost,
+		},
+		{
+			name:    "malformed host ID",
+			tenancy: api.TenancyHost,
+			hostID:  "hst-123",
+			isError: true,
+		},
+		{
+			name:    "spot with dedicated tenancy",
+			tenancy: api.TenancyDedicated,
+			spot:    true,
+			isError: true,
+		},
+		{
+			name:       "spot with configured dedicated tenancy",
+			defTenancy: api.TenancyDedicated,
+			spot:       true,
+			isError:    true,
+		},
+		{
+			name:    "spot with default tenancy",
+			tenancy: api.TenancyDefault,
+			spot:    true,
+		},
+	}
+	for _, tc := range testCases {
+		e := &AwsEC2{defaultTenancy: tc.defTenancy}
+		placement := &api.PlacementSpec{
+			Tenancy:         tc.tenancy,
+			DedicatedHostID: tc.hostID,
+		}
+		err := e.ValidatePlacement(placement, tc.spot)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestNodePlacement(t *testing.T) {
+	node := api.GetFakeNode()
+
+	// Default tenancy keeps the RunInstances request unchanged.
+	e := &AwsEC2{}
+	assert.Nil(t, e.nodePlacement(node))
+	e = &AwsEC2{defaultTenancy: api.TenancyDefault}
+	assert.Nil(t, e.nodePlacement(node))
+
+	// The node's tenancy wins over the configured default.
+	node.Spec.Placement.Tenancy = api.TenancyDedicated
+	placement := e.nodePlacement(node)
+	if assert.NotNil(t, placement) {
+		assert.Equal(t, api.TenancyDedicated, aws.StringValue(placement.Tenancy))
+		assert.Nil(t, placement.HostId)
+	}
+
+	// Host tenancy falls back to the configured dedicated host.
+	e = &AwsEC2{defaultHostID: "h-0123456789abcdef0"}
+	node.Spec.Placement.Tenancy = api.TenancyHost
+	placement = e.nodePlacement(node)
+	if assert.NotNil(t, placement) {
+		assert.Equal(t, api.TenancyHost, aws.StringValue(placement.Tenancy))
+		assert.Equal(t, "h-0123456789abcdef0", aws.StringValue(placement.HostId))
+	}
+	node.Spec.Placement.DedicatedHostID = "h-aaaabbbbccccdddd0"
+	placement = e.nodePlacement(node)
+	if assert.NotNil(t, placement) {
+		assert.Equal(t, "h-aaaabbbbccccdddd0", aws.StringValue(placement.HostId))
+	}
+}
+
+func TestValidateVolumeEncryption(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	testCases := []struct {
+		name      string
+		encrypted *bool
+		kmsKeyID  string
+		isError   bool
+	}{
+		{
+			name: "no settings",
+		},
+		{
+			name:      "encryption without a key",
+			encrypted: boolPtr(true),
+		},
+		{
+			name:      "encryption explicitly off",
+			encrypted: boolPtr(false),
+		},
+		{
+			name:     "key ID",
+			kmsKeyID: "12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:     "key alias",
+			kmsKeyID: "alias/my-key",
+		},
+		{
+			name:     "key ARN",
+			kmsKeyID: "arn:aws:kms:us-east-1:123456789012:key/12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:     "malformed key",
+			kmsKeyID: "my-key",
+			isError:  true,
+		},
+		{
+			name:      "key with encryption disabled",
+			encrypted: boolPtr(false),
+			kmsKeyID:  "alias/my-key",
+			isError:   true,
+		},
+	}
+	for _, tc := range testCases {
+		e := &AwsEC2{}
+		err := e.ValidateVolumeEncryption(tc.encrypted, tc.kmsKeyID)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestGetBlockDeviceMappingEncryption(t *testing.T) {
+	e := &AwsEC2{}
+	image := cloud.Image{RootDevice: "/dev/xvda"}
+	encrypted := true
+
+	devices := e.getBlockDeviceMapping(image, 5, api.ResourceSpec{})
+	assert.Nil(t, devices[0].Ebs.Encrypted)
+	assert.Nil(t, devices[0].Ebs.KmsKeyId)
+
+	devices = e.getBlockDeviceMapping(image, 5, api.ResourceSpec{
+		VolumeEncrypted: &encrypted,
+	})
+	assert.True(t, aws.BoolValue(devices[0].Ebs.Encrypted))
+	assert.Nil(t, devices[0].Ebs.KmsKeyId)
+
+	devices = e.getBlockDeviceMapping(image, 5, api.ResourceSpec{
+		VolumeKMSKey: "alias/my-key",
+	})
+	assert.True(t, aws.BoolValue(devices[0].Ebs.Encrypted))
+	assert.Equal(t, "alias/my-key", aws.StringValue(devices[0].Ebs.KmsKeyId))
+}
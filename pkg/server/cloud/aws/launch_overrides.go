@@ -0,0 +1,102 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// launchOverrides holds the AWS launch settings users may pass
+// through to RunInstances via the aws-launch-overrides annotation.
+// The struct doubles as the allowlist: unknown keys fail decoding.
+type launchOverrides struct {
+	EbsOptimized          *bool  `json:"ebsOptimized,omitempty"`
+	Monitoring            *bool  `json:"monitoring,omitempty"`
+	CreditSpecification   string `json:"creditSpecification,omitempty"`
+	KeyName               string `json:"keyName,omitempty"`
+	CapacityReservationID string `json:"capacityReservationId,omitempty"`
+}
+
+const allowedLaunchOverrideKeys = "ebsOptimized, monitoring, creditSpecification, keyName, capacityReservationId"
+
+func parseLaunchOverrides(overrides string) (*launchOverrides, error) {
+	dec := json.NewDecoder(strings.NewReader(overrides))
+	dec.DisallowUnknownFields()
+	lo := &launchOverrides{}
+	if err := dec.Decode(lo); err != nil {
+		return nil, fmt.Errorf("invalid launch overrides, the allowed keys are %s: %v",
+			allowedLaunchOverrideKeys, err)
+	}
+	if lo.CreditSpecification != "" &&
+		lo.CreditSpecification != "standard" &&
+		lo.CreditSpecification != "unlimited" {
+		return nil, fmt.Errorf("invalid launch overrides: creditSpecification must be \"standard\" or \"unlimited\", got %q",
+			lo.CreditSpecification)
+	}
+	return lo, nil
+}
+
+func (e *AwsEC2) ValidateLaunchOverrides(overrides string) error {
+	if overrides == "" {
+		return nil
+	}
+	_, err := parseLaunchOverrides(overrides)
+	return err
+}
+
+// applyLaunchOverrides merges the pod's validated launch overrides
+// into a RunInstances call.  Overrides are applied last so they win
+// over launch template values but they can't touch the parameters kip
+// needs to control (image, networking, user data).
+func (e *AwsEC2) applyLaunchOverrides(input *ec2.RunInstancesInput, overrides string) error {
+	if overrides == "" {
+		return nil
+	}
+	lo, err := parseLaunchOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	if lo.EbsOptimized != nil {
+		input.EbsOptimized = lo.EbsOptimized
+	}
+	if lo.Monitoring != nil {
+		input.Monitoring = &ec2.RunInstancesMonitoringEnabled{
+			Enabled: lo.Monitoring,
+		}
+	}
+	if lo.CreditSpecification != "" {
+		input.CreditSpecification = &ec2.CreditSpecificationRequest{
+			CpuCredits: aws.String(lo.CreditSpecification),
+		}
+	}
+	if lo.KeyName != "" {
+		input.KeyName = aws.String(lo.KeyName)
+	}
+	if lo.CapacityReservationID != "" {
+		input.CapacityReservationSpecification = &ec2.CapacityReservationSpecification{
+			CapacityReservationTarget: &ec2.CapacityReservationTarget{
+				CapacityReservationId: aws.String(lo.CapacityReservationID),
+			},
+		}
+	}
+	return nil
+}
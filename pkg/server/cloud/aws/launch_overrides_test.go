@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLaunchOverrides(t *testing.T) {
+	e := &AwsEC2{}
+	// empty overrides are fine
+	assert.NoError(t, e.ValidateLaunchOverrides(""))
+	assert.NoError(t, e.ValidateLaunchOverrides(
+		`{"ebsOptimized": true, "creditSpecification": "unlimited"}`))
+	// keys outside the allowlist are rejected
+	assert.Error(t, e.ValidateLaunchOverrides(`{"userData": "owned"}`))
+	// so are malformed values
+	assert.Error(t, e.ValidateLaunchOverrides(`{"ebsOptimized": "yes"}`))
+	assert.Error(t, e.ValidateLaunchOverrides(`{"creditSpecification": "turbo"}`))
+	assert.Error(t, e.ValidateLaunchOverrides(`not json`))
+}
+
+func TestApplyLaunchOverrides(t *testing.T) {
+	e := &AwsEC2{}
+	input := &ec2.RunInstancesInput{
+		ImageId:  aws.String("ami-123"),
+		UserData: aws.String("metadata"),
+	}
+	// no overrides leave the input untouched
+	assert.NoError(t, e.applyLaunchOverrides(input, ""))
+	assert.Nil(t, input.EbsOptimized)
+
+	overrides := `{"ebsOptimized": true, "monitoring": true, "creditSpecification": "standard", "keyName": "ops-key", "capacityReservationId": "cr-123"}`
+	assert.NoError(t, e.applyLaunchOverrides(input, overrides))
+	assert.True(t, aws.BoolValue(input.EbsOptimized))
+	if assert.NotNil(t, input.Monitoring) {
+		assert.True(t, aws.BoolValue(input.Monitoring.Enabled))
+	}
+	if assert.NotNil(t, input.CreditSpecification) {
+		assert.Equal(t, "standard", aws.StringValue(input.CreditSpecification.CpuCredits))
+	}
+	assert.Equal(t, "ops-key", aws.StringValue(input.KeyName))
+	if assert.NotNil(t, input.CapacityReservationSpecification) {
+		assert.Equal(t, "cr-123", aws.StringValue(
+			input.CapacityReservationSpecification.CapacityReservationTarget.CapacityReservationId))
+	}
+	// kip's parameters are untouched
+	assert.Equal(t, "ami-123", aws.StringValue(input.ImageId))
+	assert.Equal(t, "metadata", aws.StringValue(input.UserData))
+
+	assert.Error(t, e.applyLaunchOverrides(input, `{"imageId": "ami-evil"}`))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+const defaultLaunchTemplateVersion = "$Default"
+
+// validateLaunchTemplate checks that the configured launch template
+// exists and that kip's per-node overrides can be merged on top of
+// it.  Kip always supplies the instance user data, so a template that
+// sets its own user data is rejected.
+func (e *AwsEC2) validateLaunchTemplate() error {
+	output, err := e.client.DescribeLaunchTemplateVersions(
+		&ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId: aws.String(e.launchTemplateID),
+			Versions:         []*string{aws.String(e.launchTemplateVersion)},
+		})
+	if err != nil {
+		return util.WrapError(err, "Could not describe launch template %s version %s",
+			e.launchTemplateID, e.launchTemplateVersion)
+	}
+	if len(output.LaunchTemplateVersions) == 0 {
+		return fmt.Errorf("launch template %s version %s does not exist",
+			e.launchTemplateID, e.launchTemplateVersion)
+	}
+	data := output.LaunchTemplateVersions[0].LaunchTemplateData
+	if data != nil && data.UserData != nil {
+		return fmt.Errorf(
+			"launch template %s sets user data but kip needs to control instance user data, please remove user data from the template",
+			e.launchTemplateID)
+	}
+	klog.V(2).Infof("cells will launch from template %s version %s",
+		e.launchTemplateID, e.launchTemplateVersion)
+	return nil
+}
+
+// launchTemplateSpec returns the launch template reference to merge
+// into RunInstances calls, or nil when no template is configured.
+func (e *AwsEC2) launchTemplateSpec() *ec2.LaunchTemplateSpecification {
+	if e.launchTemplateID == "" {
+		return nil
+	}
+	return &ec2.LaunchTemplateSpecification{
+		LaunchTemplateId: aws.String(e.launchTemplateID),
+		Version:          aws.String(e.launchTemplateVersion),
+	}
+}
+
+// applyLaunchTemplate makes a RunInstances call use the configured
+// launch template, if there is one.  The template supplies the AMI,
+// networking and IMDS settings; kip's per-node parameters (instance
+// type, tags, user data, block devices) stay in the input and take
+// precedence over the template's values.
+func (e *AwsEC2) applyLaunchTemplate(input *ec2.RunInstancesInput) {
+	lt := e.launchTemplateSpec()
+	if lt == nil {
+		return
+	}
+	input.LaunchTemplate = lt
+	input.ImageId = nil
+	input.NetworkInterfaces = nil
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -50,3 +52,22 @@ func GetMetadata(p string) (string, error) {
 	body, err := ioutil.ReadAll(resp.Body)
 	return string(body), err
 }
+
+// DetectRegion returns the region of the instance the controller is
+// running on, taken from the availability zone in instance metadata
+// (regions end in a digit, AZs add a trailing letter).
+func DetectRegion() (string, error) {
+	az, err := GetMetadata("placement/availability-zone")
+	if err != nil || az == "" {
+		return "", fmt.Errorf("Could not get availability zone from metadata service")
+	}
+	region := regionFromAZ(az)
+	if region == "" {
+		return "", fmt.Errorf("Could not parse a region from availability zone %s", az)
+	}
+	return region, nil
+}
+
+func regionFromAZ(az string) string {
+	return strings.TrimRight(az, "abcdefghijklmnopqrstuvwxyz")
+}
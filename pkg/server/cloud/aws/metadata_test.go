@@ -0,0 +1,38 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionFromAZ(t *testing.T) {
+	tests := []struct {
+		az     string
+		region string
+	}{
+		{"us-east-1a", "us-east-1"},
+		{"eu-central-1c", "eu-central-1"},
+		{"ap-southeast-2b", "ap-southeast-2"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.region, regionFromAZ(tc.az))
+	}
+}
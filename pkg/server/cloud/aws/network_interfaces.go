@@ -0,0 +1,163 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+// ENI limits per instance type don't change, cache them for the
+// lifetime of the controller.
+var (
+	eniLimitsMutex sync.Mutex
+	eniLimits      = map[string]int64{}
+)
+
+// maxNetworkInterfaces returns how many network interfaces (including
+// the primary interface) the instance type supports.
+func (e *AwsEC2) maxNetworkInterfaces(instanceType string) (int64, error) {
+	eniLimitsMutex.Lock()
+	limit, exists := eniLimits[instanceType]
+	eniLimitsMutex.Unlock()
+	if exists {
+		return limit, nil
+	}
+	out, err := e.client.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: aws.StringSlice([]string{instanceType}),
+	})
+	if err != nil {
+		return 0, util.WrapError(err, "Error describing instance type %s", instanceType)
+	}
+	if len(out.InstanceTypes) == 0 || out.InstanceTypes[0].NetworkInfo == nil {
+		return 0, fmt.Errorf("no network interface limit found for instance type %s", instanceType)
+	}
+	limit = aws.Int64Value(out.InstanceTypes[0].NetworkInfo.MaximumNetworkInterfaces)
+	eniLimitsMutex.Lock()
+	eniLimits[instanceType] = limit
+	eniLimitsMutex.Unlock()
+	return limit, nil
+}
+
+// checkNetworkInterfaceLimit verifies the instance type can carry the
+// requested extra interfaces on top of its primary interface.
+func (e *AwsEC2) checkNetworkInterfaceLimit(numExtraInterfaces int, instanceType string) error {
+	limit, err := e.maxNetworkInterfaces(instanceType)
+	if err != nil {
+		return err
+	}
+	if int64(numExtraInterfaces)+1 > limit {
+		return fmt.Errorf("instance type %s supports at most %d network interfaces, the pod requests %d extra interfaces on top of the primary interface",
+			instanceType, limit, numExtraInterfaces)
+	}
+	return nil
+}
+
+func (e *AwsEC2) ValidateNetworkInterfaces(interfaces []api.NetworkInterfaceSpec, instanceType string) error {
+	if len(interfaces) == 0 {
+		return nil
+	}
+	for i, iface := range interfaces {
+		if iface.SubnetID == "" {
+			return fmt.Errorf("network interface %d: a subnetID is required", i)
+		}
+		if !strings.HasPrefix(iface.SubnetID, "subnet-") {
+			return fmt.Errorf("network interface %d: invalid subnet ID %q",
+				i, iface.SubnetID)
+		}
+	}
+	// A pod sized from resource requests doesn't have its instance
+	// type yet, the limit is checked again when the interfaces are
+	// attached.
+	if instanceType == "" {
+		return nil
+	}
+	return e.checkNetworkInterfaceLimit(len(interfaces), instanceType)
+}
+
+// AttachNetworkInterfaces creates the requested extra interfaces and
+// attaches them to the node's instance at device indexes 1..n. The
+// attachments are marked delete-on-termination so the interfaces are
+// cleaned up with the instance.
+func (e *AwsEC2) AttachNetworkInterfaces(node *api.Node, interfaces []api.NetworkInterfaceSpec) ([]string, error) {
+	if len(interfaces) == 0 {
+		return nil, nil
+	}
+	if err := e.checkNetworkInterfaceLimit(len(interfaces), node.Spec.InstanceType); err != nil {
+		return nil, err
+	}
+	interfaceIDs := make([]string, 0, len(interfaces))
+	for i, iface := range interfaces {
+		groups := iface.SecurityGroupIDs
+		if len(groups) == 0 {
+			groups = e.bootSecurityGroupIDs
+		}
+		created, err := e.client.CreateNetworkInterface(&ec2.CreateNetworkInterfaceInput{
+			SubnetId:    aws.String(iface.SubnetID),
+			Groups:      aws.StringSlice(groups),
+			Description: aws.String(fmt.Sprintf("kip extra interface for node %s", node.Name)),
+		})
+		if err != nil {
+			return nil, util.WrapError(err, "Error creating network interface in subnet %s", iface.SubnetID)
+		}
+		interfaceID := aws.StringValue(created.NetworkInterface.NetworkInterfaceId)
+		attachment, err := e.client.AttachNetworkInterface(&ec2.AttachNetworkInterfaceInput{
+			NetworkInterfaceId: aws.String(interfaceID),
+			InstanceId:         aws.String(node.Status.InstanceID),
+			DeviceIndex:        aws.Int64(int64(i + 1)),
+		})
+		if err != nil {
+			e.deleteNetworkInterface(interfaceID)
+			return nil, util.WrapError(err, "Error attaching network interface %s to node %s", interfaceID, node.Name)
+		}
+		// Tie the interface's lifetime to the instance so teardown is
+		// handled by the cloud when the cell is terminated.
+		_, err = e.client.ModifyNetworkInterfaceAttribute(&ec2.ModifyNetworkInterfaceAttributeInput{
+			NetworkInterfaceId: aws.String(interfaceID),
+			Attachment: &ec2.NetworkInterfaceAttachmentChanges{
+				AttachmentId:        attachment.AttachmentId,
+				DeleteOnTermination: aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return nil, util.WrapError(err, "Error marking network interface %s for deletion on termination", interfaceID)
+		}
+		klog.V(2).Infof("Attached network interface %s in subnet %s to node %s",
+			interfaceID, iface.SubnetID, node.Name)
+		interfaceIDs = append(interfaceIDs, interfaceID)
+	}
+	return interfaceIDs, nil
+}
+
+// deleteNetworkInterface removes an interface we created but could
+// not attach, best effort.
+func (e *AwsEC2) deleteNetworkInterface(interfaceID string) {
+	_, err := e.client.DeleteNetworkInterface(&ec2.DeleteNetworkInterfaceInput{
+		NetworkInterfaceId: aws.String(interfaceID),
+	})
+	if err != nil {
+		klog.Warningf("Could not delete unattached network interface %s: %v",
+			interfaceID, err)
+	}
+}
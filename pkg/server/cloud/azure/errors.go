@@ -16,12 +16,42 @@ limitations under the License.
 
 package azure
 
-import "github.com/Azure/go-autorest/autorest"
+import (
+	"net/http"
 
-func isNotFoundError(err error) bool {
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
+)
+
+func azureStatusCode(err error) (int, bool) {
 	if detailedError, ok := err.(autorest.DetailedError); ok {
 		intSC, ok := detailedError.StatusCode.(int)
-		return ok && intSC == 404
+		return intSC, ok
+	}
+	return 0, false
+}
+
+func isNotFoundError(err error) bool {
+	code, ok := azureStatusCode(err)
+	return ok && code == http.StatusNotFound
+}
+
+// mapAzureError converts an Azure API error into one of the cloud
+// package's typed errors when its status code identifies a known
+// class (throttling, auth, missing resource), so controllers can
+// branch on the class instead of parsing Azure messages.  Other
+// errors are wrapped with the given message like util.WrapError.
+func mapAzureError(err error, format string, args ...interface{}) error {
+	if code, ok := azureStatusCode(err); ok {
+		switch code {
+		case http.StatusNotFound:
+			return &cloud.NotFoundError{OriginalError: err.Error()}
+		case http.StatusTooManyRequests:
+			return &cloud.ThrottledError{OriginalError: err.Error()}
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &cloud.AuthError{OriginalError: err.Error()}
+		}
 	}
-	return false
+	return util.WrapError(err, format, args...)
 }
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func azureErrorWithStatus(code int) error {
+	return autorest.DetailedError{
+		Original:   fmt.Errorf("boom"),
+		StatusCode: code,
+	}
+}
+
+func TestMapAzureError(t *testing.T) {
+	tests := []struct {
+		status  int
+		checker func(error) bool
+	}{
+		{404, cloud.IsNotFoundError},
+		{429, cloud.IsThrottledError},
+		{401, cloud.IsAuthError},
+		{403, cloud.IsAuthError},
+	}
+	for i, tc := range tests {
+		err := mapAzureError(azureErrorWithStatus(tc.status), "wrapped")
+		assert.True(t, tc.checker(err), "case %d: %d mapped to %T", i, tc.status, err)
+	}
+
+	// other statuses and non-Azure errors get wrapped with the message
+	err := mapAzureError(azureErrorWithStatus(500), "wrapped")
+	assert.Contains(t, err.Error(), "wrapped")
+	err = mapAzureError(fmt.Errorf("plain error"), "wrapped")
+	assert.Contains(t, err.Error(), "wrapped")
+}
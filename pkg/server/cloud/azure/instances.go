@@ -43,7 +43,22 @@ func (az *AzureClient) StopInstance(instanceID string) error {
 	// Deleting the instance's resource group should delete the VM and
 	// everything else associated with the VM (NIC, Disks, Public IP,
 	// etc.)
-	return az.DeleteResourceGroup(instanceID)
+	if err := az.DeleteResourceGroup(instanceID); err != nil {
+		return mapAzureError(err, "Error terminating instance %s", instanceID)
+	}
+	return nil
+}
+
+func (az *AzureClient) HaltInstance(instanceID string) error {
+	return fmt.Errorf("stopping instances for reuse is not implemented for azure")
+}
+
+func (az *AzureClient) ResumeInstance(instanceID string) error {
+	return fmt.Errorf("resuming stopped instances is not implemented for azure")
+}
+
+func (az *AzureClient) RebootInstance(instanceID string) error {
+	return fmt.Errorf("rebooting instances is not implemented for azure")
 }
 
 func (az *AzureClient) createIPAddress(instanceID string, zone string) (*network.PublicIPAddress, error) {
@@ -509,6 +524,80 @@ func (az *AzureClient) AddInstanceTags(iid string, labels map[string]string) err
 	return nil
 }
 
+func (az *AzureClient) ValidatePlacement(placement *api.PlacementSpec, spot bool) error {
+	if placement.Tenancy == "" && placement.DedicatedHostID == "" {
+		return nil
+	}
+	return fmt.Errorf("instance tenancy is not implemented for azure")
+}
+
+func (az *AzureClient) ValidateVolumeEncryption(encrypted *bool, kmsKeyID string) error {
+	if encrypted == nil && kmsKeyID == "" {
+		return nil
+	}
+	return fmt.Errorf("root volume encryption settings are not implemented for azure")
+}
+
+func (az *AzureClient) ValidateVMSecurity(shieldedVM, confidentialVM *bool, instanceType string) error {
+	if shieldedVM == nil && confidentialVM == nil {
+		return nil
+	}
+	return fmt.Errorf("shielded and confidential VM settings are not implemented for azure")
+}
+
+func (az *AzureClient) ValidateLaunchOverrides(overrides string) error {
+	if overrides == "" {
+		return nil
+	}
+	return fmt.Errorf("launch overrides are not implemented for azure")
+}
+
+func (az *AzureClient) ValidateNetworkInterfaces(interfaces []api.NetworkInterfaceSpec, instanceType string) error {
+	if len(interfaces) == 0 {
+		return nil
+	}
+	return fmt.Errorf("extra network interfaces are not implemented for azure")
+}
+
+func (az *AzureClient) AttachNetworkInterfaces(node *api.Node, interfaces []api.NetworkInterfaceSpec) ([]string, error) {
+	if len(interfaces) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("extra network interfaces are not implemented for azure")
+}
+
+func (az *AzureClient) ValidateDataVolumes(volumes []api.DataVolumeSpec) error {
+	if len(volumes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("data volumes are not implemented for azure")
+}
+
+func (az *AzureClient) AttachDataVolumes(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error) {
+	if len(volumes) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("data volumes are not implemented for azure")
+}
+
+func (az *AzureClient) ReattachDataVolumes(node *api.Node, volumeIDs []string) error {
+	if len(volumeIDs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("data volumes are not implemented for azure")
+}
+
+func (az *AzureClient) GetDataVolumeAZ(volumeID string) (string, error) {
+	return "", fmt.Errorf("data volumes are not implemented for azure")
+}
+
+func (az *AzureClient) DeleteDataVolumes(volumeIDs []string) error {
+	if len(volumeIDs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("data volumes are not implemented for azure")
+}
+
 // Todo: need to figure out what to do with this
 func (az *AzureClient) ResizeVolume(node *api.Node, size int64) (error, bool) {
 	return nil, true
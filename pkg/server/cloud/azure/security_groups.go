@@ -192,3 +192,19 @@ func (az *AzureClient) CreateSGName(svcName string) string {
 func (az *AzureClient) AttachSecurityGroups(node *api.Node, groups []string) error {
 	return nil
 }
+
+func (az *AzureClient) EnsurePodSecurityGroup(podName string, egress []cloud.EgressRule) (string, error) {
+	return "", fmt.Errorf("pod egress security groups are not implemented for azure")
+}
+
+func (az *AzureClient) DeletePodSecurityGroup(podName string) error {
+	return nil
+}
+
+func (az *AzureClient) AssociateElasticIP(node *api.Node, eip string) error {
+	return fmt.Errorf("elastic IPs are not implemented for azure")
+}
+
+func (az *AzureClient) DisassociateElasticIP(eip string) error {
+	return nil
+}
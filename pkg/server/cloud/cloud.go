@@ -46,13 +46,79 @@ type CloudClient interface {
 	StartSpotNode(*api.Node, Image, string) (*StartNodeResult, error)
 	// This should always be called from a goroutine as it can take a while
 	StopInstance(instanceID string) error
+	// HaltInstance stops the instance without terminating it so it
+	// can be started again later with ResumeInstance.
+	HaltInstance(instanceID string) error
+	// ResumeInstance starts a previously halted instance.
+	ResumeInstance(instanceID string) error
+	// RebootInstance restarts a running instance, e.g. to recover a
+	// wedged node agent.
+	RebootInstance(instanceID string) error
 	WaitForRunning(node *api.Node) ([]api.NetworkAddress, error)
 	EnsureMilpaSecurityGroups([]string, []string) error
 	AttachSecurityGroups(node *api.Node, groups []string) error
+	// EnsurePodSecurityGroup creates an ephemeral security group
+	// restricting egress traffic from the pod's cell to the supplied
+	// rules and returns the group ID. An empty rule list denies all
+	// egress.
+	EnsurePodSecurityGroup(podName string, egress []EgressRule) (string, error)
+	// DeletePodSecurityGroup removes the pod's ephemeral egress
+	// security group if one exists.
+	DeletePodSecurityGroup(podName string) error
 	AssignInstanceProfile(node *api.Node, instanceProfile string) error
+	// AssociateElasticIP moves the pre-allocated elastic/static IP
+	// identified by eip (a public IP address or provider allocation
+	// ID) over to the node's instance. The address must be allocated
+	// in the cloud account and either unassigned or held by another
+	// cell this controller manages (the pod's previous cell when the
+	// pod reschedules).
+	AssociateElasticIP(node *api.Node, eip string) error
+	// DisassociateElasticIP detaches eip from whatever instance
+	// currently holds it. The allocation itself is kept.
+	DisassociateElasticIP(eip string) error
 	ListInstancesFilterID([]string) ([]CloudInstance, error)
 	ListInstances() ([]CloudInstance, error)
 	ResizeVolume(node *api.Node, size int64) (error, bool)
+	// ValidateDataVolumes checks size and type constraints on data
+	// volume specs against the provider's limits.
+	ValidateDataVolumes(volumes []api.DataVolumeSpec) error
+	// ValidatePlacement checks a pod's tenancy settings against what
+	// the provider supports. Spot pods can't use dedicated tenancy.
+	ValidatePlacement(placement *api.PlacementSpec, spot bool) error
+	// ValidateVolumeEncryption checks a pod's root volume encryption
+	// settings: whether the provider supports encrypted root volumes
+	// and whether the key looks like a usable key for this provider.
+	ValidateVolumeEncryption(encrypted *bool, kmsKeyID string) error
+	// ValidateVMSecurity checks a pod's shielded/confidential VM
+	// settings: whether the provider supports them and whether the
+	// requested instance type can run a confidential VM.
+	ValidateVMSecurity(shieldedVM, confidentialVM *bool, instanceType string) error
+	// ValidateLaunchOverrides checks a pod's provider specific launch
+	// overrides (a JSON object) against the provider's allowlist of
+	// permitted keys.
+	ValidateLaunchOverrides(overrides string) error
+	// ValidateNetworkInterfaces checks a pod's extra network
+	// interface specs: whether the provider supports them and
+	// whether the requested instance type can carry that many
+	// interfaces.
+	ValidateNetworkInterfaces(interfaces []api.NetworkInterfaceSpec, instanceType string) error
+	// AttachNetworkInterfaces creates the requested extra network
+	// interfaces and attaches them to the node, returning one
+	// interface ID per spec, in order. The interfaces are deleted
+	// when the node's instance is terminated.
+	AttachNetworkInterfaces(node *api.Node, interfaces []api.NetworkInterfaceSpec) ([]string, error)
+	// AttachDataVolumes creates the requested volumes and attaches
+	// them to the node, returning one volume ID per spec, in order.
+	AttachDataVolumes(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error)
+	// ReattachDataVolumes moves previously created data volumes over
+	// to node, detaching them from their old instance first if
+	// necessary. Volumes can only move within their availability
+	// zone.
+	ReattachDataVolumes(node *api.Node, volumeIDs []string) error
+	// GetDataVolumeAZ returns the availability zone a data volume
+	// lives in.
+	GetDataVolumeAZ(volumeID string) (string, error)
+	DeleteDataVolumes(volumeIDs []string) error
 	GetRegistryAuth() (string, string, error)
 	GetImage(spec BootImageSpec) (Image, error)
 	SetSustainedCPU(*api.Node, bool) error
@@ -187,3 +187,64 @@ func TestSortImagesByCreationTime(t *testing.T) {
 		prev = images[i]
 	}
 }
+
+func TestValidateEgressRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []EgressRule
+		isError bool
+	}{
+		{
+			name:  "empty rules are valid",
+			rules: []EgressRule{},
+		},
+		{
+			name: "valid tcp rule",
+			rules: []EgressRule{
+				{Protocol: api.ProtocolTCP, Port: 443, CIDR: "10.20.0.0/16"},
+			},
+		},
+		{
+			name: "valid icmp rule without port",
+			rules: []EgressRule{
+				{Protocol: api.ProtocolICMP, CIDR: "10.20.0.0/16"},
+			},
+		},
+		{
+			name: "bad cidr",
+			rules: []EgressRule{
+				{Protocol: api.ProtocolTCP, Port: 443, CIDR: "10.20.0.0"},
+			},
+			isError: true,
+		},
+		{
+			name: "bad protocol",
+			rules: []EgressRule{
+				{Protocol: api.Protocol("SCTP"), Port: 443, CIDR: "10.20.0.0/16"},
+			},
+			isError: true,
+		},
+		{
+			name: "missing port",
+			rules: []EgressRule{
+				{Protocol: api.ProtocolUDP, CIDR: "10.20.0.0/16"},
+			},
+			isError: true,
+		},
+		{
+			name: "port out of range",
+			rules: []EgressRule{
+				{Protocol: api.ProtocolTCP, Port: 70000, CIDR: "10.20.0.0/16"},
+			},
+			isError: true,
+		},
+	}
+	for _, tc := range tests {
+		err := ValidateEgressRules(tc.rules)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package cloud
 
 import (
+	"fmt"
+	"math"
+	"net"
+
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/util"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -67,6 +71,39 @@ type IngressRule struct {
 	Source        string
 }
 
+// EgressRule restricts traffic leaving a pod's cell to a destination
+// CIDR and port range. Rules come from the pod's egress annotation,
+// see annotations.PodEgressRules for the format.
+type EgressRule struct {
+	// Protocol. Can be "TCP", "UDP" or "ICMP".
+	Protocol api.Protocol `json:"protocol"`
+	// Destination port the pod is allowed to reach. Not used for "ICMP".
+	Port int `json:"port"`
+	// PortRangeSize is the number of contiguous ports the rule
+	// opens, defaulting to 1.
+	PortRangeSize int `json:"portRangeSize,omitempty"`
+	// CIDR the pod is allowed to send traffic to, e.g. "10.20.0.0/16".
+	CIDR string `json:"cidr"`
+}
+
+func ValidateEgressRules(rules []EgressRule) error {
+	for i, rule := range rules {
+		if _, _, err := net.ParseCIDR(rule.CIDR); err != nil {
+			return util.WrapError(err, "Invalid cidr %q in egress rule %d", rule.CIDR, i)
+		}
+		switch rule.Protocol {
+		case api.ProtocolTCP, api.ProtocolUDP, api.ProtocolICMP:
+		default:
+			return fmt.Errorf("Invalid protocol %q in egress rule %d", rule.Protocol, i)
+		}
+		if rule.Protocol != api.ProtocolICMP &&
+			(rule.Port < 1 || rule.Port > math.MaxUint16) {
+			return fmt.Errorf("Invalid port %d in egress rule %d", rule.Port, i)
+		}
+	}
+	return nil
+}
+
 // We do simple set differences on the keys here
 func ingressRuleDiff(spec, status map[IngressRule]struct{}) ([]IngressRule, []IngressRule) {
 	// delete is anything in status that isn't in the spec
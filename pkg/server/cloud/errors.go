@@ -43,3 +43,75 @@ type UnsupportedInstanceError struct {
 func (e *UnsupportedInstanceError) Error() string {
 	return fmt.Sprintf("Unsupported spot instance type: %s", e.OriginalError)
 }
+
+// The typed errors below let controllers branch on the class of a
+// cloud API failure (retry, fail or back off) without parsing
+// provider-specific messages.  Each provider maps its own error
+// codes onto these types.
+
+// QuotaError means an account-level quota or limit blocked the
+// operation.  Retrying won't help until the quota is raised or usage
+// drops.
+type QuotaError struct {
+	OriginalError string
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("Cloud quota exceeded: %s", e.OriginalError)
+}
+
+// AuthError means the cloud rejected our credentials or we lack
+// permission for the operation.  Retrying won't help until the
+// credentials or permissions are fixed.
+type AuthError struct {
+	OriginalError string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("Cloud authorization error: %s", e.OriginalError)
+}
+
+// NotFoundError means the referenced cloud resource does not exist,
+// e.g. an instance that was already terminated out of band.
+type NotFoundError struct {
+	OriginalError string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Cloud resource not found: %s", e.OriginalError)
+}
+
+// ThrottledError means the provider rate limited the API call.  The
+// operation can be retried after backing off.
+type ThrottledError struct {
+	OriginalError string
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("Cloud API throttled: %s", e.OriginalError)
+}
+
+func IsNoCapacityError(err error) bool {
+	_, ok := err.(*NoCapacityError)
+	return ok
+}
+
+func IsQuotaError(err error) bool {
+	_, ok := err.(*QuotaError)
+	return ok
+}
+
+func IsAuthError(err error) bool {
+	_, ok := err.(*AuthError)
+	return ok
+}
+
+func IsNotFoundError(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+func IsThrottledError(err error) bool {
+	_, ok := err.(*ThrottledError)
+	return ok
+}
@@ -302,3 +302,19 @@ func (c *gceClient) AttachSecurityGroups(node *api.Node, groups []string) error
 	}
 	return nil
 }
+
+func (c *gceClient) EnsurePodSecurityGroup(podName string, egress []cloud.EgressRule) (string, error) {
+	return "", fmt.Errorf("pod egress security groups are not implemented for gce")
+}
+
+func (c *gceClient) DeletePodSecurityGroup(podName string) error {
+	return nil
+}
+
+func (c *gceClient) AssociateElasticIP(node *api.Node, eip string) error {
+	return fmt.Errorf("elastic IPs are not implemented for gce")
+}
+
+func (c *gceClient) DisassociateElasticIP(eip string) error {
+	return nil
+}
@@ -19,6 +19,7 @@ package gce
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -28,6 +29,8 @@ import (
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/util"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 	"k8s.io/klog"
 )
 
@@ -46,7 +49,11 @@ const (
 )
 
 type gceClient struct {
-	service              *compute.Service
+	service *compute.Service
+	// httpClient is the authenticated client backing service, kept
+	// around for API calls the vendored compute client revision
+	// doesn't support (see confidential.go).
+	httpClient           *http.Client
 	clientEmail          string
 	controllerID         string
 	nametag              string
@@ -61,6 +68,11 @@ type gceClient struct {
 	bootSecurityGroupIDs []string
 	cloudStatus          *cloud.AZSubnetStatus
 	gkeMetadata          map[string]string
+	// shieldedVM and confidentialVM are the cluster-wide defaults for
+	// launching cells as shielded or confidential VMs; pods can
+	// override them with the shielded-vm/confidential-vm annotations.
+	shieldedVM     bool
+	confidentialVM bool
 }
 
 func NewGCEClient(controllerID, nametag, projectID string, opts ...ClientOption) (*gceClient, error) {
@@ -85,7 +97,7 @@ func NewGCEClient(controllerID, nametag, projectID string, opts ...ClientOption)
 		}
 	}
 	if client.service == nil {
-		client.service, err = serviceFromEnvironment()
+		client.service, client.httpClient, err = serviceFromEnvironment()
 		if err != nil {
 			return nil, err
 		}
@@ -128,13 +140,37 @@ func NewGCEClient(controllerID, nametag, projectID string, opts ...ClientOption)
 	return client, nil
 }
 
+// newComputeService mirrors compute.NewService but also hands back
+// the authenticated HTTP client it builds the service from, so calls
+// the vendored compute client revision doesn't support can reuse the
+// same credentials and endpoint.
+func newComputeService(ctx context.Context, opts ...option.ClientOption) (*compute.Service, *http.Client, error) {
+	scopesOption := option.WithScopes(
+		compute.CloudPlatformScope,
+		compute.ComputeScope,
+	)
+	// NOTE: prepend, so we don't override user-specified scopes.
+	opts = append([]option.ClientOption{scopesOption}, opts...)
+	httpClient, endpoint, err := htransport.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	service, err := compute.New(httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	if endpoint != "" {
+		service.BasePath = endpoint
+	}
+	return service, httpClient, nil
+}
+
 // Try to get credentials from environment variables or from
 // the environment the machine is running in
-func serviceFromEnvironment() (*compute.Service, error) {
+func serviceFromEnvironment() (*compute.Service, *http.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
-	service, err := compute.NewService(ctx)
-	return service, err
+	return newComputeService(ctx)
 }
 
 func (c *gceClient) autodetectProject() (string, error) {
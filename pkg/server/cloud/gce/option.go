@@ -21,7 +21,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/option"
 )
 
@@ -63,6 +62,20 @@ func (w WithPrivateIPOnly) Apply(c *gceClient) error {
 	return nil
 }
 
+type WithShieldedVM bool
+
+func (w WithShieldedVM) Apply(c *gceClient) error {
+	c.shieldedVM = bool(w)
+	return nil
+}
+
+type WithConfidentialVM bool
+
+func (w WithConfidentialVM) Apply(c *gceClient) error {
+	c.confidentialVM = bool(w)
+	return nil
+}
+
 type WithCredentialsFile string
 
 func (w WithCredentialsFile) Apply(c *gceClient) error {
@@ -76,7 +89,7 @@ func (w WithCredentialsFile) Apply(c *gceClient) error {
 	var err error
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
-	c.service, err = compute.NewService(ctx, option.WithCredentialsFile(string(w)))
+	c.service, c.httpClient, err = newComputeService(ctx, option.WithCredentialsFile(string(w)))
 	return err
 }
 
@@ -117,6 +130,6 @@ func (w withCredentials) Apply(c *gceClient) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
-	c.service, err = compute.NewService(ctx, option.WithCredentialsJSON(b))
+	c.service, c.httpClient, err = newComputeService(ctx, option.WithCredentialsJSON(b))
 	return err
 }
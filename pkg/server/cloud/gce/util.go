@@ -24,6 +24,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/hash"
 	uuid "github.com/satori/go.uuid"
 	"google.golang.org/api/googleapi"
@@ -45,6 +47,36 @@ func isHTTPErrorCode(err error, code int) bool {
 	return ok && apiErr.Code == code
 }
 
+// mapGCEError converts a compute API error into one of the cloud
+// package's typed errors when its status code or reason identifies a
+// known class (throttling, auth, quota, missing resource), so
+// controllers can branch on the class instead of parsing GCE
+// messages.  Other errors are wrapped with the given message like
+// util.WrapError.
+func mapGCEError(err error, format string, args ...interface{}) error {
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return &cloud.NotFoundError{OriginalError: err.Error()}
+		case http.StatusTooManyRequests:
+			return &cloud.ThrottledError{OriginalError: err.Error()}
+		case http.StatusUnauthorized, http.StatusForbidden:
+			// GCE reports rate limits and quotas as 403s, the reason
+			// tells them apart from real permission errors.
+			for _, item := range apiErr.Errors {
+				switch item.Reason {
+				case "rateLimitExceeded", "userRateLimitExceeded":
+					return &cloud.ThrottledError{OriginalError: err.Error()}
+				case "quotaExceeded", "limitExceeded":
+					return &cloud.QuotaError{OriginalError: err.Error()}
+				}
+			}
+			return &cloud.AuthError{OriginalError: err.Error()}
+		}
+	}
+	return util.WrapError(err, format, args...)
+}
+
 func CreateKipCellNetworkTag(controllerID string) string {
 	return fmt.Sprintf("kip-%s", controllerID)
 }
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+)
+
+func gceErrorWithReason(code int, reason string) error {
+	apiErr := &googleapi.Error{Code: code, Message: "boom"}
+	if reason != "" {
+		apiErr.Errors = []googleapi.ErrorItem{{Reason: reason}}
+	}
+	return apiErr
+}
+
+func TestMapGCEError(t *testing.T) {
+	tests := []struct {
+		code    int
+		reason  string
+		checker func(error) bool
+	}{
+		{404, "notFound", cloud.IsNotFoundError},
+		{429, "", cloud.IsThrottledError},
+		{403, "rateLimitExceeded", cloud.IsThrottledError},
+		{403, "userRateLimitExceeded", cloud.IsThrottledError},
+		{403, "quotaExceeded", cloud.IsQuotaError},
+		{403, "forbidden", cloud.IsAuthError},
+		{401, "", cloud.IsAuthError},
+	}
+	for i, tc := range tests {
+		err := mapGCEError(gceErrorWithReason(tc.code, tc.reason), "wrapped")
+		assert.True(t, tc.checker(err), "case %d: %d/%s mapped to %T",
+			i, tc.code, tc.reason, err)
+	}
+
+	// other statuses and non-API errors get wrapped with the message
+	err := mapGCEError(gceErrorWithReason(500, ""), "wrapped")
+	assert.Contains(t, err.Error(), "wrapped")
+	err = mapGCEError(fmt.Errorf("plain error"), "wrapped")
+	assert.Contains(t, err.Error(), "wrapped")
+}
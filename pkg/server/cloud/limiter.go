@@ -0,0 +1,154 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/uber-go/atomic"
+	"k8s.io/klog"
+)
+
+// LimiterStats is a snapshot of the limiter's counters.  QueueDepth
+// is the number of calls currently waiting for or holding a slot,
+// ThrottledCalls counts calls that had to wait because all slots were
+// in use.
+type LimiterStats struct {
+	MaxConcurrentCalls int   `json:"maxConcurrentCalls"`
+	QueueDepth         int64 `json:"queueDepth"`
+	ThrottledCalls     int64 `json:"throttledCalls"`
+}
+
+// LimitedClient wraps a CloudClient and bounds the number of
+// launch, terminate and describe calls that can be in flight at
+// once.  Bursty scheduling can otherwise fire enough simultaneous
+// cloud API calls to trip provider rate limits.  Calls beyond the
+// limit queue up instead of failing.  All other CloudClient methods
+// pass through unlimited.
+type LimitedClient struct {
+	CloudClient
+	sem            chan struct{}
+	queueDepth     *atomic.Int64
+	throttledCalls *atomic.Int64
+}
+
+func NewLimitedClient(client CloudClient, maxConcurrentCalls int) *LimitedClient {
+	return &LimitedClient{
+		CloudClient:    client,
+		sem:            make(chan struct{}, maxConcurrentCalls),
+		queueDepth:     atomic.NewInt64(0),
+		throttledCalls: atomic.NewInt64(0),
+	}
+}
+
+// acquire blocks until a call slot is free and returns the function
+// that releases the slot.
+func (c *LimitedClient) acquire() func() {
+	c.queueDepth.Inc()
+	select {
+	case c.sem <- struct{}{}:
+	default:
+		c.throttledCalls.Inc()
+		c.sem <- struct{}{}
+	}
+	return func() {
+		c.queueDepth.Dec()
+		<-c.sem
+	}
+}
+
+// Unwrap returns the underlying client when callers need the
+// provider-specific implementation (type assertions on the
+// CloudClient won't see through the limiter).
+func Unwrap(client CloudClient) CloudClient {
+	if limited, ok := client.(*LimitedClient); ok {
+		return limited.CloudClient
+	}
+	return client
+}
+
+func (c *LimitedClient) Stats() LimiterStats {
+	return LimiterStats{
+		MaxConcurrentCalls: cap(c.sem),
+		QueueDepth:         c.queueDepth.Load(),
+		ThrottledCalls:     c.throttledCalls.Load(),
+	}
+}
+
+func (c *LimitedClient) StartNode(node *api.Node, image Image, metadata string) (*StartNodeResult, error) {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.StartNode(node, image, metadata)
+}
+
+func (c *LimitedClient) StartSpotNode(node *api.Node, image Image, metadata string) (*StartNodeResult, error) {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.StartSpotNode(node, image, metadata)
+}
+
+func (c *LimitedClient) StopInstance(instanceID string) error {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.StopInstance(instanceID)
+}
+
+func (c *LimitedClient) ListInstances() ([]CloudInstance, error) {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.ListInstances()
+}
+
+func (c *LimitedClient) ListInstancesFilterID(ids []string) ([]CloudInstance, error) {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.ListInstancesFilterID(ids)
+}
+
+func (c *LimitedClient) AttachDataVolumes(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error) {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.AttachDataVolumes(node, volumes)
+}
+
+func (c *LimitedClient) ReattachDataVolumes(node *api.Node, volumeIDs []string) error {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.ReattachDataVolumes(node, volumeIDs)
+}
+
+func (c *LimitedClient) DeleteDataVolumes(volumeIDs []string) error {
+	release := c.acquire()
+	defer release()
+	return c.CloudClient.DeleteDataVolumes(volumeIDs)
+}
+
+// Start and Dump let the LimitedClient be registered as a controller
+// so its counters show up in dumps next to the other controllers.
+func (c *LimitedClient) Start(quit <-chan struct{}, wg *sync.WaitGroup) {
+}
+
+func (c *LimitedClient) Dump() []byte {
+	b, err := json.MarshalIndent(c.Stats(), "", "    ")
+	if err != nil {
+		klog.Errorln("Error dumping data from cloud API limiter", err)
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/atomic"
+)
+
+func TestLimitedClientBoundsConcurrency(t *testing.T) {
+	maxCalls := 2
+	numCallers := 10
+	inFlight := atomic.NewInt64(0)
+	maxInFlight := atomic.NewInt64(0)
+	release := make(chan struct{})
+	mock := &MockCloudClient{
+		Stopper: func(instanceID string) error {
+			cur := inFlight.Inc()
+			for {
+				max := maxInFlight.Load()
+				if cur <= max || maxInFlight.CAS(max, cur) {
+					break
+				}
+			}
+			<-release
+			inFlight.Dec()
+			return nil
+		},
+	}
+	client := NewLimitedClient(mock, maxCalls)
+	wg := sync.WaitGroup{}
+	for i := 0; i < numCallers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.StopInstance("i-123"))
+		}()
+	}
+	close(release)
+	wg.Wait()
+	assert.True(t, maxInFlight.Load() <= int64(maxCalls))
+	stats := client.Stats()
+	assert.Equal(t, maxCalls, stats.MaxConcurrentCalls)
+	assert.Equal(t, int64(0), stats.QueueDepth)
+}
+
+func TestLimitedClientCountsThrottledCalls(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mock := &MockCloudClient{
+		Stopper: func(instanceID string) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		},
+	}
+	client := NewLimitedClient(mock, 1)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, client.StopInstance("i-1"))
+	}()
+	// Wait for the first call to hold the only slot, then fire a
+	// second call that has to queue behind it.
+	<-started
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, client.StopInstance("i-2"))
+	}()
+	for client.Stats().ThrottledCalls == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-started
+	wg.Wait()
+	stats := client.Stats()
+	assert.Equal(t, int64(1), stats.ThrottledCalls)
+	assert.Equal(t, int64(0), stats.QueueDepth)
+}
@@ -34,14 +34,34 @@ type MockCloudClient struct {
 	VPCCIDRs     []string
 	Subnets      []SubnetAttributes
 
-	Starter             func(node *api.Node, image Image, metadata string) (*StartNodeResult, error)
-	SpotStarter         func(node *api.Node, image Image, metadata string) (*StartNodeResult, error)
-	Stopper             func(instanceID string) error
-	Waiter              func(node *api.Node) ([]api.NetworkAddress, error)
-	Lister              func() ([]CloudInstance, error)
-	Resizer             func(node *api.Node, size int64) (error, bool)
-	ContainerAuthorizer func() (string, string, error)
-	ImageGetter         func(BootImageSpec) (Image, error)
+	Starter              func(node *api.Node, image Image, metadata string) (*StartNodeResult, error)
+	SpotStarter          func(node *api.Node, image Image, metadata string) (*StartNodeResult, error)
+	Stopper              func(instanceID string) error
+	Halter               func(instanceID string) error
+	Resumer              func(instanceID string) error
+	Rebooter             func(instanceID string) error
+	Waiter               func(node *api.Node) ([]api.NetworkAddress, error)
+	Lister               func() ([]CloudInstance, error)
+	Resizer              func(node *api.Node, size int64) (error, bool)
+	DataVolumeValidator  func(volumes []api.DataVolumeSpec) error
+	PlacementValidator   func(placement *api.PlacementSpec, spot bool) error
+	EncryptionValidator  func(encrypted *bool, kmsKeyID string) error
+	VMSecurityValidator  func(shieldedVM, confidentialVM *bool, instanceType string) error
+	OverridesValidator   func(overrides string) error
+	InterfaceValidator   func(interfaces []api.NetworkInterfaceSpec, instanceType string) error
+	InterfaceAttacher    func(node *api.Node, interfaces []api.NetworkInterfaceSpec) ([]string, error)
+	DataVolumeAttacher   func(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error)
+	DataVolumeReattacher func(node *api.Node, volumeIDs []string) error
+	DataVolumeAZGetter   func(volumeID string) (string, error)
+	DataVolumeDeleter    func(volumeIDs []string) error
+	ContainerAuthorizer  func() (string, string, error)
+	ImageGetter          func(BootImageSpec) (Image, error)
+
+	PodSecurityGroupEnsurer func(podName string, egress []EgressRule) (string, error)
+	PodSecurityGroupDeleter func(podName string) error
+
+	ElasticIPAssociator    func(node *api.Node, eip string) error
+	ElasticIPDisassociator func(eip string) error
 
 	InstanceListerFilter func([]string) ([]CloudInstance, error)
 	InstanceLister       func() ([]CloudInstance, error)
@@ -86,6 +106,27 @@ func (m *MockCloudClient) StopInstance(instanceID string) error {
 	return m.Stopper(instanceID)
 }
 
+func (m *MockCloudClient) HaltInstance(instanceID string) error {
+	if m.Halter == nil {
+		return nil
+	}
+	return m.Halter(instanceID)
+}
+
+func (m *MockCloudClient) ResumeInstance(instanceID string) error {
+	if m.Resumer == nil {
+		return nil
+	}
+	return m.Resumer(instanceID)
+}
+
+func (m *MockCloudClient) RebootInstance(instanceID string) error {
+	if m.Rebooter == nil {
+		return nil
+	}
+	return m.Rebooter(instanceID)
+}
+
 func (m *MockCloudClient) WaitForRunning(node *api.Node) ([]api.NetworkAddress, error) {
 	return m.Waiter(node)
 }
@@ -94,6 +135,83 @@ func (m *MockCloudClient) ResizeVolume(node *api.Node, size int64) (error, bool)
 	return m.Resizer(node, size)
 }
 
+func (m *MockCloudClient) ValidateDataVolumes(volumes []api.DataVolumeSpec) error {
+	if m.DataVolumeValidator == nil {
+		return nil
+	}
+	return m.DataVolumeValidator(volumes)
+}
+
+func (m *MockCloudClient) ValidatePlacement(placement *api.PlacementSpec, spot bool) error {
+	if m.PlacementValidator == nil {
+		return nil
+	}
+	return m.PlacementValidator(placement, spot)
+}
+
+func (m *MockCloudClient) ValidateVolumeEncryption(encrypted *bool, kmsKeyID string) error {
+	if m.EncryptionValidator == nil {
+		return nil
+	}
+	return m.EncryptionValidator(encrypted, kmsKeyID)
+}
+
+func (m *MockCloudClient) ValidateVMSecurity(shieldedVM, confidentialVM *bool, instanceType string) error {
+	if m.VMSecurityValidator == nil {
+		return nil
+	}
+	return m.VMSecurityValidator(shieldedVM, confidentialVM, instanceType)
+}
+
+func (m *MockCloudClient) ValidateLaunchOverrides(overrides string) error {
+	if m.OverridesValidator == nil {
+		return nil
+	}
+	return m.OverridesValidator(overrides)
+}
+
+func (m *MockCloudClient) ValidateNetworkInterfaces(interfaces []api.NetworkInterfaceSpec, instanceType string) error {
+	if m.InterfaceValidator == nil {
+		return nil
+	}
+	return m.InterfaceValidator(interfaces, instanceType)
+}
+
+func (m *MockCloudClient) AttachNetworkInterfaces(node *api.Node, interfaces []api.NetworkInterfaceSpec) ([]string, error) {
+	if m.InterfaceAttacher == nil {
+		return nil, nil
+	}
+	return m.InterfaceAttacher(node, interfaces)
+}
+
+func (m *MockCloudClient) AttachDataVolumes(node *api.Node, volumes []api.DataVolumeSpec) ([]string, error) {
+	if m.DataVolumeAttacher == nil {
+		return nil, nil
+	}
+	return m.DataVolumeAttacher(node, volumes)
+}
+
+func (m *MockCloudClient) ReattachDataVolumes(node *api.Node, volumeIDs []string) error {
+	if m.DataVolumeReattacher == nil {
+		return nil
+	}
+	return m.DataVolumeReattacher(node, volumeIDs)
+}
+
+func (m *MockCloudClient) GetDataVolumeAZ(volumeID string) (string, error) {
+	if m.DataVolumeAZGetter == nil {
+		return "", nil
+	}
+	return m.DataVolumeAZGetter(volumeID)
+}
+
+func (m *MockCloudClient) DeleteDataVolumes(volumeIDs []string) error {
+	if m.DataVolumeDeleter == nil {
+		return nil
+	}
+	return m.DataVolumeDeleter(volumeIDs)
+}
+
 func (m *MockCloudClient) GetRegistryAuth() (string, string, error) {
 	return m.ContainerAuthorizer()
 }
@@ -215,10 +333,38 @@ func (m *MockCloudClient) AttachSecurityGroups(node *api.Node, groups []string)
 	return nil
 }
 
+func (m *MockCloudClient) EnsurePodSecurityGroup(podName string, egress []EgressRule) (string, error) {
+	if m.PodSecurityGroupEnsurer == nil {
+		return "", nil
+	}
+	return m.PodSecurityGroupEnsurer(podName, egress)
+}
+
+func (m *MockCloudClient) DeletePodSecurityGroup(podName string) error {
+	if m.PodSecurityGroupDeleter == nil {
+		return nil
+	}
+	return m.PodSecurityGroupDeleter(podName)
+}
+
 func (m *MockCloudClient) AssignInstanceProfile(node *api.Node, instanceProfile string) error {
 	return nil
 }
 
+func (m *MockCloudClient) AssociateElasticIP(node *api.Node, eip string) error {
+	if m.ElasticIPAssociator == nil {
+		return nil
+	}
+	return m.ElasticIPAssociator(node, eip)
+}
+
+func (m *MockCloudClient) DisassociateElasticIP(eip string) error {
+	if m.ElasticIPDisassociator == nil {
+		return nil
+	}
+	return m.ElasticIPDisassociator(eip)
+}
+
 func NewMockClient() *MockCloudClient {
 	net := &MockCloudClient{
 		Instances:          make(map[string]CloudInstance),
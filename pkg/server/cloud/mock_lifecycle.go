@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+)
+
+// NewLifecycleMockClient returns a mock cloud client that simulates the
+// full instance lifecycle in memory: launches allocate an instance ID
+// and a private address, take launchDelay to complete and fail with
+// probability failureRate.  It backs the mock provider in the cloud
+// section of provider.yaml, so the controller stack can run without
+// cloud credentials for local development, demos and integration tests.
+func NewLifecycleMockClient(launchDelay time.Duration, failureRate float64) *MockCloudClient {
+	client := NewMockClient()
+	var mtx sync.Mutex
+	numLaunched := 0
+	addresses := make(map[string][]api.NetworkAddress)
+
+	maybeFail := func(op string) error {
+		if failureRate > 0.0 && rand.Float64() < failureRate {
+			return fmt.Errorf("mock cloud: simulated %s failure", op)
+		}
+		return nil
+	}
+
+	start := func(node *api.Node, image Image, metadata string) (*StartNodeResult, error) {
+		if err := maybeFail("launch"); err != nil {
+			return nil, err
+		}
+		time.Sleep(launchDelay)
+		mtx.Lock()
+		numLaunched++
+		n := numLaunched
+		instanceID := fmt.Sprintf("i-mock%08d", n)
+		addresses[instanceID] = api.NewNetworkAddresses(
+			fmt.Sprintf("172.16.%d.%d", (n/250)%250, n%250+1), "")
+		mtx.Unlock()
+		az := client.Subnets[n%len(client.Subnets)].AZ
+		if node.Spec.Placement.AvailabilityZone != "" {
+			az = node.Spec.Placement.AvailabilityZone
+		}
+		client.AddInstances(CloudInstance{
+			ID:       instanceID,
+			NodeName: node.Name,
+		})
+		return &StartNodeResult{
+			InstanceID:       instanceID,
+			AvailabilityZone: az,
+		}, nil
+	}
+	client.Starter = start
+	client.SpotStarter = start
+
+	client.Waiter = func(node *api.Node) ([]api.NetworkAddress, error) {
+		if err := maybeFail("status"); err != nil {
+			return nil, err
+		}
+		mtx.Lock()
+		defer mtx.Unlock()
+		addrs, exists := addresses[node.Status.InstanceID]
+		if !exists {
+			return nil, fmt.Errorf("Instance %s does not exist",
+				node.Status.InstanceID)
+		}
+		return addrs, nil
+	}
+
+	client.Stopper = func(instanceID string) error {
+		if err := maybeFail("terminate"); err != nil {
+			return err
+		}
+		if _, exists := client.Instances[instanceID]; !exists {
+			return fmt.Errorf("Instance %s does not exist", instanceID)
+		}
+		delete(client.Instances, instanceID)
+		mtx.Lock()
+		delete(addresses, instanceID)
+		mtx.Unlock()
+		return nil
+	}
+
+	client.Resizer = func(node *api.Node, size int64) (error, bool) {
+		return nil, false
+	}
+
+	client.ContainerAuthorizer = func() (string, string, error) {
+		return "", "", nil
+	}
+
+	return client
+}
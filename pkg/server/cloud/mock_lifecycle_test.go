@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleMockClient(t *testing.T) {
+	client := NewLifecycleMockClient(0, 0.0)
+	node := api.GetFakeNode()
+	result, err := client.StartNode(node, Image{}, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.InstanceID)
+	assert.NotEmpty(t, result.AvailabilityZone)
+	node.Status.InstanceID = result.InstanceID
+
+	addrs, err := client.WaitForRunning(node)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, api.GetPrivateIP(addrs))
+
+	insts, err := client.ListInstances()
+	assert.NoError(t, err)
+	assert.Len(t, insts, 1)
+
+	assert.NoError(t, client.StopInstance(result.InstanceID))
+	_, err = client.WaitForRunning(node)
+	assert.Error(t, err)
+	assert.Error(t, client.StopInstance(result.InstanceID))
+}
+
+func TestLifecycleMockClientFailures(t *testing.T) {
+	client := NewLifecycleMockClient(0, 1.0)
+	node := api.GetFakeNode()
+	_, err := client.StartNode(node, Image{}, "")
+	assert.Error(t, err)
+	insts, err := client.ListInstances()
+	assert.NoError(t, err)
+	assert.Len(t, insts, 0)
+}
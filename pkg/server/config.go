@@ -20,9 +20,13 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/api/validation"
@@ -31,6 +35,7 @@ import (
 	"github.com/elotl/kip/pkg/server/cloud/azure"
 	"github.com/elotl/kip/pkg/server/cloud/gce"
 	"github.com/elotl/kip/pkg/server/nodemanager"
+	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 	vutil "github.com/elotl/kip/pkg/util/validation"
 	"github.com/elotl/kip/pkg/util/validation/field"
@@ -49,13 +54,24 @@ var (
 	defaultCloudAPIHealthCheckInterval = 60
 	defaultCloudAPIHealthCheckTimeout  = 180
 	defaultStatusHealthCheckTimeout    = 90
+	defaultAgentHealthCheckInterval    = 30
+	defaultOrphanGracePeriod           = 300
+	defaultWebhookRetries              = 3
+	defaultItzoRetries                 = 2
+	defaultAWSAPIConcurrencyLimit      = 20
+	defaultAzureAPIConcurrencyLimit    = 10
+	defaultGCEAPIConcurrencyLimit      = 20
 
 	defaultCPUCapacity    = resource.MustParse("20")
 	defaultMemoryCapacity = resource.MustParse("100Gi")
 	defaultPodCapacity    = resource.MustParse("20")
 )
 
-// ServerConfigFile stores the parsed json from provider.yaml
+// ServerConfigFile stores the parsed json from provider.yaml.
+// Configuration is layered: values from the config file are applied
+// first, then any KIP_* environment variables (declared in `env`
+// struct tags below), then command-line flags, with later sources
+// overriding earlier ones.
 type ServerConfigFile struct {
 	api.TypeMeta `json:",inline"`
 	Cloud        MultiCloudConfig `json:"cloud"`
@@ -63,6 +79,114 @@ type ServerConfigFile struct {
 	Cells        CellsConfig      `json:"cells"`
 	Testing      TestingConfig    `json:"testing"`
 	Kubelet      KubeletConfig    `json:"kubelet"`
+	Webhook      WebhookConfig    `json:"webhook"`
+	Logging      LoggingConfig    `json:"logging"`
+	Debug        DebugConfig      `json:"debug"`
+	Events       EventsConfig     `json:"events"`
+	Audit        AuditConfig      `json:"audit"`
+	Logs         LogsConfig       `json:"logs"`
+	// Quotas caps the resources each namespace can consume, keyed by
+	// namespace name.  The entry under the key "default" applies to
+	// any namespace without an explicit entry.  An empty map disables
+	// quota enforcement.
+	Quotas map[string]NamespaceQuota `json:"quotas,omitempty"`
+}
+
+// NamespaceQuota limits the aggregate resources of all live (not yet
+// terminated) pods in a namespace.  Zero or empty values leave that
+// dimension unlimited.
+type NamespaceQuota struct {
+	// CPU is the maximum total vCPUs requested by pods in the
+	// namespace, e.g. "20" or "2500m".
+	CPU string `json:"cpu,omitempty"`
+	// Memory is the maximum total memory requested by pods in the
+	// namespace, e.g. "64Gi".
+	Memory string `json:"memory,omitempty"`
+	// Pods is the maximum number of live pods in the namespace.
+	Pods int `json:"pods,omitempty"`
+}
+
+// WebhookConfig configures optional callbacks POSTed to external
+// systems when pods transition between phases.
+type WebhookConfig struct {
+	// PodPhaseURL, if set, receives a JSON payload for every pod
+	// phase transition.
+	PodPhaseURL string `json:"podPhaseURL" env:"KIP_WEBHOOK_POD_PHASE_URL"`
+	// Retries is the number of times a failed POST is retried.
+	Retries int `json:"retries" env:"KIP_WEBHOOK_RETRIES"`
+}
+
+const (
+	// LogFormatText is the default human-readable klog format.
+	LogFormatText = "text"
+	// LogFormatJSON emits controller logs as JSON lines.
+	LogFormatJSON = "json"
+)
+
+// LoggingConfig controls the format of the controllers' logs.
+type LoggingConfig struct {
+	// Format is "text" (the default, human-readable klog output) or
+	// "json", which makes the controllers emit structured JSON lines
+	// with the level, timestamp, component and pod/node identifiers.
+	Format string `json:"format" env:"KIP_LOG_FORMAT"`
+}
+
+// DebugConfig enables optional debugging facilities that are off by
+// default.
+type DebugConfig struct {
+	// PprofAddress, if set, serves Go's net/http/pprof handlers over
+	// plain HTTP on the given address, e.g. "localhost:6060".  Empty
+	// (the default) disables the endpoint.  Profiles expose internal
+	// controller state, so keep this bound to localhost unless they
+	// should be reachable from outside the pod.
+	PprofAddress string `json:"pprofAddress" env:"KIP_DEBUG_PPROF_ADDRESS"`
+}
+
+// EventsConfig controls how events emitted by the controllers are
+// aggregated before they're written to the event registry.
+type EventsConfig struct {
+	// AggregationWindow is the number of seconds during which
+	// repeated identical events are folded into a single stored
+	// event with a count and last-seen timestamp instead of hammering
+	// etcd with duplicates.  Zero uses the built-in default of 10
+	// minutes, a negative value turns aggregation off.
+	AggregationWindow int `json:"aggregationWindow" env:"KIP_EVENTS_AGGREGATION_WINDOW"`
+	// DedupKeyFields lists the event fields that make two events
+	// identical for aggregation: any of "object", "status", "source"
+	// and "message".  Empty uses all of them.
+	DedupKeyFields []string `json:"dedupKeyFields" env:"KIP_EVENTS_DEDUP_KEY_FIELDS"`
+}
+
+// AuditConfig controls the in-memory trail of controller decisions
+// served by "kipctl audit".
+type AuditConfig struct {
+	// Size is the number of decisions kept in memory, older decisions
+	// are dropped.  Zero uses the built-in default of 1000.
+	Size int `json:"size" env:"KIP_AUDIT_SIZE"`
+	// File, if set, appends every decision to this file as a JSON
+	// line so the trail survives controller restarts.  The in-memory
+	// trail is not reloaded from the file.
+	File string `json:"file" env:"KIP_AUDIT_FILE"`
+}
+
+// LogsConfig controls how long pod logs saved in the log registry are
+// retained after the pod is gone.
+type LogsConfig struct {
+	// RetentionAge is the number of seconds saved pod logs are kept
+	// before the garbage controller prunes them.  Zero uses the
+	// built-in default of 1 hour.
+	RetentionAge int `json:"retentionAge" env:"KIP_LOGS_RETENTION_AGE"`
+	// RetentionSize is the maximum total size in bytes of saved pod
+	// logs.  Once the total exceeds it the oldest logs are pruned
+	// first, even if they haven't reached the retention age.  Zero
+	// means no size limit.
+	RetentionSize int64 `json:"retentionSize" env:"KIP_LOGS_RETENTION_SIZE"`
+	// Compression is the content encoding applied to logs before
+	// they are written to etcd, reducing storage and bandwidth in
+	// log-heavy clusters.  "none" (or empty) stores logs as-is,
+	// "gzip" compresses them.  Logs are decompressed transparently
+	// on read, old uncompressed entries stay readable.
+	Compression string `json:"compression" env:"KIP_LOGS_COMPRESSION"`
 }
 
 // Kubelet stores kubelet-specific configuration such as capacity and labels.
@@ -74,12 +198,36 @@ type KubeletConfig struct {
 	Pods     *resource.Quantity `json:"pods"`
 	Capacity v1.ResourceList    `json:"capacity"`
 	Labels   map[string]string  `json:"labels"`
+	// AllowedUnsafeSysctls lists unsafe sysctls pods are permitted to
+	// set, either exact names or prefixes ending in "*".
+	AllowedUnsafeSysctls []string `json:"allowedUnsafeSysctls" env:"KIP_KUBELET_ALLOWED_UNSAFE_SYSCTLS"`
+	// APIPort, if set, makes the provider serve the kubelet API
+	// (logs, exec, stats) itself on this port with TLS, and
+	// advertise it as the node's daemon endpoint.  Zero leaves
+	// serving the kubelet API up to the command-line options.
+	APIPort int `json:"apiPort" env:"KIP_KUBELET_API_PORT"`
 }
 
 type MultiCloudConfig struct {
 	AWS   *AWSConfig   `json:"aws,omitempty"`
 	GCE   *GCEConfig   `json:"gce,omitempty"`
 	Azure *AzureConfig `json:"azure,omitempty"`
+	Mock  *MockConfig  `json:"mock,omitempty"`
+}
+
+// MockConfig selects the mock cloud provider: cells are simulated in
+// memory and paired with a mock itzo client, so the full controller
+// stack can run without cloud credentials.  Only useful for local
+// development and CI; a controller configured this way can never be
+// switched to a real cloud.
+type MockConfig struct {
+	// LaunchDelay is how long simulated instance launches take, as a
+	// duration string like "2s".  Empty or zero launches instantly.
+	LaunchDelay string `json:"launchDelay,omitempty"`
+	// FailureRate is the probability, between 0.0 and 1.0, that a
+	// simulated launch, terminate or status call fails.  Useful for
+	// robustness testing; zero never fails.
+	FailureRate float64 `json:"failureRate,omitempty"`
 }
 
 type AWSConfig struct {
@@ -89,6 +237,36 @@ type AWSConfig struct {
 	VPCID           string `json:"vpcID,omitempty"`
 	SubnetID        string `json:"subnetID,omitempty"`
 	EcsClusterName  string `json:"ecsClusterName"`
+	// Maximum number of in-flight launch/terminate/describe calls to
+	// the cloud API.  Calls beyond the limit queue up.  Zero or
+	// unset uses the provider default.
+	APIConcurrencyLimit int `json:"apiConcurrencyLimit,omitempty"`
+	// LaunchTemplateID optionally references an existing EC2 launch
+	// template that supplies the AMI, networking and IMDS settings
+	// for cells.  Kip merges its per-node parameters (instance type,
+	// tags, user data, block devices) on top of the template.
+	LaunchTemplateID string `json:"launchTemplateID,omitempty"`
+	// LaunchTemplateVersion is the launch template version to use: a
+	// version number, "$Latest" or "$Default".  Empty uses
+	// "$Default".
+	LaunchTemplateVersion string `json:"launchTemplateVersion,omitempty"`
+	// IMDSv2Required launches cells with IMDSv2 enforced: metadata
+	// requests must carry a session token and IMDSv1 is disabled.
+	// Off by default to keep the current behavior.
+	IMDSv2Required bool `json:"imdsv2Required,omitempty"`
+	// Tenancy is the default EC2 instance tenancy for cells:
+	// "default", "dedicated" or "host".  Empty means "default".
+	// Pods can override it with the tenancy annotation.
+	Tenancy string `json:"tenancy,omitempty"`
+	// DedicatedHostID pins cells launched with host tenancy to a
+	// specific dedicated host.  Requires tenancy "host".
+	DedicatedHostID string `json:"dedicatedHostID,omitempty"`
+	// IMDSv2HopLimit sets the metadata PUT response hop limit when
+	// IMDSv2Required is set.  The AWS default of 1 blocks metadata
+	// access from containerized workloads running on the cell; use 2
+	// if units need to reach the metadata service.  Zero keeps the
+	// AWS default.
+	IMDSv2HopLimit int `json:"imdsv2HopLimit,omitempty"`
 }
 
 // See https://github.com/Azure/azure-sdk-for-go/blob/master/README.md
@@ -102,6 +280,8 @@ type AzureConfig struct {
 	TenantID       string `json:"tenantID"`
 	ClientID       string `json:"clientID"`
 	ClientSecret   string `json:"clientSecret"`
+	// See AWSConfig.APIConcurrencyLimit.
+	APIConcurrencyLimit int `json:"apiConcurrencyLimit,omitempty"`
 }
 
 type GCEConfig struct {
@@ -111,6 +291,17 @@ type GCEConfig struct {
 	Zone            string          `json:"zone,omitempty"`
 	VPCName         string          `json:"vpcName,omitempty"`
 	SubnetName      string          `json:"subnetName,omitempty"`
+	// See AWSConfig.APIConcurrencyLimit.
+	APIConcurrencyLimit int `json:"apiConcurrencyLimit,omitempty"`
+	// ShieldedVM launches every cell as a Shielded VM with secure
+	// boot, vTPM and integrity monitoring enabled.  Pods can override
+	// it with the shielded-vm annotation.
+	ShieldedVM bool `json:"shieldedVM,omitempty"`
+	// ConfidentialVM launches every cell as a Confidential VM with
+	// memory encrypted in use.  Confidential VMs require an AMD EPYC
+	// based instance family (e.g. n2d) and imply shielded VMs.  Pods
+	// can override it with the confidential-vm annotation.
+	ConfidentialVM bool `json:"confidentialVM,omitempty"`
 }
 
 type GCECredentials struct {
@@ -123,37 +314,368 @@ type EtcdConfig struct {
 	Internal InternalEtcdConfig `json:"internal"`
 }
 
+// EtcdClientConfig points kip at an external etcd cluster.  When
+// endpoints are set, the embedded etcd server isn't started and all
+// registries use the external cluster, enabling HA deployments with a
+// managed etcd.  The TLS files are optional for plain http endpoints;
+// certFile and keyFile enable client certificate authentication.
 type EtcdClientConfig struct {
-	Endpoints []string `json:"endpoints"`
-	CertFile  string   `json:"certFile"`
-	KeyFile   string   `json:"keyFile"`
-	CAFile    string   `json:"caFile"`
+	Endpoints []string `json:"endpoints" env:"KIP_ETCD_CLIENT_ENDPOINTS"`
+	CertFile  string   `json:"certFile" env:"KIP_ETCD_CLIENT_CERT_FILE"`
+	KeyFile   string   `json:"keyFile" env:"KIP_ETCD_CLIENT_KEY_FILE"`
+	CAFile    string   `json:"caFile" env:"KIP_ETCD_CLIENT_CA_FILE"`
 }
 
 type InternalEtcdConfig struct {
-	DataDir    string `json:"dataDir"`
-	ConfigFile string `json:"configFile"`
+	DataDir    string `json:"dataDir" env:"KIP_ETCD_INTERNAL_DATA_DIR"`
+	ConfigFile string `json:"configFile" env:"KIP_ETCD_INTERNAL_CONFIG_FILE"`
+	// MaintenanceInterval is the number of seconds between
+	// maintenance passes of the embedded etcd: old revisions are
+	// compacted and the backend database is defragmented so a
+	// long-running controller's store doesn't grow unbounded.  Zero
+	// disables scheduled maintenance, leaving only etcd's hourly
+	// auto-compaction.
+	MaintenanceInterval int `json:"maintenanceInterval" env:"KIP_ETCD_INTERNAL_MAINTENANCE_INTERVAL"`
 }
 
 type CellsConfig struct {
-	BootImageSpec       cloud.BootImageSpec           `json:"bootImageSpec"`
-	DefaultInstanceType string                        `json:"defaultInstanceType"`
-	DefaultVolumeSize   string                        `json:"defaultVolumeSize"`
+	BootImageSpec cloud.BootImageSpec `json:"bootImageSpec"`
+	// FastBootImageSpec optionally selects a pre-baked image (a
+	// snapshot with itzo and commonly used container images already
+	// installed) that is preferred over bootImageSpec to cut cell
+	// cold start times.  When the spec doesn't resolve to an image,
+	// cells launch from bootImageSpec instead.
+	FastBootImageSpec   cloud.BootImageSpec `json:"fastBootImageSpec"`
+	DefaultInstanceType string              `json:"defaultInstanceType" env:"KIP_CELLS_DEFAULT_INSTANCE_TYPE"`
+	DefaultVolumeSize   string              `json:"defaultVolumeSize" env:"KIP_CELLS_DEFAULT_VOLUME_SIZE"`
+	// MaxVolumeSize caps the root volume a pod can request (via the
+	// volume size annotation or ephemeral-storage resources).  Pods
+	// asking for more are rejected at creation.  Empty means no cap.
+	MaxVolumeSize string `json:"maxVolumeSize" env:"KIP_CELLS_MAX_VOLUME_SIZE"`
+	// VolumeEncrypted, if set, is the default for encryption at rest
+	// of cells' root volumes.  Pods can override it with the volume
+	// encryption annotation.  Unset leaves the cloud account's
+	// default encryption setting in charge.
+	VolumeEncrypted *bool `json:"volumeEncrypted" env:"KIP_CELLS_VOLUME_ENCRYPTED"`
+	// VolumeKMSKey is the default customer managed key (key ID,
+	// alias or ARN) used to encrypt cells' root volumes.  Setting a
+	// key implies volumeEncrypted.  Empty uses the account's default
+	// key.
+	VolumeKMSKey string `json:"volumeKMSKey" env:"KIP_CELLS_VOLUME_KMS_KEY"`
+	// StandbyCells configures warm pools of pre-booted cells, one
+	// pool per instance type.  Pods with a matching instance type
+	// dispatch to a warm cell instead of waiting for a boot.
 	StandbyCells        []nodemanager.StandbyNodeSpec `json:"standbyCells"`
-	CloudInitFile       string                        `json:"cloudInitFile"`
+	CloudInitFile       string                        `json:"cloudInitFile" env:"KIP_CELLS_CLOUD_INIT_FILE"`
 	Itzo                ItzoConfig                    `json:"itzo"`
-	ExtraCIDRs          []string                      `json:"extraCIDRs"`
-	ExtraSecurityGroups []string                      `json:"extraSecurityGroups"`
-	Nametag             string                        `json:"nametag"`
-	StatusInterval      int                           `json:"statusInterval"`
-	HealthCheck         HealthCheckConfig             `json:"healthcheck"`
-	PrivateIPOnly       *bool                         `json:"privateIPOnly"`
-	CellConfig          map[string]string             `json:"cellConfig"`
+	ExtraCIDRs          []string                      `json:"extraCIDRs" env:"KIP_CELLS_EXTRA_CIDRS"`
+	ExtraSecurityGroups []string                      `json:"extraSecurityGroups" env:"KIP_CELLS_EXTRA_SECURITY_GROUPS"`
+	Nametag             string                        `json:"nametag" env:"KIP_CELLS_NAMETAG"`
+	StatusInterval      int                           `json:"statusInterval" env:"KIP_CELLS_STATUS_INTERVAL"`
+	// StatusPollConcurrency is how many cells are queried for pod
+	// status at the same time each status interval.  Zero uses the
+	// built-in default of 10.
+	StatusPollConcurrency int `json:"statusPollConcurrency" env:"KIP_CELLS_STATUS_POLL_CONCURRENCY"`
+	// StatusPollTimeout is the number of seconds a single cell's
+	// status query may take before it is abandoned and counted as an
+	// error.  Zero uses the built-in default of 90s.
+	StatusPollTimeout int               `json:"statusPollTimeout" env:"KIP_CELLS_STATUS_POLL_TIMEOUT"`
+	HealthCheck       HealthCheckConfig `json:"healthcheck"`
+	PrivateIPOnly     *bool             `json:"privateIPOnly" env:"KIP_CELLS_PRIVATE_IP_ONLY"`
+	CellConfig        map[string]string `json:"cellConfig"`
+	// BootTimeout is the number of seconds a cell may spend booting
+	// before it is reaped and its pod rescheduled.  Zero uses the
+	// built-in default of 300s.
+	BootTimeout int `json:"bootTimeout" env:"KIP_CELLS_BOOT_TIMEOUT"`
+	// CloudInitTimeout is the number of seconds a cell's cloud-init
+	// bootstrap may take after the cell answers its first healthcheck
+	// before the cell is reaped and its pod rescheduled.  Zero uses
+	// the built-in default of 180s.
+	CloudInitTimeout int `json:"cloudInitTimeout" env:"KIP_CELLS_CLOUD_INIT_TIMEOUT"`
+	// CloudInitSizeLimit is the maximum size in bytes of the merged
+	// cloud-init user-data (cloudInitFile plus the files kip injects)
+	// sent to a cell.  Zero uses the built-in default of 16000, just
+	// under the 16KB cap AWS puts on user-data.
+	CloudInitSizeLimit int `json:"cloudInitSizeLimit" env:"KIP_CELLS_CLOUD_INIT_SIZE_LIMIT"`
+	// CloudInitCompression gzips the cloud-init user-data when it is
+	// over the size limit instead of failing the launch.  Cloud-init
+	// decompresses gzipped user-data transparently, only enable this
+	// on providers that pass user-data through verbatim (e.g. AWS).
+	CloudInitCompression bool `json:"cloudInitCompression" env:"KIP_CELLS_CLOUD_INIT_COMPRESSION"`
+	// ReclaimOrphanedInstances enables terminating tagged cloud
+	// instances that are unknown to the registry (e.g. leaked after
+	// etcd data loss).
+	ReclaimOrphanedInstances bool `json:"reclaimOrphanedInstances" env:"KIP_CELLS_RECLAIM_ORPHANED_INSTANCES"`
+	// OrphanedInstanceGracePeriod is the number of seconds an
+	// instance must remain unknown to the registry before it is
+	// reclaimed.
+	OrphanedInstanceGracePeriod int `json:"orphanedInstanceGracePeriod" env:"KIP_CELLS_ORPHANED_INSTANCE_GRACE_PERIOD"`
+	// ReconcileInterval is the number of seconds between passes of
+	// the reconciler that compares the pod and node registries
+	// against the instances actually running in the cloud and
+	// corrects drift.  Zero uses the built-in default of 300s.
+	ReconcileInterval int `json:"reconcileInterval" env:"KIP_CELLS_RECONCILE_INTERVAL"`
+	// DNSCache configures an optional node-local DNS cache on cells.
+	DNSCache DNSCacheConfig `json:"dnsCache"`
+	// LogDriver optionally makes itzo forward container stdout/stderr
+	// logs to a cloud log sink.
+	LogDriver LogDriverConfig `json:"logDriver"`
+	// RegistryMirrors maps image registry hosts to the mirror base
+	// URLs itzo pulls their images through, e.g. "docker.io" ->
+	// "https://mirror.internal:5000".  Useful for air-gapped or
+	// bandwidth-constrained environments; empty leaves image pulls
+	// going directly to their registries.
+	RegistryMirrors map[string]string `json:"registryMirrors"`
+	// InsecureRegistryMirrors lists mirror hosts itzo may contact
+	// over plain HTTP.  Mirror URLs with an http scheme are rejected
+	// unless their host is on this list.
+	InsecureRegistryMirrors []string `json:"insecureRegistryMirrors" env:"KIP_CELLS_INSECURE_REGISTRY_MIRRORS"`
+	// Budget caps the estimated spend on cell instances.
+	Budget BudgetConfig `json:"budget"`
+	// StoppedPool keeps unneeded cell instances stopped instead of
+	// terminated so new cells with a matching spec can restart them,
+	// which is much faster than a fresh launch.  AWS only.
+	StoppedPool StoppedPoolConfig `json:"stoppedPool"`
+	// MirrorKubernetesNodes mirrors kip's cells as labeled, tainted
+	// Kubernetes Node objects so the cloud instances backing pods
+	// show up in kubectl.  Off by default to avoid cluttering
+	// clusters that don't want it.
+	MirrorKubernetesNodes bool `json:"mirrorKubernetesNodes" env:"KIP_CELLS_MIRROR_KUBERNETES_NODES"`
+	// AllowedRuntimeClasses lists the sandboxed container runtimes
+	// (e.g. "gvisor", "kata") pods may select via runtimeClassName or
+	// the runtime-class annotation.  Pods asking for a runtime not on
+	// the list are rejected at creation.  Empty disallows runtime
+	// selection entirely.
+	AllowedRuntimeClasses []string `json:"allowedRuntimeClasses" env:"KIP_CELLS_ALLOWED_RUNTIME_CLASSES"`
+	// RequireImageDigest, when set, rejects pods at creation unless
+	// every container image is pinned by digest (e.g.
+	// "nginx@sha256:..."), so cells always pull the exact bits the
+	// user reviewed regardless of tag moves.
+	RequireImageDigest bool `json:"requireImageDigest" env:"KIP_CELLS_REQUIRE_IMAGE_DIGEST"`
+	// AllowedInstanceTypes restricts the instance types kip may
+	// launch to the listed types or families (e.g. "m5.large", "m5").
+	// Empty allows everything not blocked.
+	AllowedInstanceTypes []string `json:"allowedInstanceTypes" env:"KIP_CELLS_ALLOWED_INSTANCE_TYPES"`
+	// BlockedInstanceTypes lists instance types or families kip must
+	// never launch (e.g. metal or previous-generation families).  The
+	// block list wins over the allow list.
+	BlockedInstanceTypes []string `json:"blockedInstanceTypes" env:"KIP_CELLS_BLOCKED_INSTANCE_TYPES"`
+	// OvercommitRatio divides pod CPU and memory requirements before
+	// instance selection so pods can be placed on smaller (cheaper)
+	// instances than their requests imply, when the operator accepts
+	// the risk.  Must be at least 1.0; zero means no overcommit.
+	// Pods can override it with the overcommit-ratio annotation.
+	OvercommitRatio float64 `json:"overcommitRatio" env:"KIP_CELLS_OVERCOMMIT_RATIO"`
+	// SchedulingTimeout is the number of seconds a pod may wait for a
+	// cell before it is marked unschedulable, giving the pod a
+	// PodScheduled=False condition instead of leaving it Pending
+	// silently.  Zero disables the check.
+	SchedulingTimeout int `json:"schedulingTimeout" env:"KIP_CELLS_SCHEDULING_TIMEOUT"`
+	// MaxSpotInterruptions is the number of spot reclaims after which
+	// a pod with the spot-then-ondemand capacity preference stops
+	// asking for spot cells and runs on-demand instead.  Zero uses
+	// the built-in default of 3.
+	MaxSpotInterruptions int `json:"maxSpotInterruptions" env:"KIP_CELLS_MAX_SPOT_INTERRUPTIONS"`
+	// ScaleInDelay is the number of seconds a cell must remain
+	// unneeded before the node scaler terminates it on scale-in.
+	// This stabilization window keeps short dips in pod load from
+	// churning through cells.  Zero uses the built-in default of
+	// 120s, a negative value disables the window so unneeded cells
+	// are reaped immediately.
+	ScaleInDelay int `json:"scaleInDelay" env:"KIP_CELLS_SCALE_IN_DELAY"`
+	// MinInstanceLifetime is the number of seconds a cell's instance
+	// must have been running before scale-in may stop it, keeping
+	// just-launched instances from being churned when pod load
+	// briefly dips.  Zero disables the minimum.
+	MinInstanceLifetime int `json:"minInstanceLifetime" env:"KIP_CELLS_MIN_INSTANCE_LIFETIME"`
+	// MaxInstanceLifetime is the number of seconds a cell's instance
+	// may run before it is proactively drained and replaced with a
+	// fresh instance, for security policies that require instances
+	// to be recycled periodically.  Zero disables lifetime-based
+	// recycling.
+	MaxInstanceLifetime int `json:"maxInstanceLifetime" env:"KIP_CELLS_MAX_INSTANCE_LIFETIME"`
+	// FailOnSchedulingTimeout fails pods that hit SchedulingTimeout
+	// instead of leaving them waiting for capacity.
+	FailOnSchedulingTimeout bool `json:"failOnSchedulingTimeout" env:"KIP_CELLS_FAIL_ON_SCHEDULING_TIMEOUT"`
+	// PodRetryLimit is the number of start failures after which a
+	// failed pod is failed permanently instead of being restarted on
+	// a fresh cell.  Pods can override it with the retry limit
+	// annotation; pods with restart policy Never are not restarted
+	// regardless.  Zero uses the built-in default of 2.
+	PodRetryLimit int `json:"podRetryLimit" env:"KIP_CELLS_POD_RETRY_LIMIT"`
+}
+
+const (
+	BudgetWindowHour = "hour"
+	BudgetWindowDay  = "day"
+)
+
+// BudgetConfig puts a spend cap on top of any node count caps.  When
+// the estimated cost of the cells we're running (computed from the
+// on-demand pricing data) would exceed MaxSpend over the accounting
+// window, no new cells are launched and their pods stay pending with a
+// budget-exceeded event until spend drops below the budget.
+type BudgetConfig struct {
+	// MaxSpend is the maximum estimated spend over the accounting
+	// window, in the pricing data's currency (USD).  Zero (the
+	// default) disables budget enforcement.
+	MaxSpend float64 `json:"maxSpend" env:"KIP_CELLS_BUDGET_MAX_SPEND"`
+	// Window is the accounting window MaxSpend covers: "hour" (the
+	// default) or "day".
+	Window string `json:"window" env:"KIP_CELLS_BUDGET_WINDOW"`
+}
+
+// budgetWindowHours converts the configured accounting window into the
+// number of hours spend estimates are scaled by.
+func budgetWindowHours(window string) float64 {
+	if window == BudgetWindowDay {
+		return 24.0
+	}
+	return 1.0
+}
+
+// StoppedPoolConfig configures the pool of stopped instances kept
+// for reuse.  When the scaler no longer needs a cell, its instance
+// is stopped (not terminated) and a later cell with the same spec
+// restarts it instead of launching a fresh one.
+type StoppedPoolConfig struct {
+	// MaxSize is how many stopped instances are kept.  Zero (the
+	// default) disables the stopped pool.
+	MaxSize int `json:"maxSize" env:"KIP_CELLS_STOPPED_POOL_MAX_SIZE"`
+	// MaxAgeSeconds is how long a stopped instance may wait for
+	// reuse before it is terminated.  Zero uses the built-in
+	// default of 3600s.
+	MaxAgeSeconds int `json:"maxAgeSeconds" env:"KIP_CELLS_STOPPED_POOL_MAX_AGE_SECONDS"`
+}
+
+// DNSCacheConfig configures a node-local DNS cache on cells.  When
+// enabled, the node bootstrap tells itzo to run a caching resolver on
+// the cell and point the cell's resolv.conf at it, cutting DNS load
+// and latency for chatty workloads.  Disabled (the default) leaves
+// DNS behavior on the cell unchanged.
+type DNSCacheConfig struct {
+	Enabled bool `json:"enabled" env:"KIP_CELLS_DNS_CACHE_ENABLED"`
+	// UpstreamResolvers are the nameserver IPs the cache forwards
+	// misses to.  Empty uses the resolvers the instance got from
+	// DHCP.
+	UpstreamResolvers []string `json:"upstreamResolvers" env:"KIP_CELLS_DNS_CACHE_UPSTREAM_RESOLVERS"`
+	// MaxTTL caps the number of seconds a record stays cached.  Zero
+	// honors the TTL from the upstream response.
+	MaxTTL int `json:"maxTTL" env:"KIP_CELLS_DNS_CACHE_MAX_TTL"`
+	// NegativeTTL is the number of seconds negative (NXDOMAIN)
+	// responses are cached.  Zero disables negative caching.
+	NegativeTTL int `json:"negativeTTL" env:"KIP_CELLS_DNS_CACHE_NEGATIVE_TTL"`
+}
+
+// cellConfigWithDNSCache folds the DNS cache settings into the cell
+// config map that gets written to the instance's bootstrap data for
+// itzo.  The user's cellConfig entries are left untouched; when the
+// cache is disabled the map is returned as-is so DNS behavior on the
+// cell is unchanged.
+func cellConfigWithDNSCache(cellConfig map[string]string, dns DNSCacheConfig) map[string]string {
+	if !dns.Enabled {
+		return cellConfig
+	}
+	merged := make(map[string]string, len(cellConfig)+4)
+	for k, v := range cellConfig {
+		merged[k] = v
+	}
+	merged["dnsCacheEnabled"] = "true"
+	if len(dns.UpstreamResolvers) > 0 {
+		merged["dnsCacheUpstreamResolvers"] = strings.Join(dns.UpstreamResolvers, ",")
+	}
+	if dns.MaxTTL > 0 {
+		merged["dnsCacheMaxTTL"] = strconv.Itoa(dns.MaxTTL)
+	}
+	if dns.NegativeTTL > 0 {
+		merged["dnsCacheNegativeTTL"] = strconv.Itoa(dns.NegativeTTL)
+	}
+	return merged
+}
+
+const (
+	// LogDriverRegistry keeps container logs in kip's registry, the
+	// default behavior.
+	LogDriverRegistry = "registry"
+	// LogDriverCloudWatch forwards container logs to CloudWatch Logs.
+	LogDriverCloudWatch = "cloudwatch"
+	// LogDriverStackdriver forwards container logs to Stackdriver
+	// (Google Cloud Logging).
+	LogDriverStackdriver = "stackdriver"
+)
+
+var (
+	defaultLogDriverGroup  = "kip-cells"
+	defaultLogDriverStream = "{podNamespace}/{podName}/{unitName}"
+)
+
+// LogDriverConfig configures where itzo sends container stdout/stderr
+// logs.  The default keeps logs in kip's registry; "cloudwatch" and
+// "stackdriver" make itzo stream them to the cloud sink as well,
+// offloading log storage and feeding existing observability tooling.
+// Registry logging stays on either way so kubectl logs keeps working.
+type LogDriverConfig struct {
+	// Driver is "registry" (the default), "cloudwatch" or
+	// "stackdriver".
+	Driver string `json:"driver" env:"KIP_CELLS_LOG_DRIVER"`
+	// Group is the CloudWatch log group or Stackdriver log name logs
+	// are written to.  Empty uses "kip-cells".
+	Group string `json:"group" env:"KIP_CELLS_LOG_DRIVER_GROUP"`
+	// Stream is the naming scheme for log streams within the group.
+	// The placeholders {podNamespace}, {podName} and {unitName} are
+	// expanded on the cell.  Empty uses
+	// "{podNamespace}/{podName}/{unitName}".
+	Stream string `json:"stream" env:"KIP_CELLS_LOG_DRIVER_STREAM"`
+	// Region is the region the CloudWatch log group lives in.  Empty
+	// uses the cell's own region.
+	Region string `json:"region" env:"KIP_CELLS_LOG_DRIVER_REGION"`
+	// InstanceProfile names an AWS instance profile granting
+	// permission to write to the sink (logs:CreateLogStream,
+	// logs:PutLogEvents).  It is attached to every cell at dispatch
+	// unless the pod supplies its own via the instance-profile
+	// annotation.  Empty assumes cells get sink credentials some other
+	// way (a launch template, or the instance service account on GCE).
+	InstanceProfile string `json:"instanceProfile" env:"KIP_CELLS_LOG_DRIVER_INSTANCE_PROFILE"`
+}
+
+// cellConfigWithLogDriver folds the log driver settings into the cell
+// config map that gets written to the instance's bootstrap data for
+// itzo.  The user's cellConfig entries are left untouched; with the
+// registry driver the map is returned as-is.
+func cellConfigWithLogDriver(cellConfig map[string]string, ld LogDriverConfig) map[string]string {
+	if ld.Driver == "" || ld.Driver == LogDriverRegistry {
+		return cellConfig
+	}
+	merged := make(map[string]string, len(cellConfig)+4)
+	for k, v := range cellConfig {
+		merged[k] = v
+	}
+	merged["logDriver"] = ld.Driver
+	group := ld.Group
+	if group == "" {
+		group = defaultLogDriverGroup
+	}
+	merged["logDriverGroup"] = group
+	stream := ld.Stream
+	if stream == "" {
+		stream = defaultLogDriverStream
+	}
+	merged["logDriverStream"] = stream
+	if ld.Region != "" {
+		merged["logDriverRegion"] = ld.Region
+	}
+	return merged
 }
 
 type HealthCheckConfig struct {
 	Status   *StatusHealthCheck   `json:"status"`
 	CloudAPI *CloudAPIHealthCheck `json:"cloudAPI"`
+	// Agent pings the itzo agent on each booted cell, separately
+	// from the regular heartbeats, and remediates cells whose
+	// instance is still running but whose agent is wedged: the
+	// instance is rebooted to restart the agent, and reaped if the
+	// agent stays unhealthy after that.  Nil disables the checks.
+	Agent *AgentHealthCheck `json:"agent"`
 }
 
 type StatusHealthCheck struct {
@@ -165,9 +687,59 @@ type CloudAPIHealthCheck struct {
 	HealthyTimeout int `json:"healthyTimeout"`
 }
 
+type AgentHealthCheck struct {
+	// Interval is the number of seconds between agent health
+	// checks.  Zero uses the built-in default of 30s.
+	Interval int `json:"interval"`
+	// FailureThreshold is the number of consecutive failed checks
+	// after which the cell's instance is rebooted to restart the
+	// agent.  Zero uses the built-in default of 3.
+	FailureThreshold int `json:"failureThreshold"`
+	// TerminateThreshold is the number of consecutive failed checks
+	// after which a cell whose instance was already rebooted is
+	// reaped and its pod rescheduled.  Zero uses the built-in
+	// default of 6.
+	TerminateThreshold int `json:"terminateThreshold"`
+}
+
 type ItzoConfig struct {
-	Version string `json:"version"`
-	URL     string `json:"url"`
+	Version string `json:"version" env:"KIP_CELLS_ITZO_VERSION"`
+	URL     string `json:"url" env:"KIP_CELLS_ITZO_URL"`
+	// ConnectTimeout is the number of seconds allowed for dialing the
+	// itzo agent on a cell (including the TLS handshake).  Zero uses
+	// the built-in default of 10s.
+	ConnectTimeout int `json:"connectTimeout" env:"KIP_CELLS_ITZO_CONNECT_TIMEOUT"`
+	// RequestTimeout is the number of seconds allowed for a full
+	// request to the itzo agent.  Zero uses the built-in default of
+	// 120s.
+	RequestTimeout int `json:"requestTimeout" env:"KIP_CELLS_ITZO_REQUEST_TIMEOUT"`
+	// Retries is the number of additional attempts made for
+	// idempotent itzo requests (status, logs) after a transient
+	// error, so short node agent hiccups don't immediately mark pods
+	// or nodes unhealthy.
+	Retries int `json:"retries" env:"KIP_CELLS_ITZO_RETRIES"`
+	// HostnameTemplate, if set, builds the address used to reach the
+	// itzo agent on a cell instead of a raw IP.  The placeholders
+	// {publicIP}, {privateIP}, {publicDNS} and {privateDNS} are
+	// replaced with the cell's addresses, e.g. "{privateDNS}" or
+	// "{privateIP}.cells.example.internal".  Useful when cells are
+	// only reachable via DNS names.
+	HostnameTemplate string `json:"hostnameTemplate" env:"KIP_CELLS_ITZO_HOSTNAME_TEMPLATE"`
+	// ProxyURL routes itzo connections through an HTTP(S) proxy,
+	// e.g. a bastion host, for clusters where cells aren't directly
+	// routable from the controller.  Empty honors the standard proxy
+	// environment variables.
+	ProxyURL string `json:"proxyURL" env:"KIP_CELLS_ITZO_PROXY_URL"`
+	// CanaryVersion, if set, is an itzo version booted on a fraction
+	// of new cells (see CanaryPercent) so a new agent can be
+	// validated before a full rollout.
+	CanaryVersion string `json:"canaryVersion" env:"KIP_CELLS_ITZO_CANARY_VERSION"`
+	// CanaryURL overrides the download URL for the canary version.
+	// Empty uses URL.
+	CanaryURL string `json:"canaryURL" env:"KIP_CELLS_ITZO_CANARY_URL"`
+	// CanaryPercent is the percentage (0-100) of newly launched
+	// cells that boot CanaryVersion.
+	CanaryPercent int `json:"canaryPercent" env:"KIP_CELLS_ITZO_CANARY_PERCENT"`
 }
 
 type TestingConfig struct {
@@ -186,10 +758,17 @@ func serverConfigFileWithDefaults() *ServerConfigFile {
 			},
 		},
 		Cells: CellsConfig{
-			BootImageSpec:     cloud.BootImageSpec{},
-			StandbyCells:      []nodemanager.StandbyNodeSpec{},
-			DefaultVolumeSize: "5Gi",
-			StatusInterval:    defaultStatusInterval,
+			BootImageSpec:               cloud.BootImageSpec{},
+			StandbyCells:                []nodemanager.StandbyNodeSpec{},
+			DefaultVolumeSize:           "5Gi",
+			StatusInterval:              defaultStatusInterval,
+			OrphanedInstanceGracePeriod: defaultOrphanGracePeriod,
+			Itzo: ItzoConfig{
+				Retries: defaultItzoRetries,
+			},
+		},
+		Webhook: WebhookConfig{
+			Retries: defaultWebhookRetries,
 		},
 		Kubelet: KubeletConfig{
 			Capacity: v1.ResourceList{
@@ -236,6 +815,17 @@ func setupAwsEnvVars(c *AWSConfig) error {
 		if err := setupAWSRegion(c.Region); err != nil {
 			return err
 		}
+	} else if os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") == "" {
+		// No region configured anywhere, see if we're running inside
+		// AWS and can get it from instance metadata.
+		region, err := aws.DetectRegion()
+		if err != nil {
+			return util.WrapError(err, "No AWS region configured and could not detect one from instance metadata. Please specify cloud.aws.region in provider.yaml")
+		}
+		klog.V(2).Infof("Detected AWS region %s from instance metadata", region)
+		if err := os.Setenv("AWS_REGION", region); err != nil {
+			return err
+		}
 	}
 	klog.V(2).Infof("Validating connection to AWS")
 	if err := aws.CheckConnection(); err != nil {
@@ -284,9 +874,25 @@ func configureCloudProvider(cf *ServerConfigFile, controllerID, nametag string)
 	if cc.GCE != nil {
 		numClouds++
 	}
+	if cc.Mock != nil {
+		numClouds++
+	}
 	if numClouds > 1 {
 		return nil, fmt.Errorf("Multiple clouds configured in cloud section of provider.yaml")
 	}
+	if cc.Mock != nil {
+		errs := validateMockConfig(cc.Mock)
+		if len(errs) > 0 {
+			err := fmt.Errorf("Invalid Mock Cloud Config: %v", errs.ToAggregate())
+			return nil, err
+		}
+		launchDelay := time.Duration(0)
+		if cc.Mock.LaunchDelay != "" {
+			launchDelay, _ = time.ParseDuration(cc.Mock.LaunchDelay)
+		}
+		klog.Warningf("Running with the mock cloud provider. Cells are simulated in memory and this kip installation can not be changed to run a real k8s cluster")
+		return cloud.NewLifecycleMockClient(launchDelay, cc.Mock.FailureRate), nil
+	}
 	privateIPOnly := false
 	if cf.Cells.PrivateIPOnly != nil && *cf.Cells.PrivateIPOnly {
 		privateIPOnly = true
@@ -308,18 +914,28 @@ func configureCloudProvider(cf *ServerConfigFile, controllerID, nametag string)
 		// will be available from there
 
 		client, err := aws.NewEC2Client(aws.EC2ClientConfig{
-			ControllerID:   controllerID,
-			Nametag:        nametag,
-			VPCID:          cc.AWS.VPCID,
-			SubnetID:       cc.AWS.SubnetID,
-			ECSClusterName: cc.AWS.EcsClusterName,
-			PrivateIPOnly:  privateIPOnly,
+			ControllerID:          controllerID,
+			Nametag:               nametag,
+			VPCID:                 cc.AWS.VPCID,
+			SubnetID:              cc.AWS.SubnetID,
+			ECSClusterName:        cc.AWS.EcsClusterName,
+			PrivateIPOnly:         privateIPOnly,
+			LaunchTemplateID:      cc.AWS.LaunchTemplateID,
+			LaunchTemplateVersion: cc.AWS.LaunchTemplateVersion,
+			IMDSv2Required:        cc.AWS.IMDSv2Required,
+			IMDSv2HopLimit:        cc.AWS.IMDSv2HopLimit,
+			Tenancy:               cc.AWS.Tenancy,
+			DedicatedHostID:       cc.AWS.DedicatedHostID,
 		})
 
 		if err != nil {
 			return nil, util.WrapError(err, "Error creating AWS cloud client")
 		}
-		return client, nil
+		limit := cc.AWS.APIConcurrencyLimit
+		if limit <= 0 {
+			limit = defaultAWSAPIConcurrencyLimit
+		}
+		return cloud.NewLimitedClient(client, limit), nil
 	} else if cc.Azure != nil {
 		errs := validateAzureConfig(cc.Azure)
 		if len(errs) > 0 {
@@ -341,7 +957,11 @@ func configureCloudProvider(cf *ServerConfigFile, controllerID, nametag string)
 		if err != nil {
 			return nil, util.WrapError(err, "Error creating Azure cloud client")
 		}
-		return client, nil
+		limit := cc.Azure.APIConcurrencyLimit
+		if limit <= 0 {
+			limit = defaultAzureAPIConcurrencyLimit
+		}
+		return cloud.NewLimitedClient(client, limit), nil
 	} else if cc.GCE != nil {
 		errs := validateGCEConfig(cc.GCE)
 		if len(errs) > 0 {
@@ -353,6 +973,8 @@ func configureCloudProvider(cf *ServerConfigFile, controllerID, nametag string)
 		options = append(options, gce.WithVPCName(cc.GCE.VPCName))
 		options = append(options, gce.WithSubnetName(cc.GCE.SubnetName))
 		options = append(options, gce.WithPrivateIPOnly(privateIPOnly))
+		options = append(options, gce.WithShieldedVM(cc.GCE.ShieldedVM))
+		options = append(options, gce.WithConfidentialVM(cc.GCE.ConfidentialVM))
 		if cc.GCE.CredentialsFile != "" {
 			options = append(options, gce.WithCredentialsFile(cc.GCE.CredentialsFile))
 		}
@@ -363,13 +985,17 @@ func configureCloudProvider(cf *ServerConfigFile, controllerID, nametag string)
 		if err != nil {
 			return nil, util.WrapError(err, "Error creating GCE cloud client")
 		}
-		return client, nil
+		limit := cc.GCE.APIConcurrencyLimit
+		if limit <= 0 {
+			limit = defaultGCEAPIConcurrencyLimit
+		}
+		return cloud.NewLimitedClient(client, limit), nil
 	} else {
 		return nil, fmt.Errorf("You must specify a cloud configuration in provider.yaml")
 	}
 }
 
-func ParseConfig(path string) (*ServerConfigFile, error) {
+func ParseConfig(path string, overrides ...ConfigOverride) (*ServerConfigFile, error) {
 	var err error
 
 	// unmarshal into ServerConfigFile
@@ -387,6 +1013,14 @@ func ParseConfig(path string) (*ServerConfigFile, error) {
 		return nil, util.WrapError(err, "Error parsing provider.yaml")
 	}
 
+	// Layer environment variables on top of the file values, then
+	// command-line flag overrides on top of both.  Validation in
+	// validateServerConfigFile runs against the merged result.
+	applyEnvOverrides(configFile)
+	for _, override := range overrides {
+		override(configFile)
+	}
+
 	setConfigDefaults(configFile)
 
 	updateCapacityFromDeprecatedFields(configFile)
@@ -410,10 +1044,8 @@ func updateCapacityFromDeprecatedFields(config *ServerConfigFile) {
 // ServerConfigFile has been parsed
 func setConfigDefaults(config *ServerConfigFile) {
 	if config.Cells.HealthCheck.Status == nil && config.Cells.HealthCheck.CloudAPI == nil {
-		config.Cells.HealthCheck = HealthCheckConfig{
-			Status: &StatusHealthCheck{
-				HealthyTimeout: defaultStatusHealthCheckTimeout,
-			},
+		config.Cells.HealthCheck.Status = &StatusHealthCheck{
+			HealthyTimeout: defaultStatusHealthCheckTimeout,
 		}
 	}
 	if config.Cells.HealthCheck.Status != nil {
@@ -428,6 +1060,11 @@ func setConfigDefaults(config *ServerConfigFile) {
 			config.Cells.HealthCheck.CloudAPI.Interval = defaultCloudAPIHealthCheckInterval
 		}
 	}
+	if config.Cells.HealthCheck.Agent != nil {
+		if config.Cells.HealthCheck.Agent.Interval <= 0 {
+			config.Cells.HealthCheck.Agent.Interval = defaultAgentHealthCheckInterval
+		}
+	}
 }
 
 func ConfigureCloud(configFile *ServerConfigFile, controllerID, nametag string) (cloud.CloudClient, error) {
@@ -477,6 +1114,28 @@ func validateAWSConfig(cf *AWSConfig) field.ErrorList {
 	if cf.SecretAccessKey == blankTemplateValue {
 		allErrs = append(allErrs, field.Required(fldPath.Child("secretAccessKey"), "secretAccessKey must be set or pulled from the environment"))
 	}
+	if cf.LaunchTemplateVersion != "" && cf.LaunchTemplateID == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("launchTemplateVersion"), cf.LaunchTemplateVersion, "launchTemplateVersion requires launchTemplateID to be set"))
+	}
+	if cf.IMDSv2HopLimit != 0 && !cf.IMDSv2Required {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("imdsv2HopLimit"), cf.IMDSv2HopLimit, "imdsv2HopLimit requires imdsv2Required to be set"))
+	}
+	if cf.IMDSv2HopLimit < 0 || cf.IMDSv2HopLimit > 64 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("imdsv2HopLimit"), cf.IMDSv2HopLimit, "imdsv2HopLimit must be between 0 and 64"))
+	}
+	switch cf.Tenancy {
+	case "", api.TenancyDefault, api.TenancyDedicated, api.TenancyHost:
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("tenancy"), cf.Tenancy, "tenancy must be \"default\", \"dedicated\" or \"host\""))
+	}
+	if cf.DedicatedHostID != "" {
+		if cf.Tenancy != api.TenancyHost {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("dedicatedHostID"), cf.DedicatedHostID, "dedicatedHostID requires tenancy \"host\""))
+		}
+		if !strings.HasPrefix(cf.DedicatedHostID, "h-") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("dedicatedHostID"), cf.DedicatedHostID, "dedicatedHostID must be an EC2 host ID (h-...)"))
+		}
+	}
 
 	return allErrs
 }
@@ -518,6 +1177,22 @@ func validateGCEConfig(cf *GCEConfig) field.ErrorList {
 	return allErrs
 }
 
+func validateMockConfig(cf *MockConfig) field.ErrorList {
+	allErrs := field.ErrorList{}
+	fldPath := field.NewPath("cloud.mock")
+	if cf.LaunchDelay != "" {
+		if d, err := time.ParseDuration(cf.LaunchDelay); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("launchDelay"), cf.LaunchDelay, "must be a duration like \"2s\""))
+		} else if d < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("launchDelay"), cf.LaunchDelay, "must not be negative"))
+		}
+	}
+	if cf.FailureRate < 0.0 || cf.FailureRate > 1.0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("failureRate"), cf.FailureRate, "must be between 0.0 and 1.0"))
+	}
+	return allErrs
+}
+
 func validateServerConfigFile(cf *ServerConfigFile) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -530,14 +1205,68 @@ func validateServerConfigFile(cf *ServerConfigFile) field.ErrorList {
 
 	allErrs = append(allErrs, validation.ValidateResourceParses(cells.DefaultVolumeSize, fldPath.Child("defaultVolumeSize"))...)
 
+	if cells.MaxVolumeSize != "" {
+		allErrs = append(allErrs, validation.ValidateResourceParses(cells.MaxVolumeSize, fldPath.Child("maxVolumeSize"))...)
+	}
+
+	if cells.VolumeKMSKey != "" && cells.VolumeEncrypted != nil && !*cells.VolumeEncrypted {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("volumeKMSKey"), cells.VolumeKMSKey, "cells.volumeKMSKey cannot be combined with volumeEncrypted: false"))
+	}
+
 	if cells.DefaultInstanceType == "" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("defaultInstanceType"), ""))
 	}
 
+	if cells.BootTimeout < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("bootTimeout"), cells.BootTimeout, "cells.bootTimeout must be >= 0"))
+	}
+	if cells.CloudInitTimeout < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cloudInitTimeout"), cells.CloudInitTimeout, "cells.cloudInitTimeout must be >= 0"))
+	}
+	if cells.CloudInitSizeLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cloudInitSizeLimit"), cells.CloudInitSizeLimit, "cells.cloudInitSizeLimit must be >= 0"))
+	}
+	if cells.OrphanedInstanceGracePeriod < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("orphanedInstanceGracePeriod"), cells.OrphanedInstanceGracePeriod, "cells.orphanedInstanceGracePeriod must be >= 0"))
+	}
+	if cells.ReconcileInterval < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("reconcileInterval"), cells.ReconcileInterval, "cells.reconcileInterval must be >= 0"))
+	}
+	if cells.MaxSpotInterruptions < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxSpotInterruptions"), cells.MaxSpotInterruptions, "cells.maxSpotInterruptions must be >= 0"))
+	}
+
+	if cells.Itzo.ConnectTimeout < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.connectTimeout"), cells.Itzo.ConnectTimeout, "cells.itzo.connectTimeout must be >= 0"))
+	}
+	if cells.Itzo.RequestTimeout < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.requestTimeout"), cells.Itzo.RequestTimeout, "cells.itzo.requestTimeout must be >= 0"))
+	}
+	if cells.Itzo.Retries < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.retries"), cells.Itzo.Retries, "cells.itzo.retries must be >= 0"))
+	}
+	if cells.Itzo.ProxyURL != "" {
+		u, err := url.Parse(cells.Itzo.ProxyURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.proxyURL"), cells.Itzo.ProxyURL, "cells.itzo.proxyURL must be an http(s) URL"))
+		}
+	}
+	if cells.Itzo.HostnameTemplate != "" && !strings.Contains(cells.Itzo.HostnameTemplate, "{") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.hostnameTemplate"), cells.Itzo.HostnameTemplate, "cells.itzo.hostnameTemplate must contain an address placeholder such as {privateDNS}"))
+	}
+
 	if cells.StatusInterval < 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("statusInterval"), cells.StatusInterval, "cells.statusInterval must be >= 1"))
 	}
 
+	if cells.StatusPollConcurrency < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("statusPollConcurrency"), cells.StatusPollConcurrency, "cells.statusPollConcurrency must be >= 0"))
+	}
+
+	if cells.StatusPollTimeout < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("statusPollTimeout"), cells.StatusPollTimeout, "cells.statusPollTimeout must be >= 0"))
+	}
+
 	if cells.HealthCheck.Status != nil && cells.HealthCheck.CloudAPI != nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("healthcheck"), "multiple healthchecks configured", "cannot set both status and cloudAPI healthchecks"))
 	}
@@ -567,12 +1296,201 @@ func validateServerConfigFile(cf *ServerConfigFile) field.ErrorList {
 		}
 	}
 
+	for i, resolver := range cells.DNSCache.UpstreamResolvers {
+		if net.ParseIP(resolver) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("dnsCache.upstreamResolvers").Index(i), resolver, "upstream resolvers must be IP addresses"))
+		}
+	}
+	if cells.DNSCache.MaxTTL < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("dnsCache.maxTTL"), cells.DNSCache.MaxTTL, "cells.dnsCache.maxTTL must be >= 0"))
+	}
+	if cells.DNSCache.NegativeTTL < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("dnsCache.negativeTTL"), cells.DNSCache.NegativeTTL, "cells.dnsCache.negativeTTL must be >= 0"))
+	}
+
+	switch cells.LogDriver.Driver {
+	case "", LogDriverRegistry:
+		if cells.LogDriver.Group != "" || cells.LogDriver.Stream != "" ||
+			cells.LogDriver.Region != "" || cells.LogDriver.InstanceProfile != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("logDriver.driver"), cells.LogDriver.Driver, "log driver settings require a cloud driver (\"cloudwatch\" or \"stackdriver\")"))
+		}
+	case LogDriverCloudWatch:
+	case LogDriverStackdriver:
+		if cells.LogDriver.Region != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("logDriver.region"), cells.LogDriver.Region, "region only applies to the cloudwatch driver"))
+		}
+		if cells.LogDriver.InstanceProfile != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("logDriver.instanceProfile"), cells.LogDriver.InstanceProfile, "instanceProfile only applies to the cloudwatch driver"))
+		}
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("logDriver.driver"), cells.LogDriver.Driver, "driver must be \"registry\", \"cloudwatch\" or \"stackdriver\""))
+	}
+
+	if cells.Itzo.CanaryPercent < 0 || cells.Itzo.CanaryPercent > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("itzo.canaryPercent"), cells.Itzo.CanaryPercent, "cells.itzo.canaryPercent must be between 0 and 100"))
+	}
+
+	mirrorPath := fldPath.Child("registryMirrors")
+	for registry, mirror := range cells.RegistryMirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(mirrorPath.Key(registry), mirror, "mirror must be a URL with a scheme and host"))
+			continue
+		}
+		switch u.Scheme {
+		case "https":
+		case "http":
+			if !util.StringInSlice(u.Host, cells.InsecureRegistryMirrors) &&
+				!util.StringInSlice(u.Hostname(), cells.InsecureRegistryMirrors) {
+				allErrs = append(allErrs, field.Invalid(mirrorPath.Key(registry), mirror, "http mirrors must be listed in cells.insecureRegistryMirrors"))
+			}
+		default:
+			allErrs = append(allErrs, field.Invalid(mirrorPath.Key(registry), mirror, "mirror scheme must be http or https"))
+		}
+	}
+
+	if cells.Budget.MaxSpend < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("budget.maxSpend"), cells.Budget.MaxSpend, "cells.budget.maxSpend must be >= 0"))
+	}
+	switch cells.Budget.Window {
+	case "", BudgetWindowHour, BudgetWindowDay:
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("budget.window"), cells.Budget.Window, "cells.budget.window must be \"hour\" or \"day\""))
+	}
+
+	if cells.PodRetryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("podRetryLimit"), cells.PodRetryLimit, "cells.podRetryLimit must be >= 0"))
+	}
+
+	if hc := cells.HealthCheck.Agent; hc != nil {
+		if hc.FailureThreshold < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("healthcheck.agent.failureThreshold"), hc.FailureThreshold, "cells.healthcheck.agent.failureThreshold must be >= 0"))
+		}
+		if hc.TerminateThreshold < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("healthcheck.agent.terminateThreshold"), hc.TerminateThreshold, "cells.healthcheck.agent.terminateThreshold must be >= 0"))
+		}
+		if hc.FailureThreshold > 0 && hc.TerminateThreshold > 0 &&
+			hc.TerminateThreshold <= hc.FailureThreshold {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("healthcheck.agent.terminateThreshold"), hc.TerminateThreshold, "cells.healthcheck.agent.terminateThreshold must be greater than failureThreshold"))
+		}
+	}
+
+	if cells.StoppedPool.MaxSize < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stoppedPool.maxSize"), cells.StoppedPool.MaxSize, "cells.stoppedPool.maxSize must be >= 0"))
+	}
+	if cells.StoppedPool.MaxAgeSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stoppedPool.maxAgeSeconds"), cells.StoppedPool.MaxAgeSeconds, "cells.stoppedPool.maxAgeSeconds must be >= 0"))
+	}
+
+	if cells.MinInstanceLifetime < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minInstanceLifetime"), cells.MinInstanceLifetime, "cells.minInstanceLifetime must be >= 0"))
+	}
+	if cells.MaxInstanceLifetime < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxInstanceLifetime"), cells.MaxInstanceLifetime, "cells.maxInstanceLifetime must be >= 0"))
+	}
+	if cells.MinInstanceLifetime > 0 && cells.MaxInstanceLifetime > 0 &&
+		cells.MaxInstanceLifetime <= cells.MinInstanceLifetime {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxInstanceLifetime"), cells.MaxInstanceLifetime, "cells.maxInstanceLifetime must be greater than minInstanceLifetime"))
+	}
+
+	if cells.OvercommitRatio != 0 && cells.OvercommitRatio < 1.0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("overcommitRatio"), cells.OvercommitRatio, "cells.overcommitRatio must be at least 1.0"))
+	}
+
+	for i, rc := range cells.AllowedRuntimeClasses {
+		for _, msg := range vutil.IsDNS1123Label(rc) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("allowedRuntimeClasses").Index(i), rc, msg))
+		}
+	}
+
 	if len(cells.Nametag) > 0 {
 		for _, msg := range validation.NameIsDNS952Label(cells.Nametag, false) {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("nametag"), cells.Nametag, msg))
 		}
 	}
 
+	etcdPath := field.NewPath("etcd").Child("client")
+	etcdClient := cf.Etcd.Client
+	if len(etcdClient.Endpoints) == 0 {
+		if etcdClient.CertFile != "" || etcdClient.KeyFile != "" || etcdClient.CAFile != "" {
+			allErrs = append(allErrs, field.Invalid(etcdPath, etcdClient, "etcd.client TLS files require etcd.client.endpoints to be set"))
+		}
+	} else {
+		for i, endpoint := range etcdClient.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				allErrs = append(allErrs, field.Invalid(etcdPath.Child("endpoints").Index(i), endpoint, "endpoints must be http(s) URLs"))
+			}
+		}
+		if (etcdClient.CertFile == "") != (etcdClient.KeyFile == "") {
+			allErrs = append(allErrs, field.Invalid(etcdPath, etcdClient, "etcd.client.certFile and keyFile must be set together"))
+		}
+		for _, f := range []struct {
+			path  string
+			child string
+		}{
+			{etcdClient.CertFile, "certFile"},
+			{etcdClient.KeyFile, "keyFile"},
+			{etcdClient.CAFile, "caFile"},
+		} {
+			if f.path != "" {
+				allErrs = append(allErrs, validation.ValidateFileExists(f.path, etcdPath.Child(f.child))...)
+			}
+		}
+	}
+	if cf.Etcd.Internal.MaintenanceInterval < 0 {
+		allErrs = append(allErrs, field.Invalid(
+			field.NewPath("etcd").Child("internal").Child("maintenanceInterval"),
+			cf.Etcd.Internal.MaintenanceInterval,
+			"maintenanceInterval must be >= 0 seconds"))
+	}
+
+	if cf.Kubelet.APIPort < 0 || cf.Kubelet.APIPort > 65535 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("kubelet").Child("apiPort"), cf.Kubelet.APIPort, "apiPort must be a valid port number"))
+	}
+
+	if cf.Debug.PprofAddress != "" {
+		if _, _, err := net.SplitHostPort(cf.Debug.PprofAddress); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("debug").Child("pprofAddress"), cf.Debug.PprofAddress, "pprofAddress must be a host:port address"))
+		}
+	}
+
+	switch cf.Logging.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		allErrs = append(allErrs, field.Invalid(field.NewPath("logging").Child("format"), cf.Logging.Format, "format must be \"text\" or \"json\""))
+	}
+
+	if cf.Audit.Size < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("audit").Child("size"), cf.Audit.Size, "audit.size must be >= 0"))
+	}
+
+	if cf.Logs.RetentionAge < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("logs").Child("retentionAge"), cf.Logs.RetentionAge, "logs.retentionAge must be >= 0"))
+	}
+	if cf.Logs.RetentionSize < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("logs").Child("retentionSize"), cf.Logs.RetentionSize, "logs.retentionSize must be >= 0"))
+	}
+	switch cf.Logs.Compression {
+	case "", "none", api.LogContentEncodingGzip:
+	default:
+		allErrs = append(allErrs, field.Invalid(field.NewPath("logs").Child("compression"), cf.Logs.Compression, "logs.compression must be \"none\" or \"gzip\""))
+	}
+
+	for i, f := range cf.Events.DedupKeyFields {
+		if !util.StringInSlice(f, registry.EventDedupFields) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("events").Child("dedupKeyFields").Index(i), f, fmt.Sprintf("dedup key fields must be one of %v", registry.EventDedupFields)))
+		}
+	}
+
+	quotaPath := field.NewPath("quotas")
+	for namespace, quota := range cf.Quotas {
+		nsPath := quotaPath.Key(namespace)
+		allErrs = append(allErrs, validation.ValidateResourceParses(quota.CPU, nsPath.Child("cpu"))...)
+		allErrs = append(allErrs, validation.ValidateResourceParses(quota.Memory, nsPath.Child("memory"))...)
+		allErrs = append(allErrs, validation.ValidateNonnegativeField(int64(quota.Pods), nsPath.Child("pods"))...)
+	}
+
 	return allErrs
 }
 
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog"
+)
+
+// ConfigOverride mutates a parsed ServerConfigFile.  Overrides are
+// applied by ParseConfig after the config file and environment
+// variables have been read, so they take precedence over both.  They
+// are used to layer command-line flag values on top of the file and
+// environment configuration.
+type ConfigOverride func(*ServerConfigFile)
+
+// applyEnvOverrides walks the config structure and overrides any
+// field that has an `env` struct tag with the value of that
+// environment variable, if it is set.  Supported field types are
+// string, int, bool, *bool and []string (comma separated).
+func applyEnvOverrides(cf *ServerConfigFile) {
+	applyEnvToValue(reflect.ValueOf(cf).Elem())
+}
+
+func applyEnvToValue(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		structField := t.Field(i)
+		if field.Kind() == reflect.Ptr && !field.IsNil() &&
+			field.Elem().Kind() == reflect.Struct {
+			applyEnvToValue(field.Elem())
+			continue
+		}
+		if field.Kind() == reflect.Struct &&
+			structField.Tag.Get("env") == "" {
+			applyEnvToValue(field)
+			continue
+		}
+		envVar := structField.Tag.Get("env")
+		if envVar == "" {
+			continue
+		}
+		envVal := os.Getenv(envVar)
+		if envVal == "" {
+			continue
+		}
+		if err := setFieldFromString(field, envVal); err != nil {
+			klog.Warningf("Ignoring invalid value %q for %s: %v",
+				envVal, envVar, err)
+		}
+	}
+}
+
+func setFieldFromString(field reflect.Value, val string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int:
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Ptr:
+		if field.Type().Elem().Kind() == reflect.Bool {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(&b))
+		}
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String {
+			parts := strings.Split(val, ",")
+			for i := range parts {
+				parts[i] = strings.TrimSpace(parts[i])
+			}
+			field.Set(reflect.ValueOf(parts))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	envVars := map[string]string{
+		"KIP_CELLS_DEFAULT_INSTANCE_TYPE": "t3.small",
+		"KIP_CELLS_STATUS_INTERVAL":       "11",
+		"KIP_CELLS_PRIVATE_IP_ONLY":       "true",
+		"KIP_CELLS_EXTRA_CIDRS":           "10.0.0.0/8, 192.168.0.0/16",
+		"KIP_CELLS_ITZO_VERSION":          "v1.2.3",
+	}
+	for k, v := range envVars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	cf := serverConfigFileWithDefaults()
+	cf.Cells.DefaultInstanceType = "from-file"
+	applyEnvOverrides(cf)
+	assert.Equal(t, "t3.small", cf.Cells.DefaultInstanceType)
+	assert.Equal(t, 11, cf.Cells.StatusInterval)
+	if assert.NotNil(t, cf.Cells.PrivateIPOnly) {
+		assert.True(t, *cf.Cells.PrivateIPOnly)
+	}
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.0.0/16"}, cf.Cells.ExtraCIDRs)
+	assert.Equal(t, "v1.2.3", cf.Cells.Itzo.Version)
+}
+
+func TestApplyEnvOverridesInvalidValue(t *testing.T) {
+	os.Setenv("KIP_CELLS_STATUS_INTERVAL", "not-a-number")
+	defer os.Unsetenv("KIP_CELLS_STATUS_INTERVAL")
+	cf := serverConfigFileWithDefaults()
+	applyEnvOverrides(cf)
+	assert.Equal(t, defaultStatusInterval, cf.Cells.StatusInterval)
+}
@@ -91,6 +91,177 @@ func TestConfigValidation(t *testing.T) {
 			},
 			errors: 0,
 		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Debug.PprofAddress = "localhost:6060"
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// Missing port.
+				cf.Debug.PprofAddress = "localhost"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.DNSCache.UpstreamResolvers = []string{"1.1.1.1", "not-an-ip"}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.DNSCache.MaxTTL = -1
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.RegistryMirrors = map[string]string{
+					"docker.io": "https://mirror.internal:5000",
+				}
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// http mirror without an insecure allowlist entry.
+				cf.Cells.RegistryMirrors = map[string]string{
+					"docker.io": "http://mirror.internal:5000",
+				}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.RegistryMirrors = map[string]string{
+					"docker.io": "http://mirror.internal:5000",
+				}
+				cf.Cells.InsecureRegistryMirrors = []string{"mirror.internal:5000"}
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.RegistryMirrors = map[string]string{
+					"docker.io": "mirror.internal:5000",
+				}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.Budget.MaxSpend = 10.0
+				cf.Cells.Budget.Window = BudgetWindowDay
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.Budget.MaxSpend = -1.0
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.Budget.Window = "week"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.AllowedRuntimeClasses = []string{"gvisor", "kata"}
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.AllowedRuntimeClasses = []string{"not a label!"}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.LogDriver = LogDriverConfig{
+					Driver:          LogDriverCloudWatch,
+					Group:           "my-cluster-logs",
+					Region:          "us-east-1",
+					InstanceProfile: "kip-cell-logging",
+				}
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.LogDriver.Driver = "syslog"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.LogDriver.Group = "my-cluster-logs"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.LogDriver = LogDriverConfig{
+					Driver: LogDriverStackdriver,
+					Region: "us-east1",
+				}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Cells.Itzo.HostnameTemplate = "{privateIP}.cells.example.internal"
+				cf.Cells.Itzo.ProxyURL = "http://bastion.example.internal:3128"
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// Static hostname without an address placeholder.
+				cf.Cells.Itzo.HostnameTemplate = "cells.example.internal"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// Missing scheme.
+				cf.Cells.Itzo.ProxyURL = "bastion.example.internal:3128"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				cf.Etcd.Client.Endpoints = []string{"http://10.0.0.1:2379"}
+			},
+			errors: 0,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// TLS files without endpoints.
+				cf.Etcd.Client.CAFile = "/etc/etcd/ca.crt"
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// Missing scheme.
+				cf.Etcd.Client.Endpoints = []string{"10.0.0.1:2379"}
+			},
+			errors: 1,
+		},
+		{
+			mod: func(cf *ServerConfigFile) {
+				// certFile without keyFile, and the file doesn't exist.
+				cf.Etcd.Client.Endpoints = []string{"https://10.0.0.1:2379"}
+				cf.Etcd.Client.CertFile = "/does/not/exist.crt"
+			},
+			errors: 2,
+		},
 	}
 	for i, test := range tests {
 		cf := serverConfigFileWithDefaults()
@@ -107,6 +278,51 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+func TestCellConfigWithDNSCache(t *testing.T) {
+	userConfig := map[string]string{"memoryLimit": "1Gi"}
+	disabled := cellConfigWithDNSCache(userConfig, DNSCacheConfig{})
+	assert.Equal(t, userConfig, disabled)
+	merged := cellConfigWithDNSCache(userConfig, DNSCacheConfig{
+		Enabled:           true,
+		UpstreamResolvers: []string{"10.0.0.2", "1.1.1.1"},
+		MaxTTL:            30,
+	})
+	assert.Equal(t, "1Gi", merged["memoryLimit"])
+	assert.Equal(t, "true", merged["dnsCacheEnabled"])
+	assert.Equal(t, "10.0.0.2,1.1.1.1", merged["dnsCacheUpstreamResolvers"])
+	assert.Equal(t, "30", merged["dnsCacheMaxTTL"])
+	_, exists := merged["dnsCacheNegativeTTL"]
+	assert.False(t, exists)
+	// the caller's map is not modified
+	assert.NotContains(t, userConfig, "dnsCacheEnabled")
+}
+
+func TestCellConfigWithLogDriver(t *testing.T) {
+	userConfig := map[string]string{"memoryLimit": "1Gi"}
+	registryOnly := cellConfigWithLogDriver(userConfig, LogDriverConfig{})
+	assert.Equal(t, userConfig, registryOnly)
+	merged := cellConfigWithLogDriver(userConfig, LogDriverConfig{
+		Driver: LogDriverCloudWatch,
+		Region: "us-east-1",
+	})
+	assert.Equal(t, "1Gi", merged["memoryLimit"])
+	assert.Equal(t, "cloudwatch", merged["logDriver"])
+	assert.Equal(t, "kip-cells", merged["logDriverGroup"])
+	assert.Equal(t, "{podNamespace}/{podName}/{unitName}", merged["logDriverStream"])
+	assert.Equal(t, "us-east-1", merged["logDriverRegion"])
+	// the caller's map is not modified
+	assert.NotContains(t, userConfig, "logDriver")
+	custom := cellConfigWithLogDriver(nil, LogDriverConfig{
+		Driver: LogDriverStackdriver,
+		Group:  "my-cluster-logs",
+		Stream: "{podName}",
+	})
+	assert.Equal(t, "my-cluster-logs", custom["logDriverGroup"])
+	assert.Equal(t, "{podName}", custom["logDriverStream"])
+	_, exists := custom["logDriverRegion"]
+	assert.False(t, exists)
+}
+
 func mustParseQuantity(str string) *resource.Quantity {
 	q := resource.MustParse(str)
 	return &q
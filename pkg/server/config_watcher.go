@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const configWatchInterval = 30 * time.Second
+
+// watchProviderConfig polls the provider config file for changes and,
+// when the file is rewritten with new kubelet capacity values,
+// pushes the new capacity through the node status controller so
+// operators can resize a busy kip node without restarting it.  If the
+// reloaded file is invalid we keep the previous configuration and log
+// an error.
+func (p *InstanceProvider) watchProviderConfig(path string, overrides []ConfigOverride, capacity v1.ResourceList, quit <-chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+	var lastModTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		lastModTime = fi.ModTime()
+	}
+	lastCapacity := capacity
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			klog.V(2).Info("stopping provider config watcher")
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			klog.Warningf("checking provider config file %s: %v", path, err)
+			continue
+		}
+		if !fi.ModTime().After(lastModTime) {
+			continue
+		}
+		lastModTime = fi.ModTime()
+		configFile, err := ParseConfig(path, overrides...)
+		if err != nil {
+			klog.Errorf("reloading provider config: %v, keeping previous configuration", err)
+			continue
+		}
+		if errs := validateServerConfigFile(configFile); len(errs) > 0 {
+			klog.Errorf("reloaded provider config is invalid: %v, keeping previous configuration", errs.ToAggregate())
+			continue
+		}
+		newCapacity := configFile.Kubelet.Capacity
+		if capacityEqual(lastCapacity, newCapacity) {
+			continue
+		}
+		klog.V(2).Infof("provider config capacity changed from %v to %v",
+			lastCapacity, newCapacity)
+		lastCapacity = newCapacity
+		p.getNodeStatusController().UpdateCapacity(newCapacity)
+	}
+}
+
+func capacityEqual(a, b v1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, aQuantity := range a {
+		bQuantity, exists := b[name]
+		if !exists || aQuantity.Cmp(bQuantity) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -31,6 +31,9 @@ import (
 const (
 	etcdClusterInfoPath string = "milpa/cluster"
 	etcdClusterUUIDPath string = "milpa/cluster/uuid"
+	// Number of times cluster-level keys are re-read after losing an
+	// AtomicPut race against another controller starting up.
+	etcdCASRetries int = 3
 )
 
 // Internally we store a UUID4 for the controller that is a string
@@ -54,18 +57,25 @@ func getControllerID(etcdClient etcd.Storer) (string, error) {
 }
 
 func ensureClusterUUID(etcdClient etcd.Storer) (string, error) {
-	pair, err := etcdClient.Get(etcdClusterUUIDPath)
-	if err == nil {
-		clusterUUID := string(pair.Value)
-		return clusterUUID, nil
-	} else if err == store.ErrKeyNotFound {
+	// When two controllers start at the same time, both can miss the
+	// key and try to create it.  The loser of the AtomicPut race
+	// re-reads and adopts the winner's UUID so both end up with the
+	// same controller ID.
+	var err error
+	for i := 0; i < etcdCASRetries; i++ {
+		var pair *store.KVPair
+		pair, err = etcdClient.Get(etcdClusterUUIDPath)
+		if err == nil {
+			clusterUUID := string(pair.Value)
+			return clusterUUID, nil
+		} else if err != store.ErrKeyNotFound {
+			return "", util.WrapError(err, "Error pulling controller UUID from storage")
+		}
 		clusterUUID := uuid.NewV4().String()
 		_, _, err = etcdClient.AtomicPut(etcdClusterUUIDPath, []byte(clusterUUID), nil, nil)
-		if err != nil {
-			return clusterUUID, util.WrapError(err, "Error storing new controller UUID")
+		if err == nil {
+			return clusterUUID, nil
 		}
-		return clusterUUID, nil
-	} else {
-		return "", util.WrapError(err, "Error pulling controller UUID from storage")
 	}
+	return "", util.WrapError(err, "Error storing new controller UUID")
 }
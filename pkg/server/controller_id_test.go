@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/docker/libkv/store"
@@ -44,3 +45,50 @@ func TestClusterID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, encoded, controllerID)
 }
+
+func TestClusterIDConcurrentControllers(t *testing.T) {
+	name, closer := util.MakeTempFileName("milpa_cid")
+	defer closer()
+	kvstore := registry.CreateKVStore(name)
+	// Controllers starting against a shared store at the same time
+	// should all converge on the same controller ID.
+	const controllers = 4
+	ids := make([]string, controllers)
+	errs := make([]error, controllers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < controllers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], errs[i] = getControllerID(kvstore)
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < controllers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+}
+
+func TestEnsureRegionUnchangedConcurrentControllers(t *testing.T) {
+	name, closer := util.MakeTempFileName("milpa_region")
+	defer closer()
+	kvstore := registry.CreateKVStore(name)
+	const controllers = 4
+	errs := make([]error, controllers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < controllers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ensureRegionUnchanged(kvstore, "us-east-1")
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < controllers; i++ {
+		assert.NoError(t, errs[i])
+	}
+	// a different region is still rejected
+	err := ensureRegionUnchanged(kvstore, "us-west-2")
+	assert.Error(t, err)
+}
@@ -31,7 +31,7 @@ type ControllerManager struct {
 	controllers         map[string]Controller
 	controllersRunning  *atomic.Bool
 	startChan           chan struct{}
-	stopChan            chan struct{}
+	stopChan            chan chan struct{}
 	controllerQuit      chan struct{}
 	controllerWaitGroup *sync.WaitGroup
 }
@@ -40,7 +40,7 @@ func NewControllerManager(controllers map[string]Controller) *ControllerManager
 	return &ControllerManager{
 		controllers:         controllers,
 		startChan:           make(chan struct{}),
-		stopChan:            make(chan struct{}),
+		stopChan:            make(chan chan struct{}),
 		controllersRunning:  atomic.NewBool(false),
 		controllerQuit:      nil,
 		controllerWaitGroup: nil,
@@ -51,8 +51,13 @@ func (cm *ControllerManager) StartControllers() {
 	cm.startChan <- struct{}{}
 }
 
+// StopControllers stops the controllers and only returns once all of
+// them have exited, allowing in-flight operations to complete before
+// anything they depend on gets torn down.
 func (cm *ControllerManager) StopControllers() {
-	cm.stopChan <- struct{}{}
+	stopped := make(chan struct{})
+	cm.stopChan <- stopped
+	<-stopped
 }
 
 func (cm *ControllerManager) ControllersRunning() bool {
@@ -81,8 +86,9 @@ func (cm *ControllerManager) Start() {
 		select {
 		case <-cm.startChan:
 			cm.startControllersHelper()
-		case <-cm.stopChan:
+		case stopped := <-cm.stopChan:
 			cm.stopControllersHelper()
+			close(stopped)
 		}
 	}
 }
@@ -98,6 +104,8 @@ func (cm *ControllerManager) WaitForShutdown(systemShutdown <-chan struct{}, sys
 	select {
 	case <-systemShutdown:
 		klog.V(2).Infof("Shutting down controllers")
+		// StopControllers blocks until the controllers have drained
+		// their in-flight work.
 		cm.StopControllers()
 		return
 	}
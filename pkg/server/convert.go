@@ -17,12 +17,18 @@ limitations under the License.
 package server
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/distribution/reference"
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/api/annotations"
+	"github.com/elotl/kip/pkg/api/labels"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/k8s/status"
 	v1 "k8s.io/api/core/v1"
@@ -36,6 +42,7 @@ const (
 	ResourceLimitsGPU    v1.ResourceName = "nvidia.com/gpu"
 	resolvconfVolumeName                 = "resolvconf"
 	etchostsVolumeName                   = "etchosts"
+	localtimeVolumeName                  = "localtime"
 )
 
 var (
@@ -55,10 +62,20 @@ func getStatus(internalIP string, milpaPod *api.Pod, pod *v1.Pod) v1.PodStatus {
 			privateIPv4Address = address.Address
 		}
 	}
+	specImages := make(map[string]string)
+	for _, unit := range milpaPod.Spec.InitUnits {
+		specImages[unit.Name] = unit.Image
+	}
+	for _, unit := range milpaPod.Spec.Units {
+		specImages[unit.Name] = unit.Image
+	}
+	for _, unit := range milpaPod.Spec.EphemeralUnits {
+		specImages[unit.Name] = unit.Image
+	}
 	initComplete := true
 	initContainerStatuses := make([]v1.ContainerStatus, len(milpaPod.Status.InitUnitStatuses))
 	for i, st := range milpaPod.Status.InitUnitStatuses {
-		initContainerStatuses[i] = unitToContainerStatus(st)
+		initContainerStatuses[i] = unitToContainerStatus(st, specImages[st.Name])
 		if st.State.Terminated == nil ||
 			st.State.Terminated.ExitCode != int32(0) {
 			initComplete = false
@@ -66,7 +83,13 @@ func getStatus(internalIP string, milpaPod *api.Pod, pod *v1.Pod) v1.PodStatus {
 	}
 	containerStatuses := make([]v1.ContainerStatus, len(milpaPod.Status.UnitStatuses))
 	for i, st := range milpaPod.Status.UnitStatuses {
-		containerStatuses[i] = unitToContainerStatus(st)
+		containerStatuses[i] = unitToContainerStatus(st, specImages[st.Name])
+	}
+	// Ephemeral container statuses are surfaced but never feed into
+	// the pod's phase or conditions, matching kubernetes semantics.
+	ephemeralContainerStatuses := make([]v1.ContainerStatus, len(milpaPod.Status.EphemeralUnitStatuses))
+	for i, st := range milpaPod.Status.EphemeralUnitStatuses {
+		ephemeralContainerStatuses[i] = unitToContainerStatus(st, specImages[st.Name])
 	}
 	phase := v1.PodUnknown
 	switch milpaPod.Status.Phase {
@@ -82,7 +105,7 @@ func getStatus(internalIP string, milpaPod *api.Pod, pod *v1.Pod) v1.PodStatus {
 		// If we set the pod to Failed in K8s, it never comes
 		// back out of that phase A failed pod in kip that should
 		// be restarted is "pending" in k8s
-		if podShouldBeRestarted(milpaPod) {
+		if podShouldBeRestarted(milpaPod, effectiveRetryLimit(milpaPod, 0)) {
 			phase = v1.PodPending
 		} else {
 			phase = v1.PodFailed
@@ -94,28 +117,80 @@ func getStatus(internalIP string, milpaPod *api.Pod, pod *v1.Pod) v1.PodStatus {
 	if phase == v1.PodRunning && !initComplete {
 		phase = v1.PodPending
 	}
+	// a pod with startup probes stays in a starting (pending) state
+	// until every probed unit has started
+	if phase == v1.PodRunning && !allStartupProbesPassed(milpaPod) {
+		phase = v1.PodPending
+	}
 	// We use the implementation from Kubernetes here to determine conditions.
+	// Readiness gate conditions set through the pod registry go in first
+	// so the Ready condition below can take them into account.
 	conditions := []v1.PodCondition{}
+	for _, c := range milpaPod.Status.Conditions {
+		conditions = append(conditions, milpaConditionToK8sCondition(c))
+	}
 	conditions = append(conditions, status.GeneratePodInitializedCondition(&pod.Spec, initContainerStatuses, pod.Status.Phase))
 	conditions = append(conditions, status.GeneratePodReadyCondition(&pod.Spec, conditions, containerStatuses, pod.Status.Phase))
 	conditions = append(conditions, status.GenerateContainersReadyCondition(&pod.Spec, containerStatuses, pod.Status.Phase))
-	// PodScheduled is always true when the pod gets to the kubelet.
-	conditions = append(conditions, v1.PodCondition{
+	// PodScheduled is true once the pod is matched to a cell.  If the
+	// pod hit the scheduling timeout, surface why it can't be placed.
+	podScheduled := v1.PodCondition{
 		Type:   v1.PodScheduled,
 		Status: v1.ConditionTrue,
-	})
+	}
+	if milpaPod.Status.UnschedulableReason != "" {
+		podScheduled.Status = v1.ConditionFalse
+		podScheduled.Reason = v1.PodReasonUnschedulable
+		podScheduled.Message = milpaPod.Status.UnschedulableReason
+	}
+	conditions = append(conditions, podScheduled)
 	return v1.PodStatus{
-		Phase:                 phase,
-		Conditions:            conditions,
-		Message:               "",
-		Reason:                "",
-		HostIP:                internalIP,
-		PodIP:                 privateIPv4Address,
-		StartTime:             &startTime,
-		InitContainerStatuses: initContainerStatuses,
-		ContainerStatuses:     containerStatuses,
-		QOSClass:              v1.PodQOSBestEffort,
+		Phase:                      phase,
+		Conditions:                 conditions,
+		Message:                    "",
+		Reason:                     milpaPod.Status.FailureReason,
+		HostIP:                     internalIP,
+		PodIP:                      privateIPv4Address,
+		StartTime:                  &startTime,
+		InitContainerStatuses:      initContainerStatuses,
+		ContainerStatuses:          containerStatuses,
+		EphemeralContainerStatuses: ephemeralContainerStatuses,
+		QOSClass:                   v1.PodQOSBestEffort,
+	}
+}
+
+// milpaConditionToK8sCondition converts a condition stored in the pod
+// registry to its kubernetes counterpart.
+func milpaConditionToK8sCondition(c api.PodCondition) v1.PodCondition {
+	return v1.PodCondition{
+		Type:               v1.PodConditionType(c.Type),
+		Status:             v1.ConditionStatus(c.Status),
+		LastTransitionTime: metav1.NewTime(c.LastTransitionTime.Time),
+		Reason:             c.Reason,
+		Message:            c.Message,
+	}
+}
+
+// allStartupProbesPassed reports whether every unit that has a
+// startup probe has its Started flag set by itzo.  Units without a
+// startup probe don't gate the pod.
+func allStartupProbesPassed(milpaPod *api.Pod) bool {
+	for _, unit := range milpaPod.Spec.Units {
+		if unit.StartupProbe == nil {
+			continue
+		}
+		started := false
+		for _, us := range milpaPod.Status.UnitStatuses {
+			if us.Name == unit.Name {
+				started = us.Started != nil && *us.Started
+				break
+			}
+		}
+		if !started {
+			return false
+		}
 	}
+	return true
 }
 
 func unitStateToContainerState(st api.UnitState) v1.ContainerState {
@@ -131,10 +206,17 @@ func unitStateToContainerState(st api.UnitState) v1.ContainerState {
 		}
 	}
 	if st.Terminated != nil {
+		reason := st.Terminated.Reason
+		// Surface kernel OOM kills the way kubernetes does. Each pod
+		// has its cell to itself, so a SIGKILL exit that itzo didn't
+		// initiate (no reason recorded) is the OOM killer.
+		if reason == "" && st.Terminated.ExitCode == 137 {
+			reason = "OOMKilled"
+		}
 		k8s.Terminated = &v1.ContainerStateTerminated{
 			ExitCode:   st.Terminated.ExitCode,
 			FinishedAt: metav1.NewTime(st.Terminated.FinishedAt.Time),
-			Reason:     st.Terminated.Reason,
+			Reason:     reason,
 			Message:    st.Terminated.Message,
 			StartedAt:  metav1.NewTime(st.Terminated.StartedAt.Time),
 		}
@@ -142,11 +224,35 @@ func unitStateToContainerState(st api.UnitState) v1.ContainerState {
 	return k8s
 }
 
-func unitToContainerStatus(st api.UnitStatus) v1.ContainerStatus {
+// imageDigestID returns a kubelet-style "docker-pullable://" image ID
+// for a digest-pinned image reference, or "" when the reference isn't
+// pinned by digest.
+func imageDigestID(image string) string {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	if _, isCanonical := ref.(reference.Canonical); !isCanonical {
+		return ""
+	}
+	return "docker-pullable://" + reference.FamiliarString(ref)
+}
+
+func unitToContainerStatus(st api.UnitStatus, specImage string) v1.ContainerStatus {
+	// Itzo doesn't report the pulled digest back, so the only digest we
+	// can surface as the image ID is one pinned in the image reference
+	// itself (either what itzo reported or the unit's spec).
+	imageID := imageDigestID(st.Image)
+	if imageID == "" {
+		imageID = imageDigestID(specImage)
+	}
+	if imageID == "" {
+		imageID = st.Image
+	}
 	cst := v1.ContainerStatus{
 		Name:         st.Name,
 		Image:        st.Image,
-		ImageID:      st.Image,
+		ImageID:      imageID,
 		RestartCount: st.RestartCount,
 		Ready:        st.Ready,
 		Started:      st.Started,
@@ -174,6 +280,7 @@ func containerToUnit(container v1.Container) api.Unit {
 		unit.SecurityContext = &api.SecurityContext{
 			RunAsUser:  container.SecurityContext.RunAsUser,
 			RunAsGroup: container.SecurityContext.RunAsGroup,
+			Privileged: container.SecurityContext.Privileged,
 		}
 		ccaps := container.SecurityContext.Capabilities
 		if ccaps != nil {
@@ -218,6 +325,7 @@ func containerToUnit(container v1.Container) api.Unit {
 	unit.StartupProbe = k8sProbeToMilpaProbe(container.StartupProbe)
 	unit.ReadinessProbe = k8sProbeToMilpaProbe(container.ReadinessProbe)
 	unit.LivenessProbe = k8sProbeToMilpaProbe(container.LivenessProbe)
+	unit.Lifecycle = k8sLifecycleToMilpaLifecycle(container.Lifecycle)
 
 	return unit
 }
@@ -256,6 +364,7 @@ func unitToContainer(unit api.Unit, container *v1.Container) v1.Container {
 		csc := container.SecurityContext
 		csc.RunAsUser = usc.RunAsUser
 		csc.RunAsGroup = usc.RunAsGroup
+		csc.Privileged = usc.Privileged
 		ucaps := usc.Capabilities
 		if ucaps != nil {
 			caps := &v1.Capabilities{
@@ -283,6 +392,7 @@ func unitToContainer(unit api.Unit, container *v1.Container) v1.Container {
 	container.StartupProbe = milpaProbeToK8sProbe(unit.StartupProbe)
 	container.ReadinessProbe = milpaProbeToK8sProbe(unit.ReadinessProbe)
 	container.LivenessProbe = milpaProbeToK8sProbe(unit.LivenessProbe)
+	container.Lifecycle = milpaLifecycleToK8sLifecycle(unit.Lifecycle)
 
 	return *container
 }
@@ -381,6 +491,16 @@ func k8sToMilpaVolume(vol v1.Volume) *api.Volume {
 					Optional: src.ConfigMap.Optional,
 				}
 				projVol.Sources[i].ConfigMap = apiCM
+			} else if src.ServiceAccountToken != nil {
+				apiSAT := &api.ServiceAccountTokenProjection{
+					Audience: src.ServiceAccountToken.Audience,
+					Path:     src.ServiceAccountToken.Path,
+				}
+				if src.ServiceAccountToken.ExpirationSeconds != nil {
+					expiration := *src.ServiceAccountToken.ExpirationSeconds
+					apiSAT.ExpirationSeconds = &expiration
+				}
+				projVol.Sources[i].ServiceAccountToken = apiSAT
 			}
 		}
 		return &api.Volume{
@@ -389,6 +509,40 @@ func k8sToMilpaVolume(vol v1.Volume) *api.Volume {
 				Projected: projVol,
 			},
 		}
+	} else if vol.DownwardAPI != nil {
+		downwardVol := &api.DownwardAPIVolumeSource{
+			DefaultMode: vol.DownwardAPI.DefaultMode,
+		}
+		downwardVol.Items = make([]api.DownwardAPIVolumeFile, len(vol.DownwardAPI.Items))
+		for i, item := range vol.DownwardAPI.Items {
+			downwardVol.Items[i] = api.DownwardAPIVolumeFile{
+				Path: item.Path,
+				Mode: item.Mode,
+			}
+			if item.FieldRef != nil {
+				downwardVol.Items[i].FieldRef = &api.ObjectFieldSelector{
+					APIVersion: item.FieldRef.APIVersion,
+					FieldPath:  item.FieldRef.FieldPath,
+				}
+			}
+			if item.ResourceFieldRef != nil {
+				divisor := ""
+				if !item.ResourceFieldRef.Divisor.IsZero() {
+					divisor = item.ResourceFieldRef.Divisor.String()
+				}
+				downwardVol.Items[i].ResourceFieldRef = &api.ResourceFieldSelector{
+					ContainerName: item.ResourceFieldRef.ContainerName,
+					Resource:      item.ResourceFieldRef.Resource,
+					Divisor:       divisor,
+				}
+			}
+		}
+		return &api.Volume{
+			Name: vol.Name,
+			VolumeSource: api.VolumeSource{
+				DownwardAPI: downwardVol,
+			},
+		}
 	} else {
 		klog.Warningf("Unsupported volume type for volume: %s", vol.Name)
 		return &api.Volume{
@@ -496,6 +650,16 @@ func milpaToK8sVolume(vol api.Volume) *v1.Volume {
 					Optional: src.ConfigMap.Optional,
 				}
 				projVol.Sources[i].ConfigMap = k8CM
+			} else if src.ServiceAccountToken != nil {
+				k8SAT := &v1.ServiceAccountTokenProjection{
+					Audience: src.ServiceAccountToken.Audience,
+					Path:     src.ServiceAccountToken.Path,
+				}
+				if src.ServiceAccountToken.ExpirationSeconds != nil {
+					expiration := *src.ServiceAccountToken.ExpirationSeconds
+					k8SAT.ExpirationSeconds = &expiration
+				}
+				projVol.Sources[i].ServiceAccountToken = k8SAT
 			}
 		}
 		return &v1.Volume{
@@ -504,6 +668,41 @@ func milpaToK8sVolume(vol api.Volume) *v1.Volume {
 				Projected: projVol,
 			},
 		}
+	} else if vol.DownwardAPI != nil {
+		downwardVol := &v1.DownwardAPIVolumeSource{
+			DefaultMode: vol.DownwardAPI.DefaultMode,
+		}
+		downwardVol.Items = make([]v1.DownwardAPIVolumeFile, len(vol.DownwardAPI.Items))
+		for i, item := range vol.DownwardAPI.Items {
+			downwardVol.Items[i] = v1.DownwardAPIVolumeFile{
+				Path: item.Path,
+				Mode: item.Mode,
+			}
+			if item.FieldRef != nil {
+				downwardVol.Items[i].FieldRef = &v1.ObjectFieldSelector{
+					APIVersion: item.FieldRef.APIVersion,
+					FieldPath:  item.FieldRef.FieldPath,
+				}
+			}
+			if item.ResourceFieldRef != nil {
+				ref := &v1.ResourceFieldSelector{
+					ContainerName: item.ResourceFieldRef.ContainerName,
+					Resource:      item.ResourceFieldRef.Resource,
+				}
+				if item.ResourceFieldRef.Divisor != "" {
+					if divisor, err := resource.ParseQuantity(item.ResourceFieldRef.Divisor); err == nil {
+						ref.Divisor = divisor
+					}
+				}
+				downwardVol.Items[i].ResourceFieldRef = ref
+			}
+		}
+		return &v1.Volume{
+			Name: vol.Name,
+			VolumeSource: v1.VolumeSource{
+				DownwardAPI: downwardVol,
+			},
+		}
 	} else if vol.PackagePath != nil {
 		klog.V(4).Infof("skipping PackagePath %q", vol.PackagePath.Path)
 	} else {
@@ -512,6 +711,152 @@ func milpaToK8sVolume(vol api.Volume) *v1.Volume {
 	return nil
 }
 
+// podFieldRefValue resolves a downward API fieldPath against pod
+// metadata. Fields are resolved once, at pod creation; only metadata
+// fields are supported since the pod has no status yet and there is no
+// kubelet on the cell to resolve spec or status fields later.
+func podFieldRefValue(milpaPod *api.Pod, fieldPath string) (string, error) {
+	if key, ok := fieldRefSubscript(fieldPath, "metadata.labels"); ok {
+		if value, ok := milpaPod.Labels[key]; ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("pod has no label %q", key)
+	}
+	if key, ok := fieldRefSubscript(fieldPath, "metadata.annotations"); ok {
+		if value, ok := milpaPod.Annotations[key]; ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("pod has no annotation %q", key)
+	}
+	switch fieldPath {
+	case "metadata.name":
+		_, name := util.SplitNamespaceAndName(milpaPod.Name)
+		return name, nil
+	case "metadata.namespace":
+		return milpaPod.Namespace, nil
+	case "metadata.uid":
+		return milpaPod.UID, nil
+	case "metadata.labels":
+		return formatFieldRefMap(milpaPod.Labels), nil
+	case "metadata.annotations":
+		return formatFieldRefMap(milpaPod.Annotations), nil
+	}
+	return "", fmt.Errorf("unsupported fieldRef %q: only pod metadata fields can be resolved", fieldPath)
+}
+
+// fieldRefSubscript extracts the key from a subscripted fieldPath such
+// as metadata.labels['mylabel'].
+func fieldRefSubscript(fieldPath, field string) (string, bool) {
+	if strings.HasPrefix(fieldPath, field+"['") && strings.HasSuffix(fieldPath, "']") {
+		return fieldPath[len(field)+2 : len(fieldPath)-2], true
+	}
+	return "", false
+}
+
+// formatFieldRefMap formats labels or annotations the way the kubelet
+// does for the downward API, one key="value" pair per line in sorted
+// order.
+func formatFieldRefMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\n")
+}
+
+// containerResourceValue computes a divisor-adjusted resource value the
+// way the kubelet does for the downward API: the value is divided by
+// the divisor and rounded up to the nearest integer, with CPU computed
+// in millicores. A resource that is not set on the container resolves
+// to 0; there is no node allocatable to default limits to since the
+// instance is only picked at dispatch.
+func containerResourceValue(container *v1.Container, resourceName string, divisor resource.Quantity) (string, error) {
+	if divisor.IsZero() {
+		divisor = resource.MustParse("1")
+	}
+	var quantity *resource.Quantity
+	switch resourceName {
+	case "limits.cpu":
+		quantity = container.Resources.Limits.Cpu()
+	case "requests.cpu":
+		quantity = container.Resources.Requests.Cpu()
+	case "limits.memory":
+		quantity = container.Resources.Limits.Memory()
+	case "requests.memory":
+		quantity = container.Resources.Requests.Memory()
+	case "limits.ephemeral-storage":
+		quantity = container.Resources.Limits.StorageEphemeral()
+	case "requests.ephemeral-storage":
+		quantity = container.Resources.Requests.StorageEphemeral()
+	default:
+		return "", fmt.Errorf("unsupported resourceFieldRef resource %q", resourceName)
+	}
+	var value int64
+	if resourceName == "limits.cpu" || resourceName == "requests.cpu" {
+		value = int64(math.Ceil(float64(quantity.MilliValue()) / float64(divisor.MilliValue())))
+	} else {
+		value = int64(math.Ceil(float64(quantity.Value()) / float64(divisor.Value())))
+	}
+	return strconv.FormatInt(value, 10), nil
+}
+
+// findContainer returns the container or init container with the given
+// name, or nil if the pod has no such container.
+func findContainer(pod *v1.Pod, name string) *v1.Container {
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i]
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// resolveFieldRefEnv replaces env values specified via the downward API
+// with their resolved values so that units only ever see plain
+// name/value pairs.
+func resolveFieldRefEnv(milpaPod *api.Pod, pod *v1.Pod, container *v1.Container) error {
+	env := make([]v1.EnvVar, len(container.Env))
+	copy(env, container.Env)
+	for i, e := range env {
+		if e.ValueFrom == nil {
+			continue
+		}
+		var (
+			value string
+			err   error
+		)
+		if e.ValueFrom.FieldRef != nil {
+			value, err = podFieldRefValue(milpaPod, e.ValueFrom.FieldRef.FieldPath)
+		} else if e.ValueFrom.ResourceFieldRef != nil {
+			ref := e.ValueFrom.ResourceFieldRef
+			target := container
+			if ref.ContainerName != "" && ref.ContainerName != container.Name {
+				target = findContainer(pod, ref.ContainerName)
+				if target == nil {
+					err = fmt.Errorf("resourceFieldRef container %q not found", ref.ContainerName)
+				}
+			}
+			if err == nil {
+				value, err = containerResourceValue(target, ref.Resource, ref.Divisor)
+			}
+		} else {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("env %s of container %s: %v", e.Name, container.Name, err)
+		}
+		env[i] = v1.EnvVar{Name: e.Name, Value: value}
+	}
+	container.Env = env
+	return nil
+}
+
 func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 	milpapod := api.NewPod()
 	milpapod.Name = util.WithNamespace(pod.Namespace, pod.Name)
@@ -521,6 +866,36 @@ func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 	milpapod.Labels = pod.Labels
 	milpapod.Annotations = pod.Annotations
 	milpapod.Spec.RestartPolicy = api.RestartPolicy(string(pod.Spec.RestartPolicy))
+	milpapod.Spec.ActiveDeadlineSeconds = pod.Spec.ActiveDeadlineSeconds
+	if len(pod.Spec.ReadinessGates) > 0 {
+		milpapod.Spec.ReadinessGates = make([]api.PodReadinessGate, len(pod.Spec.ReadinessGates))
+		for i := range pod.Spec.ReadinessGates {
+			milpapod.Spec.ReadinessGates[i].ConditionType = string(pod.Spec.ReadinessGates[i].ConditionType)
+		}
+	}
+	for _, tsc := range pod.Spec.TopologySpreadConstraints {
+		if tsc.TopologyKey != labels.NodeZone &&
+			tsc.TopologyKey != labels.NodeInstanceType {
+			klog.Warningf("Ignoring topology spread constraint on pod %s: unsupported topology key %s",
+				milpapod.Name, tsc.TopologyKey)
+			continue
+		}
+		mtsc := api.TopologySpreadConstraint{
+			MaxSkew:           tsc.MaxSkew,
+			TopologyKey:       tsc.TopologyKey,
+			WhenUnsatisfiable: api.UnsatisfiableConstraintAction(string(tsc.WhenUnsatisfiable)),
+		}
+		if tsc.LabelSelector != nil {
+			if len(tsc.LabelSelector.MatchExpressions) > 0 {
+				klog.Warningf("Ignoring topology spread constraint on pod %s: only matchLabels selectors are supported",
+					milpapod.Name)
+				continue
+			}
+			mtsc.LabelSelector = tsc.LabelSelector.MatchLabels
+		}
+		milpapod.Spec.TopologySpreadConstraints = append(
+			milpapod.Spec.TopologySpreadConstraints, mtsc)
+	}
 	if len(pod.Spec.ImagePullSecrets) > 0 {
 		milpapod.Spec.ImagePullSecrets = make([]string, len(pod.Spec.ImagePullSecrets))
 		for i := range pod.Spec.ImagePullSecrets {
@@ -528,12 +903,26 @@ func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 		}
 	}
 
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		if container.SecurityContext == nil {
+			continue
+		}
+		err := validateCapabilities(container.SecurityContext.Capabilities)
+		if err != nil {
+			return nil, fmt.Errorf("container %q: %v", container.Name, err)
+		}
+	}
+
 	podsc := pod.Spec.SecurityContext
 	if podsc != nil {
+		if err := validateSysctls(podsc.Sysctls); err != nil {
+			return nil, err
+		}
 		mpsc := &api.PodSecurityContext{
 			RunAsUser:          podsc.RunAsUser,
 			RunAsGroup:         podsc.RunAsGroup,
 			SupplementalGroups: podsc.SupplementalGroups,
+			FSGroup:            podsc.FSGroup,
 		}
 		mpsc.NamespaceOptions = &api.NamespaceOption{
 			Network: api.NamespaceModePod,
@@ -580,19 +969,75 @@ func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 		}
 	}
 	for _, initContainer := range pod.Spec.InitContainers {
+		if err := resolveFieldRefEnv(milpapod, pod, &initContainer); err != nil {
+			return nil, err
+		}
 		initUnit := containerToUnit(initContainer)
 		milpapod.Spec.InitUnits = append(milpapod.Spec.InitUnits, initUnit)
 	}
 	for _, container := range pod.Spec.Containers {
+		if err := resolveFieldRefEnv(milpapod, pod, &container); err != nil {
+			return nil, err
+		}
 		unit := containerToUnit(container)
 		milpapod.Spec.Units = append(milpapod.Spec.Units, unit)
 	}
+	// Ephemeral containers are added by kubectl debug while the pod
+	// is running; the pod controller pushes them to the cell without
+	// restarting the pod.
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		container := v1.Container(ephemeralContainer.EphemeralContainerCommon)
+		if err := resolveFieldRefEnv(milpapod, pod, &container); err != nil {
+			return nil, err
+		}
+		unit := containerToUnit(container)
+		milpapod.Spec.EphemeralUnits = append(milpapod.Spec.EphemeralUnits, unit)
+	}
 	for _, volume := range pod.Spec.Volumes {
 		volume := k8sToMilpaVolume(volume)
 		if volume != nil {
 			milpapod.Spec.Volumes = append(milpapod.Spec.Volumes, *volume)
 		}
 	}
+	// Resolve downward API volume files now so that a bad fieldRef
+	// rejects the pod at creation instead of failing at dispatch.
+	// Resource values are baked into the items since units carry no
+	// resource limits to resolve against later.
+	for i := range milpapod.Spec.Volumes {
+		volume := &milpapod.Spec.Volumes[i]
+		if volume.DownwardAPI == nil {
+			continue
+		}
+		for j := range volume.DownwardAPI.Items {
+			item := &volume.DownwardAPI.Items[j]
+			if item.ResourceFieldRef == nil {
+				continue
+			}
+			target := findContainer(pod, item.ResourceFieldRef.ContainerName)
+			if target == nil {
+				return nil, fmt.Errorf("volume %s downward API item %s: container %q not found",
+					volume.Name, item.Path, item.ResourceFieldRef.ContainerName)
+			}
+			divisor := resource.Quantity{}
+			if item.ResourceFieldRef.Divisor != "" {
+				var err error
+				divisor, err = resource.ParseQuantity(item.ResourceFieldRef.Divisor)
+				if err != nil {
+					return nil, fmt.Errorf("volume %s downward API item %s: invalid divisor %q",
+						volume.Name, item.Path, item.ResourceFieldRef.Divisor)
+				}
+			}
+			value, err := containerResourceValue(target, item.ResourceFieldRef.Resource, divisor)
+			if err != nil {
+				return nil, fmt.Errorf("volume %s downward API item %s: %v",
+					volume.Name, item.Path, err)
+			}
+			item.Value = value
+		}
+		if _, err := getDownwardAPIVolumeFiles(milpapod, volume.DownwardAPI); err != nil {
+			return nil, fmt.Errorf("volume %s: %v", volume.Name, err)
+		}
+	}
 	// A package will be generated for these by the pod controller.
 	milpapod.Spec.Volumes = append(milpapod.Spec.Volumes, api.Volume{
 		Name: resolvconfVolumeName,
@@ -616,6 +1061,14 @@ func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 	)
 	milpapod.Spec.Hostname = pod.Spec.Hostname
 	milpapod.Spec.Subdomain = pod.Spec.Subdomain
+	milpapod.Spec.ServiceAccountName = pod.Spec.ServiceAccountName
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod := *pod.Spec.TerminationGracePeriodSeconds
+		milpapod.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	}
+	if pod.Spec.RuntimeClassName != nil {
+		milpapod.Spec.RuntimeClass = *pod.Spec.RuntimeClassName
+	}
 	if len(pod.Spec.HostAliases) > 0 {
 		milpapod.Spec.HostAliases = make(
 			[]api.HostAlias, len(pod.Spec.HostAliases))
@@ -626,14 +1079,70 @@ func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error) {
 			[]string(nil), hostAlias.Hostnames...)
 	}
 	addAnnotationsToMilpaPod(milpapod)
+	if milpapod.Spec.Timezone != "" {
+		// The pod controller deploys the zone file for us.
+		milpapod.Spec.Volumes = append(milpapod.Spec.Volumes, api.Volume{
+			Name: localtimeVolumeName,
+			VolumeSource: api.VolumeSource{
+				PackagePath: &api.PackagePath{
+					Path: "/etc/localtime",
+				},
+			},
+		})
+	}
+	addTimezoneEnv(milpapod)
 	return milpapod, nil
 }
 
+// addTimezoneEnv injects the TZ, LANG and LC_ALL environment
+// variables derived from the pod's timezone and locale annotations
+// into every unit that doesn't set them itself.
+func addTimezoneEnv(milpaPod *api.Pod) {
+	env := make([]api.EnvVar, 0, 3)
+	if milpaPod.Spec.Timezone != "" {
+		env = append(env, api.EnvVar{Name: "TZ", Value: milpaPod.Spec.Timezone})
+	}
+	if milpaPod.Spec.Locale != "" {
+		env = append(env,
+			api.EnvVar{Name: "LANG", Value: milpaPod.Spec.Locale},
+			api.EnvVar{Name: "LC_ALL", Value: milpaPod.Spec.Locale})
+	}
+	if len(env) == 0 {
+		return
+	}
+	for _, units := range [][]api.Unit{
+		milpaPod.Spec.InitUnits,
+		milpaPod.Spec.Units,
+		milpaPod.Spec.EphemeralUnits,
+	} {
+		for i := range units {
+			for _, e := range env {
+				if !unitHasEnv(units[i], e.Name) {
+					units[i].Env = append(units[i].Env, e)
+				}
+			}
+		}
+	}
+}
+
+func unitHasEnv(unit api.Unit, name string) bool {
+	for _, e := range unit.Env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func addAnnotationsToMilpaPod(milpaPod *api.Pod) {
 	a := milpaPod.Annotations[annotations.PodLaunchType]
 	if strings.ToLower(a) == "spot" {
 		milpaPod.Spec.Spot.Policy = api.SpotAlways
 	}
+	a = milpaPod.Annotations[annotations.PodCapacityPreference]
+	if strings.ToLower(a) == "spot-then-ondemand" {
+		milpaPod.Spec.Spot.Policy = api.SpotPreferred
+	}
 	a = milpaPod.Annotations[annotations.PodInstanceType]
 	if strings.ToLower(a) != "" {
 		milpaPod.Spec.InstanceType = a
@@ -649,11 +1158,180 @@ func addAnnotationsToMilpaPod(milpaPod *api.Pod) {
 	if a != "" {
 		milpaPod.Spec.Resources.VolumeSize = a
 	}
+	a = milpaPod.Annotations[annotations.PodVolumeEncrypted]
+	if a != "" {
+		val, err := strconv.ParseBool(a)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodVolumeEncrypted, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.Resources.VolumeEncrypted = &val
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodVolumeKMSKey]
+	if a != "" {
+		milpaPod.Spec.Resources.VolumeKMSKey = a
+	}
+	a = milpaPod.Annotations[annotations.PodOvercommitRatio]
+	if a != "" {
+		ratio, err := strconv.ParseFloat(a, 64)
+		if err != nil || ratio < 1.0 {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: must be a number of at least 1.0",
+				annotations.PodOvercommitRatio, milpaPod.Name)
+		} else {
+			milpaPod.Spec.Resources.OvercommitRatio = a
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodShieldedVM]
+	if a != "" {
+		val, err := strconv.ParseBool(a)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodShieldedVM, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.Resources.ShieldedVM = &val
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodConfidentialVM]
+	if a != "" {
+		val, err := strconv.ParseBool(a)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodConfidentialVM, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.Resources.ConfidentialVM = &val
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodSubnetID]
+	if a != "" {
+		milpaPod.Spec.Placement.SubnetID = a
+	}
+	a = milpaPod.Annotations[annotations.PodTenancy]
+	if a != "" {
+		milpaPod.Spec.Placement.Tenancy = strings.ToLower(a)
+	}
+	a = milpaPod.Annotations[annotations.PodDedicatedHostID]
+	if a != "" {
+		milpaPod.Spec.Placement.DedicatedHostID = a
+	}
+	a = milpaPod.Annotations[annotations.PodRuntimeClass]
+	if a != "" {
+		milpaPod.Spec.RuntimeClass = a
+	}
+	a = milpaPod.Annotations[annotations.PodStopSignal]
+	if a != "" {
+		for i := range milpaPod.Spec.Units {
+			milpaPod.Spec.Units[i].StopSignal = a
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodOOMScoreAdj]
+	if a != "" {
+		val, err := strconv.ParseInt(a, 10, 32)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodOOMScoreAdj, milpaPod.Name, err)
+		} else {
+			for i := range milpaPod.Spec.Units {
+				adj := int32(val)
+				milpaPod.Spec.Units[i].OOMScoreAdj = &adj
+			}
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodDedicatedCPUs]
+	if a != "" {
+		val, err := strconv.ParseInt(a, 10, 32)
+		if err != nil || val < 0 {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %q is not a valid core count",
+				annotations.PodDedicatedCPUs, milpaPod.Name, a)
+		} else {
+			milpaPod.Spec.Resources.DedicatedCPUs = val
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodRetryLimit]
+	if a != "" {
+		val, err := strconv.ParseInt(a, 10, 32)
+		if err != nil || val < 0 {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %q is not a valid retry limit",
+				annotations.PodRetryLimit, milpaPod.Name, a)
+		} else {
+			limit := int32(val)
+			milpaPod.Spec.RetryLimit = &limit
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodUlimits]
+	if a != "" {
+		var ulimits []api.Ulimit
+		err := json.Unmarshal([]byte(a), &ulimits)
+		if err == nil {
+			err = validateUlimits(ulimits)
+		}
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodUlimits, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.Ulimits = ulimits
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodMemorySwap]
+	if a != "" {
+		for i := range milpaPod.Spec.Units {
+			milpaPod.Spec.Units[i].MemorySwap = a
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodTimezone]
+	if a != "" {
+		if _, err := time.LoadLocation(a); err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s, the pod will run in UTC: %v",
+				annotations.PodTimezone, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.Timezone = a
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodLocale]
+	if a != "" {
+		milpaPod.Spec.Locale = a
+	}
+	a = milpaPod.Annotations[annotations.PodDataVolumes]
+	if a != "" {
+		var dataVolumes []api.DataVolumeSpec
+		err := json.Unmarshal([]byte(a), &dataVolumes)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodDataVolumes, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.DataVolumes = dataVolumes
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodNetworkInterfaces]
+	if a != "" {
+		var networkInterfaces []api.NetworkInterfaceSpec
+		err := json.Unmarshal([]byte(a), &networkInterfaces)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodNetworkInterfaces, milpaPod.Name, err)
+		} else {
+			milpaPod.Spec.NetworkInterfaces = networkInterfaces
+		}
+	}
+	a = milpaPod.Annotations[annotations.PodAWSLaunchOverrides]
+	if a != "" {
+		var overrides map[string]interface{}
+		err := json.Unmarshal([]byte(a), &overrides)
+		if err != nil {
+			klog.Warningf("Ignoring invalid %s annotation on pod %s: %v",
+				annotations.PodAWSLaunchOverrides, milpaPod.Name, err)
+		} else {
+			// The allowed keys are checked by the cloud client when
+			// the pod is created.
+			milpaPod.Spec.LaunchOverrides = a
+		}
+	}
 }
 
 func aggregateResources(containers []v1.Container, nodeSelector map[string]string) api.ResourceSpec {
 	allCpu := int64(0)
 	allMemory := int64(0)
+	allEphemeralStorage := int64(0)
 	gpus := int64(0)
 	for _, container := range containers {
 		cpu := container.Resources.Limits.Cpu().MilliValue()
@@ -666,6 +1344,11 @@ func aggregateResources(containers []v1.Container, nodeSelector map[string]strin
 			memory = container.Resources.Requests.Memory().Value()
 		}
 		allMemory += memory
+		ephemeralStorage := container.Resources.Limits.StorageEphemeral().Value()
+		if ephemeralStorage <= 0 {
+			ephemeralStorage = container.Resources.Requests.StorageEphemeral().Value()
+		}
+		allEphemeralStorage += ephemeralStorage
 		gpu := container.Resources.Limits[ResourceLimitsGPU]
 		gpus += gpu.Value()
 	}
@@ -678,6 +1361,13 @@ func aggregateResources(containers []v1.Container, nodeSelector map[string]strin
 		memoryStr = fmt.Sprintf(
 			"%.2fGi", float32(allMemory)/float32(1024*1024*1024))
 	}
+	// Ephemeral-storage requests size the cell's root volume.  Cloud
+	// volumes are provisioned in whole GiBs so round up.
+	volumeSizeStr := ""
+	if allEphemeralStorage > 0 {
+		q := resource.NewQuantity(allEphemeralStorage, resource.BinarySI)
+		volumeSizeStr = fmt.Sprintf("%dGi", util.ToGiBRoundUp(q))
+	}
 	gpuStr := ""
 	if gpus > 0 {
 		gpuStr = fmt.Sprintf("%d", gpus)
@@ -696,17 +1386,19 @@ func aggregateResources(containers []v1.Container, nodeSelector map[string]strin
 				}
 				gpuStr = gpuStr + " " + gpuType
 				return api.ResourceSpec{
-					CPU:    cpuStr,
-					Memory: memoryStr,
-					GPU:    gpuStr,
+					CPU:        cpuStr,
+					Memory:     memoryStr,
+					GPU:        gpuStr,
+					VolumeSize: volumeSizeStr,
 				}
 			}
 		}
 	}
 	return api.ResourceSpec{
-		CPU:    cpuStr,
-		Memory: memoryStr,
-		GPU:    gpuStr,
+		CPU:        cpuStr,
+		Memory:     memoryStr,
+		GPU:        gpuStr,
+		VolumeSize: volumeSizeStr,
 	}
 }
 
@@ -724,6 +1416,13 @@ func milpaToK8sPod(nodeName, internalIP string, milpaPod *api.Pod) (*v1.Pod, err
 	pod.Spec.NodeName = nodeName
 	pod.Spec.Volumes = []v1.Volume{}
 	pod.Spec.RestartPolicy = v1.RestartPolicy(string(milpaPod.Spec.RestartPolicy))
+	pod.Spec.ActiveDeadlineSeconds = milpaPod.Spec.ActiveDeadlineSeconds
+	if len(milpaPod.Spec.ReadinessGates) > 0 {
+		pod.Spec.ReadinessGates = make([]v1.PodReadinessGate, len(milpaPod.Spec.ReadinessGates))
+		for i := range milpaPod.Spec.ReadinessGates {
+			pod.Spec.ReadinessGates[i].ConditionType = v1.PodConditionType(milpaPod.Spec.ReadinessGates[i].ConditionType)
+		}
+	}
 	if len(milpaPod.Spec.ImagePullSecrets) > 0 {
 		pod.Spec.ImagePullSecrets = make([]v1.LocalObjectReference, len(milpaPod.Spec.ImagePullSecrets))
 		for i := range milpaPod.Spec.ImagePullSecrets {
@@ -740,6 +1439,7 @@ func milpaToK8sPod(nodeName, internalIP string, milpaPod *api.Pod) (*v1.Pod, err
 		psc.RunAsUser = mpsc.RunAsUser
 		psc.RunAsGroup = mpsc.RunAsGroup
 		psc.SupplementalGroups = mpsc.SupplementalGroups
+		psc.FSGroup = mpsc.FSGroup
 		if mpsc.NamespaceOptions != nil {
 			if mpsc.NamespaceOptions.Network == api.NamespaceModeNode {
 				pod.Spec.HostNetwork = true
@@ -780,6 +1480,11 @@ func milpaToK8sPod(nodeName, internalIP string, milpaPod *api.Pod) (*v1.Pod, err
 	}
 	pod.Spec.Hostname = milpaPod.Spec.Hostname
 	pod.Spec.Subdomain = milpaPod.Spec.Subdomain
+	pod.Spec.ServiceAccountName = milpaPod.Spec.ServiceAccountName
+	if milpaPod.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod := *milpaPod.Spec.TerminationGracePeriodSeconds
+		pod.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	}
 	if len(milpaPod.Spec.HostAliases) > 0 {
 		pod.Spec.HostAliases = make(
 			[]v1.HostAlias, len(milpaPod.Spec.HostAliases))
@@ -815,6 +1520,13 @@ func milpaToK8sPod(nodeName, internalIP string, milpaPod *api.Pod) (*v1.Pod, err
 		container = unitToContainer(unit, ptr)
 		pod.Spec.Containers = append(pod.Spec.Containers, container)
 	}
+	for _, unit := range milpaPod.Spec.EphemeralUnits {
+		container := unitToContainer(unit, nil)
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers,
+			v1.EphemeralContainer{
+				EphemeralContainerCommon: v1.EphemeralContainerCommon(container),
+			})
+	}
 	for _, volume := range milpaPod.Spec.Volumes {
 		volume := milpaToK8sVolume(volume)
 		if volume != nil {
@@ -899,3 +1611,85 @@ func k8sProbeToMilpaProbe(kp *v1.Probe) *api.Probe {
 	return mp
 
 }
+
+func milpaHandlerToK8sHandler(mh *api.Handler) *v1.Handler {
+	if mh == nil {
+		return nil
+	}
+	kh := &v1.Handler{}
+	if mh.Exec != nil {
+		kh.Exec = &v1.ExecAction{
+			Command: mh.Exec.Command,
+		}
+	} else if mh.HTTPGet != nil {
+		kh.HTTPGet = &v1.HTTPGetAction{
+			Path:   mh.HTTPGet.Path,
+			Port:   mh.HTTPGet.Port,
+			Host:   mh.HTTPGet.Host,
+			Scheme: v1.URIScheme(string(mh.HTTPGet.Scheme)),
+		}
+		h := make([]v1.HTTPHeader, len(mh.HTTPGet.HTTPHeaders))
+		for i := range mh.HTTPGet.HTTPHeaders {
+			h[i].Name = mh.HTTPGet.HTTPHeaders[i].Name
+			h[i].Value = mh.HTTPGet.HTTPHeaders[i].Value
+		}
+		kh.HTTPGet.HTTPHeaders = h
+	} else if mh.TCPSocket != nil {
+		kh.TCPSocket = &v1.TCPSocketAction{
+			Port: mh.TCPSocket.Port,
+			Host: mh.TCPSocket.Host,
+		}
+	}
+	return kh
+}
+
+func k8sHandlerToMilpaHandler(kh *v1.Handler) *api.Handler {
+	if kh == nil {
+		return nil
+	}
+	mh := &api.Handler{}
+	if kh.Exec != nil {
+		mh.Exec = &api.ExecAction{
+			Command: kh.Exec.Command,
+		}
+	} else if kh.HTTPGet != nil {
+		mh.HTTPGet = &api.HTTPGetAction{
+			Path:   kh.HTTPGet.Path,
+			Port:   kh.HTTPGet.Port,
+			Host:   kh.HTTPGet.Host,
+			Scheme: api.URIScheme(string(kh.HTTPGet.Scheme)),
+		}
+		h := make([]api.HTTPHeader, len(kh.HTTPGet.HTTPHeaders))
+		for i := range kh.HTTPGet.HTTPHeaders {
+			h[i].Name = kh.HTTPGet.HTTPHeaders[i].Name
+			h[i].Value = kh.HTTPGet.HTTPHeaders[i].Value
+		}
+		mh.HTTPGet.HTTPHeaders = h
+	} else if kh.TCPSocket != nil {
+		mh.TCPSocket = &api.TCPSocketAction{
+			Port: kh.TCPSocket.Port,
+			Host: kh.TCPSocket.Host,
+		}
+	}
+	return mh
+}
+
+func milpaLifecycleToK8sLifecycle(ml *api.Lifecycle) *v1.Lifecycle {
+	if ml == nil {
+		return nil
+	}
+	return &v1.Lifecycle{
+		PostStart: milpaHandlerToK8sHandler(ml.PostStart),
+		PreStop:   milpaHandlerToK8sHandler(ml.PreStop),
+	}
+}
+
+func k8sLifecycleToMilpaLifecycle(kl *v1.Lifecycle) *api.Lifecycle {
+	if kl == nil {
+		return nil
+	}
+	return &api.Lifecycle{
+		PostStart: k8sHandlerToMilpaHandler(kl.PostStart),
+		PreStop:   k8sHandlerToMilpaHandler(kl.PreStop),
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/rand"
 	"github.com/stretchr/testify/assert"
@@ -134,7 +135,7 @@ func TestUnitToContainerStatus(t *testing.T) {
 			Image:        "elotl/myimage",
 			State:        tc.unitState,
 		}
-		cs := unitToContainerStatus(us)
+		cs := unitToContainerStatus(us, us.Image)
 		if us.State.Waiting != nil {
 			assert.NotNil(t, cs.State.Waiting)
 			assert.Nil(t, cs.State.Running)
@@ -637,6 +638,251 @@ func TestProjectedVolumeConversion(t *testing.T) {
 	}
 }
 
+func TestDownwardAPIVolumeConversion(t *testing.T) {
+	volMode := int32(0555)
+	fileMode := int32(0400)
+	milpaVol := api.Volume{
+		Name: "podinfo",
+		VolumeSource: api.VolumeSource{
+			DownwardAPI: &api.DownwardAPIVolumeSource{
+				Items: []api.DownwardAPIVolumeFile{
+					{
+						Path: "labels",
+						FieldRef: &api.ObjectFieldSelector{
+							FieldPath: "metadata.labels",
+						},
+						Mode: &fileMode,
+					},
+					{
+						Path: "name",
+						FieldRef: &api.ObjectFieldSelector{
+							FieldPath: "metadata.name",
+						},
+					},
+					{
+						Path: "cpu_limit",
+						ResourceFieldRef: &api.ResourceFieldSelector{
+							ContainerName: "unit",
+							Resource:      "limits.cpu",
+							Divisor:       "1m",
+						},
+					},
+				},
+				DefaultMode: &volMode,
+			},
+		},
+	}
+	kv := milpaToK8sVolume(milpaVol)
+	mv := k8sToMilpaVolume(*kv)
+	assert.Equal(t, milpaVol, *mv)
+}
+
+func TestPodFieldRefValue(t *testing.T) {
+	milpaPod := api.NewPod()
+	milpaPod.Namespace = "default"
+	milpaPod.Name = util.WithNamespace(milpaPod.Namespace, "testpod")
+	milpaPod.UID = "pod-uid"
+	milpaPod.Labels = map[string]string{
+		"app":  "myapp",
+		"tier": "backend",
+	}
+	milpaPod.Annotations = map[string]string{
+		"owner": "team-a",
+	}
+	testCases := []struct {
+		fieldPath string
+		value     string
+		isError   bool
+	}{
+		{fieldPath: "metadata.name", value: "testpod"},
+		{fieldPath: "metadata.namespace", value: "default"},
+		{fieldPath: "metadata.uid", value: "pod-uid"},
+		{fieldPath: "metadata.labels['app']", value: "myapp"},
+		{fieldPath: "metadata.annotations['owner']", value: "team-a"},
+		{fieldPath: "metadata.labels", value: "app=\"myapp\"\ntier=\"backend\""},
+		{fieldPath: "metadata.annotations", value: "owner=\"team-a\""},
+		{fieldPath: "metadata.labels['missing']", isError: true},
+		{fieldPath: "spec.nodeName", isError: true},
+		{fieldPath: "status.podIP", isError: true},
+	}
+	for _, tc := range testCases {
+		value, err := podFieldRefValue(milpaPod, tc.fieldPath)
+		if tc.isError {
+			assert.Error(t, err, tc.fieldPath)
+			continue
+		}
+		assert.NoError(t, err, tc.fieldPath)
+		assert.Equal(t, tc.value, value, tc.fieldPath)
+	}
+}
+
+func TestResolveFieldRefEnv(t *testing.T) {
+	milpaPod := api.NewPod()
+	milpaPod.Namespace = "default"
+	milpaPod.Name = util.WithNamespace(milpaPod.Namespace, "testpod")
+	milpaPod.Labels = map[string]string{"app": "myapp"}
+	pod := &v1.Pod{}
+	container := v1.Container{
+		Name: "unit",
+		Env: []v1.EnvVar{
+			{Name: "PLAIN", Value: "value"},
+			{
+				Name: "POD_NAME",
+				ValueFrom: &v1.EnvVarSource{
+					FieldRef: &v1.ObjectFieldSelector{
+						FieldPath: "metadata.name",
+					},
+				},
+			},
+			{
+				Name: "APP",
+				ValueFrom: &v1.EnvVarSource{
+					FieldRef: &v1.ObjectFieldSelector{
+						FieldPath: "metadata.labels['app']",
+					},
+				},
+			},
+		},
+	}
+	err := resolveFieldRefEnv(milpaPod, pod, &container)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1.EnvVar{
+		{Name: "PLAIN", Value: "value"},
+		{Name: "POD_NAME", Value: "testpod"},
+		{Name: "APP", Value: "myapp"},
+	}, container.Env)
+	container.Env = []v1.EnvVar{
+		{
+			Name: "NODE_NAME",
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{
+					FieldPath: "spec.nodeName",
+				},
+			},
+		},
+	}
+	err = resolveFieldRefEnv(milpaPod, pod, &container)
+	assert.Error(t, err)
+}
+
+func TestContainerResourceValue(t *testing.T) {
+	container := v1.Container{
+		Name: "unit",
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1200m"),
+				v1.ResourceMemory: resource.MustParse("100Mi"),
+			},
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("500m"),
+				v1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+	}
+	testCases := []struct {
+		resource string
+		divisor  string
+		value    string
+		isError  bool
+	}{
+		// CPU is computed in millicores and rounded up.
+		{resource: "limits.cpu", divisor: "", value: "2"},
+		{resource: "limits.cpu", divisor: "1m", value: "1200"},
+		{resource: "requests.cpu", divisor: "", value: "1"},
+		{resource: "requests.cpu", divisor: "1m", value: "500"},
+		{resource: "limits.memory", divisor: "", value: "104857600"},
+		{resource: "limits.memory", divisor: "1Mi", value: "100"},
+		{resource: "requests.memory", divisor: "1Ki", value: "65536"},
+		// Rounded up to the nearest integer.
+		{resource: "requests.memory", divisor: "1Gi", value: "1"},
+		// Unset resources resolve to 0.
+		{resource: "limits.ephemeral-storage", divisor: "", value: "0"},
+		{resource: "limits.gpu", divisor: "", isError: true},
+	}
+	for _, tc := range testCases {
+		divisor := resource.Quantity{}
+		if tc.divisor != "" {
+			divisor = resource.MustParse(tc.divisor)
+		}
+		value, err := containerResourceValue(&container, tc.resource, divisor)
+		name := fmt.Sprintf("%s/%s", tc.resource, tc.divisor)
+		if tc.isError {
+			assert.Error(t, err, name)
+			continue
+		}
+		assert.NoError(t, err, name)
+		assert.Equal(t, tc.value, value, name)
+	}
+}
+
+func TestResolveResourceFieldRefEnv(t *testing.T) {
+	milpaPod := api.NewPod()
+	milpaPod.Namespace = "default"
+	milpaPod.Name = util.WithNamespace(milpaPod.Namespace, "testpod")
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "other",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+				},
+				{
+					Name: "unit",
+					Resources: v1.ResourceRequirements{
+						Limits: v1.ResourceList{
+							v1.ResourceCPU: resource.MustParse("1500m"),
+						},
+					},
+				},
+			},
+		},
+	}
+	container := &pod.Spec.Containers[1]
+	container.Env = []v1.EnvVar{
+		{
+			Name: "CPU_LIMIT",
+			ValueFrom: &v1.EnvVarSource{
+				ResourceFieldRef: &v1.ResourceFieldSelector{
+					Resource: "limits.cpu",
+				},
+			},
+		},
+		{
+			Name: "OTHER_MEMORY_MB",
+			ValueFrom: &v1.EnvVarSource{
+				ResourceFieldRef: &v1.ResourceFieldSelector{
+					ContainerName: "other",
+					Resource:      "limits.memory",
+					Divisor:       resource.MustParse("1Mi"),
+				},
+			},
+		},
+	}
+	err := resolveFieldRefEnv(milpaPod, pod, container)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1.EnvVar{
+		{Name: "CPU_LIMIT", Value: "2"},
+		{Name: "OTHER_MEMORY_MB", Value: "256"},
+	}, container.Env)
+	container.Env = []v1.EnvVar{
+		{
+			Name: "MISSING",
+			ValueFrom: &v1.EnvVarSource{
+				ResourceFieldRef: &v1.ResourceFieldSelector{
+					ContainerName: "nosuchcontainer",
+					Resource:      "limits.cpu",
+				},
+			},
+		},
+	}
+	err = resolveFieldRefEnv(milpaPod, pod, container)
+	assert.Error(t, err)
+}
+
 //func k8sToMilpaPod(pod *v1.Pod) (*api.Pod, error)
 //func milpaToK8sPod(milpaPod *api.Pod) (*v1.Pod, error)
 func TestMilpaToK8sPod(t *testing.T) {
@@ -805,6 +1051,35 @@ func TestConvertingProbes(t *testing.T) {
 	assert.Equal(t, mp, mp2)
 }
 
+func TestConvertingLifecycle(t *testing.T) {
+	ml := &api.Lifecycle{
+		PostStart: &api.Handler{
+			Exec: &api.ExecAction{
+				Command: []string{"/bin/warmup", "--fast"},
+			},
+		},
+		PreStop: &api.Handler{
+			HTTPGet: &api.HTTPGetAction{
+				Path:   "/shutdown",
+				Port:   intstr.FromInt(8080),
+				Host:   "localhost",
+				Scheme: api.URISchemeHTTP,
+				HTTPHeaders: []api.HTTPHeader{
+					{
+						Name:  "x-name",
+						Value: "my value",
+					},
+				},
+			},
+		},
+	}
+	kl := milpaLifecycleToK8sLifecycle(ml)
+	ml2 := k8sLifecycleToMilpaLifecycle(kl)
+	assert.Equal(t, ml, ml2)
+	assert.Nil(t, milpaLifecycleToK8sLifecycle(nil))
+	assert.Nil(t, k8sLifecycleToMilpaLifecycle(nil))
+}
+
 //func aggregateResources(spec v1.PodSpec) api.ResourceSpec
 func TestAggregateResources(t *testing.T) {
 	testCases := []struct {
@@ -932,6 +1207,51 @@ func TestAggregateResources(t *testing.T) {
 				GPU:    "4 nvidia-tesla-k80",
 			},
 		},
+		{
+			requirements: []v1.ResourceRequirements{
+				{
+					Requests: v1.ResourceList{
+						v1.ResourceEphemeralStorage: resource.MustParse("8Gi"),
+					},
+				},
+			},
+			resources: api.ResourceSpec{
+				VolumeSize: "8Gi",
+			},
+		},
+		{
+			requirements: []v1.ResourceRequirements{
+				{
+					Limits: v1.ResourceList{
+						v1.ResourceEphemeralStorage: resource.MustParse("10Gi"),
+					},
+					Requests: v1.ResourceList{
+						v1.ResourceEphemeralStorage: resource.MustParse("5Gi"),
+					},
+				},
+			},
+			resources: api.ResourceSpec{
+				VolumeSize: "10Gi",
+			},
+		},
+		{
+			// Volumes are provisioned in whole GiBs, sizes round up.
+			requirements: []v1.ResourceRequirements{
+				{
+					Requests: v1.ResourceList{
+						v1.ResourceEphemeralStorage: resource.MustParse("2500Mi"),
+					},
+				},
+				{
+					Requests: v1.ResourceList{
+						v1.ResourceEphemeralStorage: resource.MustParse("2500Mi"),
+					},
+				},
+			},
+			resources: api.ResourceSpec{
+				VolumeSize: "5Gi",
+			},
+		},
 	}
 	for _, tc := range testCases {
 		containers := make([]v1.Container, len(tc.requirements))
@@ -942,3 +1262,225 @@ func TestAggregateResources(t *testing.T) {
 		assert.Equal(t, tc.resources, resources)
 	}
 }
+
+func TestMilpaToK8sPodUnschedulable(t *testing.T) {
+	milpaPod := api.GetFakePod()
+	milpaPod.Status.Phase = api.PodWaiting
+	milpaPod.Status.UnschedulableReason = "pod has been waiting 600s for a cell, no cell can currently satisfy the request"
+	pod, err := milpaToK8sPod("mynode", "", milpaPod)
+	assert.NoError(t, err)
+	var scheduled *v1.PodCondition
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == v1.PodScheduled {
+			scheduled = &pod.Status.Conditions[i]
+		}
+	}
+	if assert.NotNil(t, scheduled) {
+		assert.Equal(t, v1.ConditionFalse, scheduled.Status)
+		assert.Equal(t, v1.PodReasonUnschedulable, scheduled.Reason)
+		assert.Equal(t, milpaPod.Status.UnschedulableReason, scheduled.Message)
+	}
+
+	milpaPod.Status.UnschedulableReason = ""
+	pod, err = milpaToK8sPod("mynode", "", milpaPod)
+	assert.NoError(t, err)
+	scheduled = nil
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == v1.PodScheduled {
+			scheduled = &pod.Status.Conditions[i]
+		}
+	}
+	if assert.NotNil(t, scheduled) {
+		assert.Equal(t, v1.ConditionTrue, scheduled.Status)
+	}
+}
+
+func TestMilpaToK8sPodDeadlineExceeded(t *testing.T) {
+	milpaPod := api.GetFakePod()
+	milpaPod.Spec.RestartPolicy = api.RestartPolicyAlways
+	milpaPod.Status.Phase = api.PodFailed
+	milpaPod.Status.FailureReason = podReasonDeadlineExceeded
+	pod, err := milpaToK8sPod("mynode", "", milpaPod)
+	assert.NoError(t, err)
+	// a pod that exceeded its deadline is failed for good, the
+	// restart policy doesn't put it back into pending
+	assert.Equal(t, v1.PodFailed, pod.Status.Phase)
+	assert.Equal(t, podReasonDeadlineExceeded, pod.Status.Reason)
+}
+
+func TestActiveDeadlineSecondsConversion(t *testing.T) {
+	k8sPod := &v1.Pod{}
+	k8sPod.Name = "deadlined"
+	deadline := int64(300)
+	k8sPod.Spec.ActiveDeadlineSeconds = &deadline
+	milpaPod, err := k8sToMilpaPod(k8sPod)
+	assert.NoError(t, err)
+	if assert.NotNil(t, milpaPod.Spec.ActiveDeadlineSeconds) {
+		assert.Equal(t, deadline, *milpaPod.Spec.ActiveDeadlineSeconds)
+	}
+	back, err := milpaToK8sPod("mynode", "", milpaPod)
+	assert.NoError(t, err)
+	if assert.NotNil(t, back.Spec.ActiveDeadlineSeconds) {
+		assert.Equal(t, deadline, *back.Spec.ActiveDeadlineSeconds)
+	}
+}
+
+func TestAddAnnotationsMemoryTuning(t *testing.T) {
+	milpaPod := api.GetFakePod()
+	milpaPod.Annotations = map[string]string{
+		annotations.PodOOMScoreAdj: "-500",
+		annotations.PodMemorySwap:  "1Gi",
+	}
+	addAnnotationsToMilpaPod(milpaPod)
+	for _, unit := range milpaPod.Spec.Units {
+		if assert.NotNil(t, unit.OOMScoreAdj) {
+			assert.Equal(t, int32(-500), *unit.OOMScoreAdj)
+		}
+		assert.Equal(t, "1Gi", unit.MemorySwap)
+	}
+
+	// an unparsable adjustment is ignored
+	milpaPod = api.GetFakePod()
+	milpaPod.Annotations = map[string]string{
+		annotations.PodOOMScoreAdj: "lots",
+	}
+	addAnnotationsToMilpaPod(milpaPod)
+	for _, unit := range milpaPod.Spec.Units {
+		assert.Nil(t, unit.OOMScoreAdj)
+	}
+}
+
+func TestUnitStateToContainerStateOOMKilled(t *testing.T) {
+	st := api.UnitState{
+		Terminated: &api.UnitStateTerminated{
+			ExitCode: 137,
+		},
+	}
+	k8s := unitStateToContainerState(st)
+	if assert.NotNil(t, k8s.Terminated) {
+		assert.Equal(t, "OOMKilled", k8s.Terminated.Reason)
+	}
+	// a reason reported by itzo wins
+	st.Terminated.Reason = "Killed"
+	k8s = unitStateToContainerState(st)
+	if assert.NotNil(t, k8s.Terminated) {
+		assert.Equal(t, "Killed", k8s.Terminated.Reason)
+	}
+}
+
+func TestImageDigestID(t *testing.T) {
+	digest := "sha256:b90dcf2a8ce95cd17d41a6aa1d71c6dc6a1b68e1b583fe9eb5eb8d9a1b8e61b5"
+	// digest-pinned references get a kubelet-style image ID
+	assert.Equal(t, "docker-pullable://nginx@"+digest,
+		imageDigestID("nginx@"+digest))
+	assert.Equal(t, "docker-pullable://quay.io/elotl/itzo@"+digest,
+		imageDigestID("quay.io/elotl/itzo@"+digest))
+	// tagged or unparsable references have no digest to surface
+	assert.Equal(t, "", imageDigestID("nginx:1.19"))
+	assert.Equal(t, "", imageDigestID("UPPERCASE/nope"))
+
+	// a status without a digest falls back to the spec image's digest
+	us := api.UnitStatus{
+		Name:  "myunit",
+		Image: "nginx:1.19",
+	}
+	cs := unitToContainerStatus(us, "nginx@"+digest)
+	assert.Equal(t, "docker-pullable://nginx@"+digest, cs.ImageID)
+	// with no digest anywhere the image ID is the reported image
+	cs = unitToContainerStatus(us, "nginx:1.19")
+	assert.Equal(t, "nginx:1.19", cs.ImageID)
+}
+
+func TestAddAnnotationsTimezone(t *testing.T) {
+	milpaPod := api.GetFakePod()
+	milpaPod.Annotations = map[string]string{
+		annotations.PodTimezone: "Europe/Berlin",
+		annotations.PodLocale:   "de_DE.UTF-8",
+	}
+	addAnnotationsToMilpaPod(milpaPod)
+	assert.Equal(t, "Europe/Berlin", milpaPod.Spec.Timezone)
+	assert.Equal(t, "de_DE.UTF-8", milpaPod.Spec.Locale)
+
+	// a name that's not in the tz database is ignored
+	milpaPod = api.GetFakePod()
+	milpaPod.Annotations = map[string]string{
+		annotations.PodTimezone: "Atlantis/Underwater",
+	}
+	addAnnotationsToMilpaPod(milpaPod)
+	assert.Equal(t, "", milpaPod.Spec.Timezone)
+}
+
+func TestAddTimezoneEnv(t *testing.T) {
+	milpaPod := api.GetFakePod()
+	milpaPod.Spec.Units = []api.Unit{
+		{
+			Name:  "app",
+			Image: "nginx",
+		},
+		{
+			Name:  "custom",
+			Image: "nginx",
+			Env: []api.EnvVar{
+				{Name: "TZ", Value: "America/New_York"},
+			},
+		},
+	}
+	milpaPod.Spec.Timezone = "Europe/Berlin"
+	milpaPod.Spec.Locale = "de_DE.UTF-8"
+	addTimezoneEnv(milpaPod)
+	assert.Equal(t, []api.EnvVar{
+		{Name: "TZ", Value: "Europe/Berlin"},
+		{Name: "LANG", Value: "de_DE.UTF-8"},
+		{Name: "LC_ALL", Value: "de_DE.UTF-8"},
+	}, milpaPod.Spec.Units[0].Env)
+	// a unit that sets TZ itself wins over the annotation
+	assert.Equal(t, []api.EnvVar{
+		{Name: "TZ", Value: "America/New_York"},
+		{Name: "LANG", Value: "de_DE.UTF-8"},
+		{Name: "LC_ALL", Value: "de_DE.UTF-8"},
+	}, milpaPod.Spec.Units[1].Env)
+}
+
+func TestGetStatusReadinessGates(t *testing.T) {
+	_, ip := fakeInstanceProvider()
+	findCondition := func(conditions []v1.PodCondition, conditionType v1.PodConditionType) *v1.PodCondition {
+		for i := range conditions {
+			if conditions[i].Type == conditionType {
+				return &conditions[i]
+			}
+		}
+		return nil
+	}
+	pod := &v1.Pod{}
+	pod.Spec.ReadinessGates = []v1.PodReadinessGate{
+		{ConditionType: "elotl.co/traffic-ready"},
+	}
+	milpaPod := api.GetFakePod()
+	milpaPod.Status.Phase = api.PodRunning
+	// the gate condition hasn't been set yet, the pod must not be ready
+	podStatus := getStatus(ip, milpaPod, pod)
+	ready := findCondition(podStatus.Conditions, v1.PodReady)
+	if assert.NotNil(t, ready) {
+		assert.Equal(t, v1.ConditionFalse, ready.Status)
+	}
+	// an unsatisfied gate condition keeps the pod unready
+	milpaPod.Status.Conditions = []api.PodCondition{
+		{Type: "elotl.co/traffic-ready", Status: api.ConditionFalse},
+	}
+	podStatus = getStatus(ip, milpaPod, pod)
+	ready = findCondition(podStatus.Conditions, v1.PodReady)
+	if assert.NotNil(t, ready) {
+		assert.Equal(t, v1.ConditionFalse, ready.Status)
+	}
+	// once the gate condition is true the pod becomes ready
+	milpaPod.Status.Conditions = []api.PodCondition{
+		{Type: "elotl.co/traffic-ready", Status: api.ConditionTrue},
+	}
+	podStatus = getStatus(ip, milpaPod, pod)
+	ready = findCondition(podStatus.Conditions, v1.PodReady)
+	if assert.NotNil(t, ready) {
+		assert.Equal(t, v1.ConditionTrue, ready.Status)
+	}
+	gate := findCondition(podStatus.Conditions, "elotl.co/traffic-ready")
+	assert.NotNil(t, gate)
+}
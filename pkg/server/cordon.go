@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"github.com/docker/libkv/store"
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/etcd"
+	"github.com/elotl/kip/pkg/util"
+	"golang.org/x/net/context"
+	"k8s.io/klog"
+)
+
+const etcdClusterCordonedPath string = "milpa/cluster/cordoned"
+
+// loadCordonState reads the persisted cordon flag from storage so a
+// cordoned node stays cordoned across controller restarts.
+func loadCordonState(etcdClient etcd.Storer) (bool, error) {
+	pair, err := etcdClient.Get(etcdClusterCordonedPath)
+	if err == store.ErrKeyNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, util.WrapError(
+			err, "Error pulling cordon state from storage")
+	}
+	return string(pair.Value) == "true", nil
+}
+
+func saveCordonState(etcdClient etcd.Storer, cordoned bool) error {
+	value := "false"
+	if cordoned {
+		value = "true"
+	}
+	return etcdClient.Put(etcdClusterCordonedPath, []byte(value), nil)
+}
+
+// Cordon marks the virtual node as unschedulable (or schedulable
+// again).  A cordoned node rejects new pods in CreatePod but leaves
+// running pods alone.  The flag is persisted so it survives controller
+// restarts.
+func (s InstanceProvider) Cordon(context context.Context, request *clientapi.CordonRequest) (*clientapi.APIReply, error) {
+	if err := saveCordonState(s.etcdClient, request.Cordoned); err != nil {
+		return errToAPIReply(
+			util.WrapError(err, "Error persisting cordon state")), nil
+	}
+	s.cordoned.Store(request.Cordoned)
+	msg := "node cordoned"
+	if !request.Cordoned {
+		msg = "node uncordoned"
+	}
+	klog.V(2).Info(msg)
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   []byte(msg),
+	}
+	return &reply, nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCordonStateRoundTrip(t *testing.T) {
+	name, closer := util.MakeTempFileName("milpa_cordon")
+	defer closer()
+	kvstore := registry.CreateKVStore(name)
+	// No state persisted means not cordoned.
+	cordoned, err := loadCordonState(kvstore)
+	assert.NoError(t, err)
+	assert.False(t, cordoned)
+	err = saveCordonState(kvstore, true)
+	assert.NoError(t, err)
+	cordoned, err = loadCordonState(kvstore)
+	assert.NoError(t, err)
+	assert.True(t, cordoned)
+	err = saveCordonState(kvstore, false)
+	assert.NoError(t, err)
+	cordoned, err = loadCordonState(kvstore)
+	assert.NoError(t, err)
+	assert.False(t, cordoned)
+}
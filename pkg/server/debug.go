@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+// servePprof serves Go's net/http/pprof handlers over plain HTTP on
+// the given address.  It uses its own mux so the handlers never leak
+// onto any server using http.DefaultServeMux and stay off the kubelet
+// API port.  The server shuts down when quit is closed.
+func servePprof(address string, quit <-chan struct{}, wg *sync.WaitGroup) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return util.WrapError(err, "Invalid pprof address %s", address)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		klog.Warningf("pprof debug endpoint %s is not bound to localhost; profiles will be reachable from outside the pod", address)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return util.WrapError(err, "Error listening on %s for pprof", address)
+	}
+	server := &http.Server{
+		Handler: mux,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		klog.V(2).Infof("serving pprof debug endpoint on %s", address)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("pprof debug server error: %v", err)
+		}
+	}()
+	go func() {
+		<-quit
+		server.Close()
+	}()
+	return nil
+}
@@ -18,14 +18,23 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 	"k8s.io/klog"
 )
 
+// deletePodsConcurrency bounds how many pods we terminate in parallel
+// during a bulk delete so a large namespace doesn't overwhelm the
+// registry or the cloud API.
+const deletePodsConcurrency = 8
+
 func (s InstanceProvider) deleteHelper(kind, name string, cascade bool) (api.MilpaObject, error) {
 	store, exists := s.Registries[kind]
 	if !exists {
@@ -38,6 +47,107 @@ func (s InstanceProvider) deleteHelper(kind, name string, cascade bool) (api.Mil
 	return replyObj, nil
 }
 
+// PodDeleteFailure records a pod that could not be deleted during a
+// bulk delete along with the reason.
+type PodDeleteFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// PodsDeleteResult is the outcome of a bulk pod delete: the pods that
+// were marked for termination and the ones that failed.
+type PodsDeleteResult struct {
+	Deleted []string           `json:"deleted"`
+	Failed  []PodDeleteFailure `json:"failed,omitempty"`
+}
+
+// deletePods deletes every live pod matching the filter, fanning the
+// deletes out to a bounded pool of workers.  Failures are collected
+// per pod instead of aborting the whole operation.
+func (s InstanceProvider) deletePods(podRegistry *registry.PodRegistry, filter func(*api.Pod) bool) PodsDeleteResult {
+	podList, err := podRegistry.ListPods(filter)
+	result := PodsDeleteResult{Deleted: []string{}}
+	if err != nil {
+		result.Failed = append(result.Failed, PodDeleteFailure{
+			Name:  "",
+			Error: util.WrapError(err, "Error listing pods").Error(),
+		})
+		return result
+	}
+	workChan := make(chan *api.Pod)
+	var mu sync.Mutex
+	wg := sync.WaitGroup{}
+	for i := 0; i < deletePodsConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pod := range workChan {
+				_, err := podRegistry.Delete(pod.Name)
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, PodDeleteFailure{
+						Name:  pod.Name,
+						Error: err.Error(),
+					})
+				} else {
+					result.Deleted = append(result.Deleted, pod.Name)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, pod := range podList.Items {
+		workChan <- pod
+	}
+	close(workChan)
+	wg.Wait()
+	sort.Strings(result.Deleted)
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].Name < result.Failed[j].Name
+	})
+	return result
+}
+
+// DeletePods deletes all pods in a namespace (or every pod when all
+// is set) in one call so tearing down an environment doesn't require
+// deleting pods one by one.  The reply lists which pods were deleted
+// and which failed.
+func (s InstanceProvider) DeletePods(context context.Context, request *clientapi.DeletePodsRequest) (*clientapi.APIReply, error) {
+	if !s.controllerManager.ControllersRunning() {
+		return notTheLeaderReply(), nil
+	}
+
+	namespace := string(request.Namespace)
+	if namespace == "" && !request.All {
+		return errToAPIReply(fmt.Errorf(
+			"Deleting pods across all namespaces requires the all flag")), nil
+	}
+	klog.V(2).Infof("DeletePods request for namespace %q (all: %t)",
+		namespace, request.All)
+	reg, exists := s.Registries["Pod"]
+	if !exists {
+		return errToAPIReply(
+			fmt.Errorf("Fatal error: can't find pod registry in storage")), nil
+	}
+	podRegistry := reg.(*registry.PodRegistry)
+	filter := func(p *api.Pod) bool {
+		if !registry.MatchAllLivePods(p) {
+			return false
+		}
+		return namespace == "" || util.GetNamespaceFromString(p.Name) == namespace
+	}
+	result := s.deletePods(podRegistry, filter)
+	body, err := json.Marshal(result)
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error serializing reply object")), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 202,
+		Body:   body,
+	}
+	return &reply, nil
+}
+
 func (s InstanceProvider) Delete(context context.Context, request *clientapi.DeleteRequest) (*clientapi.APIReply, error) {
 	if !s.controllerManager.ControllersRunning() {
 		return notTheLeaderReply(), nil
@@ -22,8 +22,10 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/nodeclient"
@@ -174,6 +176,38 @@ func getSecretFiles(secVol *api.SecretVolumeSource, sec *v1.Secret) (map[string]
 	return packageItems, nil
 }
 
+func getDownwardAPIVolumeFiles(pod *api.Pod, downwardVol *api.DownwardAPIVolumeSource) (map[string]packageFile, error) {
+	packageItems := make(map[string]packageFile)
+	defaultMode := defaultVolumeFileMode
+	if downwardVol.DefaultMode != nil {
+		defaultMode = *downwardVol.DefaultMode
+	}
+	for _, item := range downwardVol.Items {
+		var value string
+		if item.FieldRef != nil {
+			var err error
+			value, err = podFieldRefValue(pod, item.FieldRef.FieldPath)
+			if err != nil {
+				return nil, fmt.Errorf("downward API item %s: %v", item.Path, err)
+			}
+		} else if item.ResourceFieldRef != nil {
+			// Resolved by the pod controller at creation time.
+			value = item.Value
+		} else {
+			return nil, fmt.Errorf("downward API item %s: only fieldRef and resourceFieldRef are supported", item.Path)
+		}
+		mode := defaultMode
+		if item.Mode != nil {
+			mode = *item.Mode
+		}
+		packageItems[item.Path] = packageFile{
+			data: []byte(value),
+			mode: mode,
+		}
+	}
+	return packageItems, nil
+}
+
 func getConfigMapVolumeFiles(namespace string, cmVol *api.ConfigMapVolumeSource, rm *manager.ResourceManager) (map[string]packageFile, error) {
 	optional := cmVol.Optional != nil && *cmVol.Optional
 	// get the configmap
@@ -209,11 +243,32 @@ func getSecretVolumeFiles(namespace string, secVol *api.SecretVolumeSource, rm *
 	return getSecretFiles(secVol, secret)
 }
 
-func getProjectedVolumeFiles(namespace string, vol *api.ProjectedVolumeSource, rm *manager.ResourceManager) (map[string]packageFile, error) {
+func getServiceAccountTokenFile(pod *api.Pod, proj *api.ServiceAccountTokenProjection, tokens *serviceAccountTokenManager) (map[string]packageFile, error) {
+	if tokens == nil {
+		return nil, fmt.Errorf("pod %s requests a projected service account token but the TokenRequest API is not available", pod.Name)
+	}
+	token, err := tokens.PodToken(pod, proj)
+	if err != nil {
+		return nil, err
+	}
+	path := proj.Path
+	if path == "" {
+		path = "token"
+	}
+	return map[string]packageFile{
+		path: {
+			data: []byte(token),
+			mode: 0600,
+		},
+	}, nil
+}
+
+func getProjectedVolumeFiles(pod *api.Pod, vol *api.ProjectedVolumeSource, rm *manager.ResourceManager, tokens *serviceAccountTokenManager) (map[string]packageFile, error) {
 	defaultMode := api.ProjectedVolumeSourceDefaultMode
 	if vol.DefaultMode != nil {
 		defaultMode = *vol.DefaultMode
 	}
+	namespace := pod.Namespace
 	allPackageFiles := make(map[string]packageFile)
 	for _, src := range vol.Sources {
 		var (
@@ -242,6 +297,11 @@ func getProjectedVolumeFiles(namespace string, vol *api.ProjectedVolumeSource, r
 			if err != nil {
 				return nil, util.WrapError(err, "couldn't get projected secret payload %v/%v", namespace, src.Secret.Name)
 			}
+		} else if src.ServiceAccountToken != nil {
+			packageFiles, err = getServiceAccountTokenFile(pod, src.ServiceAccountToken, tokens)
+			if err != nil {
+				return nil, util.WrapError(err, "couldn't get projected service account token for %v", pod.Name)
+			}
 		}
 		for k, v := range packageFiles {
 			allPackageFiles[k] = v
@@ -250,7 +310,7 @@ func getProjectedVolumeFiles(namespace string, vol *api.ProjectedVolumeSource, r
 	return allPackageFiles, nil
 }
 
-func deployPodVolumes(pod *api.Pod, node *api.Node, rm *manager.ResourceManager, nodeClientFactory nodeclient.ItzoClientFactoryer) error {
+func deployPodVolumes(pod *api.Pod, node *api.Node, rm *manager.ResourceManager, tokens *serviceAccountTokenManager, nodeClientFactory nodeclient.ItzoClientFactoryer) error {
 	client := nodeClientFactory.GetClient(node.Status.Addresses)
 	for _, vol := range pod.Spec.Volumes {
 		var (
@@ -268,10 +328,15 @@ func deployPodVolumes(pod *api.Pod, node *api.Node, rm *manager.ResourceManager,
 				return util.WrapError(err, "couldn't get secret payload %v/%v", pod.Namespace, vol.Secret.SecretName)
 			}
 		} else if vol.Projected != nil {
-			packageFiles, err = getProjectedVolumeFiles(pod.Namespace, vol.Projected, rm)
+			packageFiles, err = getProjectedVolumeFiles(pod, vol.Projected, rm, tokens)
 			if err != nil {
 				return err
 			}
+		} else if vol.DownwardAPI != nil {
+			packageFiles, err = getDownwardAPIVolumeFiles(pod, vol.DownwardAPI)
+			if err != nil {
+				return util.WrapError(err, "couldn't get downward API payload for %v", pod.Name)
+			}
 		}
 		// Deploy empty packages as well since they might be
 		// referenced in a container (but will have no data)
@@ -415,3 +480,65 @@ func deployEtcHosts(pod *api.Pod, node *api.Node, dnsConfigurer *dns.Configurer,
 	}
 	return nil
 }
+
+// zoneinfoDirs are the directories the IANA tz database is commonly
+// installed in on the machine running the provider.
+var zoneinfoDirs = []string{
+	"/usr/share/zoneinfo",
+	"/usr/share/lib/zoneinfo",
+	"/usr/lib/locale/TZ",
+}
+
+func readZoneinfoFile(timezone string) ([]byte, error) {
+	// The timezone was validated with time.LoadLocation when the pod
+	// was created but re-check here so a name that snuck into the
+	// registry by other means can't escape the zoneinfo directories.
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, util.WrapError(err, "invalid timezone %q", timezone)
+	}
+	var firstErr error
+	for _, dir := range zoneinfoDirs {
+		data, err := ioutil.ReadFile(filepath.Join(dir, timezone))
+		if err == nil {
+			return data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// deployLocaltime deploys the zone file for the pod's timezone as
+// /etc/localtime on the cell. Units also get the TZ environment
+// variable so a missing zone file on the machine running the provider
+// degrades to a warning instead of failing the pod.
+func deployLocaltime(pod *api.Pod, node *api.Node, nodeClientFactory nodeclient.ItzoClientFactoryer) error {
+	if pod.Spec.Timezone == "" {
+		return nil
+	}
+	data, err := readZoneinfoFile(pod.Spec.Timezone)
+	if err != nil {
+		klog.Warningf("Could not read zone file for timezone %q of pod %s, only setting TZ: %v",
+			pod.Spec.Timezone, pod.Name, err)
+		data = nil
+	}
+	files := map[string]packageFile{}
+	if data != nil {
+		files["/etc/localtime"] = packageFile{
+			data: data,
+			mode: 0644,
+		}
+	}
+	payload, err := makeDeployPackage(files)
+	if err != nil {
+		return util.WrapError(err, "creating pod /etc/localtime package")
+	}
+	client := nodeClientFactory.GetClient(node.Status.Addresses)
+	err = client.Deploy(pod.Name, localtimeVolumeName, bufio.NewReader(payload))
+	if err != nil {
+		return util.WrapError(
+			err, "error deploying /etc/localtime package to %s", pod.Name)
+	}
+	return nil
+}
@@ -185,6 +185,97 @@ func TestGetConfigMapFiles(t *testing.T) {
 	}
 }
 
+func TestGetDownwardAPIVolumeFiles(t *testing.T) {
+	readonlyVal := int32(0444)
+	allPermsVal := int32(0777)
+	pod := api.NewPod()
+	pod.Namespace = "default"
+	pod.Name = "default_testpod"
+	pod.Labels = map[string]string{
+		"app": "myapp",
+	}
+
+	tests := []struct {
+		name          string
+		vol           api.DownwardAPIVolumeSource
+		isErr         bool
+		expectedFiles map[string]packageFile
+	}{
+		{
+			name:          "no items",
+			vol:           api.DownwardAPIVolumeSource{},
+			isErr:         false,
+			expectedFiles: map[string]packageFile{},
+		},
+		{
+			name: "metadata files, default and item modes",
+			vol: api.DownwardAPIVolumeSource{
+				Items: []api.DownwardAPIVolumeFile{
+					{
+						Path: "podname",
+						FieldRef: &api.ObjectFieldSelector{
+							FieldPath: "metadata.name",
+						},
+						Mode: &allPermsVal,
+					},
+					{
+						Path: "labels",
+						FieldRef: &api.ObjectFieldSelector{
+							FieldPath: "metadata.labels",
+						},
+					},
+				},
+				DefaultMode: &readonlyVal,
+			},
+			isErr: false,
+			expectedFiles: map[string]packageFile{
+				"podname": packageFile{
+					data: []byte("testpod"),
+					mode: allPermsVal,
+				},
+				"labels": packageFile{
+					data: []byte(`app="myapp"`),
+					mode: readonlyVal,
+				},
+			},
+		},
+		{
+			name: "item without fieldRef is an error",
+			vol: api.DownwardAPIVolumeSource{
+				Items: []api.DownwardAPIVolumeFile{
+					{
+						Path: "limits",
+					},
+				},
+			},
+			isErr: true,
+		},
+		{
+			name: "unsupported fieldPath is an error",
+			vol: api.DownwardAPIVolumeSource{
+				Items: []api.DownwardAPIVolumeFile{
+					{
+						Path: "podip",
+						FieldRef: &api.ObjectFieldSelector{
+							FieldPath: "status.podIP",
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for _, tc := range tests {
+		files, err := getDownwardAPIVolumeFiles(pod, &tc.vol)
+		if tc.isErr {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedFiles, files, tc.name)
+		}
+	}
+}
+
 func TestDeployVolumes(t *testing.T) {
 	trueVal := true
 	pod := api.GetFakePod()
@@ -303,7 +394,7 @@ func TestDeployVolumes(t *testing.T) {
 			return nil
 		}
 		pod.Spec.Volumes = tc.volumes
-		err = deployPodVolumes(pod, testNode, rm, nc)
+		err = deployPodVolumes(pod, testNode, rm, nil, nc)
 		if tc.isErr {
 			assert.Error(t, err, tc.name)
 		} else {
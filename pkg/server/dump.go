@@ -18,6 +18,7 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"runtime/pprof"
 
@@ -73,6 +74,8 @@ func (s InstanceProvider) Dump(context context.Context, request *clientapi.DumpR
 	var err error
 	if kind == "stack" {
 		b, err = dumpStack()
+	} else if kind == "audit" {
+		b, err = json.MarshalIndent(s.auditTrail.Entries(""), "", "    ")
 	} else if kind == "all" {
 		b, err = dumpStack()
 		if err == nil {
@@ -17,23 +17,40 @@ limitations under the License.
 package events
 
 const (
-	AllEvents       = "all-events"
-	NodeCleaning    = "node-cleaning"
-	NodeCreated     = "node-created"
-	NodePurged      = "node-purged"
-	NodeRunning     = "node-running"
-	PodCreated      = "pod-created"
-	PodEjected      = "pod-ejected" // We found a lost node with a bound pod
-	PodRunning      = "pod-running"
-	PodShouldDelete = "pod-should-delete"
-	PodTerminated   = "pod-terminated"
-	PodUpdated      = "pod-updated"
-	SecretCreated   = "secret-created"
-	SecretDeleted   = "secret-deleted"
-	SecretUpdated   = "secret-updated"
-	ServiceCreated  = "service-created"
-	ServiceDeleted  = "service-deleted"
-	ServiceUpdated  = "service-updated"
-	StartSpotFailed = "start-spot-failed"
-	UsageCreated    = "usage-created"
+	AllEvents             = "all-events"
+	BudgetExceeded        = "budget-exceeded"
+	DataVolumeAttached    = "data-volume-attached"
+	DataVolumeDetached    = "data-volume-detached"
+	NodeBootTimeout       = "node-boot-timeout"
+	NodeCleaning          = "node-cleaning"
+	NodeCloudInitFailed   = "node-cloudinit-failed"
+	NodeCreated           = "node-created"
+	NodeLifetimeExceeded  = "node-lifetime-exceeded"
+	NodePurged            = "node-purged"
+	NodeRunning           = "node-running"
+	NodeVersionMismatch   = "node-version-mismatch"
+	PodCreated            = "pod-created"
+	PodDeadlineExceeded   = "pod-deadline-exceeded"
+	PodEjected            = "pod-ejected" // We found a lost node with a bound pod
+	PodInstanceBlocked    = "pod-instance-blocked"
+	PodMigrated           = "pod-migrated"
+	PodOverQuota          = "pod-over-quota"
+	PodRetryLimitExceeded = "pod-retry-limit-exceeded"
+	PodRunning            = "pod-running"
+	PodRuntimeUnsupported = "pod-runtime-unsupported"
+	PodSchedulingTimeout  = "pod-scheduling-timeout"
+	PodShouldDelete       = "pod-should-delete"
+	PodSpotFallback       = "pod-spot-fallback"
+	PodSpotInterrupted    = "pod-spot-interrupted"
+	PodSpreadUnsatisfied  = "pod-spread-unsatisfied"
+	PodTerminated         = "pod-terminated"
+	PodUpdated            = "pod-updated"
+	SecretCreated         = "secret-created"
+	SecretDeleted         = "secret-deleted"
+	SecretUpdated         = "secret-updated"
+	ServiceCreated        = "service-created"
+	ServiceDeleted        = "service-deleted"
+	ServiceUpdated        = "service-updated"
+	StartSpotFailed       = "start-spot-failed"
+	UsageCreated          = "usage-created"
 )
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/elotl/kip/pkg/util/instanceselector"
+	"golang.org/x/net/context"
+)
+
+// Inventory is the document served by the Export API ("kipctl
+// export"): a point in time snapshot of every pod and cell this
+// controller knows about, with the cloud metadata (instance IDs,
+// types, prices) that CMDB and cost tools need.  The schema is
+// stable: fields are only added, never renamed or repurposed, so
+// external tooling can depend on it.
+type Inventory struct {
+	// Timestamp is when the snapshot was taken, in RFC 3339.
+	Timestamp string `json:"timestamp"`
+	// ControllerID identifies the kip controller that produced this
+	// snapshot.
+	ControllerID string          `json:"controllerID"`
+	Cloud        InventoryCloud  `json:"cloud"`
+	Pods         []InventoryPod  `json:"pods"`
+	Nodes        []InventoryNode `json:"nodes"`
+}
+
+// InventoryCloud describes where this controller's cells run.
+type InventoryCloud struct {
+	Provider string `json:"provider"`
+	Region   string `json:"region"`
+}
+
+// InventoryPod is one pod in the inventory.
+type InventoryPod struct {
+	Name string `json:"name"`
+	// Phase is the pod's lifecycle phase: "Waiting", "Dispatching",
+	// "Running", "Succeeded", "Failed" or "Terminated".
+	Phase string `json:"phase"`
+	// NodeName is the name of the cell running the pod, empty if the
+	// pod isn't dispatched.
+	NodeName string `json:"nodeName,omitempty"`
+	// InstanceID is the cloud instance ID of that cell.
+	InstanceID   string            `json:"instanceID,omitempty"`
+	InstanceType string            `json:"instanceType,omitempty"`
+	SpotPolicy   string            `json:"spotPolicy,omitempty"`
+	Created      string            `json:"created"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// InventoryNode is one cell in the inventory.
+type InventoryNode struct {
+	Name string `json:"name"`
+	// Phase is the cell's lifecycle phase: "Creating", "Created",
+	// "Available", "Claimed", "Cleaning", "Terminating" or
+	// "Terminated".
+	Phase            string `json:"phase"`
+	InstanceID       string `json:"instanceID,omitempty"`
+	InstanceType     string `json:"instanceType"`
+	BootImage        string `json:"bootImage"`
+	Spot             bool   `json:"spot"`
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	// PodName is the name of the pod bound to this cell, if any.
+	PodName  string `json:"podName,omitempty"`
+	Launched string `json:"launched,omitempty"`
+	// HourlyPriceUSD is the on-demand price of the cell's instance
+	// type, omitted when no pricing data is available.  Spot cells
+	// typically cost less than this.
+	HourlyPriceUSD *float32 `json:"hourlyPriceUSD,omitempty"`
+}
+
+func buildInventory(controllerID string, attrs cloud.CloudAttributes, pods []*api.Pod, nodes []*api.Node) Inventory {
+	inv := Inventory{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		ControllerID: controllerID,
+		Cloud: InventoryCloud{
+			Provider: attrs.Provider,
+			Region:   attrs.Region,
+		},
+		Pods:  make([]InventoryPod, 0, len(pods)),
+		Nodes: make([]InventoryNode, 0, len(nodes)),
+	}
+	for _, pod := range pods {
+		inv.Pods = append(inv.Pods, InventoryPod{
+			Name:         pod.Name,
+			Phase:        string(pod.Status.Phase),
+			NodeName:     pod.Status.BoundNodeName,
+			InstanceID:   pod.Status.BoundInstanceID,
+			InstanceType: pod.Spec.InstanceType,
+			SpotPolicy:   string(pod.Spec.Spot.Policy),
+			Created:      pod.CreationTimestamp.UTC().Format(time.RFC3339),
+			Labels:       pod.Labels,
+		})
+	}
+	for _, node := range nodes {
+		n := InventoryNode{
+			Name:             node.Name,
+			Phase:            string(node.Status.Phase),
+			InstanceID:       node.Status.InstanceID,
+			InstanceType:     node.Spec.InstanceType,
+			BootImage:        node.Spec.BootImage,
+			Spot:             node.Spec.Spot,
+			AvailabilityZone: node.Spec.Placement.AvailabilityZone,
+			PodName:          node.Status.BoundPodName,
+		}
+		if !node.Status.LaunchedAt.IsZero() {
+			n.Launched = node.Status.LaunchedAt.UTC().Format(time.RFC3339)
+		}
+		if price, ok := instanceselector.InstancePrice(node.Spec.InstanceType); ok {
+			n.HourlyPriceUSD = &price
+		}
+		inv.Nodes = append(inv.Nodes, n)
+	}
+	return inv
+}
+
+// Export dumps the full pod and cell inventory as structured JSON.
+// Unlike Dump, which returns each controller's free-form debugging
+// output, Export serves the documented Inventory schema meant for
+// external tooling.
+func (s InstanceProvider) Export(context context.Context, request *clientapi.ExportRequest) (*clientapi.APIReply, error) {
+	podRegistry := s.getPodRegistry()
+	nodeRegistry := s.getNodeRegistry()
+	podList, err := podRegistry.ListPods(registry.MatchAllPods)
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error listing pods for export")), nil
+	}
+	nodeList, err := nodeRegistry.ListNodes(registry.MatchAllNodes)
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error listing nodes for export")), nil
+	}
+	inv := buildInventory(
+		s.controllerID, s.cloudClient.GetAttributes(), podList.Items, nodeList.Items)
+	body, err := json.MarshalIndent(inv, "", "    ")
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error serializing reply object")), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   body,
+	}
+	return &reply, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInventory(t *testing.T) {
+	pod := api.GetFakePod()
+	pod.Status.Phase = api.PodRunning
+	pod.Status.BoundNodeName = "node-1"
+	pod.Status.BoundInstanceID = "i-123"
+	node := api.GetFakeNode()
+	node.Name = "node-1"
+	node.Spec.InstanceType = "t3.nano"
+	node.Spec.BootImage = "ami-123"
+	node.Spec.Placement.AvailabilityZone = "us-east-1a"
+	node.Status.Phase = api.NodeClaimed
+	node.Status.InstanceID = "i-123"
+	node.Status.BoundPodName = pod.Name
+	node.Status.LaunchedAt = api.Now()
+	attrs := cloud.CloudAttributes{Provider: "aws", Region: "us-east-1"}
+
+	inv := buildInventory("test-controller", attrs,
+		[]*api.Pod{pod}, []*api.Node{node})
+	assert.Equal(t, "test-controller", inv.ControllerID)
+	assert.Equal(t, "aws", inv.Cloud.Provider)
+	assert.Equal(t, "us-east-1", inv.Cloud.Region)
+	assert.NotEmpty(t, inv.Timestamp)
+	assert.Len(t, inv.Pods, 1)
+	assert.Equal(t, pod.Name, inv.Pods[0].Name)
+	assert.Equal(t, "Running", inv.Pods[0].Phase)
+	assert.Equal(t, "node-1", inv.Pods[0].NodeName)
+	assert.Equal(t, "i-123", inv.Pods[0].InstanceID)
+	assert.Len(t, inv.Nodes, 1)
+	assert.Equal(t, "node-1", inv.Nodes[0].Name)
+	assert.Equal(t, "Claimed", inv.Nodes[0].Phase)
+	assert.Equal(t, "t3.nano", inv.Nodes[0].InstanceType)
+	assert.Equal(t, "ami-123", inv.Nodes[0].BootImage)
+	assert.Equal(t, "us-east-1a", inv.Nodes[0].AvailabilityZone)
+	assert.Equal(t, pod.Name, inv.Nodes[0].PodName)
+	assert.NotEmpty(t, inv.Nodes[0].Launched)
+}
@@ -19,7 +19,9 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/elotl/kip/pkg/api"
@@ -38,29 +40,77 @@ func init() {
 	lastUnknownInstances = make(map[string]bool)
 }
 
+const defaultReconcileInterval = 300 * time.Second
+
 type GarbageControllerConfig struct {
 	CleanTerminatedInterval time.Duration
 	CleanInstancesInterval  time.Duration
+	// ReclaimOrphanedInstances enables terminating cloud instances
+	// that carry our ownership tags but have no matching pod or node
+	// in the registry.  This recovers instances leaked by split-brain
+	// or etcd data loss.
+	ReclaimOrphanedInstances bool
+	// OrphanGracePeriod is how long a tagged instance must remain
+	// unknown to the registry before we will terminate it.  This
+	// prevents reclaiming instances that have just been launched but
+	// haven't made it into the registry yet.
+	OrphanGracePeriod time.Duration
+	// ReconcileInterval is how often the reconciler compares the
+	// registries against running cloud instances and corrects drift.
+	// Zero falls back to the package default.
+	ReconcileInterval time.Duration
+	// LogRetentionAge is how long saved pod logs are kept before being
+	// pruned.  Zero falls back to the log registry default.
+	LogRetentionAge time.Duration
+	// LogRetentionSize is the maximum total size in bytes of saved pod
+	// logs; once exceeded the oldest logs are pruned first.  Zero
+	// means no size limit.
+	LogRetentionSize int64
 }
 
 type GarbageController struct {
 	config                  GarbageControllerConfig
 	podRegistry             *registry.PodRegistry
 	nodeRegistry            *registry.NodeRegistry
+	logRegistry             *registry.LogRegistry
 	cloudClient             cloud.CloudClient
 	controllerID            string
 	timer                   stats.LoopTimer
+	logsPruned              int64
+	logBytesPruned          int64
 	lastOrphanedAzureGroups sets.String
 	lastOldTaskDefs         sets.String
+	orphanFirstSeen         map[string]time.Time
+	lastDriftedPods         map[string]string
+	lastStrandedNodes       map[string]string
 }
 
 func (c *GarbageController) Start(quit <-chan struct{}, wg *sync.WaitGroup) {
 	c.lastOrphanedAzureGroups = sets.NewString()
+	c.orphanFirstSeen = make(map[string]time.Time)
+	c.lastDriftedPods = make(map[string]string)
+	c.lastStrandedNodes = make(map[string]string)
 	go c.GCLoop(quit, wg)
 }
 
+func (c *GarbageController) reconcileInterval() time.Duration {
+	if c.config.ReconcileInterval > 0 {
+		return c.config.ReconcileInterval
+	}
+	return defaultReconcileInterval
+}
+
 func (c *GarbageController) Dump() []byte {
-	b, err := json.MarshalIndent(c.timer, "", "    ")
+	dumpStruct := struct {
+		Timer          *stats.LoopTimer `json:"timer"`
+		LogsPruned     int64            `json:"logsPruned"`
+		LogBytesPruned int64            `json:"logBytesPruned"`
+	}{
+		Timer:          c.timer.Copy(),
+		LogsPruned:     atomic.LoadInt64(&c.logsPruned),
+		LogBytesPruned: atomic.LoadInt64(&c.logBytesPruned),
+	}
+	b, err := json.MarshalIndent(dumpStruct, "", "    ")
 	if err != nil {
 		klog.Errorln("Error dumping data from GarbageController", err)
 		return nil
@@ -74,9 +124,13 @@ func (c *GarbageController) GCLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 	cleanTermiantedTicker := time.NewTicker(c.config.CleanTerminatedInterval)
 	instancesTicker := time.NewTicker(c.config.CleanInstancesInterval)
 	cleanResourceGroupsTicker := time.NewTicker(3 * time.Minute)
+	reconcileTicker := time.NewTicker(c.reconcileInterval())
+	cleanLogsTicker := time.NewTicker(5 * time.Minute)
 	defer cleanTermiantedTicker.Stop()
 	defer instancesTicker.Stop()
 	defer cleanResourceGroupsTicker.Stop()
+	defer reconcileTicker.Stop()
+	defer cleanLogsTicker.Stop()
 	for {
 		// The garbage controller takes a while to stop if we
 		// are timing out talking to etcd, lets give quit priority
@@ -91,11 +145,18 @@ func (c *GarbageController) GCLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 			c.timer.StartLoop()
 			c.CleanInstances()
 			c.CleanDanglingRoutes()
+			if c.config.ReclaimOrphanedInstances {
+				c.ReclaimOrphans()
+			}
 			c.timer.EndLoop()
 		case <-cleanTermiantedTicker.C:
 			c.CleanTerminatedNodes()
 		case <-cleanResourceGroupsTicker.C:
 			c.CleanAzureResourceGroups()
+		case <-reconcileTicker.C:
+			c.Reconcile()
+		case <-cleanLogsTicker.C:
+			c.CleanLogs()
 		case <-quit:
 			klog.V(2).Info("Stopping GarbageController")
 			return
@@ -172,8 +233,289 @@ func (c *GarbageController) CleanInstances() {
 	lastUnknownInstances = unknownInstances
 }
 
+// CleanLogs prunes saved pod logs that have outlived the retention age
+// and, when a total size limit is configured, the oldest logs until
+// the rest fit.  The registry writes logs with an etcd TTL as a
+// backstop; pruning here keeps the limits enforced and observable even
+// when logs were written with a longer TTL.
+func (c *GarbageController) CleanLogs() {
+	if c.logRegistry == nil {
+		return
+	}
+	loglist, err := c.logRegistry.ListLogs("", "")
+	if err != nil {
+		klog.Errorf("Error listing logs for pruning: %s", err.Error())
+		return
+	}
+	retentionAge := c.config.LogRetentionAge
+	if retentionAge <= 0 {
+		retentionAge = registry.DefaultLogTTL
+	}
+	prunable := findPrunableLogs(
+		loglist.Items, retentionAge, c.config.LogRetentionSize, time.Now())
+	for _, log := range prunable {
+		err := c.logRegistry.DeleteLog(log.ParentObject.Name, log.Name)
+		if err != nil {
+			klog.Errorf("Error pruning log %s/%s: %v",
+				log.ParentObject.Name, log.Name, err)
+			continue
+		}
+		atomic.AddInt64(&c.logsPruned, 1)
+		atomic.AddInt64(&c.logBytesPruned, int64(len(log.Content)))
+	}
+}
+
+// findPrunableLogs returns the logs that have outlived the retention
+// age plus, when the remaining logs exceed the total size limit, the
+// oldest of them until the rest fit.  A sizeLimit of zero means no
+// size limit.
+func findPrunableLogs(logs []*api.LogFile, retentionAge time.Duration, sizeLimit int64, now time.Time) []*api.LogFile {
+	sorted := make([]*api.LogFile, len(logs))
+	copy(sorted, logs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreationTimestamp.Time.Before(
+			sorted[j].CreationTimestamp.Time)
+	})
+	var totalSize int64
+	for _, log := range sorted {
+		totalSize += int64(len(log.Content))
+	}
+	prunable := make([]*api.LogFile, 0)
+	for _, log := range sorted {
+		expired := now.Sub(log.CreationTimestamp.Time) >= retentionAge
+		overSize := sizeLimit > 0 && totalSize > sizeLimit
+		if !expired && !overSize {
+			continue
+		}
+		prunable = append(prunable, log)
+		totalSize -= int64(len(log.Content))
+	}
+	return prunable
+}
+
+// ReclaimOrphans lists cloud instances by our ownership tags and
+// terminates any that have no corresponding node or pod in the
+// registry.  Unlike CleanInstances, which requires an instance to
+// show up as unknown in two consecutive loops, this uses a wall-clock
+// grace period so instances orphaned by etcd data loss are cleaned up
+// even if the controller restarts between loops.
+func (c *GarbageController) ReclaimOrphans() {
+	instances, err := c.cloudClient.ListInstances()
+	if err != nil {
+		klog.Errorf("Error listing tagged cloud instances for reclamation: %s", err.Error())
+		return
+	}
+	nodes, err := c.nodeRegistry.ListNodes(registry.MatchAllNodes)
+	if err != nil {
+		klog.Errorf("Error listing nodes for instance reclamation: %s", err.Error())
+		return
+	}
+	pods, err := c.podRegistry.ListPods(registry.MatchAllPods)
+	if err != nil {
+		klog.Errorf("Error listing pods for instance reclamation: %s", err.Error())
+		return
+	}
+	knownNodeNames := sets.NewString()
+	knownInstanceIDs := sets.NewString()
+	for _, node := range nodes.Items {
+		knownNodeNames.Insert(node.Name)
+		if node.Status.InstanceID != "" {
+			knownInstanceIDs.Insert(node.Status.InstanceID)
+		}
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.BoundNodeName != "" {
+			knownNodeNames.Insert(pod.Status.BoundNodeName)
+		}
+		if pod.Status.BoundInstanceID != "" {
+			knownInstanceIDs.Insert(pod.Status.BoundInstanceID)
+		}
+	}
+	now := time.Now()
+	orphans := c.findReclaimableOrphans(instances, knownNodeNames, knownInstanceIDs, now)
+	for _, iid := range orphans {
+		instanceID := iid
+		klog.Errorf("Reclaiming orphaned cloud instance %s", instanceID)
+		go func() {
+			err := c.cloudClient.StopInstance(instanceID)
+			if err != nil {
+				klog.Error(err)
+			}
+		}()
+	}
+}
+
+// findReclaimableOrphans updates the first-seen times of orphaned
+// instances and returns the IDs of those that have been orphaned for
+// longer than the grace period.
+func (c *GarbageController) findReclaimableOrphans(instances []cloud.CloudInstance, knownNodeNames, knownInstanceIDs sets.String, now time.Time) []string {
+	if c.orphanFirstSeen == nil {
+		c.orphanFirstSeen = make(map[string]time.Time)
+	}
+	orphanIDs := sets.NewString()
+	for _, inst := range instances {
+		if knownNodeNames.Has(inst.NodeName) || knownInstanceIDs.Has(inst.ID) {
+			continue
+		}
+		orphanIDs.Insert(inst.ID)
+		if _, seen := c.orphanFirstSeen[inst.ID]; !seen {
+			c.orphanFirstSeen[inst.ID] = now
+		}
+	}
+	for iid := range c.orphanFirstSeen {
+		if !orphanIDs.Has(iid) {
+			delete(c.orphanFirstSeen, iid)
+		}
+	}
+	reclaimable := make([]string, 0, orphanIDs.Len())
+	for _, iid := range orphanIDs.List() {
+		if now.Sub(c.orphanFirstSeen[iid]) >= c.config.OrphanGracePeriod {
+			reclaimable = append(reclaimable, iid)
+		}
+	}
+	return reclaimable
+}
+
+// Reconcile compares the desired state in the pod and node registries
+// against the instances actually running in the cloud and corrects
+// drift the event-driven controllers can miss.  Running pods whose
+// bound node is gone from the registry, or whose backing cloud
+// instance no longer exists, are marked failed so the pod controller
+// relaunches them.  Claimed nodes whose bound pod has been deleted
+// from the registry are marked for termination so the node controller
+// reaps their instances.  Unit-level drift on a healthy cell is
+// handled by the pod controller's status loop.  Like the other
+// cleanup loops, drift must be observed in two consecutive passes
+// before we act on it.
+func (c *GarbageController) Reconcile() {
+	instances, err := c.cloudClient.ListInstances()
+	if err != nil {
+		klog.Errorf("Error listing cloud instances for reconciliation: %s", err.Error())
+		return
+	}
+	nodes, err := c.nodeRegistry.ListNodes(registry.MatchAllNodes)
+	if err != nil {
+		klog.Errorf("Error listing nodes for reconciliation: %s", err.Error())
+		return
+	}
+	pods, err := c.podRegistry.ListPods(registry.MatchAllPods)
+	if err != nil {
+		klog.Errorf("Error listing pods for reconciliation: %s", err.Error())
+		return
+	}
+	cloudInstanceIDs := sets.NewString()
+	for _, inst := range instances {
+		cloudInstanceIDs.Insert(inst.ID)
+	}
+	nodesByName := make(map[string]*api.Node)
+	for i := range nodes.Items {
+		nodesByName[nodes.Items[i].Name] = nodes.Items[i]
+	}
+	podNames := sets.NewString()
+	for i := range pods.Items {
+		podNames.Insert(pods.Items[i].Name)
+	}
+
+	driftedPods := findDriftedPods(pods, nodesByName, cloudInstanceIDs)
+	for podName, msg := range c.lastDriftedPods {
+		if driftedPods[podName] != msg {
+			continue
+		}
+		klog.Errorf("Reconciler failing pod %s: %s", podName, msg)
+		c.failDriftedPod(podName, msg)
+	}
+	c.lastDriftedPods = driftedPods
+
+	strandedNodes := findStrandedNodes(nodes, podNames)
+	for nodeName, podName := range c.lastStrandedNodes {
+		if strandedNodes[nodeName] != podName {
+			continue
+		}
+		klog.Errorf("Reconciler terminating node %s claimed by deleted pod %s",
+			nodeName, podName)
+		c.terminateStrandedNode(nodeName, podName)
+	}
+	c.lastStrandedNodes = strandedNodes
+}
+
+// findDriftedPods returns a map of running pod names to a description
+// of why the pod can no longer be running: its bound node has
+// disappeared from the registry or the node's backing cloud instance
+// no longer exists.
+func findDriftedPods(pods *api.PodList, nodesByName map[string]*api.Node, cloudInstanceIDs sets.String) map[string]string {
+	drifted := make(map[string]string)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != api.PodRunning ||
+			pod.Status.BoundNodeName == "" {
+			continue
+		}
+		node, exists := nodesByName[pod.Status.BoundNodeName]
+		if !exists {
+			drifted[pod.Name] = fmt.Sprintf(
+				"bound node %s is gone from the registry",
+				pod.Status.BoundNodeName)
+			continue
+		}
+		if node.Status.InstanceID != "" &&
+			!cloudInstanceIDs.Has(node.Status.InstanceID) {
+			drifted[pod.Name] = fmt.Sprintf(
+				"cloud instance %s backing node %s no longer exists",
+				node.Status.InstanceID, node.Name)
+		}
+	}
+	return drifted
+}
+
+// findStrandedNodes returns a map of claimed node names to the name
+// of the bound pod that no longer exists in the pod registry.
+func findStrandedNodes(nodes *api.NodeList, podNames sets.String) map[string]string {
+	stranded := make(map[string]string)
+	for _, node := range nodes.Items {
+		if node.Status.Phase != api.NodeClaimed ||
+			node.Status.BoundPodName == "" {
+			continue
+		}
+		if !podNames.Has(node.Status.BoundPodName) {
+			stranded[node.Name] = node.Status.BoundPodName
+		}
+	}
+	return stranded
+}
+
+func (c *GarbageController) failDriftedPod(podName, msg string) {
+	pod, err := c.podRegistry.GetPod(podName)
+	if err != nil {
+		klog.Errorf("Error getting drifted pod %s from registry: %v", podName, err)
+		return
+	}
+	if pod.Status.Phase != api.PodRunning {
+		return
+	}
+	pod.Status.Phase = api.PodFailed
+	_, err = c.podRegistry.UpdatePodStatus(pod, msg)
+	if err != nil {
+		klog.Errorf("Error updating drifted pod %s status: %v", podName, err)
+	}
+}
+
+func (c *GarbageController) terminateStrandedNode(nodeName, podName string) {
+	node, err := c.nodeRegistry.GetNode(nodeName)
+	if err != nil {
+		klog.Errorf("Error getting stranded node %s from registry: %v", nodeName, err)
+		return
+	}
+	if node.Status.Phase != api.NodeClaimed ||
+		node.Status.BoundPodName != podName {
+		return
+	}
+	_, err = c.nodeRegistry.MarkForTermination(node)
+	if err != nil {
+		klog.Errorf("Error marking stranded node %s for termination: %v", nodeName, err)
+	}
+}
+
 func (c *GarbageController) CleanAzureResourceGroups() {
-	az, ok := c.cloudClient.(*azure.AzureClient)
+	az, ok := cloud.Unwrap(c.cloudClient).(*azure.AzureClient)
 	if !ok {
 		return
 	}
@@ -184,7 +526,7 @@ func (c *GarbageController) CleanAzureResourceGroups() {
 }
 
 func (c *GarbageController) cleanFargateTaskDefs() {
-	client, ok := c.cloudClient.(*aws.AwsEC2)
+	client, ok := cloud.Unwrap(c.cloudClient).(*aws.AwsEC2)
 	if !ok {
 		return
 	}
@@ -19,8 +19,10 @@ package server
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -55,6 +57,156 @@ func (r *MockResourcer) DeleteResourceGroup(name string) error {
 	return fmt.Errorf("Could not find resource")
 }
 
+func TestFindReclaimableOrphans(t *testing.T) {
+	ctl, closer := createGarbageController()
+	defer closer()
+	ctl.config.OrphanGracePeriod = 5 * time.Minute
+	instances := []cloud.CloudInstance{
+		{ID: "i-known", NodeName: "known-node"},
+		{ID: "i-bound", NodeName: "gone-node"},
+		{ID: "i-orphan", NodeName: "orphan-node"},
+	}
+	knownNodes := sets.NewString("known-node")
+	knownInstances := sets.NewString("i-bound")
+
+	// The first pass only records when the orphan was first seen
+	now := time.Now()
+	orphans := ctl.findReclaimableOrphans(instances, knownNodes, knownInstances, now)
+	assert.Len(t, orphans, 0)
+	assert.Len(t, ctl.orphanFirstSeen, 1)
+
+	// Within the grace period nothing is reclaimed
+	orphans = ctl.findReclaimableOrphans(instances, knownNodes, knownInstances, now.Add(1*time.Minute))
+	assert.Len(t, orphans, 0)
+
+	// After the grace period the orphan is reclaimable
+	orphans = ctl.findReclaimableOrphans(instances, knownNodes, knownInstances, now.Add(6*time.Minute))
+	assert.Equal(t, []string{"i-orphan"}, orphans)
+
+	// If the instance becomes known, its first-seen entry is dropped
+	knownNodes.Insert("orphan-node")
+	orphans = ctl.findReclaimableOrphans(instances, knownNodes, knownInstances, now.Add(7*time.Minute))
+	assert.Len(t, orphans, 0)
+	assert.Len(t, ctl.orphanFirstSeen, 0)
+}
+
+func TestFindDriftedPods(t *testing.T) {
+	makePod := func(name, nodeName string, phase api.PodPhase) *api.Pod {
+		p := api.GetFakePod()
+		p.Name = name
+		p.Status.Phase = phase
+		p.Status.BoundNodeName = nodeName
+		return p
+	}
+	healthyNode := api.GetFakeNode()
+	healthyNode.Name = "healthy-node"
+	healthyNode.Status.InstanceID = "i-healthy"
+	deadInstanceNode := api.GetFakeNode()
+	deadInstanceNode.Name = "dead-instance-node"
+	deadInstanceNode.Status.InstanceID = "i-dead"
+	nodesByName := map[string]*api.Node{
+		healthyNode.Name:      healthyNode,
+		deadInstanceNode.Name: deadInstanceNode,
+	}
+	cloudInstanceIDs := sets.NewString("i-healthy")
+	pods := &api.PodList{
+		Items: []*api.Pod{
+			makePod("healthy-pod", "healthy-node", api.PodRunning),
+			makePod("no-node-pod", "gone-node", api.PodRunning),
+			makePod("no-instance-pod", "dead-instance-node", api.PodRunning),
+			makePod("waiting-pod", "gone-node", api.PodWaiting),
+			makePod("unbound-pod", "", api.PodRunning),
+		},
+	}
+	drifted := findDriftedPods(pods, nodesByName, cloudInstanceIDs)
+	assert.Len(t, drifted, 2)
+	assert.Contains(t, drifted, "no-node-pod")
+	assert.Contains(t, drifted, "no-instance-pod")
+}
+
+func TestFindStrandedNodes(t *testing.T) {
+	makeNode := func(name, podName string, phase api.NodePhase) *api.Node {
+		n := api.GetFakeNode()
+		n.Name = name
+		n.Status.Phase = phase
+		n.Status.BoundPodName = podName
+		return n
+	}
+	nodes := &api.NodeList{
+		Items: []*api.Node{
+			makeNode("bound-node", "live-pod", api.NodeClaimed),
+			makeNode("stranded-node", "deleted-pod", api.NodeClaimed),
+			makeNode("available-node", "", api.NodeAvailable),
+			makeNode("terminating-node", "deleted-pod", api.NodeTerminating),
+		},
+	}
+	podNames := sets.NewString("live-pod")
+	stranded := findStrandedNodes(nodes, podNames)
+	assert.Equal(t, map[string]string{"stranded-node": "deleted-pod"}, stranded)
+}
+
+func TestFindPrunableLogs(t *testing.T) {
+	now := time.Now()
+	makeLog := func(name string, age time.Duration, content string) *api.LogFile {
+		log := api.NewLogFile()
+		log.Name = name
+		log.ParentObject.Name = "pod"
+		log.CreationTimestamp = api.Time{Time: now.Add(-age)}
+		log.Content = content
+		return log
+	}
+	logs := []*api.LogFile{
+		makeLog("fresh", time.Minute, "abcd"),
+		makeLog("old", 2*time.Hour, "efgh"),
+		makeLog("stale", 30*time.Minute, "ijkl"),
+	}
+	// Age-based pruning only.
+	prunable := findPrunableLogs(logs, time.Hour, 0, now)
+	assert.Len(t, prunable, 1)
+	assert.Equal(t, "old", prunable[0].Name)
+	// The size limit prunes the oldest logs first until the rest fit.
+	prunable = findPrunableLogs(logs, time.Hour, 6, now)
+	assert.Len(t, prunable, 2)
+	assert.Equal(t, "old", prunable[0].Name)
+	assert.Equal(t, "stale", prunable[1].Name)
+	// Everything fits and nothing has expired.
+	prunable = findPrunableLogs(logs, 3*time.Hour, 100, now)
+	assert.Len(t, prunable, 0)
+}
+
+func TestReconcileFailsDriftedPod(t *testing.T) {
+	ctl, closer := createGarbageController()
+	defer closer()
+	ctl.cloudClient = cloud.NewMockClient()
+	ctl.lastDriftedPods = make(map[string]string)
+	ctl.lastStrandedNodes = make(map[string]string)
+
+	pod := api.GetFakePod()
+	pod.Status.Phase = api.PodRunning
+	pod.Status.BoundNodeName = "gone-node"
+	pod, err := ctl.podRegistry.CreatePod(pod)
+	assert.NoError(t, err)
+	// Walk the pod through the valid phase changes to Running
+	pod.Status.Phase = api.PodDispatching
+	pod.Status.BoundNodeName = "gone-node"
+	pod, err = ctl.podRegistry.UpdatePodStatus(pod, "")
+	assert.NoError(t, err)
+	pod.Status.Phase = api.PodRunning
+	_, err = ctl.podRegistry.UpdatePodStatus(pod, "")
+	assert.NoError(t, err)
+
+	// First pass only records the drift, second pass acts on it
+	ctl.Reconcile()
+	p, err := ctl.podRegistry.GetPod(pod.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, api.PodRunning, p.Status.Phase)
+	assert.Len(t, ctl.lastDriftedPods, 1)
+	ctl.Reconcile()
+	p, err = ctl.podRegistry.GetPod(pod.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, api.PodFailed, p.Status.Phase)
+}
+
 func TestCleanAzureResourceGroupsHelper(t *testing.T) {
 	ctl, closer := createGarbageController()
 	defer closer()
@@ -22,6 +22,8 @@ import (
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/labels"
+	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 )
 
@@ -38,9 +40,17 @@ func (s InstanceProvider) Get(context context.Context, request *clientapi.GetReq
 			fmt.Errorf("Asked to get unknown object kind: %s", kind)), nil
 	}
 	var replyObj api.MilpaObject
+	var continueToken string
 	var err error
 	if name == "" {
-		replyObj, err = store.List()
+		opts, err := parseListOptions(request)
+		if err != nil {
+			return errToAPIReply(err), nil
+		}
+		replyObj, continueToken, err = s.listWithOptions(kind, store, opts)
+		if err != nil {
+			return errToAPIReply(util.WrapError(err, "Error getting resource")), nil
+		}
 	} else {
 		replyObj, err = store.Get(name)
 	}
@@ -53,8 +63,55 @@ func (s InstanceProvider) Get(context context.Context, request *clientapi.GetReq
 		return errToAPIReply(util.WrapError(err, "Error serializing reply object")), nil
 	}
 	reply := clientapi.APIReply{
-		Status: 200,
-		Body:   body,
+		Status:   200,
+		Body:     body,
+		Continue: []byte(continueToken),
 	}
 	return &reply, nil
 }
+
+func parseListOptions(request *clientapi.GetRequest) (registry.ListOptions, error) {
+	opts := registry.ListOptions{
+		Limit:    int(request.Limit),
+		Continue: string(request.Continue),
+	}
+	if len(request.LabelSelector) > 0 {
+		selector, err := labels.Parse(string(request.LabelSelector))
+		if err != nil {
+			return opts, util.WrapError(err, "Error parsing label selector")
+		}
+		opts.LabelSelector = selector
+	}
+	fieldSelector, err := registry.ParseFieldSelector(string(request.FieldSelector))
+	if err != nil {
+		return opts, util.WrapError(err, "Error parsing field selector")
+	}
+	opts.FieldSelector = fieldSelector
+	return opts, nil
+}
+
+// listWithOptions applies selectors and pagination to pod and node
+// lists.  Other kinds don't grow large enough to bother paginating
+// (events are truncated by filterReplyObject).
+func (s InstanceProvider) listWithOptions(kind string, store registry.Registryer, opts registry.ListOptions) (api.MilpaObject, string, error) {
+	switch kind {
+	case "Pod":
+		podList, err := store.(*registry.PodRegistry).ListPods(opts.MatchPod)
+		if err != nil {
+			return nil, "", err
+		}
+		paged, continueToken := registry.PaginatePods(
+			podList, opts.Limit, opts.Continue)
+		return paged, continueToken, nil
+	case "Node":
+		nodeList, err := store.(*registry.NodeRegistry).ListNodes(opts.MatchNode)
+		if err != nil {
+			return nil, "", err
+		}
+		paged, continueToken := registry.PaginateNodes(
+			nodeList, opts.Limit, opts.Continue)
+		return paged, continueToken, nil
+	}
+	obj, err := store.List()
+	return obj, "", err
+}
@@ -19,11 +19,13 @@ package server
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/nodeclient"
+	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/wsstream"
 	vkapi "github.com/virtual-kubelet/virtual-kubelet/node/api"
@@ -38,6 +40,9 @@ func (p *InstanceProvider) GetContainerLogs(ctx context.Context, namespace, podN
 	klog.V(5).Infof("GetContainerLogs %+v", opts)
 	follow := opts.Follow
 	podName = util.WithNamespace(namespace, podName)
+	if opts.Previous {
+		return p.getPreviousContainerLogs(podName, containerName, opts)
+	}
 	node, err := p.GetNodeForRunningPod(podName, "")
 	if !follow || err != nil || node == nil || len(node.Status.Addresses) == 0 {
 		klog.V(5).Infof("pulling logs for pod %+v", opts)
@@ -48,9 +53,8 @@ func (p *InstanceProvider) GetContainerLogs(ctx context.Context, namespace, podN
 }
 
 func (p *InstanceProvider) getContainerLogs(podName, containerName string, opts vkapi.ContainerLogOpts) (io.ReadCloser, error) {
-	lines := opts.Tail
-	limit := opts.LimitBytes
-	foundLog, err := p.findLog(podName, containerName, lines, limit)
+	lr := nodeclient.TailRange(opts.Tail, opts.LimitBytes)
+	foundLog, err := p.findLog(podName, containerName, lr)
 	if err != nil {
 		klog.Errorf("finding logs for %q/%q: %v", podName, containerName, err)
 		return nil, util.WrapError(
@@ -60,6 +64,39 @@ func (p *InstanceProvider) getContainerLogs(podName, containerName string, opts
 	return buf, nil
 }
 
+// getPreviousContainerLogs serves "kubectl logs --previous": the
+// retained log of the container's last terminated instance.  The pod
+// controller snapshots those into the log registry when it notices a
+// unit's restart count go up, so this never talks to the cell.
+func (p *InstanceProvider) getPreviousContainerLogs(podName, containerName string, opts vkapi.ContainerLogOpts) (io.ReadCloser, error) {
+	podRegistry := p.getPodRegistry()
+	pod, err := podRegistry.GetPod(podName)
+	if err != nil {
+		return nil, util.WrapError(err, "could not get pod %q", podName)
+	}
+	restarts := int32(-1)
+	statuses := append(pod.Status.InitUnitStatuses, pod.Status.UnitStatuses...)
+	for _, us := range statuses {
+		if us.Name == containerName {
+			restarts = us.RestartCount
+			break
+		}
+	}
+	if restarts < 0 {
+		return nil, fmt.Errorf("no status found for container %q in pod %q", containerName, podName)
+	} else if restarts == 0 {
+		return nil, fmt.Errorf("container %q in pod %q has not been restarted, there is no previous instance", containerName, podName)
+	}
+	lr := nodeclient.TailRange(opts.Tail, opts.LimitBytes)
+	foundLog, err := p.getLogFromRegistry(
+		podName, registry.PreviousLogName(containerName, restarts-1), lr)
+	if err != nil {
+		return nil, util.WrapError(
+			err, "finding previous logs for %q/%q", podName, containerName)
+	}
+	return ioutil.NopCloser(bytes.NewReader([]byte(foundLog.Content))), nil
+}
+
 func (p *InstanceProvider) tailContainerLogs(node *api.Node, podName, containerName string, opts vkapi.ContainerLogOpts) (io.ReadCloser, error) {
 	withMetadata := opts.Timestamps
 	logsPath := nodeclient.StreamLogsEndpoint(containerName, withMetadata)
@@ -68,7 +105,7 @@ func (p *InstanceProvider) tailContainerLogs(node *api.Node, podName, containerN
 		return nil, util.WrapError(
 			err, "could not get logs client for pod %q", podName)
 	}
-	logs, err := p.findLog(podName, containerName, opts.Tail, opts.LimitBytes)
+	logs, err := p.findLog(podName, containerName, nodeclient.TailRange(opts.Tail, opts.LimitBytes))
 	if err != nil {
 		return nil, util.WrapError(
 			err, "finding logs for %q/%q", podName, containerName)
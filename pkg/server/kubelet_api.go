@@ -0,0 +1,83 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/elotl/kip/pkg/certs"
+	"github.com/elotl/kip/pkg/util"
+	vkapi "github.com/virtual-kubelet/virtual-kubelet/node/api"
+	"k8s.io/klog"
+)
+
+// ServeKubeletAPI serves the kubelet API (container logs, exec and
+// stats) over TLS so the k8s API server can reach the virtual node
+// for kubectl logs/exec and metrics. The serving certificate is
+// minted from the controller's internal CA, the same one used to
+// secure itzo connections. The server shuts down when quit is
+// closed.
+func (p *InstanceProvider) ServeKubeletAPI(port int, certFactory *certs.CertificateFactory, quit <-chan struct{}, wg *sync.WaitGroup) error {
+	serverCert, serverKey, err := certFactory.CreateNodeCertAndKey()
+	if err != nil {
+		return util.WrapError(err, "Error creating kubelet API server certificate")
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{
+			{
+				Certificate: [][]byte{serverCert.Raw},
+				PrivateKey:  serverKey,
+			},
+		},
+		MinVersion: tls.VersionTLS12,
+	}
+	mux := http.NewServeMux()
+	podRoutes := vkapi.PodHandlerConfig{
+		RunInContainer:        p.RunInContainer,
+		GetContainerLogs:      p.GetContainerLogs,
+		GetPods:               p.GetPods,
+		GetPodsFromKubernetes: p.GetPods,
+	}
+	vkapi.AttachPodRoutes(podRoutes, mux, false)
+	mux.Handle("/stats/summary", vkapi.PodStatsSummaryHandler(p.GetStatsSummary))
+	mux.Handle("/stats/summary/", vkapi.PodStatsSummaryHandler(p.GetStatsSummary))
+	addr := fmt.Sprintf(":%d", port)
+	listener, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return util.WrapError(err, "Error listening on %s for the kubelet API", addr)
+	}
+	server := &http.Server{
+		Handler:   mux,
+		TLSConfig: tlsConfig,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		klog.V(2).Infof("serving kubelet API on %s", addr)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("kubelet API server error: %v", err)
+		}
+	}()
+	go func() {
+		<-quit
+		server.Close()
+	}()
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/server/nodemanager"
+	"github.com/elotl/kip/pkg/util"
+	"golang.org/x/net/context"
+	"k8s.io/klog"
+)
+
+func (s InstanceProvider) getNodeController() (*nodemanager.NodeController, error) {
+	ctl, exists := s.controllerManager.GetController("NodeController")
+	if !exists {
+		return nil, fmt.Errorf("Fatal error: can't find the node controller")
+	}
+	nodeController, ok := ctl.(*nodemanager.NodeController)
+	if !ok {
+		return nil, fmt.Errorf("Fatal error: NodeController has unexpected type %T", ctl)
+	}
+	return nodeController, nil
+}
+
+// GetLaunches lists the node launches that are currently in flight
+// (pod, node, instance type, AZ and elapsed time) as JSON so
+// operators can see pending scale-ups during incidents.
+func (s InstanceProvider) GetLaunches(context context.Context, request *clientapi.GetLaunchesRequest) (*clientapi.APIReply, error) {
+	if !s.controllerManager.ControllersRunning() {
+		return notTheLeaderReply(), nil
+	}
+	nodeController, err := s.getNodeController()
+	if err != nil {
+		return errToAPIReply(err), nil
+	}
+	launches := nodeController.ListInFlightLaunches()
+	body, err := json.MarshalIndent(launches, "", "    ")
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error serializing reply object")), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   body,
+	}
+	return &reply, nil
+}
+
+// CancelLaunch cancels the in-flight node launch for a pod.  The
+// launch goroutine terminates any partially created instance and the
+// pod goes back to waiting for a node.
+func (s InstanceProvider) CancelLaunch(context context.Context, request *clientapi.CancelLaunchRequest) (*clientapi.APIReply, error) {
+	if !s.controllerManager.ControllersRunning() {
+		return notTheLeaderReply(), nil
+	}
+	podName := string(request.PodName)
+	if podName == "" {
+		return errToAPIReply(fmt.Errorf("A pod name is required")), nil
+	}
+	klog.V(2).Infof("CancelLaunch request for pod %s", podName)
+	nodeController, err := s.getNodeController()
+	if err != nil {
+		return errToAPIReply(err), nil
+	}
+	if err := nodeController.CancelLaunch(podName); err != nil {
+		return errToAPIReply(err), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   []byte(fmt.Sprintf("canceled node launch for pod %s", podName)),
+	}
+	return &reply, nil
+}
@@ -33,8 +33,11 @@ import (
 
 // Logs requests can take a couple of forms:
 //
-//   logs podname unitname [lines] [limitbytes]
-//   logs nodename filepath [lines] [limitbytes]
+//   logs podname unitname [range]
+//   logs nodename filepath [range]
+//
+// the range can be a tail (lines/limitbytes, the old behavior) or a
+// specific line or byte range so UIs can paginate large logs.
 //
 // both of these can pull logs from currently running pods or nodes if
 // the pod or node are not found in the currently running set of pods
@@ -44,7 +47,7 @@ import (
 // parameter can be used to specify the path to the logfile on the
 // node (and thus the name of the logfile)
 
-func (s InstanceProvider) getPodLog(podName, itemName string, lines, bytes int) (*api.LogFile, error) {
+func (s InstanceProvider) getPodLog(podName, itemName string, lr nodeclient.LogRange) (*api.LogFile, error) {
 	reg, exists := s.Registries["Pod"]
 	if !exists {
 		return nil, fmt.Errorf("Fatal error: can't find pod registry in storage")
@@ -68,7 +71,7 @@ func (s InstanceProvider) getPodLog(podName, itemName string, lines, bytes int)
 	if nodeName == "" {
 		return nil, nil
 	}
-	log, err := s.getNodeLog(nodeName, itemName, true, lines, bytes)
+	log, err := s.getNodeLog(nodeName, itemName, true, lr)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +81,7 @@ func (s InstanceProvider) getPodLog(podName, itemName string, lines, bytes int)
 	return log, nil
 }
 
-func (s InstanceProvider) getNodeLog(nodeName, itemName string, isUnit bool, lines, bytes int) (*api.LogFile, error) {
+func (s InstanceProvider) getNodeLog(nodeName, itemName string, isUnit bool, lr nodeclient.LogRange) (*api.LogFile, error) {
 	reg, exists := s.Registries["Node"]
 	if !exists {
 		return nil, fmt.Errorf("Fatal error: can't find node registry in storage")
@@ -98,9 +101,9 @@ func (s InstanceProvider) getNodeLog(nodeName, itemName string, isUnit bool, lin
 	client := s.ItzoClientFactory.GetClient(node.Status.Addresses)
 	var data []byte
 	if isUnit {
-		data, err = client.GetLogs(itemName, lines, bytes)
+		data, err = client.GetLogs(itemName, lr)
 	} else {
-		data, err = client.GetFile("/var/log/itzo/itzo.log", lines, bytes)
+		data, err = client.GetFile("/var/log/itzo/itzo.log", lr)
 	}
 	if err != nil {
 		return nil, util.WrapError(
@@ -111,10 +114,55 @@ func (s InstanceProvider) getNodeLog(nodeName, itemName string, isUnit bool, lin
 	log.Name = nodeName + "/" + itemName
 	log.ParentObject = api.ToObjectReference(node)
 	log.Content = string(data)
+	log.StartLine = lr.StartLine
+	log.StartByte = lr.StartByte
 	return log, nil
 }
 
-func (s InstanceProvider) getLogFromRegistry(resourceName, itemName string, lines, bytes int) (*api.LogFile, error) {
+// applyLogRange trims a stored log to the requested range.  We only
+// save a couple KB of logs so doing this server side isn't all that
+// bad.  A range that starts beyond the end of the saved content
+// returns whatever is available.
+func applyLogRange(log *api.LogFile, lr nodeclient.LogRange) {
+	if lr.StartLine > 0 || lr.Lines > 0 {
+		parts := strings.Split(log.Content, "\n")
+		start := 0
+		end := len(parts)
+		if lr.StartLine > 0 {
+			start = lr.StartLine
+			if start > end {
+				start = end
+			}
+			if lr.Lines > 0 && start+lr.Lines < end {
+				end = start + lr.Lines
+			}
+		} else if lr.Lines < len(parts) {
+			// no offset requested, keep the tail of the log
+			start = len(parts) - lr.Lines
+		}
+		log.Content = strings.Join(parts[start:end], "\n")
+		log.StartLine += start
+	}
+	if lr.StartByte > 0 || lr.Bytes > 0 {
+		start := 0
+		end := len(log.Content)
+		if lr.StartByte > 0 {
+			start = lr.StartByte
+			if start > end {
+				start = end
+			}
+			if lr.Bytes > 0 && start+lr.Bytes < end {
+				end = start + lr.Bytes
+			}
+		} else if lr.Bytes < end {
+			start = end - lr.Bytes
+		}
+		log.Content = log.Content[start:end]
+		log.StartByte += start
+	}
+}
+
+func (s InstanceProvider) getLogFromRegistry(resourceName, itemName string, lr nodeclient.LogRange) (*api.LogFile, error) {
 	reg, exists := s.Registries["Log"]
 	if !exists {
 		return nil, fmt.Errorf("Fatal error: can't find log registry in storage")
@@ -150,27 +198,12 @@ func (s InstanceProvider) getLogFromRegistry(resourceName, itemName string, line
 	} else if log == nil {
 		return nil, fmt.Errorf("Could not find log in storage")
 	}
-	if lines != 0 {
-		// we only save a couple KB of logs so this shouldn't be
-		// all that bad
-		parts := strings.Split(log.Content, "\n")
-		if lines < len(parts) {
-			startLine := len(parts) - lines
-			parts = parts[startLine:]
-		}
-		log.Content = strings.Join(parts, "\n")
-	}
-	if bytes != 0 {
-		if bytes < len(log.Content) {
-			startByte := len(log.Content) - bytes
-			log.Content = log.Content[startByte:]
-		}
-	}
+	applyLogRange(log, lr)
 	return log, nil
 }
 
-func (s InstanceProvider) findLog(resourceName, itemName string, lines, bytes int) (*api.LogFile, error) {
-	log, err := s.getPodLog(resourceName, itemName, lines, bytes)
+func (s InstanceProvider) findLog(resourceName, itemName string, lr nodeclient.LogRange) (*api.LogFile, error) {
+	log, err := s.getPodLog(resourceName, itemName, lr)
 	if err != nil {
 		return nil, util.WrapError(
 			err, "Error getting log %s from pod", resourceName)
@@ -179,7 +212,7 @@ func (s InstanceProvider) findLog(resourceName, itemName string, lines, bytes in
 		return log, err
 	}
 
-	log, err = s.getNodeLog(resourceName, itemName, false, lines, bytes)
+	log, err = s.getNodeLog(resourceName, itemName, false, lr)
 	if err != nil {
 		return nil, util.WrapError(
 			err, "Error getting log from node %s", resourceName)
@@ -188,7 +221,7 @@ func (s InstanceProvider) findLog(resourceName, itemName string, lines, bytes in
 		return log, err
 	}
 
-	log, err = s.getLogFromRegistry(resourceName, itemName, lines, bytes)
+	log, err = s.getLogFromRegistry(resourceName, itemName, lr)
 	if err != nil {
 		return nil, util.WrapError(
 			err, "Error getting older log from registry %s", resourceName)
@@ -202,13 +235,17 @@ func (s InstanceProvider) findLog(resourceName, itemName string, lines, bytes in
 func (s InstanceProvider) GetLogs(context context.Context, request *clientapi.LogsRequest) (*clientapi.APIReply, error) {
 	resourceName := request.ResourceName
 	itemName := request.ItemName
-	lines := int(request.Lines)
-	bytes := int(request.Limitbytes)
+	lr := nodeclient.LogRange{
+		StartLine: int(request.StartLine),
+		Lines:     int(request.Lines),
+		StartByte: int(request.StartByte),
+		Bytes:     int(request.Limitbytes),
+	}
 
-	klog.V(2).Infof("Getting logs from %s/%s (max lines %d; limitbytes %d)",
-		resourceName, itemName, lines, bytes)
+	klog.V(2).Infof("Getting logs from %s/%s (range %+v)",
+		resourceName, itemName, lr)
 
-	foundLog, err := s.findLog(resourceName, itemName, lines, bytes)
+	foundLog, err := s.findLog(resourceName, itemName, lr)
 	if err != nil {
 		return errToAPIReply(err), nil
 	}
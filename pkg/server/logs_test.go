@@ -59,7 +59,7 @@ func TestGetLogForPod(t *testing.T) {
 	_, err = podReg.CreatePod(pod)
 	assert.NoError(t, err)
 
-	logFile, err := s.findLog(pod.Name, "", 0, 0)
+	logFile, err := s.findLog(pod.Name, "", nodeclient.LogRange{})
 	assert.NoError(t, err)
 	assert.Equal(t, "logs", logFile.Content)
 	assert.Equal(t, "Pod", logFile.ParentObject.Kind)
@@ -73,7 +73,7 @@ func TestGetLogFromRegistry(t *testing.T) {
 	logReg := s.Registries["Log"].(*registry.LogRegistry)
 	_, err := logReg.CreateLog(logInput)
 	assert.NoError(t, err)
-	logFile, err := s.findLog(logInput.ParentObject.Name, logInput.Name, 0, 0)
+	logFile, err := s.findLog(logInput.ParentObject.Name, logInput.Name, nodeclient.LogRange{})
 	assert.NoError(t, err)
 	assert.Equal(t, logInput.Content, logFile.Content)
 	assert.Equal(t, "Node", logFile.ParentObject.Kind)
@@ -97,7 +97,41 @@ func TestGetLogForNotRunningPod(t *testing.T) {
 	_, err = logReg.CreateLog(log)
 	assert.NoError(t, err)
 
-	logFile, err := s.findLog(pod.Name, "", 0, 0)
+	logFile, err := s.findLog(pod.Name, "", nodeclient.LogRange{})
 	assert.NoError(t, err)
 	assert.Equal(t, podLogData, logFile.Content)
 }
+
+func TestApplyLogRange(t *testing.T) {
+	content := "l0\nl1\nl2\nl3\nl4"
+	tests := []struct {
+		lr        nodeclient.LogRange
+		content   string
+		startLine int
+		startByte int
+	}{
+		// no range, everything comes back
+		{nodeclient.LogRange{}, content, 0, 0},
+		// tail behavior when no offset is given
+		{nodeclient.LogRange{Lines: 2}, "l3\nl4", 3, 0},
+		{nodeclient.LogRange{Bytes: 5}, "l3\nl4", 0, 9},
+		// line range
+		{nodeclient.LogRange{StartLine: 1, Lines: 2}, "l1\nl2", 1, 0},
+		// line range to the end of the log
+		{nodeclient.LogRange{StartLine: 3}, "l3\nl4", 3, 0},
+		// byte range
+		{nodeclient.LogRange{StartByte: 3, Bytes: 2}, "l1", 0, 3},
+		// ranges past the end return what's available
+		{nodeclient.LogRange{StartLine: 3, Lines: 10}, "l3\nl4", 3, 0},
+		{nodeclient.LogRange{StartLine: 100}, "", 5, 0},
+		{nodeclient.LogRange{StartByte: 100}, "", 0, 14},
+	}
+	for i, tc := range tests {
+		log := api.NewLogFile()
+		log.Content = content
+		applyLogRange(log, tc.lr)
+		assert.Equal(t, tc.content, log.Content, "case %d", i)
+		assert.Equal(t, tc.startLine, log.StartLine, "case %d", i)
+		assert.Equal(t, tc.startByte, log.StartByte, "case %d", i)
+	}
+}
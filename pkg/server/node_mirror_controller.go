@@ -0,0 +1,312 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/elotl/kip/pkg/util/controllerqueue"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// MirrorNodeControllerLabel marks Kubernetes Node objects that
+	// mirror this controller's cells so we only ever touch our own
+	// records.
+	MirrorNodeControllerLabel = "kip.elotl.co/controller-id"
+	// MirrorNodeNodeLabel holds the name of the virtual-kubelet node
+	// the cell belongs to.
+	MirrorNodeNodeLabel = "kip.elotl.co/node"
+	// MirrorNodeInstanceTypeLabel holds the cell's cloud instance
+	// type.
+	MirrorNodeInstanceTypeLabel = "kip.elotl.co/instance-type"
+	// mirrorNodeTaintKey keeps the scheduler from placing pods onto
+	// mirrored nodes, they only exist for visibility.
+	mirrorNodeTaintKey = "kip.elotl.co/cell"
+)
+
+const (
+	mirrorNodeCreated = iota
+	mirrorNodePurged
+	mirrorNodesFullSync
+)
+
+type MirrorNodeOp struct {
+	op   int
+	node *api.Node
+}
+
+// NodeMirrorController mirrors the cells in the node registry as
+// labeled Kubernetes Node objects so operators can see the cloud
+// instances backing their pods in kubectl and other standard tooling.
+// The mirrored nodes are tainted and unschedulable, they're
+// observability sugar, not real kubelets.  The whole thing is opt-in
+// through cells.mirrorKubernetesNodes and the mirrored nodes are
+// cleaned up when the controller shuts down.
+type NodeMirrorController struct {
+	controllerID  string
+	nodeName      string
+	k8sNodeClient corev1client.NodeInterface
+	eventsSystem  *events.EventSystem
+	nodeLister    registry.NodeLister
+	queue         *controllerqueue.Queue
+}
+
+func NewNodeMirrorController(
+	controllerID, nodeName string,
+	k8sNodeClient corev1client.NodeInterface,
+	eventsSystem *events.EventSystem,
+	nodeLister registry.NodeLister,
+) *NodeMirrorController {
+	c := &NodeMirrorController{
+		controllerID:  controllerID,
+		nodeName:      nodeName,
+		k8sNodeClient: k8sNodeClient,
+		eventsSystem:  eventsSystem,
+		nodeLister:    nodeLister,
+	}
+	c.queue = controllerqueue.New("mirrornodes", c.processOperation, controllerqueue.NumWorkers(1), controllerqueue.MaxRetries(0))
+	return c
+}
+
+func (c *NodeMirrorController) Start(quit <-chan struct{}, wg *sync.WaitGroup) {
+	c.queue.Start(quit)
+	go c.runSyncLoop(quit, wg)
+	c.registerEventHandlers()
+}
+
+func (c *NodeMirrorController) registerEventHandlers() {
+	c.eventsSystem.RegisterHandlerFunc(
+		events.NodeRunning, c.nodeRunning)
+	c.eventsSystem.RegisterHandlerFunc(
+		events.NodePurged, c.nodePurged)
+}
+
+func (c *NodeMirrorController) nodeRunning(e events.Event) error {
+	node, ok := e.Object.(*api.Node)
+	if !ok {
+		return fmt.Errorf("invalid event object: %v", e)
+	}
+	c.queue.Add(MirrorNodeOp{
+		op:   mirrorNodeCreated,
+		node: node,
+	})
+	return nil
+}
+
+func (c *NodeMirrorController) nodePurged(e events.Event) error {
+	node, ok := e.Object.(*api.Node)
+	if !ok {
+		return fmt.Errorf("invalid event object: %v", e)
+	}
+	c.queue.Add(MirrorNodeOp{
+		op:   mirrorNodePurged,
+		node: node,
+	})
+	return nil
+}
+
+func (c *NodeMirrorController) Dump() []byte {
+	dumpStruct := struct {
+		WorkQueueLength int
+	}{
+		WorkQueueLength: c.queue.Len(),
+	}
+	b, err := json.MarshalIndent(dumpStruct, "", "    ")
+	if err != nil {
+		klog.Errorln("Error dumping data from NodeMirrorController", err)
+		return nil
+	}
+	return b
+}
+
+func (c *NodeMirrorController) runSyncLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+	fullSyncTicker := time.NewTicker(45 * time.Second)
+	c.queue.Add(MirrorNodeOp{op: mirrorNodesFullSync})
+	for {
+		select {
+		case <-fullSyncTicker.C:
+			c.queue.Add(MirrorNodeOp{op: mirrorNodesFullSync})
+		case <-quit:
+			klog.Info("Exiting NodeMirrorController Sync Loop")
+			if err := c.deleteAllMirrorNodes(); err != nil {
+				klog.Errorf("Error cleaning up mirrored nodes: %s", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *NodeMirrorController) processOperation(item interface{}) error {
+	mirrorOp, ok := item.(MirrorNodeOp)
+	if !ok {
+		return fmt.Errorf("Incorrect item type inserted into work queue: %v",
+			item)
+	}
+	var err error
+	switch mirrorOp.op {
+	case mirrorNodeCreated:
+		err = c.createMirrorNode(mirrorOp.node)
+	case mirrorNodePurged:
+		err = c.deleteMirrorNode(mirrorOp.node)
+	case mirrorNodesFullSync:
+		err = c.syncAllMirrorNodes()
+	}
+	if err != nil {
+		klog.Errorf("Error processing mirror node operation: %s", err)
+	}
+	return err
+}
+
+func (c *NodeMirrorController) makeMirrorNode(n *api.Node) *v1.Node {
+	kn := &v1.Node{}
+	kn.Name = n.Name
+	// start from the cell's cloud metadata labels (topology zone,
+	// capacity type, ...) and layer the controller-owned keys on top
+	// so ownership labels always win
+	kn.Labels = make(map[string]string, len(n.Labels)+3)
+	for k, v := range n.Labels {
+		kn.Labels[k] = v
+	}
+	kn.Labels[MirrorNodeControllerLabel] = c.controllerID
+	kn.Labels[MirrorNodeNodeLabel] = c.nodeName
+	kn.Labels[MirrorNodeInstanceTypeLabel] = n.Spec.InstanceType
+	kn.Spec.ProviderID = "kip://" + n.Status.InstanceID
+	kn.Spec.Unschedulable = true
+	kn.Spec.Taints = []v1.Taint{
+		{
+			Key:    mirrorNodeTaintKey,
+			Value:  "true",
+			Effect: v1.TaintEffectNoSchedule,
+		},
+	}
+	if ip := api.GetPrivateIP(n.Status.Addresses); ip != "" {
+		kn.Status.Addresses = []v1.NodeAddress{
+			{
+				Type:    v1.NodeInternalIP,
+				Address: ip,
+			},
+		}
+	}
+	return kn
+}
+
+func (c *NodeMirrorController) createMirrorNode(n *api.Node) error {
+	if n == nil {
+		return fmt.Errorf("Could not create mirror node: invalid kip node")
+	}
+	_, err := c.k8sNodeClient.Create(c.makeMirrorNode(n))
+	return err
+}
+
+func (c *NodeMirrorController) deleteMirrorNode(n *api.Node) error {
+	if n == nil {
+		return fmt.Errorf("Could not delete mirror node: no corresponding kip node provided")
+	}
+	return c.k8sNodeClient.Delete(n.Name, &metav1.DeleteOptions{})
+}
+
+// listMirrorNodes returns the Kubernetes Node objects this controller
+// created, found through the ownership label.
+func (c *NodeMirrorController) listMirrorNodes() (*v1.NodeList, error) {
+	return c.k8sNodeClient.List(metav1.ListOptions{
+		LabelSelector: MirrorNodeControllerLabel + "=" + c.controllerID,
+	})
+}
+
+func (c *NodeMirrorController) syncAllMirrorNodes() error {
+	nodes, err := c.nodeLister.ListNodes(func(n *api.Node) bool {
+		switch n.Status.Phase {
+		case api.NodeCreated, api.NodeAvailable, api.NodeClaimed, api.NodeCleaning, api.NodeTerminating:
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return util.WrapError(err, "Could not load kip nodes for full sync")
+	}
+	specNodes := make(map[string]interface{})
+	for _, n := range nodes.Items {
+		kn := c.makeMirrorNode(n)
+		specNodes[kn.Name] = kn
+	}
+
+	knList, err := c.listMirrorNodes()
+	if err != nil {
+		return util.WrapError(err, "Could not load mirrored nodes from k8s for full sync")
+	}
+	statusNodes := make(map[string]interface{})
+	for i := range knList.Items {
+		statusNodes[knList.Items[i].Name] = &knList.Items[i]
+	}
+
+	add, update, delete := util.MapUserDiff(specNodes, statusNodes, diffMirrorNodes)
+	if len(add) > 0 || len(update) > 0 || len(delete) > 0 {
+		klog.V(3).Infof("reconciling mirrored nodes - add: %d, update: %d, delete: %d",
+			len(add), len(update), len(delete))
+	}
+	errs := make([]error, 0)
+	for _, name := range add {
+		kn := specNodes[name].(*v1.Node)
+		_, err := c.k8sNodeClient.Create(kn)
+		if err != nil {
+			errs = append(errs, util.WrapError(err, "Could not create mirrored node in k8s"))
+		}
+	}
+	for _, name := range update {
+		specNode := specNodes[name].(*v1.Node)
+		statusNode := statusNodes[name].(*v1.Node)
+		statusNode.Labels = specNode.Labels
+		statusNode.Spec.ProviderID = specNode.Spec.ProviderID
+		statusNode.Status.Addresses = specNode.Status.Addresses
+		_, err := c.k8sNodeClient.Update(statusNode)
+		if err != nil {
+			errs = append(errs, util.WrapError(err, "Could not update mirrored node in k8s"))
+		}
+	}
+	for _, name := range delete {
+		err := c.k8sNodeClient.Delete(name, &metav1.DeleteOptions{})
+		if err != nil {
+			errs = append(errs, util.WrapError(err, "Error deleting mirrored node in k8s"))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+// deleteAllMirrorNodes removes every mirrored node we own, called at
+// shutdown so torn down controllers don't leave phantom nodes in the
+// cluster.
+func (c *NodeMirrorController) deleteAllMirrorNodes() error {
+	knList, err := c.listMirrorNodes()
+	if err != nil {
+		return util.WrapError(err, "Could not list mirrored nodes for cleanup")
+	}
+	errs := make([]error, 0)
+	for i := range knList.Items {
+		err := c.k8sNodeClient.Delete(knList.Items[i].Name, &metav1.DeleteOptions{})
+		if err != nil {
+			errs = append(errs, util.WrapError(err, "Error deleting mirrored node in k8s"))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func diffMirrorNodes(a, b interface{}) bool {
+	aNode := a.(*v1.Node)
+	bNode := b.(*v1.Node)
+	return (reflect.DeepEqual(aNode.Labels, bNode.Labels) &&
+		aNode.Spec.ProviderID == bNode.Spec.ProviderID &&
+		reflect.DeepEqual(aNode.Status.Addresses, bNode.Status.Addresses))
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/labels"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestMakeMirrorNode(t *testing.T) {
+	c := &NodeMirrorController{
+		controllerID: "test1",
+		nodeName:     "kip-node",
+	}
+	n := api.GetFakeNode()
+	n.Status.InstanceID = "i-123"
+	n.Status.Addresses = api.NewNetworkAddresses("10.0.0.7", "")
+	n.Labels = map[string]string{
+		labels.NodeZone: "us-east-1a",
+		// a cell label colliding with a controller-owned key loses
+		MirrorNodeControllerLabel: "someone-else",
+	}
+
+	kn := c.makeMirrorNode(n)
+	assert.Equal(t, n.Name, kn.Name)
+	assert.Equal(t, "test1", kn.Labels[MirrorNodeControllerLabel])
+	assert.Equal(t, "kip-node", kn.Labels[MirrorNodeNodeLabel])
+	assert.Equal(t, n.Spec.InstanceType, kn.Labels[MirrorNodeInstanceTypeLabel])
+	assert.Equal(t, "us-east-1a", kn.Labels[labels.NodeZone])
+	assert.Equal(t, "kip://i-123", kn.Spec.ProviderID)
+	assert.True(t, kn.Spec.Unschedulable)
+	if assert.Len(t, kn.Spec.Taints, 1) {
+		assert.Equal(t, v1.TaintEffectNoSchedule, kn.Spec.Taints[0].Effect)
+	}
+	if assert.Len(t, kn.Status.Addresses, 1) {
+		assert.Equal(t, v1.NodeInternalIP, kn.Status.Addresses[0].Type)
+		assert.Equal(t, "10.0.0.7", kn.Status.Addresses[0].Address)
+	}
+}
+
+func TestDiffMirrorNodes(t *testing.T) {
+	c := &NodeMirrorController{
+		controllerID: "test1",
+		nodeName:     "kip-node",
+	}
+	n := api.GetFakeNode()
+	n.Status.InstanceID = "i-123"
+	a := c.makeMirrorNode(n)
+	b := c.makeMirrorNode(n)
+	assert.True(t, diffMirrorNodes(a, b))
+	b.Labels[MirrorNodeInstanceTypeLabel] = "m5.large"
+	assert.False(t, diffMirrorNodes(a, b))
+	b = c.makeMirrorNode(n)
+	b.Spec.ProviderID = "kip://i-456"
+	assert.False(t, diffMirrorNodes(a, b))
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"k8s.io/klog"
+)
+
+const (
+	defaultAgentFailureThreshold   = 3
+	defaultAgentTerminateThreshold = 6
+)
+
+// agentHealth tracks consecutive failed agent health checks for one
+// node and whether its instance has already been rebooted to try to
+// recover the agent.
+type agentHealth struct {
+	failures int
+	rebooted bool
+}
+
+func (c *NodeController) agentFailureThreshold() int {
+	if c.Config.AgentFailureThreshold > 0 {
+		return c.Config.AgentFailureThreshold
+	}
+	return defaultAgentFailureThreshold
+}
+
+func (c *NodeController) agentTerminateThreshold() int {
+	if c.Config.AgentTerminateThreshold > 0 {
+		return c.Config.AgentTerminateThreshold
+	}
+	return defaultAgentTerminateThreshold
+}
+
+// runAgentHealthLoop periodically pings the itzo agent on every
+// booted node, separately from the regular heartbeats.  When an
+// agent keeps failing its checks while its instance is still running
+// in the cloud, the instance is rebooted to restart the agent; if
+// the agent stays wedged after that, the cell is reaped and its pod
+// rescheduled.
+func (c *NodeController) runAgentHealthLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(c.Config.AgentHealthInterval)
+	defer ticker.Stop()
+	health := make(map[string]*agentHealth)
+	for {
+		select {
+		case <-ticker.C:
+			c.checkAgentHealth(health)
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (c *NodeController) checkAgentHealth(health map[string]*agentHealth) {
+	nodes, err := c.NodeRegistry.ListNodes(func(n *api.Node) bool {
+		return (n.Status.Phase == api.NodeAvailable ||
+			n.Status.Phase == api.NodeClaimed) &&
+			n.Status.Addresses != nil
+	})
+	if err != nil {
+		klog.Errorf("Error listing nodes for agent health check: %s", err.Error())
+		return
+	}
+	seen := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		seen[node.Name] = true
+		h := health[node.Name]
+		if h == nil {
+			h = &agentHealth{}
+			health[node.Name] = h
+		}
+		client := c.NodeClientFactory.GetClient(node.Status.Addresses)
+		if err := client.Healthcheck(); err != nil {
+			h.failures++
+			klog.V(2).Infof("Agent health check for node %s failed %d times: %v",
+				node.Name, h.failures, err)
+			c.remediateWedgedAgent(node, h)
+		} else {
+			h.failures = 0
+			h.rebooted = false
+		}
+	}
+	for name := range health {
+		if !seen[name] {
+			delete(health, name)
+		}
+	}
+}
+
+// remediateWedgedAgent handles a node whose agent keeps failing its
+// health checks.  The node's instance is rebooted once to restart
+// the agent; a node that stays unhealthy after the reboot is reaped.
+// If the instance is no longer running in the cloud there is nothing
+// to remediate, the regular heartbeat handling cleans up the node.
+func (c *NodeController) remediateWedgedAgent(node *api.Node, h *agentHealth) {
+	if h.failures < c.agentFailureThreshold() {
+		return
+	}
+	if !h.rebooted {
+		insts, err := c.CloudClient.ListInstancesFilterID(
+			[]string{node.Status.InstanceID})
+		if err != nil || len(insts) == 0 {
+			return
+		}
+		klog.Warningf("itzo agent on node %s failed %d health checks, rebooting instance %s to restart it",
+			node.Name, h.failures, node.Status.InstanceID)
+		if err := c.CloudClient.RebootInstance(node.Status.InstanceID); err != nil {
+			klog.Errorf("Error rebooting instance %s for node %s: %v",
+				node.Status.InstanceID, node.Name, err)
+		}
+		h.rebooted = true
+		return
+	}
+	if h.failures >= c.agentTerminateThreshold() {
+		klog.Errorf("itzo agent on node %s is still unhealthy after a reboot, terminating the cell",
+			node.Name)
+		if node.Status.BoundPodName != "" {
+			c.removePodFromNode(node)
+		}
+		_ = c.stopSingleNode(node)
+	}
+}
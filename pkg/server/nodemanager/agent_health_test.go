@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemediateWedgedAgent(t *testing.T) {
+	rebooted := []string{}
+	client := cloud.NewMockClient()
+	client.Rebooter = func(instanceID string) error {
+		rebooted = append(rebooted, instanceID)
+		return nil
+	}
+	ctl := &NodeController{CloudClient: client}
+	node := api.GetFakeNode()
+	node.Status.InstanceID = "i-123"
+	client.AddInstances(cloud.CloudInstance{ID: "i-123", NodeName: node.Name})
+
+	// too few failures, nothing happens
+	h := &agentHealth{failures: 1}
+	ctl.remediateWedgedAgent(node, h)
+	assert.Empty(t, rebooted)
+	assert.False(t, h.rebooted)
+
+	// hitting the failure threshold reboots the instance once
+	h.failures = defaultAgentFailureThreshold
+	ctl.remediateWedgedAgent(node, h)
+	assert.Equal(t, []string{"i-123"}, rebooted)
+	assert.True(t, h.rebooted)
+	h.failures++
+	ctl.remediateWedgedAgent(node, h)
+	assert.Len(t, rebooted, 1)
+
+	// a node whose instance is gone from the cloud is left to the
+	// regular heartbeat handling
+	gone := api.GetFakeNode()
+	gone.Status.InstanceID = "i-gone"
+	hGone := &agentHealth{failures: defaultAgentFailureThreshold}
+	ctl.remediateWedgedAgent(gone, hGone)
+	assert.Len(t, rebooted, 1)
+	assert.False(t, hGone.rebooted)
+}
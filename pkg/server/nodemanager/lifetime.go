@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/events"
+	"k8s.io/klog"
+)
+
+// nodeLaunchTime is when the node's instance was launched, falling
+// back to the node's creation time for nodes that haven't recorded a
+// launch time yet.
+func nodeLaunchTime(node *api.Node) time.Time {
+	if !node.Status.LaunchedAt.IsZero() {
+		return node.Status.LaunchedAt.Time
+	}
+	return node.CreationTimestamp.Time
+}
+
+// nodeTooYoungToStop reports whether scale-in should leave a node
+// alone because its instance hasn't reached the configured minimum
+// lifetime yet.  This keeps just-launched instances from being
+// churned when pod load briefly dips.
+func (c *NodeController) nodeTooYoungToStop(node *api.Node) bool {
+	minLifetime := c.Config.MinInstanceLifetime
+	if minLifetime <= 0 {
+		return false
+	}
+	return time.Now().Sub(nodeLaunchTime(node)) < minLifetime
+}
+
+// recycleAgedNodes drains and replaces nodes whose instances have
+// been running longer than the configured maximum lifetime, for
+// policies that require instances to be recycled every N hours.  An
+// ejected pod goes back to the pod controller and gets dispatched to
+// a fresh cell.  At most one bound node is recycled per reaper tick
+// so a fleet launched together doesn't get disrupted all at once.
+func (c *NodeController) recycleAgedNodes() {
+	maxLifetime := c.Config.MaxInstanceLifetime
+	if maxLifetime <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-maxLifetime)
+	nodes, err := c.NodeRegistry.ListNodes(func(n *api.Node) bool {
+		return (n.Status.Phase == api.NodeAvailable ||
+			n.Status.Phase == api.NodeClaimed) &&
+			!n.Spec.Terminate &&
+			nodeLaunchTime(n).Before(cutoff)
+	})
+	if err != nil {
+		klog.Errorf("Error listing nodes for lifetime check: %s", err.Error())
+		return
+	}
+	recycledBoundNode := false
+	for _, node := range nodes.Items {
+		if node.Status.BoundPodName != "" {
+			if recycledBoundNode {
+				continue
+			}
+			recycledBoundNode = true
+		}
+		c.Events.Emit(events.NodeLifetimeExceeded, "node-controller", node,
+			"Node %s instance %s exceeded the maximum instance lifetime of %s, recycling",
+			node.Name, node.Status.InstanceID, maxLifetime)
+		if node.Status.BoundPodName != "" {
+			c.removePodFromNode(node)
+		}
+		go c.stopSingleNode(node)
+	}
+}
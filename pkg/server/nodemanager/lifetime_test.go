@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLaunchTime(t *testing.T) {
+	node := api.GetFakeNode()
+	node.CreationTimestamp = api.Now()
+	assert.Equal(t, node.CreationTimestamp.Time, nodeLaunchTime(node))
+	node.Status.LaunchedAt = api.Now()
+	assert.Equal(t, node.Status.LaunchedAt.Time, nodeLaunchTime(node))
+}
+
+func TestNodeTooYoungToStop(t *testing.T) {
+	ctl := &NodeController{}
+	node := api.GetFakeNode()
+	node.Status.LaunchedAt = api.Now()
+
+	// no minimum configured, nodes can always be stopped
+	assert.False(t, ctl.nodeTooYoungToStop(node))
+
+	ctl.Config.MinInstanceLifetime = 10 * time.Minute
+	assert.True(t, ctl.nodeTooYoungToStop(node))
+	node.Status.LaunchedAt = api.Time{Time: time.Now().Add(-20 * time.Minute)}
+	assert.False(t, ctl.nodeTooYoungToStop(node))
+}
@@ -17,39 +17,58 @@ limitations under the License.
 package nodemanager
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"path"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/libkv/store"
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
+	"github.com/elotl/kip/pkg/api/labels"
 	"github.com/elotl/kip/pkg/certs"
 	"github.com/elotl/kip/pkg/nodeclient"
+	"github.com/elotl/kip/pkg/server/audit"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/events"
 	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/cloudinitfile"
+	"github.com/elotl/kip/pkg/util/instanceselector"
 	"github.com/elotl/kip/pkg/util/stats"
+	"github.com/elotl/kip/pkg/util/structlog"
 	"github.com/elotl/kip/pkg/util/timeoutmap"
+	"github.com/elotl/kip/pkg/util/traceutil"
+	octrace "go.opencensus.io/trace"
 	"k8s.io/klog"
 )
 
 // Making these vars makes it easier testing
 // non-const timeouts were endorsed by Mitchell Hashimoto
 var (
-	BootTimeout         time.Duration = 300 * time.Second
-	HealthyTimeout      time.Duration = 90 * time.Second
-	HealthcheckPause    time.Duration = 5 * time.Second
-	SpotRequestPause    time.Duration = 60 * time.Second
+	BootTimeout      time.Duration = 300 * time.Second
+	CloudInitTimeout time.Duration = 180 * time.Second
+	HealthyTimeout   time.Duration = 90 * time.Second
+	HealthcheckPause time.Duration = 5 * time.Second
+	SpotRequestPause time.Duration = 60 * time.Second
+	// StoppedPoolMaxAge is how long a stopped instance may sit in
+	// the stopped pool before the reaper terminates it.
+	StoppedPoolMaxAge   time.Duration = 60 * time.Minute
 	BootImage           cloud.Image   = cloud.Image{}
 	MaxBootPerIteration int           = 10
 	itzoDir             string        = "/tmp/itzo"
+	// HeartbeatPersistInterval bounds how often a node's last
+	// heartbeat time is written to the registry.  Heartbeats fire
+	// every few seconds, writing each one to the datastore would be
+	// wasteful, the persisted time is only there for node status.
+	HeartbeatPersistInterval time.Duration = 1 * time.Minute
 )
 
 // when configuring these intervals we want the following constraints
@@ -62,9 +81,68 @@ type NodeControllerConfig struct {
 	PoolInterval      time.Duration
 	HeartbeatInterval time.Duration
 	ReaperInterval    time.Duration
-	ItzoVersion       string
-	ItzoURL           string
+	// BootTimeout bounds how long a node can sit in Creating or
+	// Created before the reaper terminates it.  Zero falls back to
+	// the package level BootTimeout.
+	BootTimeout time.Duration
+	// CloudInitTimeout bounds how long itzo may report the cell's
+	// cloud-init bootstrap as still in progress after the node has
+	// answered its first healthcheck.  Zero falls back to the package
+	// level CloudInitTimeout.
+	CloudInitTimeout time.Duration
+	ItzoVersion      string
+	ItzoURL          string
+	// ItzoCanaryVersion, if set, is booted on roughly
+	// ItzoCanaryPercent percent of new nodes so a new agent can be
+	// validated on a fraction of the fleet before a full rollout.
+	ItzoCanaryVersion string
+	ItzoCanaryURL     string
+	ItzoCanaryPercent int
 	CellConfig        map[string]string
+	// RegistryMirrors maps image registry hosts to mirror base URLs;
+	// itzo pulls images for those registries through their mirrors.
+	RegistryMirrors map[string]string
+	// InsecureRegistryMirrors lists mirror hosts itzo may contact
+	// over plain HTTP.
+	InsecureRegistryMirrors []string
+	// BudgetMaxSpend caps the estimated spend on nodes over
+	// BudgetWindowHours hours.  When launching a node would push the
+	// estimate over the cap, the node isn't launched and its pod
+	// stays waiting.  Zero disables budget enforcement.
+	BudgetMaxSpend    float64
+	BudgetWindowHours float64
+	// StoppedPoolSize is how many unneeded instances are stopped
+	// and kept for reuse instead of terminated.  Restarting a
+	// stopped instance is much faster than a fresh launch.  Zero
+	// disables the stopped pool.
+	StoppedPoolSize int
+	// StoppedPoolMaxAge bounds how long a stopped instance is kept
+	// before the reaper terminates it.  Zero falls back to the
+	// package level StoppedPoolMaxAge.
+	StoppedPoolMaxAge time.Duration
+	// AgentHealthInterval is how often the itzo agent on each booted
+	// node is pinged, separately from the regular heartbeats.  Zero
+	// disables the agent health checks.
+	AgentHealthInterval time.Duration
+	// AgentFailureThreshold is the number of consecutive failed
+	// agent health checks after which the node's instance is
+	// rebooted to restart the agent.  Zero uses the built-in
+	// default of 3.
+	AgentFailureThreshold int
+	// AgentTerminateThreshold is the number of consecutive failed
+	// agent health checks after which a node whose instance was
+	// already rebooted is reaped.  Zero uses the built-in default
+	// of 6.
+	AgentTerminateThreshold int
+	// MinInstanceLifetime keeps just-launched instances from being
+	// stopped on scale-in before they've run for at least this long.
+	// Zero disables the minimum.
+	MinInstanceLifetime time.Duration
+	// MaxInstanceLifetime proactively drains and replaces nodes
+	// whose instances have been running longer than this, for
+	// security policies that require instances to be recycled
+	// periodically.  Zero disables lifetime-based recycling.
+	MaxInstanceLifetime time.Duration
 }
 
 type NodeController struct {
@@ -83,14 +161,53 @@ type NodeController struct {
 	CertificateFactory *certs.CertificateFactory
 	CloudStatus        cloud.StatusKeeper
 	BootImageSpec      cloud.BootImageSpec
+	// FastBootImageSpec selects an optional pre-baked snapshot image
+	// that is preferred over BootImageSpec when it resolves.
+	FastBootImageSpec cloud.BootImageSpec
+	// AuditTrail records scaling decisions for kipctl audit, a nil
+	// trail drops them.
+	AuditTrail       *audit.Trail
+	Log              *structlog.Logger
+	spendLock        sync.Mutex
+	estimatedSpend   float64
+	launchLock       sync.Mutex
+	inFlightLaunches map[string]*inFlightLaunch
+	stoppedPoolLock  sync.Mutex
+	stoppedPool      map[string]*stoppedInstance
+}
+
+// inFlightLaunch tracks a node launch that has been started but
+// hasn't finished booting yet, so it can be listed and canceled.
+type inFlightLaunch struct {
+	cancel           context.CancelFunc
+	nodeName         string
+	instanceType     string
+	availabilityZone string
+	startedAt        time.Time
+}
+
+// LaunchStatus describes one in-flight node launch, served by "kipctl
+// get launches".
+type LaunchStatus struct {
+	Pod              string `json:"pod"`
+	Node             string `json:"node"`
+	InstanceType     string `json:"instanceType"`
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	Elapsed          string `json:"elapsed"`
 }
 
 func (c *NodeController) Start(quit <-chan struct{}, wg *sync.WaitGroup) {
 	c.PoolLoopTimer = &stats.LoopTimer{}
+	// A fast create/delete of a pod should interrupt the launch of
+	// the node being booted for it instead of leaking the instance.
+	c.Events.RegisterHandlerFunc(events.PodShouldDelete, c.cancelPodLaunch)
 	c.StopCreatingNodes()
 	go c.ResumeWaits()
 	go c.runHeartbeatsLoop(quit, wg)
 	go c.reaperLoop(quit, wg)
+	if c.Config.AgentHealthInterval > 0 {
+		go c.runAgentHealthLoop(quit, wg)
+	}
 	nodeBindingsUpdate := make(chan map[string]string)
 	go c.updateBufferedNodesLoop(quit, wg, nodeBindingsUpdate)
 	go c.dispatchNodesLoop(quit, wg, nodeBindingsUpdate)
@@ -98,8 +215,22 @@ func (c *NodeController) Start(quit <-chan struct{}, wg *sync.WaitGroup) {
 }
 
 func (c *NodeController) Dump() []byte {
-	t := c.PoolLoopTimer.Copy()
-	b, err := json.MarshalIndent(*t, "", "    ")
+	dumpStruct := struct {
+		PoolLoopTimer stats.LoopTimer `json:"poolLoopTimer"`
+		// EstimatedSpend is the estimated cost of the current set of
+		// nodes over the configured budget window.
+		EstimatedSpend float64 `json:"estimatedSpend"`
+		// WarmPools is the occupancy of the configured warm pools of
+		// standby nodes.
+		WarmPools []WarmPoolStatus `json:"warmPools,omitempty"`
+	}{
+		PoolLoopTimer:  *c.PoolLoopTimer.Copy(),
+		EstimatedSpend: c.getEstimatedSpend(),
+	}
+	if scaler, ok := c.NodeScaler.(*BindingNodeScaler); ok {
+		dumpStruct.WarmPools = scaler.WarmPoolOccupancy()
+	}
+	b, err := json.MarshalIndent(dumpStruct, "", "    ")
 	if err != nil {
 		klog.Errorln("Error dumping data from NodeController", err)
 		return nil
@@ -107,6 +238,18 @@ func (c *NodeController) Dump() []byte {
 	return b
 }
 
+func (c *NodeController) getEstimatedSpend() float64 {
+	c.spendLock.Lock()
+	defer c.spendLock.Unlock()
+	return c.estimatedSpend
+}
+
+func (c *NodeController) setEstimatedSpend(spend float64) {
+	c.spendLock.Lock()
+	defer c.spendLock.Unlock()
+	c.estimatedSpend = spend
+}
+
 func (c *NodeController) updateBufferedNodesLoop(quit <-chan struct{}, wg *sync.WaitGroup, nodeBindingsUpdate chan map[string]string) {
 	wg.Add(1)
 	defer wg.Done()
@@ -153,7 +296,7 @@ func (c *NodeController) doPoolsCalculation() (map[string]string, error) {
 	}
 
 	// If we can't get the boot image, just use the old value for the image
-	newBootImage, err := c.imageSpecToImage(c.BootImageSpec)
+	newBootImage, err := c.resolveBootImage()
 	if err != nil {
 		if BootImage.ID == "" {
 			return nil, util.WrapError(err, "Could not get latest boot image")
@@ -171,8 +314,12 @@ func (c *NodeController) doPoolsCalculation() (map[string]string, error) {
 	if podNodeMap == nil {
 		return nil, fmt.Errorf("Error computing new node pools, this is likely a problem with the DB. Not updating pod-node bindings")
 	}
+	startNodes = c.enforceSpendBudget(nodes.Items, startNodes, pods.Items)
 	c.startNodes(startNodes, BootImage)
 	for _, node := range stopNodes {
+		if c.nodeTooYoungToStop(node) {
+			continue
+		}
 		err := c.stopSingleNode(node)
 		if err != nil {
 			klog.Warningln("Error stopping single node", err)
@@ -182,7 +329,72 @@ func (c *NodeController) doPoolsCalculation() (map[string]string, error) {
 	return podNodeMap, nil
 }
 
-func (c *NodeController) getInstanceCloudInit() error {
+// nodeSpend returns the estimated cost of a node over windowHours
+// hours, from the instanceselector's on-demand pricing data.  Nodes
+// with instance types missing from the pricing data cost zero so an
+// incomplete pricing table fails open instead of blocking launches.
+func nodeSpend(node *api.Node, windowHours float64) float64 {
+	price, ok := instanceselector.InstancePrice(node.Spec.InstanceType)
+	if !ok {
+		return 0.0
+	}
+	return float64(price) * windowHours
+}
+
+// estimateSpend sums the estimated cost over windowHours hours of all
+// nodes that are running or on their way to running.
+func estimateSpend(nodes []*api.Node, windowHours float64) float64 {
+	spend := 0.0
+	for _, node := range nodes {
+		if node.Status.Phase == api.NodeTerminating ||
+			node.Status.Phase == api.NodeTerminated {
+			continue
+		}
+		spend += nodeSpend(node, windowHours)
+	}
+	return spend
+}
+
+// enforceSpendBudget trims the list of nodes to start so the estimated
+// spend of our nodes over the accounting window stays under the
+// configured budget.  Pods whose node got trimmed get a
+// budget-exceeded event and stay waiting until spend drops back under
+// the budget.  The spend estimate reported by Dump is updated along
+// the way.
+func (c *NodeController) enforceSpendBudget(existingNodes []*api.Node, startNodes []*api.Node, pods []*api.Pod) []*api.Node {
+	windowHours := c.Config.BudgetWindowHours
+	if windowHours <= 0 {
+		windowHours = 1.0
+	}
+	spend := estimateSpend(existingNodes, windowHours)
+	if c.Config.BudgetMaxSpend <= 0 {
+		c.setEstimatedSpend(spend)
+		return startNodes
+	}
+	podsByName := make(map[string]*api.Pod, len(pods))
+	for _, pod := range pods {
+		podsByName[pod.Name] = pod
+	}
+	allowed := make([]*api.Node, 0, len(startNodes))
+	for _, node := range startNodes {
+		cost := nodeSpend(node, windowHours)
+		if spend+cost > c.Config.BudgetMaxSpend {
+			msg := fmt.Sprintf("Starting a %s node would bring estimated spend to %.4f, over the budget of %.4f",
+				node.Spec.InstanceType, spend+cost, c.Config.BudgetMaxSpend)
+			klog.Warningln(msg)
+			if pod := podsByName[node.Status.BoundPodName]; pod != nil {
+				c.Events.Emit(events.BudgetExceeded, "node-controller", pod, msg)
+			}
+			continue
+		}
+		spend += cost
+		allowed = append(allowed, node)
+	}
+	c.setEstimatedSpend(spend)
+	return allowed
+}
+
+func (c *NodeController) getInstanceCloudInit(itzoVersion, itzoURL string) error {
 	cert, key, err := c.CertificateFactory.CreateNodeCertAndKey()
 	if err != nil {
 		return util.WrapError(err, "Error creating node cert")
@@ -207,16 +419,20 @@ func (c *NodeController) getInstanceCloudInit() error {
 		string(certBytes), path.Join(itzoDir, "server.crt"), "0644")
 	c.CloudInitFile.AddKipFile(
 		string(keyBytes), path.Join(itzoDir, "server.key"), "0600")
-	c.CloudInitFile.AddItzoVersion(c.Config.ItzoVersion)
-	c.CloudInitFile.AddItzoURL(c.Config.ItzoURL)
+	c.CloudInitFile.AddItzoVersion(itzoVersion)
+	c.CloudInitFile.AddItzoURL(itzoURL)
 	if len(c.Config.CellConfig) > 0 {
 		c.CloudInitFile.AddCellConfig(c.Config.CellConfig)
 	}
+	if len(c.Config.RegistryMirrors) > 0 {
+		c.CloudInitFile.AddRegistryMirrors(
+			c.Config.RegistryMirrors, c.Config.InsecureRegistryMirrors)
+	}
 	return nil
 }
 
-func (c *NodeController) getCloudInitContents() (string, error) {
-	err := c.getInstanceCloudInit()
+func (c *NodeController) getCloudInitContents(itzoVersion, itzoURL string) (string, error) {
+	err := c.getInstanceCloudInit(itzoVersion, itzoURL)
 	if err != nil {
 		return "", util.WrapError(
 			err, "Error creating Kip instance keys for cloud-init data")
@@ -229,15 +445,41 @@ func (c *NodeController) getCloudInitContents() (string, error) {
 	return metadata, nil
 }
 
+// pickItzoVersion returns the itzo version and download URL for the
+// next node launch: the canary build if the roll (0-99) falls inside
+// the configured canary percentage, otherwise the regular build.
+func pickItzoVersion(cfg NodeControllerConfig, roll int) (version, url string, canary bool) {
+	if cfg.ItzoCanaryVersion != "" && roll < cfg.ItzoCanaryPercent {
+		url = cfg.ItzoCanaryURL
+		if url == "" {
+			url = cfg.ItzoURL
+		}
+		return cfg.ItzoCanaryVersion, url, true
+	}
+	return cfg.ItzoVersion, cfg.ItzoURL, false
+}
+
 func (c *NodeController) startNodes(nodes []*api.Node, image cloud.Image) {
 	if len(nodes) <= 0 {
 		return
 	}
-	metadata, err := c.getCloudInitContents()
+	metadata, err := c.getCloudInitContents(c.Config.ItzoVersion, c.Config.ItzoURL)
 	if err != nil {
-		klog.Errorf("Error creating node metadata: %s", err)
+		c.Log.Errorf("Error creating node metadata: %s", err)
 		return
 	}
+	canaryMetadata := ""
+	if c.Config.ItzoCanaryVersion != "" {
+		canaryURL := c.Config.ItzoCanaryURL
+		if canaryURL == "" {
+			canaryURL = c.Config.ItzoURL
+		}
+		canaryMetadata, err = c.getCloudInitContents(c.Config.ItzoCanaryVersion, canaryURL)
+		if err != nil {
+			c.Log.Errorf("Error creating canary node metadata: %s", err)
+			return
+		}
+	}
 	// Randomize boot order to prevent getting stuck with 10 nodes at
 	// the start of the boot list that can't be booted for some reason
 	if len(nodes) > MaxBootPerIteration {
@@ -251,16 +493,38 @@ func (c *NodeController) startNodes(nodes []*api.Node, image cloud.Image) {
 			klog.V(2).Infof("Rate limiting start requests to %d per iteration", MaxBootPerIteration)
 			break
 		}
+		version, _, canary := pickItzoVersion(c.Config, rand.Intn(100))
+		nodeMetadata := metadata
+		if canary {
+			nodeMetadata = canaryMetadata
+		}
+		newNode.Spec.ItzoVersion = version
 		newNode, err := c.NodeRegistry.CreateNode(newNode)
 		if err != nil {
-			klog.Errorf("Error creating node in registry: %v", err)
+			c.Log.Errorf("Error creating node in registry: %v", err)
+			continue
+		}
+		reason := "for buffered capacity"
+		if newNode.Status.BoundPodName != "" {
+			reason = fmt.Sprintf("for pod %s", newNode.Status.BoundPodName)
+		}
+		if instanceID, ok := c.claimStoppedInstance(newNode); ok {
+			c.AuditTrail.Record("node-controller", newNode.Name, audit.DecisionStartNode,
+				fmt.Sprintf("restarting stopped %s instance %s %s",
+					newNode.Spec.InstanceType, instanceID, reason))
+			go c.resumeSingleNode(newNode, instanceID)
 			continue
 		}
-		go c.startSingleNode(newNode, image, metadata)
+		c.AuditTrail.Record("node-controller", newNode.Name, audit.DecisionStartNode,
+			fmt.Sprintf("starting %s node %s", newNode.Spec.InstanceType, reason))
+		go c.startSingleNode(newNode, image, nodeMetadata)
 	}
 }
 
 func (c *NodeController) handleStartNodeError(node *api.Node, err error, isSpot bool) {
+	c.AuditTrail.Record("node-controller", node.Name, audit.DecisionLaunchFailed,
+		fmt.Sprintf("could not launch %s instance, the scaler will retry: %v",
+			node.Spec.InstanceType, err))
 	switch err := err.(type) {
 	case *cloud.NoCapacityError:
 		if err.AZ != "" {
@@ -275,56 +539,270 @@ func (c *NodeController) handleStartNodeError(node *api.Node, err error, isSpot
 		// with this but I hesitate to do that, instead lets push that
 		// off to the operator for now.
 		c.CloudStatus.AddUnavailableInstance(node.Spec.InstanceType, true)
+	case *cloud.QuotaError:
+		// An account quota blocks every launch of this type; steer
+		// the scaler away from it like a region-wide capacity
+		// shortage until the quota is raised or usage drops.
+		c.CloudStatus.AddUnavailableInstance(node.Spec.InstanceType, isSpot)
+	case *cloud.ThrottledError:
+		// Transient, the scaler retries on its next iteration.
+		klog.V(2).Infof("Cloud API throttled launching node %s, will retry: %v",
+			node.Name, err)
+	case *cloud.AuthError:
+		// Retrying won't help; every launch will fail until the
+		// operator fixes the credentials.
+		klog.Errorf("Cloud credentials were rejected launching node %s: %v",
+			node.Name, err)
+	}
+}
+
+// tryFallbackInstanceTypes walks the instance selector's fallback
+// ladder after the node's instance type was rejected for lack of
+// capacity, trying each next-best-fitting type in turn.  The type
+// that ultimately launched is recorded on the node object (and from
+// there in its labels), so operators can see which rung of the
+// ladder a cell ended up on.  Errors other than capacity errors stop
+// the ladder; the last error is returned if no rung launches.
+func (c *NodeController) tryFallbackInstanceTypes(node *api.Node, image cloud.Image, cloudInitData string, origErr error, log *structlog.Logger) (*cloud.StartNodeResult, error) {
+	if _, noCapacity := origErr.(*cloud.NoCapacityError); !noCapacity {
+		return nil, origErr
+	}
+	originalType := node.Spec.InstanceType
+	err := origErr
+	for _, instanceType := range instanceselector.FallbackInstanceTypes(originalType) {
+		// Record the type that had no capacity so the scaler steers
+		// around it, then try the next rung of the ladder.
+		c.handleStartNodeError(node, err, node.Spec.Spot)
+		log.Warningf("No capacity for instance type %s, falling back to %s: %v",
+			node.Spec.InstanceType, instanceType, err)
+		node.Spec.InstanceType = instanceType
+		c.AuditTrail.Record("node-controller", node.Name, audit.DecisionStartNode,
+			fmt.Sprintf("retrying with fallback instance type %s, no capacity for %s",
+				instanceType, originalType))
+		var startResult *cloud.StartNodeResult
+		if node.Spec.Spot {
+			startResult, err = c.CloudClient.StartSpotNode(node, image, cloudInitData)
+		} else {
+			startResult, err = c.CloudClient.StartNode(node, image, cloudInitData)
+		}
+		if err == nil {
+			if _, regErr := c.NodeRegistry.UpdateNode(node); regErr != nil {
+				log.Warningf("Error recording fallback instance type %s on node %s: %v",
+					instanceType, node.Name, regErr)
+			}
+			return startResult, nil
+		}
+		if _, noCapacity := err.(*cloud.NoCapacityError); !noCapacity {
+			break
+		}
+	}
+	return nil, err
+}
+
+// registerLaunch tracks an in-flight node launch under the name of
+// the pod the node is bound to, so it can be listed and canceled.
+func (c *NodeController) registerLaunch(podName string, node *api.Node, cancel context.CancelFunc) {
+	c.launchLock.Lock()
+	defer c.launchLock.Unlock()
+	if c.inFlightLaunches == nil {
+		c.inFlightLaunches = make(map[string]*inFlightLaunch)
+	}
+	c.inFlightLaunches[podName] = &inFlightLaunch{
+		cancel:           cancel,
+		nodeName:         node.Name,
+		instanceType:     node.Spec.InstanceType,
+		availabilityZone: node.Spec.Placement.AvailabilityZone,
+		startedAt:        time.Now(),
+	}
+}
+
+func (c *NodeController) deregisterLaunch(podName string) {
+	c.launchLock.Lock()
+	defer c.launchLock.Unlock()
+	delete(c.inFlightLaunches, podName)
+}
+
+// ListInFlightLaunches returns the launches that are currently in
+// flight, sorted by pod name.
+func (c *NodeController) ListInFlightLaunches() []LaunchStatus {
+	c.launchLock.Lock()
+	defer c.launchLock.Unlock()
+	launches := make([]LaunchStatus, 0, len(c.inFlightLaunches))
+	for podName, launch := range c.inFlightLaunches {
+		launches = append(launches, LaunchStatus{
+			Pod:              podName,
+			Node:             launch.nodeName,
+			InstanceType:     launch.instanceType,
+			AvailabilityZone: launch.availabilityZone,
+			Elapsed:          time.Since(launch.startedAt).Round(time.Second).String(),
+		})
+	}
+	sort.Slice(launches, func(i, j int) bool {
+		return launches[i].Pod < launches[j].Pod
+	})
+	return launches
+}
+
+// CancelLaunch cancels the in-flight node launch for the named pod.
+// The launch goroutine terminates any partially created instance and
+// the pod goes back to waiting for a node (or fails if it was
+// deleted).
+func (c *NodeController) CancelLaunch(podName string) error {
+	c.launchLock.Lock()
+	launch := c.inFlightLaunches[podName]
+	c.launchLock.Unlock()
+	if launch == nil {
+		return fmt.Errorf("no in-flight node launch found for pod %s", podName)
+	}
+	klog.V(2).Infof("Canceling in-flight node launch for pod %s", podName)
+	launch.cancel()
+	return nil
+}
+
+// cancelPodLaunch cancels the in-flight node launch for a deleted
+// pod, if there is one.  The launch goroutine terminates any
+// partially created instance.
+func (c *NodeController) cancelPodLaunch(e events.Event) error {
+	pod, ok := e.Object.(*api.Pod)
+	if !ok {
+		return fmt.Errorf("event object is not a pod: %v", e.Object)
 	}
+	c.launchLock.Lock()
+	launch := c.inFlightLaunches[pod.Name]
+	c.launchLock.Unlock()
+	if launch != nil {
+		klog.V(2).Infof("Canceling in-flight node launch for deleted pod %s", pod.Name)
+		launch.cancel()
+	}
+	return nil
 }
 
 func (c *NodeController) startSingleNode(node *api.Node, image cloud.Image, cloudInitData string) error {
+	// All messages from one node boot share a request ID so they can
+	// be correlated in a log pipeline.
+	log := c.Log.WithRequestID().With("node", node.Name).With("instanceType", node.Spec.InstanceType)
+	log.Debugf("starting node %s", node.Name)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if podName := node.Status.BoundPodName; podName != "" {
+		c.registerLaunch(podName, node, cancel)
+		defer c.deregisterLaunch(podName)
+	}
+	// Nodes are booted ahead of pod demand so a boot gets its own
+	// trace instead of joining a pod's.
+	ctx, span := octrace.StartSpan(ctx, "StartNode")
+	defer span.End()
+	span.AddAttributes(
+		octrace.StringAttribute("node", node.Name),
+		octrace.StringAttribute("instanceType", node.Spec.InstanceType))
 	var (
 		startResult *cloud.StartNodeResult
 		err         error
 	)
+	_, launchSpan := octrace.StartSpan(ctx, "CloudAPI.RunInstances")
 	if node.Spec.Spot {
 		startResult, err = c.CloudClient.StartSpotNode(node, image, cloudInitData)
 	} else {
 		startResult, err = c.CloudClient.StartNode(node, image, cloudInitData)
 	}
+	launchSpan.End()
+	if err != nil && ctx.Err() == nil {
+		startResult, err = c.tryFallbackInstanceTypes(node, image, cloudInitData, err, log)
+	}
 	if err != nil {
 		c.handleStartNodeError(node, err, false)
-		klog.Errorf("Error in node start: %v", err)
+		log.Errorf("Error in node start: %v", err)
 		_, regError := c.NodeRegistry.PurgeNode(node)
 		if regError != nil {
-			klog.Errorf("Error marking node %s terminated after failed start: %s",
+			log.Errorf("Error marking node %s terminated after failed start: %s",
 				node.Name, regError.Error())
 		}
 		return util.WrapError(err, "Error starting node")
 	}
 	node.Status.InstanceID = startResult.InstanceID
+	node.Status.LaunchedAt = api.Now()
 	node.Spec.Placement.AvailabilityZone = startResult.AvailabilityZone
-	return c.finishNodeStart(node)
+	if ctx.Err() != nil {
+		log.Infof("launch of node %s canceled, its pod was deleted; terminating instance", node.Name)
+		return c.stopSingleNode(node)
+	}
+	return c.finishNodeStart(ctx, node, log)
+}
+
+// cloudMetadataLabels derives the node's labels from the cloud
+// metadata we know once the instance has launched: instance type,
+// availability zone, spot vs on-demand capacity and the boot image.
+// The zone and instance type use the standard kubernetes label keys
+// so topology-aware scheduling can match against them.
+func cloudMetadataLabels(node *api.Node) map[string]string {
+	capacityType := labels.CapacityTypeOnDemand
+	if node.Spec.Spot {
+		capacityType = labels.CapacityTypeSpot
+	}
+	nodeLabels := map[string]string{
+		labels.NodeInstanceType: node.Spec.InstanceType,
+		labels.NodeCapacityType: capacityType,
+	}
+	if node.Spec.BootImage != "" {
+		nodeLabels[labels.NodeBootImage] = node.Spec.BootImage
+	}
+	if az := node.Spec.Placement.AvailabilityZone; az != "" {
+		nodeLabels[labels.NodeZone] = az
+	}
+	return nodeLabels
 }
 
-func (c *NodeController) finishNodeStart(node *api.Node) error {
+func (c *NodeController) finishNodeStart(ctx context.Context, node *api.Node, log *structlog.Logger) error {
+	node.Labels = cloudMetadataLabels(node)
+	if _, err := c.NodeRegistry.SetNodeLabels(node, node.Labels); err != nil {
+		log.Warningf("Error storing cloud metadata labels on node %s: %v",
+			node.Name, err)
+	}
 	node.Status.Phase = api.NodeCreated
 	_, _ = c.NodeRegistry.UpdateStatus(node)
 	c.Events.Emit(events.NodeCreated, "node-created", node, "")
 	// todo: we know the instance is running, we could just do
 	// a describe instance here...
+	_, waitSpan := octrace.StartSpan(ctx, "CloudAPI.WaitForRunning")
 	addresses, err := c.CloudClient.WaitForRunning(node)
+	waitSpan.End()
 	if err != nil {
-		klog.V(2).Infof("Unhealthy wait for running, terminating node: %s", node.Name)
+		log.Debugf("Unhealthy wait for running, terminating node: %s", node.Name)
 		_ = c.stopSingleNode(node)
 		return util.WrapError(err, "Error waiting for node to be running")
 	}
+	if ctx.Err() != nil {
+		log.Infof("launch of node %s canceled, its pod was deleted; terminating instance", node.Name)
+		return c.stopSingleNode(node)
+	}
 	c.Events.Emit(events.NodeRunning, "node-controller", node, "")
 	node.Status.Addresses = addresses
 	_, _ = c.NodeRegistry.UpdateStatus(node)
-	return c.waitForAvailableOrTerminate(node, BootTimeout)
+	_, bootSpan := octrace.StartSpan(ctx, "itzo.Bootstrap")
+	err = c.waitForAvailableOrTerminate(node, c.bootTimeout())
+	bootSpan.End()
+	return err
+}
+
+func (c *NodeController) bootTimeout() time.Duration {
+	if c.Config.BootTimeout > 0 {
+		return c.Config.BootTimeout
+	}
+	return BootTimeout
+}
+
+func (c *NodeController) cloudInitTimeout() time.Duration {
+	if c.Config.CloudInitTimeout > 0 {
+		return c.Config.CloudInitTimeout
+	}
+	return CloudInitTimeout
 }
 
 func (c *NodeController) stopSingleNode(node *api.Node) error {
 	// to keep counts in sync, don't move this inside the goroutine
 	klog.V(2).Infof("Stopping node: %s", node.Name)
 
+	reusable := c.nodeReusable(node)
 	node.Status.Phase = api.NodeTerminating
 	_, err := c.NodeRegistry.UpdateStatus(node)
 	if err != nil {
@@ -333,8 +811,20 @@ func (c *NodeController) stopSingleNode(node *api.Node) error {
 		return err
 	}
 	c.NodeClientFactory.DeleteClient(node.Status.Addresses)
+	c.AuditTrail.Record("node-controller", node.Name, audit.DecisionStopNode,
+		fmt.Sprintf("stopping %s node no longer needed by the scaler",
+			node.Spec.InstanceType))
 	go func(n *api.Node) {
-		_ = c.CloudClient.StopInstance(n.Status.InstanceID)
+		if !reusable || !c.stashStoppedInstance(n) {
+			err := c.CloudClient.StopInstance(n.Status.InstanceID)
+			if err != nil && !cloud.IsNotFoundError(err) {
+				// An instance that's already gone is fine, anything
+				// else is left to the garbage controller's sweep of
+				// orphaned instances.
+				klog.Warningf("Error terminating instance %s of node %s: %v",
+					n.Status.InstanceID, n.Name, err)
+			}
+		}
 		_, err := c.NodeRegistry.PurgeNode(node)
 		if err != nil {
 			klog.Errorf("Could not mark node %s as terminated: %v", n.Name, err)
@@ -351,6 +841,7 @@ func (c *NodeController) runHeartbeatsLoop(quit <-chan struct{}, wg *sync.WaitGr
 	defer ticker.Stop()
 	heartbeats := make(chan string)
 	LastHeartbeat := make(map[string]time.Time)
+	lastPersisted := make(map[string]time.Time)
 	for {
 		// Might want to add some timing jitter
 
@@ -381,8 +872,14 @@ func (c *NodeController) runHeartbeatsLoop(quit <-chan struct{}, wg *sync.WaitGr
 				klog.Error(err.Error())
 			}
 			pruneHeartbeats(allNodes, LastHeartbeat)
+			pruneHeartbeats(allNodes, lastPersisted)
 		case nodeName := <-heartbeats:
-			LastHeartbeat[nodeName] = time.Now().UTC()
+			now := time.Now().UTC()
+			LastHeartbeat[nodeName] = now
+			if heartbeatNeedsPersisting(lastPersisted[nodeName], now) {
+				c.persistHeartbeat(nodeName, now)
+				lastPersisted[nodeName] = now
+			}
 		case <-quit:
 			return
 		}
@@ -460,7 +957,7 @@ func (c *NodeController) ResumeWaits() {
 					_, _ = c.NodeRegistry.UpdateStatus(node)
 				}
 			}
-			return c.waitForAvailableOrTerminate(node, BootTimeout)
+			return c.waitForAvailableOrTerminate(node, c.bootTimeout())
 		}(node)
 	}
 }
@@ -510,6 +1007,32 @@ func pruneHeartbeats(allNodes *api.NodeList, lastHeartbeat map[string]time.Time)
 	}
 }
 
+// heartbeatNeedsPersisting reports whether enough time has passed
+// since a node's last heartbeat write that we should record this one
+// in the registry.
+func heartbeatNeedsPersisting(lastPersisted, now time.Time) bool {
+	return now.Sub(lastPersisted) >= HeartbeatPersistInterval
+}
+
+// persistHeartbeat records the time of a node's last successful
+// heartbeat in the registry so it shows up in the node's status.
+// Per the note in runHeartbeatsLoop, node health doesn't need to hit
+// the datastore every tick so this is only called at a coarse
+// interval.
+func (c *NodeController) persistHeartbeat(nodeName string, now time.Time) {
+	node, err := c.NodeRegistry.GetNode(nodeName)
+	if err != nil {
+		klog.Warningf("Could not get node %s to record heartbeat time: %v",
+			nodeName, err)
+		return
+	}
+	node.Status.LastHeartbeatAt = api.Time{Time: now}
+	if _, err := c.NodeRegistry.UpdateStatus(node); err != nil {
+		klog.Warningf("Could not record heartbeat time for node %s: %v",
+			nodeName, err)
+	}
+}
+
 func singleNodeHeartbeat(node *api.Node, client nodeclient.NodeClient, healthyReply chan string) {
 	err := client.Healthcheck()
 	if err != nil {
@@ -535,7 +1058,32 @@ func (c *NodeController) waitForAvailableOrTerminate(node *api.Node, timeout tim
 		_ = c.stopSingleNode(node)
 		return util.WrapError(err, "Error waiting for healthy node")
 	}
+	if version, err := client.GetVersion(); err != nil {
+		// Old itzo builds don't serve the version endpoint, don't
+		// fail the boot over it.
+		klog.Warningf("Could not get itzo version from node %s: %v", node.Name, err)
+	} else {
+		node.Status.ItzoVersion = version
+		if itzoVersionMismatch(node.Spec.ItzoVersion, version) {
+			msg := fmt.Sprintf("Node %s runs itzo %s but was launched with itzo %s; terminating so the scaler relaunches it with the right agent",
+				node.Name, version, node.Spec.ItzoVersion)
+			klog.Errorf("%s", msg)
+			c.Events.Emit(events.NodeVersionMismatch, "node-controller", node, msg)
+			_ = c.stopSingleNode(node)
+			return fmt.Errorf("itzo version mismatch on node %s: wanted %s, got %s",
+				node.Name, node.Spec.ItzoVersion, version)
+		}
+	}
+	if err := waitForBootstrap(node, client, c.cloudInitTimeout()); err != nil {
+		msg := fmt.Sprintf("Bootstrap failed on node %s: %v", node.Name, err)
+		klog.Errorf("%s", msg)
+		c.Events.Emit(events.NodeCloudInitFailed, "node-controller", node, msg)
+		klog.V(2).Infof("Terminating node: %s", node.Name)
+		_ = c.stopSingleNode(node)
+		return util.WrapError(err, "Error waiting for node bootstrap")
+	}
 	node.Status.Phase = api.NodeAvailable
+	node.Status.ReadyAt = api.Now()
 	_, err = c.NodeRegistry.UpdateStatus(node)
 	if err != nil {
 		klog.Errorf("Error setting node %s to available,", node.Name)
@@ -546,6 +1094,47 @@ func (c *NodeController) waitForAvailableOrTerminate(node *api.Node, timeout tim
 	return nil
 }
 
+// waitForBootstrap waits for itzo to report that the cell's
+// cloud-init bootstrap finished.  A reported cloud-init error fails
+// the boot immediately so the user sees the error text instead of a
+// mysterious boot timeout.  Old itzo builds don't serve the bootstrap
+// endpoint; nodes running them pass the check so kip keeps working
+// against existing cell images.
+func waitForBootstrap(node *api.Node, client nodeclient.NodeClient, timeout time.Duration) error {
+	giveUp := time.Now().UTC().Add(timeout)
+	for {
+		status, err := client.GetBootstrapStatus()
+		if err != nil {
+			klog.V(4).Infof("Could not get bootstrap status from node %s: %v",
+				node.Name, err)
+			return nil
+		}
+		if status.Error != "" {
+			return fmt.Errorf("cloud-init failed: %s", status.Error)
+		}
+		if status.Healthy {
+			return nil
+		}
+		if time.Now().UTC().After(giveUp) {
+			return fmt.Errorf("cloud-init did not finish within %s", timeout)
+		}
+		time.Sleep(HealthcheckPause)
+	}
+}
+
+// itzoVersionMismatch reports whether the itzo version a node reports
+// is incompatible with the version it was launched with.  An agent
+// from a partial upgrade would otherwise fail calls cryptically later.
+// We can only judge a pinned launch version: "latest" resolves at boot
+// time and an empty report means an old itzo without the version
+// endpoint, neither is evidence of a mismatch.
+func itzoVersionMismatch(wanted, got string) bool {
+	if wanted == "" || wanted == "latest" || got == "" {
+		return false
+	}
+	return strings.TrimPrefix(wanted, "v") != strings.TrimPrefix(got, "v")
+}
+
 func waitForHealthy(node *api.Node, client nodeclient.NodeClient, timeout time.Duration) error {
 	success := false
 	giveUp := time.Now().UTC().Add(timeout)
@@ -574,7 +1163,7 @@ func filterNodes(allNodes *api.NodeList, pred func(*api.Node) bool) []*api.Node
 }
 
 // Goes through and stops nodes that we have asked to be terminated
-func (c NodeController) reaperLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
+func (c *NodeController) reaperLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 	wg.Add(1)
 	defer wg.Done()
 
@@ -596,6 +1185,9 @@ func (c NodeController) reaperLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 				}
 				go c.stopSingleNode(node)
 			}
+			c.reapStuckBootingNodes()
+			c.pruneStoppedPool()
+			c.recycleAgedNodes()
 		case <-quit:
 			ticker.Stop()
 			return
@@ -603,6 +1195,32 @@ func (c NodeController) reaperLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 	}
 }
 
+// Terminate nodes that never became available within the boot
+// timeout (bad boot image, no network path to itzo, ...).  The
+// in-process boot wait usually catches these but that wait is lost if
+// the controller restarts mid-boot, which would leak the instance.
+func (c *NodeController) reapStuckBootingNodes() {
+	timeout := c.bootTimeout()
+	cutoff := time.Now().UTC().Add(-timeout)
+	nodes, err := c.NodeRegistry.ListNodes(func(n *api.Node) bool {
+		return ((n.Status.Phase == api.NodeCreating ||
+			n.Status.Phase == api.NodeCreated) &&
+			n.CreationTimestamp.Time.Before(cutoff))
+	})
+	if err != nil {
+		klog.Errorf("Error listing nodes for boot timeout check: %s", err.Error())
+		return
+	}
+	for _, node := range nodes.Items {
+		c.Events.Emit(events.NodeBootTimeout, "node-controller", node,
+			"Node %s did not boot within %s, terminating", node.Name, timeout)
+		if node.Status.BoundPodName != "" {
+			c.removePodFromNode(node)
+		}
+		go c.stopSingleNode(node)
+	}
+}
+
 func (c *NodeController) removePodFromNode(node *api.Node) {
 	pod, err := c.PodReader.GetPod(node.Status.BoundPodName)
 	if err != nil {
@@ -617,6 +1235,15 @@ func (c *NodeController) removePodFromNode(node *api.Node) {
 	c.Events.Emit(
 		events.PodEjected, "node-controller", pod,
 		"Pod %s was found on terminating node %s. Ejecting pod", pod.Name, node.Name)
+	if node.Spec.Spot && node.Status.Phase == api.NodeClaimed {
+		// A claimed spot node going away underneath its pod is (most
+		// likely) the cloud reclaiming the instance.  The pod
+		// controller counts these so spot-preferred pods can fall
+		// back to on-demand nodes.
+		c.Events.Emit(
+			events.PodSpotInterrupted, "node-controller", pod,
+			"Spot node %s running pod %s was reclaimed", node.Name, pod.Name)
+	}
 
 	node.Status.BoundPodName = ""
 	_, err = c.NodeRegistry.UpdateStatus(node)
@@ -689,6 +1316,11 @@ func (c *NodeController) cleanUnusedNode(name string) {
 }
 
 func (c *NodeController) requestNode(nodeReq NodeRequest, podNodeMapping map[string]string) NodeReply {
+	// The work item carries the requesting pod, whose annotations hold
+	// the trace context of the CreatePod call; join that trace here.
+	_, span := traceutil.StartSpanWithRemoteParent(context.Background(),
+		"DispenseNode", nodeReq.requestingPod.Annotations[annotations.PodTraceContext])
+	defer span.End()
 	// look up node
 	boundNodeName := podNodeMapping[nodeReq.requestingPod.Name]
 	if boundNodeName == "" {
@@ -709,11 +1341,29 @@ func (c *NodeController) requestNode(nodeReq NodeRequest, podNodeMapping map[str
 		klog.Errorln("Error binding pod to available node", err)
 		return NodeReply{}
 	}
+	span.AddAttributes(octrace.StringAttribute("node", boundNode.Name))
 	return NodeReply{
 		Node: boundNode,
 	}
 }
 
+// resolveBootImage returns the image new cells boot from.  A
+// configured fast boot image (a pre-baked snapshot with itzo and
+// common container images already installed) is preferred; when it
+// doesn't resolve, cells fall back to the base boot image.  The image
+// a node booted from is recorded in its Spec.BootImage.
+func (c *NodeController) resolveBootImage() (cloud.Image, error) {
+	if len(c.FastBootImageSpec) > 0 {
+		img, err := c.imageSpecToImage(c.FastBootImageSpec)
+		if err == nil && img.ID != "" {
+			return img, nil
+		}
+		klog.Warningf("Could not resolve fast boot image spec %v, falling back to boot image spec %v: %v",
+			c.FastBootImageSpec, c.BootImageSpec, err)
+	}
+	return c.imageSpecToImage(c.BootImageSpec)
+}
+
 func (c *NodeController) imageSpecToImage(spec cloud.BootImageSpec) (cloud.Image, error) {
 	var img cloud.Image
 	obj, exists := c.ImageIdCache.Get(spec.String())
@@ -748,7 +1398,7 @@ func (c *NodeController) saveNodeLogs(node *api.Node) {
 	klog.V(2).Infof("Saving node logs")
 	filename := "/var/log/itzo/itzo.log"
 	client := c.NodeClientFactory.GetClient(node.Status.Addresses)
-	data, err := client.GetFile(filename, 0, nodeclient.SAVE_LOG_BYTES)
+	data, err := client.GetFile(filename, nodeclient.TailRange(0, nodeclient.SAVE_LOG_BYTES))
 	if err != nil {
 		klog.Errorf("Error saving node %s log: %s", node.Name, err.Error())
 		return
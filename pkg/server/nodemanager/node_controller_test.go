@@ -17,6 +17,7 @@ limitations under the License.
 package nodemanager
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -25,13 +26,16 @@ import (
 	"time"
 
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/labels"
 	"github.com/elotl/kip/pkg/certs"
 	"github.com/elotl/kip/pkg/nodeclient"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/events"
 	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util/cloudinitfile"
+	"github.com/elotl/kip/pkg/util/instanceselector"
 	"github.com/elotl/kip/pkg/util/stats"
+	"github.com/elotl/kip/pkg/util/structlog"
 	"github.com/elotl/kip/pkg/util/timeoutmap"
 	"github.com/stretchr/testify/assert"
 )
@@ -126,6 +130,7 @@ func MakeNodeController() (*NodeController, func()) {
 		CloudInitFile:      ciFile,
 		CloudStatus:        cloudStatus,
 		BootImageSpec:      defaultBootImageSpec,
+		Log:                structlog.New("node-controller"),
 	}
 	return nc, closer
 }
@@ -180,6 +185,24 @@ func TestStopSingleNode(t *testing.T) {
 	assert.Equal(t, api.NodeTerminated, nodes.Items[0].Status.Phase)
 }
 
+func TestReapStuckBootingNodes(t *testing.T) {
+	t.Parallel()
+	ctl, closer := MakeNodeController()
+	defer closer()
+	ctl.Config.BootTimeout = 1 * time.Millisecond
+	booting := makeTestOndemandNode(t, ctl, api.NodeCreated)
+	available := makeTestOndemandNode(t, ctl, api.NodeAvailable)
+	time.Sleep(10 * time.Millisecond)
+	ctl.reapStuckBootingNodes()
+	time.Sleep(1 * time.Second)
+	n, err := ctl.NodeRegistry.GetNode(booting.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, api.NodeTerminated, n.Status.Phase)
+	n, err = ctl.NodeRegistry.GetNode(available.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, api.NodeAvailable, n.Status.Phase)
+}
+
 func StartAFewNodes(t *testing.T, numNodes int, spotNode bool) {
 	ctl, closer := MakeNodeController()
 	defer closer()
@@ -211,6 +234,106 @@ func TestStartNodes(t *testing.T) {
 	StartAFewNodes(t, 1, true)
 }
 
+func TestPickItzoVersion(t *testing.T) {
+	t.Parallel()
+	cfg := NodeControllerConfig{
+		ItzoVersion: "v1.0.0",
+		ItzoURL:     "http://itzo-bucket",
+	}
+	// no canary configured, every roll gets the regular build
+	version, url, canary := pickItzoVersion(cfg, 0)
+	assert.Equal(t, "v1.0.0", version)
+	assert.Equal(t, "http://itzo-bucket", url)
+	assert.False(t, canary)
+
+	cfg.ItzoCanaryVersion = "v1.1.0"
+	cfg.ItzoCanaryPercent = 25
+	version, url, canary = pickItzoVersion(cfg, 24)
+	assert.Equal(t, "v1.1.0", version)
+	assert.Equal(t, "http://itzo-bucket", url)
+	assert.True(t, canary)
+	version, _, canary = pickItzoVersion(cfg, 25)
+	assert.Equal(t, "v1.0.0", version)
+	assert.False(t, canary)
+
+	cfg.ItzoCanaryURL = "http://canary-bucket"
+	_, url, _ = pickItzoVersion(cfg, 0)
+	assert.Equal(t, "http://canary-bucket", url)
+}
+
+func TestItzoVersionMismatch(t *testing.T) {
+	t.Parallel()
+	// matching versions, with or without the v prefix
+	assert.False(t, itzoVersionMismatch("v1.0.0", "v1.0.0"))
+	assert.False(t, itzoVersionMismatch("v1.0.0", "1.0.0"))
+	assert.False(t, itzoVersionMismatch("1.0.0", "v1.0.0"))
+	// an unpinned launch version or a missing report can't be judged
+	assert.False(t, itzoVersionMismatch("", "v1.0.0"))
+	assert.False(t, itzoVersionMismatch("latest", "v1.0.0"))
+	assert.False(t, itzoVersionMismatch("v1.0.0", ""))
+	// a different running version is a mismatch
+	assert.True(t, itzoVersionMismatch("v1.0.0", "v1.1.0"))
+}
+
+func makeSpendNode(instanceType string, phase api.NodePhase) *api.Node {
+	n := api.GetFakeNode()
+	n.Spec.InstanceType = instanceType
+	n.Status.Phase = phase
+	return n
+}
+
+func TestEstimateSpend(t *testing.T) {
+	// In us-east-1, m5.large is $0.096/hr and t2.nano is $0.0058/hr
+	err := instanceselector.Setup("aws", "us-east-1", "", defaultInstanceType, nil, nil)
+	assert.Nil(t, err)
+	nodes := []*api.Node{
+		makeSpendNode("m5.large", api.NodeAvailable),
+		makeSpendNode("t2.nano", api.NodeCreating),
+		// terminating and terminated nodes don't count towards spend
+		makeSpendNode("m5.large", api.NodeTerminating),
+		makeSpendNode("m5.large", api.NodeTerminated),
+		// unknown instance types cost zero
+		makeSpendNode("z9.mythical", api.NodeAvailable),
+	}
+	assert.InDelta(t, 0.1018, estimateSpend(nodes, 1.0), 0.0001)
+	assert.InDelta(t, 2.4432, estimateSpend(nodes, 24.0), 0.001)
+}
+
+func TestEnforceSpendBudget(t *testing.T) {
+	err := instanceselector.Setup("aws", "us-east-1", "", defaultInstanceType, nil, nil)
+	assert.Nil(t, err)
+	quit := make(chan struct{})
+	defer close(quit)
+	ctl := &NodeController{
+		Config: NodeControllerConfig{
+			BudgetMaxSpend: 0.15,
+		},
+		Events: events.NewEventSystem(quit, &sync.WaitGroup{}),
+	}
+	existing := []*api.Node{
+		makeSpendNode("m5.large", api.NodeAvailable),
+	}
+	startNodes := []*api.Node{
+		makeSpendNode("t2.nano", api.NodeCreating),
+		makeSpendNode("m5.large", api.NodeCreating),
+		makeSpendNode("t2.nano", api.NodeCreating),
+	}
+	// current spend is 0.096; both t2.nanos fit under the budget but
+	// another m5.large would put us over
+	allowed := ctl.enforceSpendBudget(existing, startNodes, nil)
+	assert.Len(t, allowed, 2)
+	for _, node := range allowed {
+		assert.Equal(t, "t2.nano", node.Spec.InstanceType)
+	}
+	assert.InDelta(t, 0.1076, ctl.getEstimatedSpend(), 0.0001)
+
+	// a zero budget disables enforcement but still records spend
+	ctl.Config.BudgetMaxSpend = 0.0
+	allowed = ctl.enforceSpendBudget(existing, startNodes, nil)
+	assert.Len(t, allowed, 3)
+	assert.InDelta(t, 0.096, ctl.getEstimatedSpend(), 0.0001)
+}
+
 func TestStartNodeHealthcheckFails(t *testing.T) {
 	t.Parallel()
 	HealthyTimeout = 500 * time.Millisecond
@@ -584,6 +707,44 @@ func TestImageSpecToImageFailure(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestResolveBootImage(t *testing.T) {
+	ctl, closer := MakeNodeController()
+	defer closer()
+	ctl.CloudClient = &cloud.MockCloudClient{
+		Starter:     StartReturnsOK,
+		SpotStarter: StartReturnsOK,
+		Stopper:     ReturnNil,
+		Waiter:      ReturnAddresses,
+		ImageGetter: func(spec cloud.BootImageSpec) (cloud.Image, error) {
+			switch spec["name"] {
+			case "elotl-fast-*":
+				return cloud.Image{ID: "fast-image-id"}, nil
+			case "elotl-base-*":
+				return cloud.Image{ID: "base-image-id"}, nil
+			}
+			return cloud.Image{}, fmt.Errorf("no image found for %v", spec)
+		},
+	}
+	ctl.BootImageSpec = cloud.BootImageSpec{"name": "elotl-base-*"}
+
+	// no fast boot image configured, cells boot from the base image
+	img, err := ctl.resolveBootImage()
+	assert.NoError(t, err)
+	assert.Equal(t, "base-image-id", img.ID)
+
+	// the fast boot image is preferred when it resolves
+	ctl.FastBootImageSpec = cloud.BootImageSpec{"name": "elotl-fast-*"}
+	img, err = ctl.resolveBootImage()
+	assert.NoError(t, err)
+	assert.Equal(t, "fast-image-id", img.ID)
+
+	// an unresolvable fast boot image falls back to the base image
+	ctl.FastBootImageSpec = cloud.BootImageSpec{"name": "elotl-missing-*"}
+	img, err = ctl.resolveBootImage()
+	assert.NoError(t, err)
+	assert.Equal(t, "base-image-id", img.ID)
+}
+
 func TestRequestNode(t *testing.T) {
 	ctl, closer := MakeNodeController()
 	defer closer()
@@ -689,3 +850,83 @@ func TestDoPoolsCalculationComputeFails(t *testing.T) {
 	_, err := ctl.doPoolsCalculation()
 	assert.Error(t, err)
 }
+
+func TestCancelPodLaunch(t *testing.T) {
+	ctl := &NodeController{}
+	ctx, cancel := context.WithCancel(context.Background())
+	pod := api.GetFakePod()
+	node := api.GetFakeNode()
+	ctl.registerLaunch(pod.Name, node, cancel)
+	launches := ctl.ListInFlightLaunches()
+	if assert.Len(t, launches, 1) {
+		assert.Equal(t, pod.Name, launches[0].Pod)
+		assert.Equal(t, node.Name, launches[0].Node)
+	}
+	err := ctl.cancelPodLaunch(events.Event{Object: pod})
+	assert.NoError(t, err)
+	assert.Error(t, ctx.Err())
+	ctl.deregisterLaunch(pod.Name)
+	assert.Len(t, ctl.ListInFlightLaunches(), 0)
+	// canceling an unknown launch by name is an error
+	err = ctl.CancelLaunch(pod.Name)
+	assert.Error(t, err)
+	// canceling a pod with no in-flight launch is a no-op
+	err = ctl.cancelPodLaunch(events.Event{Object: pod})
+	assert.NoError(t, err)
+	// non-pod event objects are rejected
+	err = ctl.cancelPodLaunch(events.Event{Object: "not-a-pod"})
+	assert.Error(t, err)
+}
+
+func TestHeartbeatNeedsPersisting(t *testing.T) {
+	now := time.Now().UTC()
+	// a node we've never persisted a heartbeat for gets one right away
+	assert.True(t, heartbeatNeedsPersisting(time.Time{}, now))
+	assert.True(t, heartbeatNeedsPersisting(now.Add(-2*HeartbeatPersistInterval), now))
+	assert.False(t, heartbeatNeedsPersisting(now.Add(-1*time.Second), now))
+}
+
+func TestCloudMetadataLabels(t *testing.T) {
+	n := api.GetFakeNode()
+	n.Spec.InstanceType = "t3.nano"
+	n.Spec.BootImage = "ami-12345"
+	n.Spec.Placement.AvailabilityZone = "us-east-1a"
+	n.Spec.Spot = false
+	nodeLabels := cloudMetadataLabels(n)
+	assert.Equal(t, "t3.nano", nodeLabels[labels.NodeInstanceType])
+	assert.Equal(t, "ami-12345", nodeLabels[labels.NodeBootImage])
+	assert.Equal(t, "us-east-1a", nodeLabels[labels.NodeZone])
+	assert.Equal(t, labels.CapacityTypeOnDemand, nodeLabels[labels.NodeCapacityType])
+	n.Spec.Spot = true
+	// the zone isn't known until the cloud places the instance
+	n.Spec.Placement.AvailabilityZone = ""
+	nodeLabels = cloudMetadataLabels(n)
+	assert.Equal(t, labels.CapacityTypeSpot, nodeLabels[labels.NodeCapacityType])
+	_, exists := nodeLabels[labels.NodeZone]
+	assert.False(t, exists)
+}
+
+func TestWaitForBootstrap(t *testing.T) {
+	node := api.GetFakeNode()
+	client := nodeclient.NewMockItzoClientFactory()
+	// a healthy bootstrap report passes
+	assert.NoError(t, waitForBootstrap(node, client, time.Second))
+	// a failed bootstrap surfaces the cloud-init error text
+	client.Bootstrap = func() (*api.BootstrapStatus, error) {
+		return &api.BootstrapStatus{Error: "package install failed"}, nil
+	}
+	err := waitForBootstrap(node, client, time.Second)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "package install failed")
+	}
+	// a bootstrap that never finishes times out
+	client.Bootstrap = func() (*api.BootstrapStatus, error) {
+		return &api.BootstrapStatus{}, nil
+	}
+	assert.Error(t, waitForBootstrap(node, client, -time.Second))
+	// old itzo builds without the bootstrap endpoint pass the check
+	client.Bootstrap = func() (*api.BootstrapStatus, error) {
+		return nil, fmt.Errorf("404 page not found")
+	}
+	assert.NoError(t, waitForBootstrap(node, client, time.Second))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package nodemanager
 
 import (
+	"sync"
 	"time"
 
 	"github.com/elotl/kip/pkg/api"
@@ -29,28 +30,61 @@ type StatusUpdater interface {
 	UpdateStatus(*api.Node) (*api.Node, error)
 }
 
+// DefaultMaxSpotInterruptions is the number of spot reclaims after
+// which a spot-preferred pod stops asking for spot cells.
+const DefaultMaxSpotInterruptions = 3
+
+// DefaultScaleInDelay is how long a node must remain unneeded before
+// the scaler hands it back for termination.
+const DefaultScaleInDelay = 2 * time.Minute
+
 type BindingNodeScaler struct {
-	nodeRegistry      StatusUpdater
-	standbyNodes      []StandbyNodeSpec
-	cloudStatus       cloud.StatusKeeper
-	defaultVolumeSize string
-	fixedSizeVolume   bool
+	nodeRegistry         StatusUpdater
+	standbyNodes         []StandbyNodeSpec
+	cloudStatus          cloud.StatusKeeper
+	defaultVolumeSize    string
+	fixedSizeVolume      bool
+	maxSpotInterruptions int
+	scaleInDelay         time.Duration
+	// idleSince records the first time Compute saw a node as
+	// unneeded.  Nodes are only handed back for termination after
+	// they've been idle for scaleInDelay.  Only touched from Compute
+	// which runs on a single loop, so no locking is needed.
+	idleSince map[string]time.Time
+	poolLock  sync.Mutex
+	warmPools []WarmPoolStatus
 }
 
-func NewBindingNodeScaler(nodeReg StatusUpdater, standbyNodes []StandbyNodeSpec, cloudStatus cloud.StatusKeeper, defaultVolumeSize string, fixedSizeVolume bool) *BindingNodeScaler {
+func NewBindingNodeScaler(nodeReg StatusUpdater, standbyNodes []StandbyNodeSpec, cloudStatus cloud.StatusKeeper, defaultVolumeSize string, fixedSizeVolume bool, maxSpotInterruptions int, scaleInDelay time.Duration) *BindingNodeScaler {
+	if maxSpotInterruptions <= 0 {
+		maxSpotInterruptions = DefaultMaxSpotInterruptions
+	}
 	return &BindingNodeScaler{
-		nodeRegistry:      nodeReg,
-		standbyNodes:      standbyNodes,
-		cloudStatus:       cloudStatus,
-		defaultVolumeSize: defaultVolumeSize,
-		fixedSizeVolume:   fixedSizeVolume,
+		nodeRegistry:         nodeReg,
+		standbyNodes:         standbyNodes,
+		cloudStatus:          cloudStatus,
+		defaultVolumeSize:    defaultVolumeSize,
+		fixedSizeVolume:      fixedSizeVolume,
+		maxSpotInterruptions: maxSpotInterruptions,
+		scaleInDelay:         scaleInDelay,
+		idleSince:            make(map[string]time.Time),
 	}
 }
 
+// podPrefersSpot reports whether a spot-preferred pod should still be
+// placed on a spot cell.  After maxSpotInterruptions reclaims the pod
+// sticks to on-demand cells.
+func (s *BindingNodeScaler) podPrefersSpot(pod *api.Pod) bool {
+	return pod.Status.SpotFailures < s.maxSpotInterruptions
+}
+
 // We try to match spotAlways and spotPreferred to any spot nodes.
 // but if we're spotPreferred and we have unavailability, we allow the
 // pod to match to a non-spot node.
 func (s *BindingNodeScaler) spotMatches(pod *api.Pod, node *api.Node) bool {
+	if pod.Spec.Spot.Policy == api.SpotPreferred {
+		return node.Spec.Spot == s.podPrefersSpot(pod)
+	}
 	if (pod.Spec.Spot.Policy == api.SpotNever && !node.Spec.Spot) ||
 		(pod.Spec.Spot.Policy == api.SpotAlways && node.Spec.Spot) {
 		return true
@@ -61,8 +95,11 @@ func (s *BindingNodeScaler) spotMatches(pod *api.Pod, node *api.Node) bool {
 // a pod with no specified placement can match any node
 // a pod with a specified placement can only match nodes with that placement
 func placementMatches(pod *api.Pod, node *api.Node) bool {
-	return pod.Spec.Placement.AvailabilityZone == "" ||
+	azMatches := pod.Spec.Placement.AvailabilityZone == "" ||
 		pod.Spec.Placement.AvailabilityZone == node.Spec.Placement.AvailabilityZone
+	subnetMatches := pod.Spec.Placement.SubnetID == "" ||
+		pod.Spec.Placement.SubnetID == node.Spec.Placement.SubnetID
+	return azMatches && subnetMatches
 }
 
 func (s *BindingNodeScaler) podMatchesNode(pod *api.Pod, node *api.Node) bool {
@@ -71,7 +108,45 @@ func (s *BindingNodeScaler) podMatchesNode(pod *api.Pod, node *api.Node) bool {
 		node.Spec.Resources.GPU == pod.Spec.Resources.GPU &&
 		s.spotMatches(pod, node) &&
 		placementMatches(pod, node) &&
-		s.diskMatches(pod, node)
+		s.diskMatches(pod, node) &&
+		encryptionMatches(pod, node) &&
+		vmSecurityMatches(pod, node) &&
+		// launch overrides are applied at launch, so a pod with
+		// overrides can only run on a node launched with the same
+		// ones
+		node.Spec.LaunchOverrides == pod.Spec.LaunchOverrides
+}
+
+// vmSecurityMatches requires the node's shielded/confidential VM
+// settings to match the pod's exactly; like volume encryption they
+// are fixed at launch, so a pod requiring a confidential VM can never
+// run on a standby node launched without one (and vice versa).
+func vmSecurityMatches(pod *api.Pod, node *api.Node) bool {
+	return boolSettingMatches(pod.Spec.Resources.ShieldedVM, node.Spec.Resources.ShieldedVM) &&
+		boolSettingMatches(pod.Spec.Resources.ConfidentialVM, node.Spec.Resources.ConfidentialVM)
+}
+
+func boolSettingMatches(p, n *bool) bool {
+	if (p == nil) != (n == nil) {
+		return false
+	}
+	return p == nil || *p == *n
+}
+
+// encryptionMatches requires the node's root volume encryption
+// settings to match the pod's exactly; encryption and the KMS key are
+// fixed at launch, so e.g. a pod requiring a customer managed key can
+// never run on a standby node launched without one.
+func encryptionMatches(pod *api.Pod, node *api.Node) bool {
+	pe := pod.Spec.Resources.VolumeEncrypted
+	ne := node.Spec.Resources.VolumeEncrypted
+	if (pe == nil) != (ne == nil) {
+		return false
+	}
+	if pe != nil && *pe != *ne {
+		return false
+	}
+	return pod.Spec.Resources.VolumeKMSKey == node.Spec.Resources.VolumeKMSKey
 }
 
 func (s *BindingNodeScaler) diskMatches(pod *api.Pod, node *api.Node) bool {
@@ -93,6 +168,12 @@ func (s *BindingNodeScaler) createNodeForPod(pod *api.Pod) *api.Node {
 			return nil
 		}
 		isSpotPod = true
+	} else if pod.Spec.Spot.Policy == api.SpotPreferred {
+		// a spot-preferred pod falls back to an on-demand node when
+		// spot capacity is unavailable or the pod has been
+		// interrupted too many times
+		isSpotPod = s.podPrefersSpot(pod) &&
+			!s.cloudStatus.IsUnavailableZone(pod.Spec.InstanceType, true, pod.Spec.Resources.PrivateIPOnly, pod.Spec.Placement.AvailabilityZone)
 	}
 
 	if s.cloudStatus.IsUnavailableZone(pod.Spec.InstanceType, isSpotPod, pod.Spec.Resources.PrivateIPOnly, pod.Spec.Placement.AvailabilityZone) {
@@ -113,10 +194,27 @@ func (s *BindingNodeScaler) createNodeForPod(pod *api.Pod) *api.Node {
 		node.Spec.Resources.VolumeSize = pod.Spec.Resources.VolumeSize
 	}
 	node.Spec.Placement = pod.Spec.Placement
+	node.Spec.LaunchOverrides = pod.Spec.LaunchOverrides
 	node.Status.BoundPodName = pod.Name
 	return node
 }
 
+func (s *BindingNodeScaler) setWarmPools(pools []WarmPoolStatus) {
+	s.poolLock.Lock()
+	defer s.poolLock.Unlock()
+	s.warmPools = pools
+}
+
+// WarmPoolOccupancy reports how full each configured warm pool of
+// standby nodes was at the last scaling pass.
+func (s *BindingNodeScaler) WarmPoolOccupancy() []WarmPoolStatus {
+	s.poolLock.Lock()
+	defer s.poolLock.Unlock()
+	pools := make([]WarmPoolStatus, len(s.warmPools))
+	copy(pools, s.warmPools)
+	return pools
+}
+
 func (s *BindingNodeScaler) createNodeForStandbySpec(spec *StandbyNodeSpec) *api.Node {
 	node := api.NewNode()
 	node.Spec.InstanceType = spec.InstanceType
@@ -161,16 +259,41 @@ func (s *BindingNodeScaler) nodeMatchesStandbySpec(node *api.Node, spec *Standby
 // At the end of this process, return the nodes that we should start,
 // the nodes that need to be shut down and the current bindings map
 // (so that the dispatcher can be fast).
+// podAwaitingMigration reports whether pod is a running pod whose
+// instance type no longer matches the cell it is bound to, meaning
+// the user asked for the pod to be moved (kipctl migrate).  The pod
+// controller cuts the pod over once a cell of the new type is up.
+func podAwaitingMigration(pod *api.Pod, nodesByName map[string]*api.Node) bool {
+	if pod.Status.Phase != api.PodRunning ||
+		pod.Spec.InstanceType == "" ||
+		pod.Status.BoundNodeName == "" {
+		return false
+	}
+	boundNode := nodesByName[pod.Status.BoundNodeName]
+	return boundNode != nil &&
+		boundNode.Spec.InstanceType != pod.Spec.InstanceType
+}
+
 func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.Node, []*api.Node, map[string]string) {
 	// we only care about nodes in availableOrBaking...
 	// remove bound nodes and pods, leaving only needyPods and unboundNodes
 	newNodes := make([]*api.Node, 0)
 	dirtyNodes := make(map[string]*api.Node)
 
+	nodesByName := make(map[string]*api.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByName[node.Name] = node
+	}
 	waitingPods := make(map[string]*api.Pod)
 	for _, pod := range pods {
 		if pod.Status.Phase == api.PodWaiting {
 			waitingPods[pod.Name] = pod
+		} else if podAwaitingMigration(pod, nodesByName) {
+			// A running pod being migrated to a new instance type
+			// needs a replacement cell booted while its old cell
+			// keeps serving, treat it like a waiting pod so a node
+			// of the new type gets created and bound to it.
+			waitingPods[pod.Name] = pod
 		}
 	}
 
@@ -208,7 +331,8 @@ func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.
 	// prioritize matching pods with a specified Placement to a node
 	// by putting them at the front of the slice
 	util.PartitionSlice(unboundPods, func(i int) bool {
-		return unboundPods[i].Spec.Placement.AvailabilityZone != ""
+		return unboundPods[i].Spec.Placement.AvailabilityZone != "" ||
+			unboundPods[i].Spec.Placement.SubnetID != ""
 	})
 
 	// match needyPods to any unboundNodes O(n**2) but with any luck,
@@ -216,18 +340,28 @@ func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.
 	// which is typically small...
 	needyPods := make([]*api.Pod, 0, len(unboundPods))
 	for _, pod := range unboundPods {
-		matched := false
+		// Prefer a warm node that has already finished booting over
+		// one that's still baking so the pod can dispatch immediately
+		matchedIdx := -1
 		for i, node := range unboundNodes {
-			if s.podMatchesNode(pod, node) {
-				node.Status.BoundPodName = pod.Name
-				podNodeBinding[pod.Name] = node.Name
-				unboundNodes = append(unboundNodes[:i], unboundNodes[i+1:]...)
-				dirtyNodes[node.Name] = node
-				matched = true
+			if !s.podMatchesNode(pod, node) {
+				continue
+			}
+			if matchedIdx < 0 {
+				matchedIdx = i
+			}
+			if node.Status.Phase == api.NodeAvailable {
+				matchedIdx = i
 				break
 			}
 		}
-		if !matched {
+		if matchedIdx >= 0 {
+			node := unboundNodes[matchedIdx]
+			node.Status.BoundPodName = pod.Name
+			podNodeBinding[pod.Name] = node.Name
+			unboundNodes = append(unboundNodes[:matchedIdx], unboundNodes[matchedIdx+1:]...)
+			dirtyNodes[node.Name] = node
+		} else {
 			needyPods = append(needyPods, pod)
 		}
 	}
@@ -245,7 +379,13 @@ func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.
 	// Keep track of the number of nodes each pool needs and keep
 	// unbound nodes up to date by
 	standbyToNumNeeded := make(map[StandbyNodeSpec]int)
+	warmPools := make([]WarmPoolStatus, 0, len(s.standbyNodes))
 	for _, standbySpec := range s.standbyNodes {
+		pool := WarmPoolStatus{
+			InstanceType: standbySpec.InstanceType,
+			Spot:         standbySpec.Spot,
+			Desired:      standbySpec.Count,
+		}
 		neededNodes := standbySpec.Count
 		for i := 0; i < len(unboundNodes); i++ {
 			node := unboundNodes[i]
@@ -253,13 +393,20 @@ func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.
 				break
 			}
 			if s.nodeMatchesStandbySpec(node, &standbySpec) {
+				if node.Status.Phase == api.NodeAvailable {
+					pool.Warm++
+				} else {
+					pool.Booting++
+				}
 				neededNodes -= 1
 				unboundNodes = append(unboundNodes[:i], unboundNodes[i+1:]...)
 				i--
 			}
 		}
 		standbyToNumNeeded[standbySpec] = neededNodes
+		warmPools = append(warmPools, pool)
 	}
+	s.setWarmPools(warmPools)
 	// Create nodes to keep the buffered pool up to date
 	for spec, numNeeded := range standbyToNumNeeded {
 		for i := 0; i < numNeeded; i++ {
@@ -283,5 +430,37 @@ func (s *BindingNodeScaler) Compute(nodes []*api.Node, pods []*api.Pod) ([]*api.
 		}
 	}
 
-	return newNodes, unboundNodes, podNodeBinding
+	return newNodes, s.filterScaleInCandidates(unboundNodes), podNodeBinding
+}
+
+// filterScaleInCandidates implements a scale-in stabilization window:
+// a node must remain unneeded for scaleInDelay before we'll shut it
+// down.  This keeps a brief dip in pod load from churning through
+// nodes that we'd just have to boot again.  A delay <= 0 disables the
+// window and nodes are reaped as soon as they become unneeded.
+func (s *BindingNodeScaler) filterScaleInCandidates(idleNodes []*api.Node) []*api.Node {
+	if s.scaleInDelay <= 0 {
+		return idleNodes
+	}
+	now := time.Now()
+	idleNames := make(map[string]bool, len(idleNodes))
+	stopNodes := make([]*api.Node, 0, len(idleNodes))
+	for _, node := range idleNodes {
+		idleNames[node.Name] = true
+		firstIdle, seen := s.idleSince[node.Name]
+		if !seen {
+			s.idleSince[node.Name] = now
+			continue
+		}
+		if now.Sub(firstIdle) >= s.scaleInDelay {
+			stopNodes = append(stopNodes, node)
+		}
+	}
+	// nodes that got bound to a pod (or went away) are no longer idle
+	for name := range s.idleSince {
+		if !idleNames[name] {
+			delete(s.idleSince, name)
+		}
+	}
+	return stopNodes
 }
@@ -18,6 +18,7 @@ package nodemanager
 
 import (
 	"testing"
+	"time"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/server/cloud"
@@ -116,6 +117,39 @@ func TestCreateNodeForPodUnavailable(t *testing.T) {
 	assert.Nil(t, node)
 }
 
+func TestSpotPreferredFallsBack(t *testing.T) {
+	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns := BindingNodeScaler{
+		cloudStatus:          cloudStatus,
+		defaultVolumeSize:    "5G",
+		maxSpotInterruptions: 2,
+	}
+
+	// a fresh spot-preferred pod gets a spot node
+	pod := api.GetFakePod()
+	pod.Spec.Spot.Policy = api.SpotPreferred
+	node := ns.createNodeForPod(pod)
+	assert.True(t, node.Spec.Spot)
+	assert.True(t, ns.spotMatches(pod, node))
+
+	// when spot capacity is unavailable, fall back to on-demand
+	ns.cloudStatus.AddUnavailableInstance(pod.Spec.InstanceType, true)
+	node = ns.createNodeForPod(pod)
+	assert.NotNil(t, node)
+	assert.False(t, node.Spec.Spot)
+
+	// after too many interruptions, stick to on-demand nodes
+	cloudStatus, _ = cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns.cloudStatus = cloudStatus
+	pod.Status.SpotFailures = 2
+	node = ns.createNodeForPod(pod)
+	assert.False(t, node.Spec.Spot)
+	assert.True(t, ns.spotMatches(pod, node))
+	spotNode := api.GetFakeNode()
+	spotNode.Spec.Spot = true
+	assert.False(t, ns.spotMatches(pod, spotNode))
+}
+
 func TestCreateNodeForPodVolumeSize(t *testing.T) {
 	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
 	defaultVolumeSize := "5G"
@@ -147,6 +181,13 @@ func TestPlacementMatches(t *testing.T) {
 	assert.True(t, placementMatches(pod, node))
 	pod.Spec.Placement.AvailabilityZone = "us-west-1a"
 	assert.False(t, placementMatches(pod, node))
+	pod.Spec.Placement.AvailabilityZone = "us-east-1a"
+	node.Spec.Placement.SubnetID = "sub-1111"
+	assert.True(t, placementMatches(pod, node))
+	pod.Spec.Placement.SubnetID = "sub-1111"
+	assert.True(t, placementMatches(pod, node))
+	pod.Spec.Placement.SubnetID = "sub-2222"
+	assert.False(t, placementMatches(pod, node))
 }
 
 func TestCreateNodeForStandbySpec(t *testing.T) {
@@ -290,3 +331,161 @@ func TestNodeScalerDiskMatches(t *testing.T) {
 		assert.Equal(t, true, matches, "error on fixedSizeVolume = false case %d", i)
 	}
 }
+
+func TestComputePrefersWarmNode(t *testing.T) {
+	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns := &BindingNodeScaler{
+		nodeRegistry:      &FakeNodeStatusUpdater{},
+		cloudStatus:       cloudStatus,
+		defaultVolumeSize: "2G",
+	}
+	pod := api.GetFakePod()
+	booting := api.GetFakeNode()
+	booting.Status.Phase = api.NodeCreating
+	warm := api.GetFakeNode()
+	warm.Status.Phase = api.NodeAvailable
+	// the booting node comes first but the pod should bind to the
+	// node that has already finished booting
+	start, _, bindings := ns.Compute([]*api.Node{booting, warm}, []*api.Pod{pod})
+	assert.Len(t, start, 0)
+	assert.Equal(t, warm.Name, bindings[pod.Name])
+}
+
+func TestWarmPoolOccupancy(t *testing.T) {
+	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns := &BindingNodeScaler{
+		nodeRegistry: &FakeNodeStatusUpdater{},
+		standbyNodes: []StandbyNodeSpec{
+			{InstanceType: "t2.nano", Count: 3},
+		},
+		cloudStatus:       cloudStatus,
+		defaultVolumeSize: "2G",
+	}
+	warm := api.GetFakeNode()
+	warm.Spec.Resources.VolumeSize = "2G"
+	warm.Status.Phase = api.NodeAvailable
+	booting := api.GetFakeNode()
+	booting.Spec.Resources.VolumeSize = "2G"
+	booting.Status.Phase = api.NodeCreating
+	start, _, _ := ns.Compute([]*api.Node{warm, booting}, nil)
+	// one more node needs to be started to fill out the pool
+	assert.Len(t, start, 1)
+	pools := ns.WarmPoolOccupancy()
+	if assert.Len(t, pools, 1) {
+		assert.Equal(t, 3, pools[0].Desired)
+		assert.Equal(t, 1, pools[0].Warm)
+		assert.Equal(t, 1, pools[0].Booting)
+	}
+}
+
+func TestScaleInDelay(t *testing.T) {
+	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns := &BindingNodeScaler{
+		nodeRegistry:      &FakeNodeStatusUpdater{},
+		cloudStatus:       cloudStatus,
+		defaultVolumeSize: "2G",
+		scaleInDelay:      time.Minute,
+		idleSince:         make(map[string]time.Time),
+	}
+	node := api.GetFakeNode()
+	node.Status.Phase = api.NodeAvailable
+
+	// the first pass records the node as idle but doesn't stop it
+	_, stop, _ := ns.Compute([]*api.Node{node}, nil)
+	assert.Len(t, stop, 0)
+	// still within the stabilization window
+	_, stop, _ = ns.Compute([]*api.Node{node}, nil)
+	assert.Len(t, stop, 0)
+	// once the node has been idle for the full window, it gets stopped
+	ns.idleSince[node.Name] = time.Now().Add(-2 * time.Minute)
+	_, stop, _ = ns.Compute([]*api.Node{node}, nil)
+	if assert.Len(t, stop, 1) {
+		assert.Equal(t, node.Name, stop[0].Name)
+	}
+
+	// a node that gets bound to a pod has its idle time reset
+	ns.idleSince[node.Name] = time.Now().Add(-2 * time.Minute)
+	pod := api.GetFakePod()
+	pod.Spec.InstanceType = node.Spec.InstanceType
+	_, stop, bindings := ns.Compute([]*api.Node{node}, []*api.Pod{pod})
+	assert.Len(t, stop, 0)
+	assert.Equal(t, node.Name, bindings[pod.Name])
+	_, exists := ns.idleSince[node.Name]
+	assert.False(t, exists)
+
+	// a zero delay reaps unneeded nodes immediately
+	ns.scaleInDelay = 0
+	idle := api.GetFakeNode()
+	idle.Status.Phase = api.NodeAvailable
+	_, stop, _ = ns.Compute([]*api.Node{idle}, nil)
+	assert.Len(t, stop, 1)
+}
+
+func TestPodAwaitingMigration(t *testing.T) {
+	node := api.GetFakeNode()
+	node.Spec.InstanceType = "t2.nano"
+	pod := api.GetFakePod()
+	pod.Spec.InstanceType = "m5.large"
+	pod.Status.Phase = api.PodRunning
+	pod.Status.BoundNodeName = node.Name
+	nodesByName := map[string]*api.Node{node.Name: node}
+	assert.True(t, podAwaitingMigration(pod, nodesByName))
+	// pod is already on the right instance type
+	pod.Spec.InstanceType = node.Spec.InstanceType
+	assert.False(t, podAwaitingMigration(pod, nodesByName))
+	// only running pods are migrated
+	pod.Spec.InstanceType = "m5.large"
+	pod.Status.Phase = api.PodWaiting
+	assert.False(t, podAwaitingMigration(pod, nodesByName))
+	// the bound node is gone, the failure machinery owns this pod
+	pod.Status.Phase = api.PodRunning
+	pod.Status.BoundNodeName = "gone"
+	assert.False(t, podAwaitingMigration(pod, nodesByName))
+}
+
+func TestComputeBootsMigrationNode(t *testing.T) {
+	cloudStatus, _ := cloud.NewLinkedAZSubnetStatus(cloud.NewMockClient())
+	ns := &BindingNodeScaler{
+		nodeRegistry:      &FakeNodeStatusUpdater{},
+		cloudStatus:       cloudStatus,
+		defaultVolumeSize: "2G",
+	}
+	oldNode := api.GetFakeNode()
+	oldNode.Spec.InstanceType = "t2.nano"
+	oldNode.Status.Phase = api.NodeClaimed
+	pod := api.GetFakePod()
+	pod.Status.Phase = api.PodRunning
+	pod.Status.BoundNodeName = oldNode.Name
+	pod.Spec.InstanceType = "m5.large"
+	start, stop, bindings := ns.Compute([]*api.Node{oldNode}, []*api.Pod{pod})
+	// a replacement cell of the new type is booted and bound to the
+	// pod while the old cell keeps running
+	if assert.Len(t, start, 1) {
+		assert.Equal(t, "m5.large", start[0].Spec.InstanceType)
+		assert.Equal(t, start[0].Name, bindings[pod.Name])
+	}
+	assert.Len(t, stop, 0)
+}
+
+func TestEncryptionMatches(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	pod := api.GetFakePod()
+	node := api.GetFakeNode()
+	assert.True(t, encryptionMatches(pod, node))
+
+	pod.Spec.Resources.VolumeEncrypted = boolPtr(true)
+	assert.False(t, encryptionMatches(pod, node))
+
+	node.Spec.Resources.VolumeEncrypted = boolPtr(true)
+	assert.True(t, encryptionMatches(pod, node))
+
+	node.Spec.Resources.VolumeEncrypted = boolPtr(false)
+	assert.False(t, encryptionMatches(pod, node))
+
+	node.Spec.Resources.VolumeEncrypted = boolPtr(true)
+	pod.Spec.Resources.VolumeKMSKey = "alias/my-key"
+	assert.False(t, encryptionMatches(pod, node))
+
+	node.Spec.Resources.VolumeKMSKey = "alias/my-key"
+	assert.True(t, encryptionMatches(pod, node))
+}
@@ -34,3 +34,16 @@ type StandbyNodeSpec struct {
 	Spot         bool   `json:"spot"`
 	// for now, standby nodes don't get public IPs and can't have GPUs
 }
+
+// WarmPoolStatus reports the occupancy of one warm pool of standby
+// nodes as of the last scaling pass.
+type WarmPoolStatus struct {
+	InstanceType string `json:"instanceType"`
+	Spot         bool   `json:"spot"`
+	// Desired is the configured pool size.
+	Desired int `json:"desired"`
+	// Warm is the number of pool nodes booted and ready for a pod.
+	Warm int `json:"warm"`
+	// Booting is the number of pool nodes still starting up.
+	Booting int `json:"booting"`
+}
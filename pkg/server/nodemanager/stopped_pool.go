@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/audit"
+	"github.com/elotl/kip/pkg/util"
+	octrace "go.opencensus.io/trace"
+	"k8s.io/klog"
+)
+
+// stoppedInstance is a cell instance that was stopped instead of
+// terminated so it can be restarted for a new node with the same
+// spec, which is much faster than a fresh launch.
+type stoppedInstance struct {
+	instanceID string
+	spec       api.NodeSpec
+	stoppedAt  time.Time
+}
+
+func (c *NodeController) stoppedPoolMaxAge() time.Duration {
+	if c.Config.StoppedPoolMaxAge > 0 {
+		return c.Config.StoppedPoolMaxAge
+	}
+	return StoppedPoolMaxAge
+}
+
+// nodeReusable reports whether the node's instance is worth keeping
+// in the stopped pool.  Only healthy, unclaimed on-demand nodes
+// qualify: spot instances can't be stopped and a node that never
+// became available is likely broken.
+func (c *NodeController) nodeReusable(node *api.Node) bool {
+	return c.Config.StoppedPoolSize > 0 &&
+		node.Status.Phase == api.NodeAvailable &&
+		node.Status.BoundPodName == "" &&
+		node.Status.InstanceID != "" &&
+		!node.Spec.Spot
+}
+
+// stashStoppedInstance stops the node's instance and adds it to the
+// stopped pool.  It returns false when the pool is full or the stop
+// fails, in which case the caller terminates the instance instead.
+func (c *NodeController) stashStoppedInstance(node *api.Node) bool {
+	instanceID := node.Status.InstanceID
+	c.stoppedPoolLock.Lock()
+	if len(c.stoppedPool) >= c.Config.StoppedPoolSize {
+		c.stoppedPoolLock.Unlock()
+		return false
+	}
+	if c.stoppedPool == nil {
+		c.stoppedPool = make(map[string]*stoppedInstance)
+	}
+	spec := node.Spec
+	spec.Terminate = false
+	c.stoppedPool[instanceID] = &stoppedInstance{
+		instanceID: instanceID,
+		spec:       spec,
+		stoppedAt:  time.Now().UTC(),
+	}
+	c.stoppedPoolLock.Unlock()
+	if err := c.CloudClient.HaltInstance(instanceID); err != nil {
+		klog.Warningf("Could not stop instance %s for reuse, terminating it instead: %v",
+			instanceID, err)
+		c.stoppedPoolLock.Lock()
+		delete(c.stoppedPool, instanceID)
+		c.stoppedPoolLock.Unlock()
+		return false
+	}
+	klog.V(2).Infof("Stopped %s instance %s for reuse",
+		node.Spec.InstanceType, instanceID)
+	return true
+}
+
+// claimStoppedInstance removes and returns a stopped instance that
+// can serve the node, if the pool holds one.
+func (c *NodeController) claimStoppedInstance(node *api.Node) (string, bool) {
+	c.stoppedPoolLock.Lock()
+	defer c.stoppedPoolLock.Unlock()
+	for instanceID, si := range c.stoppedPool {
+		if nodeSpecsMatch(si.spec, node.Spec) {
+			delete(c.stoppedPool, instanceID)
+			return instanceID, true
+		}
+	}
+	return "", false
+}
+
+// nodeSpecsMatch reports whether an instance stopped with spec a can
+// serve a node with spec b.  Everything in the spec is fixed at
+// launch time, so the specs have to agree exactly.
+func nodeSpecsMatch(a, b api.NodeSpec) bool {
+	a.Terminate = false
+	b.Terminate = false
+	return reflect.DeepEqual(a, b)
+}
+
+// resumeSingleNode restarts a stopped instance for the node instead
+// of launching a fresh one.
+func (c *NodeController) resumeSingleNode(node *api.Node, instanceID string) error {
+	log := c.Log.WithRequestID().With("node", node.Name).With("instanceType", node.Spec.InstanceType)
+	log.Debugf("resuming stopped instance %s for node %s", instanceID, node.Name)
+	ctx, span := octrace.StartSpan(context.Background(), "ResumeNode")
+	defer span.End()
+	span.AddAttributes(
+		octrace.StringAttribute("node", node.Name),
+		octrace.StringAttribute("instanceID", instanceID))
+	_, resumeSpan := octrace.StartSpan(ctx, "CloudAPI.StartInstances")
+	err := c.CloudClient.ResumeInstance(instanceID)
+	resumeSpan.End()
+	if err != nil {
+		log.Errorf("Error resuming stopped instance %s: %v", instanceID, err)
+		go func() { _ = c.CloudClient.StopInstance(instanceID) }()
+		if _, regError := c.NodeRegistry.PurgeNode(node); regError != nil {
+			log.Errorf("Error marking node %s terminated after failed resume: %s",
+				node.Name, regError.Error())
+		}
+		return util.WrapError(err, "Error resuming stopped instance")
+	}
+	node.Status.InstanceID = instanceID
+	node.Status.LaunchedAt = api.Now()
+	return c.finishNodeStart(ctx, node, log)
+}
+
+// pruneStoppedPool terminates stopped instances that have waited in
+// the pool longer than the configured max age.
+func (c *NodeController) pruneStoppedPool() {
+	maxAge := c.stoppedPoolMaxAge()
+	cutoff := time.Now().UTC().Add(-maxAge)
+	expired := []string{}
+	c.stoppedPoolLock.Lock()
+	for instanceID, si := range c.stoppedPool {
+		if si.stoppedAt.Before(cutoff) {
+			delete(c.stoppedPool, instanceID)
+			expired = append(expired, instanceID)
+		}
+	}
+	c.stoppedPoolLock.Unlock()
+	for _, instanceID := range expired {
+		c.AuditTrail.Record("node-controller", instanceID, audit.DecisionStopNode,
+			"terminating stopped instance unused for over "+maxAge.String())
+		go func(iid string) {
+			_ = c.CloudClient.StopInstance(iid)
+		}(instanceID)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoppedPoolStashAndClaim(t *testing.T) {
+	ctl := &NodeController{
+		Config:      NodeControllerConfig{StoppedPoolSize: 1},
+		CloudClient: cloud.NewMockClient(),
+	}
+	node := api.GetFakeNode()
+	node.Status.InstanceID = "i-123"
+	node.Status.Phase = api.NodeAvailable
+	assert.True(t, ctl.nodeReusable(node))
+	node.Spec.Spot = true
+	assert.False(t, ctl.nodeReusable(node))
+	node.Spec.Spot = false
+
+	assert.True(t, ctl.stashStoppedInstance(node))
+	// the pool is full now
+	other := api.GetFakeNode()
+	other.Status.InstanceID = "i-456"
+	assert.False(t, ctl.stashStoppedInstance(other))
+
+	// only a node with a matching spec can claim the instance
+	want := api.GetFakeNode()
+	want.Spec = node.Spec
+	want.Spec.InstanceType = "m5.large"
+	_, ok := ctl.claimStoppedInstance(want)
+	assert.False(t, ok)
+	want.Spec = node.Spec
+	instanceID, ok := ctl.claimStoppedInstance(want)
+	assert.True(t, ok)
+	assert.Equal(t, "i-123", instanceID)
+	_, ok = ctl.claimStoppedInstance(want)
+	assert.False(t, ok)
+}
+
+func TestPruneStoppedPool(t *testing.T) {
+	ctl := &NodeController{
+		Config:      NodeControllerConfig{StoppedPoolSize: 2},
+		CloudClient: cloud.NewMockClient(),
+		stoppedPool: map[string]*stoppedInstance{
+			"i-old": {
+				instanceID: "i-old",
+				stoppedAt:  time.Now().UTC().Add(-2 * StoppedPoolMaxAge),
+			},
+			"i-new": {
+				instanceID: "i-new",
+				stoppedAt:  time.Now().UTC(),
+			},
+		},
+	}
+	ctl.pruneStoppedPool()
+	assert.Len(t, ctl.stoppedPool, 1)
+	assert.Contains(t, ctl.stoppedPool, "i-new")
+}
@@ -8,27 +8,44 @@ import (
 	"time"
 
 	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util/stats"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
 )
 
+// poolPressureRatio is the fraction of the advertised node capacity
+// at which we start reporting memory or disk pressure.
+const poolPressureRatio = 0.9
+
 type NodeStatusController struct {
 	nodeReady              bool
 	lastNodeReady          bool
 	networkUnavailable     bool
 	lastNetworkUnavailable bool
+	memoryPressure         bool
+	lastMemoryPressure     bool
+	diskPressure           bool
+	lastDiskPressure       bool
+	notReadyMessage        string
 	internalIP             string
 	daemonEndpointPort     int32
-	kubeletCapacity        corev1.ResourceList
-	kubeletLabels          map[string]string
-	cidrs                  []string
-	node                   *corev1.Node
-	cloudClient            cloud.CloudClient
-	controlLoopTimer       stats.LoopTimer
-	ping                   chan interface{}
-	cb                     func(*corev1.Node)
+	// capacityLock guards kubeletCapacity, which is written by the
+	// provider config watcher goroutine via UpdateCapacity while the
+	// control loop reads it.
+	capacityLock     sync.RWMutex
+	kubeletCapacity  corev1.ResourceList
+	kubeletLabels    map[string]string
+	cidrs            []string
+	node             *corev1.Node
+	cloudClient      cloud.CloudClient
+	podLister        registry.PodLister
+	etcdHealthy      func() error
+	controlLoopTimer stats.LoopTimer
+	ping             chan interface{}
+	cb               func(*corev1.Node)
 }
 
 func NewNodeStatusController(
@@ -37,6 +54,8 @@ func NewNodeStatusController(
 	daemonEndpointPort int32,
 	capacity corev1.ResourceList,
 	labels map[string]string,
+	podLister registry.PodLister,
+	etcdHealthy func() error,
 ) *NodeStatusController {
 	return &NodeStatusController{
 		nodeReady:              false,
@@ -48,6 +67,8 @@ func NewNodeStatusController(
 		daemonEndpointPort:     daemonEndpointPort,
 		kubeletCapacity:        capacity,
 		kubeletLabels:          labels,
+		podLister:              podLister,
+		etcdHealthy:            etcdHealthy,
 		ping:                   make(chan interface{}),
 	}
 }
@@ -85,13 +106,28 @@ func (n *NodeStatusController) controlLoop(quit <-chan struct{}, wg *sync.WaitGr
 
 func (n *NodeStatusController) setNodeStatus() bool {
 	available, err := n.cloudClient.IsAvailable()
+	notReadyMessage := ""
 	if err != nil {
 		klog.Errorf("checking cloud available status: %v", err)
-		return false
+		available = false
+		notReadyMessage = fmt.Sprintf("cloud API is unavailable: %v", err)
+	} else if !available {
+		notReadyMessage = "cloud API reports our availability zone is unavailable"
+	}
+	if n.etcdHealthy != nil {
+		if err := n.etcdHealthy(); err != nil {
+			klog.Errorf("checking etcd health: %v", err)
+			notReadyMessage = fmt.Sprintf("etcd is unreachable: %v", err)
+		}
 	}
-	n.nodeReady = available
+	n.nodeReady = notReadyMessage == ""
+	n.notReadyMessage = notReadyMessage
 	n.networkUnavailable = !available
-	if n.nodeReady == n.lastNodeReady && n.networkUnavailable == n.lastNetworkUnavailable {
+	n.memoryPressure, n.diskPressure = n.poolUtilization()
+	if n.nodeReady == n.lastNodeReady &&
+		n.networkUnavailable == n.lastNetworkUnavailable &&
+		n.memoryPressure == n.lastMemoryPressure &&
+		n.diskPressure == n.lastDiskPressure {
 		klog.V(5).Infof("node status unchanged")
 		return false
 	}
@@ -103,11 +139,52 @@ func (n *NodeStatusController) setNodeStatus() bool {
 	n.cb(node)
 	n.lastNodeReady = n.nodeReady
 	n.lastNetworkUnavailable = n.networkUnavailable
-	klog.V(2).Infof("node status changed ready: %v network unavailabe: %v",
-		n.nodeReady, n.networkUnavailable)
+	n.lastMemoryPressure = n.memoryPressure
+	n.lastDiskPressure = n.diskPressure
+	klog.V(2).Infof("node status changed ready: %v network unavailabe: %v memory pressure: %v disk pressure: %v",
+		n.nodeReady, n.networkUnavailable, n.memoryPressure, n.diskPressure)
 	return true
 }
 
+// poolUtilization computes aggregate utilization of the node pool
+// from the resources requested by all live pods, relative to the
+// advertised kubelet capacity. Memory pressure kicks in when
+// requested memory crosses poolPressureRatio of the memory capacity,
+// disk pressure when requested volume sizes cross the same fraction
+// of the ephemeral-storage capacity. Dimensions without a configured
+// capacity never report pressure.
+func (n *NodeStatusController) poolUtilization() (memoryPressure, diskPressure bool) {
+	if n.podLister == nil {
+		return false, false
+	}
+	podList, err := n.podLister.ListPods(registry.MatchAllLivePods)
+	if err != nil {
+		klog.Errorf("listing pods for node conditions: %v", err)
+		return n.memoryPressure, n.diskPressure
+	}
+	memoryUsed := resource.Quantity{}
+	diskUsed := resource.Quantity{}
+	for _, pod := range podList.Items {
+		if q, err := resource.ParseQuantity(pod.Spec.Resources.Memory); err == nil {
+			memoryUsed.Add(q)
+		}
+		if q, err := resource.ParseQuantity(pod.Spec.Resources.VolumeSize); err == nil {
+			diskUsed.Add(q)
+		}
+	}
+	capacity := n.getCapacity()
+	memoryPressure = overPressureRatio(memoryUsed, capacity[corev1.ResourceMemory])
+	diskPressure = overPressureRatio(diskUsed, capacity[corev1.ResourceEphemeralStorage])
+	return memoryPressure, diskPressure
+}
+
+func overPressureRatio(used, capacity resource.Quantity) bool {
+	if capacity.IsZero() {
+		return false
+	}
+	return float64(used.Value()) >= poolPressureRatio*float64(capacity.Value())
+}
+
 func (n *NodeStatusController) createNode() *corev1.Node {
 	if n.node == nil {
 		klog.Warningf("UpdateNode() has not been called")
@@ -140,7 +217,7 @@ func (n *NodeStatusController) Dump() []byte {
 		NetworkUnavailable: n.networkUnavailable,
 		InternalIP:         n.internalIP,
 		DaemonEndpointPort: n.daemonEndpointPort,
-		KubeletCapacity:    n.kubeletCapacity,
+		KubeletCapacity:    n.getCapacity(),
 		KubeletLabels:      n.kubeletLabels,
 		CIDRs:              n.cidrs,
 		NodeStatus:         nodeStatus,
@@ -169,6 +246,26 @@ func (n *NodeStatusController) Ping(ctx context.Context) error {
 	return nil
 }
 
+func (n *NodeStatusController) getCapacity() corev1.ResourceList {
+	n.capacityLock.RLock()
+	defer n.capacityLock.RUnlock()
+	return n.kubeletCapacity
+}
+
+// UpdateCapacity replaces the advertised node capacity and pushes a
+// fresh node status so the change is picked up without restarting.
+func (n *NodeStatusController) UpdateCapacity(capacity corev1.ResourceList) {
+	n.capacityLock.Lock()
+	n.kubeletCapacity = capacity
+	n.capacityLock.Unlock()
+	klog.V(2).Infof("node capacity updated to %v", capacity)
+	node := n.createNode()
+	if node == nil || n.cb == nil {
+		return
+	}
+	n.cb(node)
+}
+
 func (n *NodeStatusController) NotifyNodeStatus(ctx context.Context, cb func(*corev1.Node)) {
 	n.cb = cb
 	klog.V(5).Infof("registered node status callback")
@@ -202,26 +299,46 @@ func (n *NodeStatusController) GetNodeStatus() corev1.NodeStatus {
 }
 
 func (n *NodeStatusController) nodeCapacity() corev1.ResourceList {
-	return n.kubeletCapacity
+	return n.getCapacity()
 }
 
 func (n *NodeStatusController) nodeConditions() []corev1.NodeCondition {
 	readyCondition := corev1.ConditionTrue
+	readyReason := "KubeletReady"
+	readyMessage := "kubelet is ready"
 	if !n.nodeReady {
 		readyCondition = corev1.ConditionFalse
+		readyReason = "KubeletNotReady"
+		readyMessage = n.notReadyMessage
 	}
 	networkUnavailableCondition := corev1.ConditionFalse
 	if n.networkUnavailable {
 		networkUnavailableCondition = corev1.ConditionTrue
 	}
+	memoryPressureCondition := corev1.ConditionFalse
+	memoryPressureReason := "KubeletHasSufficientMemory"
+	memoryPressureMessage := "kubelet has sufficient memory available"
+	if n.memoryPressure {
+		memoryPressureCondition = corev1.ConditionTrue
+		memoryPressureReason = "KubeletHasInsufficientMemory"
+		memoryPressureMessage = "pods have requested most of the node pool's memory capacity"
+	}
+	diskPressureCondition := corev1.ConditionFalse
+	diskPressureReason := "KubeletHasNoDiskPressure"
+	diskPressureMessage := "kubelet has no disk pressure"
+	if n.diskPressure {
+		diskPressureCondition = corev1.ConditionTrue
+		diskPressureReason = "KubeletHasDiskPressure"
+		diskPressureMessage = "pods have requested most of the node pool's disk capacity"
+	}
 	return []corev1.NodeCondition{
 		{
 			Type:               "Ready",
 			Status:             readyCondition,
 			LastHeartbeatTime:  metav1.Now(),
 			LastTransitionTime: metav1.Now(),
-			Reason:             "KubeletReady",
-			Message:            "kubelet is ready",
+			Reason:             readyReason,
+			Message:            readyMessage,
 		},
 		{
 			Type:               "NetworkUnavailable",
@@ -241,19 +358,19 @@ func (n *NodeStatusController) nodeConditions() []corev1.NodeCondition {
 		},
 		{
 			Type:               "MemoryPressure",
-			Status:             corev1.ConditionFalse,
+			Status:             memoryPressureCondition,
 			LastHeartbeatTime:  metav1.Now(),
 			LastTransitionTime: metav1.Now(),
-			Reason:             "KubeletHasSufficientMemory",
-			Message:            "kubelet has sufficient memory available",
+			Reason:             memoryPressureReason,
+			Message:            memoryPressureMessage,
 		},
 		{
 			Type:               "DiskPressure",
-			Status:             corev1.ConditionFalse,
+			Status:             diskPressureCondition,
 			LastHeartbeatTime:  metav1.Now(),
 			LastTransitionTime: metav1.Now(),
-			Reason:             "KubeletHasNoDiskPressure",
-			Message:            "kubelet has no disk pressure",
+			Reason:             diskPressureReason,
+			Message:            diskPressureMessage,
 		},
 	}
 }
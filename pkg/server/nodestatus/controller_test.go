@@ -1,8 +1,10 @@
 package nodestatus
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -99,3 +101,91 @@ func TestGetNodeStatus(t *testing.T) {
 		}
 	}
 }
+
+type fakePodLister struct {
+	pods []*api.Pod
+}
+
+func (f *fakePodLister) GetPod(name string) (*api.Pod, error) {
+	return nil, nil
+}
+
+func (f *fakePodLister) ListPods(filter func(*api.Pod) bool) (*api.PodList, error) {
+	podList := api.NewPodList()
+	for _, p := range f.pods {
+		if filter(p) {
+			podList.Items = append(podList.Items, p)
+		}
+	}
+	return podList, nil
+}
+
+func makeResourcePod(memory, volumeSize string, phase api.PodPhase) *api.Pod {
+	pod := api.GetFakePod()
+	pod.Spec.Resources.Memory = memory
+	pod.Spec.Resources.VolumeSize = volumeSize
+	pod.Status.Phase = phase
+	if api.IsTerminalPodPhase(phase) {
+		pod.Spec.Phase = phase
+	}
+	return pod
+}
+
+//func (n *NodeStatusController) setNodeStatus()
+func TestEtcdHealthAffectsReady(t *testing.T) {
+	cloud := &cloud.MockCloudClient{
+		AvailabilityChecker: func() (bool, error) {
+			return true, nil
+		},
+	}
+	etcdErr := error(nil)
+	ctrl := &NodeStatusController{
+		cloudClient: cloud,
+		etcdHealthy: func() error { return etcdErr },
+		cb:          func(*corev1.Node) {},
+		node:        &corev1.Node{},
+	}
+	ctrl.setNodeStatus()
+	assert.True(t, ctrl.nodeReady)
+	assert.False(t, ctrl.networkUnavailable)
+	etcdErr = fmt.Errorf("connection refused")
+	ctrl.setNodeStatus()
+	assert.False(t, ctrl.nodeReady)
+	// etcd being down does not make the network unavailable
+	assert.False(t, ctrl.networkUnavailable)
+	assert.Contains(t, ctrl.notReadyMessage, "etcd")
+}
+
+//func (n *NodeStatusController) poolUtilization()
+func TestPoolUtilization(t *testing.T) {
+	lister := &fakePodLister{}
+	ctrl := &NodeStatusController{
+		podLister: lister,
+		kubeletCapacity: corev1.ResourceList{
+			"memory":            resource.MustParse("10Gi"),
+			"ephemeral-storage": resource.MustParse("100Gi"),
+		},
+	}
+	memoryPressure, diskPressure := ctrl.poolUtilization()
+	assert.False(t, memoryPressure)
+	assert.False(t, diskPressure)
+	lister.pods = []*api.Pod{
+		makeResourcePod("8Gi", "10Gi", api.PodRunning),
+		makeResourcePod("1Gi", "10Gi", api.PodWaiting),
+	}
+	memoryPressure, diskPressure = ctrl.poolUtilization()
+	assert.True(t, memoryPressure)
+	assert.False(t, diskPressure)
+	lister.pods = append(lister.pods,
+		makeResourcePod("1Gi", "80Gi", api.PodRunning))
+	memoryPressure, diskPressure = ctrl.poolUtilization()
+	assert.True(t, memoryPressure)
+	assert.True(t, diskPressure)
+	// terminal pods don't count towards utilization
+	lister.pods = []*api.Pod{
+		makeResourcePod("9Gi", "90Gi", api.PodSucceeded),
+	}
+	memoryPressure, diskPressure = ctrl.poolUtilization()
+	assert.False(t, memoryPressure)
+	assert.False(t, diskPressure)
+}
@@ -17,18 +17,23 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/docker/libkv/store"
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/api/annotations"
 	"github.com/elotl/kip/pkg/nodeclient"
+	"github.com/elotl/kip/pkg/server/audit"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/events"
 	"github.com/elotl/kip/pkg/server/healthcheck"
@@ -36,8 +41,11 @@ import (
 	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/stats"
+	"github.com/elotl/kip/pkg/util/structlog"
+	"github.com/elotl/kip/pkg/util/traceutil"
 	"github.com/elotl/node-cli/manager"
 	"github.com/kubernetes/kubernetes/pkg/kubelet/network/dns"
+	octrace "go.opencensus.io/trace"
 	"k8s.io/apimachinery/pkg/api/resource"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/klog"
@@ -45,11 +53,16 @@ import (
 
 // make this configurable
 const (
-	statusReplyTimeout          = 90 * time.Second
-	podUnboundTooLong           = 1 * time.Minute
-	PodControllerCleanPeriod    = 20 * time.Second
-	PodControllerControlPeriod  = 5 * time.Second
-	PodControllerFullSyncPeriod = 31 * time.Second
+	statusReplyTimeout              = 90 * time.Second
+	defaultStatusPollConcurrency    = 10
+	podUnboundTooLong               = 1 * time.Minute
+	PodControllerCleanPeriod        = 20 * time.Second
+	PodControllerControlPeriod      = 5 * time.Second
+	PodControllerFullSyncPeriod     = 31 * time.Second
+	PodControllerTokenRefreshPeriod = 1 * time.Minute
+	// Matches the kubernetes default for
+	// pod.spec.terminationGracePeriodSeconds.
+	defaultTerminationGracePeriod = 30 * time.Second
 )
 
 var lastWrongPod map[string]string
@@ -64,12 +77,13 @@ type PodController struct {
 	podRegistry            *registry.PodRegistry
 	logRegistry            *registry.LogRegistry
 	metricsRegistry        *registry.MetricsRegistry
-	nodeLister             registry.NodeLister
+	nodeRegistry           *registry.NodeRegistry
 	resourceManager        *manager.ResourceManager
 	nodeDispenser          *nodemanager.NodeDispenser
 	nodeClientFactory      nodeclient.ItzoClientFactoryer
 	events                 *events.EventSystem
 	cloudClient            cloud.CloudClient
+	cloudStatus            cloud.StatusKeeper
 	controllerID           string
 	nametag                string
 	controlLoopTimer       stats.LoopTimer
@@ -78,15 +92,44 @@ type PodController struct {
 	networkAgentKubeconfig *clientcmdapi.Config
 	dnsConfigurer          *dns.Configurer
 	statusInterval         time.Duration
-	healthChecker          *healthcheck.HealthCheckController
+	// statusPollConcurrency is how many cells are polled for pod
+	// status at once, statusPollTimeout bounds a single cell's
+	// status query.  Zero values fall back to the built-in
+	// defaults.
+	statusPollConcurrency int
+	statusPollTimeout     time.Duration
+	statusPollStats       stats.PollStats
+	statusPollInFlight    int32
+	healthChecker         *healthcheck.HealthCheckController
+	// defaultInstanceProfile is attached to cells that don't request
+	// their own profile, e.g. so they can write to a cloud log sink.
+	defaultInstanceProfile string
+	// schedulingTimeout is how long a pod may wait for a cell before
+	// it is marked unschedulable, zero disables the check.
+	schedulingTimeout       time.Duration
+	failOnSchedulingTimeout bool
+	// maxSpotInterruptions is the number of spot reclaims after which
+	// a spot-preferred pod falls back to on-demand cells.
+	maxSpotInterruptions int
+	// podRetryLimit is the default number of start failures after
+	// which a failed pod is failed permanently instead of being
+	// restarted.  Pods can override it with the retry limit
+	// annotation; zero uses the built-in default.
+	podRetryLimit int
+	tokenManager  *serviceAccountTokenManager
+	// auditTrail records scheduling decisions for kipctl audit, a nil
+	// trail drops them.
+	auditTrail *audit.Trail
+	log        *structlog.Logger
 }
 
 type FullPodStatus struct {
-	Name             string
-	PodIP            string
-	UnitStatuses     []api.UnitStatus
-	InitUnitStatuses []api.UnitStatus
-	ResourceUsage    api.ResourceMetrics
+	Name                  string
+	PodIP                 string
+	UnitStatuses          []api.UnitStatus
+	InitUnitStatuses      []api.UnitStatus
+	EphemeralUnitStatuses []api.UnitStatus
+	ResourceUsage         api.ResourceMetrics
 	// If an error occurred, Status will be nil, and Error will contain the
 	// error instance.
 	Error error
@@ -106,6 +149,71 @@ func (c *PodController) registerEventHandlers() {
 	c.events.RegisterHandlerFunc(events.PodUpdated, c.podUpdated)
 	// Make deletes synchronous.
 	c.events.RegisterHandlerFunc(events.PodShouldDelete, c.podDeleted)
+	// Track spot reclaims so spot-preferred pods can fall back to
+	// on-demand cells.
+	c.events.RegisterHandlerFunc(events.PodSpotInterrupted, c.podSpotInterrupted)
+	// Count cloud-init failures against the pod's start failures so
+	// a broken bootstrap doesn't retry on fresh cells forever.
+	c.events.RegisterHandlerFunc(events.NodeCloudInitFailed, c.cellCloudInitFailed)
+}
+
+// cellCloudInitFailed handles a node whose cloud-init bootstrap
+// failed before the node's pod could be dispatched to it.  The node
+// controller has already terminated the cell and the scaler will boot
+// a fresh one; marking the pod failed here puts the cloud-init error
+// text on the pod and counts the attempt against allowedStartFailures
+// so the retries don't go on forever.
+func (c *PodController) cellCloudInitFailed(e events.Event) error {
+	node, ok := e.Object.(*api.Node)
+	if !ok || node.Status.BoundPodName == "" {
+		return nil
+	}
+	pod, err := c.podRegistry.GetPod(node.Status.BoundPodName)
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return nil
+		}
+		return util.WrapError(
+			err, "Error recording cloud-init failure for pod %s",
+			node.Status.BoundPodName)
+	}
+	if pod.Status.Phase != api.PodWaiting {
+		// The pod has moved on (deleted or rescheduled elsewhere),
+		// nothing to record.
+		return nil
+	}
+	c.markFailedPod(pod, true, e.Message)
+	return nil
+}
+
+// A spot cell running the pod was reclaimed by the cloud.  Record the
+// interruption on the pod's status and, once a spot-preferred pod has
+// used up its interruption budget, announce the switch to on-demand
+// cells.
+func (c *PodController) podSpotInterrupted(e events.Event) error {
+	// the event carries a copy of the pod, update the real one
+	podCpy := e.Object.(*api.Pod)
+	pod, err := c.podRegistry.GetPod(podCpy.Name)
+	if err != nil {
+		return util.WrapError(
+			err, "Error recording spot interruption for pod %s", podCpy.Name)
+	}
+	pod.Status.SpotFailures++
+	msg := fmt.Sprintf("Spot cell running pod %s was reclaimed by the cloud (interruption %d)",
+		pod.Name, pod.Status.SpotFailures)
+	if pod.Spec.Spot.Policy == api.SpotPreferred &&
+		pod.Status.SpotFailures == c.maxSpotInterruptions {
+		msg += ", switching to on-demand cells"
+		c.events.Emit(events.PodSpotFallback, "pod-controller", pod,
+			"Pod %s was interrupted %d times on spot cells, switching to on-demand",
+			pod.Name, pod.Status.SpotFailures)
+	}
+	_, err = c.podRegistry.UpdatePodStatus(pod, msg)
+	if err != nil {
+		return util.WrapError(
+			err, "Error saving spot interruption count for pod %s", pod.Name)
+	}
+	return nil
 }
 
 func (c *PodController) podCreated(e events.Event) error {
@@ -133,6 +241,9 @@ func (c *PodController) podDeleted(e events.Event) error {
 	} else {
 		c.terminateUnboundPod(pod)
 	}
+	if c.tokenManager != nil {
+		c.tokenManager.forgetPod(pod.Name)
+	}
 	return nil
 }
 
@@ -140,9 +251,11 @@ func (c *PodController) Dump() []byte {
 	dumpStruct := struct {
 		ControlLoopTimer stats.LoopTimer
 		CleanTimer       stats.LoopTimer
+		StatusPollStats  *stats.PollStats
 	}{
 		ControlLoopTimer: c.controlLoopTimer,
 		CleanTimer:       c.cleanTimer,
+		StatusPollStats:  c.statusPollStats.Copy(),
 	}
 	b, err := json.MarshalIndent(dumpStruct, "", "    ")
 	if err != nil {
@@ -169,10 +282,12 @@ func (c *PodController) ControlLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 	statusTicker := time.NewTicker(c.statusInterval)
 	cleanTicker := time.NewTicker(PodControllerCleanPeriod)
 	fullSyncTicker := time.NewTicker(PodControllerFullSyncPeriod)
+	tokenRefreshTicker := time.NewTicker(PodControllerTokenRefreshPeriod)
 	defer controlTicker.Stop()
 	defer statusTicker.Stop()
 	defer cleanTicker.Stop()
 	defer fullSyncTicker.Stop()
+	defer tokenRefreshTicker.Stop()
 
 	for {
 		// prefer quit in case there is a leader election
@@ -190,11 +305,15 @@ func (c *PodController) ControlLoop(quit <-chan struct{}, wg *sync.WaitGroup) {
 			c.controlLoopTimer.StartLoop()
 			c.ControlPods()
 			c.terminateHealthCheckFailedPods()
+			c.checkPodDeadlines()
+			c.checkPodMigrations()
 			c.controlLoopTimer.EndLoop()
 		case <-statusTicker.C:
 			c.checkRunningPodStatus()
 		case <-fullSyncTicker.C:
 			c.SyncRunningPods()
+		case <-tokenRefreshTicker.C:
+			c.refreshServiceAccountTokens()
 		case <-cleanTicker.C:
 			c.cleanTimer.StartLoop()
 			c.checkClaimedNodes()
@@ -223,6 +342,36 @@ func (c *PodController) terminateHealthCheckFailedPods() {
 	}
 }
 
+// checkPodDeadlines fails running pods that have been active for
+// longer than their spec.activeDeadlineSeconds, so runaway Jobs don't
+// keep cells around forever. Exceeding the deadline is a permanent
+// failure: the pod is failed with reason DeadlineExceeded and its
+// cell is terminated, the restart policy doesn't bring it back.
+func (c *PodController) checkPodDeadlines() {
+	podlist, err := c.podRegistry.ListPods(func(p *api.Pod) bool {
+		return p.Spec.ActiveDeadlineSeconds != nil &&
+			p.Status.Phase == api.PodRunning
+	})
+	if err != nil {
+		klog.Errorf("Error listing pods for deadline enforcement: %v", err)
+		return
+	}
+	for _, pod := range podlist.Items {
+		deadline := time.Duration(*pod.Spec.ActiveDeadlineSeconds) * time.Second
+		active := time.Since(pod.Status.LastPhaseChange.Time)
+		if active < deadline {
+			continue
+		}
+		msg := fmt.Sprintf(
+			"Pod %s was active for %ds, longer than its %ds deadline",
+			pod.Name, int(active.Seconds()), *pod.Spec.ActiveDeadlineSeconds)
+		klog.Warningf(msg)
+		c.events.Emit(events.PodDeadlineExceeded, "pod-controller", pod, msg)
+		pod.Status.FailureReason = podReasonDeadlineExceeded
+		c.markFailedPod(pod, false, msg)
+	}
+}
+
 // This is a bit of a catch-all for failures. If Milpa fails to
 // dispatch a pod or something screws up while running, we call this.
 // We ALSO call this when a pod's status changes to api.PodFailed,
@@ -242,6 +391,9 @@ func (c *PodController) markFailedPod(pod *api.Pod, startFailure bool, msg strin
 	if err != nil {
 		klog.Errorf("Error updating pod status: %v", err)
 	}
+	c.auditTrail.Record("pod-controller", pod.Name, audit.DecisionFailPod,
+		fmt.Sprintf("marked failed (start failures: %d): %s",
+			pod.Status.StartFailures, msg))
 	go func() {
 		c.savePodLogs(pod)
 		klog.V(2).Infof("Returning node %s", pod.Status.BoundNodeName)
@@ -407,6 +559,12 @@ func (c *PodController) resizeVolume(node *api.Node, pod *api.Pod, client nodecl
 		return err
 	}
 	if resizePerformed {
+		// Record the provisioned size on the node object.
+		node.Spec.Resources.VolumeSize = pod.Spec.Resources.VolumeSize
+		if _, err := c.nodeRegistry.SetNodeVolumeSize(node, pod.Spec.Resources.VolumeSize); err != nil {
+			klog.Warningf("recording resized volume on node %s: %v",
+				node.Name, err)
+		}
 		// Itzo still needs to take care of enlarging the root partition to
 		// span the new, bigger volume.
 		klog.V(2).Infof("Resized volume on node %s, expanding partition", node.Name)
@@ -416,7 +574,7 @@ func (c *PodController) resizeVolume(node *api.Node, pod *api.Pod, client nodecl
 }
 
 func (c *PodController) updatePodUnits(pod *api.Pod) error {
-	node, err := c.nodeLister.GetNode(pod.Status.BoundNodeName)
+	node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
 	if err != nil {
 		return util.WrapError(err, "Error getting node %s for pod update", pod.Status.BoundNodeName)
 	}
@@ -434,7 +592,7 @@ func (c *PodController) updatePodUnits(pod *api.Pod) error {
 	}
 	podParams := api.PodParameters{
 		Credentials: podCreds,
-		Spec:        util.ExpandCommandAndArgs(pod.Spec),
+		Spec:        util.ExpandCommandAndArgs(foldEphemeralUnits(pod.Spec)),
 		PodName:     pod.Name,
 		NodeName:    c.kubernetesNodeName,
 		PodIP:       api.GetPodIP(node.Status.Addresses),
@@ -443,22 +601,48 @@ func (c *PodController) updatePodUnits(pod *api.Pod) error {
 	return client.UpdateUnits(podParams)
 }
 
+// foldEphemeralUnits appends the pod's ephemeral units to its regular
+// units. Itzo has no notion of ephemeral units; sending a debug unit
+// as one more regular unit makes itzo start it next to the units it
+// is already running without restarting them.
+func foldEphemeralUnits(spec api.PodSpec) api.PodSpec {
+	if len(spec.EphemeralUnits) == 0 {
+		return spec
+	}
+	units := make([]api.Unit, 0, len(spec.Units)+len(spec.EphemeralUnits))
+	units = append(units, spec.Units...)
+	units = append(units, spec.EphemeralUnits...)
+	spec.Units = units
+	spec.EphemeralUnits = nil
+	return spec
+}
+
 func isBurstableMachine(machine string) bool {
 	machineType := strings.ToLower(machine)
 	return (strings.HasPrefix(machineType, "t2") ||
 		strings.HasPrefix(machineType, "t3"))
 }
 
-func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
-	klog.V(2).Infof("Dispatching pod %s to node %s", pod.Name, node.Name)
+func (c *PodController) dispatchPodToNode(ctx context.Context, pod *api.Pod, node *api.Node) {
+	ctx, span := octrace.StartSpan(ctx, "DispatchPodToNode")
+	defer span.End()
+	span.AddAttributes(
+		octrace.StringAttribute("pod", pod.Name),
+		octrace.StringAttribute("node", node.Name))
+	// All messages from this dispatch share a request ID so they can
+	// be correlated in a log pipeline.
+	log := c.log.WithRequestID().With("pod", pod.Name).With("node", node.Name)
+	log.Debugf("Dispatching pod %s to node %s", pod.Name, node.Name)
 	client := c.nodeClientFactory.GetClient(node.Status.Addresses)
 	resizableVolume := !c.cloudClient.GetAttributes().FixedSizeVolume
 	if resizableVolume && pod.Spec.Resources.VolumeSize != "" {
+		_, rspan := octrace.StartSpan(ctx, "CloudAPI.ResizeVolume")
 		err := c.resizeVolume(node, pod, client)
+		rspan.End()
 		if err != nil {
 			msg := fmt.Sprintf("Error resizing volume on node %s pod %s: %v",
 				node.Name, pod.Name, err)
-			klog.Errorf("%s", msg)
+			log.Errorf("%s", msg)
 			c.markFailedPod(pod, true, msg)
 			return
 		}
@@ -469,45 +653,143 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 		err := c.cloudClient.SetSustainedCPU(node, *pod.Spec.Resources.SustainedCPU)
 		if err != nil {
 			msg := fmt.Sprintf("Error dispatching pod to node, could not modify Sustained CPU settings: %s", err)
-			klog.Errorln(msg)
+			log.Errorf("%s", msg)
 			c.markFailedPod(pod, true, msg)
 			return
 		}
 	}
 
-	securityGroupsStr := pod.Annotations[annotations.PodSecurityGroups]
-	if len(securityGroupsStr) != 0 {
-		err := c.attachSecurityGroupsToNode(node, securityGroupsStr)
+	if pod.Spec.Resources.DedicatedCPUs > 0 {
+		// Itzo pins the pod's units to the first DedicatedCPUs cores
+		// of the cell and keeps its own work off them; reflect the
+		// cpuset in the pod status.
+		cpuset := "0"
+		if pod.Spec.Resources.DedicatedCPUs > 1 {
+			cpuset = fmt.Sprintf("0-%d", pod.Spec.Resources.DedicatedCPUs-1)
+		}
+		pod.Status.PinnedCPUs = cpuset
+		updatedPod, err := c.podRegistry.UpdatePodStatus(pod, "Pinned CPUs")
+		if err != nil {
+			msg := fmt.Sprintf("Error saving pinned cpuset for pod %s: %s", pod.Name, err)
+			log.Errorf("%s", msg)
+			c.markFailedPod(pod, true, msg)
+			return
+		}
+		pod = updatedPod
+		log.Debugf("pinned cpus %s for pod %s on node %s",
+			cpuset, pod.Name, node.Name)
+	}
+
+	securityGroups, err := c.podSecurityGroups(pod)
+	if err != nil {
+		msg := fmt.Sprintf("Error dispatching pod to node, could not set up security groups for pod %s: %s", pod.Name, err)
+		log.Errorf("%s", msg)
+		c.markFailedPod(pod, true, msg)
+		return
+	}
+	if len(securityGroups) != 0 {
+		_, sgSpan := octrace.StartSpan(ctx, "CloudAPI.AttachSecurityGroups")
+		err := c.cloudClient.AttachSecurityGroups(node, securityGroups)
+		sgSpan.End()
 		if err != nil {
 			msg := fmt.Sprintf("Error dispatching pod to node, could not attach security groups to pod %s: %s", pod.Name, err)
-			klog.Errorln(msg)
+			log.Errorf("%s", msg)
 			c.markFailedPod(pod, true, msg)
 			return
 		}
 	}
 
 	instanceProfile := pod.Annotations[annotations.PodInstanceProfile]
+	if len(instanceProfile) == 0 {
+		instanceProfile = c.defaultInstanceProfile
+	}
 	if len(instanceProfile) != 0 {
 		err := c.cloudClient.AssignInstanceProfile(node, instanceProfile)
 		if err != nil {
 			msg := fmt.Sprintf("Error dispatching pod to node, could not assign instance profile %s to pod %s: %s", instanceProfile, pod.Name, err)
-			klog.Errorln(msg)
+			log.Errorf("%s", msg)
 			c.markFailedPod(pod, true, msg)
 			return
 		}
 	}
 
+	if eip := pod.Annotations[annotations.PodElasticIP]; eip != "" {
+		err := c.cloudClient.AssociateElasticIP(node, eip)
+		if err != nil {
+			msg := fmt.Sprintf("Error dispatching pod to node, could not associate elastic IP %s with pod %s: %s", eip, pod.Name, err)
+			log.Errorf("%s", msg)
+			c.markFailedPod(pod, true, msg)
+			return
+		}
+		log.Debugf("associated elastic IP %s with node %s for pod %s",
+			eip, node.Name, pod.Name)
+	}
+
+	if len(pod.Spec.DataVolumes) > 0 {
+		if len(pod.Status.DataVolumeIDs) > 0 {
+			// The pod was rescheduled and already owns data volumes,
+			// move them over to the new instance instead of creating
+			// new ones.
+			err := c.cloudClient.ReattachDataVolumes(node, pod.Status.DataVolumeIDs)
+			if err != nil {
+				msg := fmt.Sprintf("Error dispatching pod to node, could not reattach data volumes to pod %s: %s", pod.Name, err)
+				log.Errorf("%s", msg)
+				c.markFailedPod(pod, true, msg)
+				return
+			}
+			c.events.Emit(events.DataVolumeAttached, "pod-controller", pod,
+				"reattached data volumes %v to node %s",
+				pod.Status.DataVolumeIDs, node.Name)
+		} else {
+			_, dvSpan := octrace.StartSpan(ctx, "CloudAPI.AttachDataVolumes")
+			volumeIDs, err := c.cloudClient.AttachDataVolumes(node, pod.Spec.DataVolumes)
+			dvSpan.End()
+			if err != nil {
+				msg := fmt.Sprintf("Error dispatching pod to node, could not attach data volumes to pod %s: %s", pod.Name, err)
+				log.Errorf("%s", msg)
+				c.markFailedPod(pod, true, msg)
+				return
+			}
+			pod.Status.DataVolumeIDs = volumeIDs
+			pod, err = c.podRegistry.UpdatePodStatus(pod, "Attached data volumes")
+			if err != nil {
+				msg := fmt.Sprintf("Error saving data volume IDs for pod %s: %s", pod.Name, err)
+				log.Errorf("%s", msg)
+				c.markFailedPod(pod, true, msg)
+				return
+			}
+			c.events.Emit(events.DataVolumeAttached, "pod-controller", pod,
+				"attached data volumes %v to node %s", volumeIDs, node.Name)
+		}
+	}
+
+	if len(pod.Spec.NetworkInterfaces) > 0 {
+		_, niSpan := octrace.StartSpan(ctx, "CloudAPI.AttachNetworkInterfaces")
+		interfaceIDs, err := c.cloudClient.AttachNetworkInterfaces(node, pod.Spec.NetworkInterfaces)
+		niSpan.End()
+		if err != nil {
+			msg := fmt.Sprintf("Error dispatching pod to node, could not attach network interfaces to pod %s: %s", pod.Name, err)
+			log.Errorf("%s", msg)
+			c.markFailedPod(pod, true, msg)
+			return
+		}
+		// Itzo brings the new interfaces up inside the cell, the cloud
+		// deletes them when the instance is terminated.
+		log.Debugf("attached network interfaces %v to node %s for pod %s",
+			interfaceIDs, node.Name, pod.Name)
+	}
+
 	cidr := pod.Annotations[annotations.PodCloudRoute]
 	if len(cidr) != 0 {
 		cidrs := strings.Fields(cidr)
 		err := c.addCloudRoute(node, cidrs)
 		if err != nil {
 			msg := fmt.Sprintf("Error dispatching pod to node, could not add route %s to pod %s: %s", cidrs, pod.Name, err)
-			klog.Errorln(msg)
+			log.Errorf("%s", msg)
 			c.markFailedPod(pod, true, msg)
 			return
 		}
-		klog.V(2).Infof("added route %s for %s", cidrs, pod.Name)
+		log.Debugf("added route %s for %s", cidrs, pod.Name)
 	}
 
 	// Add labels to the instance but don't fail if that fails, just
@@ -515,10 +797,12 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 	/// as a goroutine cause we don't care when it finishes
 	go c.TagNodeWithPodLabels(pod, node)
 
-	err := deployPodVolumes(pod, node, c.resourceManager, c.nodeClientFactory)
+	_, volSpan := octrace.StartSpan(ctx, "itzo.DeployPodVolumes")
+	err = deployPodVolumes(pod, node, c.resourceManager, c.tokenManager, c.nodeClientFactory)
+	volSpan.End()
 	if err != nil {
 		msg := fmt.Sprintf("Error deploying volumes to node for pod %s: %v", pod.Name, err)
-		klog.Errorln(msg)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
@@ -526,7 +810,7 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 	err = deployResolvconf(pod, node, c.dnsConfigurer, c.nodeClientFactory)
 	if err != nil {
 		msg := fmt.Sprintf("Error deploying resolv.conf to node for pod %s: %v", pod.Name, err)
-		klog.Errorln(msg)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
@@ -534,7 +818,15 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 	err = deployEtcHosts(pod, node, c.dnsConfigurer, c.nodeClientFactory)
 	if err != nil {
 		msg := fmt.Sprintf("Error deploying /etc/hosts to node for pod %s: %v", pod.Name, err)
-		klog.Errorln(msg)
+		log.Errorf("%s", msg)
+		c.markFailedPod(pod, true, msg)
+		return
+	}
+
+	err = deployLocaltime(pod, node, c.nodeClientFactory)
+	if err != nil {
+		msg := fmt.Sprintf("Error deploying /etc/localtime to node for pod %s: %v", pod.Name, err)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
@@ -543,15 +835,17 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 	if err != nil {
 		msg := fmt.Sprintf(
 			"deploying network agent kubeconfig for %q: %v", pod.Name, err)
-		klog.Error(msg)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
 
+	_, unitSpan := octrace.StartSpan(ctx, "itzo.StartPodUnits")
 	err = c.updatePodUnits(pod)
+	unitSpan.End()
 	if err != nil {
 		msg := fmt.Sprintf("Error updating pod units after dispatching pod to node: %v", err)
-		klog.Errorln(msg)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
@@ -563,10 +857,12 @@ func (c *PodController) dispatchPodToNode(pod *api.Pod, node *api.Node) {
 	// lastStatusTime.
 	c.healthChecker.ClearLastStatusTime(pod.Name)
 
+	_, runSpan := octrace.StartSpan(ctx, "etcd.SetPodRunning")
 	err = setPodRunning(pod, node.Name, c.podRegistry, c.events)
+	runSpan.End()
 	if err != nil {
 		msg := fmt.Sprintf("Error updating pod status to running: %v", err)
-		klog.Error(msg)
+		log.Errorf("%s", msg)
 		c.markFailedPod(pod, true, msg)
 		return
 	}
@@ -595,12 +891,29 @@ func (c *PodController) addCloudRoute(node *api.Node, cidrs []string) error {
 	return nil
 }
 
-func (c *PodController) attachSecurityGroupsToNode(node *api.Node, securityGroupsStr string) error {
-	securityGroups := strings.Split(securityGroupsStr, ",")
-	if len(securityGroups) == 0 {
-		return nil
+// podSecurityGroups collects the security groups to attach to the
+// pod's cell: any groups the user listed in the security-groups
+// annotation plus the ephemeral egress group created from the
+// egress-rules annotation, if present.
+func (c *PodController) podSecurityGroups(pod *api.Pod) ([]string, error) {
+	securityGroups := []string{}
+	securityGroupsStr := pod.Annotations[annotations.PodSecurityGroups]
+	if len(securityGroupsStr) != 0 {
+		securityGroups = append(securityGroups, strings.Split(securityGroupsStr, ",")...)
 	}
-	return c.cloudClient.AttachSecurityGroups(node, securityGroups)
+	egressStr := pod.Annotations[annotations.PodEgressRules]
+	if len(egressStr) != 0 {
+		var egressRules []cloud.EgressRule
+		if err := json.Unmarshal([]byte(egressStr), &egressRules); err != nil {
+			return nil, util.WrapError(err, "Error parsing %s annotation", annotations.PodEgressRules)
+		}
+		groupID, err := c.cloudClient.EnsurePodSecurityGroup(pod.Name, egressRules)
+		if err != nil {
+			return nil, util.WrapError(err, "Error creating egress security group")
+		}
+		securityGroups = append(securityGroups, groupID)
+	}
+	return securityGroups, nil
 }
 
 func (c *PodController) SyncRunningPods() {
@@ -622,6 +935,38 @@ func (c *PodController) SyncRunningPods() {
 	}
 }
 
+// Redeploy volumes for running pods whose projected service account
+// tokens are due for a refresh.  PodToken requests a fresh token when
+// the cached one has passed its refresh threshold, so redeploying the
+// pod's volumes is enough to rotate the token on the cell.
+func (c *PodController) refreshServiceAccountTokens() {
+	if c.tokenManager == nil {
+		return
+	}
+	for _, podName := range c.tokenManager.podsDueForRefresh() {
+		pod, err := c.podRegistry.GetPod(podName)
+		if err != nil {
+			c.tokenManager.forgetPod(podName)
+			continue
+		}
+		if pod.Status.Phase != api.PodRunning ||
+			pod.Status.BoundNodeName == "" {
+			continue
+		}
+		node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
+		if err != nil {
+			klog.Errorf("Error getting node to refresh service account token for pod %s: %v", pod.Name, err)
+			continue
+		}
+		go func(p *api.Pod, n *api.Node) {
+			err := deployPodVolumes(p, n, c.resourceManager, c.tokenManager, c.nodeClientFactory)
+			if err != nil {
+				klog.Errorf("Error redeploying volumes to refresh service account token for pod %s: %v", p.Name, err)
+			}
+		}(pod, node)
+	}
+}
+
 func (c *PodController) TagNodeWithPodLabels(pod *api.Pod, node *api.Node) {
 	cloudLabels := util.FilterKeysWithPrefix(pod.Labels, util.InternalLabelPrefixes)
 	podName := util.GetNameFromString(pod.Name)
@@ -630,6 +975,16 @@ func (c *PodController) TagNodeWithPodLabels(pod *api.Pod, node *api.Node) {
 	if podNamespace != "" {
 		cloudLabels[cloud.NamespaceTagKey] = podNamespace
 	}
+	if val, _ := strconv.ParseBool(pod.Annotations[annotations.PodHostnameOnInstance]); val {
+		hostname, err := util.GeneratePodHostname(
+			c.dnsConfigurer, podName, podNamespace, pod.Spec.Hostname, pod.Spec.Subdomain)
+		if err != nil {
+			klog.Warningf("Could not name instance after pod %s hostname: %v",
+				pod.Name, err)
+		} else {
+			cloudLabels["Name"] = hostname
+		}
+	}
 	err := c.cloudClient.AddInstanceTags(node.Status.InstanceID, cloudLabels)
 	if err != nil {
 		klog.Errorln("Error tagging node", node.Name, err)
@@ -669,6 +1024,15 @@ func (c *PodController) handlePodStatusReply(reply FullPodStatus) {
 		return
 	}
 
+	// Snapshot the logs of unit instances that terminated since the
+	// last poll so "kubectl logs --previous" can show the crashed
+	// instance's output.  This has to happen before the new statuses
+	// overwrite the recorded restart counts.
+	for unitName, restarts := range unitsWithNewRestarts(
+		pod.Status.UnitStatuses, reply.UnitStatuses) {
+		go c.savePreviousUnitLogs(pod, unitName, restarts-1)
+	}
+
 	changed, startFailure, failMsg := updatePodWithStatus(pod, reply)
 	if changed {
 		if failMsg != "" {
@@ -695,6 +1059,32 @@ func (c *PodController) handlePodStatusReply(reply FullPodStatus) {
 	}
 }
 
+// ResyncPodStatus queries the pod's node for fresh unit statuses and
+// applies them to the registry right away instead of waiting for the
+// next status polling tick.  It backs the ResyncPod API.  The
+// refreshed pod is returned so callers can show the result.
+func (c *PodController) ResyncPodStatus(podName string) (*api.Pod, error) {
+	pod, err := c.podRegistry.GetPod(podName)
+	if err != nil {
+		return nil, util.WrapError(err, "Could not get pod %s", podName)
+	}
+	if pod.Status.Phase != api.PodRunning {
+		return nil, fmt.Errorf(
+			"Pod %s is %s, only running pods can be resynced",
+			podName, pod.Status.Phase)
+	}
+	if pod.Status.BoundNodeName == "" {
+		return nil, fmt.Errorf("Pod %s is not bound to a node", podName)
+	}
+	reply := c.queryPodStatus(pod)
+	if reply.Error != nil {
+		return nil, util.WrapError(
+			reply.Error, "Could not query status of pod %s", podName)
+	}
+	c.handlePodStatusReply(reply)
+	return c.podRegistry.GetPod(podName)
+}
+
 // Periodically we should go through and do a consistency check of the
 // nodes we have claimed.  We look to see if we are really using them
 // claimed but unused nodes can come from a few places, most likely a
@@ -725,7 +1115,7 @@ func (c *PodController) checkClaimedNodes() {
 		podToNode[pod.Name] = pod.Status.BoundNodeName
 	}
 
-	nodeList, err := c.nodeLister.ListNodes(registry.MatchAllNodes)
+	nodeList, err := c.nodeRegistry.ListNodes(registry.MatchAllNodes)
 	if err != nil {
 		klog.Error(err)
 		return
@@ -761,7 +1151,7 @@ func (c *PodController) checkRunningPods() {
 	// maps from pod name to node name
 	wrongNode := make(map[string]string)
 
-	nodeList, err := c.nodeLister.ListNodes(registry.MatchAllNodes)
+	nodeList, err := c.nodeRegistry.ListNodes(registry.MatchAllNodes)
 	if err != nil {
 		klog.Error(err)
 		return
@@ -813,6 +1203,8 @@ func (c *PodController) checkRunningPods() {
 func (c *PodController) setPodDispatchingParams(pod *api.Pod, node *api.Node) (*api.Pod, error) {
 	pod.Status.BoundNodeName = node.Name
 	pod.Status.BoundInstanceID = node.Status.InstanceID
+	pod.Status.NodeLabels = node.Labels
+	pod.Status.UnschedulableReason = ""
 	// The cloud backend has allocated an extra internal IP to this instance.
 	// This will be used for the pod unless the pod has requested host network
 	// mode, in which case the pod will share the main IP address of the
@@ -837,16 +1229,52 @@ func (c *PodController) setPodDispatchingParams(pod *api.Pod, node *api.Node) (*
 	// node_controller it can be reused.
 	msg := fmt.Sprintf("scheduling pod %s onto %s", pod.Name, node.Name)
 	_, err := c.podRegistry.UpdatePodStatus(pod, msg)
+	if err == nil {
+		c.auditTrail.Record("pod-controller", pod.Name, audit.DecisionBindPod,
+			fmt.Sprintf("bound to node %s (%s)", node.Name, node.Spec.InstanceType))
+	}
 	return pod, err
 }
 
 func (c *PodController) schedulePod(pod *api.Pod) {
+	// A pod that owns data volumes from an earlier run can only
+	// reattach them within their availability zone, pin the pod to
+	// the zone its volumes live in before asking for a node.
+	if len(pod.Status.DataVolumeIDs) > 0 &&
+		pod.Spec.Placement.AvailabilityZone == "" {
+		az, err := c.cloudClient.GetDataVolumeAZ(pod.Status.DataVolumeIDs[0])
+		if err != nil {
+			klog.Warningf("Could not get availability zone of data volumes for pod %s: %v",
+				pod.Name, err)
+		} else if az != "" {
+			pod.Spec.Placement.AvailabilityZone = az
+			p, err := c.podRegistry.UpdatePodSpecAndLabels(pod)
+			if err != nil {
+				klog.Warningf("Could not pin pod %s to availability zone %s: %v",
+					pod.Name, az, err)
+			} else {
+				pod = p
+			}
+		}
+	}
+	// Honor any topology spread constraints by steering the pod
+	// toward the zone or instance type with the fewest of its sibling
+	// replicas, or keeping it waiting when that isn't possible.
+	pod, ok := c.applyTopologySpread(pod)
+	if !ok {
+		return
+	}
+	// Join the trace started by the CreatePod call, if there is one.
+	ctx, span := traceutil.StartSpanWithRemoteParent(context.Background(),
+		"SchedulePod", pod.Annotations[annotations.PodTraceContext])
+	defer span.End()
 	// Get a free node from the nodeDispenser
 	// which gets nodes from the node_controller. The
 	// request has the pod name so that the node_controller
 	// can keep track of who has requested its nodes
 	nodeReply := c.nodeDispenser.RequestNode(*pod)
 	if nodeReply.Node == nil {
+		c.checkSchedulingTimeout(pod)
 		return
 	}
 	pod, err := c.setPodDispatchingParams(pod, nodeReply.Node)
@@ -855,7 +1283,41 @@ func (c *PodController) schedulePod(pod *api.Pod) {
 		c.nodeDispenser.ReturnNode(nodeReply.Node.Name, true)
 		return
 	}
-	go c.dispatchPodToNode(pod, nodeReply.Node)
+	go c.dispatchPodToNode(ctx, pod, nodeReply.Node)
+}
+
+// checkSchedulingTimeout marks a pod that has been waiting for a cell
+// for longer than the configured scheduling timeout (no capacity,
+// over quota or an unsatisfiable request) so the user gets clear
+// feedback instead of a pod sitting in Pending silently.  If
+// failOnSchedulingTimeout is set the pod is failed outright.
+func (c *PodController) checkSchedulingTimeout(pod *api.Pod) {
+	if c.schedulingTimeout <= 0 {
+		return
+	}
+	waiting := time.Since(pod.Status.LastPhaseChange.Time)
+	if waiting < c.schedulingTimeout {
+		return
+	}
+	msg := fmt.Sprintf(
+		"pod has been waiting %ds for a cell, no cell can currently satisfy the request",
+		int(waiting.Seconds()))
+	if c.failOnSchedulingTimeout {
+		c.events.Emit(events.PodSchedulingTimeout, "pod-controller", pod, msg)
+		c.markFailedPod(pod, false, msg)
+		return
+	}
+	if pod.Status.UnschedulableReason != "" {
+		// already marked, don't spam events on every control loop
+		return
+	}
+	klog.Warningf("Pod %s hit the scheduling timeout: %s", pod.Name, msg)
+	pod.Status.UnschedulableReason = msg
+	c.events.Emit(events.PodSchedulingTimeout, "pod-controller", pod, msg)
+	if _, err := c.podRegistry.UpdatePodStatus(pod, msg); err != nil {
+		klog.Errorf("Error updating unschedulable pod %s status: %v",
+			pod.Name, err)
+	}
 }
 
 func (c *PodController) terminateUnboundPod(pod *api.Pod) {
@@ -863,32 +1325,160 @@ func (c *PodController) terminateUnboundPod(pod *api.Pod) {
 }
 
 func (c *PodController) terminateBoundPod(pod *api.Pod) {
+	// Teardown messages share a request ID so the cleanup of one pod
+	// can be correlated in a log pipeline.
+	log := c.log.WithRequestID().With("pod", pod.Name).With("node", pod.Status.BoundNodeName)
 	c.savePodLogs(pod)
 	c.podRegistry.TerminatePod(pod, api.PodTerminated, "Terminating bound pod")
 
 	go func() {
+		// Give units with preStop hooks a chance to run them before
+		// the cell is torn down.
+		if podHasPreStopHooks(pod) {
+			c.runPreStopHooks(pod, log)
+		}
 		// Return node.
-		klog.V(2).Infof("returning node %s for pod %s",
+		log.Debugf("returning node %s for pod %s",
 			pod.Status.BoundNodeName, pod.Name)
 		c.nodeDispenser.ReturnNode(pod.Status.BoundNodeName, false)
 		// Remove any cloud routes created for this pod.
 		instanceID := pod.Status.BoundInstanceID
 		routes := pod.Annotations[annotations.PodCloudRoute]
 		if instanceID != "" && len(routes) > 0 {
-			klog.V(2).Infof("removing route %s for pod %s", routes, pod.Name)
+			log.Debugf("removing route %s for pod %s", routes, pod.Name)
 			for _, cidr := range strings.Fields(routes) {
 				err := c.cloudClient.RemoveRoute(cidr, instanceID)
 				if err != nil {
-					klog.Warningf("removing cidr %s for pod %s (%s): %v",
+					log.Warningf("removing cidr %s for pod %s (%s): %v",
 						cidr, pod.Name, instanceID, err)
 				}
 			}
 		}
+		// Release the elastic IP association (the allocation stays
+		// with the user's account) so the address can be reused.
+		if eip := pod.Annotations[annotations.PodElasticIP]; eip != "" {
+			log.Debugf("disassociating elastic IP %s for pod %s", eip, pod.Name)
+			if err := c.cloudClient.DisassociateElasticIP(eip); err != nil {
+				log.Warningf("disassociating elastic IP %s for pod %s: %v",
+					eip, pod.Name, err)
+			}
+		}
+		// Clean up data volumes unless they are marked persistent.
+		// Volume IDs line up with Spec.DataVolumes entries.
+		volumeIDs := make([]string, 0, len(pod.Status.DataVolumeIDs))
+		persistentIDs := make([]string, 0, len(pod.Status.DataVolumeIDs))
+		for i, volumeID := range pod.Status.DataVolumeIDs {
+			if i < len(pod.Spec.DataVolumes) &&
+				pod.Spec.DataVolumes[i].Persistent {
+				persistentIDs = append(persistentIDs, volumeID)
+				continue
+			}
+			volumeIDs = append(volumeIDs, volumeID)
+		}
+		if len(persistentIDs) > 0 {
+			c.events.Emit(events.DataVolumeDetached, "pod-controller", pod,
+				"detached persistent data volumes %v", persistentIDs)
+		}
+		if len(volumeIDs) > 0 {
+			log.Debugf("deleting data volumes %v for pod %s",
+				volumeIDs, pod.Name)
+			err := c.cloudClient.DeleteDataVolumes(volumeIDs)
+			if err != nil {
+				log.Warningf("deleting data volumes for pod %s: %v",
+					pod.Name, err)
+			}
+		}
+		// Remove the pod's ephemeral egress security group, if any.
+		if len(pod.Annotations[annotations.PodEgressRules]) > 0 {
+			node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
+			if err == nil && node != nil {
+				// Reset the instance to the boot security groups so
+				// the ephemeral group can be deleted and the cell
+				// comes back clean if it gets reused.
+				err = c.cloudClient.AttachSecurityGroups(node, nil)
+				if err != nil {
+					log.Warningf("resetting security groups for pod %s: %v",
+						pod.Name, err)
+				}
+			}
+			log.Debugf("deleting egress security group for pod %s",
+				pod.Name)
+			err = c.cloudClient.DeletePodSecurityGroup(pod.Name)
+			if err != nil {
+				log.Warningf("deleting egress security group for pod %s: %v",
+					pod.Name, err)
+			}
+		}
 	}()
 }
 
+func podHasPreStopHooks(pod *api.Pod) bool {
+	for _, unit := range pod.Spec.Units {
+		if unit.Lifecycle != nil && unit.Lifecycle.PreStop != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func terminationGracePeriod(pod *api.Pod) time.Duration {
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		return time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second
+	}
+	return defaultTerminationGracePeriod
+}
+
+// runPreStopHooks tells itzo to remove the pod's units, which makes
+// the agent run each unit's preStop hook before killing the unit,
+// then waits until the units have terminated or the pod's termination
+// grace period has elapsed before the cell is torn down.
+func (c *PodController) runPreStopHooks(pod *api.Pod, log *structlog.Logger) {
+	node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
+	if err != nil {
+		log.Warningf("getting node %s for preStop hooks of pod %s: %v",
+			pod.Status.BoundNodeName, pod.Name, err)
+		return
+	}
+	client := c.nodeClientFactory.GetClient(node.Status.Addresses)
+	spec := pod.Spec
+	spec.Units = nil
+	spec.InitUnits = nil
+	spec.EphemeralUnits = nil
+	err = client.UpdateUnits(api.PodParameters{
+		PodName:  pod.Name,
+		NodeName: c.kubernetesNodeName,
+		Spec:     spec,
+	})
+	if err != nil {
+		log.Warningf("stopping units of pod %s for preStop hooks: %v",
+			pod.Name, err)
+		return
+	}
+	gracePeriod := terminationGracePeriod(pod)
+	deadline := time.Now().Add(gracePeriod)
+	for time.Now().Before(deadline) {
+		reply, err := client.GetStatus()
+		if err != nil {
+			return
+		}
+		allStopped := true
+		for _, unitStatus := range reply.UnitStatuses {
+			if unitStatus.State.Terminated == nil {
+				allStopped = false
+				break
+			}
+		}
+		if allStopped {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	log.Warningf("units of pod %s still running after the %s grace period",
+		pod.Name, gracePeriod)
+}
+
 func (c *PodController) queryPodStatus(pod *api.Pod) FullPodStatus {
-	node, err := c.nodeLister.GetNode(pod.Status.BoundNodeName)
+	node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
 	if err != nil {
 		reply := FullPodStatus{
 			Name:             pod.Name,
@@ -920,12 +1510,13 @@ func (c *PodController) queryPodStatus(pod *api.Pod) FullPodStatus {
 		replyMap[s.Name] = s
 	}
 	reply := FullPodStatus{
-		Name:             pod.Name,
-		PodIP:            replyStatuses.PodIP,
-		UnitStatuses:     filterUnitStatuses(pod.Spec.Units, replyMap),
-		InitUnitStatuses: filterUnitStatuses(pod.Spec.InitUnits, replyMap),
-		ResourceUsage:    replyStatuses.ResourceUsage,
-		Error:            nil,
+		Name:                  pod.Name,
+		PodIP:                 replyStatuses.PodIP,
+		UnitStatuses:          filterUnitStatuses(pod.Spec.Units, replyMap),
+		InitUnitStatuses:      filterUnitStatuses(pod.Spec.InitUnits, replyMap),
+		EphemeralUnitStatuses: filterUnitStatuses(pod.Spec.EphemeralUnits, replyMap),
+		ResourceUsage:         replyStatuses.ResourceUsage,
+		Error:                 nil,
 	}
 	return reply
 }
@@ -965,16 +1556,70 @@ func (c *PodController) checkRunningPodStatus() {
 		klog.Errorln("Error listing running pods", err)
 		return
 	}
-	for _, pod := range podList.Items {
-		go func(p *api.Pod) {
-			reply := c.queryPodStatus(p)
-			if reply.Error != nil {
-				klog.Errorf("Error getting status of pod %s: %v",
-					reply.Name, reply.Error)
-			} else {
-				c.handlePodStatusReply(reply)
+	// If a previous round of polling is still running (e.g. lots of
+	// slow nodes and a short statusInterval) we skip this tick
+	// instead of piling more queries onto the same nodes.
+	if !atomic.CompareAndSwapInt32(&c.statusPollInFlight, 0, 1) {
+		klog.V(2).Infof("skipping pod status poll, previous poll is still running")
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&c.statusPollInFlight, 0)
+		c.pollPodStatuses(podList.Items)
+	}()
+}
+
+// pollPodStatuses fans pod status queries out to a bounded pool of
+// workers so a handful of slow nodes can't delay status updates for
+// the rest of the fleet.
+func (c *PodController) pollPodStatuses(pods []*api.Pod) {
+	workers := c.statusPollConcurrency
+	if workers <= 0 {
+		workers = defaultStatusPollConcurrency
+	}
+	workChan := make(chan *api.Pod)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pod := range workChan {
+				c.pollPodStatus(pod)
 			}
-		}(pod)
+		}()
+	}
+	for _, pod := range pods {
+		workChan <- pod
+	}
+	close(workChan)
+	wg.Wait()
+}
+
+func (c *PodController) pollPodStatus(pod *api.Pod) {
+	timeout := c.statusPollTimeout
+	if timeout <= 0 {
+		timeout = statusReplyTimeout
+	}
+	start := time.Now()
+	replyChan := make(chan FullPodStatus, 1)
+	go func() {
+		replyChan <- c.queryPodStatus(pod)
+	}()
+	select {
+	case reply := <-replyChan:
+		c.statusPollStats.Record(time.Since(start), reply.Error != nil, false)
+		if reply.Error != nil {
+			klog.Errorf("Error getting status of pod %s: %v",
+				reply.Name, reply.Error)
+		} else {
+			c.handlePodStatusReply(reply)
+		}
+	case <-time.After(timeout):
+		// The query goroutine will finish (and get thrown away)
+		// eventually, the itzo client has its own request timeouts.
+		c.statusPollStats.Record(time.Since(start), true, true)
+		klog.Errorf("Timed out after %s getting status of pod %s",
+			timeout, pod.Name)
 	}
 }
 
@@ -987,7 +1632,7 @@ func (c *PodController) savePodLogs(pod *api.Pod) {
 		return
 	}
 
-	node, err := c.nodeLister.GetNode(pod.Status.BoundNodeName)
+	node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
 	if err != nil {
 		klog.V(2).Infof("not saving pod logs, bound to node %q: %v",
 			pod.Status.BoundNodeName, err)
@@ -1004,7 +1649,7 @@ func (c *PodController) savePodLogs(pod *api.Pod) {
 	client := c.nodeClientFactory.GetClient(podAddresses)
 	podRef := api.ToObjectReference(pod)
 	api.ForAllUnits(pod, func(unit *api.Unit) {
-		data, err := client.GetLogs(unit.Name, 0, nodeclient.SAVE_LOG_BYTES)
+		data, err := client.GetLogs(unit.Name, nodeclient.TailRange(0, nodeclient.SAVE_LOG_BYTES))
 		if err != nil {
 			klog.Errorf("Error saving pod %s log for unit %s: %s",
 				pod.Name, unit.Name, err.Error())
@@ -1022,6 +1667,38 @@ func (c *PodController) savePodLogs(pod *api.Pod) {
 	})
 }
 
+// savePreviousUnitLogs snapshots the log of a unit whose instance
+// just terminated and was restarted, keeping it as a per-restart
+// segment in the log registry so the crashed instance's output stays
+// readable while the fresh instance runs.  Run it from a goroutine,
+// it talks to the cell.
+func (c *PodController) savePreviousUnitLogs(pod *api.Pod, unitName string, instance int32) {
+	if pod.Status.BoundNodeName == "" {
+		return
+	}
+	node, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
+	if err != nil {
+		klog.V(2).Infof("not saving previous logs of pod %s unit %s, bound to node %q: %v",
+			pod.Name, unitName, pod.Status.BoundNodeName, err)
+		return
+	}
+	client := c.nodeClientFactory.GetClient(node.Status.Addresses)
+	data, err := client.GetLogs(unitName, nodeclient.TailRange(0, nodeclient.SAVE_LOG_BYTES))
+	if err != nil {
+		klog.Errorf("Error saving previous log of pod %s unit %s: %v",
+			pod.Name, unitName, err)
+		return
+	}
+	log := api.NewLogFile()
+	log.Name = registry.PreviousLogName(unitName, instance)
+	log.ParentObject = api.ToObjectReference(pod)
+	log.Content = string(data)
+	if _, err := c.logRegistry.CreateLog(log); err != nil {
+		klog.Errorf("Error saving previous log of pod %s unit %s to registry: %v",
+			pod.Name, unitName, err)
+	}
+}
+
 func (c *PodController) handlePodSucceeded(pod *api.Pod) {
 	klog.Errorf("Pod %s has succeeded", pod.Name)
 	err := c.podRegistry.TerminatePod(pod, api.PodSucceeded, "Pod succeeded")
@@ -1066,7 +1743,7 @@ func (c *PodController) ControlPods() {
 			case api.PodRunning:
 				klog.Warningf("Pod %s is already in desired state, no control necessary", pod.Name)
 			case api.PodFailed:
-				remedyFailedPod(pod, c.podRegistry)
+				remedyFailedPod(pod, c.podRegistry, c.events, c.podRetryLimit)
 			case api.PodSucceeded:
 				c.handlePodSucceeded(pod)
 			case api.PodTerminated:
@@ -17,6 +17,7 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -31,6 +32,7 @@ import (
 	"github.com/elotl/kip/pkg/server/nodemanager"
 	"github.com/elotl/kip/pkg/server/registry"
 	"github.com/elotl/kip/pkg/util/k8s/eventrecorder"
+	"github.com/elotl/kip/pkg/util/structlog"
 	"github.com/kubernetes/kubernetes/pkg/kubelet/network/dns"
 	"github.com/stretchr/testify/assert"
 )
@@ -54,12 +56,13 @@ func createPodController(c nodeclient.ItzoClientFactoryer) (*PodController, func
 		podRegistry:       podRegistry,
 		logRegistry:       logRegistry,
 		metricsRegistry:   registry.NewMetricsRegistry(100),
-		nodeLister:        nodeRegistry,
+		nodeRegistry:      nodeRegistry,
 		nodeDispenser:     dispenser,
 		nodeClientFactory: c,
 		events:            events.NewEventSystem(quit, wg),
 		cloudClient:       cloud.NewMockClient(),
 		healthChecker:     healthChecker,
+		log:               structlog.New("pod-controller"),
 	}
 	controller.dnsConfigurer = dns.NewConfigurer(
 		eventrecorder.NewLoggingEventRecorder(5),
@@ -97,7 +100,7 @@ func TestDispatchPodToNodeHappy(t *testing.T) {
 	pod.Status.Phase = api.PodDispatching
 	ctl.podRegistry.UpdatePodStatus(pod, "")
 	node := bindPodToANode(t, pod, ctl)
-	ctl.dispatchPodToNode(pod, node)
+	ctl.dispatchPodToNode(context.Background(), pod, node)
 	if pod.Status.Phase != api.PodRunning {
 		t.Errorf("Pod should be running it's phase is %s", pod.Status.Phase)
 	}
@@ -106,7 +109,7 @@ func TestDispatchPodToNodeHappy(t *testing.T) {
 func schedulePodHelper(t *testing.T, ctl *PodController, pod *api.Pod) {
 	go func() {
 		node := api.GetFakeNode()
-		nodeReg := ctl.nodeLister.(*registry.NodeRegistry)
+		nodeReg := ctl.nodeRegistry
 		nodeReg.CreateNode(node)
 		req := <-ctl.nodeDispenser.NodeRequestChan
 		req.ReplyChan <- nodemanager.NodeReply{Node: node}
@@ -122,7 +125,7 @@ func TestCheckClaimedNodesSimple(t *testing.T) {
 	n := api.GetFakeNode()
 	n.Status.BoundPodName = ""
 	n.Status.Phase = api.NodeClaimed
-	nodeReg := ctl.nodeLister.(*registry.NodeRegistry)
+	nodeReg := ctl.nodeRegistry
 	nodeReg.CreateNode(n)
 	ctl.checkClaimedNodes()
 	assert.Equal(t, 0, len(ctl.nodeDispenser.NodeReturnChan))
@@ -365,7 +368,7 @@ func TestFailingToStartPod(t *testing.T) {
 	// Go through and test a pod that continually fails to launch
 	for i := 0; i <= allowedStartFailures; i++ {
 		ctl.markFailedPod(p, true, "")
-		remedyFailedPod(p, ctl.podRegistry)
+		remedyFailedPod(p, ctl.podRegistry, nil, 0)
 		p, err := ctl.podRegistry.GetPod(p.Name)
 		if i == allowedStartFailures {
 			assert.Equal(t, store.ErrKeyNotFound, err)
@@ -461,7 +464,7 @@ func TestQueryPodStatus(t *testing.T) {
 
 func bindPodToANode(t *testing.T, pod *api.Pod, ctl *PodController) *api.Node {
 	node := api.GetFakeNode()
-	nodeRegistry := ctl.nodeLister.(*registry.NodeRegistry)
+	nodeRegistry := ctl.nodeRegistry
 	node, err := nodeRegistry.CreateNode(node)
 	assert.NoError(t, err)
 	pod.Status.BoundNodeName = node.Name
@@ -573,3 +576,35 @@ func TestParseDockerConfigCreds(t *testing.T) {
 		assert.Equal(t, tc.password, regCreds.Password)
 	}
 }
+
+func TestFoldEphemeralUnits(t *testing.T) {
+	pod := api.GetFakePod()
+	pod.Spec.Units = []api.Unit{
+		{
+			Name:    "app",
+			Image:   "nginx",
+			Command: []string{"nginx"},
+		},
+	}
+
+	// no ephemeral units, the spec goes out unchanged
+	spec := foldEphemeralUnits(pod.Spec)
+	assert.Equal(t, pod.Spec, spec)
+
+	pod.Spec.EphemeralUnits = []api.Unit{
+		{
+			Name:    "debugger",
+			Image:   "busybox",
+			Command: []string{"sh"},
+		},
+	}
+	spec = foldEphemeralUnits(pod.Spec)
+	assert.Empty(t, spec.EphemeralUnits)
+	if assert.Len(t, spec.Units, 2) {
+		assert.Equal(t, "app", spec.Units[0].Name)
+		assert.Equal(t, "debugger", spec.Units[1].Name)
+	}
+	// folding doesn't modify the original spec
+	assert.Len(t, pod.Spec.Units, 1)
+	assert.Len(t, pod.Spec.EphemeralUnits, 1)
+}
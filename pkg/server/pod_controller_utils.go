@@ -19,6 +19,7 @@ package server
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/elotl/kip/pkg/api"
 	"github.com/elotl/kip/pkg/server/events"
@@ -31,6 +32,13 @@ import (
 
 const (
 	allowedStartFailures int = 2
+	// Matches the kubernetes probe defaults.
+	defaultProbePeriodSeconds    int32 = 10
+	defaultProbeFailureThreshold int32 = 3
+	// podReasonDeadlineExceeded is the FailureReason recorded on pods
+	// that outlive their activeDeadlineSeconds, matching the reason
+	// kubernetes reports.
+	podReasonDeadlineExceeded = "DeadlineExceeded"
 )
 
 // // Update the phase to running and the (init)unitStatuses as waiting
@@ -123,19 +131,40 @@ func computePodPhase(policy api.RestartPolicy, unitstatus []api.UnitStatus, podN
 	return phase, failMsg
 }
 
-func podShouldBeRestarted(pod *api.Pod) bool {
-	return pod.Status.StartFailures <= allowedStartFailures &&
+func podShouldBeRestarted(pod *api.Pod, retryLimit int) bool {
+	// pods with a FailureReason (e.g. DeadlineExceeded) have failed
+	// permanently, the restart policy doesn't apply to them
+	return pod.Status.FailureReason == "" &&
+		pod.Status.StartFailures <= retryLimit &&
 		pod.Spec.RestartPolicy != api.RestartPolicyNever
 }
 
+// effectiveRetryLimit is the number of start failures a pod may
+// accumulate before it is failed permanently: the pod's retry limit
+// annotation wins, then the controller's configured limit, then the
+// built-in default.
+func effectiveRetryLimit(pod *api.Pod, configuredLimit int) int {
+	if pod.Spec.RetryLimit != nil {
+		return int(*pod.Spec.RetryLimit)
+	}
+	if configuredLimit > 0 {
+		return configuredLimit
+	}
+	return allowedStartFailures
+}
+
 // Here we create a new status and populate UnitStatuses.LastTerminationState
 // with the last unit status
 func cleanFailedPodStatus(pod *api.Pod) {
 	newStatus := api.PodStatus{
 		Phase:            api.PodWaiting,
 		StartFailures:    pod.Status.StartFailures,
+		SpotFailures:     pod.Status.SpotFailures,
 		InitUnitStatuses: pod.Status.InitUnitStatuses,
 		UnitStatuses:     pod.Status.UnitStatuses,
+		// Keep data volume IDs so the volumes get reattached on the
+		// pod's next cell instead of being recreated.
+		DataVolumeIDs: pod.Status.DataVolumeIDs,
 	}
 	for i := range newStatus.InitUnitStatuses {
 		newStatus.InitUnitStatuses[i].LastTerminationState = newStatus.InitUnitStatuses[i].State
@@ -148,8 +177,9 @@ func cleanFailedPodStatus(pod *api.Pod) {
 	pod.Status = newStatus
 }
 
-func remedyFailedPod(pod *api.Pod, podRegistry *registry.PodRegistry) {
-	if podShouldBeRestarted(pod) {
+func remedyFailedPod(pod *api.Pod, podRegistry *registry.PodRegistry, eventSystem *events.EventSystem, configuredLimit int) {
+	retryLimit := effectiveRetryLimit(pod, configuredLimit)
+	if podShouldBeRestarted(pod, retryLimit) {
 		msg := fmt.Sprintf("Pod %s is being restarted on a new cell", pod.Name)
 		if pod.Status.StartFailures > 0 {
 			msg += fmt.Sprintf(", pod has failed to start %d times", pod.Status.StartFailures)
@@ -158,10 +188,37 @@ func remedyFailedPod(pod *api.Pod, podRegistry *registry.PodRegistry) {
 		cleanFailedPodStatus(pod)
 		podRegistry.UpdatePodStatus(pod, msg)
 	} else {
-		klog.Errorf("pod %s has failed to start %d times. Not trying again, pod has failed", pod.Name, pod.Status.StartFailures)
-		podRegistry.TerminatePod(pod, api.PodFailed,
-			"Pod failed: too many start failures")
+		msg := "Pod failed: too many start failures"
+		if pod.Status.FailureReason != "" {
+			msg = "Pod failed: " + pod.Status.FailureReason
+		} else if pod.Spec.RestartPolicy != api.RestartPolicyNever &&
+			eventSystem != nil {
+			// the pod ran out of retries, let watchers know the
+			// failure is terminal
+			msg = fmt.Sprintf("Pod failed: retry limit of %d exceeded", retryLimit)
+			eventSystem.Emit(events.PodRetryLimitExceeded, "pod-controller", pod, msg)
+		}
+		klog.Errorf("pod %s will not be restarted, pod has failed: %s",
+			pod.Name, msg)
+		podRegistry.TerminatePod(pod, api.PodFailed, msg)
+	}
+}
+
+// unitsWithNewRestarts returns the units whose restart count grew
+// between the old and new statuses, mapped to the new count.  Units
+// that only appear in the new statuses are not restarts.
+func unitsWithNewRestarts(oldStatuses, newStatuses []api.UnitStatus) map[string]int32 {
+	oldCounts := make(map[string]int32, len(oldStatuses))
+	for _, us := range oldStatuses {
+		oldCounts[us.Name] = us.RestartCount
+	}
+	restarted := make(map[string]int32)
+	for _, us := range newStatuses {
+		if oldCount, ok := oldCounts[us.Name]; ok && us.RestartCount > oldCount {
+			restarted[us.Name] = us.RestartCount
+		}
 	}
+	return restarted
 }
 
 func updatePodWithStatus(pod *api.Pod, reply FullPodStatus) (changed, startFailure bool, failMsg string) {
@@ -183,6 +240,16 @@ func updatePodWithStatus(pod *api.Pod, reply FullPodStatus) (changed, startFailu
 			wasStartFailure(reply.InitUnitStatuses)
 	}
 
+	// A unit that exhausts its startup budget counts as a start
+	// failure so the usual restart accounting applies.
+	if podPhase == api.PodRunning {
+		if name := startupBudgetViolation(pod.Spec.Units, reply.UnitStatuses, time.Now()); name != "" {
+			podPhase = api.PodFailed
+			startFailure = true
+			failMsg = fmt.Sprintf("Unit %s did not pass its startup probe within its startup budget", name)
+		}
+	}
+
 	// Reset StartFailures if all units on the pod get out of the
 	// waiting state
 	resetStartFailures := false
@@ -195,7 +262,9 @@ func updatePodWithStatus(pod *api.Pod, reply FullPodStatus) (changed, startFailu
 
 	// Performance: could be faster with a manual comparison but
 	// that's painful to maintain
-	statusSame := reflect.DeepEqual(pod.Status.UnitStatuses, reply.UnitStatuses) && reflect.DeepEqual(pod.Status.InitUnitStatuses, reply.InitUnitStatuses)
+	statusSame := reflect.DeepEqual(pod.Status.UnitStatuses, reply.UnitStatuses) &&
+		reflect.DeepEqual(pod.Status.InitUnitStatuses, reply.InitUnitStatuses) &&
+		reflect.DeepEqual(pod.Status.EphemeralUnitStatuses, reply.EphemeralUnitStatuses)
 	if podPhase == pod.Status.Phase &&
 		statusSame &&
 		!resetStartFailures {
@@ -206,6 +275,7 @@ func updatePodWithStatus(pod *api.Pod, reply FullPodStatus) (changed, startFailu
 	if !statusSame {
 		pod.Status.UnitStatuses = reply.UnitStatuses
 		pod.Status.InitUnitStatuses = reply.InitUnitStatuses
+		pod.Status.EphemeralUnitStatuses = reply.EphemeralUnitStatuses
 	}
 	if resetStartFailures {
 		pod.Status.StartFailures = 0
@@ -227,6 +297,51 @@ func updatePodWithStatus(pod *api.Pod, reply FullPodStatus) (changed, startFailu
 	return true, startFailure, failMsg
 }
 
+// startupBudget is how long a unit with a startup probe may run
+// before the probe must have passed: the initial delay plus
+// failureThreshold probe periods, the same budget kubernetes gives a
+// container.
+func startupBudget(probe *api.Probe) time.Duration {
+	period := probe.PeriodSeconds
+	if period <= 0 {
+		period = defaultProbePeriodSeconds
+	}
+	threshold := probe.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultProbeFailureThreshold
+	}
+	budget := probe.InitialDelaySeconds + threshold*period
+	return time.Duration(budget) * time.Second
+}
+
+// startupBudgetViolation returns the name of a unit that has been
+// running longer than its startup budget without its startup probe
+// passing, or "" if all units are within budget.  Itzo enforces the
+// budget on the cell; this is the controller-side backstop so a cell
+// that stops reporting probe results doesn't leave the pod stuck
+// starting forever.
+func startupBudgetViolation(units []api.Unit, unitstatus []api.UnitStatus, now time.Time) string {
+	for _, unit := range units {
+		if unit.StartupProbe == nil {
+			continue
+		}
+		for _, us := range unitstatus {
+			if us.Name != unit.Name {
+				continue
+			}
+			if us.Started != nil && *us.Started {
+				break
+			}
+			if us.State.Running != nil &&
+				now.Sub(us.State.Running.StartedAt.Time) > startupBudget(unit.StartupProbe) {
+				return unit.Name
+			}
+			break
+		}
+	}
+	return ""
+}
+
 func wasStartFailure(unitstatus []api.UnitStatus) bool {
 	for _, us := range unitstatus {
 		if us.State.Waiting != nil && us.State.Waiting.StartFailure {
@@ -19,6 +19,7 @@ package server
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/docker/libkv/store"
 	"github.com/elotl/kip/pkg/api"
@@ -62,7 +63,7 @@ func TestRemedyFailedPod(t *testing.T) {
 		pod.Status.StartFailures = tc.startFails
 		_, err := podReg.CreatePod(pod)
 		assert.NoError(t, err)
-		remedyFailedPod(pod, podReg)
+		remedyFailedPod(pod, podReg, nil, 0)
 		p, err := podReg.GetPod(pod.Name)
 		if tc.expectedPhase == api.PodFailed {
 			assert.Equal(t, store.ErrKeyNotFound, err)
@@ -294,3 +295,114 @@ func TestAllUnitsStarted(t *testing.T) {
 		assert.True(t, allUnitsStarted(s), "started test %d", i)
 	}
 }
+
+func TestStartupBudgetViolation(t *testing.T) {
+	probe := &api.Probe{
+		PeriodSeconds:    5,
+		FailureThreshold: 2,
+	}
+	now := time.Now()
+	started := true
+	notStarted := false
+	runningSince := func(d time.Duration) api.UnitState {
+		return api.UnitState{
+			Running: &api.UnitStateRunning{
+				StartedAt: api.Time{Time: now.Add(-d)},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		units    []api.Unit
+		statuses []api.UnitStatus
+		violator string
+	}{
+		{
+			name:     "no startup probe never violates",
+			units:    []api.Unit{{Name: "foo"}},
+			statuses: []api.UnitStatus{{Name: "foo", State: runningSince(time.Hour)}},
+			violator: "",
+		},
+		{
+			name:     "within budget",
+			units:    []api.Unit{{Name: "foo", StartupProbe: probe}},
+			statuses: []api.UnitStatus{{Name: "foo", State: runningSince(5 * time.Second), Started: &notStarted}},
+			violator: "",
+		},
+		{
+			name:     "started in time",
+			units:    []api.Unit{{Name: "foo", StartupProbe: probe}},
+			statuses: []api.UnitStatus{{Name: "foo", State: runningSince(time.Hour), Started: &started}},
+			violator: "",
+		},
+		{
+			name:     "budget exhausted",
+			units:    []api.Unit{{Name: "foo", StartupProbe: probe}},
+			statuses: []api.UnitStatus{{Name: "foo", State: runningSince(time.Minute), Started: &notStarted}},
+			violator: "foo",
+		},
+		{
+			name:     "waiting unit does not violate",
+			units:    []api.Unit{{Name: "foo", StartupProbe: probe}},
+			statuses: []api.UnitStatus{{Name: "foo", State: api.UnitState{Waiting: &api.UnitStateWaiting{}}}},
+			violator: "",
+		},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.violator,
+			startupBudgetViolation(tc.units, tc.statuses, now), tc.name)
+	}
+}
+
+func TestCleanFailedPodStatusKeepsDataVolumeIDs(t *testing.T) {
+	pod := api.GetFakePod()
+	pod.Status.Phase = api.PodFailed
+	pod.Status.BoundNodeName = "mynode"
+	pod.Status.DataVolumeIDs = []string{"vol-123", "vol-456"}
+	cleanFailedPodStatus(pod)
+	assert.Equal(t, api.PodWaiting, pod.Status.Phase)
+	assert.Equal(t, "", pod.Status.BoundNodeName)
+	assert.Equal(t, []string{"vol-123", "vol-456"}, pod.Status.DataVolumeIDs)
+}
+
+func TestPodShouldBeRestartedFailureReason(t *testing.T) {
+	pod := api.GetFakePod()
+	pod.Spec.RestartPolicy = api.RestartPolicyAlways
+	pod.Status.Phase = api.PodFailed
+	assert.True(t, podShouldBeRestarted(pod, allowedStartFailures))
+	// a permanent failure reason overrides the restart policy
+	pod.Status.FailureReason = podReasonDeadlineExceeded
+	assert.False(t, podShouldBeRestarted(pod, allowedStartFailures))
+}
+
+func TestEffectiveRetryLimit(t *testing.T) {
+	pod := api.GetFakePod()
+	assert.Equal(t, allowedStartFailures, effectiveRetryLimit(pod, 0))
+	assert.Equal(t, 5, effectiveRetryLimit(pod, 5))
+	// the pod's retry limit annotation wins over the configured limit
+	limit := int32(0)
+	pod.Spec.RetryLimit = &limit
+	assert.Equal(t, 0, effectiveRetryLimit(pod, 5))
+	pod.Spec.RestartPolicy = api.RestartPolicyAlways
+	pod.Status.StartFailures = 1
+	assert.False(t, podShouldBeRestarted(pod, effectiveRetryLimit(pod, 5)))
+}
+
+func TestUnitsWithNewRestarts(t *testing.T) {
+	oldStatuses := []api.UnitStatus{
+		{Name: "unit1", RestartCount: 0},
+		{Name: "unit2", RestartCount: 2},
+		{Name: "unit3", RestartCount: 1},
+	}
+	newStatuses := []api.UnitStatus{
+		{Name: "unit1", RestartCount: 1},
+		{Name: "unit2", RestartCount: 2},
+		{Name: "unit3", RestartCount: 3},
+		// units we have no previous status for are not restarts
+		{Name: "unit4", RestartCount: 5},
+	}
+	restarted := unitsWithNewRestarts(oldStatuses, newStatuses)
+	assert.Equal(t, map[string]int32{"unit1": 1, "unit3": 3}, restarted)
+	assert.Empty(t, unitsWithNewRestarts(newStatuses, newStatuses))
+	assert.Empty(t, unitsWithNewRestarts(nil, newStatuses))
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/events"
+	"k8s.io/klog"
+)
+
+const (
+	// How long we wait for a migrated pod's units to come up on the
+	// new cell before draining the old one anyway.  This is a
+	// best-effort bound, the regular status loop takes over once the
+	// cutover has happened.
+	podMigrationReadyTimeout = 2 * time.Minute
+	podMigrationStatusPeriod = 5 * time.Second
+)
+
+// checkPodMigrations looks for running pods whose instance type no
+// longer matches the cell they are bound to (the user changed
+// spec.instanceType, e.g. through kipctl migrate or by updating the
+// pod.elotl.co/instance-type annotation) and moves each one over to a
+// cell of the new type.  The node scaler sees the same mismatch and
+// boots a replacement cell bound to the pod; until the dispenser can
+// hand that cell out there's nothing to do here and the pod keeps
+// running undisturbed on its old cell.
+func (c *PodController) checkPodMigrations() {
+	podList, err := c.podRegistry.ListPods(func(p *api.Pod) bool {
+		return p.Status.Phase == api.PodRunning &&
+			p.Spec.InstanceType != "" &&
+			p.Status.BoundNodeName != ""
+	})
+	if err != nil {
+		klog.Errorf("Error listing pods for migration check: %v", err)
+		return
+	}
+	for _, pod := range podList.Items {
+		oldNode, err := c.nodeRegistry.GetNode(pod.Status.BoundNodeName)
+		if err != nil {
+			klog.Warningf("Could not get node %s bound to pod %s for migration check: %v",
+				pod.Status.BoundNodeName, pod.Name, err)
+			continue
+		}
+		if oldNode.Spec.InstanceType == pod.Spec.InstanceType {
+			continue
+		}
+		c.migratePod(pod, oldNode)
+	}
+}
+
+// migratePod starts the cutover of one pod to a cell of its new
+// instance type.  If the replacement cell isn't available yet we do
+// nothing and retry on the next control loop pass.  Setting the pod
+// to dispatching here (in the control loop goroutine) guarantees the
+// pod isn't picked up for migration twice.
+func (c *PodController) migratePod(pod *api.Pod, oldNode *api.Node) {
+	nodeReply := c.nodeDispenser.RequestNode(*pod)
+	if nodeReply.Node == nil {
+		return
+	}
+	newNode := nodeReply.Node
+	if newNode.Spec.InstanceType != pod.Spec.InstanceType {
+		// Stale binding, don't move the pod onto the wrong type.
+		c.nodeDispenser.ReturnNode(newNode.Name, true)
+		return
+	}
+	klog.V(2).Infof("Migrating pod %s from %s (%s) to %s (%s)",
+		pod.Name, oldNode.Name, oldNode.Spec.InstanceType,
+		newNode.Name, newNode.Spec.InstanceType)
+	pod, err := c.setPodDispatchingParams(pod, newNode)
+	if err != nil {
+		klog.Errorf("Error updating pod %s for migration dispatch: %v",
+			pod.Name, err)
+		c.nodeDispenser.ReturnNode(newNode.Name, true)
+		return
+	}
+	go c.finishPodMigration(pod, newNode, oldNode)
+}
+
+// finishPodMigration dispatches the pod to its new cell and drains
+// the old one.  Stateless pods keep running on the old cell until
+// their units have come up on the new one so the move is close to
+// seamless.  Pods that own data volumes get their old cell torn down
+// first: a volume can only be attached to one instance at a time, so
+// the old instance has to let go before the dispatch can reattach the
+// volumes, at the cost of a brief pause.
+func (c *PodController) finishPodMigration(pod *api.Pod, newNode, oldNode *api.Node) {
+	hasVolumes := len(pod.Status.DataVolumeIDs) > 0
+	if hasVolumes {
+		c.nodeDispenser.ReturnNode(oldNode.Name, false)
+	}
+	c.dispatchPodToNode(context.Background(), pod, newNode)
+	p, err := c.podRegistry.GetPod(pod.Name)
+	if err != nil ||
+		p.Status.Phase != api.PodRunning ||
+		p.Status.BoundNodeName != newNode.Name {
+		// The dispatch failed, markFailedPod has already returned the
+		// new node and queued the pod for restart, just make sure the
+		// old cell isn't leaked.
+		if !hasVolumes {
+			c.nodeDispenser.ReturnNode(oldNode.Name, false)
+		}
+		return
+	}
+	if !hasVolumes {
+		c.waitForMigratedUnits(p)
+		c.nodeDispenser.ReturnNode(oldNode.Name, false)
+	}
+	c.events.Emit(events.PodMigrated, "pod-controller", p,
+		"pod migrated from %s (%s) to %s (%s)",
+		oldNode.Name, oldNode.Spec.InstanceType,
+		newNode.Name, newNode.Spec.InstanceType)
+}
+
+// waitForMigratedUnits polls the pod's new cell until all of the
+// pod's units are ready (or have run to completion) so the old cell
+// isn't drained out from under a pod that hasn't come up yet.  If the
+// units don't come up within podMigrationReadyTimeout we drain the
+// old cell anyway and let the usual restart machinery deal with the
+// pod.
+func (c *PodController) waitForMigratedUnits(pod *api.Pod) {
+	deadline := time.Now().Add(podMigrationReadyTimeout)
+	for time.Now().Before(deadline) {
+		reply := c.queryPodStatus(pod)
+		if reply.Error == nil && allUnitsReady(reply.UnitStatuses) {
+			return
+		}
+		time.Sleep(podMigrationStatusPeriod)
+	}
+	klog.Warningf("Units of pod %s did not come up on %s within %v, draining the old cell anyway",
+		pod.Name, pod.Status.BoundNodeName, podMigrationReadyTimeout)
+}
+
+func allUnitsReady(statuses []api.UnitStatus) bool {
+	if len(statuses) == 0 {
+		return false
+	}
+	for _, s := range statuses {
+		if !s.Ready && s.State.Terminated == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/elotl/kip/pkg/util/instanceselector"
+	"golang.org/x/net/context"
+	"k8s.io/klog"
+)
+
+const etcdClusterPreflightPath string = "milpa/cluster/preflight"
+
+// PreflightCheck is the result of a single preflight check.
+type PreflightCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PreflightResult aggregates all preflight checks; Passed is false if
+// any individual check failed.
+type PreflightResult struct {
+	Passed bool             `json:"passed"`
+	Checks []PreflightCheck `json:"checks"`
+}
+
+func makePreflightCheck(name string, err error) PreflightCheck {
+	check := PreflightCheck{
+		Name:   name,
+		Passed: err == nil,
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// checkEtcd writes and deletes a throwaway key to prove the datastore
+// is reachable and writable.
+func (s InstanceProvider) checkEtcd() error {
+	if err := s.etcdClient.Put(etcdClusterPreflightPath, []byte("OK"), nil); err != nil {
+		return util.WrapError(err, "Could not write to storage")
+	}
+	if err := s.etcdClient.Delete(etcdClusterPreflightPath); err != nil {
+		return util.WrapError(err, "Could not delete from storage")
+	}
+	return nil
+}
+
+// checkCloudAPI makes a cheap describe call with the configured
+// credentials.
+func (s InstanceProvider) checkCloudAPI() error {
+	available, err := s.cloudClient.IsAvailable()
+	if err != nil {
+		return util.WrapError(err, "Could not query the cloud API")
+	}
+	if !available {
+		return fmt.Errorf("the configured availability zone is not available")
+	}
+	return nil
+}
+
+// checkBootImage resolves the configured boot image spec to a cloud
+// image.
+func (s InstanceProvider) checkBootImage() error {
+	img, err := s.cloudClient.GetImage(s.bootImageSpec)
+	if err != nil {
+		return util.WrapError(err, "Could not look up boot image for %v", s.bootImageSpec)
+	}
+	if img.ID == "" {
+		return fmt.Errorf("no boot image found for %v", s.bootImageSpec)
+	}
+	return nil
+}
+
+// checkInstanceType verifies the default instance type exists in this
+// region's pricing data.
+func (s InstanceProvider) checkInstanceType() error {
+	if s.defaultInstanceType == "" {
+		return fmt.Errorf("no default instance type configured")
+	}
+	if instanceselector.IsUnsupportedInstance(s.defaultInstanceType) {
+		return fmt.Errorf("instance type %q is not supported in this region",
+			s.defaultInstanceType)
+	}
+	if _, found := instanceselector.InstancePrice(s.defaultInstanceType); !found {
+		return fmt.Errorf("instance type %q was not found in this region",
+			s.defaultInstanceType)
+	}
+	return nil
+}
+
+// checkTestInstance launches a throwaway instance of the default
+// instance type and terminates it, proving the credentials can run
+// and terminate instances end to end.
+func (s InstanceProvider) checkTestInstance() error {
+	img, err := s.cloudClient.GetImage(s.bootImageSpec)
+	if err != nil {
+		return util.WrapError(err, "Could not look up boot image for %v", s.bootImageSpec)
+	}
+	node := api.NewNode()
+	node.Name = "preflight-" + node.Name
+	node.Spec.InstanceType = s.defaultInstanceType
+	node.Spec.BootImage = img.ID
+	node.Spec.Resources.VolumeSize = s.defaultVolumeSize
+	startResult, err := s.cloudClient.StartNode(node, img, "")
+	if err != nil {
+		return util.WrapError(err, "Could not launch test instance")
+	}
+	klog.V(2).Infof("preflight launched test instance %s, terminating it",
+		startResult.InstanceID)
+	if err := s.cloudClient.StopInstance(startResult.InstanceID); err != nil {
+		return util.WrapError(
+			err, "Could not terminate test instance %s, please terminate it manually",
+			startResult.InstanceID)
+	}
+	return nil
+}
+
+func (s InstanceProvider) runPreflight(launchTestInstance bool) PreflightResult {
+	checks := []PreflightCheck{
+		makePreflightCheck("etcd", s.checkEtcd()),
+		makePreflightCheck("cloud-api", s.checkCloudAPI()),
+		makePreflightCheck("boot-image", s.checkBootImage()),
+		makePreflightCheck("instance-type", s.checkInstanceType()),
+	}
+	if launchTestInstance {
+		checks = append(
+			checks, makePreflightCheck("test-instance", s.checkTestInstance()))
+	}
+	result := PreflightResult{
+		Passed: true,
+		Checks: checks,
+	}
+	for _, check := range checks {
+		if !check.Passed {
+			result.Passed = false
+			break
+		}
+	}
+	return result
+}
+
+// Preflight runs a series of health checks (etcd, cloud credentials,
+// boot image and instance type availability, optionally a throwaway
+// instance launch) so operators can verify a kip install before
+// deploying workloads.  Results are returned as JSON, one pass/fail
+// entry per check.
+func (s InstanceProvider) Preflight(context context.Context, request *clientapi.PreflightRequest) (*clientapi.APIReply, error) {
+	klog.V(2).Infof("Preflight request (launchTestInstance: %t)",
+		request.LaunchTestInstance)
+	result := s.runPreflight(request.LaunchTestInstance)
+	body, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return errToAPIReply(
+			util.WrapError(err, "Error serializing preflight results")), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   body,
+	}
+	return &reply, nil
+}
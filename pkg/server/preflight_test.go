@@ -0,0 +1,99 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/cloud"
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/elotl/kip/pkg/util/instanceselector"
+	"github.com/stretchr/testify/assert"
+)
+
+func makePreflightProvider(t *testing.T) (InstanceProvider, *cloud.MockCloudClient, func()) {
+	err := instanceselector.Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	assert.NoError(t, err)
+	name, closer := util.MakeTempFileName("milpa_preflight")
+	kvstore := registry.CreateKVStore(name)
+	cloudClient := cloud.NewMockClient()
+	cloudClient.ImageGetter = func(spec cloud.BootImageSpec) (cloud.Image, error) {
+		return cloud.Image{ID: "ami-1234"}, nil
+	}
+	s := InstanceProvider{
+		cloudClient:         cloudClient,
+		etcdClient:          kvstore,
+		bootImageSpec:       cloud.BootImageSpec{"name": "elotl-test"},
+		defaultInstanceType: "t2.nano",
+		defaultVolumeSize:   "5Gi",
+	}
+	return s, cloudClient, closer
+}
+
+func TestRunPreflightPasses(t *testing.T) {
+	s, _, closer := makePreflightProvider(t)
+	defer closer()
+	result := s.runPreflight(false)
+	assert.True(t, result.Passed)
+	assert.Len(t, result.Checks, 4)
+	for _, check := range result.Checks {
+		assert.True(t, check.Passed, "check %s failed: %s", check.Name, check.Error)
+	}
+}
+
+func TestRunPreflightFailures(t *testing.T) {
+	s, cloudClient, closer := makePreflightProvider(t)
+	defer closer()
+	cloudClient.ImageGetter = func(spec cloud.BootImageSpec) (cloud.Image, error) {
+		return cloud.Image{}, fmt.Errorf("no images for you")
+	}
+	s.defaultInstanceType = "not-an-instance-type"
+	result := s.runPreflight(false)
+	assert.False(t, result.Passed)
+	failed := make(map[string]bool)
+	for _, check := range result.Checks {
+		if !check.Passed {
+			failed[check.Name] = true
+			assert.NotEmpty(t, check.Error)
+		}
+	}
+	assert.True(t, failed["boot-image"])
+	assert.True(t, failed["instance-type"])
+	assert.False(t, failed["etcd"])
+}
+
+func TestRunPreflightTestInstance(t *testing.T) {
+	s, cloudClient, closer := makePreflightProvider(t)
+	defer closer()
+	launched, terminated := "", ""
+	cloudClient.Starter = func(node *api.Node, image cloud.Image, metadata string) (*cloud.StartNodeResult, error) {
+		launched = node.Name
+		return &cloud.StartNodeResult{InstanceID: "i-1234"}, nil
+	}
+	cloudClient.Stopper = func(instanceID string) error {
+		terminated = instanceID
+		return nil
+	}
+	result := s.runPreflight(true)
+	assert.True(t, result.Passed)
+	assert.Len(t, result.Checks, 5)
+	assert.Contains(t, launched, "preflight-")
+	assert.Equal(t, "i-1234", terminated)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// defaultQuotaKey is the Quotas map key whose entry applies to any
+// namespace without an explicit quota of its own.
+const defaultQuotaKey = "default"
+
+// quotaForNamespace looks up the quota for a namespace, falling back
+// to the default entry. The second return value is false if no quota
+// applies to the namespace.
+func quotaForNamespace(quotas map[string]NamespaceQuota, namespace string) (NamespaceQuota, bool) {
+	if quota, ok := quotas[namespace]; ok {
+		return quota, true
+	}
+	quota, ok := quotas[defaultQuotaKey]
+	return quota, ok
+}
+
+// namespaceUsage tallies the resources requested by all live pods in
+// a namespace. Pods with unparsable resource strings don't count
+// towards cpu and memory; CreatePod validation should prevent those
+// from existing in the first place.
+type namespaceUsage struct {
+	cpu    resource.Quantity
+	memory resource.Quantity
+	pods   int
+}
+
+func getNamespaceUsage(podRegistry *registry.PodRegistry, namespace string) (namespaceUsage, error) {
+	usage := namespaceUsage{}
+	podList, err := podRegistry.ListPods(func(p *api.Pod) bool {
+		return util.GetNamespaceFromString(p.Name) == namespace &&
+			!api.IsTerminalPodPhase(p.Status.Phase)
+	})
+	if err != nil {
+		return usage, util.WrapError(
+			err, "Error listing pods in namespace %s", namespace)
+	}
+	for _, p := range podList.Items {
+		usage.pods++
+		if q, err := resource.ParseQuantity(p.Spec.Resources.CPU); err == nil {
+			usage.cpu.Add(q)
+		}
+		if q, err := resource.ParseQuantity(p.Spec.Resources.Memory); err == nil {
+			usage.memory.Add(q)
+		}
+	}
+	return usage, nil
+}
+
+// checkNamespaceQuota rejects the pod if creating it would push its
+// namespace over the configured quota. Usage is computed from the
+// live (non-terminal) pods currently in the registry.
+func checkNamespaceQuota(podRegistry *registry.PodRegistry, quotas map[string]NamespaceQuota, milpaPod *api.Pod) error {
+	namespace := util.GetNamespaceFromString(milpaPod.Name)
+	quota, ok := quotaForNamespace(quotas, namespace)
+	if !ok {
+		return nil
+	}
+	usage, err := getNamespaceUsage(podRegistry, namespace)
+	if err != nil {
+		return err
+	}
+	if quota.Pods > 0 && usage.pods+1 > quota.Pods {
+		return fmt.Errorf("pod would exceed the pod quota for namespace %s: %d pods exist, quota is %d", namespace, usage.pods, quota.Pods)
+	}
+	if quota.CPU != "" {
+		limit, err := resource.ParseQuantity(quota.CPU)
+		if err != nil {
+			return util.WrapError(err, "Could not parse cpu quota %q for namespace %s", quota.CPU, namespace)
+		}
+		if requested, err := resource.ParseQuantity(milpaPod.Spec.Resources.CPU); err == nil {
+			total := usage.cpu.DeepCopy()
+			total.Add(requested)
+			if total.Cmp(limit) > 0 {
+				return fmt.Errorf("pod would exceed the cpu quota for namespace %s: requested %s, in use %s, quota is %s", namespace, requested.String(), usage.cpu.String(), limit.String())
+			}
+		}
+	}
+	if quota.Memory != "" {
+		limit, err := resource.ParseQuantity(quota.Memory)
+		if err != nil {
+			return util.WrapError(err, "Could not parse memory quota %q for namespace %s", quota.Memory, namespace)
+		}
+		if requested, err := resource.ParseQuantity(milpaPod.Spec.Resources.Memory); err == nil {
+			total := usage.memory.DeepCopy()
+			total.Add(requested)
+			if total.Cmp(limit) > 0 {
+				return fmt.Errorf("pod would exceed the memory quota for namespace %s: requested %s, in use %s, quota is %s", namespace, requested.String(), usage.memory.String(), limit.String())
+			}
+		}
+	}
+	return nil
+}
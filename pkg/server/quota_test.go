@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaForNamespace(t *testing.T) {
+	t.Parallel()
+	quotas := map[string]NamespaceQuota{
+		"default": {Pods: 10},
+		"tenant1": {Pods: 5},
+	}
+	quota, ok := quotaForNamespace(quotas, "tenant1")
+	assert.True(t, ok)
+	assert.Equal(t, 5, quota.Pods)
+	quota, ok = quotaForNamespace(quotas, "tenant2")
+	assert.True(t, ok)
+	assert.Equal(t, 10, quota.Pods)
+	_, ok = quotaForNamespace(map[string]NamespaceQuota{}, "tenant1")
+	assert.False(t, ok)
+}
+
+func TestCheckNamespaceQuota(t *testing.T) {
+	podReg, closer := registry.SetupTestPodRegistry()
+	defer closer()
+	makePod := func(namespace, name, cpu, memory string, phase api.PodPhase) *api.Pod {
+		pod := api.GetFakePod()
+		pod.Name = util.WithNamespace(namespace, name)
+		pod.Spec.Resources.CPU = cpu
+		pod.Spec.Resources.Memory = memory
+		pod.Status.Phase = phase
+		return pod
+	}
+	existing := []*api.Pod{
+		makePod("myns", "running", "1", "2Gi", api.PodRunning),
+		makePod("myns", "waiting", "1", "2Gi", api.PodWaiting),
+		makePod("myns", "done", "4", "16Gi", api.PodSucceeded),
+		makePod("otherns", "running", "4", "16Gi", api.PodRunning),
+	}
+	for _, pod := range existing {
+		_, err := podReg.CreatePod(pod)
+		assert.NoError(t, err)
+	}
+	tests := []struct {
+		name    string
+		quotas  map[string]NamespaceQuota
+		pod     *api.Pod
+		isError bool
+	}{
+		{
+			name:   "no quota configured",
+			quotas: nil,
+			pod:    makePod("myns", "new", "10", "100Gi", api.PodWaiting),
+		},
+		{
+			name:   "under quota",
+			quotas: map[string]NamespaceQuota{"myns": {CPU: "4", Memory: "8Gi", Pods: 3}},
+			pod:    makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+		},
+		{
+			name:    "over pod count quota",
+			quotas:  map[string]NamespaceQuota{"myns": {Pods: 2}},
+			pod:     makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+			isError: true,
+		},
+		{
+			name:    "over cpu quota",
+			quotas:  map[string]NamespaceQuota{"myns": {CPU: "2500m"}},
+			pod:     makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+			isError: true,
+		},
+		{
+			name:    "over memory quota",
+			quotas:  map[string]NamespaceQuota{"myns": {Memory: "5Gi"}},
+			pod:     makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+			isError: true,
+		},
+		{
+			name:   "terminal pods do not count",
+			quotas: map[string]NamespaceQuota{"myns": {CPU: "6"}},
+			pod:    makePod("myns", "new", "4", "2Gi", api.PodWaiting),
+		},
+		{
+			name:   "other namespaces do not count",
+			quotas: map[string]NamespaceQuota{"otherns": {Pods: 2}},
+			pod:    makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+		},
+		{
+			name:    "default quota applies",
+			quotas:  map[string]NamespaceQuota{"default": {Pods: 2}},
+			pod:     makePod("myns", "new", "1", "2Gi", api.PodWaiting),
+			isError: true,
+		},
+	}
+	for _, tc := range tests {
+		err := checkNamespaceQuota(podReg, tc.quotas, tc.pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/libkv/store"
@@ -36,13 +38,38 @@ const (
 	EventTrashDirectory            string        = "milpa/trash/events/"
 	EventTrashDirectoryPlaceholder string        = "milpa/trash/events/."
 	DefaultTTL                     time.Duration = 1 * time.Hour
+	// DefaultAggregationWindow mirrors the 10 minute window Kubernetes
+	// uses when folding repeated identical events into one record.
+	DefaultAggregationWindow time.Duration = 10 * time.Minute
 )
 
+// EventDedupFields lists the event fields that can make up the
+// aggregation dedup key.
+var EventDedupFields = []string{"object", "status", "source", "message"}
+
 type EventRegistry struct {
 	etcd.Storer
 	codec       api.MilpaCodec
 	eventSystem *events.EventSystem
 	ttl         time.Duration // Warning: TTLs don't work in BoltDB.
+
+	// A flapping pod or node can emit a flood of identical events.
+	// Instead of writing each one to storage, repeated events inside
+	// aggregationWindow bump the count and last-seen timestamp of the
+	// first stored copy.
+	aggregationWindow time.Duration
+	dedupKeyFields    []string
+	aggMutex          sync.Mutex
+	aggregated        map[string]*aggregatedEvent
+}
+
+// aggregatedEvent remembers where the stored copy of a repeated event
+// lives so later occurrences can update it instead of creating a new
+// event.
+type aggregatedEvent struct {
+	objRef    api.ObjectReference
+	name      string
+	firstSeen time.Time
 }
 
 func makeKey(prefix string, obj *api.ObjectReference, name string) string {
@@ -71,7 +98,15 @@ func makeDeletedEventKey(obj *api.ObjectReference, name string) string {
 }
 
 func NewEventRegistry(kvstore etcd.Storer, codec api.MilpaCodec, es *events.EventSystem) *EventRegistry {
-	reg := &EventRegistry{kvstore, codec, es, DefaultTTL}
+	reg := &EventRegistry{
+		Storer:            kvstore,
+		codec:             codec,
+		eventSystem:       es,
+		ttl:               DefaultTTL,
+		aggregationWindow: DefaultAggregationWindow,
+		dedupKeyFields:    EventDedupFields,
+		aggregated:        make(map[string]*aggregatedEvent),
+	}
 	_ = reg.Put(EventDirectoryPlaceholder, []byte("."), &store.WriteOptions{IsDir: true})
 	_ = reg.Put(EventTrashDirectoryPlaceholder, []byte("."), &store.WriteOptions{IsDir: true})
 	es.RegisterHandler(events.AllEvents, reg)
@@ -95,6 +130,11 @@ func (reg *EventRegistry) Handle(e events.Event) error {
 	ev.Status = string(e.Status)
 	ev.Source = e.Source
 	ev.Message = e.Message
+	ev.Count = 1
+	ev.LastSeen = api.Now()
+	if reg.aggregateEvent(ev) {
+		return nil
+	}
 	_, err := reg.CreateEvent(ev)
 	if err != nil {
 		klog.Errorf("Error creating event %v in storage: %v", ev, err)
@@ -103,6 +143,97 @@ func (reg *EventRegistry) Handle(e events.Event) error {
 	return nil
 }
 
+// SetAggregation overrides how long repeated events are folded into a
+// single stored event and which fields make two events identical (any
+// of "object", "status", "source" and "message").  A non-positive
+// window turns aggregation off, an empty field list keeps the default
+// of all fields.
+func (reg *EventRegistry) SetAggregation(window time.Duration, dedupKeyFields []string) {
+	reg.aggMutex.Lock()
+	defer reg.aggMutex.Unlock()
+	reg.aggregationWindow = window
+	if len(dedupKeyFields) > 0 {
+		reg.dedupKeyFields = dedupKeyFields
+	}
+}
+
+func (reg *EventRegistry) dedupKey(e *api.Event) string {
+	parts := make([]string, 0, len(reg.dedupKeyFields)+2)
+	for _, f := range reg.dedupKeyFields {
+		switch f {
+		case "object":
+			parts = append(parts, e.InvolvedObject.Kind,
+				e.InvolvedObject.Name, e.InvolvedObject.UID)
+		case "status":
+			parts = append(parts, e.Status)
+		case "source":
+			parts = append(parts, e.Source)
+		case "message":
+			parts = append(parts, e.Message)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// aggregateEvent folds ev into an identical event seen within the
+// aggregation window, bumping the stored event's count and last-seen
+// timestamp, and reports whether ev was absorbed.
+func (reg *EventRegistry) aggregateEvent(ev *api.Event) bool {
+	if reg.aggregationWindow <= 0 {
+		return false
+	}
+	reg.aggMutex.Lock()
+	defer reg.aggMutex.Unlock()
+	for key, agg := range reg.aggregated {
+		if time.Since(agg.firstSeen) > reg.aggregationWindow {
+			delete(reg.aggregated, key)
+		}
+	}
+	key := reg.dedupKey(ev)
+	agg, exists := reg.aggregated[key]
+	if exists {
+		stored, err := reg.GetEvent(&agg.objRef, agg.name)
+		if err == nil {
+			if stored.Count < 1 {
+				stored.Count = 1
+			}
+			stored.Count++
+			stored.LastSeen = ev.LastSeen
+			stored.Message = ev.Message
+			err = reg.updateEvent(stored)
+			if err == nil {
+				return true
+			}
+			klog.Warningf("Error updating aggregated event %s: %v",
+				stored.Name, err)
+		}
+		// The stored event was deleted or expired, start a new
+		// aggregation run with this event.
+	}
+	reg.aggregated[key] = &aggregatedEvent{
+		objRef:    ev.InvolvedObject,
+		name:      ev.Name,
+		firstSeen: time.Now(),
+	}
+	return false
+}
+
+// updateEvent overwrites the stored copy of an event, refreshing its
+// TTL.  It's only used by aggregation, Update remains unsupported for
+// clients.
+func (reg *EventRegistry) updateEvent(e *api.Event) error {
+	key := makeEventKey(&e.InvolvedObject, e.Name)
+	data, err := reg.codec.Marshal(e)
+	if err != nil {
+		return err
+	}
+	wo := store.WriteOptions{
+		IsDir: false,
+		TTL:   reg.ttl,
+	}
+	return reg.Storer.Put(key, data, &wo)
+}
+
 func (reg *EventRegistry) New() api.MilpaObject {
 	return api.NewEvent()
 }
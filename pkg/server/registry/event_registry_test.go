@@ -191,3 +191,36 @@ func TestListEventsByObject(t *testing.T) {
 		assert.Equal(t, o.Name, "myobject")
 	}
 }
+
+func TestEventDedupKey(t *testing.T) {
+	e1 := api.GetFakeEvent()
+	e1.Status = "pod-created"
+	e1.Source = "event-tests"
+	e1.Message = "Created pod"
+	e2 := e1.DeepCopy()
+
+	reg := &EventRegistry{dedupKeyFields: EventDedupFields}
+	assert.Equal(t, reg.dedupKey(e1), reg.dedupKey(e2))
+	e2.Message = "a different message"
+	assert.NotEqual(t, reg.dedupKey(e1), reg.dedupKey(e2))
+
+	// Leaving the message out of the key makes the events identical
+	// again.
+	reg.dedupKeyFields = []string{"object", "status", "source"}
+	assert.Equal(t, reg.dedupKey(e1), reg.dedupKey(e2))
+
+	e2.InvolvedObject.UID = "some-other-uid"
+	assert.NotEqual(t, reg.dedupKey(e1), reg.dedupKey(e2))
+}
+
+func TestAggregateEventDisabled(t *testing.T) {
+	reg := &EventRegistry{
+		aggregationWindow: -1,
+		dedupKeyFields:    EventDedupFields,
+		aggregated:        make(map[string]*aggregatedEvent),
+	}
+	e := api.GetFakeEvent()
+	assert.False(t, reg.aggregateEvent(e))
+	assert.False(t, reg.aggregateEvent(e))
+	assert.Len(t, reg.aggregated, 0)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/labels"
+)
+
+// ListOptions narrows and paginates registry list operations so
+// clients of large clusters don't have to pull every object.  A zero
+// Limit means no pagination and nil selectors match everything.
+type ListOptions struct {
+	Limit         int
+	Continue      string
+	LabelSelector labels.Selector
+	FieldSelector map[string]string
+}
+
+// ParseFieldSelector parses a comma separated list of field=value
+// requirements, e.g. "status.phase=Running,spec.nodeName=node-1".
+func ParseFieldSelector(selector string) (map[string]string, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	fields := make(map[string]string)
+	for _, requirement := range strings.Split(selector, ",") {
+		parts := strings.SplitN(requirement, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid field selector %q", requirement)
+		}
+		fields[parts[0]] = strings.TrimPrefix(parts[1], "=")
+	}
+	return fields, nil
+}
+
+func matchFields(objFields, selector map[string]string) bool {
+	for field, value := range selector {
+		if objFields[field] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchPod reports whether a pod satisfies the label and field
+// selectors.
+func (o *ListOptions) MatchPod(pod *api.Pod) bool {
+	if o.LabelSelector != nil &&
+		!o.LabelSelector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+	if len(o.FieldSelector) == 0 {
+		return true
+	}
+	namespace, name := splitNamespaceAndName(pod.Name)
+	return matchFields(map[string]string{
+		"metadata.name":      name,
+		"metadata.namespace": namespace,
+		"status.phase":       string(pod.Status.Phase),
+		"spec.nodeName":      pod.Status.BoundNodeName,
+	}, o.FieldSelector)
+}
+
+// MatchNode reports whether a node satisfies the label and field
+// selectors.
+func (o *ListOptions) MatchNode(node *api.Node) bool {
+	if o.LabelSelector != nil &&
+		!o.LabelSelector.Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	if len(o.FieldSelector) == 0 {
+		return true
+	}
+	return matchFields(map[string]string{
+		"metadata.name":     node.Name,
+		"status.phase":      string(node.Status.Phase),
+		"spec.instanceType": node.Spec.InstanceType,
+	}, o.FieldSelector)
+}
+
+func splitNamespaceAndName(n string) (string, string) {
+	parts := strings.SplitN(n, "_", 2)
+	if len(parts) < 2 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// PaginatePods sorts pods by name and returns the page of items
+// following the continue token, along with the token for the next
+// page ("" when this is the last page).
+func PaginatePods(list *api.PodList, limit int, continueToken string) (*api.PodList, string) {
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	start := continueIndex(len(list.Items), continueToken, func(i int) string {
+		return list.Items[i].Name
+	})
+	end, nextToken := pageEnd(len(list.Items), start, limit, func(i int) string {
+		return list.Items[i].Name
+	})
+	paged := api.NewPodList()
+	paged.Items = list.Items[start:end]
+	return paged, nextToken
+}
+
+// PaginateNodes is the node flavor of PaginatePods.
+func PaginateNodes(list *api.NodeList, limit int, continueToken string) (*api.NodeList, string) {
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	start := continueIndex(len(list.Items), continueToken, func(i int) string {
+		return list.Items[i].Name
+	})
+	end, nextToken := pageEnd(len(list.Items), start, limit, func(i int) string {
+		return list.Items[i].Name
+	})
+	paged := api.NewNodeList()
+	paged.Items = list.Items[start:end]
+	return paged, nextToken
+}
+
+// continueIndex returns the index of the first item after the
+// continue token (tokens are the name of the last item on the
+// previous page).
+func continueIndex(numItems int, continueToken string, nameAt func(int) string) int {
+	if continueToken == "" {
+		return 0
+	}
+	return sort.Search(numItems, func(i int) bool {
+		return nameAt(i) > continueToken
+	})
+}
+
+func pageEnd(numItems, start, limit int, nameAt func(int) string) (int, string) {
+	if limit <= 0 || start+limit >= numItems {
+		return numItems, ""
+	}
+	end := start + limit
+	return end, nameAt(end - 1)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/labels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginatePods(t *testing.T) {
+	list := api.NewPodList()
+	for i := 0; i < 5; i++ {
+		p := api.GetFakePod()
+		p.Name = fmt.Sprintf("pod-%d", i)
+		list.Items = append(list.Items, p)
+	}
+	page, continueToken := PaginatePods(list, 2, "")
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "pod-0", page.Items[0].Name)
+	assert.Equal(t, "pod-1", continueToken)
+
+	page, continueToken = PaginatePods(list, 2, continueToken)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "pod-2", page.Items[0].Name)
+	assert.Equal(t, "pod-3", continueToken)
+
+	page, continueToken = PaginatePods(list, 2, continueToken)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "pod-4", page.Items[0].Name)
+	assert.Equal(t, "", continueToken)
+
+	// No limit returns everything
+	page, continueToken = PaginatePods(list, 0, "")
+	assert.Len(t, page.Items, 5)
+	assert.Equal(t, "", continueToken)
+}
+
+func TestParseFieldSelector(t *testing.T) {
+	fields, err := ParseFieldSelector("status.phase=Running,metadata.name=foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"status.phase":  "Running",
+		"metadata.name": "foo",
+	}, fields)
+	_, err = ParseFieldSelector("nonsense")
+	assert.Error(t, err)
+	fields, err = ParseFieldSelector("")
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+}
+
+func TestListOptionsMatchPod(t *testing.T) {
+	pod := api.GetFakePod()
+	pod.Name = "default_mypod"
+	pod.Labels = map[string]string{"app": "web"}
+	pod.Status.Phase = api.PodRunning
+
+	selector, err := labels.Parse("app=web")
+	assert.NoError(t, err)
+	opts := ListOptions{
+		LabelSelector: selector,
+		FieldSelector: map[string]string{"status.phase": "Running"},
+	}
+	assert.True(t, opts.MatchPod(pod))
+
+	opts.FieldSelector = map[string]string{"status.phase": "Failed"}
+	assert.False(t, opts.MatchPod(pod))
+
+	selector, err = labels.Parse("app=db")
+	assert.NoError(t, err)
+	opts = ListOptions{LabelSelector: selector}
+	assert.False(t, opts.MatchPod(pod))
+
+	opts = ListOptions{
+		FieldSelector: map[string]string{
+			"metadata.name":      "mypod",
+			"metadata.namespace": "default",
+		},
+	}
+	assert.True(t, opts.MatchPod(pod))
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package registry
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"time"
 
@@ -40,6 +44,9 @@ type LogRegistry struct {
 	codec       api.MilpaCodec
 	eventSystem *events.EventSystem
 	ttl         time.Duration
+	// compression is the content encoding applied to logs on write,
+	// empty writes logs uncompressed.
+	compression string
 }
 
 func makeLogKey(creatorName, logName string) string {
@@ -52,12 +59,89 @@ func makeLogKey(creatorName, logName string) string {
 	}
 }
 
+// PreviousLogName is the registry log name under which the retained
+// log of a unit's terminated instance is saved.  instance is zero
+// based: a unit with a restart count of N runs instance N and
+// instance N-1 terminated most recently.
+func PreviousLogName(unitName string, instance int32) string {
+	return fmt.Sprintf("%s.prev.%d", unitName, instance)
+}
+
 func NewLogRegistry(kvstore etcd.Storer, codec api.MilpaCodec, es *events.EventSystem) *LogRegistry {
-	reg := &LogRegistry{kvstore, codec, es, DefaultLogTTL}
+	reg := &LogRegistry{kvstore, codec, es, DefaultLogTTL, ""}
 	_ = reg.Put(LogDirectoryPlaceholder, []byte("."), nil)
 	return reg
 }
 
+// SetRetention overrides how long logs are kept in the registry. The
+// TTL is applied when a log is written; logs already in the registry
+// keep the TTL they were written with. A non-positive ttl keeps the
+// default.
+func (reg *LogRegistry) SetRetention(ttl time.Duration) {
+	if ttl > 0 {
+		reg.ttl = ttl
+	}
+}
+
+// SetCompression sets the content encoding applied to logs on write.
+// Logs already in the registry keep the encoding they were written
+// with, a per-object marker makes reads of old entries work either
+// way. "" and "none" store logs uncompressed, "gzip" is the only
+// compression implemented so far.
+func (reg *LogRegistry) SetCompression(compression string) error {
+	switch compression {
+	case "", "none":
+		reg.compression = ""
+	case api.LogContentEncodingGzip:
+		reg.compression = api.LogContentEncodingGzip
+	default:
+		return fmt.Errorf("unsupported log compression %q", compression)
+	}
+	return nil
+}
+
+// compressLogContent gzips the content and wraps it in base64 so it
+// survives the trip through the JSON codec.
+func compressLogContent(content string) (string, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte(content)); err != nil {
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeLogContent reverses the content encoding recorded on the log
+// so readers always see plain text. Logs written before compression
+// existed carry no marker and pass through untouched.
+func decodeLogContent(log *api.LogFile) error {
+	switch log.ContentEncoding {
+	case "":
+		return nil
+	case api.LogContentEncodingGzip:
+		compressed, err := base64.StdEncoding.DecodeString(log.Content)
+		if err != nil {
+			return util.WrapError(err, "decoding gzipped log content")
+		}
+		gr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return util.WrapError(err, "decoding gzipped log content")
+		}
+		content, err := ioutil.ReadAll(gr)
+		if err != nil {
+			return util.WrapError(err, "decoding gzipped log content")
+		}
+		log.Content = string(content)
+		log.ContentEncoding = ""
+		return nil
+	default:
+		return fmt.Errorf("unknown log content encoding %q", log.ContentEncoding)
+	}
+}
+
 func (reg *LogRegistry) New() api.MilpaObject {
 	return api.NewLogFile()
 }
@@ -101,6 +185,17 @@ func (reg *LogRegistry) CreateLog(log *api.LogFile) (*api.LogFile, error) {
 // Create or overwrite the log, No updates at this time
 func (reg *LogRegistry) PutLog(log *api.LogFile) (*api.LogFile, error) {
 	key := makeLogKey(log.ParentObject.Name, log.Name)
+	if reg.compression == api.LogContentEncodingGzip && log.Content != "" {
+		// Compress a copy, the caller keeps its readable log.
+		stored := *log
+		compressed, err := compressLogContent(log.Content)
+		if err != nil {
+			return nil, util.WrapError(err, "Could not compress log content")
+		}
+		stored.Content = compressed
+		stored.ContentEncoding = api.LogContentEncodingGzip
+		log = &stored
+	}
 	data, err := reg.codec.Marshal(log)
 	if err != nil {
 		return nil, err
@@ -120,6 +215,17 @@ func (reg *LogRegistry) PutLog(log *api.LogFile) (*api.LogFile, error) {
 	return newLog, nil
 }
 
+// DeleteLog removes a saved log from the registry. Deleting a log
+// that is already gone is not an error.
+func (reg *LogRegistry) DeleteLog(creatorName, logName string) error {
+	key := makeLogKey(creatorName, logName)
+	err := reg.Storer.Delete(key)
+	if err != nil && err != store.ErrKeyNotFound {
+		return util.WrapError(err, "Could not delete log from registry")
+	}
+	return nil
+}
+
 func (reg *LogRegistry) GetLog(creatorName, logName string) (*api.LogFile, error) {
 	key := makeLogKey(creatorName, logName)
 	pair, err := reg.Storer.Get(key)
@@ -134,6 +240,9 @@ func (reg *LogRegistry) GetLog(creatorName, logName string) (*api.LogFile, error
 	if err != nil {
 		return nil, util.WrapError(err, "Error unmarshaling log from storage")
 	}
+	if err := decodeLogContent(log); err != nil {
+		return nil, util.WrapError(err, "Error decoding log content from storage")
+	}
 	return log, nil
 }
 
@@ -158,6 +267,10 @@ func (reg *LogRegistry) ListLogs(creatorName, logName string) (*api.LogFileList,
 			klog.Errorf("Error unmarshalling single log in list operation: %v", err)
 			continue
 		}
+		if err := decodeLogContent(log); err != nil {
+			klog.Errorf("Error decoding single log in list operation: %v", err)
+			continue
+		}
 		loglist.Items = append(loglist.Items, log)
 	}
 	return loglist, nil
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogContentRoundTrip(t *testing.T) {
+	content := "line one\nline two\nline three\n"
+	compressed, err := compressLogContent(content)
+	assert.NoError(t, err)
+	assert.NotEqual(t, content, compressed)
+	log := api.NewLogFile()
+	log.Content = compressed
+	log.ContentEncoding = api.LogContentEncodingGzip
+	err = decodeLogContent(log)
+	assert.NoError(t, err)
+	assert.Equal(t, content, log.Content)
+	assert.Equal(t, "", log.ContentEncoding)
+}
+
+func TestDecodeLogContent(t *testing.T) {
+	// entries written before compression existed carry no marker and
+	// pass through untouched
+	log := api.NewLogFile()
+	log.Content = "plain old log"
+	err := decodeLogContent(log)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain old log", log.Content)
+
+	// garbage that claims to be gzipped errors out
+	log.Content = "not really gzip"
+	log.ContentEncoding = api.LogContentEncodingGzip
+	err = decodeLogContent(log)
+	assert.Error(t, err)
+
+	// unknown encodings error out instead of handing back garbage
+	log.ContentEncoding = "zstd"
+	err = decodeLogContent(log)
+	assert.Error(t, err)
+}
+
+func TestSetCompression(t *testing.T) {
+	reg := &LogRegistry{}
+	assert.NoError(t, reg.SetCompression(""))
+	assert.Equal(t, "", reg.compression)
+	assert.NoError(t, reg.SetCompression("gzip"))
+	assert.Equal(t, api.LogContentEncodingGzip, reg.compression)
+	assert.NoError(t, reg.SetCompression("none"))
+	assert.Equal(t, "", reg.compression)
+	assert.Error(t, reg.SetCompression("zstd"))
+}
@@ -336,6 +336,28 @@ func (reg *NodeRegistry) UpdateStatus(node *api.Node) (*api.Node, error) {
 	return n, err
 }
 
+// SetNodeLabels stores labels derived from cloud metadata on the
+// node.  UpdateStatus only copies the status block, so labels get
+// their own setter.
+func (reg *NodeRegistry) SetNodeLabels(node *api.Node, labels map[string]string) (*api.Node, error) {
+	n, err := reg.AtomicUpdate(node.Name, func(in *api.Node) error {
+		in.Labels = labels
+		return nil
+	})
+	return n, err
+}
+
+// SetNodeVolumeSize records the size of the node's root volume after
+// it has been resized to satisfy a pod's request, so the node object
+// reflects the provisioned size.
+func (reg *NodeRegistry) SetNodeVolumeSize(node *api.Node, volumeSize string) (*api.Node, error) {
+	n, err := reg.AtomicUpdate(node.Name, func(in *api.Node) error {
+		in.Spec.Resources.VolumeSize = volumeSize
+		return nil
+	})
+	return n, err
+}
+
 func (reg *NodeRegistry) SetNodeDeletionTimestamp(node *api.Node) (*api.Node, error) {
 	n, err := reg.AtomicUpdate(node.Name, func(in *api.Node) error {
 		now := api.Now()
@@ -221,8 +221,35 @@ func (reg *PodRegistry) UpdatePodSpecAndLabels(p *api.Pod) (*api.Pod, error) {
 		}
 		copyObjectMetaForUpdate(&in.ObjectMeta, &p.ObjectMeta)
 		in.Spec = p.Spec
+		// Conditions in the update are merged into the stored status
+		// so readiness gate conditions can be patched through the
+		// regular update API.
+		for _, condition := range p.Status.Conditions {
+			api.SetPodCondition(&in.Status, condition)
+		}
+		return nil
+	})
+	if err == nil {
+		// Lets the pod controller push spec changes (e.g. an ephemeral
+		// debug unit added by kubectl debug) to a running cell right
+		// away instead of waiting for the next full sync.
+		reg.eventSystem.Emit(events.PodUpdated, "pod-registry", p, "Pod spec updated")
+	}
+	return p, err
+}
+
+// UpdatePodCondition sets or replaces a single condition in the pod's
+// status. External controllers (e.g. rollout controllers) use it to
+// satisfy readiness gates declared in the pod's spec.
+func (reg *PodRegistry) UpdatePodCondition(name string, condition api.PodCondition) (*api.Pod, error) {
+	p, err := reg.AtomicUpdate(name, func(in *api.Pod) error {
+		api.SetPodCondition(&in.Status, condition)
 		return nil
 	})
+	if err == nil {
+		eventMsg := fmt.Sprintf("Pod condition %s updated", condition.Type)
+		reg.eventSystem.Emit(events.PodUpdated, "pod-registry", p, eventMsg)
+	}
 	return p, err
 }
 
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/util"
+	"golang.org/x/net/context"
+	"k8s.io/klog"
+)
+
+func (s InstanceProvider) getPodController() (*PodController, error) {
+	ctl, exists := s.controllerManager.GetController("PodController")
+	if !exists {
+		return nil, fmt.Errorf("Fatal error: can't find the pod controller")
+	}
+	podController, ok := ctl.(*PodController)
+	if !ok {
+		return nil, fmt.Errorf("Fatal error: PodController has unexpected type %T", ctl)
+	}
+	return podController, nil
+}
+
+// ResyncPod forces an immediate re-poll of a running pod's unit
+// statuses from its cell, bypassing the normal status poll interval,
+// and returns the refreshed pod.  Useful when debugging a pod that
+// looks stale or right after poking at a cell by hand.
+func (s InstanceProvider) ResyncPod(context context.Context, request *clientapi.ResyncPodRequest) (*clientapi.APIReply, error) {
+	if !s.controllerManager.ControllersRunning() {
+		return notTheLeaderReply(), nil
+	}
+	podName := string(request.PodName)
+	if podName == "" {
+		return errToAPIReply(fmt.Errorf("A pod name is required")), nil
+	}
+	klog.V(2).Infof("ResyncPod request for pod %s", podName)
+	podController, err := s.getPodController()
+	if err != nil {
+		return errToAPIReply(err), nil
+	}
+	pod, err := podController.ResyncPodStatus(podName)
+	if err != nil {
+		return errToAPIReply(err), nil
+	}
+	body, err := s.Encoder.Marshal(pod)
+	if err != nil {
+		return errToAPIReply(util.WrapError(err, "Error serializing reply object")), nil
+	}
+	reply := clientapi.APIReply{
+		Status: 200,
+		Body:   body,
+	}
+	return &reply, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/util"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const (
+	// Tokens without an explicit expirationSeconds get the kubelet
+	// default of one hour.
+	defaultTokenExpirationSeconds = int64(3600)
+	// Refresh tokens once 80% of their lifetime has passed, matching
+	// the kubelet's token manager.
+	tokenRefreshFraction = 0.8
+)
+
+// tokenFetcher requests a bound service account token, typically via
+// the Kubernetes TokenRequest API.
+type tokenFetcher func(namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error)
+
+type cachedToken struct {
+	podName        string
+	namespace      string
+	serviceAccount string
+	audience       string
+	expiration     int64
+	token          *authenticationv1.TokenRequest
+	issuedAt       time.Time
+}
+
+func (ct *cachedToken) refreshDue(now time.Time) bool {
+	// The issuer may have shortened the requested validity, trust the
+	// returned expiration when it's set.
+	ttl := time.Duration(ct.expiration) * time.Second
+	if exp := ct.token.Status.ExpirationTimestamp; !exp.IsZero() {
+		ttl = exp.Time.Sub(ct.issuedAt)
+	}
+	refreshAt := ct.issuedAt.Add(
+		time.Duration(float64(ttl) * tokenRefreshFraction))
+	return now.After(refreshAt)
+}
+
+// serviceAccountTokenManager caches bound service account tokens for
+// pods so projected serviceAccountToken volumes don't hit the
+// TokenRequest API on every deploy.  Cached tokens are refreshed once
+// most of their lifetime has passed; the pod controller redeploys the
+// projected volumes of affected pods.
+type serviceAccountTokenManager struct {
+	sync.Mutex
+	fetch  tokenFetcher
+	tokens map[string]*cachedToken
+}
+
+func newServiceAccountTokenManager(fetch tokenFetcher) *serviceAccountTokenManager {
+	return &serviceAccountTokenManager{
+		fetch:  fetch,
+		tokens: make(map[string]*cachedToken),
+	}
+}
+
+func tokenCacheKey(podName, serviceAccount, audience string, expiration int64) string {
+	return fmt.Sprintf("%s/%s/%s/%d", podName, serviceAccount, audience, expiration)
+}
+
+// PodToken returns a bound token for the pod's service account,
+// requesting a fresh one if the cached token is missing or close to
+// expiring.
+func (m *serviceAccountTokenManager) PodToken(pod *api.Pod, proj *api.ServiceAccountTokenProjection) (string, error) {
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	expiration := defaultTokenExpirationSeconds
+	if proj.ExpirationSeconds != nil {
+		expiration = *proj.ExpirationSeconds
+	}
+	key := tokenCacheKey(pod.Name, serviceAccount, proj.Audience, expiration)
+	m.Lock()
+	ct, exists := m.tokens[key]
+	m.Unlock()
+	if exists && !ct.refreshDue(time.Now()) {
+		return ct.token.Status.Token, nil
+	}
+	tr := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expiration,
+		},
+	}
+	if proj.Audience != "" {
+		tr.Spec.Audiences = []string{proj.Audience}
+	}
+	token, err := m.fetch(pod.Namespace, serviceAccount, tr)
+	if err != nil {
+		if exists {
+			// Hand out the stale token rather than failing, it might
+			// still be valid and the next refresh can succeed.
+			return ct.token.Status.Token, nil
+		}
+		return "", util.WrapError(err, "Error requesting token for service account %s/%s", pod.Namespace, serviceAccount)
+	}
+	m.Lock()
+	m.tokens[key] = &cachedToken{
+		podName:        pod.Name,
+		namespace:      pod.Namespace,
+		serviceAccount: serviceAccount,
+		audience:       proj.Audience,
+		expiration:     expiration,
+		token:          token,
+		issuedAt:       time.Now(),
+	}
+	m.Unlock()
+	return token.Status.Token, nil
+}
+
+// podsDueForRefresh returns the names of pods holding cached tokens
+// that have passed their refresh threshold.
+func (m *serviceAccountTokenManager) podsDueForRefresh() []string {
+	m.Lock()
+	defer m.Unlock()
+	now := time.Now()
+	podNames := make(map[string]bool)
+	for _, ct := range m.tokens {
+		if ct.refreshDue(now) {
+			podNames[ct.podName] = true
+		}
+	}
+	pods := make([]string, 0, len(podNames))
+	for name := range podNames {
+		pods = append(pods, name)
+	}
+	return pods
+}
+
+// forgetPod drops cached tokens for a pod that went away.
+func (m *serviceAccountTokenManager) forgetPod(podName string) {
+	m.Lock()
+	defer m.Unlock()
+	for key, ct := range m.tokens {
+		if ct.podName == podName {
+			delete(m.tokens, key)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+func fakeTokenFetcher(calls *int, token string, err error) tokenFetcher {
+	return func(namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+		*calls++
+		if err != nil {
+			return nil, err
+		}
+		reply := tr.DeepCopy()
+		reply.Status.Token = token
+		return reply, nil
+	}
+}
+
+func TestServiceAccountTokenManagerCaches(t *testing.T) {
+	calls := 0
+	m := newServiceAccountTokenManager(fakeTokenFetcher(&calls, "tok-1", nil))
+	pod := api.GetFakePod()
+	pod.Namespace = "default"
+	proj := &api.ServiceAccountTokenProjection{
+		Audience: "vault",
+		Path:     "token",
+	}
+	token, err := m.PodToken(pod, proj)
+	assert.NoError(t, err)
+	assert.Equal(t, "tok-1", token)
+	assert.Equal(t, 1, calls)
+	// Second request within the token's lifetime comes from cache.
+	token, err = m.PodToken(pod, proj)
+	assert.NoError(t, err)
+	assert.Equal(t, "tok-1", token)
+	assert.Equal(t, 1, calls)
+	assert.Len(t, m.podsDueForRefresh(), 0)
+
+	// Age the token past its refresh threshold.
+	for _, ct := range m.tokens {
+		ct.issuedAt = time.Now().Add(-time.Hour)
+	}
+	assert.Equal(t, []string{pod.Name}, m.podsDueForRefresh())
+	_, err = m.PodToken(pod, proj)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	m.forgetPod(pod.Name)
+	assert.Len(t, m.tokens, 0)
+}
+
+func TestServiceAccountTokenManagerStaleOnError(t *testing.T) {
+	calls := 0
+	m := newServiceAccountTokenManager(fakeTokenFetcher(&calls, "tok-1", nil))
+	pod := api.GetFakePod()
+	pod.Namespace = "default"
+	proj := &api.ServiceAccountTokenProjection{Path: "token"}
+	_, err := m.PodToken(pod, proj)
+	assert.NoError(t, err)
+
+	// When a refresh fails we keep serving the stale token.
+	m.fetch = fakeTokenFetcher(&calls, "", fmt.Errorf("apiserver down"))
+	for _, ct := range m.tokens {
+		ct.issuedAt = time.Now().Add(-time.Hour)
+	}
+	token, err := m.PodToken(pod, proj)
+	assert.NoError(t, err)
+	assert.Equal(t, "tok-1", token)
+
+	// A miss with a failing fetcher is an error.
+	other := api.GetFakePod()
+	other.Namespace = "default"
+	_, err = m.PodToken(other, proj)
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// The sysctls kubernetes considers safe to set without explicit
+// operator opt-in.  Everything else must be listed in the kubelet
+// section of provider.yaml under allowedUnsafeSysctls.
+var safeSysctls = sets.NewString(
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.tcp_syncookies",
+)
+
+// Linux capabilities itzo knows how to apply to a unit.  Used to
+// reject pods early with a clear error instead of having them fail on
+// the cell.
+var knownCapabilities = sets.NewString(
+	"ALL",
+	"AUDIT_CONTROL", "AUDIT_READ", "AUDIT_WRITE", "BLOCK_SUSPEND",
+	"CHOWN", "DAC_OVERRIDE", "DAC_READ_SEARCH", "FOWNER", "FSETID",
+	"IPC_LOCK", "IPC_OWNER", "KILL", "LEASE", "LINUX_IMMUTABLE",
+	"MAC_ADMIN", "MAC_OVERRIDE", "MKNOD", "NET_ADMIN", "NET_BIND_SERVICE",
+	"NET_BROADCAST", "NET_RAW", "SETFCAP", "SETGID", "SETPCAP", "SETUID",
+	"SYSLOG", "SYS_ADMIN", "SYS_BOOT", "SYS_CHROOT", "SYS_MODULE",
+	"SYS_NICE", "SYS_PACCT", "SYS_PTRACE", "SYS_RAWIO", "SYS_RESOURCE",
+	"SYS_TIME", "SYS_TTY_CONFIG", "WAKE_ALARM",
+)
+
+// allowedUnsafeSysctls comes from the kubelet section of
+// provider.yaml and is set once at startup.  Patterns ending in "*"
+// allow a whole sysctl prefix, e.g. "kernel.shm*".
+var allowedUnsafeSysctls []string
+
+func SetAllowedUnsafeSysctls(sysctls []string) {
+	allowedUnsafeSysctls = sysctls
+}
+
+func sysctlAllowed(name string) bool {
+	if safeSysctls.Has(name) {
+		return true
+	}
+	for _, pattern := range allowedUnsafeSysctls {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSysctls(sysctls []v1.Sysctl) error {
+	for _, sysctl := range sysctls {
+		if !sysctlAllowed(sysctl.Name) {
+			return fmt.Errorf("sysctl %q is not safe and is not in the kubelet allowedUnsafeSysctls list", sysctl.Name)
+		}
+	}
+	return nil
+}
+
+func validateCapabilities(caps *v1.Capabilities) error {
+	if caps == nil {
+		return nil
+	}
+	for _, lists := range [][]v1.Capability{caps.Add, caps.Drop} {
+		for _, capability := range lists {
+			name := strings.TrimPrefix(string(capability), "CAP_")
+			if !knownCapabilities.Has(name) {
+				return fmt.Errorf("capability %q is not supported by the cell runtime", capability)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestValidateSysctls(t *testing.T) {
+	defer SetAllowedUnsafeSysctls(nil)
+	tests := []struct {
+		allowed []string
+		sysctl  string
+		isError bool
+	}{
+		{nil, "net.ipv4.tcp_syncookies", false},
+		{nil, "kernel.msgmax", true},
+		{[]string{"kernel.msgmax"}, "kernel.msgmax", false},
+		{[]string{"kernel.shm*"}, "kernel.shmmax", false},
+		{[]string{"kernel.shm*"}, "net.core.somaxconn", true},
+	}
+	for i, test := range tests {
+		SetAllowedUnsafeSysctls(test.allowed)
+		err := validateSysctls([]v1.Sysctl{{Name: test.sysctl, Value: "1"}})
+		if test.isError {
+			assert.Error(t, err, "test %d", i+1)
+		} else {
+			assert.NoError(t, err, "test %d", i+1)
+		}
+	}
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	assert.NoError(t, validateCapabilities(nil))
+	assert.NoError(t, validateCapabilities(&v1.Capabilities{
+		Add:  []v1.Capability{"NET_BIND_SERVICE", "CAP_NET_ADMIN"},
+		Drop: []v1.Capability{"ALL"},
+	}))
+	err := validateCapabilities(&v1.Capabilities{
+		Add: []v1.Capability{"NOT_A_CAPABILITY"},
+	})
+	assert.Error(t, err)
+}
@@ -19,13 +19,15 @@ package server
 import (
 	"fmt"
 	"net"
-	"os"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/docker/distribution/reference"
 	"github.com/docker/libkv/store"
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
 	"github.com/elotl/kip/pkg/api/validation"
 	"github.com/elotl/kip/pkg/certs"
 	"github.com/elotl/kip/pkg/clientapi"
@@ -33,6 +35,7 @@ import (
 	kubeclient "github.com/elotl/kip/pkg/k8sclient/clientset/versioned"
 	"github.com/elotl/kip/pkg/nodeclient"
 	"github.com/elotl/kip/pkg/portmanager"
+	"github.com/elotl/kip/pkg/server/audit"
 	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/cloud/azure"
 	"github.com/elotl/kip/pkg/server/events"
@@ -43,14 +46,22 @@ import (
 	"github.com/elotl/kip/pkg/util"
 	"github.com/elotl/kip/pkg/util/cloudinitfile"
 	"github.com/elotl/kip/pkg/util/instanceselector"
+	"github.com/elotl/kip/pkg/util/structlog"
 	"github.com/elotl/kip/pkg/util/timeoutmap"
+	"github.com/elotl/kip/pkg/util/traceutil"
+	vutil "github.com/elotl/kip/pkg/util/validation"
 	"github.com/elotl/kip/pkg/util/validation/field"
 	"github.com/elotl/node-cli/manager"
+	"github.com/uber-go/atomic"
 	"github.com/virtual-kubelet/virtual-kubelet/errdefs"
 	"github.com/virtual-kubelet/virtual-kubelet/trace"
+	octrace "go.opencensus.io/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/klog"
@@ -87,11 +98,38 @@ type InstanceProvider struct {
 	ItzoClientFactory nodeclient.ItzoClientFactoryer
 	cloudClient       cloud.CloudClient
 	controllerManager *ControllerManager
+	controllerID      string
 	nodeName          string
 	internalIP        string
 	startTime         time.Time
 	podNotifier       func(*v1.Pod)
 	portManager       *portmanager.PortManager
+	watchHub          *watchHub
+	events            *events.EventSystem
+	quotas            map[string]NamespaceQuota
+	etcdClient        etcd.Storer
+	cordoned          *atomic.Bool
+	defaultVolumeSize string
+	maxVolumeSize     string
+	allowedRuntimes   []string
+	// requireImageDigest mirrors cells.requireImageDigest; when set,
+	// pods must pin every container image by digest.
+	requireImageDigest bool
+	// privateIPOnly mirrors cells.privateIPOnly; when set, every pod
+	// is forced onto a cell without a public address.
+	privateIPOnly bool
+	// volumeEncrypted and volumeKMSKey mirror the cells-wide root
+	// volume encryption defaults, applied to pods that don't pick
+	// their own via annotations.
+	volumeEncrypted *bool
+	volumeKMSKey    string
+	// bootImageSpec and defaultInstanceType are kept for preflight
+	// checks, the controllers hold their own copies.
+	bootImageSpec       cloud.BootImageSpec
+	defaultInstanceType string
+	// auditTrail holds recent controller decisions, served by
+	// "kipctl audit".
+	auditTrail *audit.Trail
 }
 
 func validateWriteToEtcd(client *etcd.SimpleEtcd) error {
@@ -109,23 +147,40 @@ func validateWriteToEtcd(client *etcd.SimpleEtcd) error {
 	return nil
 }
 
-func setupEtcd(configFile, dataDir string, quit <-chan struct{}, wg *sync.WaitGroup) (*etcd.SimpleEtcd, error) {
-	// if we have client endpoints, don't start the server. This could
-	// change in the future if we want the embedded server to join
-	// existing etcd server, but, for now just don't start it.
+func setupEtcd(config EtcdConfig, quit <-chan struct{}, wg *sync.WaitGroup) (*etcd.SimpleEtcd, error) {
+	// If we have client endpoints, don't start the embedded server,
+	// connect to the external etcd cluster instead. The embedded
+	// server never joins an existing cluster.
 	var client *etcd.SimpleEtcd
-	klog.V(2).Infof("starting internal etcd")
-	etcdServer := etcd.EtcdServer{
-		ConfigFile: configFile,
-		DataDir:    dataDir,
-	}
-	err := etcdServer.Start(quit, wg)
-	if err != nil {
-		return nil, util.WrapError(
-			err, "error creating internal etcd storage backend")
+	if len(config.Client.Endpoints) > 0 {
+		klog.V(2).Infof("connecting to external etcd at %v",
+			config.Client.Endpoints)
+		var err error
+		client, err = etcd.NewEtcdClient(
+			config.Client.Endpoints,
+			config.Client.CertFile,
+			config.Client.KeyFile,
+			config.Client.CAFile,
+		)
+		if err != nil {
+			return nil, util.WrapError(
+				err, "error creating external etcd client")
+		}
+	} else {
+		klog.V(2).Infof("starting internal etcd")
+		etcdServer := etcd.EtcdServer{
+			ConfigFile:          config.Internal.ConfigFile,
+			DataDir:             config.Internal.DataDir,
+			MaintenanceInterval: time.Duration(config.Internal.MaintenanceInterval) * time.Second,
+		}
+		err := etcdServer.Start(quit, wg)
+		if err != nil {
+			return nil, util.WrapError(
+				err, "error creating internal etcd storage backend")
+		}
+		client = etcdServer.Client
 	}
-	client = etcdServer.Client
-	err = validateWriteToEtcd(client)
+	err := validateWriteToEtcd(client)
 	if err != nil {
 		return nil, util.WrapError(err, "fatal error: Could not write to etcd")
 	}
@@ -148,37 +203,46 @@ func ConfigureK8sKipClient() (*kubeclient.Clientset, *rest.Config, error) {
 	return clientset, config, nil
 }
 
-func ensureRegionUnchanged(etcdClient *etcd.SimpleEtcd, region string) error {
+func ensureRegionUnchanged(etcdClient etcd.Storer, region string) error {
 	klog.V(2).Infof("ensuring region has not changed")
-	var savedRegion string
-	pair, err := etcdClient.Get(etcdClusterRegionPath)
-	if err != nil {
-		if err != store.ErrKeyNotFound {
+	// Two controllers starting at the same time can race on creating
+	// the region key.  When the AtomicPut loses that race, re-read
+	// and compare instead of erroring out so both controllers
+	// converge on the same stored region.
+	var err error
+	for i := 0; i < etcdCASRetries; i++ {
+		var pair *store.KVPair
+		pair, err = etcdClient.Get(etcdClusterRegionPath)
+		if err == nil {
+			savedRegion := string(pair.Value)
+			if region != savedRegion {
+				return fmt.Errorf(
+					"error: region has changed from %s to %s. "+
+						"This is unsupported. "+
+						"To change regions, delete all existing kip resources and instances and delete the kip persistent volume",
+					savedRegion, region)
+			}
+			return nil
+		} else if err != store.ErrKeyNotFound {
 			return err
 		}
 		_, _, err = etcdClient.AtomicPut(etcdClusterRegionPath, []byte(region), nil, nil)
-		return err
-	}
-	savedRegion = string(pair.Value)
-	if region != savedRegion {
-		return fmt.Errorf(
-			"error: region has changed from %s to %s. "+
-				"This is unsupported. "+
-				"To change regions, delete all existing kip resources and instances and delete the kip persistent volume",
-			savedRegion, region)
+		if err == nil {
+			return nil
+		}
 	}
-	return nil
+	return err
 }
 
 // InstanceProvider should implement node.PodLifecycleHandler
-func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networkAgentSecret, clusterDNS, clusterDomain string, daemonEndpointPort int32, debugServer bool, rm *manager.ResourceManager, systemQuit <-chan struct{}) (*InstanceProvider, error) {
+func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networkAgentSecret, clusterDNS, clusterDomain string, daemonEndpointPort int32, debugServer bool, rm *manager.ResourceManager, systemQuit <-chan struct{}, configOverrides ...ConfigOverride) (*InstanceProvider, error) {
 	systemWG := &sync.WaitGroup{}
 
 	execer := utilexec.New()
 	ipt := utiliptables.New(execer, utiliptables.ProtocolIpv4)
 	portManager := portmanager.NewPortManager(ipt)
 
-	serverConfigFile, err := ParseConfig(configFilePath)
+	serverConfigFile, err := ParseConfig(configFilePath, configOverrides...)
 	if err != nil {
 		return nil, fmt.Errorf("loading Config file (%s) failed with error: %s",
 			configFilePath, err.Error())
@@ -188,11 +252,25 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		return nil, fmt.Errorf("invalid server config: %v", errs.ToAggregate())
 	}
 
+	structlog.SetJSONOutput(serverConfigFile.Logging.Format == LogFormatJSON)
+
+	// If we serve the kubelet API ourselves, advertise our port as
+	// the node's daemon endpoint instead of the command-line one.
+	if serverConfigFile.Kubelet.APIPort > 0 {
+		daemonEndpointPort = int32(serverConfigFile.Kubelet.APIPort)
+	}
+
+	// Storage and the event system get their own quit channel that is
+	// only closed after the controllers have drained, so in-flight
+	// operations can still write to etcd and flush their events
+	// during shutdown.
+	storageQuit := make(chan struct{})
+	storageWG := &sync.WaitGroup{}
+
 	etcdClient, err := setupEtcd(
-		serverConfigFile.Etcd.Internal.ConfigFile,
-		serverConfigFile.Etcd.Internal.DataDir,
-		systemQuit,
-		systemWG,
+		serverConfigFile.Etcd,
+		storageQuit,
+		storageWG,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("etcd error: %s", err)
@@ -211,6 +289,14 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 
 	klog.V(2).Infof("ControllerID: %s", controllerID)
 
+	cordoned, err := loadCordonState(etcdClient)
+	if err != nil {
+		return nil, fmt.Errorf("cordon state error: %s", err)
+	}
+	if cordoned {
+		klog.V(2).Infof("node is cordoned, new pods will be rejected")
+	}
+
 	klog.V(5).Infof("creating cert factory")
 	certFactory, err := certs.New(etcdClient)
 	if err != nil {
@@ -249,10 +335,13 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		cloudClient.GetAttributes().Provider,
 		cloudClient.GetAttributes().Region,
 		cloudClient.GetAttributes().Zone,
-		serverConfigFile.Cells.DefaultInstanceType)
+		serverConfigFile.Cells.DefaultInstanceType,
+		serverConfigFile.Cells.AllowedInstanceTypes,
+		serverConfigFile.Cells.BlockedInstanceTypes)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up instance selector %s", err)
 	}
+	instanceselector.SetOvercommitRatio(serverConfigFile.Cells.OvercommitRatio)
 
 	// Ugly: need to do validation of this field after we have setup
 	// the instanceselector
@@ -262,8 +351,10 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		return nil, fmt.Errorf("error validating provider.yaml: %v", errs.ToAggregate())
 	}
 
+	SetAllowedUnsafeSysctls(serverConfigFile.Kubelet.AllowedUnsafeSysctls)
+
 	klog.V(5).Infof("setting up events")
-	eventSystem := events.NewEventSystem(systemQuit, systemWG)
+	eventSystem := events.NewEventSystem(storageQuit, storageWG)
 
 	klog.V(5).Infof("setting up registry")
 	podRegistry := registry.NewPodRegistry(
@@ -272,8 +363,19 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		etcdClient, api.VersioningCodec{}, eventSystem)
 	eventRegistry := registry.NewEventRegistry(
 		etcdClient, api.VersioningCodec{}, eventSystem)
+	eventAggregationWindow := registry.DefaultAggregationWindow
+	if serverConfigFile.Events.AggregationWindow != 0 {
+		eventAggregationWindow = time.Duration(serverConfigFile.Events.AggregationWindow) * time.Second
+	}
+	eventRegistry.SetAggregation(
+		eventAggregationWindow, serverConfigFile.Events.DedupKeyFields)
 	logRegistry := registry.NewLogRegistry(
 		etcdClient, api.VersioningCodec{}, eventSystem)
+	logRetentionAge := time.Duration(serverConfigFile.Logs.RetentionAge) * time.Second
+	logRegistry.SetRetention(logRetentionAge)
+	if err := logRegistry.SetCompression(serverConfigFile.Logs.Compression); err != nil {
+		return nil, fmt.Errorf("log compression error: %s", err)
+	}
 	metricsRegistry := registry.NewMetricsRegistry(240)
 	kv := map[string]registry.Registryer{
 		"Pod":    podRegistry,
@@ -299,8 +401,22 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 
 	klog.V(5).Infof("determining connectivity to cells")
 	connectWithPublicIPs := cloudClient.ConnectWithPublicIPs()
-	itzoClientFactory := nodeclient.NewItzoFactory(
-		&certFactory.Root, *clientCert, connectWithPublicIPs)
+	itzoClientConfig := nodeclient.DefaultItzoClientConfig()
+	if serverConfigFile.Cells.Itzo.ConnectTimeout > 0 {
+		itzoClientConfig.ConnectTimeout = time.Duration(serverConfigFile.Cells.Itzo.ConnectTimeout) * time.Second
+	}
+	if serverConfigFile.Cells.Itzo.RequestTimeout > 0 {
+		itzoClientConfig.RequestTimeout = time.Duration(serverConfigFile.Cells.Itzo.RequestTimeout) * time.Second
+	}
+	itzoClientConfig.Retries = serverConfigFile.Cells.Itzo.Retries
+	itzoClientConfig.HostnameTemplate = serverConfigFile.Cells.Itzo.HostnameTemplate
+	itzoClientConfig.ProxyURL = serverConfigFile.Cells.Itzo.ProxyURL
+	var itzoClientFactory nodeclient.ItzoClientFactoryer = nodeclient.NewItzoFactory(
+		&certFactory.Root, *clientCert, connectWithPublicIPs, itzoClientConfig)
+	if serverConfigFile.Cloud.Mock != nil {
+		// the mock cloud provider has no real cells to talk to
+		itzoClientFactory = nodeclient.NewMockItzoClientFactory()
+	}
 	nodeDispenser := nodemanager.NewNodeDispenser()
 
 	klog.V(5).Infof("setting up health checks")
@@ -322,24 +438,48 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		)
 	}
 
+	klog.V(5).Infof("creating audit trail")
+	auditTrail, err := audit.NewTrail(serverConfigFile.Audit.Size, serverConfigFile.Audit.File)
+	if err != nil {
+		return nil, fmt.Errorf("error creating audit trail: %v", err)
+	}
+
 	klog.V(5).Infof("creating pod controller")
+	maxSpotInterruptions := serverConfigFile.Cells.MaxSpotInterruptions
+	if maxSpotInterruptions <= 0 {
+		maxSpotInterruptions = nodemanager.DefaultMaxSpotInterruptions
+	}
+	scaleInDelay := nodemanager.DefaultScaleInDelay
+	if serverConfigFile.Cells.ScaleInDelay != 0 {
+		scaleInDelay = time.Duration(serverConfigFile.Cells.ScaleInDelay) * time.Second
+	}
 	podController := &PodController{
-		podRegistry:            podRegistry,
-		logRegistry:            logRegistry,
-		metricsRegistry:        metricsRegistry,
-		nodeLister:             nodeRegistry,
-		resourceManager:        rm,
-		nodeDispenser:          nodeDispenser,
-		nodeClientFactory:      itzoClientFactory,
-		events:                 eventSystem,
-		cloudClient:            cloudClient,
-		controllerID:           controllerID,
-		nametag:                nametag,
-		kubernetesNodeName:     nodeName,
-		dnsConfigurer:          dnsConfigurer,
-		networkAgentKubeconfig: networkAgentKubeconfig,
-		statusInterval:         time.Duration(serverConfigFile.Cells.StatusInterval) * time.Second,
-		healthChecker:          healthChecker,
+		podRegistry:             podRegistry,
+		logRegistry:             logRegistry,
+		metricsRegistry:         metricsRegistry,
+		nodeRegistry:            nodeRegistry,
+		resourceManager:         rm,
+		nodeDispenser:           nodeDispenser,
+		nodeClientFactory:       itzoClientFactory,
+		events:                  eventSystem,
+		cloudClient:             cloudClient,
+		cloudStatus:             cloudStatus,
+		controllerID:            controllerID,
+		nametag:                 nametag,
+		kubernetesNodeName:      nodeName,
+		dnsConfigurer:           dnsConfigurer,
+		networkAgentKubeconfig:  networkAgentKubeconfig,
+		statusInterval:          time.Duration(serverConfigFile.Cells.StatusInterval) * time.Second,
+		statusPollConcurrency:   serverConfigFile.Cells.StatusPollConcurrency,
+		statusPollTimeout:       time.Duration(serverConfigFile.Cells.StatusPollTimeout) * time.Second,
+		healthChecker:           healthChecker,
+		defaultInstanceProfile:  serverConfigFile.Cells.LogDriver.InstanceProfile,
+		schedulingTimeout:       time.Duration(serverConfigFile.Cells.SchedulingTimeout) * time.Second,
+		failOnSchedulingTimeout: serverConfigFile.Cells.FailOnSchedulingTimeout,
+		maxSpotInterruptions:    maxSpotInterruptions,
+		podRetryLimit:           serverConfigFile.Cells.PodRetryLimit,
+		auditTrail:              auditTrail,
+		log:                     structlog.New("pod-controller"),
 	}
 
 	klog.V(5).Infof("creating image ID cache")
@@ -350,17 +490,48 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 	if err != nil {
 		return nil, fmt.Errorf("error in user supplied cloud-init file: %v", err)
 	}
+	cloudInitFile.SetSizeLimit(serverConfigFile.Cells.CloudInitSizeLimit)
+	cloudInitFile.SetCompression(serverConfigFile.Cells.CloudInitCompression)
 	fixedSizeVolume := cloudClient.GetAttributes().FixedSizeVolume
 
+	var agentHealthInterval time.Duration
+	agentFailureThreshold := 0
+	agentTerminateThreshold := 0
+	if hc := serverConfigFile.Cells.HealthCheck.Agent; hc != nil {
+		agentHealthInterval = time.Duration(hc.Interval) * time.Second
+		agentFailureThreshold = hc.FailureThreshold
+		agentTerminateThreshold = hc.TerminateThreshold
+	}
+
 	klog.V(5).Infof("creating node controller")
 	nodeController := &nodemanager.NodeController{
 		Config: nodemanager.NodeControllerConfig{
 			PoolInterval:      7 * time.Second,
 			HeartbeatInterval: 10 * time.Second,
 			ReaperInterval:    10 * time.Second,
+			BootTimeout:       time.Duration(serverConfigFile.Cells.BootTimeout) * time.Second,
+			CloudInitTimeout:  time.Duration(serverConfigFile.Cells.CloudInitTimeout) * time.Second,
 			ItzoVersion:       serverConfigFile.Cells.Itzo.Version,
 			ItzoURL:           serverConfigFile.Cells.Itzo.URL,
-			CellConfig:        serverConfigFile.Cells.CellConfig,
+			ItzoCanaryVersion: serverConfigFile.Cells.Itzo.CanaryVersion,
+			ItzoCanaryURL:     serverConfigFile.Cells.Itzo.CanaryURL,
+			ItzoCanaryPercent: serverConfigFile.Cells.Itzo.CanaryPercent,
+			CellConfig: cellConfigWithLogDriver(
+				cellConfigWithDNSCache(
+					serverConfigFile.Cells.CellConfig,
+					serverConfigFile.Cells.DNSCache),
+				serverConfigFile.Cells.LogDriver),
+			RegistryMirrors:         serverConfigFile.Cells.RegistryMirrors,
+			InsecureRegistryMirrors: serverConfigFile.Cells.InsecureRegistryMirrors,
+			BudgetMaxSpend:          serverConfigFile.Cells.Budget.MaxSpend,
+			BudgetWindowHours:       budgetWindowHours(serverConfigFile.Cells.Budget.Window),
+			StoppedPoolSize:         serverConfigFile.Cells.StoppedPool.MaxSize,
+			StoppedPoolMaxAge:       time.Duration(serverConfigFile.Cells.StoppedPool.MaxAgeSeconds) * time.Second,
+			AgentHealthInterval:     agentHealthInterval,
+			AgentFailureThreshold:   agentFailureThreshold,
+			AgentTerminateThreshold: agentTerminateThreshold,
+			MinInstanceLifetime:     time.Duration(serverConfigFile.Cells.MinInstanceLifetime) * time.Second,
+			MaxInstanceLifetime:     time.Duration(serverConfigFile.Cells.MaxInstanceLifetime) * time.Second,
 		},
 		NodeRegistry:  nodeRegistry,
 		LogRegistry:   logRegistry,
@@ -372,6 +543,8 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 			cloudStatus,
 			serverConfigFile.Cells.DefaultVolumeSize,
 			fixedSizeVolume,
+			maxSpotInterruptions,
+			scaleInDelay,
 		),
 		CloudClient:        cloudClient,
 		NodeClientFactory:  itzoClientFactory,
@@ -381,16 +554,25 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		CertificateFactory: certFactory,
 		CloudStatus:        cloudStatus,
 		BootImageSpec:      serverConfigFile.Cells.BootImageSpec,
+		FastBootImageSpec:  serverConfigFile.Cells.FastBootImageSpec,
+		AuditTrail:         auditTrail,
+		Log:                structlog.New("node-controller"),
 	}
 
 	klog.V(5).Infof("creating garbage controller")
 	garbageController := &GarbageController{
 		config: GarbageControllerConfig{
-			CleanInstancesInterval:  60 * time.Second,
-			CleanTerminatedInterval: 10 * time.Second,
+			CleanInstancesInterval:   60 * time.Second,
+			CleanTerminatedInterval:  10 * time.Second,
+			ReclaimOrphanedInstances: serverConfigFile.Cells.ReclaimOrphanedInstances,
+			OrphanGracePeriod:        time.Duration(serverConfigFile.Cells.OrphanedInstanceGracePeriod) * time.Second,
+			ReconcileInterval:        time.Duration(serverConfigFile.Cells.ReconcileInterval) * time.Second,
+			LogRetentionAge:          logRetentionAge,
+			LogRetentionSize:         serverConfigFile.Logs.RetentionSize,
 		},
 		podRegistry:  podRegistry,
 		nodeRegistry: nodeRegistry,
+		logRegistry:  logRegistry,
 		cloudClient:  cloudClient,
 		controllerID: controllerID,
 	}
@@ -404,9 +586,18 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 	klog.V(5).Infof("configuring k8s client")
 	k8sKipClient, k8sRestConfig, err := ConfigureK8sKipClient()
 	if err != nil {
-		klog.Errorln("Error configuring kubernetes kip client", err)
-		time.Sleep(3 * time.Second)
-		os.Exit(2)
+		return nil, util.WrapError(err, "Error configuring kubernetes kip client")
+	}
+
+	klog.V(5).Infof("setting up service account token manager")
+	k8sCoreClient, err := kubernetes.NewForConfig(k8sRestConfig)
+	if err != nil {
+		klog.Warningf("Could not create kubernetes client, projected service account tokens will be unavailable: %v", err)
+	} else {
+		podController.tokenManager = newServiceAccountTokenManager(
+			func(namespace, name string, tr *authenticationv1.TokenRequest) (*authenticationv1.TokenRequest, error) {
+				return k8sCoreClient.CoreV1().ServiceAccounts(namespace).CreateToken(name, tr)
+			})
 	}
 
 	klog.V(5).Infof("creating cell controller")
@@ -420,8 +611,7 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		nodeRegistry,
 	)
 	if err != nil {
-		klog.Error(err)
-		os.Exit(1)
+		return nil, util.WrapError(err, "Error creating cell controller")
 	}
 
 	klog.V(5).Infof("creating node status controller")
@@ -431,6 +621,11 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		daemonEndpointPort,
 		serverConfigFile.Kubelet.Capacity,
 		serverConfigFile.Kubelet.Labels,
+		podRegistry,
+		func() error {
+			_, err := etcdClient.Exists("/milpa/startup")
+			return err
+		},
 	)
 
 	controllers := map[string]Controller{
@@ -442,7 +637,26 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		"NodeStatusController": nodeStatusController,
 	}
 
-	if azClient, ok := cloudClient.(*azure.AzureClient); ok {
+	if serverConfigFile.Cells.MirrorKubernetesNodes {
+		if k8sCoreClient == nil {
+			klog.Warningf("Could not create node mirror controller: no kubernetes client available")
+		} else {
+			klog.V(5).Infof("creating node mirror controller")
+			controllers["NodeMirrorController"] = NewNodeMirrorController(
+				controllerID,
+				nodeName,
+				k8sCoreClient.CoreV1().Nodes(),
+				eventSystem,
+				nodeRegistry,
+			)
+		}
+	}
+
+	if limited, ok := cloudClient.(*cloud.LimitedClient); ok {
+		controllers["CloudAPILimiter"] = limited
+	}
+
+	if azClient, ok := cloud.Unwrap(cloudClient).(*azure.AzureClient); ok {
 		klog.V(5).Infof("creating azure image controller")
 		azureImageController := azure.NewImageController(
 			controllerID, serverConfigFile.Cells.BootImageSpec, azClient)
@@ -451,17 +665,50 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 	controllerManager := NewControllerManager(controllers)
 
 	s := &InstanceProvider{
-		Registries:        kv,
-		Encoder:           api.VersioningCodec{},
-		SystemQuit:        systemQuit,
-		SystemWaitGroup:   systemWG,
-		ItzoClientFactory: itzoClientFactory,
-		cloudClient:       cloudClient,
-		controllerManager: controllerManager,
-		nodeName:          nodeName,
-		internalIP:        internalIP,
-		startTime:         time.Now(),
-		portManager:       portManager,
+		Registries:          kv,
+		Encoder:             api.VersioningCodec{},
+		SystemQuit:          systemQuit,
+		SystemWaitGroup:     systemWG,
+		ItzoClientFactory:   itzoClientFactory,
+		cloudClient:         cloudClient,
+		controllerManager:   controllerManager,
+		controllerID:        controllerID,
+		nodeName:            nodeName,
+		internalIP:          internalIP,
+		startTime:           time.Now(),
+		portManager:         portManager,
+		events:              eventSystem,
+		quotas:              serverConfigFile.Quotas,
+		etcdClient:          etcdClient,
+		cordoned:            atomic.NewBool(cordoned),
+		defaultVolumeSize:   serverConfigFile.Cells.DefaultVolumeSize,
+		maxVolumeSize:       serverConfigFile.Cells.MaxVolumeSize,
+		allowedRuntimes:     serverConfigFile.Cells.AllowedRuntimeClasses,
+		requireImageDigest:  serverConfigFile.Cells.RequireImageDigest,
+		privateIPOnly:       serverConfigFile.Cells.PrivateIPOnly != nil && *serverConfigFile.Cells.PrivateIPOnly,
+		volumeEncrypted:     serverConfigFile.Cells.VolumeEncrypted,
+		volumeKMSKey:        serverConfigFile.Cells.VolumeKMSKey,
+		bootImageSpec:       serverConfigFile.Cells.BootImageSpec,
+		defaultInstanceType: serverConfigFile.Cells.DefaultInstanceType,
+		auditTrail:          auditTrail,
+	}
+	s.watchHub = newWatchHub(eventSystem, s.Encoder)
+
+	if serverConfigFile.Kubelet.APIPort > 0 {
+		klog.V(2).Infof("serving the kubelet API on port %d",
+			serverConfigFile.Kubelet.APIPort)
+		err = s.ServeKubeletAPI(
+			serverConfigFile.Kubelet.APIPort, certFactory, systemQuit, systemWG)
+		if err != nil {
+			return nil, fmt.Errorf("error starting kubelet API server: %v", err)
+		}
+	}
+
+	if serverConfigFile.Debug.PprofAddress != "" {
+		err = servePprof(serverConfigFile.Debug.PprofAddress, systemQuit, systemWG)
+		if err != nil {
+			return nil, fmt.Errorf("error starting pprof debug server: %v", err)
+		}
 	}
 
 	klog.V(5).Infof("registering internal event handlers")
@@ -470,12 +717,48 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 	eventSystem.RegisterHandler(events.PodUpdated, s)
 	eventSystem.RegisterHandler(events.PodEjected, s)
 
+	if serverConfigFile.Webhook.PodPhaseURL != "" {
+		klog.V(2).Infof("registering pod phase webhook for %s",
+			serverConfigFile.Webhook.PodPhaseURL)
+		webhook := NewPodPhaseWebhook(
+			serverConfigFile.Webhook.PodPhaseURL,
+			serverConfigFile.Webhook.Retries,
+		)
+		for _, status := range []string{
+			events.PodCreated,
+			events.PodRunning,
+			events.PodUpdated,
+			events.PodTerminated,
+			events.PodEjected,
+		} {
+			eventSystem.RegisterHandler(status, webhook)
+		}
+	}
+
 	klog.V(5).Infof("starting controller manager")
 	go controllerManager.Start()
-	go controllerManager.WaitForShutdown(systemQuit, systemWG)
+	go func() {
+		systemWG.Add(1)
+		defer systemWG.Done()
+		controllerManager.WaitForShutdown(systemQuit, systemWG)
+		// The controllers have drained, nothing writes to etcd or
+		// emits events anymore; flush and close storage.
+		klog.V(2).Infof("controllers stopped, shutting down storage")
+		close(storageQuit)
+		storageWG.Wait()
+	}()
 
 	controllerManager.StartControllers()
 
+	klog.V(5).Infof("starting provider config watcher")
+	go s.watchProviderConfig(
+		configFilePath,
+		configOverrides,
+		serverConfigFile.Kubelet.Capacity,
+		systemQuit,
+		systemWG,
+	)
+
 	if ctrl, ok := controllers["ImageController"]; ok {
 		klog.V(5).Infof("starting azure image controller")
 		azureImageController := ctrl.(*azure.ImageController)
@@ -490,6 +773,14 @@ func NewInstanceProvider(configFilePath, nodeName, internalIP, serverURL, networ
 		}
 	}
 
+	if s.volumeEncrypted != nil || s.volumeKMSKey != "" {
+		klog.V(5).Infof("validating volume encryption settings")
+		err = cloudClient.ValidateVolumeEncryption(s.volumeEncrypted, s.volumeKMSKey)
+		if err != nil {
+			return nil, util.WrapError(err, "failed to validate cells volume encryption settings")
+		}
+	}
+
 	klog.V(5).Infof("validating boot image spec")
 	err = validateBootImageSpec(
 		serverConfigFile.Cells.BootImageSpec, cloudClient)
@@ -582,15 +873,17 @@ func (p *InstanceProvider) Handle(ev events.Event) error {
 }
 
 func (p *InstanceProvider) Stop() {
-	quitTimeout := time.Duration(10)
+	// Give the controllers time to drain in-flight launches and
+	// binds and for storage to flush before giving up on them.
+	quitTimeout := 30 * time.Second
 	waitGroupDone := make(chan struct{})
 	go waitForWaitGroup(p.SystemWaitGroup, waitGroupDone)
 	select {
 	case <-waitGroupDone:
 		return
-	case <-time.After(time.Second * quitTimeout):
+	case <-time.After(quitTimeout):
 		klog.Errorf(
-			"Loops were still running after %d seconds, forcing exit",
+			"Loops were still running after %v, forcing exit",
 			quitTimeout)
 		return
 	}
@@ -638,18 +931,357 @@ func (p *InstanceProvider) getMetricsRegistry() *registry.MetricsRegistry {
 	return reg.(*registry.MetricsRegistry)
 }
 
+// checkPodResources rejects a pod at creation time if its resource
+// requests can't be satisfied by any instance type in the region.
+// Memory-backed emptyDir volumes live in instance memory so their size
+// limits get added to the pod's memory request before consulting the
+// instanceselector.
+func checkPodResources(milpaPod *api.Pod) error {
+	spec := &milpaPod.Spec
+	if spec.InstanceType != "" ||
+		(spec.Resources.ContainerInstance != nil && *spec.Resources.ContainerInstance) {
+		return nil
+	}
+	rs := spec.Resources
+	emptyDirBytes := int64(0)
+	for _, vol := range spec.Volumes {
+		if vol.EmptyDir != nil && vol.EmptyDir.Medium == api.StorageMediumMemory {
+			emptyDirBytes += vol.EmptyDir.SizeLimit
+		}
+	}
+	if emptyDirBytes > 0 {
+		memoryBytes := int64(0)
+		if rs.Memory != "" {
+			q, err := resource.ParseQuantity(rs.Memory)
+			if err != nil {
+				return util.WrapError(err, "Could not parse pod memory request %q", rs.Memory)
+			}
+			memoryBytes = q.Value()
+		}
+		rs.Memory = fmt.Sprintf(
+			"%.2fGi", float32(memoryBytes+emptyDirBytes)/float32(1024*1024*1024))
+	}
+	return instanceselector.ValidateResourceSpec(rs)
+}
+
+// checkPodVolumeSize applies the cluster's volume sizing policy to a
+// pod's translated volume size.  Pods asking for more disk than
+// cells.maxVolumeSize allows are rejected.  A size derived from
+// ephemeral-storage requests is a lower bound: when the default cell
+// volume already satisfies it, the default is kept; only an explicit
+// volume size annotation is taken as-is.
+func (p *InstanceProvider) checkPodVolumeSize(milpaPod *api.Pod) error {
+	volumeSize := milpaPod.Spec.Resources.VolumeSize
+	if volumeSize == "" {
+		return nil
+	}
+	size, err := resource.ParseQuantity(volumeSize)
+	if err != nil {
+		return util.WrapError(err, "Could not parse pod volume size %q", volumeSize)
+	}
+	if p.maxVolumeSize != "" {
+		maxSize, err := resource.ParseQuantity(p.maxVolumeSize)
+		if err == nil && size.Cmp(maxSize) > 0 {
+			return fmt.Errorf("pod requests a %s volume but cell volumes are capped at %s (cells.maxVolumeSize)",
+				volumeSize, p.maxVolumeSize)
+		}
+	}
+	if milpaPod.Annotations[annotations.PodVolumeSize] != "" {
+		return nil
+	}
+	defaultSize, err := resource.ParseQuantity(p.defaultVolumeSize)
+	if err == nil && size.Cmp(defaultSize) <= 0 {
+		milpaPod.Spec.Resources.VolumeSize = ""
+	}
+	return nil
+}
+
+// checkPodHostname rejects a pod whose hostname or subdomain isn't an
+// RFC 1123 DNS label at creation time.  The same constraints are
+// enforced again when the hostname is generated at dispatch, but by
+// then a cell has already been booted for the pod.
+func checkPodHostname(milpaPod *api.Pod) error {
+	if hostname := milpaPod.Spec.Hostname; hostname != "" {
+		if msgs := vutil.IsDNS1123Label(hostname); len(msgs) != 0 {
+			return fmt.Errorf("pod hostname %q is not a valid DNS label: %s",
+				hostname, strings.Join(msgs, ";"))
+		}
+	}
+	if subdomain := milpaPod.Spec.Subdomain; subdomain != "" {
+		if msgs := vutil.IsDNS1123Label(subdomain); len(msgs) != 0 {
+			return fmt.Errorf("pod subdomain %q is not a valid DNS label: %s",
+				subdomain, strings.Join(msgs, ";"))
+		}
+	}
+	return nil
+}
+
+// checkPodRuntimeClass rejects a pod asking for a sandboxed container
+// runtime (via runtimeClassName or the runtime-class annotation) that
+// isn't listed in cells.allowedRuntimeClasses.  Itzo falls back to the
+// cell's default runtime for unknown values so an unchecked typo would
+// silently run the pod unsandboxed.
+func checkPodRuntimeClass(allowedRuntimes []string, milpaPod *api.Pod) error {
+	runtimeClass := milpaPod.Spec.RuntimeClass
+	if runtimeClass == "" {
+		return nil
+	}
+	if !util.StringInSlice(runtimeClass, allowedRuntimes) {
+		return fmt.Errorf("pod requests runtime class %q but it is not in cells.allowedRuntimeClasses", runtimeClass)
+	}
+	return nil
+}
+
+// checkPodPrivateIP forces the cells.privateIPOnly default onto the
+// pod and rejects a private-IP-only pod when the controller can only
+// reach cells over public addresses (i.e. it runs outside the cells'
+// network); launching such a cell would leave it unreachable forever.
+func (p *InstanceProvider) checkPodPrivateIP(milpaPod *api.Pod) error {
+	if p.privateIPOnly {
+		// the config default wins over the pod annotation, going
+		// private-only cluster wide is a security decision
+		milpaPod.Spec.Resources.PrivateIPOnly = true
+	}
+	if !milpaPod.Spec.Resources.PrivateIPOnly {
+		return nil
+	}
+	if p.cloudClient.ConnectWithPublicIPs() {
+		return fmt.Errorf("pod requests a cell without a public IP but this controller connects to cells via public addresses; private connectivity to the cell would not be viable")
+	}
+	return nil
+}
+
+// checkPodVolumeEncryption applies the cells-wide root volume
+// encryption default to pods that don't choose their own settings and
+// has the cloud client check the result; the config default is only
+// applied as a whole so a pod opting out of encryption doesn't pick
+// up the cluster's KMS key.
+func (p *InstanceProvider) checkPodVolumeEncryption(milpaPod *api.Pod) error {
+	if milpaPod.Spec.Resources.VolumeEncrypted == nil &&
+		milpaPod.Spec.Resources.VolumeKMSKey == "" {
+		milpaPod.Spec.Resources.VolumeEncrypted = p.volumeEncrypted
+		milpaPod.Spec.Resources.VolumeKMSKey = p.volumeKMSKey
+	}
+	return p.cloudClient.ValidateVolumeEncryption(
+		milpaPod.Spec.Resources.VolumeEncrypted,
+		milpaPod.Spec.Resources.VolumeKMSKey)
+}
+
+// checkPodVMSecurity has the cloud client check the pod's shielded
+// and confidential VM settings; only GCE supports them and
+// confidential VMs need an AMD EPYC based instance family.
+func (p *InstanceProvider) checkPodVMSecurity(milpaPod *api.Pod) error {
+	return p.cloudClient.ValidateVMSecurity(
+		milpaPod.Spec.Resources.ShieldedVM,
+		milpaPod.Spec.Resources.ConfidentialVM,
+		milpaPod.Spec.InstanceType)
+}
+
+// checkPodInstanceType rejects a pod that explicitly requests an
+// instance type the operator has excluded via the cells instance type
+// allow/block lists.  Pods sized from resource requests are covered
+// by the instance selector, which only considers permitted types.
+func checkPodInstanceType(milpaPod *api.Pod) error {
+	instanceType := milpaPod.Spec.InstanceType
+	if instanceType == "" {
+		return nil
+	}
+	if !instanceselector.IsPermittedInstance(instanceType) {
+		return fmt.Errorf("pod requests instance type %q but it is excluded by the operator's instance type allow/block lists", instanceType)
+	}
+	return nil
+}
+
+// checkPodDedicatedCPUs rejects a pod that requests more pinned
+// cores than its instance type (explicit or chosen by the instance
+// selector) has.  A pod with pinned cores needs its whole cpuset to
+// itself, so the request can't be overcommitted away.
+func checkPodDedicatedCPUs(milpaPod *api.Pod) error {
+	numCPUs := milpaPod.Spec.Resources.DedicatedCPUs
+	if numCPUs == 0 {
+		return nil
+	}
+	instanceType, _, err := instanceselector.ResourcesToInstanceType(&milpaPod.Spec)
+	if err != nil {
+		return util.WrapError(err, "cannot check dedicated CPU request")
+	}
+	cores, found := instanceselector.InstanceCPUCount(instanceType)
+	if !found {
+		return nil
+	}
+	if float32(numCPUs) > cores {
+		return fmt.Errorf("pod requests %d dedicated cpus but instance type %s only has %g vCPUs", numCPUs, instanceType, cores)
+	}
+	return nil
+}
+
+// ulimitMaximums lists the ulimits pods may adjust and the
+// instance-level maximum for each, i.e. the kernel-level ceiling of
+// the cell images. A negative maximum means the value is only
+// bounded by the kernel. Limits that aren't listed can't be tuned
+// through kip.
+var ulimitMaximums = map[string]int64{
+	"core":    -1,
+	"cpu":     -1,
+	"data":    -1,
+	"fsize":   -1,
+	"memlock": -1,
+	"nofile":  1048576,
+	"nproc":   4194304,
+	"stack":   -1,
+}
+
+// validateUlimits rejects unknown limit names, soft limits above
+// their hard limit and values above the instance-level maximums in
+// ulimitMaximums.
+func validateUlimits(ulimits []api.Ulimit) error {
+	for _, u := range ulimits {
+		max, known := ulimitMaximums[u.Name]
+		if !known {
+			return fmt.Errorf("unknown ulimit %q", u.Name)
+		}
+		if u.Soft < 0 || u.Hard < 0 {
+			return fmt.Errorf("ulimit %s values must be >= 0", u.Name)
+		}
+		if u.Soft > u.Hard {
+			return fmt.Errorf("ulimit %s soft limit %d is above its hard limit %d", u.Name, u.Soft, u.Hard)
+		}
+		if max >= 0 && u.Hard > max {
+			return fmt.Errorf("ulimit %s hard limit %d is above the instance-level maximum of %d", u.Name, u.Hard, max)
+		}
+	}
+	return nil
+}
+
+func checkPodUlimits(milpaPod *api.Pod) error {
+	return validateUlimits(milpaPod.Spec.Ulimits)
+}
+
+// checkPodImageDigests rejects a pod whose container images aren't
+// pinned by digest (e.g. "nginx@sha256:...") when
+// cells.requireImageDigest is set.  A digest-pinned reference makes the
+// cell pull the exact bits the user reviewed no matter what the tag
+// points at by the time the cell boots.
+func checkPodImageDigests(milpaPod *api.Pod) error {
+	units := append([]api.Unit{}, milpaPod.Spec.InitUnits...)
+	units = append(units, milpaPod.Spec.Units...)
+	for _, unit := range units {
+		ref, err := reference.ParseNormalizedNamed(unit.Image)
+		if err != nil {
+			return fmt.Errorf("unit %s: cannot parse image reference %q: %v", unit.Name, unit.Image, err)
+		}
+		if _, isCanonical := ref.(reference.Canonical); !isCanonical {
+			return fmt.Errorf("unit %s: image %q is not pinned by digest but cells.requireImageDigest is set", unit.Name, unit.Image)
+		}
+	}
+	return nil
+}
+
 func (p *InstanceProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
 	ctx, span := trace.StartSpan(ctx, "CreatePod")
 	defer span.End()
 	ctx = addAttributes(ctx, span, namespaceKey, pod.Namespace, nameKey, pod.Name)
 	klog.V(5).Infof("CreatePod %q", pod.Name)
+	select {
+	case <-p.SystemQuit:
+		err := fmt.Errorf("controller is shutting down, not accepting new pods")
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	default:
+	}
+	if p.cordoned.Load() {
+		err := fmt.Errorf("node is cordoned, not accepting new pods")
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
 	milpaPod, err := k8sToMilpaPod(pod)
 	if err != nil {
 		klog.Errorf("CreatePod %q: %v", pod.Name, err)
 		return err
 	}
+	if err := checkPodResources(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := checkPodHostname(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.checkPodVolumeSize(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.checkPodPrivateIP(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.checkPodVolumeEncryption(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := checkPodInstanceType(milpaPod); err != nil {
+		p.events.Emit(events.PodInstanceBlocked, "instance-provider", milpaPod, err.Error())
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := checkPodDedicatedCPUs(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.checkPodVMSecurity(milpaPod); err != nil {
+		p.events.Emit(events.PodInstanceBlocked, "instance-provider", milpaPod, err.Error())
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := checkPodRuntimeClass(p.allowedRuntimes, milpaPod); err != nil {
+		p.events.Emit(events.PodRuntimeUnsupported, "instance-provider", milpaPod, err.Error())
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := checkPodUlimits(milpaPod); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if p.requireImageDigest {
+		if err := checkPodImageDigests(milpaPod); err != nil {
+			klog.Errorf("CreatePod %q: %v", pod.Name, err)
+			return err
+		}
+	}
+	if err := p.cloudClient.ValidateDataVolumes(milpaPod.Spec.DataVolumes); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.cloudClient.ValidateNetworkInterfaces(milpaPod.Spec.NetworkInterfaces, milpaPod.Spec.InstanceType); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	if err := p.cloudClient.ValidateLaunchOverrides(milpaPod.Spec.LaunchOverrides); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	isSpot := milpaPod.Spec.Spot.Policy == api.SpotAlways
+	if err := p.cloudClient.ValidatePlacement(&milpaPod.Spec.Placement, isSpot); err != nil {
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	// Stash our span context on the pod so the controllers' async
+	// spans (scheduling, node dispensing, dispatch) join this trace.
+	if sc, ok := traceutil.SpanContextFromContext(ctx); ok {
+		if milpaPod.Annotations == nil {
+			milpaPod.Annotations = make(map[string]string)
+		}
+		milpaPod.Annotations[annotations.PodTraceContext] = traceutil.EncodeSpanContext(sc)
+	}
 	podRegistry := p.getPodRegistry()
+	if err := checkNamespaceQuota(podRegistry, p.quotas, milpaPod); err != nil {
+		p.events.Emit(events.PodOverQuota, "instance-provider", milpaPod, err.Error())
+		klog.Errorf("CreatePod %q: %v", pod.Name, err)
+		return err
+	}
+	_, regSpan := octrace.StartSpan(ctx, "etcd.CreatePod")
 	_, err = podRegistry.CreatePod(milpaPod)
+	regSpan.End()
 	if err != nil {
 		klog.Errorf("CreatePod %q: %v", pod.Name, err)
 		return err
@@ -779,6 +1411,8 @@ func (p *InstanceProvider) ConfigureNode(ctx context.Context, n *v1.Node) {
 	ctrl := p.getNodeStatusController()
 	ctrl.UpdateNode(n)
 	n.Status = ctrl.GetNodeStatus()
+	// A cordoned node shows up as SchedulingDisabled.
+	n.Spec.Unschedulable = p.cordoned.Load()
 }
 
 // NotifyPods is called to set a pod notifier callback function. This should be
@@ -22,8 +22,11 @@ import (
 	"time"
 
 	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
 	"github.com/elotl/kip/pkg/etcd"
+	"github.com/elotl/kip/pkg/server/cloud"
 	"github.com/elotl/kip/pkg/server/registry"
+	"github.com/elotl/kip/pkg/util/instanceselector"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
 )
@@ -79,7 +82,7 @@ func TestEnsureRegionUnchanged(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-//func getPortMappings(containers []v1.Container) []v1.ContainerPort
+// func getPortMappings(containers []v1.Container) []v1.ContainerPort
 func TestGetPortMappings(t *testing.T) {
 	testCases := []struct {
 		containers   []v1.Container
@@ -150,3 +153,362 @@ func TestGetPortMappings(t *testing.T) {
 		assert.Equal(t, tc.portMappings, pms)
 	}
 }
+
+func TestCheckPodResources(t *testing.T) {
+	t.Parallel()
+	err := instanceselector.Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	assert.NoError(t, err)
+	testCases := []struct {
+		name    string
+		spec    api.PodSpec
+		isError bool
+	}{
+		{
+			name: "satisfiable request",
+			spec: api.PodSpec{
+				Resources: api.ResourceSpec{
+					CPU:    "1",
+					Memory: "1Gi",
+				},
+			},
+			isError: false,
+		},
+		{
+			name: "explicit instance type skips the check",
+			spec: api.PodSpec{
+				InstanceType: "t2.nano",
+				Resources: api.ResourceSpec{
+					Memory: "100000Gi",
+				},
+			},
+			isError: false,
+		},
+		{
+			name: "memory request too large",
+			spec: api.PodSpec{
+				Resources: api.ResourceSpec{
+					Memory: "100000Gi",
+				},
+			},
+			isError: true,
+		},
+		{
+			name: "memory-backed emptyDir pushes request over the top",
+			spec: api.PodSpec{
+				Resources: api.ResourceSpec{
+					Memory: "1Gi",
+				},
+				Volumes: []api.Volume{
+					{
+						Name: "scratch",
+						VolumeSource: api.VolumeSource{
+							EmptyDir: &api.EmptyDir{
+								Medium:    api.StorageMediumMemory,
+								SizeLimit: 100000 * 1024 * 1024 * 1024,
+							},
+						},
+					},
+				},
+			},
+			isError: true,
+		},
+	}
+	for _, tc := range testCases {
+		pod := api.GetFakePod()
+		pod.Spec = tc.spec
+		err := checkPodResources(pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestCheckPodVolumeSize(t *testing.T) {
+	t.Parallel()
+	p := &InstanceProvider{
+		defaultVolumeSize: "5Gi",
+		maxVolumeSize:     "20Gi",
+	}
+	testCases := []struct {
+		name         string
+		volumeSize   string
+		annotated    bool
+		isError      bool
+		expectedSize string
+	}{
+		{
+			name:         "no volume size requested",
+			volumeSize:   "",
+			expectedSize: "",
+		},
+		{
+			name:         "ephemeral-storage larger than default",
+			volumeSize:   "10Gi",
+			expectedSize: "10Gi",
+		},
+		{
+			name:         "ephemeral-storage within the default keeps the default",
+			volumeSize:   "2Gi",
+			expectedSize: "",
+		},
+		{
+			name:       "request over the cap is rejected",
+			volumeSize: "30Gi",
+			isError:    true,
+		},
+		{
+			name:         "annotated size is taken as-is",
+			volumeSize:   "2Gi",
+			annotated:    true,
+			expectedSize: "2Gi",
+		},
+		{
+			name:       "annotated size over the cap is rejected",
+			volumeSize: "30Gi",
+			annotated:  true,
+			isError:    true,
+		},
+		{
+			name:       "unparseable size is rejected",
+			volumeSize: "lots",
+			isError:    true,
+		},
+	}
+	for _, tc := range testCases {
+		pod := api.GetFakePod()
+		pod.Spec.Resources.VolumeSize = tc.volumeSize
+		if tc.annotated {
+			pod.Annotations = map[string]string{
+				annotations.PodVolumeSize: tc.volumeSize,
+			}
+		}
+		err := p.checkPodVolumeSize(pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedSize, pod.Spec.Resources.VolumeSize, tc.name)
+		}
+	}
+}
+
+func TestCheckPodVolumeSizeNoCap(t *testing.T) {
+	t.Parallel()
+	p := &InstanceProvider{
+		defaultVolumeSize: "5Gi",
+	}
+	pod := api.GetFakePod()
+	pod.Spec.Resources.VolumeSize = "500Gi"
+	err := p.checkPodVolumeSize(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, "500Gi", pod.Spec.Resources.VolumeSize)
+}
+
+func TestCheckPodRuntimeClass(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name         string
+		allowed      []string
+		runtimeClass string
+		isError      bool
+	}{
+		{
+			name:         "no runtime class requested",
+			allowed:      nil,
+			runtimeClass: "",
+		},
+		{
+			name:         "allowed runtime class",
+			allowed:      []string{"gvisor", "kata"},
+			runtimeClass: "gvisor",
+		},
+		{
+			name:         "runtime class not on the list",
+			allowed:      []string{"gvisor"},
+			runtimeClass: "kata",
+			isError:      true,
+		},
+		{
+			name:         "empty list disallows runtime selection",
+			allowed:      nil,
+			runtimeClass: "gvisor",
+			isError:      true,
+		},
+	}
+	for _, tc := range testCases {
+		pod := api.GetFakePod()
+		pod.Spec.RuntimeClass = tc.runtimeClass
+		err := checkPodRuntimeClass(tc.allowed, pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestCheckPodImageDigests(t *testing.T) {
+	t.Parallel()
+	digest := "sha256:b90dcf2a8ce95cd17d41a6aa1d71c6dc6a1b68e1b583fe9eb5eb8d9a1b8e61b5"
+	testCases := []struct {
+		name      string
+		image     string
+		initImage string
+		isError   bool
+	}{
+		{
+			name:      "all images pinned by digest",
+			image:     "nginx@" + digest,
+			initImage: "quay.io/elotl/init@" + digest,
+		},
+		{
+			name:    "tagged image is rejected",
+			image:   "nginx:1.19",
+			isError: true,
+		},
+		{
+			name:      "unpinned init image is rejected",
+			image:     "nginx@" + digest,
+			initImage: "quay.io/elotl/init:latest",
+			isError:   true,
+		},
+		{
+			name:    "unparseable image reference is rejected",
+			image:   "UPPERCASE/nope",
+			isError: true,
+		},
+	}
+	for _, tc := range testCases {
+		pod := api.GetFakePod()
+		pod.Spec.Units[0].Image = tc.image
+		if tc.initImage != "" {
+			pod.Spec.InitUnits = []api.Unit{{
+				Name:  "init",
+				Image: tc.initImage,
+			}}
+		}
+		err := checkPodImageDigests(pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestCheckPodHostname(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		hostname  string
+		subdomain string
+		isError   bool
+	}{
+		{
+			name: "no hostname defaults to the pod name",
+		},
+		{
+			name:      "valid hostname and subdomain",
+			hostname:  "kafka-0",
+			subdomain: "kafka",
+		},
+		{
+			name:     "invalid hostname",
+			hostname: "Kafka_0",
+			isError:  true,
+		},
+		{
+			name:      "invalid subdomain",
+			subdomain: "kafka.brokers",
+			isError:   true,
+		},
+	}
+	for _, tc := range testCases {
+		pod := api.GetFakePod()
+		pod.Spec.Hostname = tc.hostname
+		pod.Spec.Subdomain = tc.subdomain
+		err := checkPodHostname(pod)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestCheckPodPrivateIP(t *testing.T) {
+	t.Parallel()
+	cloudClient := cloud.NewMockClient()
+	p := InstanceProvider{cloudClient: cloudClient}
+	pod := api.GetFakePod()
+
+	// pod doesn't care about public IPs, nothing to check
+	assert.NoError(t, p.checkPodPrivateIP(pod))
+
+	// private-IP-only pod, controller connects via private IPs
+	pod.Spec.Resources.PrivateIPOnly = true
+	cloudClient.InsideVPC = false // the mock returns this from ConnectWithPublicIPs
+	assert.NoError(t, p.checkPodPrivateIP(pod))
+
+	// controller connects via public IPs, launching would strand the cell
+	cloudClient.InsideVPC = true
+	assert.Error(t, p.checkPodPrivateIP(pod))
+
+	// the config default forces private-IP-only onto every pod
+	cloudClient.InsideVPC = false
+	pod = api.GetFakePod()
+	p.privateIPOnly = true
+	assert.NoError(t, p.checkPodPrivateIP(pod))
+	assert.True(t, pod.Spec.Resources.PrivateIPOnly)
+}
+
+func TestValidateUlimits(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name    string
+		ulimits []api.Ulimit
+		isError bool
+	}{
+		{
+			name:    "no ulimits",
+			ulimits: nil,
+		},
+		{
+			name: "valid nofile and nproc",
+			ulimits: []api.Ulimit{
+				{Name: "nofile", Soft: 65536, Hard: 65536},
+				{Name: "nproc", Soft: 4096, Hard: 8192},
+			},
+		},
+		{
+			name:    "unknown limit name",
+			ulimits: []api.Ulimit{{Name: "sigpending", Soft: 1, Hard: 1}},
+			isError: true,
+		},
+		{
+			name:    "soft above hard",
+			ulimits: []api.Ulimit{{Name: "nofile", Soft: 2048, Hard: 1024}},
+			isError: true,
+		},
+		{
+			name:    "negative value",
+			ulimits: []api.Ulimit{{Name: "nofile", Soft: -1, Hard: 1024}},
+			isError: true,
+		},
+		{
+			name:    "above the instance-level maximum",
+			ulimits: []api.Ulimit{{Name: "nofile", Soft: 1048576, Hard: 2097152}},
+			isError: true,
+		},
+	}
+	for _, tc := range testCases {
+		err := validateUlimits(tc.ulimits)
+		if tc.isError {
+			assert.Error(t, err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/annotations"
+	"github.com/elotl/kip/pkg/api/labels"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/elotl/kip/pkg/util/instanceselector"
+	"k8s.io/klog"
+)
+
+// matchesSpreadSelector reports whether the pod carries every label
+// in the constraint's selector.  An empty selector matches no pods,
+// same as in kubernetes.
+func matchesSpreadSelector(pod *api.Pod, selector map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if pod.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// countPodsByTopology buckets the pods matching the constraint's
+// selector by the value the topology label has on their cells.  Pods
+// that haven't landed on a cell yet carry no node labels and are not
+// counted.
+func countPodsByTopology(pods []*api.Pod, tsc api.TopologySpreadConstraint) map[string]int {
+	counts := make(map[string]int)
+	for _, p := range pods {
+		if !matchesSpreadSelector(p, tsc.LabelSelector) {
+			continue
+		}
+		value := p.Status.NodeLabels[tsc.TopologyKey]
+		if value == "" {
+			continue
+		}
+		counts[value]++
+	}
+	return counts
+}
+
+// pickSpreadValue returns the candidate topology value with the
+// fewest matching pods and whether placing one more pod there keeps
+// the skew within maxSkew.  The skew is measured against the least
+// loaded domain overall, including domains the pod cannot use (e.g.
+// an instance type pinned by annotation excludes the others), which
+// is what makes a DoNotSchedule constraint unsatisfiable.  Candidate
+// order breaks ties so callers can prefer the pod's current
+// placement.
+func pickSpreadValue(counts map[string]int, candidates []string, maxSkew int32) (string, bool) {
+	if len(candidates) == 0 {
+		return "", false
+	}
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if counts[c] < counts[best] {
+			best = c
+		}
+	}
+	minCount := counts[best]
+	for _, count := range counts {
+		if count < minCount {
+			minCount = count
+		}
+	}
+	return best, int32(counts[best]+1-minCount) <= maxSkew
+}
+
+// spreadInstanceTypeCandidates returns the instance types the pod
+// could be placed on, its current type first.  A pod that pinned its
+// instance type with the instance type annotation only gets that
+// type; otherwise the fallback ladder supplies compatible types to
+// spread onto.
+func (c *PodController) spreadInstanceTypeCandidates(pod *api.Pod) []string {
+	candidates := []string{pod.Spec.InstanceType}
+	if pod.Annotations[annotations.PodInstanceType] != "" {
+		return candidates
+	}
+	return append(candidates,
+		instanceselector.FallbackInstanceTypes(pod.Spec.InstanceType)...)
+}
+
+// spreadZoneCandidates returns the availability zones the pod could
+// be placed in.  A pod with a placement keeps its zone; otherwise
+// the zones the cloud currently has capacity in are candidates.
+func (c *PodController) spreadZoneCandidates(pod *api.Pod) []string {
+	if pod.Spec.Placement.AvailabilityZone != "" {
+		return []string{pod.Spec.Placement.AvailabilityZone}
+	}
+	if c.cloudStatus == nil || !c.cloudStatus.SupportsAvailabilityZones() {
+		return nil
+	}
+	return c.cloudStatus.GetAvailableZones(
+		pod.Spec.InstanceType,
+		pod.Spec.Spot.Policy == api.SpotAlways,
+		pod.Spec.Resources.PrivateIPOnly)
+}
+
+// applyTopologySpread steers a waiting pod toward the topology
+// domain (instance type or availability zone) holding the fewest of
+// its sibling replicas before a cell is requested for it.  It
+// returns false when a DoNotSchedule constraint cannot be satisfied;
+// the pod stays waiting and an event records why.
+func (c *PodController) applyTopologySpread(pod *api.Pod) (*api.Pod, bool) {
+	if len(pod.Spec.TopologySpreadConstraints) == 0 {
+		return pod, true
+	}
+	podList, err := c.podRegistry.ListPods(func(p *api.Pod) bool {
+		return p.Name != pod.Name &&
+			(p.Status.Phase == api.PodDispatching ||
+				p.Status.Phase == api.PodRunning)
+	})
+	if err != nil {
+		klog.Errorf("Error listing pods for topology spread of %s: %v",
+			pod.Name, err)
+		return pod, true
+	}
+	changed := false
+	for _, tsc := range pod.Spec.TopologySpreadConstraints {
+		counts := countPodsByTopology(podList.Items, tsc)
+		var candidates []string
+		switch tsc.TopologyKey {
+		case labels.NodeInstanceType:
+			candidates = c.spreadInstanceTypeCandidates(pod)
+		case labels.NodeZone:
+			candidates = c.spreadZoneCandidates(pod)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		chosen, ok := pickSpreadValue(counts, candidates, tsc.MaxSkew)
+		if !ok && tsc.WhenUnsatisfiable != api.ScheduleAnyway {
+			c.markPodSpreadUnsatisfied(pod, tsc)
+			return pod, false
+		}
+		switch tsc.TopologyKey {
+		case labels.NodeInstanceType:
+			if chosen != pod.Spec.InstanceType {
+				klog.V(2).Infof("Spreading pod %s onto instance type %s (was %s)",
+					pod.Name, chosen, pod.Spec.InstanceType)
+				pod.Spec.InstanceType = chosen
+				changed = true
+			}
+		case labels.NodeZone:
+			if chosen != pod.Spec.Placement.AvailabilityZone {
+				klog.V(2).Infof("Spreading pod %s into availability zone %s",
+					pod.Name, chosen)
+				pod.Spec.Placement.AvailabilityZone = chosen
+				changed = true
+			}
+		}
+	}
+	if changed {
+		p, err := c.podRegistry.UpdatePodSpecAndLabels(pod)
+		if err != nil {
+			klog.Warningf("Could not update pod %s placement for topology spread: %v",
+				pod.Name, err)
+			return pod, true
+		}
+		pod = p
+	}
+	return pod, true
+}
+
+// markPodSpreadUnsatisfied records why the pod is being kept waiting,
+// mirroring how the scheduling timeout surfaces unschedulable pods.
+func (c *PodController) markPodSpreadUnsatisfied(pod *api.Pod, tsc api.TopologySpreadConstraint) {
+	msg := fmt.Sprintf(
+		"pod cannot be placed without exceeding the max skew of %d for topology key %s",
+		tsc.MaxSkew, tsc.TopologyKey)
+	if pod.Status.UnschedulableReason == msg {
+		// already marked, don't spam events on every control loop
+		return
+	}
+	klog.Warningf("Keeping pod %s waiting: %s", pod.Name, msg)
+	pod.Status.UnschedulableReason = msg
+	c.events.Emit(events.PodSpreadUnsatisfied, "pod-controller", pod, msg)
+	if _, err := c.podRegistry.UpdatePodStatus(pod, msg); err != nil {
+		klog.Errorf("Error updating unschedulable pod %s status: %v",
+			pod.Name, err)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/api/labels"
+	"github.com/stretchr/testify/assert"
+)
+
+func spreadPod(podLabels map[string]string, instanceType string) *api.Pod {
+	pod := api.GetFakePod()
+	pod.Labels = podLabels
+	pod.Status.NodeLabels = map[string]string{
+		labels.NodeInstanceType: instanceType,
+	}
+	return pod
+}
+
+func TestCountPodsByTopology(t *testing.T) {
+	appLabels := map[string]string{"app": "web"}
+	pods := []*api.Pod{
+		spreadPod(appLabels, "m5.large"),
+		spreadPod(appLabels, "m5.large"),
+		spreadPod(appLabels, "c5.large"),
+		spreadPod(map[string]string{"app": "db"}, "m5.large"),
+		spreadPod(appLabels, ""),
+	}
+	tsc := api.TopologySpreadConstraint{
+		TopologyKey:   labels.NodeInstanceType,
+		LabelSelector: appLabels,
+	}
+	counts := countPodsByTopology(pods, tsc)
+	assert.Equal(t, map[string]int{"m5.large": 2, "c5.large": 1}, counts)
+	// an empty selector matches no pods
+	tsc.LabelSelector = nil
+	assert.Empty(t, countPodsByTopology(pods, tsc))
+}
+
+func TestPickSpreadValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		counts     map[string]int
+		candidates []string
+		maxSkew    int32
+		expected   string
+		ok         bool
+	}{
+		{
+			name:       "empty counts keeps the first candidate",
+			counts:     map[string]int{},
+			candidates: []string{"m5.large", "c5.large"},
+			maxSkew:    1,
+			expected:   "m5.large",
+			ok:         true,
+		},
+		{
+			name:       "least loaded candidate wins",
+			counts:     map[string]int{"m5.large": 2, "c5.large": 1},
+			candidates: []string{"m5.large", "c5.large"},
+			maxSkew:    1,
+			expected:   "c5.large",
+			ok:         true,
+		},
+		{
+			name:       "ties break toward the earlier candidate",
+			counts:     map[string]int{"m5.large": 1, "c5.large": 1},
+			candidates: []string{"m5.large", "c5.large"},
+			maxSkew:    1,
+			expected:   "m5.large",
+			ok:         true,
+		},
+		{
+			name:       "pinned candidate exceeding the skew is unsatisfiable",
+			counts:     map[string]int{"m5.large": 2, "c5.large": 0},
+			candidates: []string{"m5.large"},
+			maxSkew:    1,
+			expected:   "m5.large",
+			ok:         false,
+		},
+		{
+			name:       "pinned candidate within the skew is fine",
+			counts:     map[string]int{"m5.large": 1, "c5.large": 1},
+			candidates: []string{"m5.large"},
+			maxSkew:    1,
+			expected:   "m5.large",
+			ok:         true,
+		},
+		{
+			name:       "no candidates",
+			counts:     map[string]int{},
+			candidates: nil,
+			maxSkew:    1,
+			expected:   "",
+			ok:         false,
+		},
+	}
+	for _, tc := range tests {
+		chosen, ok := pickSpreadValue(tc.counts, tc.candidates, tc.maxSkew)
+		assert.Equal(t, tc.expected, chosen, tc.name)
+		assert.Equal(t, tc.ok, ok, tc.name)
+	}
+}
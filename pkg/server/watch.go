@@ -0,0 +1,227 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+const (
+	// How many recent events we keep around so a reconnecting watcher
+	// can resume from its last seen version instead of relisting.
+	watchBufferSize = 1024
+	// Per-subscriber channel depth.  A subscriber that falls this far
+	// behind starts losing events rather than blocking the event
+	// system.
+	watchSubscriberBuffer = 128
+)
+
+// watchEventStatuses maps internal event statuses to the watch event
+// types clients see.  Statuses not listed here (services, secrets,
+// spot failures, ...) aren't surfaced through the watch API.
+var watchEventStatuses = map[string]string{
+	events.PodCreated:      clientapi.WatchAdded,
+	events.PodUpdated:      clientapi.WatchModified,
+	events.PodRunning:      clientapi.WatchModified,
+	events.PodEjected:      clientapi.WatchModified,
+	events.PodTerminated:   clientapi.WatchModified,
+	events.PodShouldDelete: clientapi.WatchDeleted,
+	events.NodeCreated:     clientapi.WatchAdded,
+	events.NodeRunning:     clientapi.WatchModified,
+	events.NodeCleaning:    clientapi.WatchModified,
+	events.NodePurged:      clientapi.WatchDeleted,
+}
+
+type watchEvent struct {
+	version int64
+	kind    string
+	event   clientapi.WatchEvent
+}
+
+type watchSubscriber struct {
+	kind    string
+	ch      chan watchEvent
+	dropped bool
+}
+
+// watchHub fans events out from the event system to watch streams.
+// It keeps a bounded buffer of recent events so clients can resume
+// after a dropped connection, and it never blocks the event system:
+// subscribers that can't keep up lose events instead.
+type watchHub struct {
+	sync.Mutex
+	codec          api.MilpaCodec
+	nextVersion    int64
+	buffer         []watchEvent
+	droppedThrough int64
+	subscribers    map[int]*watchSubscriber
+	nextSubID      int
+}
+
+func newWatchHub(eventSystem *events.EventSystem, codec api.MilpaCodec) *watchHub {
+	w := &watchHub{
+		codec:       codec,
+		nextVersion: 1,
+		subscribers: make(map[int]*watchSubscriber),
+	}
+	eventSystem.RegisterHandlerFunc(events.AllEvents, w.handleEvent)
+	return w
+}
+
+func (w *watchHub) handleEvent(ev events.Event) error {
+	eventType, watchable := watchEventStatuses[ev.Status]
+	if !watchable {
+		return nil
+	}
+	var kind string
+	switch ev.Object.(type) {
+	case *api.Pod:
+		kind = "Pod"
+	case *api.Node:
+		kind = "Node"
+	default:
+		return nil
+	}
+	body, err := w.codec.Marshal(ev.Object.(api.MilpaObject))
+	if err != nil {
+		return util.WrapError(err, "Error serializing %s watch event", kind)
+	}
+	w.Lock()
+	defer w.Unlock()
+	e := watchEvent{
+		version: w.nextVersion,
+		kind:    kind,
+		event: clientapi.WatchEvent{
+			Type:    eventType,
+			Version: w.nextVersion,
+			Object:  json.RawMessage(body),
+		},
+	}
+	w.nextVersion++
+	if len(w.buffer) >= watchBufferSize {
+		w.droppedThrough = w.buffer[0].version
+		w.buffer = w.buffer[1:]
+	}
+	w.buffer = append(w.buffer, e)
+	for _, sub := range w.subscribers {
+		if sub.kind != kind {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+			sub.dropped = false
+		default:
+			if !sub.dropped {
+				sub.dropped = true
+				klog.Warningf("Slow %s watcher, dropping events", kind)
+			}
+		}
+	}
+	return nil
+}
+
+// subscribe returns buffered events newer than sinceVersion along
+// with a channel of future events and a cancel function.  It errors
+// when sinceVersion predates the buffer; the client has missed events
+// and needs to relist instead of resuming.
+func (w *watchHub) subscribe(kind string, sinceVersion int64) ([]watchEvent, <-chan watchEvent, func(), error) {
+	w.Lock()
+	defer w.Unlock()
+	if sinceVersion > 0 && sinceVersion < w.droppedThrough {
+		return nil, nil, nil, fmt.Errorf(
+			"watch version %d is too old, relist and watch again", sinceVersion)
+	}
+	var backlog []watchEvent
+	for _, e := range w.buffer {
+		if e.kind == kind && e.version > sinceVersion {
+			backlog = append(backlog, e)
+		}
+	}
+	id := w.nextSubID
+	w.nextSubID++
+	sub := &watchSubscriber{
+		kind: kind,
+		ch:   make(chan watchEvent, watchSubscriberBuffer),
+	}
+	w.subscribers[id] = sub
+	cancel := func() {
+		w.Lock()
+		defer w.Unlock()
+		delete(w.subscribers, id)
+	}
+	return backlog, sub.ch, cancel, nil
+}
+
+func (s InstanceProvider) Watch(request *clientapi.WatchRequest, stream clientapi.Kip_WatchServer) error {
+	kind := string(request.Kind)
+	switch kind {
+	case "pod", "pods":
+		kind = "Pod"
+	case "node", "nodes":
+		kind = "Node"
+	}
+	if kind != "Pod" && kind != "Node" {
+		return fmt.Errorf("Asked to watch unknown object kind: %s", kind)
+	}
+	backlog, ch, cancel, err := s.watchHub.subscribe(kind, request.SinceVersion)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	send := func(e watchEvent) error {
+		data, err := json.Marshal(&e.event)
+		if err != nil {
+			return util.WrapError(err, "Error serializing watch event")
+		}
+		return stream.Send(&clientapi.StreamMsg{Data: data})
+	}
+	for _, e := range backlog {
+		// Events buffered before this watcher subscribed can show up
+		// again on its channel, skip those when they do.
+		if err := send(e); err != nil {
+			return err
+		}
+	}
+	lastSent := int64(0)
+	if len(backlog) > 0 {
+		lastSent = backlog[len(backlog)-1].version
+	}
+	for {
+		select {
+		case e := <-ch:
+			if e.version <= lastSent {
+				continue
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+			lastSent = e.version
+		case <-stream.Context().Done():
+			return nil
+		case <-s.SystemQuit:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/clientapi"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// The vendored json-iterator panics marshaling api objects under
+// newer Go runtimes, so hub tests use a stand-in codec.
+type stubCodec struct{}
+
+func (c stubCodec) Marshal(obj api.MilpaObject) ([]byte, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return []byte(`{}`), nil
+	}
+	return json.Marshal(map[string]string{"name": pod.Name})
+}
+
+func (c stubCodec) Unmarshal(data []byte, obj api.MilpaObject) error {
+	return nil
+}
+
+func makeWatchHub() *watchHub {
+	return &watchHub{
+		codec:       stubCodec{},
+		nextVersion: 1,
+		subscribers: make(map[int]*watchSubscriber),
+	}
+}
+
+func TestWatchHubFanout(t *testing.T) {
+	hub := makeWatchHub()
+	backlog, ch, cancel, err := hub.subscribe("Pod", 0)
+	assert.NoError(t, err)
+	assert.Len(t, backlog, 0)
+	defer cancel()
+
+	pod := api.GetFakePod()
+	err = hub.handleEvent(events.Event{
+		Status: events.PodCreated,
+		Object: pod,
+	})
+	assert.NoError(t, err)
+	e := <-ch
+	assert.Equal(t, clientapi.WatchAdded, e.event.Type)
+	assert.Equal(t, int64(1), e.version)
+	assert.Contains(t, string(e.event.Object), pod.Name)
+
+	// Node watchers don't see pod events
+	nodeBacklog, nodeCh, nodeCancel, err := hub.subscribe("Node", 0)
+	assert.NoError(t, err)
+	assert.Len(t, nodeBacklog, 0)
+	defer nodeCancel()
+	err = hub.handleEvent(events.Event{
+		Status: events.PodUpdated,
+		Object: pod,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, nodeCh, 0)
+
+	// Unwatched statuses are ignored
+	err = hub.handleEvent(events.Event{
+		Status: events.StartSpotFailed,
+		Object: pod,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ch, 1)
+}
+
+func TestWatchHubResume(t *testing.T) {
+	hub := makeWatchHub()
+	pod := api.GetFakePod()
+	for i := 0; i < 3; i++ {
+		err := hub.handleEvent(events.Event{
+			Status: events.PodUpdated,
+			Object: pod,
+		})
+		assert.NoError(t, err)
+	}
+	backlog, _, cancel, err := hub.subscribe("Pod", 1)
+	assert.NoError(t, err)
+	defer cancel()
+	assert.Len(t, backlog, 2)
+	assert.Equal(t, int64(2), backlog[0].version)
+	assert.Equal(t, int64(3), backlog[1].version)
+
+	// Resuming from a version that fell out of the buffer fails,
+	// the client has to relist.
+	hub.droppedThrough = 10
+	_, _, _, err = hub.subscribe("Pod", 5)
+	assert.Error(t, err)
+}
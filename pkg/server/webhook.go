@@ -0,0 +1,120 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/elotl/kip/pkg/util"
+	"k8s.io/klog"
+)
+
+const webhookRequestTimeout = 10 * time.Second
+
+// PodPhaseWebhook POSTs pod phase transitions to a user-configured
+// URL so external systems can track pod lifecycles without polling.
+// It's registered as an event handler for pod events and only fires
+// when a pod's phase actually changes.
+type PodPhaseWebhook struct {
+	url       string
+	retries   int
+	client    *http.Client
+	lastPhase map[string]api.PodPhase
+}
+
+// PodPhaseTransition is the JSON payload POSTed to the webhook URL.
+type PodPhaseTransition struct {
+	PodName       string       `json:"podName"`
+	Namespace     string       `json:"namespace"`
+	Phase         api.PodPhase `json:"phase"`
+	PreviousPhase api.PodPhase `json:"previousPhase"`
+	Time          time.Time    `json:"time"`
+}
+
+func NewPodPhaseWebhook(url string, retries int) *PodPhaseWebhook {
+	return &PodPhaseWebhook{
+		url:     url,
+		retries: retries,
+		client: &http.Client{
+			Timeout: webhookRequestTimeout,
+		},
+		lastPhase: make(map[string]api.PodPhase),
+	}
+}
+
+// Handle is called from the event system's dispatch loop so it must
+// not block, the actual POST happens in a goroutine.
+func (w *PodPhaseWebhook) Handle(ev events.Event) error {
+	pod, ok := ev.Object.(*api.Pod)
+	if !ok {
+		return nil
+	}
+	phase := pod.Status.Phase
+	previous, seen := w.lastPhase[pod.Name]
+	if seen && previous == phase {
+		return nil
+	}
+	if phase == api.PodSucceeded || phase == api.PodFailed ||
+		phase == api.PodTerminated {
+		delete(w.lastPhase, pod.Name)
+	} else {
+		w.lastPhase[pod.Name] = phase
+	}
+	namespace, name := util.SplitNamespaceAndName(pod.Name)
+	transition := PodPhaseTransition{
+		PodName:       name,
+		Namespace:     namespace,
+		Phase:         phase,
+		PreviousPhase: previous,
+		Time:          time.Now().UTC(),
+	}
+	go w.post(transition)
+	return nil
+}
+
+func (w *PodPhaseWebhook) post(transition PodPhaseTransition) {
+	body, err := json.Marshal(transition)
+	if err != nil {
+		klog.Errorf("Error serializing pod phase webhook payload: %v", err)
+		return
+	}
+	var lastErr error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		resp, err := w.client.Post(
+			w.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return
+		}
+		lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	klog.Errorf("Error posting pod phase transition for %s to %s after %d attempts: %v",
+		transition.PodName, w.url, w.retries+1, lastErr)
+}
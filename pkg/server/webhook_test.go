@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elotl/kip/pkg/api"
+	"github.com/elotl/kip/pkg/server/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodPhaseWebhook(t *testing.T) {
+	transitions := make(chan PodPhaseTransition, 10)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var transition PodPhaseTransition
+			err := json.NewDecoder(r.Body).Decode(&transition)
+			assert.NoError(t, err)
+			transitions <- transition
+		}))
+	defer srv.Close()
+
+	webhook := NewPodPhaseWebhook(srv.URL, 0)
+	pod := api.GetFakePod()
+	pod.Name = "default_testpod"
+	pod.Status.Phase = api.PodDispatching
+	err := webhook.Handle(events.Event{Status: events.PodUpdated, Object: pod})
+	assert.NoError(t, err)
+	select {
+	case transition := <-transitions:
+		assert.Equal(t, "testpod", transition.PodName)
+		assert.Equal(t, "default", transition.Namespace)
+		assert.Equal(t, api.PodDispatching, transition.Phase)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for webhook POST")
+	}
+
+	// Same phase again: no new POST
+	err = webhook.Handle(events.Event{Status: events.PodUpdated, Object: pod})
+	assert.NoError(t, err)
+
+	// Phase change fires another POST
+	pod.Status.Phase = api.PodRunning
+	err = webhook.Handle(events.Event{Status: events.PodRunning, Object: pod})
+	assert.NoError(t, err)
+	select {
+	case transition := <-transitions:
+		assert.Equal(t, api.PodRunning, transition.Phase)
+		assert.Equal(t, api.PodDispatching, transition.PreviousPhase)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for webhook POST")
+	}
+	assert.Len(t, transitions, 0)
+}
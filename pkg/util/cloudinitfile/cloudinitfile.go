@@ -20,6 +20,8 @@ limitations under the License.
 package cloudinitfile
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"regexp"
@@ -33,18 +35,24 @@ const semverRegexFmt string = `v?([0-9]+)(\.[0-9]+)(\.[0-9]+)?` +
 	`(\+([0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?`
 
 var (
-	itzoDir          = "/tmp/itzo"
-	ItzoVersionPath  = itzoDir + "/itzo_version"
-	ItzoURLPath      = itzoDir + "/itzo_url"
-	CellConfigPath   = itzoDir + "/cell_config.yaml"
-	cloudInitHeader  = []byte("#cloud-config\n")
-	maxCloudInitSize = 16000
-	semverRegex      = regexp.MustCompile("^" + semverRegexFmt + "$")
+	itzoDir             = "/tmp/itzo"
+	ItzoVersionPath     = itzoDir + "/itzo_version"
+	ItzoURLPath         = itzoDir + "/itzo_url"
+	CellConfigPath      = itzoDir + "/cell_config.yaml"
+	RegistryMirrorsPath = itzoDir + "/registry_mirrors.yaml"
+	cloudInitHeader     = []byte("#cloud-config\n")
+	maxCloudInitSize    = 16000
+	semverRegex         = regexp.MustCompile("^" + semverRegexFmt + "$")
 )
 
 type File struct {
 	userData CloudConfig
 	kipFiles map[string]CloudInitFile
+	// sizeLimit is the maximum size of the merged user-data in
+	// bytes, non-positive uses maxCloudInitSize.
+	sizeLimit int
+	// compress gzips the user-data when it is over the size limit.
+	compress bool
 }
 
 func New(path string) (*File, error) {
@@ -63,6 +71,34 @@ func New(path string) (*File, error) {
 	return f, nil
 }
 
+// SetSizeLimit overrides the maximum size of the merged user-data.
+// Cloud providers cap user-data size (AWS at 16KB) so only lower the
+// limit below the default. A non-positive limit keeps the default.
+func (f *File) SetSizeLimit(limit int) {
+	f.sizeLimit = limit
+}
+
+// SetCompression enables gzipping the user-data when it is over the
+// size limit.  Cloud-init transparently decompresses gzipped
+// user-data so this is safe on providers that pass user-data through
+// verbatim (e.g. AWS), buying considerable headroom for data-heavy
+// bootstraps.
+func (f *File) SetCompression(enabled bool) {
+	f.compress = enabled
+}
+
+func gzipContents(contents []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(contents); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func (f *File) ResetInstanceData() {
 	f.kipFiles = make(map[string]CloudInitFile)
 }
@@ -106,6 +142,29 @@ func (f *File) AddItzoURL(url string) {
 	f.AddKipFile(url, ItzoURLPath, "0444")
 }
 
+// RegistryMirrorConfig is the image registry mirror mapping written
+// to the instance for itzo: Mirrors maps registry hosts to the mirror
+// base URLs pulls are routed through, Insecure lists the mirror hosts
+// itzo may contact over plain HTTP.
+type RegistryMirrorConfig struct {
+	Mirrors  map[string]string `yaml:"mirrors"`
+	Insecure []string          `yaml:"insecure,omitempty"`
+}
+
+func (f *File) AddRegistryMirrors(mirrors map[string]string, insecure []string) {
+	if len(mirrors) == 0 {
+		return
+	}
+	buf, err := yaml.Marshal(RegistryMirrorConfig{
+		Mirrors:  mirrors,
+		Insecure: insecure,
+	})
+	if err != nil {
+		return
+	}
+	f.AddKipFile(string(buf), RegistryMirrorsPath, "0444")
+}
+
 func (f *File) AddCellConfig(cfg map[string]string) {
 	if len(cfg) == 0 {
 		return
@@ -131,8 +190,22 @@ func (f *File) Contents() ([]byte, error) {
 	}
 	cloudInitContent := cloudInitHeader
 	cloudInitContent = append(cloudInitContent, mergedContent...)
-	if len(cloudInitContent) > maxCloudInitSize {
-		return nil, fmt.Errorf("Cloud init data length is over 16K")
+	limit := f.sizeLimit
+	if limit <= 0 {
+		limit = maxCloudInitSize
+	}
+	if len(cloudInitContent) <= limit {
+		return cloudInitContent, nil
+	}
+	if !f.compress {
+		return nil, fmt.Errorf("Cloud init data is %d bytes, over the %d byte user-data limit, trim the cloud-init file or enable cells.cloudInitCompression", len(cloudInitContent), limit)
+	}
+	compressed, err := gzipContents(cloudInitContent)
+	if err != nil {
+		return nil, util.WrapError(err, "Could not compress cloud init data")
+	}
+	if len(compressed) > limit {
+		return nil, fmt.Errorf("Cloud init data is %d bytes after gzip (%d uncompressed), over the %d byte user-data limit, trim the cloud-init file", len(compressed), len(cloudInitContent), limit)
 	}
-	return cloudInitContent, nil
+	return compressed, nil
 }
@@ -17,9 +17,12 @@ limitations under the License.
 package cloudinitfile
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-yaml/yaml"
@@ -92,6 +95,34 @@ func TestWriteContent(t *testing.T) {
 	assert.Equal(t, expected, string(cloudInitContent))
 }
 
+func TestAddRegistryMirrors(t *testing.T) {
+	cif, err := New("")
+	assert.NoError(t, err)
+	cif.AddRegistryMirrors(nil, nil)
+	cloudInitContent, err := cif.Contents()
+	assert.NoError(t, err)
+	expected := string(cloudInitHeader) + "{}\n"
+	assert.Equal(t, expected, string(cloudInitContent))
+
+	mirrors := map[string]string{
+		"docker.io": "https://mirror.internal:5000",
+	}
+	cif.AddRegistryMirrors(mirrors, []string{"plain.internal"})
+	cloudInitContent, err = cif.Contents()
+	assert.NoError(t, err)
+	expected = string(cloudInitHeader) + fmt.Sprintf(`write_files:
+- content: |
+    mirrors:
+      docker.io: https://mirror.internal:5000
+    insecure:
+    - plain.internal
+  owner: root
+  path: %s
+  permissions: "0444"
+`, RegistryMirrorsPath)
+	assert.Equal(t, expected, string(cloudInitContent))
+}
+
 func TestAddItzoFuncs(t *testing.T) {
 	cif, err := New("")
 	assert.NoError(t, err)
@@ -158,3 +189,43 @@ func TestAddItzoFuncs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(cloudInitContent))
 }
+
+func TestContentsSizeLimit(t *testing.T) {
+	cif, err := New("")
+	assert.NoError(t, err)
+	// repetitive content compresses well
+	bigContent := strings.Repeat("all work and no play makes jack a dull boy\n", 50)
+	cif.AddKipFile(bigContent, "/tmp/itzo/big", "0444")
+	cloudInitContent, err := cif.Contents()
+	assert.NoError(t, err)
+
+	// over the limit without compression is a hard error that names
+	// the sizes involved
+	cif.SetSizeLimit(1000)
+	_, err = cif.Contents()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1000 byte user-data limit")
+
+	// with compression enabled the gzipped user-data fits
+	cif.SetCompression(true)
+	compressed, err := cif.Contents()
+	assert.NoError(t, err)
+	assert.True(t, len(compressed) <= 1000)
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudInitContent, decompressed)
+
+	// data that is still too big after gzip fails with both sizes
+	cif.SetSizeLimit(100)
+	_, err = cif.Contents()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "after gzip")
+
+	// content under the limit passes through uncompressed
+	cif.SetSizeLimit(0)
+	cloudInitContent, err = cif.Contents()
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(cloudInitContent, cloudInitHeader))
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -54,11 +55,50 @@ type instanceSelector struct {
 	// eventually need to make the GPU spec vary as well
 	memorySpecParser          func(resource.Quantity) float32
 	containerInstanceSelector func(*api.ResourceSpec) (int64, int64, error)
+	// allowedInstances and blockedInstances restrict which instance
+	// types or families the selector may pick, see
+	// InstanceTypePermitted.
+	allowedInstances []string
+	blockedInstances []string
+	// overcommitRatio divides CPU and memory requirements before
+	// matching instance types, see SetOvercommitRatio.
+	overcommitRatio float64
 }
 
 var selector *instanceSelector
 
-func Setup(cloud, region, zone, defaultInstanceType string) error {
+// instanceFamily returns the family portion of an instance type name,
+// e.g. "m5" for "m5.large" and "n1" for "n1-standard-1".
+func instanceFamily(instanceType string) string {
+	if i := strings.IndexAny(instanceType, ".-"); i > 0 {
+		return instanceType[:i]
+	}
+	return instanceType
+}
+
+// InstanceTypePermitted reports whether instanceType passes the given
+// allow/block lists.  List entries match either a full type name
+// ("m5.large") or a family ("m5").  A non-empty allow list permits
+// only its entries; the block list always wins.
+func InstanceTypePermitted(instanceType string, allowed, blocked []string) bool {
+	family := instanceFamily(instanceType)
+	for _, entry := range blocked {
+		if entry == instanceType || entry == family {
+			return false
+		}
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, entry := range allowed {
+		if entry == instanceType || entry == family {
+			return true
+		}
+	}
+	return false
+}
+
+func Setup(cloud, region, zone, defaultInstanceType string, allowedInstances, blockedInstances []string) error {
 	switch cloud {
 	case "aws":
 		d, err := getSelectorData(awsInstanceJson, region)
@@ -113,9 +153,22 @@ func Setup(cloud, region, zone, defaultInstanceType string) error {
 	default:
 		return fmt.Errorf("unknown cloud for instanceselector setup: %s", cloud)
 	}
+	selector.allowedInstances = allowedInstances
+	selector.blockedInstances = blockedInstances
 	return nil
 }
 
+// SetOvercommitRatio sets the cluster-wide ratio CPU and memory
+// requirements are divided by before matching instance types, letting
+// pods land on smaller (cheaper) instances than their requests imply.
+// Ratios below 1.0 are ignored, so the default preserves exact sizing.
+func SetOvercommitRatio(ratio float64) {
+	if selector == nil || ratio < 1.0 {
+		return
+	}
+	selector.overcommitRatio = ratio
+}
+
 func getSelectorData(data, regionOrZone string) ([]InstanceData, error) {
 	d := make(map[string][]InstanceData)
 	err := json.Unmarshal([]byte(data), &d)
@@ -177,6 +230,23 @@ func (instSel *instanceSelector) priceForCPUSpec(cpu float32, inst InstanceData)
 	}
 }
 
+// effectiveOvercommitRatio returns the overcommit ratio to apply to a
+// resource spec: the spec's own ratio if it carries a valid one (set
+// from the pod's overcommit-ratio annotation), otherwise the
+// cluster-wide ratio from SetOvercommitRatio.
+func (instSel *instanceSelector) effectiveOvercommitRatio(rs api.ResourceSpec) float64 {
+	if rs.OvercommitRatio != "" {
+		ratio, err := strconv.ParseFloat(rs.OvercommitRatio, 64)
+		if err != nil {
+			klog.Errorf("Error parsing overcommit ratio %q: %s",
+				rs.OvercommitRatio, err)
+		} else if ratio >= 1.0 {
+			return ratio
+		}
+	}
+	return instSel.overcommitRatio
+}
+
 func filterInstanceData(instances []InstanceData, predicate func(i InstanceData) bool) []InstanceData {
 	filtered := make([]InstanceData, 0, len(instances))
 	for _, inst := range instances {
@@ -199,7 +269,7 @@ func findCheapestInstance(matches []InstanceData) string {
 	return cheapestInstance
 }
 
-//The instance selector tries to find the minimum cost instance that
+// The instance selector tries to find the minimum cost instance that
 // satisfies all constraints in the resource spec.  This gets a bit
 // tricky to figure out the easiest way to satisfy constraints with
 // the t2.Unlimited option from AWS. For T2 instances, we try to
@@ -218,9 +288,15 @@ func (instSel *instanceSelector) getInstanceFromResources(rs api.ResourceSpec) (
 	if err != nil {
 		klog.Errorf("Error parsing GPU spec: %s", err)
 	}
+	if ratio := instSel.effectiveOvercommitRatio(rs); ratio > 1.0 {
+		memoryRequirement = float32(float64(memoryRequirement) / ratio)
+		cpuRequirements = float32(float64(cpuRequirements) / ratio)
+	}
 
 	matches := filterInstanceData(instSel.data, func(inst InstanceData) bool {
-		return !IsUnsupportedInstance(inst.InstanceType)
+		return !IsUnsupportedInstance(inst.InstanceType) &&
+			InstanceTypePermitted(inst.InstanceType,
+				instSel.allowedInstances, instSel.blockedInstances)
 	})
 
 	// Memory
@@ -285,6 +361,17 @@ func noResourceSpecified(ps *api.PodSpec) bool {
 		ps.Resources.GPU == ""
 }
 
+// IsPermittedInstance reports whether an explicitly requested
+// instance type passes the configured allow/block lists.  Used by
+// validation code in Kip.
+func IsPermittedInstance(instanceType string) bool {
+	if selector == nil {
+		return true
+	}
+	return InstanceTypePermitted(
+		instanceType, selector.allowedInstances, selector.blockedInstances)
+}
+
 // Used by validation code in Kip
 func IsUnsupportedInstance(instanceType string) bool {
 	if len(instanceType) < 2 {
@@ -324,6 +411,146 @@ func ResourcesToInstanceType(ps *api.PodSpec) (string, *bool, error) {
 	return instanceType, &needsSustainedCPU, nil
 }
 
+// InstancePrice returns the hourly on-demand price of an instance type
+// in the configured region.  The second return value is false if the
+// instance type isn't in the pricing data (or the selector hasn't been
+// initialized).
+func InstancePrice(instanceType string) (float32, bool) {
+	if selector == nil {
+		return 0.0, false
+	}
+	for _, inst := range selector.data {
+		if inst.InstanceType == instanceType {
+			return inst.Price, true
+		}
+	}
+	return 0.0, false
+}
+
+// maxInstanceFallbacks caps how many rungs the fallback ladder has
+// so a regional capacity shortage doesn't turn into a long walk
+// through ever more expensive instance types.
+const maxInstanceFallbacks = 3
+
+// FallbackInstanceTypes returns a ranked list of instance types that
+// can stand in for instanceType when the cloud has no capacity for
+// it: types with at least as much CPU, memory and GPU, possibly from
+// a different family, cheapest first.  The list excludes
+// instanceType itself and is capped at maxInstanceFallbacks entries.
+// It is empty if the selector hasn't been initialized or the type
+// isn't in the instance data.
+func FallbackInstanceTypes(instanceType string) []string {
+	if selector == nil {
+		return nil
+	}
+	var orig *InstanceData
+	for i := range selector.data {
+		if selector.data[i].InstanceType == instanceType {
+			orig = &selector.data[i]
+			break
+		}
+	}
+	if orig == nil {
+		return nil
+	}
+	matches := filterInstanceData(selector.data, func(inst InstanceData) bool {
+		return inst.InstanceType != instanceType &&
+			!IsUnsupportedInstance(inst.InstanceType) &&
+			InstanceTypePermitted(inst.InstanceType,
+				selector.allowedInstances, selector.blockedInstances) &&
+			inst.Price > 0.0 &&
+			inst.CPU >= orig.CPU &&
+			inst.Memory >= orig.Memory &&
+			inst.GPU >= orig.GPU &&
+			// never trade a fixed-performance type for a burstable one
+			(orig.Burstable || !inst.Burstable)
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Price < matches[j].Price
+	})
+	if len(matches) > maxInstanceFallbacks {
+		matches = matches[:maxInstanceFallbacks]
+	}
+	fallbacks := make([]string, 0, len(matches))
+	for _, inst := range matches {
+		fallbacks = append(fallbacks, inst.InstanceType)
+	}
+	return fallbacks
+}
+
+// InstanceCPUCount returns the number of vCPUs an instance type has
+// in the configured region.  The second return value is false if the
+// instance type isn't in the instance data (or the selector hasn't
+// been initialized).
+func InstanceCPUCount(instanceType string) (float32, bool) {
+	if selector == nil {
+		return 0.0, false
+	}
+	for _, inst := range selector.data {
+		if inst.InstanceType == instanceType {
+			return inst.CPU, true
+		}
+	}
+	return 0.0, false
+}
+
+// largestInstanceType returns the supported instance type in the
+// region with the most memory, using CPU count to break ties. It's
+// used to give users a hint when their resource request can't be
+// satisfied by anything in the region.
+func (instSel *instanceSelector) largestInstanceType() (InstanceData, bool) {
+	largest := InstanceData{}
+	found := false
+	for _, inst := range instSel.data {
+		if IsUnsupportedInstance(inst.InstanceType) ||
+			!InstanceTypePermitted(inst.InstanceType,
+				instSel.allowedInstances, instSel.blockedInstances) {
+			continue
+		}
+		if !found ||
+			inst.Memory > largest.Memory ||
+			(inst.Memory == largest.Memory && inst.CPU > largest.CPU) {
+			largest = inst
+			found = true
+		}
+	}
+	return largest, found
+}
+
+// ValidateResourceSpec returns an error if no instance type in the
+// region can satisfy the resource spec. The error names the largest
+// available instance type so users get immediate feedback instead of
+// a pod that stays pending forever.
+func ValidateResourceSpec(rs api.ResourceSpec) error {
+	if selector == nil {
+		msg := "fatal: instanceselector has not been initialized"
+		klog.Errorf(msg)
+		return fmt.Errorf(msg)
+	}
+	instanceType, _ := selector.getInstanceFromResources(rs)
+	if instanceType != "" {
+		return nil
+	}
+	if len(selector.allowedInstances) > 0 || len(selector.blockedInstances) > 0 {
+		// See if the request would have been satisfiable without the
+		// operator's allow/block lists so the user knows why the pod
+		// was rejected.
+		unfiltered := *selector
+		unfiltered.allowedInstances = nil
+		unfiltered.blockedInstances = nil
+		if it, _ := unfiltered.getInstanceFromResources(rs); it != "" {
+			return fmt.Errorf("the resource request can only be satisfied by instance type %s, which is excluded by the operator's instance type allow/block lists", it)
+		}
+	}
+	largest, found := selector.largestInstanceType()
+	if !found {
+		return fmt.Errorf("no supported instance types are available in this region")
+	}
+	return fmt.Errorf("no instance type in this region satisfies the resource request (cpu %q, memory %q, gpu %q); the largest available instance type is %s (%g vCPUs, %gGB memory, %d GPUs)",
+		rs.CPU, rs.Memory, rs.GPU,
+		largest.InstanceType, largest.CPU, largest.Memory, largest.GPU)
+}
+
 func ResourcesToContainerInstance(rs *api.ResourceSpec) (int64, int64, error) {
 	return selector.containerInstanceSelector(rs)
 }
@@ -26,13 +26,13 @@ import (
 
 func TestSetupInstanceSelector(t *testing.T) {
 	defaultInstanceType := "t2.nano"
-	err := Setup("aws", "us-east-1", "", defaultInstanceType)
+	err := Setup("aws", "us-east-1", "", defaultInstanceType, nil, nil)
 	assert.NoError(t, err)
 }
 
 func TestHappy(t *testing.T) {
 	defaultInstanceType := "t2.nano"
-	_ = Setup("aws", "us-east-1", "", defaultInstanceType)
+	_ = Setup("aws", "us-east-1", "", defaultInstanceType, nil, nil)
 	ps := api.PodSpec{}
 	ps.Resources.CPU = "1"
 	ps.Resources.Memory = "1Gi"
@@ -48,9 +48,41 @@ func TestHappy(t *testing.T) {
 	assert.Nil(t, sustainedCPU)
 }
 
+func TestOvercommitRatio(t *testing.T) {
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	ps := api.PodSpec{}
+	ps.Resources.CPU = "2"
+	ps.Resources.Memory = "8Gi"
+	ps.Resources.DedicatedCPU = true
+	inst, _, err := ResourcesToInstanceType(&ps)
+	assert.NoError(t, err)
+	assert.Equal(t, "m5.large", inst)
+
+	// with a cluster-wide ratio of 2 the pod fits on half the
+	// resources and lands on a smaller instance
+	SetOvercommitRatio(2.0)
+	inst, _, err = ResourcesToInstanceType(&ps)
+	assert.NoError(t, err)
+	assert.Equal(t, "c5.large", inst)
+
+	// the pod's own ratio (from the overcommit-ratio annotation) wins
+	// over the cluster-wide one
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	ps.Resources.OvercommitRatio = "2.0"
+	inst, _, err = ResourcesToInstanceType(&ps)
+	assert.NoError(t, err)
+	assert.Equal(t, "c5.large", inst)
+
+	// ratios below 1.0 are ignored
+	ps.Resources.OvercommitRatio = "0.5"
+	inst, _, err = ResourcesToInstanceType(&ps)
+	assert.NoError(t, err)
+	assert.Equal(t, "m5.large", inst)
+}
+
 func TestAWSGPUInstance(t *testing.T) {
 	defaultInstanceType := "t2.nano"
-	_ = Setup("aws", "us-east-1", "", defaultInstanceType)
+	_ = Setup("aws", "us-east-1", "", defaultInstanceType, nil, nil)
 	ps := api.PodSpec{}
 	ps.Resources.GPU = "1"
 	inst, _, err := ResourcesToInstanceType(&ps)
@@ -60,7 +92,7 @@ func TestAWSGPUInstance(t *testing.T) {
 }
 
 func TestGCEDefaultGPUInstance(t *testing.T) {
-	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro")
+	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro", nil, nil)
 	assert.NoError(t, err)
 	ps := api.PodSpec{}
 	ps.Resources.GPU = "1"
@@ -70,7 +102,7 @@ func TestGCEDefaultGPUInstance(t *testing.T) {
 }
 
 func TestGCESpecificGPUInstance(t *testing.T) {
-	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro")
+	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro", nil, nil)
 	assert.NoError(t, err)
 	ps := api.PodSpec{}
 	ps.Resources.GPU = "1 nvidia-tesla-p100"
@@ -80,7 +112,7 @@ func TestGCESpecificGPUInstance(t *testing.T) {
 }
 
 func TestHasInstanceType(t *testing.T) {
-	_ = Setup("aws", "us-east-1", "", "t2.nano")
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
 	ps := api.PodSpec{}
 	specType := "m4.xlarge"
 	ps.InstanceType = specType
@@ -103,14 +135,14 @@ func TestHasInstanceType(t *testing.T) {
 }
 
 func TestIsUnsupportedInstance(t *testing.T) {
-	_ = Setup("aws", "us-east-1", "", "t2.nano")
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
 	selector.unsupportedInstances.Insert("ZZ")
 	v := IsUnsupportedInstance("ZZ.top")
 	assert.True(t, v)
 }
 
 func TestNoMatch(t *testing.T) {
-	_ = Setup("aws", "us-east-1", "", "t2.nano")
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
 	ps := api.PodSpec{}
 	ps.Resources.CPU = "1000"
 	ps.Resources.Memory = "1"
@@ -123,7 +155,7 @@ func TestNoMatch(t *testing.T) {
 }
 
 func TestAWSResourcesToInstanceType(t *testing.T) {
-	_ = Setup("aws", "us-east-1", "", "t2.nano")
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
 	f := false
 	testCases := []struct {
 		Resources    api.ResourceSpec
@@ -189,7 +221,7 @@ func TestAWSResourcesToInstanceType(t *testing.T) {
 }
 
 func TestGCEResourcesToInstanceType(t *testing.T) {
-	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro")
+	err := Setup("gce", "us-west-1", "us-west1-a", "f1-micro", nil, nil)
 	assert.NoError(t, err)
 	f := false
 	testCases := []struct {
@@ -251,7 +283,7 @@ func TestGCEResourcesToInstanceType(t *testing.T) {
 }
 
 func TestAzureResourcesToInstanceType(t *testing.T) {
-	_ = Setup("azure", "East US", "", "Standard_B1s")
+	_ = Setup("azure", "East US", "", "Standard_B1s", nil, nil)
 	testCases := []struct {
 		Resources    api.ResourceSpec
 		instanceType string
@@ -279,3 +311,101 @@ func TestNoSetup(t *testing.T) {
 	_, _, err := ResourcesToInstanceType(&ps)
 	assert.NotNil(t, err)
 }
+
+func TestValidateResourceSpec(t *testing.T) {
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	rs := api.ResourceSpec{
+		CPU:    "1",
+		Memory: "1Gi",
+	}
+	assert.NoError(t, ValidateResourceSpec(rs))
+	rs.Memory = "100000Gi"
+	err := ValidateResourceSpec(rs)
+	assert.NotNil(t, err)
+	largest, found := selector.largestInstanceType()
+	assert.True(t, found)
+	assert.Contains(t, err.Error(), largest.InstanceType)
+}
+
+func TestInstanceTypePermitted(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		allowed      []string
+		blocked      []string
+		permitted    bool
+	}{
+		{"m5.large", nil, nil, true},
+		{"m5.large", nil, []string{"m5"}, false},
+		{"m5.large", nil, []string{"m5.large"}, false},
+		{"m5.xlarge", nil, []string{"m5.large"}, true},
+		{"m5.large", []string{"m5"}, nil, true},
+		{"c5.large", []string{"m5"}, nil, false},
+		// the block list wins over the allow list
+		{"m5.large", []string{"m5"}, []string{"m5.large"}, false},
+		// gce style family names
+		{"n1-standard-1", []string{"n1"}, nil, true},
+		{"e2-standard-2", []string{"n1"}, nil, false},
+	}
+	for i, tc := range tests {
+		assert.Equal(
+			t, tc.permitted,
+			InstanceTypePermitted(tc.instanceType, tc.allowed, tc.blocked),
+			"case %d", i)
+	}
+}
+
+func TestInstanceTypeLists(t *testing.T) {
+	// with c5 blocked the selector falls back to the next cheapest
+	// family satisfying a dedicated CPU request
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, []string{"c5"})
+	ps := api.PodSpec{}
+	ps.Resources.CPU = "1"
+	ps.Resources.Memory = "1Gi"
+	ps.Resources.DedicatedCPU = true
+	inst, _, err := ResourcesToInstanceType(&ps)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "c5.large", inst)
+	assert.False(t, IsPermittedInstance("c5.large"))
+	assert.True(t, IsPermittedInstance(inst))
+
+	// a GPU request that only blocked families can satisfy is
+	// rejected with an error naming the excluded type
+	_ = Setup("aws", "us-east-1", "", "t2.nano", []string{"t2", "m5"}, nil)
+	rs := api.ResourceSpec{GPU: "1"}
+	err = ValidateResourceSpec(rs)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "allow/block lists")
+	}
+	selector = nil
+}
+
+func TestFallbackInstanceTypes(t *testing.T) {
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, nil)
+	fallbacks := FallbackInstanceTypes("m5.large")
+	assert.NotEmpty(t, fallbacks)
+	assert.True(t, len(fallbacks) <= maxInstanceFallbacks)
+	origCPU, _ := InstanceCPUCount("m5.large")
+	lastPrice := float32(0.0)
+	for _, ft := range fallbacks {
+		assert.NotEqual(t, "m5.large", ft)
+		cpu, ok := InstanceCPUCount(ft)
+		assert.True(t, ok)
+		assert.True(t, cpu >= origCPU)
+		price, ok := InstancePrice(ft)
+		assert.True(t, ok)
+		// ranked cheapest first
+		assert.True(t, price >= lastPrice)
+		lastPrice = price
+	}
+
+	// blocked families never show up on the ladder
+	_ = Setup("aws", "us-east-1", "", "t2.nano", nil, []string{"m5a"})
+	for _, ft := range FallbackInstanceTypes("m5.large") {
+		assert.NotEqual(t, "m5a", instanceFamily(ft))
+	}
+
+	// unknown types have no fallbacks
+	assert.Empty(t, FallbackInstanceTypes("no-such-type"))
+	selector = nil
+	assert.Empty(t, FallbackInstanceTypes("m5.large"))
+}
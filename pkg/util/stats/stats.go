@@ -43,6 +43,44 @@ func (t *LoopTimer) Copy() *LoopTimer {
 	return lt
 }
 
+// PollStats aggregates the outcome of polls that fan out to many
+// remote nodes: how long the individual polls take and how many of
+// them failed or timed out.
+type PollStats struct {
+	LastPoll time.Duration
+	Average  time.Duration
+	Count    int64
+	Errors   int64
+	Timeouts int64
+	sync.Mutex
+}
+
+func (s *PollStats) Copy() *PollStats {
+	s.Lock()
+	defer s.Unlock()
+	return &PollStats{
+		LastPoll: s.LastPoll,
+		Average:  s.Average,
+		Count:    s.Count,
+		Errors:   s.Errors,
+		Timeouts: s.Timeouts,
+	}
+}
+
+func (s *PollStats) Record(d time.Duration, isError, isTimeout bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.LastPoll = d
+	s.Average = time.Duration((int64(s.Average)*s.Count + int64(d)) / (s.Count + 1))
+	s.Count += 1
+	if isError {
+		s.Errors += 1
+	}
+	if isTimeout {
+		s.Timeouts += 1
+	}
+}
+
 func (t *LoopTimer) StartLoop() {
 	t.Lock()
 	defer t.Unlock()
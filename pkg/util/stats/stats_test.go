@@ -0,0 +1,37 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollStatsRecord(t *testing.T) {
+	s := &PollStats{}
+	s.Record(2*time.Second, false, false)
+	s.Record(4*time.Second, true, false)
+	s.Record(6*time.Second, true, true)
+	c := s.Copy()
+	assert.Equal(t, 6*time.Second, c.LastPoll)
+	assert.Equal(t, 4*time.Second, c.Average)
+	assert.Equal(t, int64(3), c.Count)
+	assert.Equal(t, int64(2), c.Errors)
+	assert.Equal(t, int64(1), c.Timeouts)
+}
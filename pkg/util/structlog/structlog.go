@@ -0,0 +1,155 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package structlog provides a small structured logger for the
+// controllers.  By default messages are forwarded to klog in the
+// usual human-readable format with any attached fields appended as
+// key=value pairs.  When JSON output is enabled (see the logging
+// section of provider.yaml) each message is written as a single JSON
+// object carrying the level, timestamp, component and fields so logs
+// can be parsed by a log pipeline.
+package structlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elotl/kip/pkg/util/rand"
+	"k8s.io/klog"
+)
+
+var output = struct {
+	sync.Mutex
+	json bool
+	w    io.Writer
+}{
+	w: os.Stderr,
+}
+
+// SetJSONOutput switches all structlog loggers between the default
+// human-readable klog format and JSON lines.  It should be called
+// once at startup, before the controllers start logging.
+func SetJSONOutput(enabled bool) {
+	output.Lock()
+	defer output.Unlock()
+	output.json = enabled
+}
+
+// setWriter redirects JSON output, used by tests.
+func setWriter(w io.Writer) {
+	output.Lock()
+	defer output.Unlock()
+	output.w = w
+}
+
+// NewRequestID returns a short random identifier used to correlate
+// all log messages belonging to one operation.
+func NewRequestID() string {
+	return rand.String(8)
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Logger is an immutable set of fields (at minimum the component
+// name) that get attached to every message it logs.  With and
+// WithRequestID return copies, so a logger can be shared between
+// goroutines and specialized per operation.
+type Logger struct {
+	fields []field
+}
+
+// New creates a logger for a controller or other component; the
+// component name is attached to every message.
+func New(component string) *Logger {
+	return &Logger{fields: []field{{"component", component}}}
+}
+
+// With returns a copy of the logger with an extra field attached,
+// e.g. the pod or node an operation works on.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return &Logger{fields: append(fields, field{key, value})}
+}
+
+// WithRequestID returns a copy of the logger with a fresh request ID
+// attached so messages from a single operation can be correlated.
+func (l *Logger) WithRequestID() *Logger {
+	return l.With("requestID", NewRequestID())
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if !bool(klog.V(2)) {
+		return
+	}
+	l.emit("debug", klog.InfoDepth, format, args)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.emit("info", klog.InfoDepth, format, args)
+}
+
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.emit("warning", klog.WarningDepth, format, args)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.emit("error", klog.ErrorDepth, format, args)
+}
+
+func (l *Logger) emit(level string, klogFunc func(int, ...interface{}), format string, args []interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	output.Lock()
+	jsonOut := output.json
+	w := output.w
+	output.Unlock()
+	if !jsonOut {
+		klogFunc(2, msg+l.fieldString())
+		return
+	}
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": level,
+		"msg":   msg,
+	}
+	for _, f := range l.fields {
+		entry[f.key] = f.value
+	}
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		klog.ErrorDepth(2, msg+l.fieldString())
+		return
+	}
+	output.Lock()
+	defer output.Unlock()
+	_, _ = w.Write(append(buf, '\n'))
+}
+
+func (l *Logger) fieldString() string {
+	b := strings.Builder{}
+	for _, f := range l.fields {
+		fmt.Fprintf(&b, " %s=%v", f.key, f.value)
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package structlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureJSON(t *testing.T, logIt func()) map[string]interface{} {
+	buf := &bytes.Buffer{}
+	SetJSONOutput(true)
+	setWriter(buf)
+	defer func() {
+		SetJSONOutput(false)
+		setWriter(os.Stderr)
+	}()
+	logIt()
+	entry := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	assert.NoError(t, err)
+	return entry
+}
+
+func TestJSONEntryFields(t *testing.T) {
+	log := New("pod-controller").With("pod", "default_mypod").With("node", "abc123")
+	entry := captureJSON(t, func() {
+		log.Errorf("dispatch failed: %s", "boom")
+	})
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "dispatch failed: boom", entry["msg"])
+	assert.Equal(t, "pod-controller", entry["component"])
+	assert.Equal(t, "default_mypod", entry["pod"])
+	assert.Equal(t, "abc123", entry["node"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestWithRequestID(t *testing.T) {
+	log := New("pod-controller")
+	first := log.WithRequestID()
+	second := log.WithRequestID()
+	e1 := captureJSON(t, func() { first.Infof("starting") })
+	e2 := captureJSON(t, func() { second.Infof("starting") })
+	assert.NotEmpty(t, e1["requestID"])
+	assert.NotEmpty(t, e2["requestID"])
+	assert.NotEqual(t, e1["requestID"], e2["requestID"])
+	// The parent logger is unchanged.
+	parent := captureJSON(t, func() { log.Infof("no request") })
+	assert.NotContains(t, parent, "requestID")
+}
+
+func TestFieldString(t *testing.T) {
+	log := New("node-controller").With("node", "n1")
+	assert.Equal(t, " component=node-controller node=n1", log.fieldString())
+}
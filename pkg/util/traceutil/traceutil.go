@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package traceutil passes opencensus span contexts between the
+// asynchronous controllers so the stages of a pod's lifecycle
+// (CreatePod, scheduling, node dispensing, dispatch) join a single
+// trace.  Span contexts are serialized to strings so they can ride
+// along on pod annotations through the registry.  Exporters (e.g. the
+// ocagent collector) are configured with the --trace-exporter
+// command-line flags.
+package traceutil
+
+import (
+	"context"
+	"encoding/base64"
+
+	"go.opencensus.io/trace"
+	"go.opencensus.io/trace/propagation"
+)
+
+// SpanContextFromContext returns the span context of the span stored
+// in ctx, if there is one.
+func SpanContextFromContext(ctx context.Context) (trace.SpanContext, bool) {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return trace.SpanContext{}, false
+	}
+	return span.SpanContext(), true
+}
+
+// EncodeSpanContext serializes a span context to a string that can be
+// stored in a pod annotation.
+func EncodeSpanContext(sc trace.SpanContext) string {
+	return base64.StdEncoding.EncodeToString(propagation.Binary(sc))
+}
+
+// DecodeSpanContext deserializes a span context encoded by
+// EncodeSpanContext.
+func DecodeSpanContext(encoded string) (trace.SpanContext, bool) {
+	if encoded == "" {
+		return trace.SpanContext{}, false
+	}
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	return propagation.FromBinary(buf)
+}
+
+// StartSpanWithRemoteParent starts a span joined to the trace carried
+// in encoded.  When the encoding is empty or invalid a new root span
+// is started instead, so callers always get a usable span back.
+func StartSpanWithRemoteParent(ctx context.Context, name, encoded string) (context.Context, *trace.Span) {
+	if sc, ok := DecodeSpanContext(encoded); ok {
+		return trace.StartSpanWithRemoteParent(ctx, name, sc)
+	}
+	return trace.StartSpan(ctx, name)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 Elotl Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traceutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/trace"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	ctx, span := trace.StartSpan(context.Background(), "parent")
+	defer span.End()
+	sc, ok := SpanContextFromContext(ctx)
+	assert.True(t, ok)
+	decoded, ok := DecodeSpanContext(EncodeSpanContext(sc))
+	assert.True(t, ok)
+	assert.Equal(t, sc.TraceID, decoded.TraceID)
+	assert.Equal(t, sc.SpanID, decoded.SpanID)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, ok := DecodeSpanContext("")
+	assert.False(t, ok)
+	_, ok = DecodeSpanContext("not base64!!!")
+	assert.False(t, ok)
+	_, ok = DecodeSpanContext("aGVsbG8=")
+	assert.False(t, ok)
+}
+
+func TestStartSpanWithRemoteParent(t *testing.T) {
+	ctx, parent := trace.StartSpan(context.Background(), "parent")
+	defer parent.End()
+	sc, _ := SpanContextFromContext(ctx)
+
+	_, child := StartSpanWithRemoteParent(
+		context.Background(), "child", EncodeSpanContext(sc))
+	defer child.End()
+	assert.Equal(t, sc.TraceID, child.SpanContext().TraceID)
+
+	// An empty encoding falls back to a new root span.
+	_, root := StartSpanWithRemoteParent(context.Background(), "orphan", "")
+	defer root.End()
+	assert.NotEqual(t, sc.TraceID, root.SpanContext().TraceID)
+}
+
+func TestSpanContextFromContextEmpty(t *testing.T) {
+	_, ok := SpanContextFromContext(context.Background())
+	assert.False(t, ok)
+}